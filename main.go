@@ -1,29 +1,965 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	projectclient "github.com/openshift/client-go/project/clientset/versioned"
+	quotaclient "github.com/openshift/client-go/quota/clientset/versioned"
 	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/admin"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/awssecrets"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/bulk"
 	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/evidence"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/export"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/fakecluster"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/githubteam"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/observability"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/policytest"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/tracing"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/wake"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
 	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
+	ctrllog "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 func main() {
 	klog.InitFlags(nil)
+	ctrllog.SetLogger(klog.NewKlogr())
 
-	// Build the Kubernetes client configuration
-	var config *rest.Config
-	var err error
+	// Dispatch administrative commands, running the controller by default
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "bulk-onboard":
+			os.Exit(runBulkOnboard(os.Args[2:]))
+		case "bulk-offboard":
+			os.Exit(runBulkOffboard(os.Args[2:]))
+		case "uninstall-cleanup":
+			os.Exit(runUninstallCleanup(os.Args[2:]))
+		case "migrate-naming":
+			os.Exit(runMigrateNaming(os.Args[2:]))
+		case "approve":
+			os.Exit(runApprove(os.Args[2:]))
+		case "retry-provision":
+			os.Exit(runRetryProvision(os.Args[2:]))
+		case "read-only":
+			os.Exit(runReadOnly(os.Args[2:]))
+		case "export-terraform":
+			os.Exit(runExportTerraform(os.Args[2:]))
+		case "gen-observability":
+			os.Exit(runGenObservability(os.Args[2:]))
+		case "policy-test":
+			os.Exit(runPolicyTest(os.Args[2:]))
+		case "wake":
+			os.Exit(runWake(os.Args[2:]))
+		case "verify-report":
+			os.Exit(runVerifyReport(os.Args[2:]))
+		}
+	}
+
+	fake := flag.Bool("fake", false, "Run against in-memory fake clientsets instead of a cluster")
+	scenario := flag.String("scenario", "", "YAML file of group changes to play in fake mode")
+	configFile := flag.String("config", "", "YAML file of configuration variables applied on top of the environment and reloaded when it changes")
+	once := flag.Bool("once", false, "Reconcile every member once and exit, with a non-zero status if any user failed, e.g. from a CronJob")
+	dryRun := flag.Bool("dry-run", false, "Log, count and publish the changes the controller would make instead of making them, as DRY_RUN_ENABLED=true")
+	adoptExisting := flag.Bool("adopt-existing", false, "Update RoleBindings named like managed ones but binding other users or not created by the controller to the desired state, as ADOPT_EXISTING_ROLEBINDINGS=true")
+	pprof := flag.Bool("pprof", false, "Serve net/http/pprof on localhost:6060 unless PPROF_BIND_ADDRESS sets another loopback address")
+	flag.Parse()
+
+	if *dryRun {
+		controller.EnableDryRun()
+	}
+	if *adoptExisting {
+		controller.EnableAdoptExisting()
+	}
+	if *pprof {
+		controller.EnablePprof()
+	}
+
+	if *configFile != "" {
+		if err := controller.LoadConfigFile(*configFile); err != nil {
+			klog.Fatalf("Failed to load configuration file: %v", err)
+		}
+	}
+
+	if *fake {
+		runFake(*scenario)
+		return
+	}
+	if *once {
+		os.Exit(runOnce())
+	}
+	runController()
+}
+
+// Reconciles every member once without starting the informers, returning the process exit code
+func runOnce() int {
+	validateConfig()
+	config := buildConfig()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	tracker := newHealthTracker()
+	notifier := newNotifier(config, tracker)
+	ctrl := newController(config, integrationOptions(ctx, tracker, notifier)...)
+	validateSeededManifests(ctx, ctrl)
+
+	if err := ctrl.ReconcileOnce(ctx); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			klog.Errorf("Reconcile failed: %s", line)
+		}
+		return 1
+	}
+	klog.Info("Reconciled every member")
+	return 0
+}
+
+// Runs the controller until a shutdown signal is received
+func runController() {
+	validateConfig()
+	config := buildConfig()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	// Configure optional integrations
+	tracker := newHealthTracker()
+	notifier := newNotifier(config, tracker)
+	opts := integrationOptions(ctx, tracker, notifier)
+	if path := controller.GetGroupChangesRecordFile(); path != "" {
+		opts = append(opts, controller.WithGroupRecorder(fakecluster.NewRecorder(path, controller.GetTargetGroupNames()[0])))
+	}
+	if controller.GetIdleShutdownAfter() > 0 {
+		opts = append(opts, controller.WithIdleShutdown(idleShutdown(config, cancel)))
+	}
+	if dir := controller.GetDeprovisionReportsDir(); dir != "" {
+		key, err := controller.LoadDeprovisionReportKey()
+		if err != nil {
+			klog.Fatalf("Failed to load the deprovision report signing key: %v", err)
+		}
+		opts = append(opts, controller.WithDeprovisionReports(evidence.NewFileStore(dir), key))
+	}
+
+	var broadcaster *events.Broadcaster
+	addr := controller.GetAdminAPIAddress()
+	digest := notifier != nil && controller.GetNotificationMode() == controller.NotificationModeDigest
+	lifecycleURL := controller.GetLifecycleWebhookURL()
+	if addr != "" || digest || lifecycleURL != "" {
+		broadcaster = events.NewBroadcaster()
+		opts = append(opts, controller.WithEventBroadcaster(broadcaster))
+	}
+
+	// Batch provisioning results into periodic digests, sending the last one before exiting
+	digestDone := make(chan struct{})
+	if digest {
+		ch, unsubscribe := broadcaster.Subscribe()
+		defer unsubscribe()
+		go func() {
+			defer close(digestDone)
+			notify.NewDigest(notifier, controller.GetNotificationDigestInterval()).Run(ctx, ch)
+		}()
+	} else {
+		close(digestDone)
+	}
+
+	// Post signed lifecycle events to external systems tracking namespace ownership
+	if lifecycleURL != "" {
+		key, err := controller.LoadLifecycleWebhookKey()
+		if err != nil {
+			klog.Fatalf("Failed to load the lifecycle webhook signing key: %v", err)
+		}
+		ch, unsubscribe := broadcaster.Subscribe()
+		defer unsubscribe()
+		go notify.NewLifecycleWebhook(lifecycleURL, key, controller.GetLifecycleWebhookMaxAttempts()).Run(ctx, ch)
+	}
+
+	// Export spans of reconciles, sending the pending ones before exiting
+	tracingDone := make(chan struct{})
+	if endpoint := controller.GetTracingEndpoint(); endpoint != "" {
+		tracer := tracing.NewTracer(endpoint, controller.GetTracingServiceName())
+		opts = append(opts, controller.WithTracer(tracer))
+		go func() {
+			defer close(tracingDone)
+			tracer.Run(ctx)
+		}()
+	} else {
+		close(tracingDone)
+	}
+
+	// Create and start the controller
+	ctrl := newController(config, opts...)
+	validateSeededManifests(ctx, ctrl)
+
+	if addr != "" {
+		var approver admin.Approver
+		if controller.GetApprovalRequired() {
+			approver = ctrl
+		}
+		adminServer := admin.NewServer(addr, broadcaster, ctrl, approver, ctrl, ctrl, tracker).
+			WithProvisionRetrier(ctrl).
+			WithAuthorizer(newAdminAuthorizer(config))
+		go func() {
+			if err := adminServer.Run(ctx); err != nil {
+				klog.Fatalf("Admin API failed: %v", err)
+			}
+		}()
+	}
+
+	runManager(ctx, config, controller.ManagerOptions(), ctrl)
+	<-digestDone
+	<-tracingDone
+
+	klog.Info("Controller shut down gracefully")
+}
+
+// Runs the controller against in-memory fake clientsets until a shutdown signal is received, playing
+// the group changes of the scenario file if one is given. External integrations are disabled and the
+// admin API always serves the fake state.
+func runFake(scenarioPath string) {
+	validateConfig()
+
+	scenario := &fakecluster.Scenario{}
+	if scenarioPath != "" {
+		var err error
+		scenario, err = fakecluster.LoadScenario(scenarioPath)
+		if err != nil {
+			klog.Fatalf("Failed to load scenario: %v", err)
+		}
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	groups := controller.GetTargetGroupNames()
+	clients := fakecluster.NewClients(groups, scenario.Members)
+	tracker := newHealthTracker()
+	broadcaster := events.NewBroadcaster()
+	opts := []controller.Option{
+		controller.WithHealthTracker(tracker),
+		controller.WithEventBroadcaster(broadcaster),
+		controller.WithNetworkingClient(clients.Kube.NetworkingV1()),
+	}
+	if path := controller.GetGroupChangesRecordFile(); path != "" {
+		opts = append(opts, controller.WithGroupRecorder(fakecluster.NewRecorder(path, controller.GetTargetGroupNames()[0])))
+	}
+	ctrl := controller.NewController(clients.User, clients.Project, clients.Kube.RbacV1(), clients.Quota, clients.Kube.CoreV1(), clients.Dynamic, opts...)
+
+	// there is no API server to authorize requests, so only serve them locally
+	addr := controller.GetAdminAPIAddress()
+	if addr == "" {
+		addr = "127.0.0.1:8081"
+	}
+	if err := controller.ValidateLoopbackAddress(addr); err != nil {
+		klog.Fatalf("Invalid ADMIN_API_ADDRESS for the fake cluster, which serves the admin API without authorization: %v", err)
+	}
+	var approver admin.Approver
+	if controller.GetApprovalRequired() {
+		approver = ctrl
+	}
+	adminServer := admin.NewServer(addr, broadcaster, ctrl, approver, ctrl, ctrl, tracker).WithProvisionRetrier(ctrl)
+	go func() {
+		if err := adminServer.Run(ctx); err != nil {
+			klog.Fatalf("Admin API failed: %v", err)
+		}
+	}()
+
+	go func() {
+		if err := scenario.Play(ctx, clients.User, groups[0]); err != nil {
+			klog.Errorf("Error playing scenario: %v", err)
+			return
+		}
+		klog.Infof("Played %d steps of scenario", len(scenario.Steps))
+	}()
+
+	// There is a single fake controller, and no API server to hold a Lease
+	options := controller.ManagerOptions()
+	options.LeaderElection = false
+
+	klog.Infof("Running against fake clientsets with group %s holding %v", groups[0], scenario.Members)
+	runManager(ctx, fakecluster.Config(), options, ctrl)
+	klog.Info("Controller shut down gracefully")
+}
+
+// Runs the controller with a controller-runtime manager until the context is cancelled
+func runManager(ctx context.Context, config *rest.Config, options manager.Options, ctrl *controller.Controller) {
+	mgr, err := manager.New(config, options)
+	if err != nil {
+		klog.Fatalf("Failed to create manager: %v", err)
+	}
+	if err := ctrl.SetupWithManager(mgr); err != nil {
+		klog.Fatalf("Failed to set up controller: %v", err)
+	}
+	if err := mgr.Start(ctx); err != nil {
+		klog.Fatalf("Controller failed: %v", err)
+	}
+}
+
+// Runs the bulk-onboard command, returning the process exit code
+func runBulkOnboard(args []string) int {
+	fs := flag.NewFlagSet("bulk-onboard", flag.ExitOnError)
+	file := fs.String("file", "", "File of usernames to onboard, one per line")
+	direct := fs.Bool("direct", false, "Provision users directly instead of adding them to the target group")
+	group := fs.String("group", controller.GetTargetGroupNames()[0], "Target group users are added to")
+	timeout := fs.Duration("timeout", 15*time.Minute, "Maximum time to wait for all namespaces to be ready")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "bulk-onboard: --file is required")
+		fs.Usage()
+		return 2
+	}
+
+	users, err := bulk.ReadUsersFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bulk-onboard: %v\n", err)
+		return 1
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	userClient, err := userclient.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create OpenShift user client: %v", err)
+	}
+
+	results := bulk.Onboard(ctx, userClient, newController(config, integrationOptions(ctx, nil, newNotifier(config, nil))...), bulk.OnboardOptions{
+		Users:     users,
+		GroupName: *group,
+		Direct:    *direct,
+		Timeout:   *timeout,
+	})
+	bulk.PrintResults(os.Stdout, results)
+
+	if bulk.Failed(results) {
+		return 1
+	}
+	return 0
+}
+
+// Runs the bulk-offboard command, returning the process exit code
+func runBulkOffboard(args []string) int {
+	fs := flag.NewFlagSet("bulk-offboard", flag.ExitOnError)
+	file := fs.String("file", "", "File of usernames to offboard, one per line")
+	direct := fs.Bool("direct", false, "Deprovision users directly instead of removing them from the target groups")
+	confirm := fs.Bool("confirm", false, "Delete the reported namespaces; without it only a dry-run report is printed")
+	timeout := fs.Duration("timeout", 15*time.Minute, "Maximum time to wait for all namespaces to be deleted")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		fmt.Fprintln(os.Stderr, "bulk-offboard: --file is required")
+		fs.Usage()
+		return 2
+	}
+
+	users, err := bulk.ReadUsersFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bulk-offboard: %v\n", err)
+		return 1
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	userClient, err := userclient.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create OpenShift user client: %v", err)
+	}
+	kubeClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create Kubernetes client: %v", err)
+	}
+	ctrl := newController(config, integrationOptions(ctx, nil, newNotifier(config, nil))...)
+
+	bulk.PrintPlan(os.Stdout, bulk.PlanOffboard(ctx, kubeClient, ctrl, users))
+	if !*confirm {
+		fmt.Println("\nDry run only, re-run with --confirm to delete")
+		return 0
+	}
+
+	fmt.Println()
+	results := bulk.Offboard(ctx, userClient, kubeClient, ctrl, bulk.OffboardOptions{
+		Users:      users,
+		GroupNames: controller.GetTargetGroupNames(),
+		Direct:     *direct,
+		Timeout:    *timeout,
+	}, os.Stdout)
+	fmt.Println()
+	bulk.PrintOffboardResults(os.Stdout, results)
+
+	if bulk.Failed(results) {
+		return 1
+	}
+	return 0
+}
+
+// Runs the approve command approving the given users waiting for approval, or listing them,
+// returning the process exit code
+func runApprove(args []string) int {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	list := fs.Bool("list", false, "List the users waiting for approval as JSON instead of approving")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	if !*list && fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "approve: at least one user or --list is required")
+		fs.Usage()
+		return 2
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	ctrl := newController(config)
+
+	if *list {
+		pending, err := ctrl.PendingApprovals(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "approve: %v\n", err)
+			return 1
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(pending); err != nil {
+			fmt.Fprintf(os.Stderr, "approve: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	code := 0
+	for _, user := range fs.Args() {
+		if err := ctrl.Approve(ctx, user); err != nil {
+			fmt.Fprintf(os.Stderr, "approve: %v\n", err)
+			code = 1
+			continue
+		}
+		fmt.Printf("Approved %s\n", user)
+	}
+	return code
+}
+
+// Runs the retry-provision command retrying the dead-lettered provisioning of the given users, or
+// listing them, returning the process exit code
+func runRetryProvision(args []string) int {
+	fs := flag.NewFlagSet("retry-provision", flag.ExitOnError)
+	list := fs.Bool("list", false, "List the dead-lettered provisions as JSON instead of retrying")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	if !*list && fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "retry-provision: at least one user or --list is required")
+		fs.Usage()
+		return 2
+	}
+	// the command doesn't provision, it requests the retry from the leader through the ConfigMap
+	if controller.GetPodNamespace() == "" {
+		fmt.Fprintln(os.Stderr, "retry-provision: POD_NAMESPACE is required to find the failed provisions")
+		return 2
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	ctrl := newController(config)
+
+	if *list {
+		failed, err := ctrl.FailedProvisions(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "retry-provision: %v\n", err)
+			return 1
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(failed); err != nil {
+			fmt.Fprintf(os.Stderr, "retry-provision: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	code := 0
+	for _, user := range fs.Args() {
+		if err := ctrl.RetryProvision(ctx, user); err != nil {
+			fmt.Fprintf(os.Stderr, "retry-provision: %v\n", err)
+			code = 1
+			continue
+		}
+		fmt.Printf("Requested retry of %s\n", user)
+	}
+	return code
+}
+
+// Runs the uninstall-cleanup command removing everything the provisioner created, returning the
+// process exit code
+func runUninstallCleanup(args []string) int {
+	fs := flag.NewFlagSet("uninstall-cleanup", flag.ExitOnError)
+	namespaces := fs.String("namespaces", controller.UninstallKeepNamespaces, "What happens to managed namespaces, keep to only remove what the provisioner added to them or delete to delete them with their contents")
+	confirm := fs.Bool("confirm", false, "Apply the reported changes; without it only a dry-run report is printed")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	ctrl := newController(config)
+	actions, err := ctrl.PlanUninstall(ctx, *namespaces)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "uninstall-cleanup: %v\n", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tCHANGE")
+	for _, action := range actions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", action.Kind, action.Namespace, action.Name, action.Change)
+	}
+	_ = tw.Flush()
+	fmt.Printf("\n%d changes\n", len(actions))
+	if !*confirm {
+		fmt.Println("\nDry run only, stop the controller and re-run with --confirm to apply")
+		return 0
+	}
+
+	fmt.Println()
+	failed := 0
+	for i, action := range actions {
+		object := action.Kind + " " + action.Name
+		if action.Namespace != "" {
+			object = fmt.Sprintf("%s %s/%s", action.Kind, action.Namespace, action.Name)
+		}
+		if err := action.Apply(ctx); err != nil {
+			failed++
+			fmt.Printf("[%d/%d] %s: failed: %v\n", i+1, len(actions), object, err)
+			continue
+		}
+		fmt.Printf("[%d/%d] %s: %s\n", i+1, len(actions), object, action.Change)
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d/%d changes failed, re-run to retry\n", failed, len(actions))
+		return 1
+	}
+	return 0
+}
+
+// Runs the migrate-naming command moving managed namespaces to the names of the current naming
+// scheme, returning the process exit code
+func runMigrateNaming(args []string) int {
+	fs := flag.NewFlagSet("migrate-naming", flag.ExitOnError)
+	copyKinds := fs.String("copy", "", "Comma separated kinds of resources to copy from the old namespaces, secrets and configmaps")
+	oldNamespaces := fs.String("old-namespaces", controller.MigrationDeleteOld, "What happens to old namespaces, delete to delete them per the deletion policy or keep to keep them unmanaged")
+	confirm := fs.Bool("confirm", false, "Migrate the reported namespaces; without it only a dry-run report is printed")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	opts := controller.MigrationOptions{OldNamespaces: *oldNamespaces}
+	for _, kind := range strings.Split(*copyKinds, ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			opts.Copy = append(opts.Copy, kind)
+		}
+	}
+	if err := opts.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-naming: %v\n", err)
+		fs.Usage()
+		return 2
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	ctrl := newController(config, integrationOptions(ctx, nil, nil)...)
+	migrations, err := ctrl.PlanNamingMigration(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "migrate-naming: %v\n", err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tFROM\tTO")
+	for _, migration := range migrations {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", migration.User, migration.From, migration.To)
+	}
+	_ = tw.Flush()
+	fmt.Printf("\n%d namespaces will be migrated, old namespaces: %s\n", len(migrations), opts.OldNamespaces)
+	if !*confirm {
+		fmt.Println("\nDry run only, re-run with --confirm to migrate")
+		return 0
+	}
+
+	fmt.Println()
+	failed := 0
+	for i, migration := range migrations {
+		if err := ctrl.MigrateNamespace(ctx, migration, opts); err != nil {
+			failed++
+			fmt.Printf("[%d/%d] %s: migration failed: %v\n", i+1, len(migrations), migration.User, err)
+			continue
+		}
+		fmt.Printf("[%d/%d] %s: migrated %s to %s\n", i+1, len(migrations), migration.User, migration.From, migration.To)
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d/%d migrations failed, re-run to retry\n", failed, len(migrations))
+		return 1
+	}
+	return 0
+}
+
+// Runs the read-only command serving the inventory and drift report without reconciling,
+// returning the process exit code
+func runReadOnly(args []string) int {
+	fs := flag.NewFlagSet("read-only", flag.ExitOnError)
+	address := fs.String("address", ":8081", "Listen address of the read-only admin API")
+	once := fs.Bool("once", false, "Print the inventory and drift report as JSON and exit instead of serving it")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	ctrl := newController(config)
+
+	if *once {
+		reports, err := ctrl.Report(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "read-only: %v\n", err)
+			return 1
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			fmt.Fprintf(os.Stderr, "read-only: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if err := admin.NewServer(*address, nil, ctrl, nil, nil, nil, nil).WithAuthorizer(newAdminAuthorizer(config)).Run(ctx); err != nil {
+		klog.Errorf("Read-only admin API failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// Runs the wake command serving the webhook that wakes up a controller shut down idle, returning the
+// process exit code
+func runWake(args []string) int {
+	fs := flag.NewFlagSet("wake", flag.ExitOnError)
+	address := fs.String("address", ":8082", "Listen address of the wake webhook")
+	deployment := fs.String("deployment", "", "Deployment of the controller to scale up on a wake-up, as <namespace>/<name>, or empty to leave scaling to KEDA")
+	window := fs.Duration("window", 5*time.Minute, "How long a wake-up is reported as pending to KEDA")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	var appsClient appsv1client.DeploymentsGetter
+	var namespace, name string
+	if *deployment != "" {
+		var found bool
+		namespace, name, found = strings.Cut(*deployment, "/")
+		if !found || namespace == "" || name == "" {
+			fmt.Fprintf(os.Stderr, "wake: invalid deployment %q, expected <namespace>/<name>\n", *deployment)
+			return 2
+		}
+		client, err := appsv1client.NewForConfig(buildConfig())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "wake: %v\n", err)
+			return 1
+		}
+		appsClient = client
+	}
+
+	if err := wake.NewServer(*address, appsClient, namespace, name, *window).Run(ctx); err != nil {
+		klog.Errorf("Wake webhook failed: %v", err)
+		return 1
+	}
+	return 0
+}
+
+// Runs the verify-report command checking the signatures of deprovision reports, returning the
+// process exit code
+func runVerifyReport(args []string) int {
+	fs := flag.NewFlagSet("verify-report", flag.ExitOnError)
+	keyFile := fs.String("key-file", "", "File holding the key the reports were signed with")
+	_ = fs.Parse(args)
+	if *keyFile == "" || fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "verify-report: --key-file and at least one report file are required")
+		fs.Usage()
+		return 2
+	}
+	key, err := os.ReadFile(*keyFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "verify-report: %v\n", err)
+		return 2
+	}
+	key = bytes.TrimSpace(key)
+
+	invalid := 0
+	for _, path := range fs.Args() {
+		signed, err := evidence.ReadFile(path)
+		if err == nil {
+			err = evidence.Verify(signed, key)
+		}
+		if err != nil {
+			fmt.Printf("INVALID %s: %v\n", path, err)
+			invalid++
+			continue
+		}
+		fmt.Printf("OK %s (user %s, namespace %s, completed %s)\n", path, signed.Report.User, signed.Report.Namespace, signed.Report.CompletedAt.Format(time.RFC3339))
+	}
+	if invalid > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Runs the export-terraform command rendering the desired per-user resources, returning the
+// process exit code
+func runExportTerraform(args []string) int {
+	fs := flag.NewFlagSet("export-terraform", flag.ExitOnError)
+	format := fs.String("format", "hcl", "Output format, hcl for Terraform/OpenTofu configuration or json for a plan")
+	output := fs.String("output", "", "File to write to instead of stdout")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	var write func(io.Writer, *export.Plan) error
+	switch *format {
+	case "hcl":
+		write = export.WriteHCL
+	case "json":
+		write = export.WriteJSON
+	default:
+		fmt.Fprintf(os.Stderr, "export-terraform: invalid format %q, expected hcl or json\n", *format)
+		fs.Usage()
+		return 2
+	}
+
+	config := buildConfig()
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	plan, err := export.BuildPlan(ctx, newController(config))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export-terraform: %v\n", err)
+		return 1
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export-terraform: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := write(out, plan); err != nil {
+		fmt.Fprintf(os.Stderr, "export-terraform: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// Creates the authorizer of admin API requests, reviewing their tokens and access with the API server
+func newAdminAuthorizer(config *rest.Config) admin.Authorizer {
+	authenticationClient, err := authenticationv1client.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create authentication client: %v", err)
+	}
+	authorizationClient, err := authorizationv1client.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create authorization client: %v", err)
+	}
+	return admin.NewKubeAuthorizer(authenticationClient, authorizationClient)
+}
+
+// Validates the configuration, exiting on invalid values unless configured to start degraded
+func validateConfig() {
+	err := controller.ValidateConfig()
+	if err == nil {
+		return
+	}
+	for _, line := range strings.Split(err.Error(), "\n") {
+		klog.Errorf("Invalid configuration: %s", line)
+	}
+	if controller.GetConfigValidationMode() == controller.ConfigValidationStrict {
+		klog.Fatalf("Refusing to start with an invalid configuration, set CONFIG_VALIDATION_MODE=%s to start degraded", controller.ConfigValidationWarn)
+	}
+	klog.Warning("Starting degraded with an invalid configuration, affected features fail when used")
+}
+
+// Checks the seeded manifests against the cluster, exiting on invalid manifests unless configured to
+// start degraded
+func validateSeededManifests(ctx context.Context, ctrl *controller.Controller) {
+	namespace := controller.GetPodNamespace()
+	if namespace == "" {
+		klog.Warning("POD_NAMESPACE is not set, skipping the schema check of the seeded manifests")
+		return
+	}
+	err := ctrl.ValidateSeededManifests(ctx, namespace)
+	if err == nil {
+		return
+	}
+	for _, line := range strings.Split(err.Error(), "\n") {
+		klog.Errorf("Invalid seeded manifest: %s", line)
+	}
+	if controller.GetConfigValidationMode() == controller.ConfigValidationStrict {
+		klog.Fatalf("Refusing to start with invalid seeded manifests, set CONFIG_VALIDATION_MODE=%s to start degraded", controller.ConfigValidationWarn)
+	}
+	klog.Warning("Starting degraded with invalid seeded manifests, affected features fail when used")
+}
+
+// Runs the gen-observability command writing the Grafana dashboard and PrometheusRule matching the
+// exported metrics, returning the process exit code
+func runGenObservability(args []string) int {
+	fs := flag.NewFlagSet("gen-observability", flag.ExitOnError)
+	outputDir := fs.String("output-dir", ".", "Directory to write grafana-dashboard.json and prometheusrule.yaml to")
+	namespace := fs.String("namespace", "rosa-namespace-provisioner", "Namespace of the generated PrometheusRule")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+
+	opts := observability.Options{
+		SLOTarget: controller.GetProvisioningSLOTarget(),
+		Namespace: *namespace,
+	}
+	for _, output := range []struct {
+		file  string
+		write func(io.Writer, observability.Options) error
+	}{
+		{"grafana-dashboard.json", observability.WriteDashboard},
+		{"prometheusrule.yaml", observability.WritePrometheusRule},
+	} {
+		path := filepath.Join(*outputDir, output.file)
+		out, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gen-observability: %v\n", err)
+			return 1
+		}
+		err = output.write(out, opts)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "gen-observability: failed to write %s: %v\n", path, err)
+			return 1
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	return 0
+}
+
+// Runs the policy-test command checking the configured provisioning policies against the fixtures
+// in the given files or directories, returning the process exit code
+func runPolicyTest(args []string) int {
+	fs := flag.NewFlagSet("policy-test", flag.ExitOnError)
+	verbose := fs.Bool("verbose", false, "Print the actions taken for passing fixtures too")
+	klog.InitFlags(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "policy-test: at least one fixture file or directory is required")
+		fs.Usage()
+		return 2
+	}
+
+	var fixtures []*policytest.Fixture
+	for _, path := range fs.Args() {
+		loaded, err := policytest.LoadFixtures(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "policy-test: %v\n", err)
+			return 2
+		}
+		fixtures = append(fixtures, loaded...)
+	}
+
+	failed := 0
+	for _, fixture := range fixtures {
+		result, err := policytest.Run(fixture)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "policy-test: %v\n", err)
+			return 2
+		}
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+			failed++
+		}
+		fmt.Printf("%s %s\n", status, result.Name)
+		for _, configErr := range result.ConfigErrors {
+			fmt.Printf("    invalid configuration: %s\n", configErr)
+		}
+		for _, action := range result.Missing {
+			fmt.Printf("    missing: %s\n", action)
+		}
+		for _, action := range result.Rejected {
+			fmt.Printf("    rejected: %s\n", action)
+		}
+		if *verbose || !result.Passed() {
+			for _, action := range result.Actions {
+				fmt.Printf("    took: %s\n", action)
+			}
+		}
+	}
+
+	fmt.Printf("%d of %d fixtures passed\n", len(fixtures)-failed, len(fixtures))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Builds the Kubernetes client configuration
+func buildConfig() *rest.Config {
+	// Connect to an explicitly configured API server, e.g. from a management cluster
+	if host := controller.GetAPIHost(); host != "" {
+		config, err := remoteConfig(host)
+		if err != nil {
+			klog.Fatalf("Failed to build config for API server %s: %v", host, err)
+		}
+		return config
+	}
 
 	// Try to get in-cluster config first
-	config, err = rest.InClusterConfig()
+	config, err := rest.InClusterConfig()
 	if err != nil {
 		// If not in cluster, try to get kubeconfig
 		kubeconfig := os.Getenv("KUBECONFIG")
@@ -36,7 +972,35 @@ func main() {
 			klog.Fatalf("Failed to build config: %v", err)
 		}
 	}
+	return config
+}
+
+// Builds the configuration of a remote API server authenticated with a bound service account
+// token file, which the client re-reads so rotated tokens are picked up without a restart
+func remoteConfig(host string) (*rest.Config, error) {
+	tokenFile := controller.GetAPITokenFile()
+	if tokenFile == "" {
+		return nil, fmt.Errorf("KUBE_API_TOKEN_FILE is required when KUBE_API_HOST is set")
+	}
+	if _, err := os.Stat(tokenFile); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %w", err)
+	}
+
+	config := &rest.Config{
+		Host:            host,
+		BearerTokenFile: tokenFile,
+	}
+	if caFile := controller.GetAPICAFile(); caFile != "" {
+		if _, err := os.Stat(caFile); err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		config.TLSClientConfig.CAFile = caFile
+	}
+	return config, nil
+}
 
+// Creates the clients used by the controller and the controller itself
+func newController(config *rest.Config, opts ...controller.Option) *controller.Controller {
 	// Create the OpenShift user client
 	userClient, err := userclient.NewForConfig(config)
 	if err != nil {
@@ -49,19 +1013,160 @@ func main() {
 		klog.Fatalf("Failed to create OpenShift project client: %v", err)
 	}
 
+	// Create the OpenShift quota client
+	quotaClient, err := quotaclient.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create OpenShift quota client: %v", err)
+	}
+
 	// Create the RBAC client
 	rbacClient, err := rbacv1client.NewForConfig(config)
 	if err != nil {
 		klog.Fatalf("Failed to create RBAC client: %v", err)
 	}
 
-	// Create and start the controller
-	ctrl := controller.NewController(userClient, projectClient, rbacClient)
+	// Create the core client
+	coreClient, err := corev1client.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create core client: %v", err)
+	}
 
+	// Create the networking client
+	networkingClient, err := networkingv1client.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create networking client: %v", err)
+	}
+
+	// Create the dynamic client
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create dynamic client: %v", err)
+	}
+
+	// Resolve the kinds of the template bundle, discovering the resources of the cluster on first use
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		klog.Fatalf("Failed to create discovery client: %v", err)
+	}
+	restMapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+
+	opts = append([]controller.Option{controller.WithNetworkingClient(networkingClient), controller.WithRESTMapper(restMapper)}, opts...)
+	opts = append(opts, membershipOptions()...)
+	return controller.NewController(userClient, projectClient, rbacClient, quotaClient, coreClient, dynamicClient, opts...)
+}
+
+// Returns the controller options of the external membership sources listed in MEMBERSHIP_SOURCES
+func membershipOptions() []controller.Option {
+	var opts []controller.Option
+	if slices.Contains(controller.GetMembershipSources(), controller.MembershipSourceGitHub) {
+		org, team, err := controller.GetGitHubTeam()
+		if err != nil {
+			klog.Fatalf("Failed to configure the GitHub membership source: %v", err)
+		}
+		opts = append(opts, controller.WithMembershipSources(
+			githubteam.NewSource(controller.GetGitHubAPIURL(), org, team, controller.GetGitHubToken()),
+		))
+	}
+	return opts
+}
+
+// Returns the idle shutdown of the controller, scaling its Deployment to zero when
+// IDLE_SHUTDOWN_DEPLOYMENT is set so it isn't restarted, then shutting it down gracefully
+func idleShutdown(config *rest.Config, cancel context.CancelFunc) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		namespace, name, _ := controller.GetIdleShutdownDeployment()
+		if name != "" {
+			appsClient, err := appsv1client.NewForConfig(config)
+			if err != nil {
+				klog.Errorf("Failed to create apps client, staying up: %v", err)
+				return
+			}
+			if err := wake.ScaleDown(ctx, appsClient, namespace, name); err != nil {
+				klog.Errorf("Error scaling Deployment %s/%s down, staying up: %v", namespace, name, err)
+				return
+			}
+		}
+		cancel()
+	}
+}
+
+// Creates the tracker disabling persistently failing integrations
+func newHealthTracker() *health.Tracker {
+	return health.NewTracker(int(controller.GetIntegrationFailureThreshold()), controller.GetIntegrationDisableDuration())
+}
+
+// Returns the controller options of the enabled external integrations, tracking their health
+// when a tracker is given
+func integrationOptions(ctx context.Context, tracker *health.Tracker, notifier notify.Notifier) []controller.Option {
+	opts := []controller.Option{controller.WithHealthTracker(tracker)}
+
+	if controller.GetAWSSecretsEnabled() {
+		secretsClient, err := awssecrets.NewClient(ctx)
+		if err != nil {
+			klog.Fatalf("Failed to create AWS Secrets Manager client: %v", err)
+		}
+		tracker.Register(controller.IntegrationAWSSecrets)
+		opts = append(opts, controller.WithSecretSource(secretsClient))
+	}
+
+	if notifier != nil {
+		opts = append(opts, controller.WithNotifier(notifier))
+	}
+
+	return opts
+}
+
+// Creates the notifier fanning out to the configured notification providers, tracking the health of
+// each provider when a tracker is given, or returns nil when notifications are disabled
+func newNotifier(config *rest.Config, tracker *health.Tracker) notify.Notifier {
+	configured, err := controller.GetNotificationProviders()
+	if err != nil {
+		klog.Fatalf("Failed to configure notifications: %v", err)
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	var providers []notify.Provider
+	for _, provider := range configured {
+		var notifier notify.Notifier
+		switch provider.Name {
+		case controller.NotificationProviderWebhook:
+			notifier = notify.NewWebhookNotifier(controller.GetNotificationWebhookURL())
+		case controller.NotificationProviderSlack:
+			notifier = notify.NewSlackNotifier(controller.GetNotificationSlackWebhookURL())
+		case controller.NotificationProviderEmail:
+			notifier = notify.NewEmailNotifier(
+				controller.GetNotificationSMTPAddress(),
+				controller.GetNotificationSMTPUsername(),
+				controller.GetNotificationSMTPPassword(),
+				controller.GetNotificationEmailFrom(),
+				controller.GetNotificationEmailDomain(),
+				controller.GetNotificationEmailTo(),
+			)
+		case controller.NotificationProviderEvents:
+			coreClient, err := corev1client.NewForConfig(config)
+			if err != nil {
+				klog.Fatalf("Failed to create core client: %v", err)
+			}
+			notifier = notify.NewEventNotifier(coreClient, "rosa-namespace-provisioner")
+		}
+		if tracker != nil {
+			notifier = notify.WithHealth(notifier, tracker, controller.IntegrationNotifications+"-"+provider.Name)
+		}
+		providers = append(providers, notify.Provider{
+			Name:        provider.Name,
+			Notifier:    notifier,
+			MinSeverity: provider.MinSeverity,
+		})
+	}
+	return notify.NewFanOut(providers...)
+}
+
+// Returns a context cancelled on SIGINT or SIGTERM for graceful shutdown
+func signalContext() (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
-	// Handle graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -71,9 +1176,5 @@ func main() {
 		cancel()
 	}()
 
-	if err := ctrl.Run(ctx); err != nil {
-		klog.Fatalf("Controller failed: %v", err)
-	}
-
-	klog.Info("Controller shut down gracefully")
+	return ctx, cancel
 }
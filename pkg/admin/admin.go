@@ -0,0 +1,375 @@
+// Package admin serves the controller's administrative HTTP API
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"k8s.io/klog/v2"
+)
+
+// Reporter reports the inventory of managed namespaces
+type Reporter interface {
+	Report(ctx context.Context) ([]controller.NamespaceReport, error)
+}
+
+// Approver lists and approves users waiting for approval
+type Approver interface {
+	PendingApprovals(ctx context.Context) ([]controller.PendingApproval, error)
+	Approve(ctx context.Context, user string) error
+}
+
+// CleanupQueue lists and retries external cleanups which kept failing
+type CleanupQueue interface {
+	DeadLetters(ctx context.Context) ([]controller.DeadLetter, error)
+	RetryDeadLetters(ctx context.Context, user string) error
+}
+
+// ProvisionRetrier lists and retries the provisioning of users which kept failing
+type ProvisionRetrier interface {
+	FailedProvisions(ctx context.Context) ([]controller.FailedProvision, error)
+	RetryProvision(ctx context.Context, user string) error
+}
+
+// AnomalyAcknowledger lists and acknowledges anomalous drops in the membership of target groups
+type AnomalyAcknowledger interface {
+	GroupAnomalies(ctx context.Context) ([]controller.GroupAnomaly, error)
+	AcknowledgeGroupAnomaly(ctx context.Context, group string, token string) error
+}
+
+// Server serves the admin API
+type Server struct {
+	server      *http.Server
+	mux         *http.ServeMux
+	broadcaster *events.Broadcaster
+	reporter    Reporter
+	approver    Approver
+	cleanups    CleanupQueue
+	anomalies   AnomalyAcknowledger
+	provisions  ProvisionRetrier
+	health      *health.Tracker
+	authorizer  Authorizer
+}
+
+// NewServer creates a new admin API Server listening on the given address. The event stream is
+// only served with a broadcaster, the inventory only with a reporter, approvals only with an
+// approver, dead-lettered cleanups only with a cleanup queue and group anomalies only with an
+// anomaly acknowledger.
+func NewServer(addr string, broadcaster *events.Broadcaster, reporter Reporter, approver Approver, cleanups CleanupQueue, anomalies AnomalyAcknowledger, tracker *health.Tracker) *Server {
+	s := &Server{
+		broadcaster: broadcaster,
+		reporter:    reporter,
+		approver:    approver,
+		cleanups:    cleanups,
+		anomalies:   anomalies,
+		health:      tracker,
+	}
+
+	mux := http.NewServeMux()
+	if broadcaster != nil {
+		mux.HandleFunc("GET /events", s.authorized(s.handleEvents))
+	}
+	if reporter != nil {
+		mux.HandleFunc("GET /namespaces", s.authorized(s.handleNamespaces))
+	}
+	if approver != nil {
+		mux.HandleFunc("GET /approvals", s.authorized(s.handleApprovals))
+		mux.HandleFunc("POST /approvals/{user}", s.authorized(s.handleApprove))
+	}
+	if cleanups != nil {
+		mux.HandleFunc("GET /cleanups/dead-letters", s.authorized(s.handleDeadLetters))
+		mux.HandleFunc("POST /cleanups/dead-letters/{user}", s.authorized(s.handleRetryDeadLetters))
+	}
+	if anomalies != nil {
+		mux.HandleFunc("GET /anomalies", s.authorized(s.handleAnomalies))
+		mux.HandleFunc("POST /anomalies/{group}", s.authorized(s.handleAcknowledgeAnomaly))
+	}
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.Handle("GET /metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	s.mux = mux
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// WithProvisionRetrier serves the dead-lettered provisions of users and retries them
+func (s *Server) WithProvisionRetrier(provisions ProvisionRetrier) *Server {
+	s.provisions = provisions
+	s.mux.HandleFunc("GET /provisions/dead-letters", s.authorized(s.handleFailedProvisions))
+	s.mux.HandleFunc("POST /provisions/dead-letters/{user}", s.authorized(s.handleRetryProvision))
+	return s
+}
+
+// WithAuthorizer requires the requests to every endpoint but the health and metrics endpoints to be
+// authorized by the authorizer. Without an authorizer these endpoints are served to anyone able to
+// connect, so the server should only listen on localhost.
+func (s *Server) WithAuthorizer(authorizer Authorizer) *Server {
+	s.authorizer = authorizer
+	return s
+}
+
+// Run serves the admin API until the context is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Error shutting down admin API: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving admin API on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Reports the admin API as live
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+// Returns the health of every integration as JSON. Failing integrations are disabled rather than
+// holding back provisioning, so the provisioner stays ready unless the integration query parameter
+// names an integration that is unhealthy or disabled.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	statuses := s.health.Statuses()
+	if statuses == nil {
+		statuses = []health.Status{}
+	}
+
+	code := http.StatusOK
+	if name := r.URL.Query().Get("integration"); name != "" {
+		code = http.StatusNotFound
+		for _, status := range statuses {
+			if status.Name != name {
+				continue
+			}
+			code = http.StatusOK
+			if !status.Healthy || status.Disabled {
+				code = http.StatusServiceUnavailable
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		klog.Errorf("Error encoding integration health: %v", err)
+	}
+}
+
+// Returns the inventory of managed namespaces as JSON, optionally filtered by the owner query parameter
+func (s *Server) handleNamespaces(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.reporter.Report(r.Context())
+	if err != nil {
+		klog.Errorf("Error reporting managed namespaces: %v", err)
+		http.Error(w, "failed to report managed namespaces", http.StatusInternalServerError)
+		return
+	}
+
+	if owner := r.URL.Query().Get("owner"); owner != "" {
+		filtered := make([]controller.NamespaceReport, 0, len(reports))
+		for _, report := range reports {
+			if report.Owner == owner {
+				filtered = append(filtered, report)
+			}
+		}
+		reports = filtered
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reports); err != nil {
+		klog.Errorf("Error encoding managed namespaces: %v", err)
+	}
+}
+
+// Returns the users waiting for approval as JSON
+func (s *Server) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	pending, err := s.approver.PendingApprovals(r.Context())
+	if err != nil {
+		klog.Errorf("Error listing pending approvals: %v", err)
+		http.Error(w, "failed to list pending approvals", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pending); err != nil {
+		klog.Errorf("Error encoding pending approvals: %v", err)
+	}
+}
+
+// Approves provisioning the user in the path, which happens asynchronously
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	user := r.PathValue("user")
+	if err := s.approver.Approve(r.Context(), user); err != nil {
+		if errors.Is(err, controller.ErrNoPendingApproval) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Error approving user %s: %v", user, err)
+		http.Error(w, "failed to approve user", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Returns the external cleanups which kept failing after every retry as JSON
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters, err := s.cleanups.DeadLetters(r.Context())
+	if err != nil {
+		klog.Errorf("Error listing dead-lettered cleanups: %v", err)
+		http.Error(w, "failed to list dead-lettered cleanups", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(deadLetters); err != nil {
+		klog.Errorf("Error encoding dead-lettered cleanups: %v", err)
+	}
+}
+
+// Queues the dead-lettered cleanups of the user in the path again, which run asynchronously
+func (s *Server) handleRetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	user := r.PathValue("user")
+	if err := s.cleanups.RetryDeadLetters(r.Context(), user); err != nil {
+		if errors.Is(err, controller.ErrNoDeadLetter) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Error retrying dead-lettered cleanups of user %s: %v", user, err)
+		http.Error(w, "failed to retry cleanups", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Returns the dead-lettered provisions of users as JSON
+func (s *Server) handleFailedProvisions(w http.ResponseWriter, r *http.Request) {
+	failedProvisions, err := s.provisions.FailedProvisions(r.Context())
+	if err != nil {
+		klog.Errorf("Error listing dead-lettered provisions: %v", err)
+		http.Error(w, "failed to list dead-lettered provisions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(failedProvisions); err != nil {
+		klog.Errorf("Error encoding dead-lettered provisions: %v", err)
+	}
+}
+
+// Provisions the user in the path again, which happens asynchronously
+func (s *Server) handleRetryProvision(w http.ResponseWriter, r *http.Request) {
+	user := r.PathValue("user")
+	if err := s.provisions.RetryProvision(r.Context(), user); err != nil {
+		if errors.Is(err, controller.ErrNoFailedProvision) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		klog.Errorf("Error retrying the provisioning of user %s: %v", user, err)
+		http.Error(w, "failed to retry provisioning", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Returns the unacknowledged membership anomalies of the target groups as JSON
+func (s *Server) handleAnomalies(w http.ResponseWriter, r *http.Request) {
+	anomalies, err := s.anomalies.GroupAnomalies(r.Context())
+	if err != nil {
+		klog.Errorf("Error listing group anomalies: %v", err)
+		http.Error(w, "failed to list group anomalies", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(anomalies); err != nil {
+		klog.Errorf("Error encoding group anomalies: %v", err)
+	}
+}
+
+// Acknowledges the membership anomaly of the group in the path, deprovisioning the users it held. The
+// token query parameter must confirm the anomaly as listed, so users held since it was reviewed aren't
+// deprovisioned.
+func (s *Server) handleAcknowledgeAnomaly(w http.ResponseWriter, r *http.Request) {
+	group := r.PathValue("group")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "missing token query parameter, list the anomalies to get the token of the anomaly", http.StatusBadRequest)
+		return
+	}
+	if err := s.anomalies.AcknowledgeGroupAnomaly(r.Context(), group, token); err != nil {
+		if errors.Is(err, controller.ErrNoGroupAnomaly) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, controller.ErrAnomalyChanged) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		klog.Errorf("Error acknowledging anomaly in group %s: %v", group, err)
+		http.Error(w, "failed to acknowledge anomaly", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Streams live provisioning events as server-sent events, optionally filtered by the user query parameter
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	user := r.URL.Query().Get("user")
+	ch, unsubscribe := s.broadcaster.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if user != "" && event.User != user {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				klog.Errorf("Error encoding provisioning event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: provisioning\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
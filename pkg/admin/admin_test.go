@@ -0,0 +1,438 @@
+package admin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+)
+
+func TestServer_handleEvents(t *testing.T) {
+	broadcaster := events.NewBroadcaster()
+	server := NewServer("", broadcaster, nil, nil, nil, nil, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, httpServer.URL+"/events?user=alice", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected Content-Type text/event-stream, but got %s", got)
+	}
+
+	// The subscription is registered before the headers are flushed
+	broadcaster.Publish(events.Event{User: "bob", Action: events.ActionProvision, Result: events.ResultStarted})
+	broadcaster.Publish(events.Event{User: "alice", Action: events.ActionProvision, Result: events.ResultSucceeded})
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event events.Event
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if event.User != "alice" || event.Result != events.ResultSucceeded {
+			t.Errorf("Expected only the succeeded event of alice, but got %+v", event)
+		}
+		return
+	}
+	t.Fatalf("Event stream ended without events: %v", scanner.Err())
+}
+
+func TestServer_metrics(t *testing.T) {
+	server := NewServer("", nil, nil, nil, nil, nil, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("Failed to get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read metrics: %v", err)
+	}
+	if !strings.Contains(string(body), "rosa_namespace_provisioner_provisioning_duration_seconds") {
+		t.Errorf("Expected provisioning duration histogram to be exported")
+	}
+}
+
+// reporterFunc adapts a function to a Reporter
+type reporterFunc func(ctx context.Context) ([]controller.NamespaceReport, error)
+
+func (f reporterFunc) Report(ctx context.Context) ([]controller.NamespaceReport, error) {
+	return f(ctx)
+}
+
+func TestServer_handleNamespaces(t *testing.T) {
+	server := NewServer("", nil, reporterFunc(func(ctx context.Context) ([]controller.NamespaceReport, error) {
+		return []controller.NamespaceReport{
+			{Namespace: "alice", Owner: "alice", Phase: "Active"},
+			{Namespace: "bob", Owner: "bob", Phase: "Active", Drift: []string{"RoleBinding bob-edit is missing"}},
+		}, nil
+	}), nil, nil, nil, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/namespaces?owner=bob")
+	if err != nil {
+		t.Fatalf("Failed to get namespaces: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var reports []controller.NamespaceReport
+	if err := json.NewDecoder(resp.Body).Decode(&reports); err != nil {
+		t.Fatalf("Failed to decode namespaces: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Namespace != "bob" || len(reports[0].Drift) != 1 {
+		t.Errorf("Expected only the namespace of bob with its drift, but got %+v", reports)
+	}
+
+	// The event stream is not served without a broadcaster
+	resp, err = http.Get(httpServer.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to get events: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for events, but got %d", resp.StatusCode)
+	}
+}
+
+// fakeApprover approves the users it holds as pending
+type fakeApprover struct {
+	pending  []controller.PendingApproval
+	approved []string
+}
+
+func (f *fakeApprover) PendingApprovals(ctx context.Context) ([]controller.PendingApproval, error) {
+	return f.pending, nil
+}
+
+func (f *fakeApprover) Approve(ctx context.Context, user string) error {
+	for _, pending := range f.pending {
+		if pending.User == user {
+			f.approved = append(f.approved, user)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for user %s", controller.ErrNoPendingApproval, user)
+}
+
+func TestServer_handleApprovals(t *testing.T) {
+	approver := &fakeApprover{pending: []controller.PendingApproval{{User: "alice", Namespace: "alice"}}}
+	server := NewServer("", nil, nil, approver, nil, nil, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/approvals")
+	if err != nil {
+		t.Fatalf("Failed to get approvals: %v", err)
+	}
+	var pending []controller.PendingApproval
+	err = json.NewDecoder(resp.Body).Decode(&pending)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode approvals: %v", err)
+	}
+	if len(pending) != 1 || pending[0].User != "alice" {
+		t.Errorf("Expected alice to be pending, but got %+v", pending)
+	}
+
+	for _, tt := range []struct {
+		user string
+		want int
+	}{
+		{user: "alice", want: http.StatusAccepted},
+		{user: "bob", want: http.StatusNotFound},
+	} {
+		resp, err := http.Post(httpServer.URL+"/approvals/"+tt.user, "", nil)
+		if err != nil {
+			t.Fatalf("Failed to approve %s: %v", tt.user, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Expected status %d approving %s, but got %d", tt.want, tt.user, resp.StatusCode)
+		}
+	}
+	if len(approver.approved) != 1 || approver.approved[0] != "alice" {
+		t.Errorf("Expected only alice to be approved, but got %v", approver.approved)
+	}
+}
+
+// fakeCleanupQueue retries the dead letters it holds
+type fakeCleanupQueue struct {
+	deadLetters []controller.DeadLetter
+	retried     []string
+}
+
+func (f *fakeCleanupQueue) DeadLetters(ctx context.Context) ([]controller.DeadLetter, error) {
+	return f.deadLetters, nil
+}
+
+func (f *fakeCleanupQueue) RetryDeadLetters(ctx context.Context, user string) error {
+	for _, deadLetter := range f.deadLetters {
+		if deadLetter.User == user {
+			f.retried = append(f.retried, user)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for user %s", controller.ErrNoDeadLetter, user)
+}
+
+func TestServer_handleDeadLetters(t *testing.T) {
+	cleanups := &fakeCleanupQueue{deadLetters: []controller.DeadLetter{{Integration: "quay", User: "alice", Namespace: "alice", Attempts: 11}}}
+	server := NewServer("", nil, nil, nil, cleanups, nil, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/cleanups/dead-letters")
+	if err != nil {
+		t.Fatalf("Failed to get dead letters: %v", err)
+	}
+	var deadLetters []controller.DeadLetter
+	err = json.NewDecoder(resp.Body).Decode(&deadLetters)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode dead letters: %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].User != "alice" || deadLetters[0].Integration != "quay" {
+		t.Errorf("Expected the quay cleanup of alice to be dead-lettered, but got %+v", deadLetters)
+	}
+
+	for _, tt := range []struct {
+		user string
+		want int
+	}{
+		{user: "alice", want: http.StatusAccepted},
+		{user: "bob", want: http.StatusNotFound},
+	} {
+		resp, err := http.Post(httpServer.URL+"/cleanups/dead-letters/"+tt.user, "", nil)
+		if err != nil {
+			t.Fatalf("Failed to retry cleanups of %s: %v", tt.user, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Expected status %d retrying cleanups of %s, but got %d", tt.want, tt.user, resp.StatusCode)
+		}
+	}
+	if len(cleanups.retried) != 1 || cleanups.retried[0] != "alice" {
+		t.Errorf("Expected only the cleanups of alice to be retried, but got %v", cleanups.retried)
+	}
+}
+
+// fakeProvisionRetrier retries the dead-lettered provisions it holds
+type fakeProvisionRetrier struct {
+	failed  []controller.FailedProvision
+	retried []string
+}
+
+func (f *fakeProvisionRetrier) FailedProvisions(ctx context.Context) ([]controller.FailedProvision, error) {
+	return f.failed, nil
+}
+
+func (f *fakeProvisionRetrier) RetryProvision(ctx context.Context, user string) error {
+	for _, failed := range f.failed {
+		if failed.User == user {
+			f.retried = append(f.retried, user)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for user %s", controller.ErrNoFailedProvision, user)
+}
+
+func TestServer_handleFailedProvisions(t *testing.T) {
+	provisions := &fakeProvisionRetrier{failed: []controller.FailedProvision{{User: "alice", Namespace: "alice", Attempts: 5, DeadLettered: true}}}
+	server := NewServer("", nil, nil, nil, nil, nil, nil).WithProvisionRetrier(provisions)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/provisions/dead-letters")
+	if err != nil {
+		t.Fatalf("Failed to get dead-lettered provisions: %v", err)
+	}
+	var failed []controller.FailedProvision
+	err = json.NewDecoder(resp.Body).Decode(&failed)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode dead-lettered provisions: %v", err)
+	}
+	if len(failed) != 1 || failed[0].User != "alice" || !failed[0].DeadLettered {
+		t.Errorf("Expected the provisioning of alice to be dead-lettered, but got %+v", failed)
+	}
+
+	for _, tt := range []struct {
+		user string
+		want int
+	}{
+		{user: "alice", want: http.StatusAccepted},
+		{user: "bob", want: http.StatusNotFound},
+	} {
+		resp, err := http.Post(httpServer.URL+"/provisions/dead-letters/"+tt.user, "", nil)
+		if err != nil {
+			t.Fatalf("Failed to retry the provisioning of %s: %v", tt.user, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Expected status %d retrying the provisioning of %s, but got %d", tt.want, tt.user, resp.StatusCode)
+		}
+	}
+	if len(provisions.retried) != 1 || provisions.retried[0] != "alice" {
+		t.Errorf("Expected only the provisioning of alice to be retried, but got %v", provisions.retried)
+	}
+}
+
+func TestServer_handleReadyz(t *testing.T) {
+	tracker := health.NewTracker(1, time.Minute)
+	tracker.Register("notifications")
+	tracker.Register("aws-secrets")
+	tracker.Record("aws-secrets", errors.New("access denied"))
+
+	server := NewServer("", nil, nil, nil, nil, nil, tracker)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{
+			name:   "overall readiness ignores disabled integrations",
+			query:  "",
+			status: http.StatusOK,
+		},
+		{
+			name:   "healthy integration",
+			query:  "?integration=notifications",
+			status: http.StatusOK,
+		},
+		{
+			name:   "disabled integration",
+			query:  "?integration=aws-secrets",
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "unknown integration",
+			query:  "?integration=vault",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(httpServer.URL + "/readyz" + tt.query)
+			if err != nil {
+				t.Fatalf("Failed to get readiness: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, but got %d", tt.status, resp.StatusCode)
+			}
+			var statuses []health.Status
+			if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+				t.Fatalf("Failed to decode integration health: %v", err)
+			}
+			if len(statuses) != 2 || statuses[0].Name != "aws-secrets" || !statuses[0].Disabled {
+				t.Errorf("Expected the disabled aws-secrets integration to be reported first, but got %+v", statuses)
+			}
+		})
+	}
+}
+
+// fakeAnomalyAcknowledger acknowledges the group anomalies it holds
+type fakeAnomalyAcknowledger struct {
+	anomalies    []controller.GroupAnomaly
+	acknowledged []string
+}
+
+func (f *fakeAnomalyAcknowledger) GroupAnomalies(ctx context.Context) ([]controller.GroupAnomaly, error) {
+	return f.anomalies, nil
+}
+
+func (f *fakeAnomalyAcknowledger) AcknowledgeGroupAnomaly(ctx context.Context, group string, token string) error {
+	for _, anomaly := range f.anomalies {
+		if anomaly.Group == group {
+			if token != anomaly.Token {
+				return fmt.Errorf("%w for group %s", controller.ErrAnomalyChanged, group)
+			}
+			f.acknowledged = append(f.acknowledged, group)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w for group %s", controller.ErrNoGroupAnomaly, group)
+}
+
+func TestServer_handleAnomalies(t *testing.T) {
+	anomalies := &fakeAnomalyAcknowledger{anomalies: []controller.GroupAnomaly{{Group: "workshop", PreviousSize: 40, Size: 0, HeldUsers: []string{"alice"}, Token: "0123abcd"}}}
+	server := NewServer("", nil, nil, nil, nil, anomalies, nil)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + "/anomalies")
+	if err != nil {
+		t.Fatalf("Failed to get anomalies: %v", err)
+	}
+	var got []controller.GroupAnomaly
+	err = json.NewDecoder(resp.Body).Decode(&got)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Failed to decode anomalies: %v", err)
+	}
+	if len(got) != 1 || got[0].Group != "workshop" || got[0].PreviousSize != 40 {
+		t.Errorf("Expected the anomaly of group workshop, but got %+v", got)
+	}
+
+	for _, tt := range []struct {
+		group string
+		token string
+		want  int
+	}{
+		{group: "workshop", want: http.StatusBadRequest},
+		{group: "workshop", token: "stale", want: http.StatusConflict},
+		{group: "workshop", token: "0123abcd", want: http.StatusNoContent},
+		{group: "staff", token: "0123abcd", want: http.StatusNotFound},
+	} {
+		resp, err := http.Post(httpServer.URL+"/anomalies/"+tt.group+"?token="+tt.token, "", nil)
+		if err != nil {
+			t.Fatalf("Failed to acknowledge anomaly of %s: %v", tt.group, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Expected status %d acknowledging anomaly of %s, but got %d", tt.want, tt.group, resp.StatusCode)
+		}
+	}
+	if len(anomalies.acknowledged) != 1 || anomalies.acknowledged[0] != "workshop" {
+		t.Errorf("Expected only the anomaly of workshop to be acknowledged, but got %v", anomalies.acknowledged)
+	}
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/klog/v2"
+)
+
+// Authorizer authenticates the bearer token of a request to the admin API and authorizes the verb on
+// its path
+type Authorizer interface {
+	Authorize(ctx context.Context, token string, verb string, path string) (user string, allowed bool, err error)
+}
+
+// KubeAuthorizer authenticates bearer tokens with TokenReviews and authorizes them with
+// SubjectAccessReviews of the non-resource URL requested, so access to the admin API is granted with
+// ClusterRoles like access to the cluster
+type KubeAuthorizer struct {
+	tokenReviews  authenticationv1client.TokenReviewInterface
+	accessReviews authorizationv1client.SubjectAccessReviewInterface
+}
+
+// NewKubeAuthorizer creates a new KubeAuthorizer reviewing tokens and access with the API server
+func NewKubeAuthorizer(authenticationClient authenticationv1client.AuthenticationV1Interface, authorizationClient authorizationv1client.AuthorizationV1Interface) *KubeAuthorizer {
+	return &KubeAuthorizer{
+		tokenReviews:  authenticationClient.TokenReviews(),
+		accessReviews: authorizationClient.SubjectAccessReviews(),
+	}
+}
+
+// Authorize returns the user of the token and whether they may use the verb on the path
+func (a *KubeAuthorizer) Authorize(ctx context.Context, token string, verb string, path string) (string, bool, error) {
+	tokenReview, err := a.tokenReviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to review token: %w", err)
+	}
+	if !tokenReview.Status.Authenticated {
+		return "", false, nil
+	}
+
+	userInfo := tokenReview.Status.User
+	extra := make(map[string]authorizationv1.ExtraValue, len(userInfo.Extra))
+	for key, values := range userInfo.Extra {
+		extra[key] = authorizationv1.ExtraValue(values)
+	}
+	accessReview, err := a.accessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   userInfo.Username,
+			UID:    userInfo.UID,
+			Groups: userInfo.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return userInfo.Username, false, fmt.Errorf("failed to review access of %s: %w", userInfo.Username, err)
+	}
+	return userInfo.Username, accessReview.Status.Allowed, nil
+}
+
+// Wraps the handler of an endpoint exposing or changing the state of users, requiring the bearer token
+// of the request to be authorized for the endpoint when the server has an authorizer
+func (s *Server) authorized(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.authorizer == nil {
+			handler(w, r)
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+		// POST endpoints trigger actions, like creating a subresource of the path
+		verb := "get"
+		if r.Method == http.MethodPost {
+			verb = "create"
+		}
+		user, allowed, err := s.authorizer.Authorize(r.Context(), token, verb, r.URL.Path)
+		if err != nil {
+			klog.Errorf("Error authorizing admin API request %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "failed to authorize request", http.StatusInternalServerError)
+			return
+		}
+		if user == "" {
+			http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		if !allowed {
+			klog.Warningf("Denied admin API request %s %s of user %s", r.Method, r.URL.Path, user)
+			http.Error(w, fmt.Sprintf("user %s may not %s %s", user, verb, r.URL.Path), http.StatusForbidden)
+			return
+		}
+		klog.V(2).Infof("Authorized admin API request %s %s of user %s", r.Method, r.URL.Path, user)
+		handler(w, r)
+	}
+}
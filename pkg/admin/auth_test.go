@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeAuthorizer allows the verbs of the users holding its tokens
+type fakeAuthorizer struct {
+	users   map[string]string
+	allowed map[string]bool
+}
+
+func (f *fakeAuthorizer) Authorize(ctx context.Context, token string, verb string, path string) (string, bool, error) {
+	user := f.users[token]
+	return user, user != "" && f.allowed[user+" "+verb+" "+path], nil
+}
+
+func TestServer_authorized(t *testing.T) {
+	approver := &fakeApprover{pending: []controller.PendingApproval{{User: "alice", Namespace: "alice"}}}
+	authorizer := &fakeAuthorizer{
+		users:   map[string]string{"admin-token": "admin", "viewer-token": "viewer"},
+		allowed: map[string]bool{"admin create /approvals/alice": true, "viewer get /approvals": true},
+	}
+	server := NewServer("", nil, nil, approver, nil, nil, nil).WithAuthorizer(authorizer)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	for _, tt := range []struct {
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		{method: http.MethodGet, path: "/approvals", want: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/approvals", token: "invalid-token", want: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/approvals", token: "viewer-token", want: http.StatusOK},
+		{method: http.MethodPost, path: "/approvals/alice", token: "viewer-token", want: http.StatusForbidden},
+		{method: http.MethodPost, path: "/approvals/alice", token: "admin-token", want: http.StatusAccepted},
+		{method: http.MethodGet, path: "/healthz", want: http.StatusOK},
+	} {
+		req, err := http.NewRequest(tt.method, httpServer.URL+tt.path, nil)
+		if err != nil {
+			t.Fatalf("Failed to create request: %v", err)
+		}
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to request %s %s: %v", tt.method, tt.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("Expected status %d for %s %s with token %q, but got %d", tt.want, tt.method, tt.path, tt.token, resp.StatusCode)
+		}
+	}
+	if len(approver.approved) != 1 || approver.approved[0] != "alice" {
+		t.Errorf("Expected alice to be approved once by the admin, but got %v", approver.approved)
+	}
+}
+
+func TestKubeAuthorizer_Authorize(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	kubeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "admin-token" {
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          authenticationv1.UserInfo{Username: "admin", Groups: []string{"provisioner-admins"}},
+			}
+		}
+		return true, review, nil
+	})
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attributes := review.Spec.NonResourceAttributes
+		review.Status.Allowed = len(review.Spec.Groups) == 1 && review.Spec.Groups[0] == "provisioner-admins" &&
+			attributes != nil && attributes.Verb == "create" && attributes.Path == "/anomalies/sandbox-users"
+		return true, review, nil
+	})
+	authorizer := NewKubeAuthorizer(kubeClient.AuthenticationV1(), kubeClient.AuthorizationV1())
+
+	ctx := context.Background()
+	user, allowed, err := authorizer.Authorize(ctx, "admin-token", "create", "/anomalies/sandbox-users")
+	if err != nil || user != "admin" || !allowed {
+		t.Errorf("Expected admin to be allowed, but got %q, %v (%v)", user, allowed, err)
+	}
+	user, allowed, err = authorizer.Authorize(ctx, "admin-token", "create", "/approvals/alice")
+	if err != nil || user != "admin" || allowed {
+		t.Errorf("Expected admin to be denied another path, but got %q, %v (%v)", user, allowed, err)
+	}
+	user, allowed, err = authorizer.Authorize(ctx, "other-token", "create", "/anomalies/sandbox-users")
+	if err != nil || user != "" || allowed {
+		t.Errorf("Expected an invalid token not to be authenticated, but got %q, %v (%v)", user, allowed, err)
+	}
+}
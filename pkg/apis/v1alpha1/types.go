@@ -0,0 +1,210 @@
+// Package v1alpha1 contains the custom resources managed by the provisioner
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group of the provisioner's custom resources
+const GroupName = "provisioner.redhat-ai-dev.io"
+
+// SchemeGroupVersion is the group version of the provisioner's custom resources
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// ManagedNamespacesResource identifies ManagedNamespaces for the dynamic client
+var ManagedNamespacesResource = SchemeGroupVersion.WithResource("managednamespaces")
+
+// ManagedNamespaceKind is the kind of ManagedNamespace objects
+const ManagedNamespaceKind = "ManagedNamespace"
+
+// ProvisionerConfigsResource identifies ProvisionerConfigs for the dynamic client
+var ProvisionerConfigsResource = SchemeGroupVersion.WithResource("provisionerconfigs")
+
+// ProvisionerConfigKind is the kind of ProvisionerConfig objects
+const ProvisionerConfigKind = "ProvisionerConfig"
+
+// ProvisionerConfigName is the name of the only ProvisionerConfig applied by the controller
+const ProvisionerConfigName = "cluster"
+
+// NamespaceClaimsResource identifies NamespaceClaims for the dynamic client
+var NamespaceClaimsResource = SchemeGroupVersion.WithResource("namespaceclaims")
+
+// NamespaceClaimKind is the kind of NamespaceClaim objects
+const NamespaceClaimKind = "NamespaceClaim"
+
+// ConditionReady reports whether every provisioning step of the namespace succeeded
+const ConditionReady = "Ready"
+
+// ConditionIntegrationsHealthy reports whether every optional integration was enabled when the
+// namespace was last provisioned
+const ConditionIntegrationsHealthy = "IntegrationsHealthy"
+
+// ConditionApproved reports whether an admin approved provisioning the namespace, only set when
+// approval is required
+const ConditionApproved = "Approved"
+
+// ConditionDeprovisioned reports that the owner was deprovisioned while their namespace is still
+// being deleted. The record is removed once the deletion is verified.
+const ConditionDeprovisioned = "Deprovisioned"
+
+// ConditionApplied reports whether the controller applied the current generation of the
+// ProvisionerConfig, or rejected it as invalid and kept the previous configuration
+const ConditionApplied = "Applied"
+
+// Phases of a ManagedNamespace, summarizing its conditions for oc get
+const (
+	PhasePendingApproval = "PendingApproval"
+	PhaseProvisioned     = "Provisioned"
+	PhaseFailed          = "Failed"
+	PhaseDeadLettered    = "DeadLettered"
+	PhaseDeprovisioning  = "Deprovisioning"
+)
+
+// ManagedNamespace is the inventory record of a namespace provisioned by the controller,
+// named after the namespace it describes
+type ManagedNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ManagedNamespaceSpec   `json:"spec,omitempty"`
+	Status ManagedNamespaceStatus `json:"status,omitempty"`
+}
+
+// ManagedNamespaceSpec describes who the namespace was provisioned for and the policy exceptions
+// approved for it
+type ManagedNamespaceSpec struct {
+	// Owner is the user the namespace was provisioned for
+	Owner string `json:"owner"`
+	// SourceGroup is the group whose membership granted the namespace
+	SourceGroup string `json:"sourceGroup,omitempty"`
+	// PolicyExceptions lists the policies an admin approved an exception to, e.g. allowing
+	// LoadBalancer Services or extra GPUs. Only set by admins, the controller never changes it.
+	PolicyExceptions []PolicyException `json:"policyExceptions,omitempty"`
+}
+
+// PolicyException is an approved exception to a policy object seeded into the namespace, which the
+// controller leaves as found instead of creating or restoring it
+type PolicyException struct {
+	// Kind is the kind of the policy object: ResourceQuota, LimitRange or NetworkPolicy
+	Kind string `json:"kind"`
+	// Name is the name of the policy object, e.g. deny-load-balancers
+	Name string `json:"name"`
+	// Reason records why the exception was approved
+	Reason string `json:"reason,omitempty"`
+	// ApprovedBy records who approved the exception
+	ApprovedBy string `json:"approvedBy,omitempty"`
+	// Expires is when the policy is enforced again, never when unset
+	Expires *metav1.Time `json:"expires,omitempty"`
+}
+
+// ManagedNamespaceStatus describes what the controller applied to the namespace
+type ManagedNamespaceStatus struct {
+	// Policies lists the policies applied to the namespace
+	Policies []ResourceReference `json:"policies,omitempty"`
+	// SeededResources lists the objects seeded into the namespace
+	SeededResources []ResourceReference `json:"seededResources,omitempty"`
+	// MembershipSources lists the membership sources granting the owner the namespace in priority
+	// order, only set when membership is merged from several sources
+	MembershipSources []string `json:"membershipSources,omitempty"`
+	// LastReconcileTrigger is what triggered the last provisioning of the namespace, e.g. update,
+	// resync, admin or repair
+	LastReconcileTrigger string `json:"lastReconcileTrigger,omitempty"`
+	// Phase summarizes the provisioning state of the namespace: PendingApproval, Provisioned, Failed,
+	// DeadLettered or Deprovisioning
+	Phase string `json:"phase,omitempty"`
+	// LastError is the error which stopped the last provisioning, cleared once it succeeds
+	LastError string `json:"lastError,omitempty"`
+	// FailedAttempts is the number of consecutive provisionings which failed permanently
+	FailedAttempts int `json:"failedAttempts,omitempty"`
+	// LastAttemptTime is when the namespace was last provisioned, successfully or not
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+	// LastProvisionedTime is when the namespace was last provisioned successfully
+	LastProvisionedTime *metav1.Time `json:"lastProvisionedTime,omitempty"`
+	// Conditions describe the provisioning state of the namespace
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// ResourceReference identifies an object managed for the namespace
+type ResourceReference struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ProvisionerConfig is the cluster-wide configuration of the provisioner, managed e.g. through GitOps.
+// The fields it sets take precedence over the environment variables of the same settings and are
+// applied without restarting the controller.
+type ProvisionerConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ProvisionerConfigSpec   `json:"spec,omitempty"`
+	Status ProvisionerConfigStatus `json:"status,omitempty"`
+}
+
+// ProvisionerConfigSpec sets the behavior of the provisioner, leaving unset fields to the environment
+type ProvisionerConfigSpec struct {
+	// TargetGroups are the groups whose members get namespaces, as TARGET_GROUP_NAMES
+	TargetGroups []string `json:"targetGroups,omitempty"`
+	// ProjectNameTemplate is the Go template naming the namespace of each user from its .User, where
+	// {user} is shorthand for {{ .User }}, as PROJECT_NAME_TEMPLATE
+	ProjectNameTemplate string `json:"projectNameTemplate,omitempty"`
+	// UserClusterRole is the ClusterRole granted to users in their namespace, as USER_CLUSTER_ROLE
+	UserClusterRole string `json:"userClusterRole,omitempty"`
+	// UserClusterRoleOverrides maps target groups to the ClusterRole granted to their members instead,
+	// as USER_CLUSTER_ROLE_OVERRIDES
+	UserClusterRoleOverrides map[string]string `json:"userClusterRoleOverrides,omitempty"`
+	// ResourceQuota is the ResourceQuota seeded into every namespace, enabling RESOURCE_QUOTA_ENABLED
+	ResourceQuota *ResourceQuotaTemplate `json:"resourceQuota,omitempty"`
+	// LimitRange is the LimitRange seeded into every namespace, replacing the LIMIT_RANGE_FILE template
+	// and enabling LIMIT_RANGE_ENABLED
+	LimitRange *corev1.LimitRangeSpec `json:"limitRange,omitempty"`
+	// ProjectDeletionPolicy is what happens to the namespace of removed users: Delete, Retain or
+	// Orphan, as PROJECT_DELETION_POLICY
+	ProjectDeletionPolicy string `json:"projectDeletionPolicy,omitempty"`
+	// DeletionGracePeriod is how long namespaces of removed users are kept before being deleted, as
+	// DELETION_GRACE_PERIOD
+	DeletionGracePeriod *metav1.Duration `json:"deletionGracePeriod,omitempty"`
+}
+
+// ResourceQuotaTemplate is the hard limits of the ResourceQuota seeded into every namespace
+type ResourceQuotaTemplate struct {
+	// Hard maps resource names to their limits, as RESOURCE_QUOTA_HARD
+	Hard corev1.ResourceList `json:"hard"`
+}
+
+// ProvisionerConfigStatus describes whether the controller applied the configuration
+type ProvisionerConfigStatus struct {
+	// ObservedGeneration is the generation of the spec the controller last handled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe whether the configuration was applied
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// NamespaceClaim requests an additional namespace for the owner of the managed namespace it is
+// created in, named after both, e.g. alice-experiments for the claim experiments under alice
+type NamespaceClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NamespaceClaimSpec   `json:"spec,omitempty"`
+	Status NamespaceClaimStatus `json:"status,omitempty"`
+}
+
+// NamespaceClaimSpec describes the claimed namespace
+type NamespaceClaimSpec struct {
+	// DisplayName is the name the OpenShift console shows for the claimed namespace
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// NamespaceClaimStatus describes whether the claimed namespace was provisioned
+type NamespaceClaimStatus struct {
+	// Namespace is the name of the claimed namespace
+	Namespace string `json:"namespace,omitempty"`
+	// ObservedGeneration is the generation of the spec the controller last handled
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions describe whether the claimed namespace was provisioned, and why not
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
@@ -0,0 +1,61 @@
+// Package awssecrets fetches secret values from AWS Secrets Manager so they can be
+// materialized as Kubernetes Secrets in provisioned namespaces
+package awssecrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+// default key used when a secret value is not a JSON object
+const defaultSecretKey = "value"
+
+// Client retrieves secrets from AWS Secrets Manager
+type Client struct {
+	secretsManager *secretsmanager.Client
+}
+
+// NewClient creates a Client using the default AWS credential chain, which picks up
+// IRSA web identity credentials when running on ROSA with an annotated ServiceAccount
+func NewClient(ctx context.Context) (*Client, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return &Client{
+		secretsManager: secretsmanager.NewFromConfig(cfg),
+	}, nil
+}
+
+// GetSecretData returns the current value of the secret with the given id (name or ARN) as Secret data
+func (c *Client) GetSecretData(ctx context.Context, id string) (map[string][]byte, error) {
+	output, err := c.secretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(id),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS secret %s: %w", id, err)
+	}
+	if output.SecretString != nil {
+		return secretStringData(*output.SecretString), nil
+	}
+	return map[string][]byte{defaultSecretKey: output.SecretBinary}, nil
+}
+
+// Converts a secret string into Secret data, splitting flat JSON objects into one key per field
+func secretStringData(value string) map[string][]byte {
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(value), &fields); err != nil || len(fields) == 0 {
+		return map[string][]byte{defaultSecretKey: []byte(value)}
+	}
+
+	data := make(map[string][]byte, len(fields))
+	for key, field := range fields {
+		data[key] = []byte(field)
+	}
+	return data
+}
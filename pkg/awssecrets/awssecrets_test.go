@@ -0,0 +1,48 @@
+package awssecrets
+
+import (
+	"testing"
+)
+
+func TestSecretStringData(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]string
+	}{
+		{
+			name:  "plain string",
+			value: "s3cr3t",
+			want:  map[string]string{"value": "s3cr3t"},
+		},
+		{
+			name:  "JSON object",
+			value: `{"api-key":"abc","endpoint":"https://models.example.com"}`,
+			want:  map[string]string{"api-key": "abc", "endpoint": "https://models.example.com"},
+		},
+		{
+			name:  "nested JSON object",
+			value: `{"api":{"key":"abc"}}`,
+			want:  map[string]string{"value": `{"api":{"key":"abc"}}`},
+		},
+		{
+			name:  "empty JSON object",
+			value: `{}`,
+			want:  map[string]string{"value": `{}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := secretStringData(tt.value)
+			if len(got) != len(tt.want) {
+				t.Fatalf("secretStringData() = %v, want %v", got, tt.want)
+			}
+			for key, value := range tt.want {
+				if string(got[key]) != value {
+					t.Errorf("secretStringData()[%s] = %q, want %q", key, got[key], value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package bulk implements onboarding of many users at once, e.g. ahead of instructor-led workshops
+package bulk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// interval between checks of whether onboarded namespaces are ready
+const readyPollInterval = 2 * time.Second
+
+// Provisioner provisions user namespaces and reports their readiness
+type Provisioner interface {
+	ProvisionUser(ctx context.Context, user string) error
+	IsUserReady(ctx context.Context, user string) (bool, error)
+}
+
+// OnboardOptions configures a bulk onboarding
+type OnboardOptions struct {
+	// Users to onboard
+	Users []string
+	// GroupName is the target group users are added to
+	GroupName string
+	// Direct provisions users directly instead of adding them to the target group
+	Direct bool
+	// Timeout is the maximum time to wait for all namespaces to be ready
+	Timeout time.Duration
+}
+
+// Result is the outcome of onboarding or offboarding a single user
+type Result struct {
+	User string
+	// Ready reports whether the user reached the desired state, i.e. their namespace
+	// is ready when onboarding or deleted when offboarding
+	Ready bool
+	Err   error
+}
+
+// ReadUsersFile reads usernames from a file, one per line, ignoring blank lines and # comments
+func ReadUsersFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open users file: %w", err)
+	}
+	defer file.Close()
+	return ReadUsers(file)
+}
+
+// ReadUsers reads unique usernames, one per line, ignoring blank lines and # comments
+func ReadUsers(r io.Reader) ([]string, error) {
+	var users []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		user := strings.TrimSpace(line)
+		if user == "" || seen[user] {
+			continue
+		}
+		seen[user] = true
+		users = append(users, user)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read users: %w", err)
+	}
+	return users, nil
+}
+
+// Onboard adds the users to the target group (or provisions them directly), then waits
+// until every namespace is ready or the timeout expires, returning a result per user
+func Onboard(ctx context.Context, userClient userclient.Interface, provisioner Provisioner, opts OnboardOptions) []Result {
+	results := make(map[string]*Result, len(opts.Users))
+	for _, user := range opts.Users {
+		results[user] = &Result{User: user}
+	}
+
+	if opts.Direct {
+		for _, user := range opts.Users {
+			if err := provisioner.ProvisionUser(ctx, user); err != nil {
+				results[user].Err = err
+			}
+		}
+	} else if err := addUsersToGroup(ctx, userClient, opts.GroupName, opts.Users); err != nil {
+		for _, result := range results {
+			result.Err = err
+		}
+		return sortedResults(results)
+	}
+
+	// Wait for every namespace to become ready
+	err := wait.PollUntilContextTimeout(ctx, readyPollInterval, opts.Timeout, true, func(ctx context.Context) (bool, error) {
+		pending := 0
+		for _, result := range results {
+			if result.Ready || result.Err != nil {
+				continue
+			}
+			ready, err := provisioner.IsUserReady(ctx, result.User)
+			if err != nil {
+				klog.V(2).Infof("Error checking readiness of user %s: %v", result.User, err)
+			}
+			if ready {
+				result.Ready = true
+				klog.Infof("Namespace of user %s is ready", result.User)
+			} else {
+				pending++
+			}
+		}
+		return pending == 0, nil
+	})
+	if err != nil {
+		for _, result := range results {
+			if !result.Ready && result.Err == nil {
+				result.Err = fmt.Errorf("namespace not ready within %s", opts.Timeout)
+			}
+		}
+	}
+
+	return sortedResults(results)
+}
+
+// Adds the users missing from the target group
+func addUsersToGroup(ctx context.Context, userClient userclient.Interface, groupName string, users []string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		group, err := userClient.UserV1().Groups().Get(ctx, groupName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get group %s: %w", groupName, err)
+		}
+
+		members := make(map[string]bool, len(group.Users))
+		for _, member := range group.Users {
+			members[member] = true
+		}
+		added := 0
+		for _, user := range users {
+			if !members[user] {
+				group.Users = append(group.Users, user)
+				added++
+			}
+		}
+		if added == 0 {
+			klog.Infof("All users are already members of group %s", groupName)
+			return nil
+		}
+
+		if _, err := userClient.UserV1().Groups().Update(ctx, group, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Added %d users to group %s", added, groupName)
+		return nil
+	})
+}
+
+// Returns the results ordered by user
+func sortedResults(results map[string]*Result) []Result {
+	sorted := make([]Result, 0, len(results))
+	for _, result := range results {
+		sorted = append(sorted, *result)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].User < sorted[j].User
+	})
+	return sorted
+}
+
+// Failed returns whether any user failed to be onboarded
+func Failed(results []Result) bool {
+	for _, result := range results {
+		if !result.Ready || result.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintResults writes a per-user table of results followed by a summary
+func PrintResults(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tSTATUS\tMESSAGE")
+	ready := 0
+	for _, result := range results {
+		status := "Ready"
+		message := ""
+		if result.Err != nil || !result.Ready {
+			status = "Failed"
+		} else {
+			ready++
+		}
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.User, status, message)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d/%d users ready\n", ready, len(results))
+}
@@ -0,0 +1,158 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeProvisioner provisions users in memory, failing for the configured users
+type fakeProvisioner struct {
+	mu          sync.Mutex
+	provisioned map[string]bool
+	failing     map[string]bool
+}
+
+func (f *fakeProvisioner) ProvisionUser(ctx context.Context, user string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failing[user] {
+		return fmt.Errorf("provisioning failed for %s", user)
+	}
+	f.provisioned[user] = true
+	return nil
+}
+
+func (f *fakeProvisioner) IsUserReady(ctx context.Context, user string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.provisioned[user], nil
+}
+
+func TestReadUsers(t *testing.T) {
+	input := `# workshop cohort
+alice
+bob   # instructor
+
+alice
+ charlie
+`
+	users, err := ReadUsers(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"alice", "bob", "charlie"}
+	if strings.Join(users, ",") != strings.Join(want, ",") {
+		t.Errorf("ReadUsers() = %v, want %v", users, want)
+	}
+}
+
+func TestOnboard(t *testing.T) {
+	tests := []struct {
+		name        string
+		direct      bool
+		failing     map[string]bool
+		wantReady   map[string]bool
+		wantFailure bool
+	}{
+		{
+			name:      "direct provisioning",
+			direct:    true,
+			wantReady: map[string]bool{"alice": true, "bob": true},
+		},
+		{
+			name:        "direct provisioning with failure",
+			direct:      true,
+			failing:     map[string]bool{"bob": true},
+			wantReady:   map[string]bool{"alice": true, "bob": false},
+			wantFailure: true,
+		},
+		{
+			name:        "group membership without controller",
+			direct:      false,
+			wantReady:   map[string]bool{"alice": false, "bob": false},
+			wantFailure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userClient := userfake.NewSimpleClientset(&userv1.Group{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "workshop",
+				},
+				Users: []string{"alice"},
+			})
+			provisioner := &fakeProvisioner{
+				provisioned: make(map[string]bool),
+				failing:     tt.failing,
+			}
+
+			results := Onboard(ctx, userClient, provisioner, OnboardOptions{
+				Users:     []string{"bob", "alice"},
+				GroupName: "workshop",
+				Direct:    tt.direct,
+				Timeout:   100 * time.Millisecond,
+			})
+
+			if len(results) != 2 || results[0].User != "alice" || results[1].User != "bob" {
+				t.Fatalf("Expected sorted results for alice and bob, but got %+v", results)
+			}
+			for _, result := range results {
+				if result.Ready != tt.wantReady[result.User] {
+					t.Errorf("Expected user %s ready = %v, but got %+v", result.User, tt.wantReady[result.User], result)
+				}
+			}
+			if Failed(results) != tt.wantFailure {
+				t.Errorf("Expected Failed() = %v", tt.wantFailure)
+			}
+
+			group, err := userClient.UserV1().Groups().Get(ctx, "workshop", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get group: %v", err)
+			}
+			wantMembers := 1
+			if !tt.direct {
+				wantMembers = 2
+			}
+			if len(group.Users) != wantMembers {
+				t.Errorf("Expected %d group members, but got %v", wantMembers, group.Users)
+			}
+		})
+	}
+}
+
+func TestOnboardMissingGroup(t *testing.T) {
+	results := Onboard(context.Background(), userfake.NewSimpleClientset(), &fakeProvisioner{}, OnboardOptions{
+		Users:     []string{"alice"},
+		GroupName: "missing",
+		Timeout:   time.Second,
+	})
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("Expected onboarding into a missing group to fail, but got %+v", results)
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	var out bytes.Buffer
+	PrintResults(&out, []Result{
+		{User: "alice", Ready: true},
+		{User: "bob", Err: fmt.Errorf("namespace not ready within 1m0s")},
+	})
+
+	output := out.String()
+	for _, want := range []string{"alice", "Ready", "bob", "Failed", "namespace not ready", "1/2 users ready"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, but got:\n%s", want, output)
+		}
+	}
+}
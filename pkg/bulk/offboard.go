@@ -0,0 +1,247 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// interval between checks of whether offboarded namespaces are gone
+const deletedPollInterval = 2 * time.Second
+
+// Deprovisioner deprovisions user namespaces
+type Deprovisioner interface {
+	DeprovisionUser(ctx context.Context, user string) error
+	ProjectName(user string) string
+}
+
+// OffboardOptions configures a bulk offboarding
+type OffboardOptions struct {
+	// Users to offboard
+	Users []string
+	// GroupNames are the target groups users are removed from
+	GroupNames []string
+	// Direct deprovisions users directly instead of removing them from the target groups
+	Direct bool
+	// Timeout is the maximum time to wait for all namespaces to be deleted
+	Timeout time.Duration
+}
+
+// OffboardPlan describes everything that will be deleted when offboarding a user
+type OffboardPlan struct {
+	User      string
+	Namespace string
+	Exists    bool
+	PVCs      int
+	Workloads int
+	Err       error
+}
+
+// PlanOffboard inspects the namespace of every user to report what offboarding would delete
+func PlanOffboard(ctx context.Context, kubeClient kubernetes.Interface, deprovisioner Deprovisioner, users []string) []OffboardPlan {
+	plans := make([]OffboardPlan, 0, len(users))
+	for _, user := range users {
+		plan := OffboardPlan{
+			User:      user,
+			Namespace: deprovisioner.ProjectName(user),
+		}
+		plan.Exists, plan.PVCs, plan.Workloads, plan.Err = inspectNamespace(ctx, kubeClient, plan.Namespace)
+		plans = append(plans, plan)
+	}
+	return plans
+}
+
+// Counts the PVCs and workloads (Deployments, StatefulSets, DaemonSets, Jobs and CronJobs) of a namespace
+func inspectNamespace(ctx context.Context, kubeClient kubernetes.Interface, namespace string) (bool, int, int, error) {
+	_, err := kubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, 0, 0, nil
+		}
+		return false, 0, 0, err
+	}
+
+	pvcs, err := kubeClient.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+
+	workloads := 0
+	deployments, err := kubeClient.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+	workloads += len(deployments.Items)
+	statefulSets, err := kubeClient.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+	workloads += len(statefulSets.Items)
+	daemonSets, err := kubeClient.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+	workloads += len(daemonSets.Items)
+	jobs, err := kubeClient.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+	workloads += len(jobs.Items)
+	cronJobs, err := kubeClient.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return true, 0, 0, err
+	}
+	workloads += len(cronJobs.Items)
+
+	return true, len(pvcs.Items), workloads, nil
+}
+
+// PrintPlan writes the dry-run report of an offboarding
+func PrintPlan(w io.Writer, plans []OffboardPlan) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tNAMESPACE\tPVCS\tWORKLOADS\tNOTE")
+	namespaces, pvcs, workloads := 0, 0, 0
+	for _, plan := range plans {
+		note := ""
+		switch {
+		case plan.Err != nil:
+			note = fmt.Sprintf("inspection failed: %v", plan.Err)
+		case !plan.Exists:
+			note = "namespace does not exist"
+		default:
+			namespaces++
+			pvcs += plan.PVCs
+			workloads += plan.Workloads
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", plan.User, plan.Namespace, plan.PVCs, plan.Workloads, note)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d namespaces, %d PVCs and %d workloads will be deleted for %d users\n", namespaces, pvcs, workloads, len(plans))
+}
+
+// Offboard removes the users from the target group (or deprovisions them directly), reporting
+// progress as each namespace disappears, and returns a result per user once every namespace
+// is deleted or the timeout expires
+func Offboard(ctx context.Context, userClient userclient.Interface, kubeClient kubernetes.Interface, deprovisioner Deprovisioner, opts OffboardOptions, progress io.Writer) []Result {
+	results := make(map[string]*Result, len(opts.Users))
+	for _, user := range opts.Users {
+		results[user] = &Result{User: user}
+	}
+
+	if opts.Direct {
+		for _, user := range opts.Users {
+			if err := deprovisioner.DeprovisionUser(ctx, user); err != nil {
+				results[user].Err = err
+				fmt.Fprintf(progress, "%s: deprovisioning failed: %v\n", user, err)
+			}
+		}
+	} else {
+		for _, groupName := range opts.GroupNames {
+			if err := removeUsersFromGroup(ctx, userClient, groupName, opts.Users); err != nil {
+				for _, result := range results {
+					result.Err = err
+				}
+				return sortedResults(results)
+			}
+		}
+	}
+
+	// Wait for every namespace to be deleted
+	done := 0
+	err := wait.PollUntilContextTimeout(ctx, deletedPollInterval, opts.Timeout, true, func(ctx context.Context) (bool, error) {
+		pending := 0
+		for _, result := range results {
+			if result.Ready || result.Err != nil {
+				continue
+			}
+			namespace := deprovisioner.ProjectName(result.User)
+			_, err := kubeClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				result.Ready = true
+				done++
+				fmt.Fprintf(progress, "[%d/%d] %s: namespace %s deleted\n", done, len(results), result.User, namespace)
+				continue
+			}
+			if err != nil {
+				klog.V(2).Infof("Error checking namespace %s of user %s: %v", namespace, result.User, err)
+			}
+			pending++
+		}
+		return pending == 0, nil
+	})
+	if err != nil {
+		for _, result := range results {
+			if !result.Ready && result.Err == nil {
+				result.Err = fmt.Errorf("namespace not deleted within %s", opts.Timeout)
+			}
+		}
+	}
+
+	return sortedResults(results)
+}
+
+// Removes the users from the target group
+func removeUsersFromGroup(ctx context.Context, userClient userclient.Interface, groupName string, users []string) error {
+	removing := make(map[string]bool, len(users))
+	for _, user := range users {
+		removing[user] = true
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		group, err := userClient.UserV1().Groups().Get(ctx, groupName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get group %s: %w", groupName, err)
+		}
+
+		var remaining []string
+		for _, member := range group.Users {
+			if !removing[member] {
+				remaining = append(remaining, member)
+			}
+		}
+		removed := len(group.Users) - len(remaining)
+		if removed == 0 {
+			klog.Infof("None of the users are members of group %s", groupName)
+			return nil
+		}
+
+		group.Users = remaining
+		if _, err := userClient.UserV1().Groups().Update(ctx, group, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		klog.Infof("Removed %d users from group %s", removed, groupName)
+		return nil
+	})
+}
+
+// PrintOffboardResults writes a per-user table of offboarding results followed by a summary
+func PrintOffboardResults(w io.Writer, results []Result) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tSTATUS\tMESSAGE")
+	deleted := 0
+	for _, result := range results {
+		status := "Deleted"
+		message := ""
+		if result.Err != nil || !result.Ready {
+			status = "Failed"
+		} else {
+			deleted++
+		}
+		if result.Err != nil {
+			message = result.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.User, status, message)
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\n%d/%d users offboarded\n", deleted, len(results))
+}
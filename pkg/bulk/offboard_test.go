@@ -0,0 +1,121 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeDeprovisioner deletes user namespaces directly
+type fakeDeprovisioner struct {
+	kubeClient kubernetes.Interface
+}
+
+func (f *fakeDeprovisioner) DeprovisionUser(ctx context.Context, user string) error {
+	return f.kubeClient.CoreV1().Namespaces().Delete(ctx, f.ProjectName(user), metav1.DeleteOptions{})
+}
+
+func (f *fakeDeprovisioner) ProjectName(user string) string {
+	return user
+}
+
+func newOffboardKubeClient() *fake.Clientset {
+	return fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "alice"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "models", Namespace: "alice"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "notebook", Namespace: "alice"}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "training", Namespace: "alice"}},
+	)
+}
+
+func TestPlanOffboard(t *testing.T) {
+	kubeClient := newOffboardKubeClient()
+	plans := PlanOffboard(context.Background(), kubeClient, &fakeDeprovisioner{kubeClient: kubeClient}, []string{"alice", "bob"})
+
+	if len(plans) != 2 {
+		t.Fatalf("Expected 2 plans, but got %+v", plans)
+	}
+	if !plans[0].Exists || plans[0].PVCs != 2 || plans[0].Workloads != 2 {
+		t.Errorf("Expected alice to have 2 PVCs and 2 workloads, but got %+v", plans[0])
+	}
+	if plans[1].Exists {
+		t.Errorf("Expected bob to have no namespace, but got %+v", plans[1])
+	}
+
+	var out bytes.Buffer
+	PrintPlan(&out, plans)
+	if !strings.Contains(out.String(), "1 namespaces, 2 PVCs and 2 workloads will be deleted for 2 users") {
+		t.Errorf("Unexpected plan summary:\n%s", out.String())
+	}
+}
+
+func TestOffboard(t *testing.T) {
+	tests := []struct {
+		name        string
+		direct      bool
+		wantDeleted bool
+	}{
+		{
+			name:        "direct deprovisioning",
+			direct:      true,
+			wantDeleted: true,
+		},
+		{
+			name:        "group removal without controller",
+			direct:      false,
+			wantDeleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			kubeClient := newOffboardKubeClient()
+			userClient := userfake.NewSimpleClientset(&userv1.Group{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "workshop",
+				},
+				Users: []string{"alice", "bob"},
+			})
+
+			var progress bytes.Buffer
+			results := Offboard(ctx, userClient, kubeClient, &fakeDeprovisioner{kubeClient: kubeClient}, OffboardOptions{
+				Users:      []string{"alice"},
+				GroupNames: []string{"workshop"},
+				Direct:     tt.direct,
+				Timeout:    100 * time.Millisecond,
+			}, &progress)
+
+			if len(results) != 1 || results[0].Ready != tt.wantDeleted {
+				t.Errorf("Expected alice deleted = %v, but got %+v", tt.wantDeleted, results)
+			}
+			if tt.wantDeleted && !strings.Contains(progress.String(), "[1/1] alice: namespace alice deleted") {
+				t.Errorf("Expected progress to be reported, but got:\n%s", progress.String())
+			}
+
+			group, err := userClient.UserV1().Groups().Get(ctx, "workshop", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get group: %v", err)
+			}
+			wantMembers := []string{"alice", "bob"}
+			if !tt.direct {
+				wantMembers = []string{"bob"}
+			}
+			if strings.Join(group.Users, ",") != strings.Join(wantMembers, ",") {
+				t.Errorf("Expected group members %v, but got %v", wantMembers, group.Users)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Reasons of the Events recorded when access windows close and open
+const (
+	accessWindowClosedReason = "AccessWindowClosed"
+	accessWindowOpenedReason = "AccessWindowOpened"
+)
+
+// annotation recording when the access of the owner of a managed namespace was revoked as their
+// access window closed
+const accessRevokedAnnotation = "rosa-namespace-provisioner/access-revoked"
+
+// layout of the dates bounding access windows
+const accessWindowDateLayout = "2006-01-02"
+
+// AccessWindow is a period during which the members of a target group may access their namespaces
+type AccessWindow struct {
+	// Start is the first instant of the window
+	Start time.Time
+	// End is the first instant after the window
+	End time.Time
+}
+
+// Contains returns whether the time falls within the window
+func (w AccessWindow) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Parses a "<start>/<end>" window of inclusive YYYY-MM-DD dates in UTC
+func parseAccessWindow(value string) (AccessWindow, error) {
+	startValue, endValue, found := strings.Cut(value, "/")
+	if !found {
+		return AccessWindow{}, fmt.Errorf("expected <start>/<end>, got %q", value)
+	}
+	start, err := time.Parse(accessWindowDateLayout, strings.TrimSpace(startValue))
+	if err != nil {
+		return AccessWindow{}, fmt.Errorf("invalid window start: %w", err)
+	}
+	end, err := time.Parse(accessWindowDateLayout, strings.TrimSpace(endValue))
+	if err != nil {
+		return AccessWindow{}, fmt.Errorf("invalid window end: %w", err)
+	}
+	if end.Before(start) {
+		return AccessWindow{}, fmt.Errorf("window ends on %s before it starts on %s", endValue, startValue)
+	}
+	// the end date is inclusive, so the window lasts until the next midnight
+	return AccessWindow{Start: start, End: end.AddDate(0, 0, 1)}, nil
+}
+
+// Returns whether the target user may access their namespace at the given time: always, unless every
+// target group granting their namespace has access windows and none of them is open
+func (c *Controller) accessWindowOpen(ctx context.Context, user string, now time.Time) (bool, error) {
+	windows, err := GetAccessWindows()
+	if err != nil || len(windows) == 0 {
+		return true, err
+	}
+	groups, err := c.sourceGroups(ctx, user)
+	if err != nil {
+		return false, err
+	}
+	if len(groups) == 0 {
+		return true, nil
+	}
+	for _, group := range groups {
+		groupWindows, ok := windows[group.Name]
+		if !ok {
+			return true, nil
+		}
+		for _, window := range groupWindows {
+			if window.Contains(now) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Grants the target user their ClusterRole while their access window is open, and revokes it while
+// it is closed, keeping their namespace
+func (c *Controller) syncRoleBinding(ctx context.Context, user string, projectName string) error {
+	if windows, err := GetAccessWindows(); err == nil && len(windows) == 0 {
+		return c.createRoleBinding(ctx, user, projectName)
+	}
+
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil {
+		klog.Errorf("Error checking the access window of user %s: %v", user, err)
+		return err
+	}
+	if !open {
+		return c.revokeRoleBinding(ctx, user, projectName)
+	}
+	if err := c.createRoleBinding(ctx, user, projectName); err != nil {
+		return err
+	}
+	return c.restoreAccess(ctx, user, projectName)
+}
+
+// Deletes the RoleBinding of the target user while their access window is closed, marking their
+// namespace as revoked
+func (c *Controller) revokeRoleBinding(ctx context.Context, user string, projectName string) error {
+	name := roleBindingName(projectName)
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding exists for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	// never delete RoleBindings binding another user or not created by the controller
+	if !bindsUser(roleBinding, user) {
+		err := fmt.Errorf("RoleBinding %s under project %s does not bind user %s and will not be revoked", name, projectName, user)
+		klog.Error(err)
+		return err
+	}
+	if !isManaged(roleBinding) {
+		err := fmt.Errorf("RoleBinding %s under project %s is not managed by the controller and will not be revoked", name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	err = c.updateNamespace(ctx, projectName, func(namespace *corev1.Namespace) {
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string)
+		}
+		namespace.Annotations[accessRevokedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	})
+	if err != nil {
+		klog.Errorf("Error marking namespace %s of user %s as revoked: %v", projectName, user, err)
+		return err
+	}
+	if err := c.rbacClient.RoleBindings(projectName).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Revoked RoleBinding %s of user %s under project %s outside their access window", name, user, projectName)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, accessWindowClosedReason,
+		fmt.Sprintf("Revoked access of user %s to namespace %s outside their access window", user, projectName))
+	return nil
+}
+
+// Clears the revoked mark of the target user namespace once their RoleBinding is restored
+func (c *Controller) restoreAccess(ctx context.Context, user string, projectName string) error {
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		// the project may not be backed by a namespace yet
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if _, revoked := namespace.Annotations[accessRevokedAnnotation]; !revoked {
+		return nil
+	}
+
+	err = c.updateNamespace(ctx, projectName, func(namespace *corev1.Namespace) {
+		delete(namespace.Annotations, accessRevokedAnnotation)
+	})
+	if err != nil {
+		klog.Errorf("Error clearing the revoked mark of namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Restored access of user %s to project %s as their access window opened", user, projectName)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, accessWindowOpenedReason,
+		fmt.Sprintf("Restored access of user %s to namespace %s as their access window opened", user, projectName))
+	return nil
+}
+
+// Re-syncs the RoleBindings of every managed user when the access window of a target group opens or
+// closes, so access follows the windows without any change to the groups
+func (c *Controller) syncAccessWindows(ctx context.Context) {
+	windows, err := GetAccessWindows()
+	if err != nil {
+		klog.Errorf("Error reading access windows: %v", err)
+		return
+	}
+
+	now := time.Now()
+	changed := false
+	c.mu.Lock()
+	if c.accessWindowStates == nil {
+		c.accessWindowStates = make(map[string]bool, len(windows))
+	}
+	for group, groupWindows := range windows {
+		open := false
+		for _, window := range groupWindows {
+			open = open || window.Contains(now)
+		}
+		if previous, ok := c.accessWindowStates[group]; !ok || previous != open {
+			klog.Infof("Access window of group %s is open: %t", group, open)
+			c.accessWindowStates[group] = open
+			changed = true
+		}
+	}
+	c.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping RoleBinding sync of managed users as access windows opened or closed")
+		return
+	}
+	managed, err := c.ManagedUsers(ctx)
+	if err != nil {
+		klog.Errorf("Error listing managed users to sync access windows: %v", err)
+		return
+	}
+	for user := range managed {
+		_ = c.syncRoleBinding(ctx, user, c.ProjectName(user))
+		_ = c.syncAdditionalRoleBindings(ctx, user, c.ProjectName(user))
+		if GetDelegatesEnabled() {
+			_ = c.syncDelegatesRoleBinding(ctx, user, c.ProjectName(user))
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseAccessWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		at          string
+		contains    bool
+		shouldError bool
+	}{
+		{name: "first day", value: "2026-03-01/2026-04-30", at: "2026-03-01T00:00:00Z", contains: true},
+		{name: "last day is inclusive", value: "2026-03-01/2026-04-30", at: "2026-04-30T23:59:59Z", contains: true},
+		{name: "after the window", value: "2026-03-01/2026-04-30", at: "2026-05-01T00:00:00Z"},
+		{name: "before the window", value: "2026-03-01/2026-04-30", at: "2026-02-28T23:59:59Z"},
+		{name: "single day", value: "2026-03-01/2026-03-01", at: "2026-03-01T12:00:00Z", contains: true},
+		{name: "missing end", value: "2026-03-01", shouldError: true},
+		{name: "invalid date", value: "2026-03-01/April", shouldError: true},
+		{name: "end before start", value: "2026-04-30/2026-03-01", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := parseAccessWindow(tt.value)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected window %q to be rejected", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected window %q to be parsed, but got error: %v", tt.value, err)
+			}
+			at, _ := time.Parse(time.RFC3339, tt.at)
+			if got := window.Contains(at); got != tt.contains {
+				t.Errorf("Expected window %q to contain %s: %t, but got %t", tt.value, tt.at, tt.contains, got)
+			}
+		})
+	}
+}
+
+func TestController_syncAccessWindows(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort,staff")
+	// The cohort window is over while staff has no window
+	t.Setenv("ACCESS_WINDOWS", "cohort=2020-03-01/2020-04-30")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice"}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bob"}},
+	)
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(newGroup("cohort", "alice"), newGroup("staff", "bob")),
+		projectClient: projectfake.NewSimpleClientset(
+			&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+			&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{ownerLabel: "bob"}}},
+		),
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := kubeClient.RbacV1().RoleBindings(user).Create(ctx, desiredRoleBinding(user, user, "edit"), metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create RoleBinding of %s: %v", user, err)
+		}
+	}
+	roleBindingExists := func(user string) bool {
+		_, err := kubeClient.RbacV1().RoleBindings(user).Get(ctx, roleBindingName(user), metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatalf("Failed to get RoleBinding of %s: %v", user, err)
+		}
+		return err == nil
+	}
+	revoked := func(user string) bool {
+		namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, user, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get namespace %s: %v", user, err)
+		}
+		_, ok := namespace.Annotations[accessRevokedAnnotation]
+		return ok
+	}
+
+	// Access is revoked outside the window, keeping the namespace
+	controller.syncAccessWindows(ctx)
+	if roleBindingExists("alice") || !revoked("alice") {
+		t.Errorf("Expected the access of alice to be revoked outside the cohort window")
+	}
+	if !roleBindingExists("bob") || revoked("bob") {
+		t.Errorf("Expected bob to keep access without an access window")
+	}
+	if drift, err := controller.namespaceDrift(ctx, "alice", "alice", nil); err != nil || len(drift) != 0 {
+		t.Errorf("Expected no drift for a revoked namespace, but got %v, %v", drift, err)
+	}
+
+	// Access is restored once the window opens
+	t.Setenv("ACCESS_WINDOWS", "cohort=2020-03-01/2999-04-30")
+	controller.syncAccessWindows(ctx)
+	if !roleBindingExists("alice") || revoked("alice") {
+		t.Errorf("Expected the access of alice to be restored within the cohort window")
+	}
+}
+
+func TestController_accessWindowOpenAnyGroup(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort,mentors,staff")
+	// The cohort window is over while the mentors window is open and staff has no window
+	t.Setenv("ACCESS_WINDOWS", "cohort=2020-03-01/2020-04-30,mentors=2020-03-01/2999-04-30")
+
+	ctx := context.Background()
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(
+			newGroup("cohort", "alice", "bob", "carol"),
+			newGroup("mentors", "bob"),
+			newGroup("staff", "carol"),
+		),
+	}
+
+	for user, expected := range map[string]bool{"alice": false, "bob": true, "carol": true} {
+		open, err := controller.accessWindowOpen(ctx, user, time.Now())
+		if err != nil {
+			t.Fatalf("Expected the access window of %s to be checked, but got error: %v", user, err)
+		}
+		if open != expected {
+			t.Errorf("Expected the access window of %s to be open: %t, but got %t", user, expected, open)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/klog/v2"
+)
+
+// AdditionalRoleBinding is a RoleBinding granting the owner of every managed namespace another
+// ClusterRole besides USER_CLUSTER_ROLE
+type AdditionalRoleBinding struct {
+	// Suffix names the RoleBinding "<namespace>-<suffix>"
+	Suffix string
+	// ClusterRole is the ClusterRole granted to the owner
+	ClusterRole string
+}
+
+// suffixes of the RoleBindings the controller already creates under managed namespaces
+var reservedRoleBindingSuffixes = map[string]bool{
+	"edit":       true,
+	"delegates":  true,
+	"ops":        true,
+	"peers":      true,
+	"automation": true,
+}
+
+// Returns the name of the additional RoleBinding with the suffix under the namespace
+func additionalRoleBindingName(projectName string, suffix string) string {
+	return fmt.Sprintf("%s-%s", projectName, suffix)
+}
+
+// Returns the additional RoleBinding granting the target user its ClusterRole under the project
+func desiredAdditionalRoleBinding(user string, projectName string, binding AdditionalRoleBinding) *rbacv1.RoleBinding {
+	roleBinding := desiredRoleBinding(user, projectName, binding.ClusterRole)
+	roleBinding.Name = additionalRoleBindingName(projectName, binding.Suffix)
+	return roleBinding
+}
+
+// Grants the target user the ClusterRoles of ADDITIONAL_ROLE_BINDINGS under their project while their
+// access window is open, restoring the RoleBindings when modified, and deletes them while it is closed
+func (c *Controller) syncAdditionalRoleBindings(ctx context.Context, user string, projectName string) error {
+	bindings, err := GetAdditionalRoleBindings()
+	if err != nil {
+		return err
+	}
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil {
+		klog.Errorf("Error checking the access window of user %s: %v", user, err)
+		return err
+	}
+	for _, binding := range bindings {
+		if !open {
+			err = c.deleteManagedRoleBinding(ctx, user, projectName, additionalRoleBindingName(projectName, binding.Suffix))
+		} else {
+			err = c.syncManagedRoleBinding(ctx, user, projectName, desiredAdditionalRoleBinding(user, projectName, binding))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deletes the additional RoleBindings of the target user under their project, keeping ones not created
+// by the controller
+func (c *Controller) deleteAdditionalRoleBindings(ctx context.Context, user string, projectName string) error {
+	bindings, err := GetAdditionalRoleBindings()
+	if err != nil {
+		return err
+	}
+	for _, binding := range bindings {
+		if err := c.deleteManagedRoleBinding(ctx, user, projectName, additionalRoleBindingName(projectName, binding.Suffix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the drift of the additional RoleBindings of the target user under their project
+func (c *Controller) additionalRoleBindingsDrift(ctx context.Context, user string, projectName string) ([]string, error) {
+	bindings, err := GetAdditionalRoleBindings()
+	if err != nil {
+		return nil, err
+	}
+	var drift []string
+	for _, binding := range bindings {
+		bindingDrift, err := c.managedRoleBindingDrift(ctx, desiredAdditionalRoleBinding(user, projectName, binding))
+		if err != nil {
+			return nil, err
+		}
+		if bindingDrift != "" {
+			drift = append(drift, bindingDrift)
+		}
+	}
+	return drift, nil
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncAdditionalRoleBindings(t *testing.T) {
+	t.Setenv("ADDITIONAL_ROLE_BINDINGS", "monitoring=monitoring-rules-view,pipelines=pipeline-runner")
+	t.Setenv("PROJECT_DELETION_POLICY", ProjectDeletionRetain)
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	// Every configured RoleBinding grants alice its ClusterRole besides edit
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	expected := map[string]string{
+		"alice-edit":       "edit",
+		"alice-monitoring": "monitoring-rules-view",
+		"alice-pipelines":  "pipeline-runner",
+	}
+	for name, clusterRole := range expected {
+		roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected RoleBinding %s, but got error: %v", name, err)
+		}
+		if roleBinding.RoleRef.Name != clusterRole {
+			t.Errorf("Expected RoleBinding %s to grant %s, but got %s", name, clusterRole, roleBinding.RoleRef.Name)
+		}
+		if !bindsUser(roleBinding, "alice") || len(roleBinding.Subjects) != 1 {
+			t.Errorf("Expected RoleBinding %s to bind only alice, but got %+v", name, roleBinding.Subjects)
+		}
+	}
+
+	// A deleted RoleBinding is reported and restored by the resync
+	if err := kubeClient.RbacV1().RoleBindings("alice").Delete(ctx, "alice-pipelines", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete RoleBinding: %v", err)
+	}
+	drift, err := controller.additionalRoleBindingsDrift(ctx, "alice", "alice")
+	if err != nil || len(drift) != 1 {
+		t.Errorf("Expected the missing RoleBinding to be reported as drift, but got %v, %v", drift, err)
+	}
+	controller.resyncRoleBindings(ctx)
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, "alice-pipelines", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected RoleBinding alice-pipelines to be restored, but got error: %v", err)
+	}
+
+	// The retained project of a removed user keeps none of their RoleBindings
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	for name := range expected {
+		if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected RoleBinding %s to be deleted, but got error: %v", name, err)
+		}
+	}
+}
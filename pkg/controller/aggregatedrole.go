@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// ClusterRoles shipped with the cluster, which the aggregated ClusterRole must never replace
+var builtinClusterRoles = map[string]bool{
+	"admin":         true,
+	"edit":          true,
+	"view":          true,
+	"cluster-admin": true,
+}
+
+// Returns the aggregated ClusterRole granted to users, whose rules are filled by the API server from
+// the ClusterRoles matching the configured selectors
+func desiredAggregatedClusterRole(name string) (*rbacv1.ClusterRole, error) {
+	selectors, err := GetAggregatedClusterRoleSelectors()
+	if err != nil {
+		return nil, err
+	}
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				partOfLabel: seededSet,
+			},
+		},
+		AggregationRule: &rbacv1.AggregationRule{
+			ClusterRoleSelectors: selectors,
+		},
+	}, nil
+}
+
+// Creates the aggregated ClusterRole granted to users when configured, and restores its selectors
+// when they were modified. Its rules are left to the API server, so changes to the aggregated
+// ClusterRoles reach every managed RoleBinding without updating them.
+func (c *Controller) syncAggregatedClusterRole(ctx context.Context) {
+	if err := c.ensureAggregatedClusterRole(ctx); err != nil {
+		klog.Errorf("Error syncing aggregated ClusterRole %s: %v", GetAggregatedClusterRole(), err)
+	}
+}
+
+// Creates or updates the aggregated ClusterRole, refusing to overwrite a ClusterRole it did not create
+func (c *Controller) ensureAggregatedClusterRole(ctx context.Context) error {
+	name := GetAggregatedClusterRole()
+	if name == "" {
+		return nil
+	}
+	desired, err := desiredAggregatedClusterRole(name)
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.rbacClient.ClusterRoles().Get(ctx, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if GetDryRunEnabled() {
+			klog.Infof("Dry run: skipping creation of aggregated ClusterRole %s", name)
+			return nil
+		}
+		if _, err := c.rbacClient.ClusterRoles().Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			// creating a ClusterRole with an aggregation rule requires escalate on every ClusterRole
+			if errors.IsForbidden(err) {
+				return fmt.Errorf("ClusterRole %s does not exist and can't be created by the controller, create it as in deploy/aggregated-clusterrole.yaml: %w", name, err)
+			}
+			return err
+		}
+		klog.Infof("Created aggregated ClusterRole %s", name)
+		return nil
+	}
+	if errors.IsForbidden(err) {
+		return fmt.Errorf("ClusterRole %s is not in the resourceNames the controller may get, update, bind and escalate, see deploy/aggregated-clusterrole.yaml: %w", name, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.Labels[partOfLabel] != seededSet {
+		return fmt.Errorf("ClusterRole %s exists and is not managed by the provisioner", name)
+	}
+	if equality.Semantic.DeepEqual(existing.AggregationRule, desired.AggregationRule) {
+		return nil
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping restore of the selectors of aggregated ClusterRole %s", name)
+		return nil
+	}
+	existing.AggregationRule = desired.AggregationRule
+	if _, err := c.rbacClient.ClusterRoles().Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("Updated selectors of aggregated ClusterRole %s", name)
+	return nil
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGetAggregatedClusterRoleSelectors(t *testing.T) {
+	t.Setenv("AGGREGATED_CLUSTER_ROLE_SELECTORS", "rbac.authorization.k8s.io/aggregate-to-edit=true; sandbox/tier in (gpu,standard)")
+	selectors, err := GetAggregatedClusterRoleSelectors()
+	if err != nil {
+		t.Fatalf("Expected selectors to be parsed, but got error: %v", err)
+	}
+	if len(selectors) != 2 || selectors[1].MatchExpressions[0].Key != "sandbox/tier" || len(selectors[1].MatchExpressions[0].Values) != 2 {
+		t.Errorf("Expected two selectors keeping the commas of the second, but got %v", selectors)
+	}
+
+	t.Setenv("AGGREGATED_CLUSTER_ROLE_SELECTORS", "")
+	if _, err := GetAggregatedClusterRoleSelectors(); err == nil {
+		t.Errorf("Expected an empty selector list to be rejected")
+	}
+}
+
+func TestController_ensureAggregatedClusterRole(t *testing.T) {
+	t.Setenv("AGGREGATED_CLUSTER_ROLE", "sandbox-user")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged"}})
+	controller := &Controller{rbacClient: kubeClient.RbacV1()}
+
+	if role := GetUserClusterRole(); role != "sandbox-user" {
+		t.Errorf("Expected users to be granted the aggregated ClusterRole, but got %s", role)
+	}
+	if err := controller.ensureAggregatedClusterRole(ctx); err != nil {
+		t.Fatalf("Expected ClusterRole to be created, but got error: %v", err)
+	}
+	role, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, "sandbox-user", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ClusterRole sandbox-user to exist, but got error: %v", err)
+	}
+	if role.AggregationRule == nil || len(role.AggregationRule.ClusterRoleSelectors) != 2 {
+		t.Fatalf("Expected the default selectors to be aggregated, but got %v", role.AggregationRule)
+	}
+
+	// Modified selectors are restored, keeping the rules filled by the API server
+	role.AggregationRule.ClusterRoleSelectors = nil
+	role.Rules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+	if _, err := kubeClient.RbacV1().ClusterRoles().Update(ctx, role, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ClusterRole sandbox-user: %v", err)
+	}
+	if err := controller.ensureAggregatedClusterRole(ctx); err != nil {
+		t.Fatalf("Expected ClusterRole to be restored, but got error: %v", err)
+	}
+	role, err = kubeClient.RbacV1().ClusterRoles().Get(ctx, "sandbox-user", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ClusterRole sandbox-user to exist, but got error: %v", err)
+	}
+	if len(role.AggregationRule.ClusterRoleSelectors) != 2 || len(role.Rules) != 1 {
+		t.Errorf("Expected selectors to be restored and rules kept, but got %v and %v", role.AggregationRule, role.Rules)
+	}
+
+	// ClusterRoles which were not created by the provisioner are never overwritten
+	t.Setenv("AGGREGATED_CLUSTER_ROLE", "unmanaged")
+	if err := controller.ensureAggregatedClusterRole(ctx); err == nil {
+		t.Errorf("Expected the unmanaged ClusterRole to be kept")
+	}
+}
+
+func TestController_ensureAggregatedClusterRoleForbidden(t *testing.T) {
+	t.Setenv("AGGREGATED_CLUSTER_ROLE", "sandbox-user")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	kubeClient.PrependReactor("create", "clusterroles", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "clusterroles"}, "sandbox-user", errors.New("escalate required"))
+	})
+	controller := &Controller{rbacClient: kubeClient.RbacV1()}
+
+	err := controller.ensureAggregatedClusterRole(ctx)
+	if err == nil || !strings.Contains(err.Error(), "deploy/aggregated-clusterrole.yaml") || !apierrors.IsForbidden(err) {
+		t.Errorf("Expected the missing ClusterRole to point at its manifest, but got %v", err)
+	}
+}
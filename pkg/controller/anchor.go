@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// name of the ConfigMap anchoring the objects seeded into a user namespace
+const anchorConfigMapName = "rosa-namespace-provisioner-anchor"
+
+// Creates the anchor ConfigMap owning the objects seeded into the target user project
+func (c *Controller) createAnchor(ctx context.Context, user string, projectName string) error {
+	anchor := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      anchorConfigMapName,
+			Namespace: projectName,
+			Labels: map[string]string{
+				ownerLabel: ownerLabelValue(user),
+			},
+		},
+	}
+
+	_, err := c.coreClient.ConfigMaps(projectName).Get(ctx, anchor.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.coreClient.ConfigMaps(projectName).Patch, anchor, corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+			} else {
+				_, err = c.coreClient.ConfigMaps(projectName).Create(ctx, anchor, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating anchor ConfigMap for user %s under project %s: %v", user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created anchor ConfigMap %s for user %s under project %s", anchor.Name, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if anchor ConfigMap exists for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	klog.V(2).Infof("Anchor ConfigMap %s already exists for user %s under project %s", anchor.Name, user, projectName)
+	return nil
+}
+
+// Returns the owner reference to the anchor of the target project, or nil when owner references
+// are disabled
+func (c *Controller) anchorReference(ctx context.Context, projectName string) (*metav1.OwnerReference, error) {
+	if !GetOwnerReferencesEnabled() {
+		return nil, nil
+	}
+
+	anchor, err := c.coreClient.ConfigMaps(projectName).Get(ctx, anchorConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	controller := true
+	return &metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Name:       anchor.Name,
+		UID:        anchor.UID,
+		Controller: &controller,
+	}, nil
+}
+
+// Adds the anchor owner reference to the object, returning whether it was missing
+func setAnchorReference(obj metav1.Object, ref *metav1.OwnerReference) bool {
+	if ref == nil || ownedByAnchor(obj, ref) {
+		return false
+	}
+	obj.SetOwnerReferences(append(obj.GetOwnerReferences(), *ref))
+	return true
+}
+
+// Removes the anchor owner reference from the object, returning whether it was set. Policy objects such as
+// ResourceQuotas, LimitRanges, NetworkPolicies and NetworkQoSes are never owned by the anchor: users with
+// edit rights may delete the anchor, and garbage collection would then remove the policies confining them.
+func releaseAnchorReference(obj metav1.Object) bool {
+	var ownerRefs []metav1.OwnerReference
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.Kind != "ConfigMap" || ownerRef.Name != anchorConfigMapName {
+			ownerRefs = append(ownerRefs, ownerRef)
+		}
+	}
+	if len(ownerRefs) == len(obj.GetOwnerReferences()) {
+		return false
+	}
+	obj.SetOwnerReferences(ownerRefs)
+	return true
+}
+
+// Returns whether the object is owned by the anchor
+func ownedByAnchor(obj metav1.Object, ref *metav1.OwnerReference) bool {
+	for _, ownerRef := range obj.GetOwnerReferences() {
+		if ownerRef.UID == ref.UID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_anchorReference(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	ref, err := controller.anchorReference(ctx, "alice")
+	if err != nil || ref != nil {
+		t.Errorf("Expected no anchor reference while disabled, but got %v (%v)", ref, err)
+	}
+
+	t.Setenv("OWNER_REFERENCES_ENABLED", "true")
+	if err := controller.createAnchor(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected anchor to be created, but got error: %v", err)
+	}
+	// Creating the anchor a second time should be a no-op
+	if err := controller.createAnchor(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected existing anchor to be accepted, but got error: %v", err)
+	}
+
+	ref, err = controller.anchorReference(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected anchor reference, but got error: %v", err)
+	}
+	if ref.Kind != "ConfigMap" || ref.Name != anchorConfigMapName {
+		t.Errorf("Expected reference to ConfigMap %s, but got %+v", anchorConfigMapName, ref)
+	}
+}
+
+func TestSetAnchorReference(t *testing.T) {
+	ref := &metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: anchorConfigMapName, UID: types.UID("anchor-uid")}
+	secret := &corev1.Secret{}
+
+	if !setAnchorReference(secret, ref) {
+		t.Errorf("Expected missing anchor reference to be added")
+	}
+	if setAnchorReference(secret, ref) {
+		t.Errorf("Expected existing anchor reference not to be added again")
+	}
+	if len(secret.OwnerReferences) != 1 || !ownedByAnchor(secret, ref) {
+		t.Errorf("Expected Secret to be owned by the anchor, but got %+v", secret.OwnerReferences)
+	}
+	if setAnchorReference(secret, nil) {
+		t.Errorf("Expected no change without an anchor reference")
+	}
+}
+
+func TestController_createRoleBindingAdoptsExisting(t *testing.T) {
+	t.Setenv("OWNER_REFERENCES_ENABLED", "true")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: anchorConfigMapName, Namespace: "alice", UID: types.UID("anchor-uid")},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice-edit", Namespace: "alice"},
+			Subjects:   []rbacv1.Subject{{Kind: "User", Name: "alice"}},
+		},
+	)
+	controller := &Controller{
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.createRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected RoleBinding to be adopted, but got error: %v", err)
+	}
+
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, "alice-edit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected RoleBinding alice-edit to be found, but got error: %v", err)
+	}
+	if len(roleBinding.OwnerReferences) != 1 || roleBinding.OwnerReferences[0].UID != "anchor-uid" {
+		t.Errorf("Expected RoleBinding to be owned by the anchor, but got %+v", roleBinding.OwnerReferences)
+	}
+}
+
+func TestController_syncResourceQuotaReleasesAnchor(t *testing.T) {
+	t.Setenv("OWNER_REFERENCES_ENABLED", "true")
+
+	ctx := context.Background()
+	quota := desiredLoadBalancerQuota("alice", "alice")
+	existing := quota.DeepCopy()
+	existing.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "v1", Kind: "ConfigMap", Name: anchorConfigMapName, UID: types.UID("anchor-uid")},
+	}
+	kubeClient := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: anchorConfigMapName, Namespace: "alice", UID: types.UID("anchor-uid")},
+		},
+		existing,
+	)
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.syncResourceQuota(ctx, "alice", "alice", quota); err != nil {
+		t.Fatalf("Expected ResourceQuota to be synced, but got error: %v", err)
+	}
+
+	updated, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, quota.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota %s to be found, but got error: %v", quota.Name, err)
+	}
+	if len(updated.OwnerReferences) != 0 {
+		t.Errorf("Expected ResourceQuota not to be owned by the anchor, but got %+v", updated.OwnerReferences)
+	}
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation on the target group acknowledging an anomalous drop in its membership whenever its
+// value changes, e.g. set to the current timestamp
+const acknowledgeAnomalyAnnotation = "rosa-namespace-provisioner/acknowledge-anomaly"
+
+// reason of the Event recorded on a target group whose membership dropped anomalously
+const groupAnomalyReason = "MembershipDropped"
+
+// ErrNoGroupAnomaly is returned when acknowledging a group without an anomalous membership drop
+var ErrNoGroupAnomaly = errors.New("no membership anomaly")
+
+// ErrAnomalyChanged is returned when acknowledging a membership anomaly with the confirmation token of
+// an earlier state, e.g. before more users were held
+var ErrAnomalyChanged = errors.New("membership anomaly changed")
+
+// GroupAnomaly is an anomalous drop in the membership of a target group. Users removed from the
+// group are held, keeping their namespaces, until an admin acknowledges the drop.
+type GroupAnomaly struct {
+	Group        string    `json:"group"`
+	PreviousSize int       `json:"previousSize"`
+	Size         int       `json:"size"`
+	Since        time.Time `json:"since"`
+	HeldUsers    []string  `json:"heldUsers"`
+	// Token confirms the acknowledgement of the anomaly as listed, changing whenever more users are held
+	Token string `json:"token"`
+}
+
+// Returns the confirmation token of the anomaly in its current state
+func (a *GroupAnomaly) confirmationToken() string {
+	sum := sha256.Sum256([]byte(a.Group + "\n" + a.Since.UTC().Format(time.RFC3339Nano) + "\n" + strings.Join(a.HeldUsers, ",")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Records the size of a target group and returns the removed users which may be deprovisioned. While
+// the group has an unacknowledged anomaly, every removed user is held instead.
+func (c *Controller) checkGroupAnomaly(group *userv1.Group, previousSize int, size int, removedUsers []string) []string {
+	metrics.GroupMembers.WithLabelValues(group.Name).Set(float64(size))
+	if !GetGroupAnomalyDetectionEnabled() {
+		return removedUsers
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.groupSizes == nil {
+		c.groupSizes = make(map[string]int)
+	}
+	if last, ok := c.groupSizes[group.Name]; ok {
+		previousSize = last
+	}
+	c.groupSizes[group.Name] = size
+
+	anomaly, ok := c.groupAnomalies[group.Name]
+	if !ok && anomalousDrop(previousSize, size) {
+		anomaly = &GroupAnomaly{
+			Group:        group.Name,
+			PreviousSize: previousSize,
+			Since:        time.Now(),
+		}
+		if c.groupAnomalies == nil {
+			c.groupAnomalies = make(map[string]*GroupAnomaly)
+		}
+		c.groupAnomalies[group.Name] = anomaly
+		metrics.GroupMembershipAnomaly.WithLabelValues(group.Name).Set(1)
+
+		message := fmt.Sprintf("Membership dropped from %d to %d users in one update, deprovisioning is paused until an admin acknowledges it by annotating the group %s",
+			previousSize,
+			size,
+			acknowledgeAnomalyAnnotation,
+		)
+		klog.Warningf("Detected anomaly in group %s: %s", group.Name, message)
+		if c.recorder != nil {
+			c.recorder.Event(group, corev1.EventTypeWarning, groupAnomalyReason, message)
+		}
+	}
+	if anomaly == nil {
+		return removedUsers
+	}
+
+	anomaly.Size = size
+	if len(removedUsers) > 0 {
+		anomaly.HeldUsers = mergeUsers(anomaly.HeldUsers, removedUsers)
+		klog.Warningf("Holding deprovisioning of users removed from group %s until its anomaly is acknowledged: %v", group.Name, removedUsers)
+	}
+	return nil
+}
+
+// Returns whether a change in the size of a group drops more of its members than the threshold
+// allows. Groups smaller than the minimum size are never flagged.
+func anomalousDrop(previousSize int, size int) bool {
+	if int64(previousSize) < GetGroupAnomalyMinSize() || size >= previousSize {
+		return false
+	}
+	return float64(previousSize-size) > float64(previousSize)*GetGroupAnomalyThreshold()
+}
+
+// Returns the sorted union of two lists of users
+func mergeUsers(users []string, more []string) []string {
+	set := make(map[string]bool, len(users)+len(more))
+	for _, user := range users {
+		set[user] = true
+	}
+	for _, user := range more {
+		set[user] = true
+	}
+	merged := make([]string, 0, len(set))
+	for user := range set {
+		merged = append(merged, user)
+	}
+	sort.Strings(merged)
+	return merged
+}
+
+// GroupAnomalies returns the unacknowledged membership anomalies of the target groups, sorted by group
+func (c *Controller) GroupAnomalies(ctx context.Context) ([]GroupAnomaly, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	anomalies := make([]GroupAnomaly, 0, len(c.groupAnomalies))
+	for _, anomaly := range c.groupAnomalies {
+		listed := *anomaly
+		listed.Token = anomaly.confirmationToken()
+		anomalies = append(anomalies, listed)
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].Group < anomalies[j].Group
+	})
+	return anomalies, nil
+}
+
+// AcknowledgeGroupAnomaly resumes deprovisioning for a target group whose membership dropped
+// anomalously, deprovisioning the held users who are still not members of any target group. Unless
+// empty, the token must be the confirmation token of the anomaly as listed by GroupAnomalies. It
+// returns ErrNoGroupAnomaly if the group has no anomaly, and ErrAnomalyChanged if the token doesn't
+// match.
+func (c *Controller) AcknowledgeGroupAnomaly(ctx context.Context, group string, token string) error {
+	c.mu.Lock()
+	anomaly, ok := c.groupAnomalies[group]
+	if ok && token != "" && token != anomaly.confirmationToken() {
+		c.mu.Unlock()
+		return fmt.Errorf("%w for group %s, list the anomalies again to review the held users", ErrAnomalyChanged, group)
+	}
+	delete(c.groupAnomalies, group)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w for group %s", ErrNoGroupAnomaly, group)
+	}
+	metrics.GroupMembershipAnomaly.WithLabelValues(group).Set(0)
+	klog.Infof("Anomaly in group %s acknowledged, resuming deprovisioning of %d held users", group, len(anomaly.HeldUsers))
+	ctx = withDefaultTrigger(ctx, TriggerAdmin)
+
+	if len(anomaly.HeldUsers) == 0 {
+		return nil
+	}
+	members, err := c.Members(ctx)
+	if err != nil {
+		// Hold the users again rather than remove users who may have come back
+		c.mu.Lock()
+		c.groupAnomalies[group] = anomaly
+		c.mu.Unlock()
+		metrics.GroupMembershipAnomaly.WithLabelValues(group).Set(1)
+		return fmt.Errorf("failed to get members of %s: %w", membershipDescription(), err)
+	}
+	for _, user := range anomaly.HeldUsers {
+		if members[user] {
+			klog.Infof("Keeping namespace of held user %s who is granted a namespace by %s again", user, membershipDescription())
+			continue
+		}
+		_ = c.deprovisionUser(ctx, user)
+	}
+	return nil
+}
+
+// Acknowledges the anomaly of the target group when the acknowledge annotation changed
+func (c *Controller) handleAnomalyAcknowledgement(oldGroup, newGroup *userv1.Group) {
+	value := newGroup.Annotations[acknowledgeAnomalyAnnotation]
+	if value == "" || value == oldGroup.Annotations[acknowledgeAnomalyAnnotation] {
+		return
+	}
+	// updating the group is authorized by the API server, which confirms the acknowledgement
+	err := c.AcknowledgeGroupAnomaly(withTrigger(context.Background(), TriggerAdmin), newGroup.Name, "")
+	if errors.Is(err, ErrNoGroupAnomaly) {
+		klog.V(2).Infof("Ignoring %s=%s on group %s without an anomaly", acknowledgeAnomalyAnnotation, value, newGroup.Name)
+	} else if err != nil {
+		klog.Errorf("Error acknowledging anomaly in group %s: %v", newGroup.Name, err)
+	}
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAnomalousDrop(t *testing.T) {
+	t.Setenv("GROUP_ANOMALY_THRESHOLD", "50")
+	t.Setenv("GROUP_ANOMALY_MIN_SIZE", "10")
+
+	tests := []struct {
+		name         string
+		previousSize int
+		size         int
+		expected     bool
+	}{
+		{name: "group emptied", previousSize: 40, size: 0, expected: true},
+		{name: "more than half removed", previousSize: 10, size: 4, expected: true},
+		{name: "half removed", previousSize: 10, size: 5, expected: false},
+		{name: "group grew", previousSize: 10, size: 20, expected: false},
+		{name: "small group emptied", previousSize: 9, size: 0, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := anomalousDrop(tt.previousSize, tt.size); got != tt.expected {
+				t.Errorf("Expected anomalousDrop(%d, %d) to be %v, but got %v", tt.previousSize, tt.size, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestController_handleGroupAnomaly(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+	t.Setenv("GROUP_ANOMALY_DETECTION_ENABLED", "true")
+	t.Setenv("GROUP_ANOMALY_MIN_SIZE", "3")
+
+	ctx := context.Background()
+	userClient := userfake.NewSimpleClientset()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userClient,
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	updateGroup := func(oldGroup, newGroup *userv1.Group) {
+		t.Helper()
+		if _, err := userClient.UserV1().Groups().Update(ctx, newGroup, metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Failed to update group test-group: %v", err)
+		}
+		controller.handleGroup(oldGroup, newGroup)
+	}
+
+	group := newGroup("test-group", "alice", "bob", "carol", "dave")
+	if _, err := userClient.UserV1().Groups().Create(ctx, group, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create group test-group: %v", err)
+	}
+	controller.handleGroup(nil, group)
+
+	// A removal within the threshold is applied at once
+	shrunk := newGroup("test-group", "alice", "bob", "carol")
+	updateGroup(group, shrunk)
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "dave", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project dave to be deleted")
+	}
+
+	// An IdP sync emptying the group holds every removal
+	emptied := newGroup("test-group")
+	updateGroup(shrunk, emptied)
+	for _, user := range []string{"alice", "bob", "carol"} {
+		if _, err := projectClient.ProjectV1().Projects().Get(ctx, user, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected project %s to be kept during the anomaly, but got error: %v", user, err)
+		}
+	}
+
+	// Removals stay held until the anomaly is acknowledged, while users coming back are kept
+	restored := newGroup("test-group", "alice")
+	updateGroup(emptied, restored)
+	anomalies, err := controller.GroupAnomalies(ctx)
+	if err != nil {
+		t.Fatalf("Expected group anomalies, but got error: %v", err)
+	}
+	expected := []GroupAnomaly{{Group: "test-group", PreviousSize: 3, Size: 1, HeldUsers: []string{"alice", "bob", "carol"}}}
+	var token string
+	if len(anomalies) == 1 {
+		token = anomalies[0].Token
+		anomalies[0].Since = expected[0].Since
+		anomalies[0].Token = ""
+	}
+	if !reflect.DeepEqual(anomalies, expected) {
+		t.Errorf("Expected anomalies %+v, but got %+v", expected, anomalies)
+	}
+	if token == "" {
+		t.Errorf("Expected the anomaly to be listed with a confirmation token")
+	}
+	if err := controller.AcknowledgeGroupAnomaly(ctx, "test-group", "stale"); !errors.Is(err, ErrAnomalyChanged) {
+		t.Errorf("Expected acknowledging with a stale token to fail with ErrAnomalyChanged, but got %v", err)
+	}
+
+	acknowledged := restored.DeepCopy()
+	acknowledged.Annotations = map[string]string{acknowledgeAnomalyAnnotation: "2026-10-16T10:00:00Z"}
+	updateGroup(restored, acknowledged)
+
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be kept after coming back, but got error: %v", err)
+	}
+	for _, user := range []string{"bob", "carol"} {
+		if _, err := projectClient.ProjectV1().Projects().Get(ctx, user, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected project %s to be deleted once the anomaly was acknowledged", user)
+		}
+	}
+	if err := controller.AcknowledgeGroupAnomaly(ctx, "test-group", token); !errors.Is(err, ErrNoGroupAnomaly) {
+		t.Errorf("Expected acknowledging again to fail with ErrNoGroupAnomaly, but got %v", err)
+	}
+}
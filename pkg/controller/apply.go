@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+)
+
+// patchFunc is the Patch method of a typed client
+type patchFunc[T any] func(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (T, error)
+
+// Creates or updates the object with server-side apply as FIELD_MANAGER, which then owns every field
+// set on the object. A field another manager set to another value is a conflict, unless
+// SERVER_SIDE_APPLY_FORCE takes it over.
+func applyObject[T any](ctx context.Context, patch patchFunc[T], obj runtime.Object, gvk schema.GroupVersionKind) (T, error) {
+	var applied T
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return applied, err
+	}
+	manifest, err := toManifest(obj.DeepCopyObject(), gvk)
+	if err != nil {
+		return applied, err
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return applied, err
+	}
+	return patch(ctx, accessor.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: GetFieldManager(),
+		Force:        ptr.To(GetServerSideApplyForce()),
+	})
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// Returns whether the object has fields applied by the controller
+func appliedBy(obj metav1.Object) bool {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == componentName && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return true
+		}
+	}
+	return false
+}
+
+func TestController_serverSideApply(t *testing.T) {
+	t.Setenv("OPS_GROUP_NAME", "platform-sre")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+		rbacClient: kubeClient.RbacV1(),
+	}
+	if err := controller.createLoadBalancerQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be applied, but got error: %v", err)
+	}
+	quota, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, loadBalancerQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", loadBalancerQuotaName, err)
+	}
+	if !appliedBy(quota) {
+		t.Errorf("Expected ResourceQuota %s to be applied by %s, but got managed fields %+v", loadBalancerQuotaName, componentName, quota.ManagedFields)
+	}
+
+	// A limit modified by another actor is taken back
+	quota.Spec.Hard[corev1.ResourceServicesLoadBalancers] = resource.MustParse("5")
+	if _, err := kubeClient.CoreV1().ResourceQuotas("alice").Update(ctx, quota, metav1.UpdateOptions{FieldManager: "kubectl-edit"}); err != nil {
+		t.Fatalf("Failed to update ResourceQuota: %v", err)
+	}
+	if err := controller.createLoadBalancerQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be applied again, but got error: %v", err)
+	}
+	drift, err := controller.resourceQuotaDrift(ctx, "alice", desiredLoadBalancerQuota("alice", "alice"))
+	if err != nil || drift != "" {
+		t.Errorf("Expected the modified limit to be restored, but got drift %q (error: %v)", drift, err)
+	}
+
+	// Without forcing, a limit modified by another actor is a conflict
+	t.Setenv("SERVER_SIDE_APPLY_FORCE", "false")
+	quota, err = kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, loadBalancerQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ResourceQuota: %v", err)
+	}
+	quota.Spec.Hard[corev1.ResourceServicesLoadBalancers] = resource.MustParse("5")
+	if _, err := kubeClient.CoreV1().ResourceQuotas("alice").Update(ctx, quota, metav1.UpdateOptions{FieldManager: "kubectl-edit"}); err != nil {
+		t.Fatalf("Failed to update ResourceQuota: %v", err)
+	}
+	if err := controller.createLoadBalancerQuota(ctx, "alice", "alice"); !apierrors.IsConflict(err) {
+		t.Errorf("Expected a conflict with the other field manager, but got error: %v", err)
+	}
+
+	if err := controller.syncOpsRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected RoleBinding to be applied, but got error: %v", err)
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, opsRoleBindingName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected RoleBinding %s to exist, but got error: %v", opsRoleBindingName("alice"), err)
+	}
+	if !appliedBy(roleBinding) || !isManaged(roleBinding) {
+		t.Errorf("Expected RoleBinding %s to be applied by %s with its labels, but got %+v", opsRoleBindingName("alice"), componentName, roleBinding.ObjectMeta)
+	}
+}
+
+func TestController_serverSideApplyProject(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "requests.cpu=4")
+	t.Setenv("SEED_NAMESPACE", "seed")
+	t.Setenv("SEED_SECRETS", "pull-secret")
+	t.Setenv("SEED_CONFIGMAPS", "ca-bundle")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "pull-secret", Namespace: "seed"}, Data: map[string][]byte{"token": []byte("secret")}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "seed"}, Data: map[string]string{"ca.crt": "bundle"}},
+	)
+	quotaClient := quotafake.NewClientset()
+	projectClient := projectfake.NewSimpleClientset()
+	projectClient.PrependReactor("create", "projectrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		request := action.(k8stesting.CreateAction).GetObject().(*projectv1.ProjectRequest)
+		project := &projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: request.Name}}
+		return true, project, projectClient.Tracker().Add(project)
+	})
+	controller := &Controller{
+		projectClient: projectClient,
+		quotaClient:   quotaClient,
+		coreClient:    kubeClient.CoreV1(),
+		rbacClient:    kubeClient.RbacV1(),
+	}
+
+	// The labels and annotations of created and requested projects are applied to their namespace
+	if err := controller.createUserProject(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected project alice to be created, but got error: %v", err)
+	}
+	t.Setenv("PROJECT_REQUEST_ENABLED", "true")
+	if err := controller.createUserProject(ctx, "bob", "bob"); err != nil {
+		t.Fatalf("Expected project bob to be requested, but got error: %v", err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, user, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected namespace %s to be applied, but got error: %v", user, err)
+		}
+		if !appliedBy(namespace) || objectOwner(namespace) != user || !isManaged(namespace) || namespace.Annotations[ownerAnnotation] != user {
+			t.Errorf("Expected namespace %s to be applied by %s with its labels, but got %+v", user, componentName, namespace.ObjectMeta)
+		}
+	}
+
+	if err := controller.createClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Fatalf("Expected ClusterResourceQuota to be applied, but got error: %v", err)
+	}
+	quota, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, clusterResourceQuotaName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ClusterResourceQuota %s to exist, but got error: %v", clusterResourceQuotaName("alice"), err)
+	}
+	if !appliedBy(quota) {
+		t.Errorf("Expected ClusterResourceQuota %s to be applied by %s, but got managed fields %+v", quota.Name, componentName, quota.ManagedFields)
+	}
+
+	// A modified copy of a seeded object is applied again
+	if err := controller.syncSeedResources(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected seed resources to be applied, but got error: %v", err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "pull-secret", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected seed Secret to be copied, but got error: %v", err)
+	}
+	if !appliedBy(secret) || secret.Labels[partOfLabel] != copiedSet {
+		t.Errorf("Expected seed Secret to be applied by %s with its labels, but got %+v", componentName, secret.ObjectMeta)
+	}
+	secret.Data["token"] = []byte("modified")
+	if _, err := kubeClient.CoreV1().Secrets("alice").Update(ctx, secret, metav1.UpdateOptions{FieldManager: "kubectl-edit"}); err != nil {
+		t.Fatalf("Failed to update Secret: %v", err)
+	}
+	if err := controller.syncSeedResources(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected seed resources to be applied again, but got error: %v", err)
+	}
+	if secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "pull-secret", metav1.GetOptions{}); err != nil || string(secret.Data["token"]) != "secret" {
+		t.Errorf("Expected the modified seed Secret to be restored, but got %v (error: %v)", secret, err)
+	}
+	configMap, err := kubeClient.CoreV1().ConfigMaps("alice").Get(ctx, "ca-bundle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected seed ConfigMap to be copied, but got error: %v", err)
+	}
+	if !appliedBy(configMap) || configMap.Data["ca.crt"] != "bundle" {
+		t.Errorf("Expected seed ConfigMap to be applied by %s, but got %+v", componentName, configMap)
+	}
+}
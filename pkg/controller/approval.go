@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// label set to "true" on a pending ManagedNamespace to approve provisioning its owner
+const approvedLabel = "rosa-namespace-provisioner/approved"
+
+// reason of the Approved condition of users waiting for approval
+const pendingApprovalReason = "PendingApproval"
+
+// ErrNoPendingApproval is returned when approving a user who is not waiting for approval
+var ErrNoPendingApproval = errors.New("no pending approval")
+
+// PendingApproval is a user waiting for an admin to approve provisioning their namespace
+type PendingApproval struct {
+	User      string    `json:"user"`
+	Namespace string    `json:"namespace"`
+	Since     time.Time `json:"since"`
+}
+
+// Returns the Approved condition of a ManagedNamespace
+func approvalCondition(generation int64, approved bool) metav1.Condition {
+	if approved {
+		return metav1.Condition{
+			Type:               v1alpha1.ConditionApproved,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "Approved",
+			Message:            "Provisioning was approved",
+		}
+	}
+	return metav1.Condition{
+		Type:               v1alpha1.ConditionApproved,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             pendingApprovalReason,
+		Message:            fmt.Sprintf("Waiting for an admin to approve, e.g. by labeling this record %s=true", approvedLabel),
+	}
+}
+
+// Returns whether the ManagedNamespace is waiting for approval
+func pendingApproval(managed *v1alpha1.ManagedNamespace) bool {
+	condition := meta.FindStatusCondition(managed.Status.Conditions, v1alpha1.ConditionApproved)
+	return condition != nil && condition.Status == metav1.ConditionFalse
+}
+
+// Returns whether provisioning the target user was approved, either explicitly through their
+// ManagedNamespace or implicitly by their project having been provisioned before
+func (c *Controller) approvalGranted(ctx context.Context, user string, projectName string) (bool, error) {
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err == nil && objectOwner(project) == user {
+		return true, nil
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return false, err
+	}
+
+	current, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return current.GetLabels()[approvedLabel] == "true", nil
+}
+
+// Records that the target user is waiting for approval on their ManagedNamespace
+func (c *Controller) requestApproval(ctx context.Context, user string, projectName string) error {
+	managed, err := c.ensureManagedNamespace(ctx, user, projectName)
+	if err != nil {
+		return err
+	}
+	if pendingApproval(managed) {
+		klog.V(2).Infof("User %s is still waiting for approval", user)
+		return nil
+	}
+
+	meta.SetStatusCondition(&managed.Status.Conditions, approvalCondition(managed.Generation, false))
+	if err := c.updateManagedNamespaceStatus(ctx, user, managed); err != nil {
+		return err
+	}
+	klog.Infof("Provisioning of user %s is pending approval on ManagedNamespace %s", user, projectName)
+	return nil
+}
+
+// Provisions the target user once provisioning was approved when approval is required, otherwise
+// records them as waiting for approval. Users not allowed or excluded are skipped.
+func (c *Controller) admitUser(ctx context.Context, user string) error {
+	if !userProvisionable(user) {
+		klog.V(2).Infof("Skipping provisioning of user %s, not allowed or excluded by the user filters", user)
+		c.clearPending(user, time.Time{})
+		return nil
+	}
+	if !GetApprovalRequired() || !GetManagedNamespacesEnabled() || c.dynamicClient == nil {
+		return c.provisionUser(ctx, user)
+	}
+
+	projectName := c.ProjectName(user)
+	approved, err := c.approvalGranted(ctx, user, projectName)
+	if err != nil {
+		klog.Errorf("Error checking if provisioning was approved for user %s: %v", user, err)
+		return err
+	}
+	if approved {
+		return c.provisionUser(ctx, user)
+	}
+
+	// Time waiting for an admin doesn't count towards the provisioning SLO
+	c.clearPending(user, time.Time{})
+	return c.requestApproval(ctx, user, projectName)
+}
+
+// Approve approves provisioning the target user by labeling their pending ManagedNamespace, which
+// the controller picks up to provision them
+func (c *Controller) Approve(ctx context.Context, user string) error {
+	projectName := c.ProjectName(user)
+	client := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource)
+
+	current, err := client.Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return fmt.Errorf("%w for user %s", ErrNoPendingApproval, user)
+		}
+		return err
+	}
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.Object, managed); err != nil {
+		return err
+	}
+	if !pendingApproval(managed) {
+		return fmt.Errorf("%w for user %s", ErrNoPendingApproval, user)
+	}
+
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:"true"}}}`, approvedLabel)
+	if _, err := client.Patch(ctx, projectName, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+		klog.Errorf("Error approving ManagedNamespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Approved provisioning of user %s", user)
+	return nil
+}
+
+// PendingApprovals returns the users waiting for approval, sorted by name
+func (c *Controller) PendingApprovals(ctx context.Context) ([]PendingApproval, error) {
+	list, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing ManagedNamespaces for pending approvals: %v", err)
+		return nil, err
+	}
+
+	pending := make([]PendingApproval, 0)
+	for _, item := range list.Items {
+		managed := &v1alpha1.ManagedNamespace{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, managed); err != nil {
+			return nil, err
+		}
+		if !pendingApproval(managed) || managed.Labels[approvedLabel] == "true" {
+			continue
+		}
+		condition := meta.FindStatusCondition(managed.Status.Conditions, v1alpha1.ConditionApproved)
+		pending = append(pending, PendingApproval{
+			User:      managed.Spec.Owner,
+			Namespace: managed.Name,
+			Since:     condition.LastTransitionTime.Time,
+		})
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].User < pending[j].User
+	})
+	return pending, nil
+}
+
+// Creates an informer watching approved ManagedNamespaces
+func newApprovalInformer(dynamicClient dynamic.Interface, watches *watchHealth) cache.SharedIndexInformer {
+	filterApproved := func(options *metav1.ListOptions) {
+		options.LabelSelector = approvedLabel + "=true"
+		tuneListOptions(options)
+	}
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			filterApproved(&options)
+			return dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			filterApproved(&options)
+			return dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Watch(ctx, options)
+		},
+	}
+
+	return watches.newInformer("approvals", listWatcher, &unstructured.Unstructured{}, GetInformerResyncPeriod())
+}
+
+// Provisions the owner of a ManagedNamespace that was approved while waiting for approval,
+// provided they are still a member of the group
+func (c *Controller) handleApproval(obj *unstructured.Unstructured) {
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, managed); err != nil {
+		klog.Errorf("Error decoding ManagedNamespace %s: %v", obj.GetName(), err)
+		return
+	}
+	if !pendingApproval(managed) || managed.DeletionTimestamp != nil {
+		return
+	}
+	user := managed.Spec.Owner
+
+	ctx := withTrigger(context.Background(), TriggerAdmin)
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s to provision approved user %s: %v", membershipDescription(), user, err)
+		return
+	}
+	if !members[user] {
+		klog.Warningf("Skipping approved user %s as they are not granted a namespace by %s", user, membershipDescription())
+		return
+	}
+
+	klog.Infof("Provisioning of user %s was approved", user)
+	c.markPending(user, time.Now())
+	_ = c.provisionUser(ctx, user)
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_approvalWorkflow(t *testing.T) {
+	t.Setenv("APPROVAL_REQUIRED", "true")
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	ctx := context.Background()
+	group := newGroup(GetTargetGroupName(), "alice")
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(group),
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+	}
+
+	// New members wait for approval instead of being provisioned
+	controller.handleGroup(nil, group)
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("Expected project alice not to be provisioned before approval, but got error: %v", err)
+	}
+	pending, err := controller.PendingApprovals(ctx)
+	if err != nil {
+		t.Fatalf("Expected pending approvals to be listed, but got error: %v", err)
+	}
+	if len(pending) != 1 || pending[0].User != "alice" || pending[0].Since.IsZero() {
+		t.Fatalf("Expected alice to be pending approval, but got %+v", pending)
+	}
+
+	if err := controller.Approve(ctx, "bob"); !errors.Is(err, ErrNoPendingApproval) {
+		t.Errorf("Expected approving bob to fail with no pending approval, but got error: %v", err)
+	}
+	if err := controller.Approve(ctx, "alice"); err != nil {
+		t.Fatalf("Expected alice to be approved, but got error: %v", err)
+	}
+
+	// The approved record is picked up by the approval informer
+	obj, err := controller.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ManagedNamespace alice to be found, but got error: %v", err)
+	}
+	controller.handleApproval(obj)
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be provisioned after approval, but got error: %v", err)
+	}
+	managed := getManagedNamespace(t, controller, "alice")
+	if !meta.IsStatusConditionTrue(managed.Status.Conditions, v1alpha1.ConditionApproved) {
+		t.Errorf("Expected ManagedNamespace alice to be approved, but got %+v", managed.Status.Conditions)
+	}
+	if pending, _ := controller.PendingApprovals(ctx); len(pending) != 0 {
+		t.Errorf("Expected no pending approvals, but got %+v", pending)
+	}
+
+	// Provisioned members don't need to be approved again, e.g. on restart
+	granted, err := controller.approvalGranted(ctx, "alice", "alice")
+	if err != nil || !granted {
+		t.Errorf("Expected provisioned user alice to be approved, but got %v with error: %v", granted, err)
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// label tagging managed namespaces with their owner for the cluster audit pipeline, unless other
+// labels are configured
+const auditTenantLabel = "rosa-namespace-provisioner/audit-tenant"
+
+// Returns the audit tenant labels a managed namespace of the target user must carry
+func desiredAuditLabels(user string) map[string]string {
+	labels := make(map[string]string)
+	for _, key := range GetAuditTenantLabels() {
+		labels[key] = ownerLabelValue(user)
+	}
+	return labels
+}
+
+// Tags the namespace of the target user project with the audit tenant labels, so audit events of
+// API requests in it can be attributed to the owning user
+func (c *Controller) addAuditLabels(ctx context.Context, user string, projectName string) error {
+	desired := desiredAuditLabels(user)
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	if len(missingAuditLabels(namespace, desired)) == 0 {
+		return nil
+	}
+
+	err = c.updateNamespace(ctx, projectName, func(namespace *corev1.Namespace) {
+		if namespace.Labels == nil {
+			namespace.Labels = make(map[string]string)
+		}
+		for key, value := range desired {
+			namespace.Labels[key] = value
+		}
+	})
+	if err != nil {
+		klog.Errorf("Error adding audit labels to namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Tagged namespace %s with audit tenant %s", projectName, user)
+	return nil
+}
+
+// Returns the audit tenant labels which are missing from a namespace or set to another value, sorted
+func missingAuditLabels(namespace *corev1.Namespace, desired map[string]string) []string {
+	var missing []string
+	for key, value := range desired {
+		if namespace.Labels[key] != value {
+			missing = append(missing, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
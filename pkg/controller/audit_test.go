@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_addAuditLabels(t *testing.T) {
+	t.Setenv("AUDIT_TENANT_LABELS", "audit.example.com/tenant,team.example.com/owner")
+
+	ctx := context.Background()
+	coreClient := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "alice",
+			Labels: map[string]string{
+				ownerLabel:                 "alice",
+				"audit.example.com/tenant": "mallory",
+			},
+		},
+	}).CoreV1()
+	controller := &Controller{
+		coreClient: coreClient,
+	}
+
+	namespace, err := coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace alice to be found, but got error: %v", err)
+	}
+	expectedMissing := []string{"audit.example.com/tenant=alice", "team.example.com/owner=alice"}
+	if missing := missingAuditLabels(namespace, desiredAuditLabels("alice")); !reflect.DeepEqual(missing, expectedMissing) {
+		t.Errorf("Expected missing audit labels %v, but got %v", expectedMissing, missing)
+	}
+
+	if err := controller.addAuditLabels(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected audit labels to be added, but got error: %v", err)
+	}
+
+	namespace, err = coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace alice to be found, but got error: %v", err)
+	}
+	expected := map[string]string{
+		ownerLabel:                 "alice",
+		"audit.example.com/tenant": "alice",
+		"team.example.com/owner":   "alice",
+	}
+	if !reflect.DeepEqual(namespace.Labels, expected) {
+		t.Errorf("Expected labels %v, but got %v", expected, namespace.Labels)
+	}
+	if missing := missingAuditLabels(namespace, desiredAuditLabels("alice")); len(missing) != 0 {
+		t.Errorf("Expected no missing audit labels, but got %v", missing)
+	}
+}
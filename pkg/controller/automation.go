@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// value of the partOfLabel for the automation ServiceAccount and its pull Secret, kept apart from the
+// seeded set so the secrets step doesn't prune the pull Secret
+const automationSet = "automation"
+
+// selects the automation ServiceAccount and its pull Secret
+var automationSelector = fmt.Sprintf("%s=%s", partOfLabel, automationSet)
+
+// Returns the labels of the automation ServiceAccount and its pull Secret
+func automationLabels(user string) map[string]string {
+	return map[string]string{
+		ownerLabel:  ownerLabelValue(user),
+		partOfLabel: automationSet,
+	}
+}
+
+// Returns the name of the RoleBinding granting the automation ServiceAccount access to the namespace
+func automationRoleBindingName(projectName string) string {
+	return fmt.Sprintf("%s-automation", projectName)
+}
+
+// Returns the automation ServiceAccount of the target user project, using the pull Secret if any
+func desiredAutomationServiceAccount(user string, projectName string, pullSecret string) *corev1.ServiceAccount {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetAutomationServiceAccount(),
+			Namespace: projectName,
+			Labels:    automationLabels(user),
+		},
+	}
+	if pullSecret != "" {
+		serviceAccount.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+	return serviceAccount
+}
+
+// Returns the RoleBinding granting the automation ServiceAccount AUTOMATION_CLUSTER_ROLE under the target
+// user project
+func desiredAutomationRoleBinding(user string, projectName string) *rbacv1.RoleBinding {
+	roleBinding := desiredRoleBinding(user, projectName, GetAutomationClusterRole())
+	roleBinding.Name = automationRoleBindingName(projectName)
+	roleBinding.Subjects = []rbacv1.Subject{{
+		Kind:      "ServiceAccount",
+		Name:      GetAutomationServiceAccount(),
+		Namespace: projectName,
+	}}
+	return roleBinding
+}
+
+// Returns the copy of AUTOMATION_PULL_SECRET for the target user project, or nil when it is unset
+func (c *Controller) desiredAutomationPullSecret(ctx context.Context, user string, projectName string) (*corev1.Secret, error) {
+	namespace, name, err := GetAutomationPullSecret()
+	if err != nil || name == "" {
+		return nil, err
+	}
+	source, err := c.coreClient.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting automation pull Secret %s/%s for user %s: %v", namespace, name, user, err)
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: projectName,
+			Labels:    automationLabels(user),
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}, nil
+}
+
+// Creates the automation ServiceAccount under the target user project, along with the copy of the pull
+// Secret it uses and the RoleBinding granting it AUTOMATION_CLUSTER_ROLE, restoring them when modified
+func (c *Controller) syncAutomation(ctx context.Context, user string, projectName string) error {
+	secret, err := c.desiredAutomationPullSecret(ctx, user, projectName)
+	if err != nil {
+		return err
+	}
+	desired := make(map[string]bool)
+	var pullSecret string
+	if secret != nil {
+		if err := c.syncAutomationPullSecret(ctx, user, projectName, secret); err != nil {
+			return err
+		}
+		pullSecret = secret.Name
+		desired[pullSecret] = true
+	}
+	if err := c.pruneAutomationSecrets(ctx, user, projectName, desired); err != nil {
+		return err
+	}
+	if err := c.syncAutomationServiceAccount(ctx, user, projectName, desiredAutomationServiceAccount(user, projectName, pullSecret)); err != nil {
+		return err
+	}
+	return c.syncManagedRoleBinding(ctx, user, projectName, desiredAutomationRoleBinding(user, projectName))
+}
+
+// Copies the pull Secret under the target user project, restoring it when modified
+func (c *Controller) syncAutomationPullSecret(ctx context.Context, user string, projectName string, secret *corev1.Secret) error {
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(secret, anchorRef)
+
+	existing, err := c.coreClient.Secrets(projectName).Get(ctx, secret.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if GetServerSideApplyEnabled() {
+			_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+		} else {
+			_, err = c.coreClient.Secrets(projectName).Create(ctx, secret, metav1.CreateOptions{})
+		}
+		if err != nil {
+			klog.Errorf("Error creating automation pull Secret %s for user %s under project %s: %v", secret.Name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Successfully created automation pull Secret %s for user %s under project %s", secret.Name, user, projectName)
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if automation pull Secret %s exists for user %s under project %s: %v", secret.Name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite Secrets that were not created by the controller
+	if existing.Labels[partOfLabel] != automationSet {
+		err := fmt.Errorf("Secret %s under project %s is not managed by the controller and will not be overwritten", secret.Name, projectName)
+		klog.Error(err)
+		return err
+	}
+	// the type of a Secret is immutable
+	if existing.Type != secret.Type {
+		if err := c.coreClient.Secrets(projectName).Delete(ctx, secret.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error deleting automation pull Secret %s for user %s under project %s: %v", secret.Name, user, projectName, err)
+			return err
+		}
+		return c.syncAutomationPullSecret(ctx, user, projectName, secret)
+	}
+
+	adopted := setAnchorReference(existing, anchorRef)
+	if !adopted && equality.Semantic.DeepEqual(existing.Data, secret.Data) {
+		klog.V(2).Infof("Automation pull Secret %s under project %s already exist for user %s", secret.Name, projectName, user)
+		return nil
+	}
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+	} else {
+		existing.Data = secret.Data
+		_, err = c.coreClient.Secrets(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating automation pull Secret %s for user %s under project %s: %v", secret.Name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated automation pull Secret %s for user %s under project %s", secret.Name, user, projectName)
+	return nil
+}
+
+// Creates the automation ServiceAccount under the target user project, adding back the pull Secret when
+// removed. The pull Secrets added by the cluster are kept.
+func (c *Controller) syncAutomationServiceAccount(ctx context.Context, user string, projectName string, serviceAccount *corev1.ServiceAccount) error {
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(serviceAccount, anchorRef)
+
+	name := serviceAccount.Name
+	existing, err := c.coreClient.ServiceAccounts(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := c.coreClient.ServiceAccounts(projectName).Create(ctx, serviceAccount, metav1.CreateOptions{}); err != nil {
+			klog.Errorf("Error creating automation ServiceAccount %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Successfully created automation ServiceAccount %s for user %s under project %s", name, user, projectName)
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if automation ServiceAccount %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+
+	// never take over ServiceAccounts that were not created by the controller
+	if existing.Labels[partOfLabel] != automationSet {
+		err := fmt.Errorf("ServiceAccount %s under project %s is not managed by the controller and will not be overwritten", name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	adopted := setAnchorReference(existing, anchorRef)
+	missing := false
+	for _, ref := range serviceAccount.ImagePullSecrets {
+		if !slices.Contains(existing.ImagePullSecrets, ref) {
+			existing.ImagePullSecrets = append(existing.ImagePullSecrets, ref)
+			missing = true
+		}
+	}
+	if !adopted && !missing {
+		klog.V(2).Infof("Automation ServiceAccount %s under project %s already exist for user %s", name, projectName, user)
+		return nil
+	}
+	if _, err := c.coreClient.ServiceAccounts(projectName).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating automation ServiceAccount %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated automation ServiceAccount %s for user %s under project %s", name, user, projectName)
+	return nil
+}
+
+// Deletes the RoleBinding of the automation ServiceAccount under the target user project, keeping one
+// not created by the controller
+func (c *Controller) deleteAutomationRoleBinding(ctx context.Context, user string, projectName string) error {
+	return c.deleteManagedRoleBinding(ctx, user, projectName, automationRoleBindingName(projectName))
+}
+
+// Deletes the automation pull Secrets of the target user project which are no longer desired
+func (c *Controller) pruneAutomationSecrets(ctx context.Context, user string, projectName string, desired map[string]bool) error {
+	secrets, err := c.coreClient.Secrets(projectName).List(ctx, metav1.ListOptions{LabelSelector: automationSelector})
+	if err != nil {
+		klog.Errorf("Error listing automation Secrets for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		objects = append(objects, &secrets.Items[i])
+	}
+	return c.pruneSeeded(ctx, user, projectName, "Secret", true, objects, desired, func(name string) error {
+		return c.coreClient.Secrets(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the automation ServiceAccounts of the target user project, along with their pull Secrets, once
+// AUTOMATION_SERVICE_ACCOUNT is unset or renamed
+func (c *Controller) pruneAutomation(ctx context.Context, user string, projectName string) error {
+	serviceAccounts, err := c.coreClient.ServiceAccounts(projectName).List(ctx, metav1.ListOptions{LabelSelector: automationSelector})
+	if err != nil {
+		klog.Errorf("Error listing automation ServiceAccounts for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(serviceAccounts.Items))
+	for i := range serviceAccounts.Items {
+		objects = append(objects, &serviceAccounts.Items[i])
+	}
+	desired := make(map[string]bool)
+	if name := GetAutomationServiceAccount(); name != "" {
+		desired[name] = true
+	}
+	err = c.pruneSeeded(ctx, user, projectName, "ServiceAccount", true, objects, desired, func(name string) error {
+		return c.coreClient.ServiceAccounts(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+	if err != nil || len(desired) > 0 {
+		return err
+	}
+	return c.pruneAutomationSecrets(ctx, user, projectName, nil)
+}
+
+// Returns the drift of the automation ServiceAccount and its RoleBinding under the target user project
+func (c *Controller) automationDrift(ctx context.Context, user string, projectName string) ([]string, error) {
+	var drift []string
+	name := GetAutomationServiceAccount()
+	serviceAccount, err := c.coreClient.ServiceAccounts(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		drift = append(drift, fmt.Sprintf("ServiceAccount %s is missing", name))
+	} else if err != nil {
+		return nil, err
+	} else if _, pullSecret, _ := GetAutomationPullSecret(); pullSecret != "" &&
+		!slices.Contains(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: pullSecret}) {
+		drift = append(drift, fmt.Sprintf("ServiceAccount %s does not use pull Secret %s", name, pullSecret))
+	}
+
+	roleBindingDrift, err := c.managedRoleBindingDrift(ctx, desiredAutomationRoleBinding(user, projectName))
+	if err != nil {
+		return nil, err
+	}
+	if roleBindingDrift != "" {
+		drift = append(drift, roleBindingDrift)
+	}
+	return drift, nil
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncAutomation(t *testing.T) {
+	t.Setenv("AUTOMATION_SERVICE_ACCOUNT", "ci")
+	t.Setenv("AUTOMATION_PULL_SECRET", "openshift-config/ci-registry")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci-registry", Namespace: "openshift-config"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	})
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	serviceAccount, err := kubeClient.CoreV1().ServiceAccounts("alice").Get(ctx, "ci", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the automation ServiceAccount under alice, but got error: %v", err)
+	}
+	if len(serviceAccount.ImagePullSecrets) != 1 || serviceAccount.ImagePullSecrets[0].Name != "ci-registry" {
+		t.Errorf("Expected the ServiceAccount to use pull Secret ci-registry, but got %v", serviceAccount.ImagePullSecrets)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "ci-registry", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the pull Secret to be copied under alice, but got error: %v", err)
+	}
+	if secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("Expected the copy to keep the type of the pull Secret, but got %s", secret.Type)
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, automationRoleBindingName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the RoleBinding of the automation ServiceAccount, but got error: %v", err)
+	}
+	if roleBinding.RoleRef.Name != "edit" {
+		t.Errorf("Expected the ServiceAccount to be granted edit, but got %s", roleBinding.RoleRef.Name)
+	}
+	if len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0].Kind != "ServiceAccount" || roleBinding.Subjects[0].Namespace != "alice" {
+		t.Errorf("Expected the ServiceAccount ci of alice to be bound, but got %+v", roleBinding.Subjects)
+	}
+
+	// Pull Secrets added by the cluster are kept while a removed one is added back
+	serviceAccount.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "ci-dockercfg-x7k2p"}}
+	if _, err := kubeClient.CoreV1().ServiceAccounts("alice").Update(ctx, serviceAccount, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ServiceAccount: %v", err)
+	}
+	if drift, err := controller.automationDrift(ctx, "alice", "alice"); err != nil || len(drift) != 1 {
+		t.Errorf("Expected the missing pull Secret to be reported as drift, but got %v, %v", drift, err)
+	}
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned again, but got error: %v", err)
+	}
+	serviceAccount, err = kubeClient.CoreV1().ServiceAccounts("alice").Get(ctx, "ci", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ServiceAccount: %v", err)
+	}
+	if len(serviceAccount.ImagePullSecrets) != 2 {
+		t.Errorf("Expected both pull Secrets to be used, but got %v", serviceAccount.ImagePullSecrets)
+	}
+
+	// Unsetting the pull Secret prunes its copy, and unsetting the ServiceAccount prunes it
+	t.Setenv("AUTOMATION_PULL_SECRET", "")
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned again, but got error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "ci-registry", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the copied pull Secret to be pruned, but got error: %v", err)
+	}
+	t.Setenv("AUTOMATION_SERVICE_ACCOUNT", "")
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned again, but got error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().ServiceAccounts("alice").Get(ctx, "ci", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the automation ServiceAccount to be pruned, but got error: %v", err)
+	}
+}
+
+func TestController_syncAutomationUnmanaged(t *testing.T) {
+	t.Setenv("AUTOMATION_SERVICE_ACCOUNT", "ci")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "ci", Namespace: "alice"},
+	})
+	controller := &Controller{
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.syncAutomation(ctx, "alice", "alice"); err == nil {
+		t.Errorf("Expected a ServiceAccount not created by the controller not to be overwritten")
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, automationRoleBindingName("alice"), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected no RoleBinding for a ServiceAccount not created by the controller, but got error: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// name of the NetworkQoS limiting the egress bandwidth of user namespaces
+const networkQoSName = "egress-bandwidth"
+
+// priority of the seeded NetworkQoS, leaving room for admins to override it with higher priorities
+const networkQoSPriority = 10
+
+// networkQoSResource identifies OVN-Kubernetes NetworkQoSes for the dynamic client
+var networkQoSResource = schema.GroupVersionResource{Group: "k8s.ovn.org", Version: "v1alpha1", Resource: "networkqoses"}
+
+// BandwidthTier is a named egress bandwidth limit applied to every pod of a namespace
+type BandwidthTier struct {
+	Name string
+	// Rate is the sustained egress rate in bits per second
+	Rate resource.Quantity
+	// Burst is the egress burst in bits, left to OVN when zero
+	Burst resource.Quantity
+}
+
+// Parses "<tier>=<rate>[/<burst>]" entries into bandwidth tiers keyed by name
+func parseBandwidthTiers(entries []string) (map[string]BandwidthTier, error) {
+	tiers := make(map[string]BandwidthTier)
+	for _, entry := range entries {
+		name, limits, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		rate, burst, _ := strings.Cut(limits, "/")
+		rate = strings.TrimSpace(rate)
+		burst = strings.TrimSpace(burst)
+		if !found || name == "" || rate == "" {
+			return nil, fmt.Errorf("invalid bandwidth tier %q, expected <tier>=<rate>[/<burst>]", entry)
+		}
+		tier := BandwidthTier{Name: name}
+		var err error
+		if tier.Rate, err = resource.ParseQuantity(rate); err != nil {
+			return nil, fmt.Errorf("invalid rate of bandwidth tier %q: %w", entry, err)
+		}
+		if burst != "" {
+			if tier.Burst, err = resource.ParseQuantity(burst); err != nil {
+				return nil, fmt.Errorf("invalid burst of bandwidth tier %q: %w", entry, err)
+			}
+		}
+		if tier.Rate.Value() < 1000 {
+			return nil, fmt.Errorf("rate of bandwidth tier %q is below 1k bits per second", entry)
+		}
+		if tier.Burst.Sign() < 0 {
+			return nil, fmt.Errorf("burst of bandwidth tier %q is negative", entry)
+		}
+		tiers[name] = tier
+	}
+	return tiers, nil
+}
+
+// Returns the bandwidth tier of the target user, from the override of their target group or else
+// the default tier, or nil when their bandwidth is not limited
+func (c *Controller) userBandwidthTier(ctx context.Context, user string) (*BandwidthTier, error) {
+	tiers, err := GetBandwidthTiers()
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := GetBandwidthTierOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	name := GetBandwidthDefaultTier()
+	if len(overrides) > 0 {
+		group, err := c.sourceGroup(ctx, user)
+		if err != nil {
+			klog.Errorf("Error getting the target group of user %s to select their bandwidth tier: %v", user, err)
+			return nil, err
+		}
+		if group != nil {
+			if override, ok := overrides[group.Name]; ok {
+				name = override
+			}
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	tier, ok := tiers[name]
+	if !ok {
+		return nil, fmt.Errorf("bandwidth tier %s is not configured", name)
+	}
+	return &tier, nil
+}
+
+// Returns the NetworkQoS limiting the egress bandwidth of every pod of the target user project to
+// the tier, so a single sandbox can't saturate the egress of the cluster
+func desiredNetworkQoS(user string, projectName string, tier *BandwidthTier) *unstructured.Unstructured {
+	// OVN expects the rate in kbit/s and the burst in kbit
+	bandwidth := map[string]interface{}{
+		"rate": tier.Rate.Value() / 1000,
+	}
+	if !tier.Burst.IsZero() {
+		bandwidth["burst"] = tier.Burst.Value() / 1000
+	}
+
+	networkQoS := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "k8s.ovn.org/v1alpha1",
+		"kind":       "NetworkQoS",
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{},
+			"priority":    int64(networkQoSPriority),
+			"egress": []interface{}{
+				map[string]interface{}{
+					"dscp":      int64(0),
+					"bandwidth": bandwidth,
+				},
+			},
+		},
+	}}
+	networkQoS.SetName(networkQoSName)
+	networkQoS.SetNamespace(projectName)
+	networkQoS.SetLabels(seededLabels(user))
+	return networkQoS
+}
+
+// Creates the seeded NetworkQoS under the target user project, restores its limits when they were
+// modified or the tier of the user changed, and deletes it when the user has no tier
+func (c *Controller) syncNetworkQoS(ctx context.Context, user string, projectName string) error {
+	if c.dynamicClient == nil {
+		return errors.New("no dynamic client configured")
+	}
+	tier, err := c.userBandwidthTier(ctx, user)
+	if err != nil {
+		klog.Errorf("Error selecting the bandwidth tier of user %s: %v", user, err)
+		return err
+	}
+
+	client := c.dynamicClient.Resource(networkQoSResource).Namespace(projectName)
+	existing, err := client.Get(ctx, networkQoSName, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error checking if NetworkQoS %s exists for user %s under project %s: %v", networkQoSName, user, projectName, err)
+		return err
+	}
+	found := err == nil
+
+	// never overwrite or delete NetworkQoSes that were not seeded by the controller
+	if found && existing.GetLabels()[partOfLabel] != seededSet {
+		err := fmt.Errorf("NetworkQoS %s under project %s is not managed by the controller and will not be overwritten", networkQoSName, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	if tier == nil {
+		if !found {
+			return nil
+		}
+		if err := client.Delete(ctx, networkQoSName, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error deleting NetworkQoS %s for user %s under project %s: %v", networkQoSName, user, projectName, err)
+			return err
+		}
+		klog.Infof("Deleted NetworkQoS %s of user %s without a bandwidth tier under project %s", networkQoSName, user, projectName)
+		return nil
+	}
+
+	networkQoS := desiredNetworkQoS(user, projectName, tier)
+	if !found {
+		if _, err := client.Create(ctx, networkQoS, metav1.CreateOptions{}); err != nil {
+			klog.Errorf("Error creating NetworkQoS %s for user %s under project %s: %v", networkQoSName, user, projectName, err)
+			return err
+		}
+		klog.Infof("Successfully created NetworkQoS %s with bandwidth tier %s for user %s under project %s", networkQoSName, tier.Name, user, projectName)
+		return nil
+	}
+
+	released := releaseAnchorReference(existing)
+	spec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	if !released && reflect.DeepEqual(spec, networkQoS.Object["spec"]) {
+		klog.V(2).Infof("NetworkQoS %s under project %s already exist for user %s", networkQoSName, projectName, user)
+		return nil
+	}
+
+	existing.Object["spec"] = networkQoS.Object["spec"]
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating NetworkQoS %s for user %s under project %s: %v", networkQoSName, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated NetworkQoS %s with bandwidth tier %s for user %s under project %s", networkQoSName, tier.Name, user, projectName)
+	return nil
+}
+
+// Returns the drift of the seeded NetworkQoS under the target user project, if any
+func (c *Controller) networkQoSDrift(ctx context.Context, user string, projectName string) (string, error) {
+	if c.dynamicClient == nil {
+		return "", nil
+	}
+	tier, err := c.userBandwidthTier(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	existing, err := c.dynamicClient.Resource(networkQoSResource).Namespace(projectName).Get(ctx, networkQoSName, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		if tier != nil {
+			return fmt.Sprintf("NetworkQoS %s is missing", networkQoSName), nil
+		}
+		return "", nil
+	case err != nil:
+		return "", err
+	case tier == nil:
+		if existing.GetLabels()[partOfLabel] == seededSet {
+			return fmt.Sprintf("NetworkQoS %s limits the bandwidth of a user without a bandwidth tier", networkQoSName), nil
+		}
+		return "", nil
+	}
+	spec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	if !reflect.DeepEqual(spec, desiredNetworkQoS(user, projectName, tier).Object["spec"]) {
+		return fmt.Sprintf("NetworkQoS %s does not apply bandwidth tier %s", networkQoSName, tier.Name), nil
+	}
+	return "", nil
+}
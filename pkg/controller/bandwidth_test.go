@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseBandwidthTiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     []string
+		rate        int64
+		burst       int64
+		shouldError bool
+	}{
+		{name: "rate", entries: []string{"standard=100M"}, rate: 100000000},
+		{name: "rate and burst", entries: []string{"standard=1G/2G"}, rate: 1000000000, burst: 2000000000},
+		{name: "missing rate", entries: []string{"standard="}, shouldError: true},
+		{name: "invalid rate", entries: []string{"standard=fast"}, shouldError: true},
+		{name: "rate below 1k", entries: []string{"standard=500"}, shouldError: true},
+		{name: "negative burst", entries: []string{"standard=100M/-1"}, shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiers, err := parseBandwidthTiers(tt.entries)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected %v to be rejected", tt.entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected %v to be parsed, but got error: %v", tt.entries, err)
+			}
+			tier := tiers["standard"]
+			if tier.Rate.Value() != tt.rate || tier.Burst.Value() != tt.burst {
+				t.Errorf("Expected rate %d and burst %d, but got %s and %s", tt.rate, tt.burst, tier.Rate.String(), tier.Burst.String())
+			}
+		})
+	}
+}
+
+func TestController_syncNetworkQoS(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "workshop,staff")
+	t.Setenv("BANDWIDTH_TIERS", "standard=100M,large=1G/2G")
+	t.Setenv("BANDWIDTH_DEFAULT_TIER", "standard")
+	t.Setenv("BANDWIDTH_TIER_OVERRIDES", "staff=large")
+
+	ctx := context.Background()
+	unmanaged := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "k8s.ovn.org/v1alpha1",
+		"kind":       "NetworkQoS",
+		"metadata":   map[string]interface{}{"name": networkQoSName, "namespace": "carol"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), unmanaged)
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("workshop", "alice", "carol"), newGroup("staff", "bob")),
+		coreClient:    fake.NewClientset().CoreV1(),
+		dynamicClient: dynamicClient,
+	}
+	rate := func(namespace string) (int64, int64, error) {
+		networkQoS, err := dynamicClient.Resource(networkQoSResource).Namespace(namespace).Get(ctx, networkQoSName, metav1.GetOptions{})
+		if err != nil {
+			return 0, 0, err
+		}
+		rules, _, _ := unstructured.NestedSlice(networkQoS.Object, "spec", "egress")
+		bandwidth := rules[0].(map[string]interface{})["bandwidth"].(map[string]interface{})
+		burst, _ := bandwidth["burst"].(int64)
+		return bandwidth["rate"].(int64), burst, nil
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if err := controller.syncNetworkQoS(ctx, user, user); err != nil {
+			t.Fatalf("Expected NetworkQoS to be created for %s, but got error: %v", user, err)
+		}
+	}
+	if got, burst, err := rate("alice"); err != nil || got != 100000 || burst != 0 {
+		t.Errorf("Expected the standard tier of 100000 kbit/s for alice, but got %d/%d, %v", got, burst, err)
+	}
+	if got, burst, err := rate("bob"); err != nil || got != 1000000 || burst != 2000000 {
+		t.Errorf("Expected the large tier of 1000000 kbit/s for bob, but got %d/%d, %v", got, burst, err)
+	}
+
+	// Modified limits are restored
+	networkQoS, err := dynamicClient.Resource(networkQoSResource).Namespace("alice").Get(ctx, networkQoSName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected NetworkQoS %s to exist, but got error: %v", networkQoSName, err)
+	}
+	networkQoS.Object["spec"] = map[string]interface{}{}
+	if _, err := dynamicClient.Resource(networkQoSResource).Namespace("alice").Update(ctx, networkQoS, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update NetworkQoS %s: %v", networkQoSName, err)
+	}
+	if drift, err := controller.networkQoSDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the modified NetworkQoS to be reported as drift, but got %q, %v", drift, err)
+	}
+	if err := controller.syncNetworkQoS(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected NetworkQoS to be restored, but got error: %v", err)
+	}
+	if got, _, err := rate("alice"); err != nil || got != 100000 {
+		t.Errorf("Expected the standard tier to be restored for alice, but got %d, %v", got, err)
+	}
+
+	// Users without a tier are no longer limited
+	t.Setenv("BANDWIDTH_DEFAULT_TIER", "")
+	if err := controller.syncNetworkQoS(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected NetworkQoS to be deleted, but got error: %v", err)
+	}
+	if _, _, err := rate("alice"); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the NetworkQoS of alice to be deleted, but got %v", err)
+	}
+	if drift, err := controller.networkQoSDrift(ctx, "alice", "alice"); err != nil || drift != "" {
+		t.Errorf("Expected no drift for a user without a tier, but got %q, %v", drift, err)
+	}
+
+	// NetworkQoSes which were not seeded are never overwritten
+	t.Setenv("BANDWIDTH_DEFAULT_TIER", "standard")
+	if err := controller.syncNetworkQoS(ctx, "carol", "carol"); err == nil {
+		t.Errorf("Expected the unmanaged NetworkQoS of project carol to be kept")
+	}
+}
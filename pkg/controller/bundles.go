@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// label on a User, or annotation on the target group for its members' default, selecting the
+// bundle of secrets seeded into the user's namespace
+const bundleLabel = "rosa-namespace-provisioner/bundle"
+
+// Parses "<bundle>:<secret-id>=<secret-name>" entries into the secret mappings of each bundle
+func parseSecretBundles(entries []string) (map[string][]SecretMapping, error) {
+	bundles := make(map[string][]SecretMapping)
+	for _, entry := range entries {
+		bundle, mapping, found := strings.Cut(entry, ":")
+		bundle = strings.TrimSpace(bundle)
+		if !found || bundle == "" {
+			return nil, fmt.Errorf("invalid secret bundle entry %q, expected <bundle>:<secret-id>=<secret-name>", entry)
+		}
+		mappings, err := parseSecretMappings([]string{mapping})
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret bundle entry %q: %w", entry, err)
+		}
+		bundles[bundle] = append(bundles[bundle], mappings...)
+	}
+	return bundles, nil
+}
+
+// Returns the bundle selected for the target user by the label on their User, falling back to the
+// annotation on the target group granting their namespace, or an empty string when no bundle is selected
+func (c *Controller) selectedBundle(ctx context.Context, user string) (string, error) {
+	userObj, err := c.userClient.UserV1().Users().Get(ctx, user, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error getting User %s to select its bundle: %v", user, err)
+		return "", err
+	}
+	if err == nil {
+		if bundle := userObj.Labels[bundleLabel]; bundle != "" {
+			return bundle, nil
+		}
+	}
+
+	group, err := c.sourceGroup(ctx, user)
+	if err != nil {
+		klog.Errorf("Error getting the target group of user %s to select their bundle: %v", user, err)
+		return "", err
+	}
+	if group != nil {
+		return group.Annotations[bundleLabel], nil
+	}
+	return "", nil
+}
+
+// Returns the secret mappings seeded into the target user project: the mappings common to every
+// user followed by those of the user's selected bundle
+func (c *Controller) userSecretMappings(ctx context.Context, user string) ([]SecretMapping, error) {
+	mappings, err := GetAWSSecretMappings()
+	if err != nil {
+		return nil, err
+	}
+	bundles, err := GetAWSSecretBundles()
+	if err != nil || len(bundles) == 0 {
+		return mappings, err
+	}
+
+	bundle, err := c.selectedBundle(ctx, user)
+	if err != nil || bundle == "" {
+		return mappings, err
+	}
+	bundleMappings, ok := bundles[bundle]
+	if !ok {
+		names := make([]string, 0, len(bundles))
+		for name := range bundles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown bundle %q selected for user %s, expected one of %s", bundle, user, strings.Join(names, ", "))
+	}
+	return append(mappings, bundleMappings...), nil
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseSecretBundles(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     []string
+		want        map[string]int
+		shouldError bool
+	}{
+		{
+			name: "valid bundles",
+			entries: []string{
+				"data-science:sandbox/model-api=model-api-key",
+				"data-science:arn:aws:secretsmanager:us-east-1:123:secret:hf=hf-token",
+				"app-dev:sandbox/registry=registry-credentials",
+			},
+			want: map[string]int{"data-science": 2, "app-dev": 1},
+		},
+		{
+			name:        "missing bundle",
+			entries:     []string{"sandbox/model-api=model-api-key"},
+			shouldError: true,
+		},
+		{
+			name:        "invalid mapping",
+			entries:     []string{"app-dev:sandbox/registry"},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSecretBundles(tt.entries)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseSecretBundles() = %v, want bundles %v", got, tt.want)
+			}
+			for bundle, count := range tt.want {
+				if len(got[bundle]) != count {
+					t.Errorf("Expected bundle %s to hold %d mappings, but got %v", bundle, count, got[bundle])
+				}
+			}
+		})
+	}
+}
+
+func TestController_syncUserSecretsSwapsBundles(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/ca=ca-bundle")
+	t.Setenv("AWS_SECRET_BUNDLES", "data-science:sandbox/model-api=model-api-key,app-dev:sandbox/registry=registry-credentials")
+
+	ctx := context.Background()
+	group := newGroup(GetTargetGroupName(), "alice")
+	group.Annotations = map[string]string{bundleLabel: "data-science"}
+	user := &userv1.User{ObjectMeta: metav1.ObjectMeta{Name: "alice"}}
+	userClient := userfake.NewSimpleClientset(group, user)
+	coreClient := fake.NewClientset().CoreV1()
+	controller := &Controller{
+		userClient: userClient,
+		coreClient: coreClient,
+		secretSource: &fakeSecretSource{
+			secrets: map[string]map[string][]byte{
+				"sandbox/ca":        {"value": []byte("pem")},
+				"sandbox/model-api": {"api-key": []byte("abc")},
+				"sandbox/registry":  {"token": []byte("xyz")},
+			},
+		},
+	}
+
+	// The group annotation selects the default bundle
+	if err := controller.syncUserSecrets(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected Secrets to be synced, but got error: %v", err)
+	}
+	for _, name := range []string{"ca-bundle", "model-api-key"} {
+		if _, err := coreClient.Secrets("alice").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected Secret %s to be found, but got error: %v", name, err)
+		}
+	}
+
+	// The label on the User overrides it, swapping the bundles
+	user.Labels = map[string]string{bundleLabel: "app-dev"}
+	if _, err := userClient.UserV1().Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to label User alice: %v", err)
+	}
+	if err := controller.syncUserSecrets(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected Secrets to be synced, but got error: %v", err)
+	}
+	for _, name := range []string{"ca-bundle", "registry-credentials"} {
+		if _, err := coreClient.Secrets("alice").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected Secret %s to be found, but got error: %v", name, err)
+		}
+	}
+	if _, err := coreClient.Secrets("alice").Get(ctx, "model-api-key", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Secret model-api-key of the previous bundle to be pruned, but got error: %v", err)
+	}
+
+	// Unknown bundles fail instead of silently seeding nothing
+	user.Labels[bundleLabel] = "unknown"
+	if _, err := userClient.UserV1().Users().Update(ctx, user, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to label User alice: %v", err)
+	}
+	if err := controller.syncUserSecrets(ctx, "alice", "alice"); err == nil {
+		t.Errorf("Expected an unknown bundle to receive an error")
+	}
+}
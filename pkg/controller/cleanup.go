@@ -0,0 +1,213 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2"
+)
+
+// ErrNoDeadLetter is returned when retrying the dead letters of a user who has none
+var ErrNoDeadLetter = errors.New("no dead-lettered cleanups")
+
+// ExternalCleaner deletes the artifacts an integration created outside of the cluster for a user
+type ExternalCleaner interface {
+	// Name returns the name of the integration, used to track its health
+	Name() string
+	// Cleanup deletes the artifacts of the user, succeeding if they are already gone
+	Cleanup(ctx context.Context, user string, namespace string) error
+}
+
+// cleanupItem is the external cleanup of a single user by a single integration
+type cleanupItem struct {
+	integration string
+	user        string
+	namespace   string
+}
+
+// DeadLetter is an external cleanup which kept failing after every retry
+type DeadLetter struct {
+	Integration string    `json:"integration"`
+	User        string    `json:"user"`
+	Namespace   string    `json:"namespace"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	Since       time.Time `json:"since"`
+}
+
+// WithExternalCleaners deletes the artifacts integrations created outside of the cluster when users
+// are deprovisioned. Cleanups run from a rate-limited queue while the controller runs, so slow or
+// failing external APIs never hold back deleting the namespace.
+func WithExternalCleaners(cleaners ...ExternalCleaner) Option {
+	return func(c *Controller) {
+		if len(cleaners) == 0 {
+			return
+		}
+		c.cleaners = make(map[string]ExternalCleaner, len(cleaners))
+		for _, cleaner := range cleaners {
+			c.cleaners[cleaner.Name()] = cleaner
+		}
+		c.deadLetters = make(map[cleanupItem]DeadLetter)
+		c.cleanupQueue = workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.NewTypedMaxOfRateLimiter(
+				workqueue.NewTypedItemExponentialFailureRateLimiter[cleanupItem](time.Second, 5*time.Minute),
+				&workqueue.TypedBucketRateLimiter[cleanupItem]{
+					Limiter: rate.NewLimiter(rate.Limit(GetExternalCleanupRate()), int(GetExternalCleanupBurst())),
+				},
+			),
+			workqueue.TypedRateLimitingQueueConfig[cleanupItem]{Name: "external-cleanup"},
+		)
+	}
+}
+
+// Queues the external cleanups of a deprovisioned user
+func (c *Controller) queueExternalCleanups(user string, projectName string) {
+	if c.cleanupQueue == nil {
+		return
+	}
+	names := make([]string, 0, len(c.cleaners))
+	for name := range c.cleaners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	c.expectRevocations(projectName, names)
+	for _, name := range names {
+		klog.V(2).Infof("Queueing cleanup of integration %s for user %s under project %s", name, user, projectName)
+		c.cleanupQueue.AddRateLimited(cleanupItem{integration: name, user: user, namespace: projectName})
+	}
+}
+
+// Processes queued external cleanups until the queue shuts down
+func (c *Controller) runCleanupWorker(ctx context.Context) {
+	for c.processNextCleanup(ctx) {
+	}
+}
+
+// Runs the next queued external cleanup, retrying it with backoff when it fails and moving it to
+// the dead letters once its retries are exhausted. Returns false once the queue shuts down.
+func (c *Controller) processNextCleanup(ctx context.Context) bool {
+	item, shutdown := c.cleanupQueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.cleanupQueue.Done(item)
+
+	// Wait out the cool-down of a disabled integration without using up retries
+	if !c.health.Enabled(item.integration) {
+		klog.V(2).Infof("Delaying cleanup of integration %s for user %s while it is disabled", item.integration, item.user)
+		c.cleanupQueue.AddAfter(item, GetIntegrationDisableDuration())
+		return true
+	}
+
+	cleanupCtx, cancel := context.WithTimeout(ctx, GetProvisioningStepTimeout())
+	err := c.cleaners[item.integration].Cleanup(cleanupCtx, item.user, item.namespace)
+	cancel()
+	c.health.Record(item.integration, err)
+	if err == nil {
+		c.cleanupQueue.Forget(item)
+		c.clearDeadLetter(item)
+		klog.Infof("Cleaned up integration %s for user %s under project %s", item.integration, item.user, item.namespace)
+		c.recordRevocation(item, nil)
+		c.completeDeprovisionReport(ctx, item.namespace)
+		return true
+	}
+
+	// Cleanups are queued rate limited, so the first attempt already counts as a requeue
+	attempts := c.cleanupQueue.NumRequeues(item)
+	if int64(attempts) <= GetExternalCleanupMaxRetries() {
+		klog.Warningf("Error cleaning up integration %s for user %s under project %s (attempt %d, retrying): %v", item.integration, item.user, item.namespace, attempts, err)
+		c.cleanupQueue.AddRateLimited(item)
+		return true
+	}
+
+	klog.Errorf("Error cleaning up integration %s for user %s under project %s, giving up after %d attempts: %v", item.integration, item.user, item.namespace, attempts, err)
+	c.cleanupQueue.Forget(item)
+	c.mu.Lock()
+	c.deadLetters[item] = DeadLetter{
+		Integration: item.integration,
+		User:        item.user,
+		Namespace:   item.namespace,
+		Attempts:    attempts,
+		LastError:   err.Error(),
+		Since:       time.Now(),
+	}
+	c.updateDeadLetterMetric()
+	c.mu.Unlock()
+	c.recordRevocation(item, err)
+	c.completeDeprovisionReport(ctx, item.namespace)
+	return true
+}
+
+// Removes the dead letter of a cleanup which succeeded
+func (c *Controller) clearDeadLetter(item cleanupItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.deadLetters[item]; ok {
+		delete(c.deadLetters, item)
+		c.updateDeadLetterMetric()
+	}
+}
+
+// Exports the number of dead letters of each integration. Must be called with the lock held.
+func (c *Controller) updateDeadLetterMetric() {
+	counts := make(map[string]int, len(c.cleaners))
+	for name := range c.cleaners {
+		counts[name] = 0
+	}
+	for item := range c.deadLetters {
+		counts[item.integration]++
+	}
+	for name, count := range counts {
+		metrics.ExternalCleanupDeadLetters.WithLabelValues(name).Set(float64(count))
+	}
+}
+
+// DeadLetters returns the external cleanups which kept failing after every retry, sorted by user
+// and integration
+func (c *Controller) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deadLetters := make([]DeadLetter, 0, len(c.deadLetters))
+	for _, deadLetter := range c.deadLetters {
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool {
+		if deadLetters[i].User != deadLetters[j].User {
+			return deadLetters[i].User < deadLetters[j].User
+		}
+		return deadLetters[i].Integration < deadLetters[j].Integration
+	})
+	return deadLetters, nil
+}
+
+// RetryDeadLetters queues the dead-lettered cleanups of a user again with a fresh set of retries.
+// It returns ErrNoDeadLetter if the user has none.
+func (c *Controller) RetryDeadLetters(ctx context.Context, user string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var retried []cleanupItem
+	for item := range c.deadLetters {
+		if item.user == user {
+			retried = append(retried, item)
+		}
+	}
+	if len(retried) == 0 {
+		return fmt.Errorf("%w for user %s", ErrNoDeadLetter, user)
+	}
+
+	for _, item := range retried {
+		delete(c.deadLetters, item)
+		c.cleanupQueue.AddRateLimited(item)
+		klog.Infof("Retrying cleanup of integration %s for user %s under project %s", item.integration, item.user, item.namespace)
+	}
+	c.updateDeadLetterMetric()
+	return nil
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// fakeCleaner fails the cleanups of the users it holds as failing
+type fakeCleaner struct {
+	failing map[string]bool
+	cleaned []string
+}
+
+func (f *fakeCleaner) Name() string {
+	return "fake"
+}
+
+func (f *fakeCleaner) Cleanup(ctx context.Context, user string, namespace string) error {
+	if f.failing[user] {
+		return errors.New("rate limited")
+	}
+	f.cleaned = append(f.cleaned, user)
+	return nil
+}
+
+func TestController_processNextCleanup(t *testing.T) {
+	t.Setenv("EXTERNAL_CLEANUP_MAX_RETRIES", "2")
+
+	ctx := context.Background()
+	cleaner := &fakeCleaner{failing: map[string]bool{"bob": true}}
+	controller := &Controller{
+		cleaners:    map[string]ExternalCleaner{cleaner.Name(): cleaner},
+		deadLetters: make(map[cleanupItem]DeadLetter),
+		cleanupQueue: workqueue.NewTypedRateLimitingQueue(
+			workqueue.NewTypedItemExponentialFailureRateLimiter[cleanupItem](time.Millisecond, 10*time.Millisecond),
+		),
+	}
+	defer controller.cleanupQueue.ShutDown()
+
+	controller.queueExternalCleanups("alice", "alice")
+	controller.queueExternalCleanups("bob", "bob")
+
+	// alice is cleaned up at once, bob is retried twice and then dead-lettered
+	for range 4 {
+		controller.processNextCleanup(ctx)
+	}
+	if controller.cleanupQueue.Len() != 0 {
+		t.Errorf("Expected no cleanups to be queued, but got %d", controller.cleanupQueue.Len())
+	}
+	if len(cleaner.cleaned) != 1 || cleaner.cleaned[0] != "alice" {
+		t.Errorf("Expected only alice to be cleaned up, but got %v", cleaner.cleaned)
+	}
+
+	deadLetters, err := controller.DeadLetters(ctx)
+	if err != nil {
+		t.Fatalf("Expected dead letters to be listed, but got error: %v", err)
+	}
+	if len(deadLetters) != 1 || deadLetters[0].User != "bob" || deadLetters[0].Attempts != 3 || deadLetters[0].LastError != "rate limited" {
+		t.Fatalf("Expected the cleanup of bob to be dead-lettered after 3 attempts, but got %+v", deadLetters)
+	}
+	if count := testutil.ToFloat64(metrics.ExternalCleanupDeadLetters.WithLabelValues("fake")); count != 1 {
+		t.Errorf("Expected 1 dead letter to be exported, but got %g", count)
+	}
+
+	if err := controller.RetryDeadLetters(ctx, "carol"); !errors.Is(err, ErrNoDeadLetter) {
+		t.Errorf("Expected ErrNoDeadLetter retrying carol, but got: %v", err)
+	}
+
+	// Once the external API recovers, a retry cleans bob up
+	cleaner.failing["bob"] = false
+	if err := controller.RetryDeadLetters(ctx, "bob"); err != nil {
+		t.Fatalf("Expected the cleanups of bob to be retried, but got error: %v", err)
+	}
+	controller.processNextCleanup(ctx)
+	if len(cleaner.cleaned) != 2 || cleaner.cleaned[1] != "bob" {
+		t.Errorf("Expected bob to be cleaned up, but got %v", cleaner.cleaned)
+	}
+	if deadLetters, _ := controller.DeadLetters(ctx); len(deadLetters) != 0 {
+		t.Errorf("Expected no dead letters, but got %+v", deadLetters)
+	}
+	if count := testutil.ToFloat64(metrics.ExternalCleanupDeadLetters.WithLabelValues("fake")); count != 0 {
+		t.Errorf("Expected no dead letters to be exported, but got %g", count)
+	}
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	quotav1 "github.com/openshift/api/quota/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+)
+
+// Returns the name of the ClusterResourceQuota managed for the target user
+func clusterResourceQuotaName(user string) string {
+	name := fmt.Sprintf("%s-quota", user)
+	if len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+	return sanitizeName(user, validation.DNS1123LabelMaxLength-len("-quota")) + "-quota"
+}
+
+// Returns the scope selector restricting managed quotas to the configured PriorityClasses,
+// or nil when quotas apply to all pods
+func priorityClassScopeSelector() (*corev1.ScopeSelector, error) {
+	priorityClasses := GetQuotaPriorityClasses()
+	if len(priorityClasses) == 0 {
+		return nil, nil
+	}
+
+	operator, err := GetQuotaPriorityClassOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ScopeSelector{
+		MatchExpressions: []corev1.ScopedResourceSelectorRequirement{
+			{
+				ScopeName: corev1.ResourceQuotaScopePriorityClass,
+				Operator:  operator,
+				Values:    priorityClasses,
+			},
+		},
+	}, nil
+}
+
+// Returns the ClusterResourceQuota covering every project owned by the target user
+func desiredClusterResourceQuota(user string) (*quotav1.ClusterResourceQuota, error) {
+	hard, err := GetClusterResourceQuotaHard()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ClusterResourceQuota limits: %w", err)
+	}
+
+	quota := &quotav1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: clusterResourceQuotaName(user),
+			Labels: map[string]string{
+				ownerLabel: ownerLabelValue(user),
+			},
+		},
+		Spec: quotav1.ClusterResourceQuotaSpec{
+			Selector: quotav1.ClusterResourceQuotaSelector{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{
+						ownerLabel: ownerLabelValue(user),
+					},
+				},
+			},
+		},
+	}
+	quota.Spec.Quota.Hard = hard
+
+	scopeSelector, err := priorityClassScopeSelector()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build ClusterResourceQuota scope: %w", err)
+	}
+	quota.Spec.Quota.ScopeSelector = scopeSelector
+	return quota, nil
+}
+
+// Creates the ClusterResourceQuota covering every project owned by the target user, or restores its
+// limits, scope and selector when they changed, e.g. after CLUSTER_RESOURCE_QUOTA_HARD was changed
+func (c *Controller) createClusterResourceQuota(ctx context.Context, user string) error {
+	quota, err := desiredClusterResourceQuota(user)
+	if err != nil {
+		klog.Errorf("Error building ClusterResourceQuota for user %s: %v", user, err)
+		return err
+	}
+
+	existingQuota, err := c.quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, quota.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.Infof("ClusterResourceQuota %s not found for user %s", quota.Name, user)
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.quotaClient.QuotaV1().ClusterResourceQuotas().Patch, quota, quotav1.GroupVersion.WithKind("ClusterResourceQuota"))
+			} else {
+				_, err = c.quotaClient.QuotaV1().ClusterResourceQuotas().Create(ctx, quota, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating ClusterResourceQuota for user %s: %v", user, err)
+				return err
+			}
+			klog.Infof("Successfully created ClusterResourceQuota %s for user %s", quota.Name, user)
+			return nil
+		}
+		klog.Errorf("Error checking if ClusterResourceQuota exists for user %s: %v", user, err)
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existingQuota.Spec, quota.Spec) {
+		klog.Infof("ClusterResourceQuota %s already exists for user %s", quota.Name, user)
+		return nil
+	}
+
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.quotaClient.QuotaV1().ClusterResourceQuotas().Patch, quota, quotav1.GroupVersion.WithKind("ClusterResourceQuota"))
+	} else {
+		existingQuota.Spec = quota.Spec
+		_, err = c.quotaClient.QuotaV1().ClusterResourceQuotas().Update(ctx, existingQuota, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating ClusterResourceQuota %s for user %s: %v", quota.Name, user, err)
+		return err
+	}
+	klog.Infof("Updated ClusterResourceQuota %s for user %s", quota.Name, user)
+	return nil
+}
+
+// Deletes the ClusterResourceQuota of the target user if present
+func (c *Controller) deleteClusterResourceQuota(ctx context.Context, user string) error {
+	name := clusterResourceQuotaName(user)
+	err := c.quotaClient.QuotaV1().ClusterResourceQuotas().Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.Infof("ClusterResourceQuota %s does not exist for user %s", name, user)
+			return nil
+		}
+		klog.Errorf("Error deleting ClusterResourceQuota for user %s: %v", user, err)
+		return err
+	}
+	klog.Infof("Successfully deleted ClusterResourceQuota %s for user %s", name, user)
+	return nil
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	quotav1 "github.com/openshift/api/quota/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_createClusterResourceQuota(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "requests.cpu=4,pods=20")
+
+	ctx := context.Background()
+	quotaClient := quotafake.NewClientset()
+	controller := &Controller{
+		quotaClient: quotaClient,
+	}
+
+	if err := controller.createClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Fatalf("Expected ClusterResourceQuota to be created, but got error: %v", err)
+	}
+
+	quota, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ClusterResourceQuota alice-quota to be found, but got error: %v", err)
+	}
+	if got := quota.Spec.Selector.LabelSelector.MatchLabels[ownerLabel]; got != "alice" {
+		t.Errorf("Expected ClusterResourceQuota to select owner alice, but got %q", got)
+	}
+	if got := quota.Spec.Quota.Hard[corev1.ResourcePods]; got.String() != "20" {
+		t.Errorf("Expected pods limit of 20, but got %s", got.String())
+	}
+
+	// Creating the quota a second time should be a no-op
+	if err := controller.createClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Errorf("Expected existing ClusterResourceQuota to be accepted, but got error: %v", err)
+	}
+}
+
+func TestPriorityClassScopeSelector(t *testing.T) {
+	tests := []struct {
+		name            string
+		priorityClasses string
+		operator        string
+		wantNil         bool
+		wantOperator    corev1.ScopeSelectorOperator
+		shouldError     bool
+	}{
+		{
+			name:    "no PriorityClasses configured",
+			wantNil: true,
+		},
+		{
+			name:            "default operator",
+			priorityClasses: "high-priority,critical",
+			wantOperator:    corev1.ScopeSelectorOpIn,
+		},
+		{
+			name:            "exclude low priority",
+			priorityClasses: "low-priority",
+			operator:        "NotIn",
+			wantOperator:    corev1.ScopeSelectorOpNotIn,
+		},
+		{
+			name:            "invalid operator",
+			priorityClasses: "low-priority",
+			operator:        "Exists",
+			shouldError:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QUOTA_PRIORITY_CLASSES", tt.priorityClasses)
+			t.Setenv("QUOTA_PRIORITY_CLASS_OPERATOR", tt.operator)
+
+			got, err := priorityClassScopeSelector()
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("Expected no scope selector, but got %v", got)
+				}
+				return
+			}
+			if len(got.MatchExpressions) != 1 {
+				t.Fatalf("Expected a single match expression, but got %v", got.MatchExpressions)
+			}
+			expression := got.MatchExpressions[0]
+			if expression.ScopeName != corev1.ResourceQuotaScopePriorityClass {
+				t.Errorf("Expected PriorityClass scope, but got %s", expression.ScopeName)
+			}
+			if expression.Operator != tt.wantOperator {
+				t.Errorf("Expected operator %s, but got %s", tt.wantOperator, expression.Operator)
+			}
+		})
+	}
+}
+
+func TestController_createClusterResourceQuotaUpdates(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "requests.cpu=4")
+
+	ctx := context.Background()
+	quotaClient := quotafake.NewClientset()
+	controller := &Controller{
+		quotaClient: quotaClient,
+	}
+	if err := controller.createClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Fatalf("Expected ClusterResourceQuota to be created, but got error: %v", err)
+	}
+
+	// Changed limits and scopes reach the existing quota
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "requests.cpu=8")
+	t.Setenv("QUOTA_PRIORITY_CLASSES", "high-priority")
+	if err := controller.createClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Fatalf("Expected ClusterResourceQuota to be updated, but got error: %v", err)
+	}
+	quota, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ClusterResourceQuota alice-quota to exist, but got error: %v", err)
+	}
+	if cpu := quota.Spec.Quota.Hard[corev1.ResourceRequestsCPU]; cpu.Value() != 8 {
+		t.Errorf("Expected requests.cpu to be limited to 8, but got %s", cpu.String())
+	}
+	if quota.Spec.Quota.ScopeSelector == nil {
+		t.Errorf("Expected ClusterResourceQuota to be scoped to high-priority")
+	}
+}
+
+func TestController_deleteClusterResourceQuota(t *testing.T) {
+	ctx := context.Background()
+	quotaClient := quotafake.NewClientset(&quotav1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "alice-quota",
+		},
+	})
+	controller := &Controller{
+		quotaClient: quotaClient,
+	}
+
+	if err := controller.deleteClusterResourceQuota(ctx, "alice"); err != nil {
+		t.Fatalf("Expected ClusterResourceQuota to be deleted, but got error: %v", err)
+	}
+	_, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected ClusterResourceQuota alice-quota to be deleted, but it still exists")
+	}
+
+	// Deleting a missing quota should not error
+	if err := controller.deleteClusterResourceQuota(ctx, "bob"); err != nil {
+		t.Errorf("Expected missing ClusterResourceQuota to be ignored, but got error: %v", err)
+	}
+}
+
+func TestController_handleGroupClusterResourceQuota(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "requests.cpu=4")
+
+	ctx := context.Background()
+	quotaClient := quotafake.NewClientset()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		quotaClient:   quotaClient,
+	}
+
+	group := &userv1.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-group",
+		},
+		Users: []string{"alice"},
+	}
+	controller.handleGroup(nil, group)
+
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project alice to be created, but got error: %v", err)
+	}
+	if project.Labels[ownerLabel] != "alice" {
+		t.Errorf("Expected project alice to carry owner label, but got labels %v", project.Labels)
+	}
+	if _, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected ClusterResourceQuota alice-quota to be created, but got error: %v", err)
+	}
+
+	// Removing the user should also remove the quota
+	controller.handleGroup(group, &userv1.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-group",
+		},
+	})
+	_, err = quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected ClusterResourceQuota alice-quota to be deleted, but it still exists")
+	}
+}
@@ -0,0 +1,1519 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// GetAPIHost returns the URL of a remote Kubernetes API server to connect to with token file
+// authentication, or an empty string to use the in-cluster or kubeconfig configuration
+func GetAPIHost() string {
+	return strings.TrimSpace(getEnv("KUBE_API_HOST"))
+}
+
+// GetAPITokenFile returns the path of the bearer token used against the remote API server
+func GetAPITokenFile() string {
+	return strings.TrimSpace(getEnv("KUBE_API_TOKEN_FILE"))
+}
+
+// GetAPICAFile returns the path of the CA bundle verifying the remote API server, or an empty
+// string to use the system roots
+func GetAPICAFile() string {
+	return strings.TrimSpace(getEnv("KUBE_API_CA_FILE"))
+}
+
+// GetGroupChangesRecordFile returns the file observed changes to the target group are recorded to as
+// a scenario, or an empty string when they aren't recorded
+func GetGroupChangesRecordFile() string {
+	return getEnv("GROUP_CHANGES_RECORD_FILE")
+}
+
+// GetAdminAPIAddress returns the listen address of the admin API, or an empty string when disabled
+func GetAdminAPIAddress() string {
+	return getEnv("ADMIN_API_ADDRESS")
+}
+
+// GetMetricsBindAddress returns the listen address of the controller-runtime metrics server, or "0"
+// when disabled
+func GetMetricsBindAddress() string {
+	if address := getEnv("METRICS_BIND_ADDRESS"); address != "" {
+		return address
+	}
+	return "0"
+}
+
+// GetHealthProbeBindAddress returns the listen address of the liveness and readiness probes, or an
+// empty string when disabled
+func GetHealthProbeBindAddress() string {
+	return getEnv("HEALTH_PROBE_BIND_ADDRESS")
+}
+
+// default listen address of the pprof endpoint enabled by the --pprof flag
+const defaultPprofBindAddress = "localhost:6060"
+
+var pprofForced bool
+
+// EnablePprof turns on the pprof endpoint regardless of PPROF_BIND_ADDRESS, for the --pprof flag
+func EnablePprof() {
+	pprofForced = true
+}
+
+// GetPprofBindAddress returns the listen address of the net/http/pprof endpoint, or "0" when disabled
+func GetPprofBindAddress() string {
+	if address := getEnv("PPROF_BIND_ADDRESS"); address != "" {
+		return address
+	}
+	if pprofForced {
+		return defaultPprofBindAddress
+	}
+	return "0"
+}
+
+// GetLeaderElectionEnabled returns whether replicas elect a leader, so only one of them reconciles
+func GetLeaderElectionEnabled() bool {
+	return getBoolEnv("LEADER_ELECTION_ENABLED", false)
+}
+
+// GetLeaderElectionNamespace returns the namespace of the leader election Lease, or an empty string
+// for the namespace the controller runs in
+func GetLeaderElectionNamespace() string {
+	return getEnv("LEADER_ELECTION_NAMESPACE")
+}
+
+// GetLeaderElectionID returns the name of the leader election Lease, defaulting to
+// rosa-namespace-provisioner
+func GetLeaderElectionID() string {
+	if id := strings.TrimSpace(getEnv("LEADER_ELECTION_ID")); id != "" {
+		return id
+	}
+	return componentName
+}
+
+// GetLeaderElectionLeaseDuration returns how long standby replicas wait before taking over a Lease
+// that is no longer renewed
+func GetLeaderElectionLeaseDuration() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_LEASE_DURATION", 15*time.Second)
+}
+
+// GetLeaderElectionRenewDeadline returns how long the leader retries renewing its Lease before giving
+// up leadership
+func GetLeaderElectionRenewDeadline() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_RENEW_DEADLINE", 10*time.Second)
+}
+
+// GetLeaderElectionRetryPeriod returns how often replicas try to acquire or renew the Lease
+func GetLeaderElectionRetryPeriod() time.Duration {
+	return getDurationEnv("LEADER_ELECTION_RETRY_PERIOD", 2*time.Second)
+}
+
+// GetProvisioningStepTimeout returns the maximum duration of a single provisioning step
+func GetProvisioningStepTimeout() time.Duration {
+	return getDurationEnv("PROVISIONING_STEP_TIMEOUT", 30*time.Second)
+}
+
+// GetProvisioningMaxFailures returns how many times the provisioning of a user may fail permanently
+// before it is dead-lettered and only retried by admins
+func GetProvisioningMaxFailures() int64 {
+	return getIntEnv("PROVISIONING_MAX_FAILURES", 5)
+}
+
+// GetStepRetryAttempts returns how many times a step failing with a transient error is attempted
+// before the failure is left to the next reconcile
+func GetStepRetryAttempts() int {
+	return int(getIntEnv("STEP_RETRY_ATTEMPTS", 3))
+}
+
+// GetStepRetryBackoff returns the wait before the second attempt of a failed step, doubled for every
+// further one
+func GetStepRetryBackoff() time.Duration {
+	return getDurationEnv("STEP_RETRY_BACKOFF", 500*time.Millisecond)
+}
+
+// whether the --dry-run flag was passed, which takes precedence over the configuration
+var dryRunForced bool
+
+// EnableDryRun turns on dry runs regardless of DRY_RUN_ENABLED, for the --dry-run flag
+func EnableDryRun() {
+	dryRunForced = true
+}
+
+// GetProjectRequestEnabled returns whether projects are created through ProjectRequests, applying the
+// project request template of the cluster, instead of created directly
+func GetProjectRequestEnabled() bool {
+	return getBoolEnv("PROJECT_REQUEST_ENABLED", false)
+}
+
+// GetServerSideApplyEnabled returns whether the managed objects, and the labels and annotations of the
+// namespaces of created projects, are created and restored with server-side apply instead of create and
+// update calls
+func GetServerSideApplyEnabled() bool {
+	return getBoolEnv("SERVER_SIDE_APPLY_ENABLED", true)
+}
+
+// GetFieldManager returns the field manager owning the fields applied by the controller
+func GetFieldManager() string {
+	if manager := strings.TrimSpace(getEnv("FIELD_MANAGER")); manager != "" {
+		return manager
+	}
+	return componentName
+}
+
+// GetServerSideApplyForce returns whether fields set by other managers are taken over when applied,
+// restoring them like updates do, instead of failing the apply with a conflict
+func GetServerSideApplyForce() bool {
+	return getBoolEnv("SERVER_SIDE_APPLY_FORCE", true)
+}
+
+// whether the --adopt-existing flag was passed, which takes precedence over the configuration
+var adoptExistingForced bool
+
+// EnableAdoptExisting turns on the adoption of existing RoleBindings regardless of
+// ADOPT_EXISTING_ROLEBINDINGS, for the --adopt-existing flag
+func EnableAdoptExisting() {
+	adoptExistingForced = true
+}
+
+// GetAdoptExistingEnabled returns whether a RoleBinding named like a managed one but binding other users
+// or not created by the controller is updated to the desired state, instead of failing the user
+func GetAdoptExistingEnabled() bool {
+	return adoptExistingForced || getBoolEnv("ADOPT_EXISTING_ROLEBINDINGS", false)
+}
+
+// GetDryRunEnabled returns whether provisioning and deprovisioning steps are only logged instead of
+// run, so a configuration can be tried against a live cluster without changing it. DRY_RUN is accepted
+// as a shorthand of DRY_RUN_ENABLED.
+func GetDryRunEnabled() bool {
+	return dryRunForced || getBoolEnv("DRY_RUN_ENABLED", getBoolEnv("DRY_RUN", false))
+}
+
+// GetAuditLogEnabled returns whether a structured audit record is logged for every provisioning and
+// deprovisioning step
+func GetAuditLogEnabled() bool {
+	return getBoolEnv("AUDIT_LOG_ENABLED", false)
+}
+
+// GetStepRateLimit returns how many provisioning and deprovisioning steps may start per second across
+// all users, 0 meaning unlimited
+func GetStepRateLimit() float64 {
+	return getFloatEnv("STEP_RATE_LIMIT", 0)
+}
+
+// GetStepRateBurst returns how many steps may start at once before the rate limit applies
+func GetStepRateBurst() int64 {
+	return getIntEnv("STEP_RATE_BURST", 10)
+}
+
+// GetProvisioningSLOTarget returns the maximum time from a user appearing in the group until their
+// namespace is provisioned before the SLO counts as breached
+func GetProvisioningSLOTarget() time.Duration {
+	return getDurationEnv("PROVISIONING_SLO_TARGET", 5*time.Minute)
+}
+
+// GetInformerResyncPeriod returns how often informers replay their cache to the event handlers
+func GetInformerResyncPeriod() time.Duration {
+	return getDurationEnv("INFORMER_RESYNC_PERIOD", 10*time.Minute)
+}
+
+// GetInformerWatchTimeout returns the timeout requested for informer watches, or zero to use the
+// client default
+func GetInformerWatchTimeout() time.Duration {
+	return getDurationEnv("INFORMER_WATCH_TIMEOUT", 0)
+}
+
+// GetWatchBrokenThreshold returns how long an informer watch may stay broken before the readiness
+// probe fails
+func GetWatchBrokenThreshold() time.Duration {
+	return getDurationEnv("WATCH_BROKEN_THRESHOLD", 2*time.Minute)
+}
+
+// GetInformerListPageSize returns the page size of the initial informer lists, or zero to use
+// the client default
+func GetInformerListPageSize() int64 {
+	return getIntEnv("INFORMER_LIST_PAGE_SIZE", 0)
+}
+
+// Modes of handling an invalid configuration at startup
+const (
+	// ConfigValidationStrict refuses to start with an invalid configuration
+	ConfigValidationStrict = "strict"
+	// ConfigValidationWarn logs the invalid values and starts degraded
+	ConfigValidationWarn = "warn"
+)
+
+// GetConfigValidationMode returns how an invalid configuration is handled at startup, falling
+// back to strict validation for unknown modes
+func GetConfigValidationMode() string {
+	if strings.TrimSpace(getEnv("CONFIG_VALIDATION_MODE")) == ConfigValidationWarn {
+		return ConfigValidationWarn
+	}
+	return ConfigValidationStrict
+}
+
+// GetPodNamespace returns the namespace the controller runs in, where the seeded manifests are checked
+// against the cluster at startup, or an empty string to skip the check
+func GetPodNamespace() string {
+	return strings.TrimSpace(getEnv("POD_NAMESPACE"))
+}
+
+// Policies applied to a pre-existing project named after a user but not owned by them
+const (
+	// ExistingProjectSkip provisions into the project without claiming it
+	ExistingProjectSkip = "skip"
+	// ExistingProjectClaim labels the project as owned by the user and manages it
+	ExistingProjectClaim = "claim"
+	// ExistingProjectConflict fails provisioning of the user
+	ExistingProjectConflict = "conflict"
+	// ExistingProjectRename names the project of the user with a hash suffix instead
+	ExistingProjectRename = "rename"
+)
+
+// GetExistingProjectPolicy returns how a pre-existing project not owned by the user is handled
+func GetExistingProjectPolicy() (string, error) {
+	policy := strings.TrimSpace(getEnv("EXISTING_PROJECT_POLICY"))
+	switch policy {
+	case "":
+		return ExistingProjectSkip, nil
+	case ExistingProjectSkip, ExistingProjectClaim, ExistingProjectConflict, ExistingProjectRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid existing project policy %q, expected %s, %s, %s or %s", policy, ExistingProjectSkip, ExistingProjectClaim, ExistingProjectConflict, ExistingProjectRename)
+	}
+}
+
+// placeholder of PROJECT_NAME_TEMPLATE replaced with the user name
+const projectNameUserPlaceholder = "{user}"
+
+// GetProjectNameTemplate returns the Go template naming the project of each user from its .User, where
+// {user} is shorthand for {{ .User }}, defaulting to the user name alone. PROJECT_PREFIX and
+// PROJECT_SUFFIX are added around it.
+func GetProjectNameTemplate() string {
+	return projectNameTemplateSource(lookupEnv)
+}
+
+// Returns the project name template set by the lookup, between its prefix and suffix
+func projectNameTemplateSource(lookup configLookup) string {
+	template := strings.TrimSpace(lookup.get("PROJECT_NAME_TEMPLATE"))
+	if template == "" {
+		template = projectNameUserPlaceholder
+	}
+	return strings.TrimSpace(lookup.get("PROJECT_PREFIX")) + template + strings.TrimSpace(lookup.get("PROJECT_SUFFIX"))
+}
+
+// GetProjectDisplayNameTemplate returns the Go template of the display name of new projects, executed
+// with the user name as .User and their target group as .Group
+func GetProjectDisplayNameTemplate() string {
+	if source := strings.TrimSpace(getEnv("PROJECT_DISPLAY_NAME_TEMPLATE")); source != "" {
+		return source
+	}
+	return "{{ .User }}"
+}
+
+// GetProjectDescriptionTemplate returns the Go template of the description of new projects, executed
+// with the user name as .User and their target group as .Group
+func GetProjectDescriptionTemplate() string {
+	if source := strings.TrimSpace(getEnv("PROJECT_DESCRIPTION_TEMPLATE")); source != "" {
+		return source
+	}
+	return "Sandbox of {{ .User }}, provisioned by " + componentName
+}
+
+// GetNamespaceMappingConfigMap returns the namespace and name of the ConfigMap recording the namespace of
+// each user, from NAMESPACE_MAPPING_CONFIGMAP as <namespace>/<name>, both empty when it is unset
+func GetNamespaceMappingConfigMap() (string, string, error) {
+	value := strings.TrimSpace(getEnv("NAMESPACE_MAPPING_CONFIGMAP"))
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid namespace mapping ConfigMap %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetNamespaceClaimsEnabled returns whether users may request additional namespaces with
+// NamespaceClaims created in their managed namespace
+func GetNamespaceClaimsEnabled() bool {
+	return getBoolEnv("NAMESPACE_CLAIMS_ENABLED", false)
+}
+
+// GetNamespaceClaimsMax returns how many namespaces each user may claim in addition to their own
+func GetNamespaceClaimsMax() int64 {
+	return getIntEnv("NAMESPACE_CLAIMS_MAX", 3)
+}
+
+// GetProvisionerConfigEnabled returns whether the ProvisionerConfig named cluster is watched and
+// applied on top of the environment
+func GetProvisionerConfigEnabled() bool {
+	return getBoolEnv("PROVISIONER_CONFIG_ENABLED", false)
+}
+
+// Policies applied to the project of a user removed from the target groups
+const (
+	// ProjectDeletionDelete deletes the project along with everything in it
+	ProjectDeletionDelete = "Delete"
+	// ProjectDeletionRetain keeps the project managed but removes the RoleBinding of the user
+	ProjectDeletionRetain = "Retain"
+	// ProjectDeletionOrphan keeps the project as is and stops managing it
+	ProjectDeletionOrphan = "Orphan"
+)
+
+// GetProjectDeletionPolicy returns what happens to the project of a user removed from the target groups
+func GetProjectDeletionPolicy() (string, error) {
+	return parseProjectDeletionPolicy(getEnv("PROJECT_DELETION_POLICY"))
+}
+
+// Parses a project deletion policy, defaulting to Delete
+func parseProjectDeletionPolicy(value string) (string, error) {
+	policy := strings.TrimSpace(value)
+	switch policy {
+	case "":
+		return ProjectDeletionDelete, nil
+	case ProjectDeletionDelete, ProjectDeletionRetain, ProjectDeletionOrphan:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid project deletion policy %q, expected %s, %s or %s", policy, ProjectDeletionDelete, ProjectDeletionRetain, ProjectDeletionOrphan)
+	}
+}
+
+// GetDeleteUnmanagedProjects returns whether removed users lose any project named after them, even
+// when the controller didn't create it, as releases before managed-by labels did
+func GetDeleteUnmanagedProjects() bool {
+	return getBoolEnv("DELETE_UNMANAGED_PROJECTS", false)
+}
+
+// GetDeletionGracePeriod returns how long the project of a removed user is kept before it is deleted,
+// zero deleting it right away
+func GetDeletionGracePeriod() time.Duration {
+	return getDurationEnv("DELETION_GRACE_PERIOD", 0)
+}
+
+// GetDeletionSweepInterval returns how often the projects whose deletion was scheduled are checked
+func GetDeletionSweepInterval() time.Duration {
+	return getDurationEnv("DELETION_SWEEP_INTERVAL", 5*time.Minute)
+}
+
+// GetRoleBindingResyncInterval returns how often the RoleBindings of managed namespaces are checked
+// for deletion or modification
+func GetRoleBindingResyncInterval() time.Duration {
+	return getDurationEnv("ROLEBINDING_RESYNC_INTERVAL", 10*time.Minute)
+}
+
+// GetDeletionVerifyInterval returns how often the deletions of the namespaces of deprovisioned users
+// are verified
+func GetDeletionVerifyInterval() time.Duration {
+	return getDurationEnv("DELETION_VERIFY_INTERVAL", 30*time.Second)
+}
+
+// GetDeletionVerifyTimeout returns how long the namespace of a deprovisioned user may take to be
+// deleted before its deletion is reported as stuck
+func GetDeletionVerifyTimeout() time.Duration {
+	return getDurationEnv("DELETION_VERIFY_TIMEOUT", 10*time.Minute)
+}
+
+// GetClusterResourceQuotaEnabled returns whether a per-user ClusterResourceQuota should be managed
+func GetClusterResourceQuotaEnabled() bool {
+	return getBoolEnv("CLUSTER_RESOURCE_QUOTA_ENABLED", false)
+}
+
+// GetClusterResourceQuotaHard returns the hard limits applied by the per-user ClusterResourceQuota
+func GetClusterResourceQuotaHard() (corev1.ResourceList, error) {
+	return parseResourceList(getEnv("CLUSTER_RESOURCE_QUOTA_HARD"))
+}
+
+// GetQuotaPriorityClasses returns the PriorityClasses that managed quotas are scoped to
+func GetQuotaPriorityClasses() []string {
+	return getListEnv("QUOTA_PRIORITY_CLASSES")
+}
+
+// GetQuotaPriorityClassOperator returns how managed quotas match the configured PriorityClasses
+func GetQuotaPriorityClassOperator() (corev1.ScopeSelectorOperator, error) {
+	operator := corev1.ScopeSelectorOperator(getEnv("QUOTA_PRIORITY_CLASS_OPERATOR"))
+	switch operator {
+	case "":
+		return corev1.ScopeSelectorOpIn, nil
+	case corev1.ScopeSelectorOpIn, corev1.ScopeSelectorOpNotIn:
+		return operator, nil
+	default:
+		return "", fmt.Errorf("invalid PriorityClass operator %q, expected %s or %s", operator, corev1.ScopeSelectorOpIn, corev1.ScopeSelectorOpNotIn)
+	}
+}
+
+// ClusterRole granted to users in their namespace unless configured otherwise
+const defaultUserClusterRole = "edit"
+
+// default selectors of the ClusterRoles aggregated into AGGREGATED_CLUSTER_ROLE: every permission of
+// edit plus the ClusterRoles labeled for the sandbox by the platform team
+const defaultAggregatedClusterRoleSelectors = "rbac.authorization.k8s.io/aggregate-to-edit=true;rosa-namespace-provisioner/aggregate-to-sandbox=true"
+
+// GetUserClusterRole returns the ClusterRole granted to users in their namespace: USER_CLUSTER_ROLE,
+// the managed AGGREGATED_CLUSTER_ROLE, or edit
+func GetUserClusterRole() string {
+	return userClusterRole(lookupEnv)
+}
+
+// Returns the ClusterRole granted to users by the configuration of the lookup
+func userClusterRole(lookup configLookup) string {
+	if role := strings.TrimSpace(lookup.get("USER_CLUSTER_ROLE")); role != "" {
+		return role
+	}
+	if role := strings.TrimSpace(lookup.get("AGGREGATED_CLUSTER_ROLE")); role != "" {
+		return role
+	}
+	return defaultUserClusterRole
+}
+
+// GetAggregatedClusterRole returns the name of the aggregated ClusterRole managed by the controller
+// and granted to users, or empty when disabled
+func GetAggregatedClusterRole() string {
+	return strings.TrimSpace(getEnv("AGGREGATED_CLUSTER_ROLE"))
+}
+
+// GetAggregatedClusterRoleSelectors returns the label selectors of the ClusterRoles aggregated into
+// AGGREGATED_CLUSTER_ROLE, separated by semicolons as selectors contain commas, e.g.
+// "rbac.authorization.k8s.io/aggregate-to-edit=true;sandbox/tier in (gpu,standard)"
+func GetAggregatedClusterRoleSelectors() ([]metav1.LabelSelector, error) {
+	value, ok := lookupEnv("AGGREGATED_CLUSTER_ROLE_SELECTORS")
+	if !ok {
+		value = defaultAggregatedClusterRoleSelectors
+	}
+	var selectors []metav1.LabelSelector
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		selector, err := metav1.ParseToLabelSelector(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ClusterRole selector %q: %w", entry, err)
+		}
+		selectors = append(selectors, *selector)
+	}
+	if len(selectors) == 0 {
+		return nil, errors.New("no ClusterRole selectors configured")
+	}
+	return selectors, nil
+}
+
+// GetUserClusterRoleOverrides returns the ClusterRole granted to the users of a target group instead
+// of USER_CLUSTER_ROLE, configured as "<group>=<cluster-role>" entries, e.g. "workshop-staff=admin"
+func GetUserClusterRoleOverrides() (map[string]string, error) {
+	return parseUserClusterRoleOverrides(getListEnv("USER_CLUSTER_ROLE_OVERRIDES"))
+}
+
+// Parses "<group>=<cluster-role>" ClusterRole overrides
+func parseUserClusterRoleOverrides(entries []string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, entry := range entries {
+		group, role, found := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		role = strings.TrimSpace(role)
+		if !found || group == "" || role == "" {
+			return nil, fmt.Errorf("invalid ClusterRole override %q, expected <group>=<cluster-role>", entry)
+		}
+		overrides[group] = role
+	}
+	return overrides, nil
+}
+
+// GetAdditionalRoleBindings returns the RoleBindings granting the owner of every managed namespace
+// other ClusterRoles besides USER_CLUSTER_ROLE, configured as "<suffix>=<cluster-role>" entries, e.g.
+// "monitoring=monitoring-rules-view", each named "<namespace>-<suffix>"
+func GetAdditionalRoleBindings() ([]AdditionalRoleBinding, error) {
+	return parseAdditionalRoleBindings(getListEnv("ADDITIONAL_ROLE_BINDINGS"))
+}
+
+// Parses "<suffix>=<cluster-role>" additional RoleBindings
+func parseAdditionalRoleBindings(entries []string) ([]AdditionalRoleBinding, error) {
+	var bindings []AdditionalRoleBinding
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		suffix, role, found := strings.Cut(entry, "=")
+		suffix = strings.TrimSpace(suffix)
+		role = strings.TrimSpace(role)
+		if !found || suffix == "" || role == "" {
+			return nil, fmt.Errorf("invalid RoleBinding %q, expected <suffix>=<cluster-role>", entry)
+		}
+		if msgs := validation.IsDNS1123Label(suffix); len(msgs) > 0 {
+			return nil, fmt.Errorf("invalid RoleBinding suffix %q: %s", suffix, strings.Join(msgs, ", "))
+		}
+		if reservedRoleBindingSuffixes[suffix] {
+			return nil, fmt.Errorf("RoleBinding suffix %q is used by the controller", suffix)
+		}
+		if seen[suffix] {
+			return nil, fmt.Errorf("duplicate RoleBinding suffix %q", suffix)
+		}
+		seen[suffix] = true
+		bindings = append(bindings, AdditionalRoleBinding{Suffix: suffix, ClusterRole: role})
+	}
+	return bindings, nil
+}
+
+// GetAccessWindows returns the periods during which the members of target groups may access their
+// namespaces, configured as "<group>=<start>/<end>" entries of inclusive UTC dates, e.g.
+// "spring-cohort=2026-03-01/2026-04-30". A group may have several windows; groups without any always
+// have access.
+func GetAccessWindows() (map[string][]AccessWindow, error) {
+	return parseAccessWindows(getListEnv("ACCESS_WINDOWS"))
+}
+
+// Parses "<group>=<start>/<end>" access windows
+func parseAccessWindows(entries []string) (map[string][]AccessWindow, error) {
+	windows := make(map[string][]AccessWindow)
+	for _, entry := range entries {
+		group, value, found := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		if !found || group == "" {
+			return nil, fmt.Errorf("invalid access window %q, expected <group>=<start>/<end>", entry)
+		}
+		window, err := parseAccessWindow(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access window %q: %w", entry, err)
+		}
+		windows[group] = append(windows[group], window)
+	}
+	return windows, nil
+}
+
+// GetAccessWindowSyncInterval returns how often access windows are checked for opening or closing
+func GetAccessWindowSyncInterval() time.Duration {
+	return getDurationEnv("ACCESS_WINDOW_SYNC_INTERVAL", time.Minute)
+}
+
+// GetDenyLoadBalancersEnabled returns whether a ResourceQuota denying LoadBalancer Services is seeded
+// into every managed namespace
+func GetDenyLoadBalancersEnabled() bool {
+	return getBoolEnv("DENY_LOAD_BALANCERS_ENABLED", false)
+}
+
+// GetObjectCountQuotaEnabled returns whether a ResourceQuota limiting object counts is seeded into
+// every managed namespace
+func GetObjectCountQuotaEnabled() bool {
+	return getBoolEnv("OBJECT_COUNT_QUOTA_ENABLED", false)
+}
+
+// GetObjectCountQuotaHard returns the object count limits seeded into every managed namespace
+func GetObjectCountQuotaHard() (corev1.ResourceList, error) {
+	return parseResourceList(getEnv("OBJECT_COUNT_QUOTA_HARD"))
+}
+
+// GetAuditTaggingEnabled returns whether managed namespaces are labeled with their owner for the
+// cluster audit pipeline
+func GetAuditTaggingEnabled() bool {
+	return getBoolEnv("AUDIT_TAGGING_ENABLED", false)
+}
+
+// GetAuditTenantLabels returns the keys of the namespace labels set to the owner of every managed
+// namespace, defaulting to rosa-namespace-provisioner/audit-tenant
+func GetAuditTenantLabels() []string {
+	if keys := getListEnv("AUDIT_TENANT_LABELS"); len(keys) > 0 {
+		return keys
+	}
+	return []string{auditTenantLabel}
+}
+
+// GetResourceQuotaEnabled returns whether a ResourceQuota limiting compute and storage is seeded into
+// every managed namespace
+func GetResourceQuotaEnabled() bool {
+	return getBoolEnv("RESOURCE_QUOTA_ENABLED", false)
+}
+
+// GetResourceQuotaHard returns the compute and storage limits seeded into every managed namespace
+func GetResourceQuotaHard() (corev1.ResourceList, error) {
+	return parseResourceList(getEnv("RESOURCE_QUOTA_HARD"))
+}
+
+// GetProfiles returns the provisioning profiles users can select, from the "<profile>:<resource>=<quantity>"
+// entries of PROFILE_QUOTAS and the "<profile>=<cluster-role>" entries of PROFILE_CLUSTER_ROLES
+func GetProfiles() (map[string]Profile, error) {
+	return parseProfiles(getListEnv("PROFILE_QUOTAS"), getListEnv("PROFILE_CLUSTER_ROLES"))
+}
+
+// GetProfileDefault returns the profile of users selecting none, or "" to leave them unprofiled
+func GetProfileDefault() string {
+	return strings.TrimSpace(getEnv("PROFILE_DEFAULT"))
+}
+
+// GetLimitRangeEnabled returns whether a LimitRange with default container requests and limits is
+// seeded into every managed namespace
+func GetLimitRangeEnabled() bool {
+	return getBoolEnv("LIMIT_RANGE_ENABLED", false)
+}
+
+// GetLimitRangeFile returns the path of the LimitRange template, e.g. a mounted ConfigMap
+func GetLimitRangeFile() string {
+	return getEnv("LIMIT_RANGE_FILE")
+}
+
+// GetNetworkPoliciesEnabled returns whether NetworkPolicies isolating every managed namespace are
+// seeded into it
+func GetNetworkPoliciesEnabled() bool {
+	return getBoolEnv("NETWORK_POLICIES_ENABLED", false)
+}
+
+// GetNetworkPoliciesFile returns the path of the NetworkPolicy templates, e.g. a mounted ConfigMap,
+// replacing the baseline policies when set
+func GetNetworkPoliciesFile() string {
+	return getEnv("NETWORK_POLICIES_FILE")
+}
+
+// GetBandwidthLimitsEnabled returns whether a NetworkQoS limiting the egress bandwidth of every
+// managed namespace is seeded into it
+func GetBandwidthLimitsEnabled() bool {
+	return getBoolEnv("BANDWIDTH_LIMITS_ENABLED", false)
+}
+
+// GetBandwidthTiers returns the named egress bandwidth limits users can be assigned to
+func GetBandwidthTiers() (map[string]BandwidthTier, error) {
+	return parseBandwidthTiers(getListEnv("BANDWIDTH_TIERS"))
+}
+
+// GetBandwidthDefaultTier returns the bandwidth tier of users without an override, or "" to leave
+// their bandwidth unlimited
+func GetBandwidthDefaultTier() string {
+	return getEnv("BANDWIDTH_DEFAULT_TIER")
+}
+
+// GetBandwidthTierOverrides returns the bandwidth tier of the members of each target group which
+// overrides the default tier
+func GetBandwidthTierOverrides() (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, entry := range getListEnv("BANDWIDTH_TIER_OVERRIDES") {
+		group, tier, found := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		tier = strings.TrimSpace(tier)
+		if !found || group == "" || tier == "" {
+			return nil, fmt.Errorf("invalid bandwidth tier override %q, expected <group>=<tier>", entry)
+		}
+		overrides[group] = tier
+	}
+	return overrides, nil
+}
+
+// GetMembershipSources returns the sources granting users a namespace in priority order, defaulting
+// to the target groups alone
+func GetMembershipSources() []string {
+	if sources := getListEnv("MEMBERSHIP_SOURCES"); len(sources) > 0 {
+		return sources
+	}
+	return []string{MembershipSourceGroup}
+}
+
+// GetMembershipMergePolicy returns how the members of several sources are merged, either union or
+// intersection
+func GetMembershipMergePolicy() string {
+	if policy := strings.TrimSpace(getEnv("MEMBERSHIP_MERGE_POLICY")); policy != "" {
+		return policy
+	}
+	return MergePolicyUnion
+}
+
+// GetMembershipSyncInterval returns how often the members of merged membership sources are synced
+func GetMembershipSyncInterval() time.Duration {
+	return getDurationEnv("MEMBERSHIP_SYNC_INTERVAL", 5*time.Minute)
+}
+
+// GetRosterConfigMap returns the namespace and name of the ConfigMap listing the users of the roster
+// membership source, from ROSTER_CONFIGMAP as <namespace>/<name>
+func GetRosterConfigMap() (string, string, error) {
+	value := strings.TrimSpace(getEnv("ROSTER_CONFIGMAP"))
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid roster ConfigMap %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetRosterConfigMapKey returns the key of the roster ConfigMap listing the users
+func GetRosterConfigMapKey() string {
+	if key := strings.TrimSpace(getEnv("ROSTER_CONFIGMAP_KEY")); key != "" {
+		return key
+	}
+	return "users"
+}
+
+// GetGitHubTeam returns the organization and slug of the GitHub team of the github membership
+// source, from GITHUB_TEAM as <org>/<team>
+func GetGitHubTeam() (string, string, error) {
+	value := strings.TrimSpace(getEnv("GITHUB_TEAM"))
+	org, team, found := strings.Cut(value, "/")
+	if !found || org == "" || team == "" {
+		return "", "", fmt.Errorf("invalid GitHub team %q, expected <org>/<team>", value)
+	}
+	return org, team, nil
+}
+
+// GetGitHubToken returns the token reading the members of the GitHub team
+func GetGitHubToken() string {
+	return strings.TrimSpace(getEnv("GITHUB_TOKEN"))
+}
+
+// GetGitHubAPIURL returns the base URL of the GitHub API, e.g. of GitHub Enterprise Server
+func GetGitHubAPIURL() string {
+	if url := strings.TrimSpace(getEnv("GITHUB_API_URL")); url != "" {
+		return url
+	}
+	return "https://api.github.com"
+}
+
+// GetManagedNamespacesEnabled returns whether a ManagedNamespace inventory record is maintained per namespace
+func GetManagedNamespacesEnabled() bool {
+	return getBoolEnv("MANAGED_NAMESPACES_ENABLED", false)
+}
+
+// GetApprovalRequired returns whether users added to the group wait for an admin to approve
+// provisioning their namespace
+func GetApprovalRequired() bool {
+	return getBoolEnv("APPROVAL_REQUIRED", false)
+}
+
+// GetOwnerReferencesEnabled returns whether seeded namespaced objects are owned by a per-namespace anchor
+func GetOwnerReferencesEnabled() bool {
+	return getBoolEnv("OWNER_REFERENCES_ENABLED", false)
+}
+
+// GetNamespaceFinalizerEnabled returns whether managed namespaces are protected by a finalizer
+func GetNamespaceFinalizerEnabled() bool {
+	return getBoolEnv("NAMESPACE_FINALIZER_ENABLED", false)
+}
+
+// GetGroupFinalizerEnabled returns whether the target groups carry a finalizer so their deletion tears
+// down the namespaces of their members before the groups disappear
+func GetGroupFinalizerEnabled() bool {
+	return getBoolEnv("GROUP_FINALIZER_ENABLED", false)
+}
+
+// GetDeletionMaintenanceWindow returns the daily "HH:MM-HH:MM" UTC window in which managed
+// namespaces may be deleted, or an empty string when deletions are allowed at any time
+func GetDeletionMaintenanceWindow() string {
+	return strings.TrimSpace(getEnv("DELETION_MAINTENANCE_WINDOW"))
+}
+
+// GetQuotaWarningsEnabled returns whether quota usage in managed namespaces is checked periodically
+func GetQuotaWarningsEnabled() bool {
+	return getBoolEnv("QUOTA_WARNINGS_ENABLED", false)
+}
+
+// GetQuotaWarningThreshold returns the fraction of a quota's hard limit above which owners are warned
+func GetQuotaWarningThreshold() float64 {
+	percent, err := strconv.ParseFloat(getEnv("QUOTA_WARNING_THRESHOLD"), 64)
+	if err != nil || percent <= 0 || percent > 100 {
+		return 0.9
+	}
+	return percent / 100
+}
+
+// GetConsoleNotificationsEnabled returns whether a ConsoleNotification banner announces managed
+// namespaces waiting for the maintenance window to be deleted
+func GetConsoleNotificationsEnabled() bool {
+	return getBoolEnv("CONSOLE_NOTIFICATIONS_ENABLED", false)
+}
+
+// GetConsoleNotificationInterval returns how often the ConsoleNotification banner is synced
+func GetConsoleNotificationInterval() time.Duration {
+	return getDurationEnv("CONSOLE_NOTIFICATION_INTERVAL", time.Minute)
+}
+
+// GetConsoleNotificationLink returns the URL the ConsoleNotification banner links to, e.g. the
+// sandbox usage policy, or an empty string for no link
+func GetConsoleNotificationLink() string {
+	return getEnv("CONSOLE_NOTIFICATION_LINK")
+}
+
+// GetOnboardingEnabled returns whether an onboarding artifact explaining how to access their
+// namespace is delivered to every provisioned user
+func GetOnboardingEnabled() bool {
+	return getBoolEnv("ONBOARDING_ENABLED", false)
+}
+
+// GetOnboardingDelivery returns how onboarding artifacts are delivered: stored as a Secret in the
+// namespace, sent as a notification, or both, defaulting to a Secret
+func GetOnboardingDelivery() []string {
+	if delivery := getListEnv("ONBOARDING_DELIVERY"); len(delivery) > 0 {
+		return delivery
+	}
+	return []string{OnboardingDeliverySecret}
+}
+
+// GetOnboardingAPIURL returns the URL of the cluster API server users log in to, e.g.
+// https://api.my-rosa.example.com:443
+func GetOnboardingAPIURL() string {
+	return strings.TrimSuffix(strings.TrimSpace(getEnv("ONBOARDING_API_URL")), "/")
+}
+
+// GetOnboardingConsoleURL returns the URL of the OpenShift web console linked from onboarding
+// artifacts, or an empty string for no link
+func GetOnboardingConsoleURL() string {
+	return strings.TrimSuffix(strings.TrimSpace(getEnv("ONBOARDING_CONSOLE_URL")), "/")
+}
+
+// GetQuotaWarningInterval returns how often quota usage in managed namespaces is checked
+func GetQuotaWarningInterval() time.Duration {
+	return getDurationEnv("QUOTA_WARNING_INTERVAL", 15*time.Minute)
+}
+
+// GetCostTrackingEnabled returns whether AWS resources created from managed namespaces are tagged
+// with their owner and the estimated cost of each namespace is exported as a metric
+func GetCostTrackingEnabled() bool {
+	return getBoolEnv("COST_TRACKING_ENABLED", false)
+}
+
+// GetCostTrackingInterval returns how often managed namespaces are tagged and their cost estimated
+func GetCostTrackingInterval() time.Duration {
+	return getDurationEnv("COST_TRACKING_INTERVAL", 15*time.Minute)
+}
+
+// GetCostTagKey returns the key of the AWS tag recording the owner of resources created from
+// managed namespaces
+func GetCostTagKey() string {
+	key := strings.TrimSpace(getEnv("COST_TAG_KEY"))
+	if key == "" {
+		return ownerLabel
+	}
+	return key
+}
+
+// CostPrices are the prices in dollars used to estimate the cost of a namespace
+type CostPrices struct {
+	CPUCoreHour      float64
+	MemoryGiBHour    float64
+	StorageGiBMonth  float64
+	LoadBalancerHour float64
+}
+
+// GetCostPrices returns the prices used to estimate the cost of a namespace, defaulting to
+// approximate us-east-1 on-demand prices
+func GetCostPrices() CostPrices {
+	return CostPrices{
+		CPUCoreHour:      getFloatEnv("COST_PRICE_CPU_CORE_HOUR", 0.0425),
+		MemoryGiBHour:    getFloatEnv("COST_PRICE_MEMORY_GIB_HOUR", 0.0053),
+		StorageGiBMonth:  getFloatEnv("COST_PRICE_STORAGE_GIB_MONTH", 0.08),
+		LoadBalancerHour: getFloatEnv("COST_PRICE_LOAD_BALANCER_HOUR", 0.0225),
+	}
+}
+
+// GetNotificationWebhookURL returns the webhook notifications are posted to, or an empty string when disabled
+func GetNotificationWebhookURL() string {
+	return getEnv("NOTIFICATION_WEBHOOK_URL")
+}
+
+// Notification providers
+const (
+	// NotificationProviderWebhook posts notifications as JSON to NOTIFICATION_WEBHOOK_URL
+	NotificationProviderWebhook = "webhook"
+	// NotificationProviderSlack posts notifications to the NOTIFICATION_SLACK_WEBHOOK_URL incoming webhook
+	NotificationProviderSlack = "slack"
+	// NotificationProviderEmail sends notifications through the NOTIFICATION_SMTP_ADDRESS server
+	NotificationProviderEmail = "email"
+	// NotificationProviderEvents records notifications as Kubernetes Events on the namespace
+	NotificationProviderEvents = "events"
+)
+
+// NotificationProvider is a configured notification provider and the minimum severity it receives
+type NotificationProvider struct {
+	Name        string
+	MinSeverity string
+}
+
+// GetNotificationProviders returns the providers notifications are fanned out to, configured as
+// "<provider>[:<min-severity>]" entries, e.g. "slack:error,events". Without providers, notifications
+// are posted to NOTIFICATION_WEBHOOK_URL when it is set.
+func GetNotificationProviders() ([]NotificationProvider, error) {
+	entries := getListEnv("NOTIFICATION_PROVIDERS")
+	if len(entries) == 0 && GetNotificationWebhookURL() != "" {
+		return []NotificationProvider{{Name: NotificationProviderWebhook, MinSeverity: notify.SeverityInfo}}, nil
+	}
+
+	var providers []NotificationProvider
+	for _, entry := range entries {
+		name, severity, found := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		severity = strings.TrimSpace(severity)
+		if !found {
+			severity = notify.SeverityInfo
+		}
+		switch name {
+		case NotificationProviderWebhook, NotificationProviderSlack, NotificationProviderEmail, NotificationProviderEvents:
+		default:
+			return nil, fmt.Errorf("invalid notification provider %q, expected %s, %s, %s or %s", name,
+				NotificationProviderWebhook,
+				NotificationProviderSlack,
+				NotificationProviderEmail,
+				NotificationProviderEvents,
+			)
+		}
+		if !notify.ValidSeverity(severity) {
+			return nil, fmt.Errorf("invalid severity %q of notification provider %s, expected %s, %s or %s", severity, name,
+				notify.SeverityInfo,
+				notify.SeverityWarning,
+				notify.SeverityError,
+			)
+		}
+		providers = append(providers, NotificationProvider{Name: name, MinSeverity: severity})
+	}
+	return providers, nil
+}
+
+// GetNotificationSlackWebhookURL returns the Slack incoming webhook of the slack notification provider
+func GetNotificationSlackWebhookURL() string {
+	return strings.TrimSpace(getEnv("NOTIFICATION_SLACK_WEBHOOK_URL"))
+}
+
+// GetNotificationSMTPAddress returns the "host:port" of the SMTP server of the email notification provider
+func GetNotificationSMTPAddress() string {
+	return strings.TrimSpace(getEnv("NOTIFICATION_SMTP_ADDRESS"))
+}
+
+// GetNotificationSMTPUsername returns the username authenticating against the SMTP server, or an
+// empty string to send without authentication
+func GetNotificationSMTPUsername() string {
+	return getEnv("NOTIFICATION_SMTP_USERNAME")
+}
+
+// GetNotificationSMTPPassword returns the password authenticating against the SMTP server
+func GetNotificationSMTPPassword() string {
+	return getEnv("NOTIFICATION_SMTP_PASSWORD")
+}
+
+// GetNotificationEmailFrom returns the sender address of notification emails
+func GetNotificationEmailFrom() string {
+	return strings.TrimSpace(getEnv("NOTIFICATION_EMAIL_FROM"))
+}
+
+// GetNotificationEmailDomain returns the domain namespace owners receive notification emails at
+// as "<username>@<domain>"
+func GetNotificationEmailDomain() string {
+	return strings.TrimSpace(getEnv("NOTIFICATION_EMAIL_DOMAIN"))
+}
+
+// GetNotificationEmailConfigMap returns the namespace and name of the ConfigMap mapping user names to the
+// addresses onboarding emails are sent to, from NOTIFICATION_EMAIL_CONFIGMAP as <namespace>/<name>, or
+// empty strings when unset
+func GetNotificationEmailConfigMap() (string, string, error) {
+	value := strings.TrimSpace(getEnv("NOTIFICATION_EMAIL_CONFIGMAP"))
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid email ConfigMap %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetNotificationEmailFromIdentity returns whether onboarding emails are sent to the email of the
+// identities of the user, as recorded by identity providers such as OpenID Connect
+func GetNotificationEmailFromIdentity() bool {
+	return getBoolEnv("NOTIFICATION_EMAIL_FROM_IDENTITY", false)
+}
+
+// GetNotificationEmailTo returns the recipients of notification emails without an owner, e.g. digests
+func GetNotificationEmailTo() []string {
+	return getListEnv("NOTIFICATION_EMAIL_TO")
+}
+
+// Modes of delivering provisioning notifications
+const (
+	// NotificationModeImmediate notifies the owner of every provisioning result
+	NotificationModeImmediate = "immediate"
+	// NotificationModeDigest sends a periodic summary of provisioning results instead
+	NotificationModeDigest = "digest"
+)
+
+// GetNotificationMode returns how provisioning notifications are delivered, falling back to
+// immediate notifications for unknown modes
+func GetNotificationMode() string {
+	if strings.TrimSpace(getEnv("NOTIFICATION_MODE")) == NotificationModeDigest {
+		return NotificationModeDigest
+	}
+	return NotificationModeImmediate
+}
+
+// GetNotificationDigestInterval returns the window over which provisioning results are batched in digest mode
+func GetNotificationDigestInterval() time.Duration {
+	return getDurationEnv("NOTIFICATION_DIGEST_INTERVAL", time.Hour)
+}
+
+// GetIntegrationFailureThreshold returns the number of consecutive failures after which an
+// optional integration is disabled
+func GetIntegrationFailureThreshold() int64 {
+	return getIntEnv("INTEGRATION_FAILURE_THRESHOLD", 5)
+}
+
+// GetIntegrationDisableDuration returns how long a persistently failing integration stays disabled
+// before it is tried again
+func GetIntegrationDisableDuration() time.Duration {
+	return getDurationEnv("INTEGRATION_DISABLE_DURATION", 10*time.Minute)
+}
+
+// GetExternalCleanupRate returns how many external cleanups may start per second across all
+// integrations
+func GetExternalCleanupRate() float64 {
+	rate := getFloatEnv("EXTERNAL_CLEANUP_RATE", 1)
+	if rate == 0 {
+		return 1
+	}
+	return rate
+}
+
+// GetExternalCleanupBurst returns how many external cleanups may start at once before the rate applies
+func GetExternalCleanupBurst() int64 {
+	return getIntEnv("EXTERNAL_CLEANUP_BURST", 5)
+}
+
+// GetExternalCleanupMaxRetries returns how many times a failed external cleanup is retried before
+// it is moved to the dead letters
+func GetExternalCleanupMaxRetries() int64 {
+	return getIntEnv("EXTERNAL_CLEANUP_MAX_RETRIES", 10)
+}
+
+// GetIdleShutdownAfter returns how long the controller may go without provisioning or deprovisioning
+// anyone before it shuts down, zero to never shut down
+func GetIdleShutdownAfter() time.Duration {
+	return getDurationEnv("IDLE_SHUTDOWN_AFTER", 0)
+}
+
+// GetIdleShutdownDeployment returns the namespace and name of the controller's Deployment, scaled to
+// zero replicas when the controller shuts down idle, or empty strings to only exit
+func GetIdleShutdownDeployment() (string, string, error) {
+	value := strings.TrimSpace(getEnv("IDLE_SHUTDOWN_DEPLOYMENT"))
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid deployment %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetDeprovisionReportsDir returns the directory signed reports of fully deprovisioned users are
+// written to, or an empty string when no reports are generated
+func GetDeprovisionReportsDir() string {
+	return strings.TrimSpace(getEnv("DEPROVISION_REPORTS_DIR"))
+}
+
+// GetDeprovisionReportKeyFile returns the path of the key signing deprovision reports
+func GetDeprovisionReportKeyFile() string {
+	return strings.TrimSpace(getEnv("DEPROVISION_REPORT_KEY_FILE"))
+}
+
+// GetDeprovisionReportsURL returns the base URL deprovision reports are served from, linking them
+// from notifications, or an empty string to name the report file instead
+func GetDeprovisionReportsURL() string {
+	return strings.TrimSuffix(strings.TrimSpace(getEnv("DEPROVISION_REPORTS_URL")), "/")
+}
+
+// GetLifecycleWebhookURL returns the HTTPS endpoint lifecycle events are posted to, or an empty string
+// when they aren't sent
+func GetLifecycleWebhookURL() string {
+	return strings.TrimSpace(getEnv("LIFECYCLE_WEBHOOK_URL"))
+}
+
+// GetLifecycleWebhookKeyFile returns the path of the key signing lifecycle events
+func GetLifecycleWebhookKeyFile() string {
+	return strings.TrimSpace(getEnv("LIFECYCLE_WEBHOOK_KEY_FILE"))
+}
+
+// GetLifecycleWebhookMaxAttempts returns how many times the delivery of a lifecycle event is attempted
+// before it is given up
+func GetLifecycleWebhookMaxAttempts() int {
+	return int(getIntEnv("LIFECYCLE_WEBHOOK_MAX_ATTEMPTS", 5))
+}
+
+// LoadLifecycleWebhookKey reads the key signing lifecycle events from LIFECYCLE_WEBHOOK_KEY_FILE
+func LoadLifecycleWebhookKey() ([]byte, error) {
+	return readKeyFile(GetLifecycleWebhookKeyFile())
+}
+
+// Reads a signing key, e.g. from a mounted Secret, ignoring surrounding whitespace
+func readKeyFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("no signing key file configured")
+	}
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key = bytes.TrimSpace(key)
+	if len(key) == 0 {
+		return nil, fmt.Errorf("signing key file %s is empty", path)
+	}
+	return key, nil
+}
+
+// GetTracingEndpoint returns the OTLP/HTTP endpoint spans are exported to, from
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT or else the traces path of OTEL_EXPORTER_OTLP_ENDPOINT, or an empty
+// string when tracing is disabled
+func GetTracingEndpoint() string {
+	if endpoint := strings.TrimSpace(getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := strings.TrimSpace(getEnv("OTEL_EXPORTER_OTLP_ENDPOINT")); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+// GetTracingServiceName returns the service name of the exported spans
+func GetTracingServiceName() string {
+	if name := strings.TrimSpace(getEnv("OTEL_SERVICE_NAME")); name != "" {
+		return name
+	}
+	return componentName
+}
+
+// GetDelegatesEnabled returns whether the delegates listed on managed namespaces are granted access and
+// notified alongside the owner
+func GetDelegatesEnabled() bool {
+	return getBoolEnv("DELEGATES_ENABLED", false)
+}
+
+// GetOpsGroupName returns the group of platform admins granted OPS_CLUSTER_ROLE in every managed
+// namespace, or an empty string when no ops group is configured
+func GetOpsGroupName() string {
+	return strings.TrimSpace(getEnv("OPS_GROUP_NAME"))
+}
+
+// GetOpsClusterRole returns the ClusterRole granted to the ops group in every managed namespace
+func GetOpsClusterRole() string {
+	if role := strings.TrimSpace(getEnv("OPS_CLUSTER_ROLE")); role != "" {
+		return role
+	}
+	return "admin"
+}
+
+// GetPeerClusterRole returns the ClusterRole granted to the target groups of the owner in every managed
+// namespace, or an empty string when peers are granted no access
+func GetPeerClusterRole() string {
+	return strings.TrimSpace(getEnv("PEER_CLUSTER_ROLE"))
+}
+
+// GetAutomationServiceAccount returns the name of the ServiceAccount created in every managed namespace
+// for CI and automation, or an empty string when none is created
+func GetAutomationServiceAccount() string {
+	return strings.TrimSpace(getEnv("AUTOMATION_SERVICE_ACCOUNT"))
+}
+
+// GetAutomationClusterRole returns the ClusterRole granted to the automation ServiceAccount in its namespace
+func GetAutomationClusterRole() string {
+	if role := strings.TrimSpace(getEnv("AUTOMATION_CLUSTER_ROLE")); role != "" {
+		return role
+	}
+	return "edit"
+}
+
+// GetAutomationPullSecret returns the namespace and name of the image pull Secret copied into every managed
+// namespace for the automation ServiceAccount, from AUTOMATION_PULL_SECRET as <namespace>/<name>, both
+// empty when it is unset
+func GetAutomationPullSecret() (string, string, error) {
+	value := strings.TrimSpace(getEnv("AUTOMATION_PULL_SECRET"))
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid automation pull Secret %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetSeedNamespace returns the namespace holding the Secrets and ConfigMaps copied into every managed
+// namespace, or an empty string when nothing is copied
+func GetSeedNamespace() string {
+	return strings.TrimSpace(getEnv("SEED_NAMESPACE"))
+}
+
+// GetSeedSecrets returns the names of the Secrets of SEED_NAMESPACE copied into every managed namespace
+func GetSeedSecrets() []string {
+	return getListEnv("SEED_SECRETS")
+}
+
+// GetSeedConfigMaps returns the names of the ConfigMaps of SEED_NAMESPACE copied into every managed namespace
+func GetSeedConfigMaps() []string {
+	return getListEnv("SEED_CONFIGMAPS")
+}
+
+// GetTemplateBundleDir returns the directory of the manifest templates applied into every managed
+// namespace, typically a mounted ConfigMap, or an empty string when none is configured
+func GetTemplateBundleDir() string {
+	return strings.TrimSpace(getEnv("TEMPLATE_BUNDLE_DIR"))
+}
+
+// GetTemplateBundleConfigMap returns the namespace and name of the ConfigMap of manifest templates applied
+// into every managed namespace, from TEMPLATE_BUNDLE_CONFIGMAP as <namespace>/<name>, both empty when it
+// is unset
+func GetTemplateBundleConfigMap() (string, string, error) {
+	value := strings.TrimSpace(getEnv("TEMPLATE_BUNDLE_CONFIGMAP"))
+	if value == "" {
+		return "", "", nil
+	}
+	namespace, name, found := strings.Cut(value, "/")
+	if !found || namespace == "" || name == "" {
+		return "", "", fmt.Errorf("invalid template bundle ConfigMap %q, expected <namespace>/<name>", value)
+	}
+	return namespace, name, nil
+}
+
+// GetRecreateDeletedProjectsEnabled returns whether the project of a user still granted a namespace
+// is provisioned again when deleted out-of-band
+func GetRecreateDeletedProjectsEnabled() bool {
+	return getBoolEnv("RECREATE_DELETED_PROJECTS", false)
+}
+
+// GetStartupCleanupEnabled returns whether users no longer members of the target groups are
+// deprovisioned when the controller starts
+func GetStartupCleanupEnabled() bool {
+	return getBoolEnv("STARTUP_CLEANUP_ENABLED", false)
+}
+
+// GetGroupAnomalyDetectionEnabled returns whether deprovisioning is paused when the membership of a
+// target group drops anomalously
+func GetGroupAnomalyDetectionEnabled() bool {
+	return getBoolEnv("GROUP_ANOMALY_DETECTION_ENABLED", false)
+}
+
+// GetGroupAnomalyThreshold returns the fraction of a target group's members which, when removed in a
+// single update, is flagged as an anomaly
+func GetGroupAnomalyThreshold() float64 {
+	percent, err := strconv.ParseFloat(getEnv("GROUP_ANOMALY_THRESHOLD"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return 0.5
+	}
+	return percent / 100
+}
+
+// GetGroupAnomalyMinSize returns the size below which target groups are not checked for anomalies
+func GetGroupAnomalyMinSize() int64 {
+	return getIntEnv("GROUP_ANOMALY_MIN_SIZE", 10)
+}
+
+// GetNestedGroupsEnabled returns whether members naming another Group should be expanded into its users
+func GetNestedGroupsEnabled() bool {
+	return getBoolEnv("NESTED_GROUPS_ENABLED", false)
+}
+
+// GetSubGroupNames returns the configured sub-groups whose users are merged into the target group
+func GetSubGroupNames() []string {
+	return getListEnv("SUB_GROUP_NAMES")
+}
+
+// GetExcludedUsers returns the users of the target groups never provisioned a namespace, e.g. service
+// accounts, bots and admins
+func GetExcludedUsers() []string {
+	return getListEnv("EXCLUDED_USERS")
+}
+
+// GetExcludedUserRegex returns the regular expression matching the whole name of the users never
+// provisioned a namespace, nil when EXCLUDED_USER_REGEX is unset
+func GetExcludedUserRegex() (*regexp.Regexp, error) {
+	return userPattern(getEnv("EXCLUDED_USER_REGEX"))
+}
+
+// GetAllowedUsers returns the only users of the target groups provisioned a namespace along with those
+// matching ALLOWED_USER_REGEX, e.g. the pilot cohort of a phased rollout. Every user is allowed when
+// neither is set.
+func GetAllowedUsers() []string {
+	return getListEnv("ALLOWED_USERS")
+}
+
+// GetAllowedUserRegex returns the regular expression matching the whole name of the users allowed a
+// namespace, nil when ALLOWED_USER_REGEX is unset
+func GetAllowedUserRegex() (*regexp.Regexp, error) {
+	return userPattern(getEnv("ALLOWED_USER_REGEX"))
+}
+
+// Compiles a regular expression matching whole user names, nil when the pattern is empty
+func userPattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid user regular expression %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// GetAWSSecretsEnabled returns whether secrets should be materialized from AWS Secrets Manager
+func GetAWSSecretsEnabled() bool {
+	return getBoolEnv("AWS_SECRETS_ENABLED", false)
+}
+
+// GetAWSSecretMappings returns the AWS secrets materialized into every user project
+func GetAWSSecretMappings() ([]SecretMapping, error) {
+	return parseSecretMappings(getListEnv("AWS_SECRETS"))
+}
+
+// GetAWSSecretBundles returns the named bundles of AWS secrets a user can select in addition to the
+// secrets materialized into every user project
+func GetAWSSecretBundles() (map[string][]SecretMapping, error) {
+	return parseSecretBundles(getListEnv("AWS_SECRET_BUNDLES"))
+}
+
+// GetAWSSecretsRefreshInterval returns how often materialized AWS secrets are refreshed
+func GetAWSSecretsRefreshInterval() time.Duration {
+	return getDurationEnv("AWS_SECRETS_REFRESH_INTERVAL", time.Hour)
+}
+
+// getEnv returns the value of a configuration variable, taken from the applied ProvisionerConfig when
+// it sets the variable and from the environment otherwise
+func getEnv(name string) string {
+	return configLookup(lookupEnv).get(name)
+}
+
+// lookupEnv returns the value of a configuration variable and whether it is set, by the applied
+// ProvisionerConfig, the configuration file or the environment
+func lookupEnv(name string) (string, bool) {
+	if value, ok := configOverride(name); ok {
+		return value, true
+	}
+	if value, ok := fileOverride(name); ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+// configLookup returns the value of a configuration variable and whether it is set, either lookupEnv
+// or a candidate configuration checked before it is applied
+type configLookup func(name string) (string, bool)
+
+// Returns a lookup of the configuration variables set by the ProvisionerConfig overrides, then the
+// configuration file values, then the environment
+func layeredLookup(overrides map[string]string, file map[string]string) configLookup {
+	return func(name string) (string, bool) {
+		if value, ok := overrides[name]; ok {
+			return value, true
+		}
+		if value, ok := file[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+}
+
+// Returns the value of a configuration variable, empty when unset
+func (lookup configLookup) get(name string) string {
+	value, _ := lookup(name)
+	return value
+}
+
+// Returns the boolean value of a configuration variable or the given default when it is unset or
+// cannot be parsed
+func (lookup configLookup) boolean(name string, defaultValue bool) bool {
+	value := lookup.get(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return parsed
+}
+
+// Returns the duration value of a configuration variable or the given default when it is unset or
+// cannot be parsed
+func (lookup configLookup) duration(name string, defaultValue time.Duration) time.Duration {
+	value := lookup.get(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+// Returns the non-empty entries of a comma separated configuration variable
+func (lookup configLookup) list(name string) []string {
+	var values []string
+	for _, value := range strings.Split(lookup.get(name), ",") {
+		value = strings.TrimSpace(value)
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getBoolEnv returns the boolean value of an environment variable or the given default
+// when the variable is unset or cannot be parsed
+func getBoolEnv(name string, defaultValue bool) bool {
+	return configLookup(lookupEnv).boolean(name, defaultValue)
+}
+
+// getDurationEnv returns the duration value of an environment variable or the given default
+// when the variable is unset or cannot be parsed
+func getDurationEnv(name string, defaultValue time.Duration) time.Duration {
+	return configLookup(lookupEnv).duration(name, defaultValue)
+}
+
+// getIntEnv returns the positive integer value of an environment variable or the given default
+// when the variable is unset or cannot be parsed
+func getIntEnv(name string, defaultValue int64) int64 {
+	value := getEnv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getFloatEnv returns the non-negative float value of an environment variable or the given default
+// when the variable is unset or cannot be parsed
+func getFloatEnv(name string, defaultValue float64) float64 {
+	value := getEnv(name)
+	if value == "" {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		return defaultValue
+	}
+	return parsed
+}
+
+// getListEnv returns the non-empty entries of a comma separated environment variable
+func getListEnv(name string) []string {
+	return configLookup(lookupEnv).list(name)
+}
+
+// parseResourceList parses a comma separated list of resource=quantity pairs,
+// e.g. "requests.cpu=4,requests.memory=16Gi,pods=20"
+func parseResourceList(value string) (corev1.ResourceList, error) {
+	resources := corev1.ResourceList{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, quantity, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid resource entry %q, expected <resource>=<quantity>", entry)
+		}
+		parsed, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity for resource %s: %w", name, err)
+		}
+		resources[corev1.ResourceName(strings.TrimSpace(name))] = parsed
+	}
+	return resources, nil
+}
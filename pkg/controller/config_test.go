@@ -0,0 +1,404 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestGetBoolEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		envValue     string
+		defaultValue bool
+		want         bool
+	}{
+		{
+			name:         "environment variable unset",
+			envValue:     "",
+			defaultValue: true,
+			want:         true,
+		},
+		{
+			name:         "environment variable true",
+			envValue:     "true",
+			defaultValue: false,
+			want:         true,
+		},
+		{
+			name:         "environment variable false",
+			envValue:     "false",
+			defaultValue: true,
+			want:         false,
+		},
+		{
+			name:         "environment variable invalid",
+			envValue:     "maybe",
+			defaultValue: true,
+			want:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_BOOL_ENV", tt.envValue)
+
+			got := getBoolEnv("TEST_BOOL_ENV", tt.defaultValue)
+			if got != tt.want {
+				t.Errorf("getBoolEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResourceList(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		want        corev1.ResourceList
+		shouldError bool
+	}{
+		{
+			name:  "empty value",
+			value: "",
+			want:  corev1.ResourceList{},
+		},
+		{
+			name:  "multiple resources",
+			value: "requests.cpu=4, requests.memory=16Gi,pods=20",
+			want: corev1.ResourceList{
+				corev1.ResourceRequestsCPU:    resource.MustParse("4"),
+				corev1.ResourceRequestsMemory: resource.MustParse("16Gi"),
+				corev1.ResourcePods:           resource.MustParse("20"),
+			},
+		},
+		{
+			name:        "missing quantity",
+			value:       "requests.cpu",
+			shouldError: true,
+		},
+		{
+			name:        "invalid quantity",
+			value:       "requests.cpu=lots",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseResourceList(tt.value)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseResourceList() returned %d resources, want %d", len(got), len(tt.want))
+			}
+			for name, quantity := range tt.want {
+				gotQuantity := got[name]
+				if gotQuantity.Cmp(quantity) != 0 {
+					t.Errorf("parseResourceList()[%s] = %s, want %s", name, gotQuantity.String(), quantity.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGetDurationEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     time.Duration
+	}{
+		{
+			name:     "environment variable unset",
+			envValue: "",
+			want:     time.Hour,
+		},
+		{
+			name:     "environment variable set",
+			envValue: "15m",
+			want:     15 * time.Minute,
+		},
+		{
+			name:     "environment variable invalid",
+			envValue: "soon",
+			want:     time.Hour,
+		},
+		{
+			name:     "environment variable negative",
+			envValue: "-5m",
+			want:     time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_DURATION_ENV", tt.envValue)
+
+			got := getDurationEnv("TEST_DURATION_ENV", time.Hour)
+			if got != tt.want {
+				t.Errorf("getDurationEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetQuotaWarningThreshold(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     float64
+	}{
+		{
+			name:     "environment variable unset",
+			envValue: "",
+			want:     0.9,
+		},
+		{
+			name:     "environment variable set",
+			envValue: "75",
+			want:     0.75,
+		},
+		{
+			name:     "environment variable out of range",
+			envValue: "150",
+			want:     0.9,
+		},
+		{
+			name:     "environment variable invalid",
+			envValue: "high",
+			want:     0.9,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QUOTA_WARNING_THRESHOLD", tt.envValue)
+
+			got := GetQuotaWarningThreshold()
+			if got != tt.want {
+				t.Errorf("GetQuotaWarningThreshold() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDryRunEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		forced bool
+		want   bool
+	}{
+		{
+			name: "disabled by default",
+			want: false,
+		},
+		{
+			name: "enabled by DRY_RUN_ENABLED",
+			env:  map[string]string{"DRY_RUN_ENABLED": "true"},
+			want: true,
+		},
+		{
+			name: "enabled by the DRY_RUN shorthand",
+			env:  map[string]string{"DRY_RUN": "true"},
+			want: true,
+		},
+		{
+			name: "DRY_RUN_ENABLED takes precedence over DRY_RUN",
+			env:  map[string]string{"DRY_RUN_ENABLED": "false", "DRY_RUN": "true"},
+			want: false,
+		},
+		{
+			name:   "the flag takes precedence over the environment",
+			env:    map[string]string{"DRY_RUN_ENABLED": "false"},
+			forced: true,
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if tt.forced {
+				EnableDryRun()
+				t.Cleanup(func() { dryRunForced = false })
+			}
+
+			if got := GetDryRunEnabled(); got != tt.want {
+				t.Errorf("GetDryRunEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIntEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		want     int64
+	}{
+		{
+			name:     "environment variable unset",
+			envValue: "",
+			want:     500,
+		},
+		{
+			name:     "environment variable set",
+			envValue: "100",
+			want:     100,
+		},
+		{
+			name:     "environment variable invalid",
+			envValue: "many",
+			want:     500,
+		},
+		{
+			name:     "environment variable zero",
+			envValue: "0",
+			want:     500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_INT_ENV", tt.envValue)
+
+			got := getIntEnv("TEST_INT_ENV", 500)
+			if got != tt.want {
+				t.Errorf("getIntEnv() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNotificationProviders(t *testing.T) {
+	tests := []struct {
+		name        string
+		providers   string
+		webhookURL  string
+		want        []NotificationProvider
+		shouldError bool
+	}{
+		{
+			name: "notifications disabled",
+		},
+		{
+			name:       "webhook URL without providers",
+			webhookURL: "https://hooks.example.com/provisioner",
+			want:       []NotificationProvider{{Name: NotificationProviderWebhook, MinSeverity: "info"}},
+		},
+		{
+			name:      "providers with minimum severities",
+			providers: "slack:error, webhook, events:warning",
+			want: []NotificationProvider{
+				{Name: NotificationProviderSlack, MinSeverity: "error"},
+				{Name: NotificationProviderWebhook, MinSeverity: "info"},
+				{Name: NotificationProviderEvents, MinSeverity: "warning"},
+			},
+		},
+		{
+			name:        "unknown provider",
+			providers:   "pagerduty",
+			shouldError: true,
+		},
+		{
+			name:        "unknown severity",
+			providers:   "slack:critical",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NOTIFICATION_PROVIDERS", tt.providers)
+			t.Setenv("NOTIFICATION_WEBHOOK_URL", tt.webhookURL)
+
+			got, err := GetNotificationProviders()
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetNotificationProviders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAdditionalRoleBindings(t *testing.T) {
+	tests := []struct {
+		name        string
+		bindings    string
+		want        []AdditionalRoleBinding
+		shouldError bool
+	}{
+		{
+			name: "no additional RoleBindings",
+		},
+		{
+			name:     "RoleBindings in order",
+			bindings: "monitoring = monitoring-rules-view, pipelines=pipeline-runner",
+			want: []AdditionalRoleBinding{
+				{Suffix: "monitoring", ClusterRole: "monitoring-rules-view"},
+				{Suffix: "pipelines", ClusterRole: "pipeline-runner"},
+			},
+		},
+		{
+			name:        "missing ClusterRole",
+			bindings:    "monitoring",
+			shouldError: true,
+		},
+		{
+			name:        "invalid suffix",
+			bindings:    "Monitoring=view",
+			shouldError: true,
+		},
+		{
+			name:        "suffix of the owner's RoleBinding",
+			bindings:    "edit=admin",
+			shouldError: true,
+		},
+		{
+			name:        "duplicate suffix",
+			bindings:    "monitoring=view,monitoring=monitoring-rules-view",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADDITIONAL_ROLE_BINDINGS", tt.bindings)
+
+			got, err := GetAdditionalRoleBindings()
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetAdditionalRoleBindings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// the settings of the configuration file, taking precedence over the environment
+var fileConfig struct {
+	sync.RWMutex
+	// path of the file, empty when no configuration file is used
+	path string
+	// values of the configuration variables set by the file
+	values map[string]string
+}
+
+// name of a configuration variable set by the configuration file
+var configVariablePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// GetConfigFile returns the path of the YAML configuration file loaded with LoadConfigFile, or an empty
+// string when the controller is only configured by the environment
+func GetConfigFile() string {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	return fileConfig.path
+}
+
+// Returns the value the configuration file sets the configuration variable to, if any
+func fileOverride(name string) (string, bool) {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	value, ok := fileConfig.values[name]
+	return value, ok
+}
+
+// Returns whether the target groups may change without a restart
+func targetGroupsReloadable() bool {
+	return GetProvisionerConfigEnabled() || GetConfigFile() != ""
+}
+
+// Parses a configuration file mapping configuration variables to their value. Lists are joined with
+// commas, so TARGET_GROUP_NAMES may be given as a YAML list.
+func parseConfigFile(content []byte) (map[string]string, error) {
+	var settings map[string]any
+	if err := yaml.Unmarshal(content, &settings); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(settings))
+	var errs []error
+	for name, setting := range settings {
+		if !configVariablePattern.MatchString(name) {
+			errs = append(errs, fmt.Errorf("%s: not a configuration variable", name))
+			continue
+		}
+		if list, ok := setting.([]any); ok {
+			entries := make([]string, 0, len(list))
+			for _, entry := range list {
+				value, err := configScalar(entry)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				}
+				entries = append(entries, value)
+			}
+			values[name] = strings.Join(entries, ",")
+			continue
+		}
+		value, err := configScalar(setting)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		values[name] = value
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return values, nil
+}
+
+// Returns the value of a scalar of the configuration file as it would be set in the environment
+func configScalar(setting any) (string, error) {
+	switch value := setting.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unsupported value %v, expected a string, number, boolean or list of them", setting)
+	}
+}
+
+// Reads and parses the configuration file
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values, err := parseConfigFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Returns the settings of the configuration file
+func fileConfigValues() map[string]string {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	return fileConfig.values
+}
+
+// Replaces the settings of the configuration file, returning whether they changed
+func setFileConfig(path string, values map[string]string) bool {
+	fileConfig.Lock()
+	defer fileConfig.Unlock()
+	changed := !maps.Equal(fileConfig.values, values)
+	fileConfig.path, fileConfig.values = path, values
+	return changed
+}
+
+// LoadConfigFile applies the settings of the YAML configuration file at the path on top of the
+// environment. It is called before the configuration is validated, and the file is then watched by the
+// controller for changes.
+func LoadConfigFile(path string) error {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	setFileConfig(path, values)
+	return nil
+}
+
+// Applies the current content of the configuration file, returning whether the configuration changed.
+// An unreadable or invalid file, or one changing settings only read on startup, is rejected and the
+// previous settings kept.
+func reloadConfigFile() (bool, error) {
+	path := GetConfigFile()
+	values, err := readConfigFile(path)
+	if err != nil {
+		return false, err
+	}
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	candidate := layeredLookup(configOverrides(), values)
+	if err := validateReload(candidate, configLimitRange(), variablesSetBy(fileConfigValues(), values)); err != nil {
+		return false, err
+	}
+	return setFileConfig(path, values), nil
+}
+
+// Applies changes to the configuration file until the context is cancelled, re-provisioning every
+// member after each change so it takes effect. The directory is watched rather than the file, as a
+// mounted ConfigMap is updated by swapping a symlink.
+func (c *Controller) watchConfigFile(ctx context.Context) {
+	path := GetConfigFile()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		klog.Errorf("Error watching configuration file %s, changes require a restart: %v", path, err)
+		return
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		klog.Errorf("Error watching configuration file %s, changes require a restart: %v", path, err)
+		return
+	}
+	klog.Infof("Watching configuration file %s for changes", path)
+
+	// pick up changes made while this replica wasn't the leader
+	c.applyConfigFile(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Has(fsnotify.Chmod) {
+				continue
+			}
+			klog.V(2).Infof("Configuration file directory changed: %s", event)
+			c.applyConfigFile(ctx)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			klog.Errorf("Error watching configuration file %s: %v", path, err)
+		}
+	}
+}
+
+// Applies the configuration file, re-provisioning every member when it changed
+func (c *Controller) applyConfigFile(ctx context.Context) {
+	changed, err := reloadConfigFile()
+	if err != nil {
+		klog.Errorf("Rejected configuration file %s, keeping the previous configuration: %v", GetConfigFile(), err)
+		return
+	}
+	if !changed {
+		return
+	}
+	klog.Infof("Applied changes to configuration file %s", GetConfigFile())
+	c.reprovisionMembers(withTrigger(ctx, TriggerAdmin))
+}
@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseConfigFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+		errors   []string
+	}{
+		{
+			name: "scalars and lists",
+			content: `
+TARGET_GROUP_NAMES: [cohort, staff]
+USER_CLUSTER_ROLE: edit
+RESOURCE_QUOTA_ENABLED: true
+QUOTA_WARNING_THRESHOLD: 80
+COST_PRICE_CPU_CORE_HOUR: 0.04
+`,
+			expected: map[string]string{
+				"TARGET_GROUP_NAMES":       "cohort,staff",
+				"USER_CLUSTER_ROLE":        "edit",
+				"RESOURCE_QUOTA_ENABLED":   "true",
+				"QUOTA_WARNING_THRESHOLD":  "80",
+				"COST_PRICE_CPU_CORE_HOUR": "0.04",
+			},
+		},
+		{
+			name: "every invalid setting is located",
+			content: `
+targetGroups: [cohort]
+RESOURCE_QUOTA_HARD:
+  pods: 10
+`,
+			errors: []string{
+				"targetGroups: not a configuration variable",
+				"RESOURCE_QUOTA_HARD: unsupported value",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := parseConfigFile([]byte(tt.content))
+			if len(tt.errors) == 0 {
+				if err != nil {
+					t.Fatalf("Expected the configuration file to be parsed, but got error: %v", err)
+				}
+				if !maps.Equal(values, tt.expected) {
+					t.Errorf("Expected %v, but got %v", tt.expected, values)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Expected case '%s' to receive an error", tt.name)
+			}
+			for _, expected := range tt.errors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, but got: %v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestController_watchConfigFile(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort")
+	t.Cleanup(func() { setFileConfig("", nil) })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	// replaced at once as the kubelet does, so the watcher never reads a partly written file
+	writeConfig := func(content string) {
+		t.Helper()
+		staged := filepath.Join(dir, ".config.yaml")
+		if err := os.WriteFile(staged, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write configuration file: %v", err)
+		}
+		if err := os.Rename(staged, path); err != nil {
+			t.Fatalf("Failed to replace configuration file: %v", err)
+		}
+	}
+	writeConfig("USER_CLUSTER_ROLE: view\n")
+	if err := LoadConfigFile(path); err != nil {
+		t.Fatalf("Expected the configuration file to be loaded, but got error: %v", err)
+	}
+
+	// The file takes precedence over the environment
+	t.Setenv("USER_CLUSTER_ROLE", "edit")
+	if got := GetUserClusterRole(); got != "view" {
+		t.Errorf("Expected the ClusterRole of the configuration file, but got %s", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice"), newGroup("staff", "bob")),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	go controller.watchConfigFile(ctx)
+
+	// Changing the target groups provisions the members of the new groups without a restart
+	changed := "TARGET_GROUP_NAMES: [staff]\nUSER_CLUSTER_ROLE: view\n"
+	err := wait.PollUntilContextTimeout(ctx, 50*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		if slices.Equal(GetTargetGroupNames(), []string{"staff"}) {
+			return true, nil
+		}
+		// rewritten until the watcher has started
+		writeConfig(changed)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected the target groups of the configuration file to be applied, but got %v", GetTargetGroupNames())
+	}
+	err = wait.PollUntilContextTimeout(ctx, 50*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		_, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{})
+		return err == nil, nil
+	})
+	if err != nil {
+		t.Errorf("Expected project bob to be provisioned after the change")
+	}
+
+	// An invalid file is rejected and the previous configuration kept
+	writeConfig("TARGET_GROUP_NAMES: [staff]\nUSER_CLUSTER_ROLE: edit/all\n")
+	if _, err := reloadConfigFile(); err == nil {
+		t.Errorf("Expected the invalid configuration file to be rejected")
+	}
+	if got := GetUserClusterRole(); got != "view" {
+		t.Errorf("Expected the previous ClusterRole to be kept, but got %s", got)
+	}
+
+	// So is a file changing settings only read on startup
+	writeConfig("TARGET_GROUP_NAMES: [staff]\nUSER_CLUSTER_ROLE: admin\nMETRICS_BIND_ADDRESS: :9090\nPROJECT_NAME_TEMPLATE: sandbox-{user}\n")
+	_, err = reloadConfigFile()
+	for _, variable := range []string{"METRICS_BIND_ADDRESS", "PROJECT_NAME_TEMPLATE"} {
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) || !strings.Contains(err.Error(), variable+": can only be changed with a restart") {
+			t.Errorf("Expected the change to %s to be rejected, but got %v", variable, err)
+		}
+	}
+	if got := GetUserClusterRole(); got != "view" {
+		t.Errorf("Expected the previous ClusterRole to be kept, but got %s", got)
+	}
+}
@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+)
+
+// name of the ConsoleNotification banner announcing pending deletions of managed namespaces
+const consoleNotificationName = "rosa-namespace-provisioner-maintenance"
+
+// consoleNotificationsResource identifies ConsoleNotifications for the dynamic client
+var consoleNotificationsResource = schema.GroupVersionResource{Group: "console.openshift.io", Version: "v1", Resource: "consolenotifications"}
+
+// Returns the managed namespaces whose deletion waits for the maintenance window
+func (c *Controller) namespacesAwaitingDeletion(ctx context.Context, now time.Time) ([]string, error) {
+	window := GetDeletionMaintenanceWindow()
+	if window == "" {
+		return nil, nil
+	}
+	inWindow, err := inMaintenanceWindow(window, now)
+	if err != nil || inWindow {
+		return nil, err
+	}
+
+	namespaces, err := c.coreClient.Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var waiting []string
+	for _, namespace := range namespaces.Items {
+		// protected namespaces are kept regardless of the window
+		if !awaitingDeletion(&namespace) || namespace.Annotations[protectedAnnotation] == "true" {
+			continue
+		}
+		waiting = append(waiting, namespace.Name)
+	}
+	return waiting, nil
+}
+
+// Returns the ConsoleNotification banner announcing the number of namespaces deleted in the next
+// maintenance window
+func desiredConsoleNotification(count int) *unstructured.Unstructured {
+	text := fmt.Sprintf("%d sandbox namespaces are scheduled for deletion during the maintenance window %s (UTC).", count, GetDeletionMaintenanceWindow())
+	if count == 1 {
+		text = fmt.Sprintf("1 sandbox namespace is scheduled for deletion during the maintenance window %s (UTC).", GetDeletionMaintenanceWindow())
+	}
+	spec := map[string]interface{}{
+		"text":            text,
+		"location":        "BannerTop",
+		"color":           "#fff",
+		"backgroundColor": "#0088ce",
+	}
+	if link := GetConsoleNotificationLink(); link != "" {
+		spec["link"] = map[string]interface{}{
+			"href": link,
+			"text": "Learn more",
+		}
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "console.openshift.io/v1",
+		"kind":       "ConsoleNotification",
+		"metadata": map[string]interface{}{
+			"name": consoleNotificationName,
+		},
+		"spec": spec,
+	}}
+}
+
+// Shows the ConsoleNotification banner while managed namespaces wait for the maintenance window to
+// be deleted, and removes it once they are gone, so the console stays in sync with the deletions
+func (c *Controller) syncConsoleNotification(ctx context.Context) {
+	waiting, err := c.namespacesAwaitingDeletion(ctx, time.Now())
+	if err != nil {
+		klog.Errorf("Error listing managed namespaces awaiting deletion: %v", err)
+		return
+	}
+
+	client := c.dynamicClient.Resource(consoleNotificationsResource)
+	existing, err := client.Get(ctx, consoleNotificationName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error getting ConsoleNotification %s: %v", consoleNotificationName, err)
+		return
+	}
+	found := err == nil
+
+	if GetDryRunEnabled() {
+		klog.V(2).Infof("Dry run: skipping sync of ConsoleNotification %s announcing the deletion of namespaces %v", consoleNotificationName, waiting)
+		return
+	}
+	if len(waiting) == 0 {
+		if !found {
+			return
+		}
+		if err := client.Delete(ctx, consoleNotificationName, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Error deleting ConsoleNotification %s: %v", consoleNotificationName, err)
+			return
+		}
+		klog.Infof("Removed ConsoleNotification %s as no managed namespaces await deletion", consoleNotificationName)
+		return
+	}
+
+	desired := desiredConsoleNotification(len(waiting))
+	if !found {
+		if _, err := client.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			klog.Errorf("Error creating ConsoleNotification %s: %v", consoleNotificationName, err)
+			return
+		}
+		klog.Infof("Created ConsoleNotification %s announcing the deletion of namespaces %v", consoleNotificationName, waiting)
+		return
+	}
+
+	spec, _, _ := unstructured.NestedMap(existing.Object, "spec")
+	if reflect.DeepEqual(spec, desired.Object["spec"]) {
+		return
+	}
+	existing.Object["spec"] = desired.Object["spec"]
+	if _, err := client.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating ConsoleNotification %s: %v", consoleNotificationName, err)
+		return
+	}
+	klog.Infof("Updated ConsoleNotification %s announcing the deletion of namespaces %v", consoleNotificationName, waiting)
+}
+
+// Returns whether a namespace is terminating and held back by the protection finalizer
+func awaitingDeletion(namespace *corev1.Namespace) bool {
+	return namespace.DeletionTimestamp != nil && hasFinalizer(namespace, protectionFinalizer)
+}
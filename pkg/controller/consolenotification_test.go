@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncConsoleNotification(t *testing.T) {
+	// A window starting in an hour, so deletions currently wait for it
+	now := time.Now().UTC()
+	window := fmt.Sprintf("%s-%s", now.Add(time.Hour).Format("15:04"), now.Add(2*time.Hour).Format("15:04"))
+	t.Setenv("DELETION_MAINTENANCE_WINDOW", window)
+	t.Setenv("CONSOLE_NOTIFICATION_LINK", "https://docs.example.com/sandboxes")
+
+	ctx := context.Background()
+	terminating := func(name string, annotations map[string]string) *corev1.Namespace {
+		return &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{ownerLabel: name},
+				Annotations:       annotations,
+				Finalizers:        []string{protectionFinalizer},
+				DeletionTimestamp: &metav1.Time{Time: now},
+			},
+		}
+	}
+	kubeClient := fake.NewClientset(
+		terminating("alice", nil),
+		terminating("bob", nil),
+		terminating("carol", map[string]string{protectedAnnotation: "true"}),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dave", Labels: map[string]string{ownerLabel: "dave"}}},
+	)
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	controller := &Controller{
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: dynamicClient,
+	}
+	banner := func() (*unstructured.Unstructured, error) {
+		return dynamicClient.Resource(consoleNotificationsResource).Get(ctx, consoleNotificationName, metav1.GetOptions{})
+	}
+
+	controller.syncConsoleNotification(ctx)
+	notification, err := banner()
+	if err != nil {
+		t.Fatalf("Expected ConsoleNotification %s to be created, but got error: %v", consoleNotificationName, err)
+	}
+	text, _, _ := unstructured.NestedString(notification.Object, "spec", "text")
+	if !strings.HasPrefix(text, "2 sandbox namespaces") || !strings.Contains(text, window) {
+		t.Errorf("Expected the banner to announce 2 deletions in window %s, but got %q", window, text)
+	}
+	href, _, _ := unstructured.NestedString(notification.Object, "spec", "link", "href")
+	if href != "https://docs.example.com/sandboxes" {
+		t.Errorf("Expected the banner to link to the configured URL, but got %q", href)
+	}
+
+	// The banner follows the deletions
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "bob", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete namespace bob: %v", err)
+	}
+	controller.syncConsoleNotification(ctx)
+	notification, err = banner()
+	if err != nil {
+		t.Fatalf("Expected ConsoleNotification %s to be kept, but got error: %v", consoleNotificationName, err)
+	}
+	text, _, _ = unstructured.NestedString(notification.Object, "spec", "text")
+	if !strings.HasPrefix(text, "1 sandbox namespace is") {
+		t.Errorf("Expected the banner to announce 1 deletion, but got %q", text)
+	}
+
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "alice", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete namespace alice: %v", err)
+	}
+	controller.syncConsoleNotification(ctx)
+	if _, err := banner(); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected ConsoleNotification %s to be removed once no deletions are pending, but got %v", consoleNotificationName, err)
+	}
+}
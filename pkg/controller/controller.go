@@ -2,90 +2,372 @@ package controller
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"os"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	projectv1 "github.com/openshift/api/project/v1"
+	quotav1 "github.com/openshift/api/quota/v1"
 	userv1 "github.com/openshift/api/user/v1"
 	projectclient "github.com/openshift/client-go/project/clientset/versioned"
+	quotaclient "github.com/openshift/client-go/quota/clientset/versioned"
 	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/evidence"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/tracing"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
 	rbacv1client "k8s.io/client-go/kubernetes/typed/rbac/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 )
 
 // default value of the target group name
 const defaultTargetGroupName = "redhat-ai-dev-users"
 
+// name of the component recording Events
+const componentName = "rosa-namespace-provisioner"
+
+// Names of the optional integrations tracked for health, where each notification provider is
+// tracked as "notifications-<provider>"
+const (
+	IntegrationAWSSecrets    = "aws-secrets"
+	IntegrationNotifications = "notifications"
+)
+
+// label identifying the user owning a provisioned project
+const ownerLabel = "rosa-namespace-provisioner/owner"
+
+// label marking the Projects and RoleBindings created by the controller, which are the only ones it
+// ever deletes
+const managedByLabel = "app.kubernetes.io/managed-by"
+
+// annotation recording the user owning an object created by the controller, holding the user name
+// as is even when it isn't a valid label value
+const ownerAnnotation = "rosa-namespace-provisioner/owner-user"
+
+// annotations of the name and description shown for a project by the OpenShift console
+const (
+	displayNameAnnotation = "openshift.io/display-name"
+	descriptionAnnotation = "openshift.io/description"
+)
+
 // GetTargetGroupName returns the target group name from environment variable or default
 func GetTargetGroupName() string {
-	groupName := os.Getenv("TARGET_GROUP_NAME")
+	groupName := getEnv("TARGET_GROUP_NAME")
 	if groupName == "" {
 		return defaultTargetGroupName // default value
 	}
 	return groupName
 }
 
+// GetTargetGroupNames returns the groups whose members get namespaces, from the comma separated
+// TARGET_GROUP_NAMES or else the single TARGET_GROUP_NAME
+func GetTargetGroupNames() []string {
+	return targetGroupNames(lookupEnv)
+}
+
+// Returns the target groups configured by the lookup
+func targetGroupNames(lookup configLookup) []string {
+	if names := lookup.list("TARGET_GROUP_NAMES"); len(names) > 0 {
+		return names
+	}
+	if name := lookup.get("TARGET_GROUP_NAME"); name != "" {
+		return []string{name}
+	}
+	return []string{defaultTargetGroupName}
+}
+
+// Returns the target group names for log and error messages
+func targetGroupList() string {
+	return strings.Join(GetTargetGroupNames(), ", ")
+}
+
 // Controller represents the OpenShift Group controller that manages project lifecycle
 type Controller struct {
 	userClient    userclient.Interface
 	projectClient projectclient.Interface
 	rbacClient    rbacv1client.RbacV1Interface
+	quotaClient   quotaclient.Interface
+	coreClient    corev1client.CoreV1Interface
+	dynamicClient dynamic.Interface
+
+	// seeds NetworkPolicies into user projects, when configured
+	networkingClient networkingv1client.NetworkingV1Interface
+
+	// resolves the resources of the kinds of the template bundle, when configured
+	restMapper meta.RESTMapper
+
+	secretSource  SecretSource
+	groupRecorder GroupRecorder
+	broadcaster   *events.Broadcaster
+	notifier      notify.Notifier
+	tracer        *tracing.Tracer
+	health        *health.Tracker
+	recorder      record.EventRecorder
 	informer      cache.SharedIndexInformer
-	stopCh        chan struct{}
+
+	// watches managed namespaces when the protection finalizer is enabled
+	namespaceInformer cache.SharedIndexInformer
+
+	// watches approved ManagedNamespaces when approval is required
+	approvalInformer cache.SharedIndexInformer
+
+	// watches the ProvisionerConfig when enabled
+	configInformer cache.SharedIndexInformer
+
+	// watches NamespaceClaims when users may claim additional namespaces
+	claimInformer cache.SharedIndexInformer
+
+	// watches the ConfigMap of the failed provisions for retries requested by admins
+	failedProvisionsInformer cache.SharedIndexInformer
+
+	// since when the watch of each informer is broken, reported by the readiness probe
+	watches *watchHealth
+
+	// revisions of the target groups and managed namespaces handled last by the reconcilers
+	handledGroups     map[string]*userv1.Group
+	handledNamespaces map[string]*corev1.Namespace
+
+	// last resolved transitive user set of each group, used when nested groups are enabled
+	resolvedUsers map[string]map[string]bool
+	mu            sync.Mutex
+
+	// quota resources whose owners were already warned about high usage
+	quotaWarnings map[string]bool
+
+	// time each user appeared in the group, until their namespace is provisioned
+	pendingSince map[string]time.Time
+
+	// integrations cleaning up external artifacts of deprovisioned users, through a rate-limited queue
+	cleaners     map[string]ExternalCleaner
+	cleanupQueue workqueue.TypedRateLimitingInterface[cleanupItem]
+	deadLetters  map[cleanupItem]DeadLetter
+
+	// users whose provisioning failed permanently, dead-lettered after PROVISIONING_MAX_FAILURES
+	failedProvisions map[string]*FailedProvision
+	// whether the failed provisions persisted by a previous leader were loaded
+	failedProvisionsLoaded bool
+
+	// external membership sources by name, and the sources granting each user a namespace as of the
+	// last membership sync
+	membershipSources map[string]MembershipSource
+	grantedBy         map[string][]string
+
+	// whether the access window of each target group was open as of the last access window sync
+	accessWindowStates map[string]bool
+
+	// deletions of the namespaces of deprovisioned users waiting to be verified, by project name
+	pendingDeletions map[string]*pendingDeletion
+
+	// limits how fast steps start across all users, when a step rate limit is configured
+	stepLimiter *rate.Limiter
+
+	// last observed size of each target group and their unacknowledged membership anomalies
+	groupSizes     map[string]int
+	groupAnomalies map[string]*GroupAnomaly
+
+	// shuts the controller down once idle for IDLE_SHUTDOWN_AFTER, and when it last did any work
+	idleShutdown func(ctx context.Context)
+	lastActivity time.Time
+
+	// stores the signed deprovision reports, and the offboardings in progress by project name
+	reportStore  evidence.Store
+	reportKey    []byte
+	offboardings map[string]*offboarding
+
+	// namespace of each user recorded in the namespace mapping ConfigMap
+	namespaceMapping namespaceMapping
+}
+
+// Option configures optional integrations of the Controller
+type Option func(*Controller)
+
+// WithSecretSource enables materializing external secrets into every user project
+func WithSecretSource(source SecretSource) Option {
+	return func(c *Controller) {
+		c.secretSource = source
+	}
+}
+
+// WithEventBroadcaster publishes live provisioning events to the given broadcaster
+func WithEventBroadcaster(broadcaster *events.Broadcaster) Option {
+	return func(c *Controller) {
+		c.broadcaster = broadcaster
+	}
+}
+
+// WithTracer records the reconciles of group events and the steps they run as spans of the given tracer
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(c *Controller) {
+		c.tracer = tracer
+	}
+}
+
+// WithHealthTracker skips optional integrations while the tracker reports them disabled
+func WithHealthTracker(tracker *health.Tracker) Option {
+	return func(c *Controller) {
+		c.health = tracker
+	}
+}
+
+// WithNotifier delivers notifications to namespace owners through the given notifier
+func WithNotifier(notifier notify.Notifier) Option {
+	return func(c *Controller) {
+		c.notifier = notifier
+	}
+}
+
+// WithDeprovisionReports stores a report signed with the key for every fully deprovisioned user, once
+// their namespace and external artifacts are gone, and links it from the final notification
+func WithDeprovisionReports(store evidence.Store, key []byte) Option {
+	return func(c *Controller) {
+		c.reportStore = store
+		c.reportKey = key
+	}
+}
+
+// WithIdleShutdown calls shutdown once the controller has provisioned or deprovisioned no one for
+// IDLE_SHUTDOWN_AFTER, e.g. to scale its Deployment to zero until woken up
+func WithIdleShutdown(shutdown func(ctx context.Context)) Option {
+	return func(c *Controller) {
+		c.idleShutdown = shutdown
+	}
+}
+
+// GroupRecorder records the changes to the target group observed by the controller
+type GroupRecorder interface {
+	RecordGroup(oldGroup, newGroup *userv1.Group)
+}
+
+// WithGroupRecorder records every change to the target group observed by the controller, e.g. to
+// replay it later against a fake cluster
+func WithGroupRecorder(recorder GroupRecorder) Option {
+	return func(c *Controller) {
+		c.groupRecorder = recorder
+	}
+}
+
+// Applies the configured watch timeout to informer watch requests and the list page size to informer
+// list requests, so a short watch timeout doesn't cut off paginated lists of large clusters
+func tuneListOptions(options *metav1.ListOptions) {
+	if timeout := GetInformerWatchTimeout(); timeout > 0 && options.Watch {
+		timeoutSeconds := int64(timeout.Seconds())
+		options.TimeoutSeconds = &timeoutSeconds
+	}
+	if pageSize := GetInformerListPageSize(); pageSize > 0 && !options.Watch {
+		options.Limit = pageSize
+	}
 }
 
 // NewController creates a new Controller instance
-func NewController(userClient userclient.Interface, projectClient projectclient.Interface, rbacClient rbacv1client.RbacV1Interface) *Controller {
-	// Get the target group name
-	targetGroupName := GetTargetGroupName()
-
-	// Create a filtered informer that only watches our specific group
-	listWatcher := cache.NewListWatchFromClient(
-		userClient.UserV1().RESTClient(),
-		"groups",
-		"", // namespace - empty for cluster-scoped resources
-		fields.OneTermEqualSelector("metadata.name", targetGroupName),
-	)
+func NewController(userClient userclient.Interface, projectClient projectclient.Interface, rbacClient rbacv1client.RbacV1Interface, quotaClient quotaclient.Interface, coreClient corev1client.CoreV1Interface, dynamicClient dynamic.Interface, opts ...Option) *Controller {
+	// Get the target group names
+	targetGroupNames := GetTargetGroupNames()
+	targetGroups := make(map[string]bool, len(targetGroupNames))
+	for _, name := range targetGroupNames {
+		targetGroups[name] = true
+	}
+
+	// Create an informer watching the target groups through the typed client, so it also works
+	// against fake clientsets. A field selector can only match a single group, so with several
+	// target groups every group is watched and the others are filtered out by the group reconciler. So
+	// is every group when the target groups may be changed by the ProvisionerConfig or configuration
+	// file, and when nested groups are resolved from the informer.
+	filterGroups := func(options *metav1.ListOptions) {
+		if len(targetGroupNames) == 1 && !targetGroupsReloadable() && !GetNestedGroupsEnabled() {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", targetGroupNames[0]).String()
+		}
+		tuneListOptions(options)
+	}
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			filterGroups(&options)
+			return userClient.UserV1().Groups().List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			filterGroups(&options)
+			return userClient.UserV1().Groups().Watch(ctx, options)
+		},
+	}
 
 	// Create informer with only the specific group
-	informer := cache.NewSharedIndexInformer(
-		listWatcher,
-		&userv1.Group{},
-		time.Minute*10, // resync period
-		cache.Indexers{},
-	)
+	watches := newWatchHealth()
+	informer := watches.newInformer("groups", listWatcher, &userv1.Group{}, GetInformerResyncPeriod())
+
+	// Record Kubernetes Events through the core client, including on OpenShift objects
+	eventScheme := runtime.NewScheme()
+	utilruntime.Must(scheme.AddToScheme(eventScheme))
+	utilruntime.Must(quotav1.Install(eventScheme))
+	utilruntime.Must(projectv1.Install(eventScheme))
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: coreClient.Events("")})
+	recorder := eventBroadcaster.NewRecorder(eventScheme, corev1.EventSource{Component: componentName})
 
 	controller := &Controller{
 		userClient:    userClient,
 		projectClient: projectClient,
 		rbacClient:    rbacClient,
+		quotaClient:   quotaClient,
+		coreClient:    coreClient,
+		dynamicClient: dynamicClient,
+		recorder:      recorder,
 		informer:      informer,
-		stopCh:        make(chan struct{}),
+		watches:       watches,
 	}
 
-	// Add event handlers
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			// Handle group creation - treat all users as new additions
-			group := obj.(*userv1.Group)
-			controller.handleGroup(nil, group)
-		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			// This is the main event we're interested in
-			controller.handleGroup(oldObj.(*userv1.Group), newObj.(*userv1.Group))
-		},
-		DeleteFunc: func(obj interface{}) {
-			// We don't care about deletes for right now, so we'll ignore Delete events
-			group := obj.(*userv1.Group)
-			klog.V(4).Infof("Group %s was deleted (ignoring)", group.Name)
-		},
-	})
+	for _, opt := range opts {
+		opt(controller)
+	}
+	for name := range controller.cleaners {
+		controller.health.Register(name)
+	}
+
+	// Watch managed namespaces for deletions to complete and reconciles requested by annotation
+	controller.namespaceInformer = newNamespaceInformer(coreClient, watches)
+
+	// Provision users once an admin approves their pending ManagedNamespace
+	if dynamicClient != nil && GetApprovalRequired() && GetManagedNamespacesEnabled() {
+		controller.approvalInformer = newApprovalInformer(dynamicClient, watches)
+	}
+
+	// Provision the additional namespaces users claim
+	if dynamicClient != nil && GetNamespaceClaimsEnabled() {
+		controller.claimInformer = newNamespaceClaimInformer(dynamicClient, watches)
+	}
+
+	// Retry dead-lettered provisions requested from any replica
+	if coreClient != nil && GetPodNamespace() != "" {
+		controller.failedProvisionsInformer = newFailedProvisionsInformer(coreClient, watches)
+	}
+
+	// Apply changes to the ProvisionerConfig without a restart
+	if dynamicClient != nil && GetProvisionerConfigEnabled() {
+		controller.configInformer = newProvisionerConfigInformer(dynamicClient, watches)
+	}
 
 	return controller
 }
@@ -97,39 +379,82 @@ func (c *Controller) handleGroup(oldGroup, newGroup *userv1.Group) {
 		klog.Infof("Detected update to Group: %s", newGroup.Name)
 	}
 
+	if c.groupRecorder != nil {
+		c.groupRecorder.RecordGroup(oldGroup, newGroup)
+	}
+	trigger := groupTrigger(oldGroup, newGroup)
+	ctx, span := c.tracer.Start(withTrigger(context.Background(), trigger), "group", tracing.String("group", newGroup.Name), tracing.String("trigger", trigger))
+	defer span.End(nil)
+
+	// With merged membership, a group change is one of the sources changing and every source is synced
+	if mergedMembershipEnabled() {
+		c.syncMembership(ctx)
+		return
+	}
+
 	// Log the changes for debugging purposes
 	if oldGroup != nil {
 		klog.V(2).Infof("Old Group ResourceVersion: %s", oldGroup.ResourceVersion)
 	}
 	klog.V(2).Infof("New Group ResourceVersion: %s", newGroup.ResourceVersion)
 
-	// Check if users were added or removed
-	oldUsers := make(map[string]bool)
-	if oldGroup != nil {
-		for _, user := range oldGroup.Users {
-			oldUsers[user] = true
+	// Check if users were added or removed. Direct members are diffed as sorted lists, so resyncs of
+	// groups with tens of thousands of members don't build sets on every event.
+	var newUsers, addedUsers, removedUsers []string
+	var oldCount int
+	if GetNestedGroupsEnabled() {
+		resolvedUsers, err := c.resolveGroupUsers(newGroup)
+		if err != nil {
+			// Skip processing rather than risk removing users due to a partial expansion
+			klog.Errorf("Error resolving nested members of group %s: %v", newGroup.Name, err)
+			return
 		}
-	}
 
-	newUsers := make(map[string]bool)
-	for _, user := range newGroup.Users {
-		newUsers[user] = true
-	}
+		// Diff against the last resolution so changes within sub-groups are picked up on resync
+		c.mu.Lock()
+		previousUsers, ok := c.resolvedUsers[newGroup.Name]
+		if c.resolvedUsers == nil {
+			c.resolvedUsers = make(map[string]map[string]bool)
+		}
+		c.resolvedUsers[newGroup.Name] = resolvedUsers
+		c.mu.Unlock()
+
+		oldUsers := previousUsers
+		if !ok {
+			oldUsers = make(map[string]bool)
+			if oldGroup != nil {
+				oldUsers, err = c.resolveGroupUsers(oldGroup)
+				if err != nil {
+					klog.Errorf("Error resolving nested members of group %s: %v", oldGroup.Name, err)
+					return
+				}
+			}
+		}
 
-	// Find added users
-	var addedUsers []string
-	for user := range newUsers {
-		if !oldUsers[user] {
-			addedUsers = append(addedUsers, user)
+		addedUsers, removedUsers = diffUsers(oldUsers, resolvedUsers)
+		oldCount = len(oldUsers)
+		for user := range resolvedUsers {
+			newUsers = append(newUsers, user)
 		}
+		sort.Strings(newUsers)
+	} else {
+		oldUsers, releaseOld := sortedGroupUsers(oldGroup)
+		defer releaseOld()
+		var releaseNew func()
+		newUsers, releaseNew = sortedGroupUsers(newGroup)
+		defer releaseNew()
+
+		addedUsers, removedUsers = diffSortedUsers(oldUsers, newUsers)
+		oldCount = len(oldUsers)
 	}
 
-	// Find removed users
-	var removedUsers []string
-	for user := range oldUsers {
-		if !newUsers[user] {
-			removedUsers = append(removedUsers, user)
+	// A requested reconcile re-provisions every member, including those just added
+	if oldGroup != nil && reconcileRequested(oldGroup, newGroup) {
+		for _, user := range addedUsers {
+			c.markPending(user, time.Now())
 		}
+		c.reconcileGroup(newGroup, newUsers)
+		addedUsers = nil
 	}
 
 	if len(addedUsers) > 0 {
@@ -137,29 +462,39 @@ func (c *Controller) handleGroup(oldGroup, newGroup *userv1.Group) {
 
 		// For each added user, check if a project exists with the same name as the user
 		for _, user := range addedUsers {
-			if err := c.createUserProject(user); err == nil {
-				_ = c.createRoleBinding(user, user)
+			c.markPending(user, time.Now())
+			_ = c.admitUser(ctx, user)
+		}
+	}
+
+	// Users removed from one target group keep their namespace while they are members of another
+	if len(removedUsers) > 0 && len(GetTargetGroupNames()) > 1 {
+		members, err := c.GroupMembers(ctx)
+		if err != nil {
+			// Skip deprovisioning rather than remove users who may still be members elsewhere
+			klog.Errorf("Error getting members of target groups %s to deprovision users removed from group %s: %v", targetGroupList(), newGroup.Name, err)
+			removedUsers = nil
+		}
+		var deprovisioned []string
+		for _, user := range removedUsers {
+			if members[user] {
+				klog.Infof("Keeping namespace of user %s removed from group %s as they are a member of another target group", user, newGroup.Name)
+				continue
 			}
+			deprovisioned = append(deprovisioned, user)
 		}
+		removedUsers = deprovisioned
 	}
 
+	if oldGroup != nil {
+		c.handleAnomalyAcknowledgement(oldGroup, newGroup)
+	}
+	removedUsers = c.checkGroupAnomaly(newGroup, oldCount, len(newUsers), removedUsers)
+
 	if len(removedUsers) > 0 {
 		klog.Infof("Users removed from group %s: %v", newGroup.Name, removedUsers)
 		for _, user := range removedUsers {
-			// Check if a project exists with the same name as the user
-			project, err := c.projectClient.ProjectV1().Projects().Get(context.Background(), user, metav1.GetOptions{})
-			if err != nil {
-				klog.Errorf("Error checking if project exists for user %s: %v", user, err)
-			}
-			if project != nil {
-				// Delete the project
-				err = c.projectClient.ProjectV1().Projects().Delete(context.Background(), user, metav1.DeleteOptions{})
-				if err != nil {
-					klog.Errorf("Error deleting project for user %s: %v", user, err)
-				}
-			} else {
-				klog.Infof("Project %s does not exist for user %s", user, user)
-			}
+			_ = c.deprovisionUser(ctx, user)
 		}
 	}
 
@@ -168,24 +503,112 @@ func (c *Controller) handleGroup(oldGroup, newGroup *userv1.Group) {
 	}
 }
 
-// Creates Project for target user
-func (c *Controller) createUserProject(user string) error {
-	project := &projectv1.Project{
+// Returns the users added to and removed from a set of users, sorted by name
+func diffUsers(oldUsers map[string]bool, newUsers map[string]bool) ([]string, []string) {
+	// Find added users
+	var addedUsers []string
+	for user := range newUsers {
+		if !oldUsers[user] {
+			addedUsers = append(addedUsers, user)
+		}
+	}
+
+	// Find removed users
+	var removedUsers []string
+	for user := range oldUsers {
+		if !newUsers[user] {
+			removedUsers = append(removedUsers, user)
+		}
+	}
+
+	sort.Strings(addedUsers)
+	sort.Strings(removedUsers)
+	return addedUsers, removedUsers
+}
+
+// Returns the Project provisioned for the target user
+func desiredProject(user string, projectName string) *projectv1.Project {
+	return &projectv1.Project{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: user,
+			Name: projectName,
+			Labels: map[string]string{
+				ownerLabel:     ownerLabelValue(user),
+				managedByLabel: componentName,
+			},
+			Annotations: map[string]string{
+				ownerAnnotation: user,
+			},
 		},
 	}
+}
+
+// Returns whether the object bears the managed-by label of the controller
+func isManaged(obj metav1.Object) bool {
+	return obj.GetLabels()[managedByLabel] == componentName
+}
+
+// Sets the managed-by label and owner annotation on an object of the target user, returning whether
+// they changed
+func setManagedMetadata(obj metav1.Object, user string) bool {
+	if isManaged(obj) && obj.GetAnnotations()[ownerAnnotation] == user {
+		return false
+	}
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[managedByLabel] = componentName
+	obj.SetLabels(labels)
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ownerAnnotation] = user
+	obj.SetAnnotations(annotations)
+	return true
+}
+
+// Returns the merge patch labeling a project as owned by the target user and managed by the
+// controller, clearing any retained mark or scheduled deletion
+func managedProjectPatch(user string) []byte {
+	return []byte(fmt.Sprintf(`{"metadata":{"labels":{%q:%q,%q:%q},"annotations":{%q:%q,%q:null,%q:null}}}`,
+		ownerLabel, ownerLabelValue(user), managedByLabel, componentName, ownerAnnotation, user, retainedAnnotation, scheduledDeletionAnnotation))
+}
+
+// Returns whether the project, or its namespace, was retained or scheduled for deletion after its
+// owner was removed
+func removedUserMarked(project metav1.Object) bool {
+	_, retained := project.GetAnnotations()[retainedAnnotation]
+	_, scheduled := project.GetAnnotations()[scheduledDeletionAnnotation]
+	return retained || scheduled
+}
+
+// Creates Project for target user, recording the name it collided on when it was renamed
+func (c *Controller) createUserProject(ctx context.Context, user string, projectName string) error {
+	project := desiredProject(user, projectName)
+	annotations, err := c.projectConsoleAnnotations(ctx, user)
+	if err != nil {
+		klog.Errorf("Error rendering the display name and description of project %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	maps.Copy(project.Annotations, annotations)
+	if name := templateProjectName(user); name != projectName {
+		project.Annotations[collidingNameAnnotation] = name
+	}
 	// Check if a project exists with the same name as the user
-	_, err := c.projectClient.ProjectV1().Projects().Get(context.Background(), project.Name, metav1.GetOptions{})
+	existingProject, err := c.projectClient.ProjectV1().Projects().Get(ctx, project.Name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			klog.Infof("Project %s not found for user %s", project.Name, user)
-			_, err := c.projectClient.ProjectV1().Projects().Create(context.Background(), project, metav1.CreateOptions{})
+			err := c.createProject(ctx, user, project)
 			if err != nil {
 				klog.Errorf("Error creating project for user %s: %v", user, err)
 				return err
 			} else {
 				klog.Infof("Successfully created project %s for user %s", project.Name, user)
+				metrics.ProjectsCreated.Inc()
+				c.recordProvisioningEvent(ctx, user, project.Name, corev1.EventTypeNormal, projectCreatedReason,
+					fmt.Sprintf("Created project %s for user %s", project.Name, user))
 			}
 		} else {
 			// Just log the error for now
@@ -194,17 +617,175 @@ func (c *Controller) createUserProject(user string) error {
 		}
 	} else {
 		klog.Infof("Project %s already exists for user %s", project.Name, user)
+		return c.reconcileExistingProject(ctx, user, existingProject)
+	}
+
+	return nil
+}
+
+// Creates the project, through a ProjectRequest when PROJECT_REQUEST_ENABLED is set so the project
+// request template of the cluster applies. A request can't carry labels and annotations, so the
+// project is labeled as managed once created, and deleted again when that fails so it is requested
+// anew instead of found unowned on the next attempt. With server-side apply, the labels and
+// annotations are also applied to the namespace of a project created directly, so the controller
+// owns them as it does the fields of the other managed objects.
+func (c *Controller) createProject(ctx context.Context, user string, project *projectv1.Project) error {
+	if !GetProjectRequestEnabled() {
+		_, err := c.projectClient.ProjectV1().Projects().Create(ctx, project, metav1.CreateOptions{})
+		if err != nil || !GetServerSideApplyEnabled() {
+			return err
+		}
+	} else {
+		request := &projectv1.ProjectRequest{
+			ObjectMeta:  metav1.ObjectMeta{Name: project.Name},
+			DisplayName: project.Annotations[displayNameAnnotation],
+			Description: project.Annotations[descriptionAnnotation],
+		}
+		if _, err := c.projectClient.ProjectV1().ProjectRequests().Create(ctx, request, metav1.CreateOptions{}); err != nil {
+			return err
+		}
 	}
 
+	if err := c.labelCreatedProject(ctx, project); err != nil {
+		klog.Errorf("Error labeling created project %s of user %s as managed, deleting it: %v", project.Name, user, err)
+		if err := c.projectClient.ProjectV1().Projects().Delete(ctx, project.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Error deleting unlabeled project %s of user %s: %v", project.Name, user, err)
+		}
+		return err
+	}
 	return nil
 }
 
-// Creates user project RoleBinding for edit permissions
-func (c *Controller) createRoleBinding(user string, projectName string) error {
-	roleBinding := &rbacv1.RoleBinding{
+// Sets the labels and annotations of the desired project on the project just created, applying them
+// to its namespace when SERVER_SIDE_APPLY_ENABLED is set, as projects can't be applied
+func (c *Controller) labelCreatedProject(ctx context.Context, project *projectv1.Project) error {
+	if GetServerSideApplyEnabled() {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        project.Name,
+				Labels:      project.Labels,
+				Annotations: project.Annotations,
+			},
+		}
+		_, err := applyObject(ctx, c.coreClient.Namespaces().Patch, namespace, corev1.SchemeGroupVersion.WithKind("Namespace"))
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      project.Labels,
+			"annotations": project.Annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.projectClient.ProjectV1().Projects().Patch(ctx, project.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// Applies the configured policy to a pre-existing project which is not labeled as owned by the user
+func (c *Controller) reconcileExistingProject(ctx context.Context, user string, project *projectv1.Project) error {
+	owner := objectOwner(project)
+	if owner == user {
+		// label projects provisioned before managed-by labels were set, so they can still be deleted,
+		// and manage projects retained or scheduled for deletion while the user was removed again
+		if isManaged(project) && project.Annotations[ownerAnnotation] == user && !removedUserMarked(project) {
+			return nil
+		}
+		_, err := c.projectClient.ProjectV1().Projects().Patch(ctx, project.Name, types.MergePatchType, managedProjectPatch(user), metav1.PatchOptions{})
+		if err != nil {
+			klog.Errorf("Error labeling project %s of user %s as managed: %v", project.Name, user, err)
+			return err
+		}
+		klog.Infof("Labeled project %s of user %s as managed", project.Name, user)
+		return nil
+	}
+	if owner != "" {
+		err := fmt.Errorf("project %s is owned by user %s and cannot be provisioned for user %s", project.Name, owner, user)
+		klog.Error(err)
+		return err
+	}
+
+	policy, err := GetExistingProjectPolicy()
+	if err != nil {
+		klog.Errorf("Error reading existing project policy for user %s: %v", user, err)
+		return err
+	}
+
+	switch policy {
+	case ExistingProjectClaim:
+		_, err := c.projectClient.ProjectV1().Projects().Patch(ctx, project.Name, types.MergePatchType, managedProjectPatch(user), metav1.PatchOptions{})
+		if err != nil {
+			klog.Errorf("Error claiming project %s for user %s: %v", project.Name, user, err)
+			return err
+		}
+		klog.Infof("Claimed pre-existing project %s for user %s", project.Name, user)
+	case ExistingProjectConflict:
+		err := fmt.Errorf("project %s already exists and is not owned by user %s", project.Name, user)
+		klog.Error(err)
+		return err
+	case ExistingProjectRename:
+		// created since the name was checked, the next attempt renames the project
+		err := fmt.Errorf("project %s was created while provisioning user %s and is not owned by them", project.Name, user)
+		klog.Error(err)
+		return err
+	default:
+		klog.Warningf("Project %s already exists but is not owned by user %s, provisioning without claiming it", project.Name, user)
+	}
+	return nil
+}
+
+// Returns the name of the RoleBinding managed under the target project. It keeps the edit suffix
+// whichever ClusterRole it grants, so RoleBindings of existing namespaces remain managed.
+func roleBindingName(projectName string) string {
+	return fmt.Sprintf("%s-edit", projectName)
+}
+
+// Returns the ClusterRole granted to the target user, from their profile or else overridden by the
+// target group granting their namespace when configured
+func (c *Controller) userClusterRole(ctx context.Context, user string) (string, error) {
+	profile, err := c.userProfile(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	if profile != nil && profile.ClusterRole != "" {
+		return profile.ClusterRole, nil
+	}
+
+	overrides, err := GetUserClusterRoleOverrides()
+	if err != nil {
+		return "", err
+	}
+	if len(overrides) == 0 {
+		return GetUserClusterRole(), nil
+	}
+
+	group, err := c.sourceGroup(ctx, user)
+	if err != nil {
+		klog.Errorf("Error getting the target group of user %s to select their ClusterRole: %v", user, err)
+		return "", err
+	}
+	if group != nil {
+		if role, ok := overrides[group.Name]; ok {
+			return role, nil
+		}
+	}
+	return GetUserClusterRole(), nil
+}
+
+// Returns the RoleBinding granting the target user the ClusterRole under the project
+func desiredRoleBinding(user string, projectName string, clusterRole string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-edit", projectName),
+			Name:      roleBindingName(projectName),
 			Namespace: projectName,
+			Labels: map[string]string{
+				managedByLabel: componentName,
+			},
+			Annotations: map[string]string{
+				ownerAnnotation: user,
+			},
 		},
 		Subjects: []rbacv1.Subject{
 			{
@@ -216,30 +797,57 @@ func (c *Controller) createRoleBinding(user string, projectName string) error {
 		RoleRef: rbacv1.RoleRef{
 			APIGroup: "rbac.authorization.k8s.io",
 			Kind:     "ClusterRole",
-			Name:     "edit",
+			Name:     clusterRole,
 		},
 	}
+}
 
-	existingRoleBinding, err := c.rbacClient.RoleBindings(projectName).Get(context.Background(), roleBinding.Name, metav1.GetOptions{})
+// Creates user project RoleBinding granting the configured ClusterRole, replacing it when it grants
+// another ClusterRole
+func (c *Controller) createRoleBinding(ctx context.Context, user string, projectName string) error {
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return err
+	}
+	roleBinding := desiredRoleBinding(user, projectName, clusterRole)
+
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(roleBinding, anchorRef)
+
+	existingRoleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, roleBinding.Name, metav1.GetOptions{})
 	if err != nil {
 		if errors.IsNotFound(err) {
 			klog.Infof("RoleBinding %s not found for user %s under project %s", roleBinding.Name, user, projectName)
 
-			_, err := c.rbacClient.RoleBindings(projectName).Create(context.Background(), roleBinding, metav1.CreateOptions{})
+			err := c.createManagedRoleBinding(ctx, roleBinding)
 			if err != nil {
-				klog.Errorf("Error creating edit RoleBinding for user %s under project %s: %v", user, projectName, err)
+				klog.Errorf("Error creating %s RoleBinding for user %s under project %s: %v", clusterRole, user, projectName, err)
 				return err
 			} else {
-				klog.Infof("Successfully created edit RoleBinding %s for user %s under project %s", roleBinding.Name, user, projectName)
+				klog.Infof("Successfully created %s RoleBinding %s for user %s under project %s", clusterRole, roleBinding.Name, user, projectName)
+				metrics.RoleBindingsCreated.Inc()
+				c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, roleBindingCreatedReason,
+					fmt.Sprintf("Created RoleBinding %s granting ClusterRole %s to user %s", roleBinding.Name, clusterRole, user))
 			}
 		} else {
 			klog.Errorf("Error checking if RoleBinding exists for user %s under project %s: %v", user, projectName, err)
 			return err
 		}
 	} else {
-		// error if existing RoleBinding is not owned
+		// error if existing RoleBinding is not owned, unless it is taken over
+		adopted := false
 		for _, subject := range existingRoleBinding.Subjects {
 			if subject.Kind == "User" && subject.Name != user {
+				if GetAdoptExistingEnabled() {
+					klog.Warningf("Adopting RoleBinding %s under project %s binding user %s for user %s", existingRoleBinding.Name, projectName, subject.Name, user)
+					existingRoleBinding.Subjects = roleBinding.Subjects
+					adopted = true
+					break
+				}
 				err := fmt.Errorf("RoleBinding %s under project %s already belongs to user %s and cannot be assigned to user %s",
 					existingRoleBinding.Name,
 					projectName,
@@ -251,31 +859,54 @@ func (c *Controller) createRoleBinding(user string, projectName string) error {
 			}
 		}
 		klog.Infof("RoleBinding %s under project %s already exist for user %s", roleBinding.Name, user, projectName)
+
+		// adopt RoleBindings of the user created before owner references or managed-by labels were set,
+		// before they may be replaced
+		if setAnchorReference(existingRoleBinding, anchorRef) {
+			adopted = true
+		}
+		if setManagedMetadata(existingRoleBinding, user) {
+			adopted = true
+		}
+		if adopted {
+			if _, err := c.rbacClient.RoleBindings(projectName).Update(ctx, existingRoleBinding, metav1.UpdateOptions{}); err != nil {
+				klog.Errorf("Error adopting RoleBinding %s for user %s under project %s: %v", existingRoleBinding.Name, user, projectName, err)
+				return err
+			}
+			klog.Infof("Adopted RoleBinding %s for user %s under project %s", existingRoleBinding.Name, user, projectName)
+		}
+
+		// the role of a RoleBinding is immutable, so one granting another ClusterRole is recreated
+		if existingRoleBinding.RoleRef != roleBinding.RoleRef {
+			if err := c.replaceRoleBinding(ctx, user, projectName, roleBinding); err != nil {
+				return err
+			}
+			klog.Infof("Replaced RoleBinding %s granting ClusterRole %s with %s for user %s under project %s",
+				roleBinding.Name,
+				existingRoleBinding.RoleRef.Name,
+				clusterRole,
+				user,
+				projectName,
+			)
+		}
 	}
 
 	return nil
 }
 
-// Run starts the controller and blocks until the context is cancelled
-func (c *Controller) Run(ctx context.Context) error {
-	klog.Info("Starting controller")
-
-	// Start the informer
-	go c.informer.Run(c.stopCh)
-
-	// Wait for the informer cache to sync
-	if !cache.WaitForCacheSync(c.stopCh, c.informer.HasSynced) {
-		return fmt.Errorf("failed to wait for caches to sync")
+// Deletes the RoleBinding under the target user project and creates it again as desired
+func (c *Controller) replaceRoleBinding(ctx context.Context, user string, projectName string, roleBinding *rbacv1.RoleBinding) error {
+	err := c.rbacClient.RoleBindings(projectName).Delete(ctx, roleBinding.Name, metav1.DeleteOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error deleting RoleBinding %s for user %s under project %s: %v", roleBinding.Name, user, projectName, err)
+		return err
 	}
-
-	targetGroupName := GetTargetGroupName()
-	klog.Infof("Controller started successfully, watching for updates to Group: %s", targetGroupName)
-
-	// Wait for context cancellation
-	<-ctx.Done()
-
-	klog.Info("Shutting down controller")
-	close(c.stopCh)
-
+	if err := c.createManagedRoleBinding(ctx, roleBinding); err != nil {
+		klog.Errorf("Error creating RoleBinding %s for user %s under project %s: %v", roleBinding.Name, user, projectName, err)
+		return err
+	}
+	metrics.RoleBindingsCreated.Inc()
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, roleBindingCreatedReason,
+		fmt.Sprintf("Created RoleBinding %s granting ClusterRole %s to user %s", roleBinding.Name, roleBinding.RoleRef.Name, user))
 	return nil
 }
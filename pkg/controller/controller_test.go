@@ -9,13 +9,16 @@ import (
 	projectv1 "github.com/openshift/api/project/v1"
 	userv1 "github.com/openshift/api/user/v1"
 	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
 	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 func TestGetTargetGroupName(t *testing.T) {
@@ -247,19 +250,20 @@ func TestController_createRoleBinding(t *testing.T) {
 			// Create fake clients
 			userClient := userfake.NewSimpleClientset(userObjects...)
 			projectClient := projectfake.NewSimpleClientset(projectObjects...)
-			rbacClient := fake.NewSimpleClientset(kubernetesObjects...).RbacV1()
+			kubeClient := fake.NewClientset(kubernetesObjects...)
 
 			// Create controller
 			controller := &Controller{
 				userClient:    userClient,
 				projectClient: projectClient,
-				rbacClient:    rbacClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
 			}
 
 			errorCount := 0
 			for _, userinfo := range tt.users {
 				expectedRoleBindingName := fmt.Sprintf("%s-edit", userinfo.project)
-				err := controller.createRoleBinding(userinfo.user, userinfo.project)
+				err := controller.createRoleBinding(ctx, userinfo.user, userinfo.project)
 				if !tt.shouldError && err != nil {
 					t.Errorf("Expected RoleBinding %s to be created, but got error: %v", expectedRoleBindingName, err)
 					continue
@@ -281,6 +285,125 @@ func TestController_createRoleBinding(t *testing.T) {
 	}
 }
 
+func TestController_createRoleBindingClusterRole(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "workshop,workshop-staff")
+	t.Setenv("USER_CLUSTER_ROLE", "view")
+
+	ctx := context.Background()
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(newGroup("workshop", "alice"), newGroup("workshop-staff", "bob")),
+		rbacClient: fake.NewClientset().RbacV1(),
+	}
+	grantedRole := func(user string) string {
+		t.Helper()
+		roleBinding, err := controller.rbacClient.RoleBindings(user).Get(ctx, roleBindingName(user), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected RoleBinding %s to be found, but got error: %v", roleBindingName(user), err)
+		}
+		return roleBinding.RoleRef.Name
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if err := controller.createRoleBinding(ctx, user, user); err != nil {
+			t.Fatalf("Expected RoleBinding to be created for user %s, but got error: %v", user, err)
+		}
+		if role := grantedRole(user); role != "view" {
+			t.Errorf("Expected user %s to be granted ClusterRole view, but got %s", user, role)
+		}
+	}
+
+	// Overriding the role of a group replaces the RoleBindings of its members only
+	t.Setenv("USER_CLUSTER_ROLE_OVERRIDES", "workshop-staff=admin")
+	for _, user := range []string{"alice", "bob"} {
+		if err := controller.createRoleBinding(ctx, user, user); err != nil {
+			t.Fatalf("Expected RoleBinding to be updated for user %s, but got error: %v", user, err)
+		}
+	}
+	if role := grantedRole("alice"); role != "view" {
+		t.Errorf("Expected user alice to keep ClusterRole view, but got %s", role)
+	}
+	if role := grantedRole("bob"); role != "admin" {
+		t.Errorf("Expected user bob to be granted ClusterRole admin, but got %s", role)
+	}
+}
+
+func TestController_createRoleBindingAdoptExisting(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: roleBindingName("alice"), Namespace: "alice"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "mallory"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "view"},
+	})
+	controller := &Controller{rbacClient: kubeClient.RbacV1()}
+
+	// A RoleBinding binding another user fails the user unless it is adopted
+	if err := controller.createRoleBinding(ctx, "alice", "alice"); err == nil {
+		t.Fatalf("Expected the RoleBinding of another user to fail the user")
+	}
+
+	t.Setenv("ADOPT_EXISTING_ROLEBINDINGS", "true")
+	if err := controller.createRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the RoleBinding to be adopted, but got error: %v", err)
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, roleBindingName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected RoleBinding %s to be found, but got error: %v", roleBindingName("alice"), err)
+	}
+	if !isManaged(roleBinding) || !bindsUser(roleBinding, "alice") || len(roleBinding.Subjects) != 1 {
+		t.Errorf("Expected the adopted RoleBinding to be managed and bind only alice, but got %+v", roleBinding)
+	}
+	if roleBinding.RoleRef.Name != "edit" {
+		t.Errorf("Expected the adopted RoleBinding to grant edit, but got %s", roleBinding.RoleRef.Name)
+	}
+}
+
+func TestController_createUserProjectRequest(t *testing.T) {
+	t.Setenv("PROJECT_REQUEST_ENABLED", "true")
+	// requested projects are labeled through the Project API rather than applied to their namespace
+	t.Setenv("SERVER_SIDE_APPLY_ENABLED", "false")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	// the API server creates the project of a request from the project request template
+	projectClient.PrependReactor("create", "projectrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		request := action.(k8stesting.CreateAction).GetObject().(*projectv1.ProjectRequest)
+		project := &projectv1.Project{ObjectMeta: metav1.ObjectMeta{
+			Name:        request.Name,
+			Annotations: map[string]string{"openshift.io/sa.scc.mcs": "s0:c26,c5"},
+		}}
+		if err := projectClient.Tracker().Add(project); err != nil {
+			return true, nil, err
+		}
+		return true, project, nil
+	})
+	controller := &Controller{projectClient: projectClient}
+
+	if err := controller.createUserProject(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected project alice to be requested, but got error: %v", err)
+	}
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project alice to be created, but got error: %v", err)
+	}
+	if objectOwner(project) != "alice" || !isManaged(project) {
+		t.Errorf("Expected the requested project to be labeled as owned by alice, but got labels %v", project.Labels)
+	}
+	if project.Annotations["openshift.io/sa.scc.mcs"] == "" {
+		t.Errorf("Expected the annotations set by the template to be kept, but got %v", project.Annotations)
+	}
+
+	// A requested project that can't be labeled is deleted so it is requested again
+	projectClient.PrependReactor("patch", "projects", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("patch failed")
+	})
+	if err := controller.createUserProject(ctx, "bob", "bob"); err == nil {
+		t.Fatalf("Expected the unlabeled project to fail user bob")
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected the unlabeled project bob to be deleted, but got error: %v", err)
+	}
+}
+
 func TestController_createUserProject(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -360,18 +483,19 @@ func TestController_createUserProject(t *testing.T) {
 			// Create fake clients
 			userClient := userfake.NewSimpleClientset(userObjects...)
 			projectClient := projectfake.NewSimpleClientset(projectObjects...)
-			rbacClient := fake.NewSimpleClientset(namespaceObjects...).RbacV1()
+			kubeClient := fake.NewClientset(namespaceObjects...)
 
 			// Create controller
 			controller := &Controller{
 				userClient:    userClient,
 				projectClient: projectClient,
-				rbacClient:    rbacClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
 			}
 
 			errorCount := 0
 			for _, user := range tt.users {
-				err := controller.createUserProject(user)
+				err := controller.createUserProject(ctx, user, user)
 				if !tt.shouldError && err != nil {
 					t.Errorf("Expected project %s to be created, but got error: %v", user, err)
 					continue
@@ -534,7 +658,8 @@ func TestController_handleGroup(t *testing.T) {
 			for _, projectName := range tt.existingProjects {
 				projectObjects = append(projectObjects, &projectv1.Project{
 					ObjectMeta: metav1.ObjectMeta{
-						Name: projectName,
+						Name:   projectName,
+						Labels: map[string]string{managedByLabel: componentName},
 					},
 				})
 				namespaceObjects = append(namespaceObjects, &corev1.Namespace{
@@ -546,13 +671,14 @@ func TestController_handleGroup(t *testing.T) {
 
 			userClient := userfake.NewSimpleClientset()
 			projectClient := projectfake.NewSimpleClientset(projectObjects...)
-			rbacClient := fake.NewSimpleClientset(namespaceObjects...).RbacV1()
+			kubeClient := fake.NewClientset(namespaceObjects...)
 
 			// Create controller
 			controller := &Controller{
 				userClient:    userClient,
 				projectClient: projectClient,
-				rbacClient:    rbacClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
 			}
 
 			// Call handleGroup
@@ -626,12 +752,13 @@ func TestController_handleGroupErrorHandling(t *testing.T) {
 
 		userClient := userfake.NewSimpleClientset()
 		projectClient := projectfake.NewSimpleClientset(existingProject)
-		rbacClient := fake.NewSimpleClientset(existingNamespace).RbacV1()
+		kubeClient := fake.NewClientset(existingNamespace)
 
 		controller := &Controller{
 			userClient:    userClient,
 			projectClient: projectClient,
-			rbacClient:    rbacClient,
+			rbacClient:    kubeClient.RbacV1(),
+			coreClient:    kubeClient.CoreV1(),
 		}
 
 		// Create group with users where one will conflict
@@ -674,9 +801,14 @@ func TestNewController(t *testing.T) {
 
 	userClient := userfake.NewSimpleClientset()
 	projectClient := projectfake.NewSimpleClientset()
-	rbacClient := fake.NewSimpleClientset().RbacV1()
+	kubeClient := fake.NewClientset()
+	rbacClient := kubeClient.RbacV1()
+	quotaClient := quotafake.NewClientset()
+	coreClient := kubeClient.CoreV1()
 
-	controller := NewController(userClient, projectClient, rbacClient)
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+
+	controller := NewController(userClient, projectClient, rbacClient, quotaClient, coreClient, dynamicClient)
 
 	if controller == nil {
 		t.Fatal("Expected controller to be created, but got nil")
@@ -694,11 +826,127 @@ func TestNewController(t *testing.T) {
 		t.Error("Expected rbacClient to be set correctly")
 	}
 
+	if controller.quotaClient != quotaClient {
+		t.Error("Expected quotaClient to be set correctly")
+	}
+
+	if controller.coreClient != coreClient {
+		t.Error("Expected coreClient to be set correctly")
+	}
+
+	if controller.dynamicClient != dynamicClient {
+		t.Error("Expected dynamicClient to be set correctly")
+	}
+
 	if controller.informer == nil {
 		t.Error("Expected informer to be created")
 	}
 
-	if controller.stopCh == nil {
-		t.Error("Expected stopCh to be created")
+	if controller.namespaceInformer == nil {
+		t.Error("Expected namespace informer to be created")
+	}
+}
+
+func TestTuneListOptions(t *testing.T) {
+	t.Setenv("INFORMER_WATCH_TIMEOUT", "2m")
+	t.Setenv("INFORMER_LIST_PAGE_SIZE", "100")
+
+	listOptions := metav1.ListOptions{}
+	tuneListOptions(&listOptions)
+	if listOptions.Limit != 100 {
+		t.Errorf("Expected list page size of 100, but got %d", listOptions.Limit)
+	}
+	if listOptions.TimeoutSeconds != nil {
+		t.Errorf("Expected no timeout on list requests, but got %d", *listOptions.TimeoutSeconds)
+	}
+
+	watchOptions := metav1.ListOptions{Watch: true}
+	tuneListOptions(&watchOptions)
+	if watchOptions.Limit != 0 {
+		t.Errorf("Expected no page size on watch requests, but got %d", watchOptions.Limit)
+	}
+	if watchOptions.TimeoutSeconds == nil || *watchOptions.TimeoutSeconds != 120 {
+		t.Errorf("Expected watch timeout of 120 seconds, but got %v", watchOptions.TimeoutSeconds)
+	}
+}
+
+func TestController_createUserProjectExistingPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        string
+		owner         string
+		shouldError   bool
+		expectedOwner string
+		managed       bool
+	}{
+		{
+			name:   "skip leaves unlabeled project unclaimed",
+			policy: "skip",
+		},
+		{
+			name:          "claim labels unlabeled project",
+			policy:        "claim",
+			expectedOwner: "alice",
+			managed:       true,
+		},
+		{
+			name:          "owned project is labeled as managed",
+			policy:        "skip",
+			owner:         "alice",
+			expectedOwner: "alice",
+			managed:       true,
+		},
+		{
+			name:        "conflict fails on unlabeled project",
+			policy:      "conflict",
+			shouldError: true,
+		},
+		{
+			name:          "project owned by another user always fails",
+			policy:        "claim",
+			owner:         "bob",
+			shouldError:   true,
+			expectedOwner: "bob",
+		},
+		{
+			name:        "invalid policy",
+			policy:      "adopt",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("EXISTING_PROJECT_POLICY", tt.policy)
+
+			ctx := context.Background()
+			project := &projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice"}}
+			if tt.owner != "" {
+				project.Labels = map[string]string{ownerLabel: tt.owner}
+			}
+			projectClient := projectfake.NewSimpleClientset(project)
+			controller := &Controller{
+				projectClient: projectClient,
+			}
+
+			err := controller.createUserProject(ctx, "alice", "alice")
+			if tt.shouldError && err == nil {
+				t.Errorf("Expected case '%s' to receive an error", tt.name)
+			}
+			if !tt.shouldError && err != nil {
+				t.Errorf("Expected project to be accepted, but got error: %v", err)
+			}
+
+			got, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected project alice to be found, but got error: %v", err)
+			}
+			if owner := got.Labels[ownerLabel]; owner != tt.expectedOwner {
+				t.Errorf("Expected owner label %q, but got %q", tt.expectedOwner, owner)
+			}
+			if managed := isManaged(got) && got.Annotations[ownerAnnotation] == tt.expectedOwner; managed != tt.managed {
+				t.Errorf("Expected project to be managed: %t, but got %v", tt.managed, got.ObjectMeta)
+			}
+		})
 	}
 }
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation of LoadBalancer Services listing the extra tags the AWS Load Balancer Controller and
+// the cloud provider set on the load balancers they create
+const loadBalancerTagsAnnotation = "service.beta.kubernetes.io/aws-load-balancer-additional-resource-tags"
+
+// hours in an average month, converting monthly storage prices to hourly ones
+const hoursPerMonth = 730
+
+// Tags the AWS resources created from every managed namespace with their owner and exports the
+// estimated hourly cost of each namespace
+func (c *Controller) trackCosts(ctx context.Context) {
+	namespaces, err := c.coreClient.Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing managed namespaces for cost tracking: %v", err)
+		return
+	}
+
+	// Drop the estimates of namespaces which no longer exist
+	metrics.EstimatedHourlyCost.Reset()
+	prices := GetCostPrices()
+	for _, namespace := range namespaces.Items {
+		user := objectOwner(&namespace)
+		cost, err := c.trackNamespaceCost(ctx, user, namespace.Name, prices)
+		if err != nil {
+			continue
+		}
+		metrics.EstimatedHourlyCost.WithLabelValues(user, namespace.Name).Set(cost)
+	}
+}
+
+// Tags the load balancers of a managed namespace with its owner and returns the estimated hourly
+// cost of the resources requested in it
+func (c *Controller) trackNamespaceCost(ctx context.Context, user string, namespace string, prices CostPrices) (float64, error) {
+	var cost float64
+
+	services, err := c.coreClient.Services(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing Services for user %s under project %s: %v", user, namespace, err)
+		return 0, err
+	}
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.Type != corev1.ServiceTypeLoadBalancer {
+			continue
+		}
+		cost += prices.LoadBalancerHour
+
+		tags, changed := mergeTag(service.Annotations[loadBalancerTagsAnnotation], GetCostTagKey(), user)
+		if !changed {
+			continue
+		}
+		if GetDryRunEnabled() {
+			klog.Infof("Dry run: skipping tagging of Service %s for user %s under project %s", service.Name, user, namespace)
+			continue
+		}
+		if service.Annotations == nil {
+			service.Annotations = make(map[string]string)
+		}
+		service.Annotations[loadBalancerTagsAnnotation] = tags
+		if _, err := c.coreClient.Services(namespace).Update(ctx, service, metav1.UpdateOptions{}); err != nil {
+			klog.Errorf("Error tagging Service %s for user %s under project %s: %v", service.Name, user, namespace, err)
+			continue
+		}
+		klog.Infof("Tagged Service %s for user %s under project %s", service.Name, user, namespace)
+	}
+
+	pods, err := c.coreClient.Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing Pods for user %s under project %s: %v", user, namespace, err)
+		return 0, err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, container := range pod.Spec.Containers {
+			requests := container.Resources.Requests
+			cost += requests.Cpu().AsApproximateFloat64() * prices.CPUCoreHour
+			cost += gibibytes(requests.Memory()) * prices.MemoryGiBHour
+		}
+	}
+
+	claims, err := c.coreClient.PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Errorf("Error listing PersistentVolumeClaims for user %s under project %s: %v", user, namespace, err)
+		return 0, err
+	}
+	for _, claim := range claims.Items {
+		cost += gibibytes(claim.Spec.Resources.Requests.Storage()) * prices.StorageGiBMonth / hoursPerMonth
+	}
+
+	return cost, nil
+}
+
+// Returns a quantity in GiB
+func gibibytes(quantity *resource.Quantity) float64 {
+	return quantity.AsApproximateFloat64() / (1 << 30)
+}
+
+// Sets a tag in a comma separated list of key=value tags, returning the sorted list and whether
+// the tag was missing or had another value
+func mergeTag(tags string, key string, value string) (string, bool) {
+	parsed := make(map[string]string)
+	for _, tag := range strings.Split(tags, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		k, v, _ := strings.Cut(tag, "=")
+		parsed[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	if current, ok := parsed[key]; ok && current == value {
+		return tags, false
+	}
+	parsed[key] = value
+
+	merged := make([]string, 0, len(parsed))
+	for k, v := range parsed {
+		merged = append(merged, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(merged)
+	return strings.Join(merged, ","), true
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestMergeTag(t *testing.T) {
+	tests := []struct {
+		name            string
+		tags            string
+		expected        string
+		expectedChanged bool
+	}{
+		{
+			name:            "no tags",
+			expected:        "owner=alice",
+			expectedChanged: true,
+		},
+		{
+			name:            "other tags are kept",
+			tags:            "team=ai, env=dev",
+			expected:        "env=dev,owner=alice,team=ai",
+			expectedChanged: true,
+		},
+		{
+			name:            "tag with another value",
+			tags:            "owner=bob",
+			expected:        "owner=alice",
+			expectedChanged: true,
+		},
+		{
+			name:     "tag already set",
+			tags:     "team=ai,owner=alice",
+			expected: "team=ai,owner=alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, changed := mergeTag(tt.tags, "owner", "alice")
+			if tags != tt.expected || changed != tt.expectedChanged {
+				t.Errorf("mergeTag() = %q, %v, want %q, %v", tags, changed, tt.expected, tt.expectedChanged)
+			}
+		})
+	}
+}
+
+func TestController_trackCosts(t *testing.T) {
+	t.Setenv("COST_PRICE_CPU_CORE_HOUR", "0.04")
+	t.Setenv("COST_PRICE_MEMORY_GIB_HOUR", "0.005")
+	t.Setenv("COST_PRICE_STORAGE_GIB_MONTH", "0.073")
+	t.Setenv("COST_PRICE_LOAD_BALANCER_HOUR", "0.02")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "model", Namespace: "alice"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "model",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("2"),
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "alice"},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "job",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8")},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodSucceeded},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "alice"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.VolumeResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")},
+				},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "model",
+				Namespace:   "alice",
+				Annotations: map[string]string{loadBalancerTagsAnnotation: "team=ai"},
+			},
+			Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal", Namespace: "alice"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		},
+	)
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	controller.trackCosts(ctx)
+
+	service, err := kubeClient.CoreV1().Services("alice").Get(ctx, "model", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected Service model to exist, but got error: %v", err)
+	}
+	if tags := service.Annotations[loadBalancerTagsAnnotation]; tags != "rosa-namespace-provisioner/owner=alice,team=ai" {
+		t.Errorf("Expected load balancer to be tagged with its owner, but got %q", tags)
+	}
+	internal, err := kubeClient.CoreV1().Services("alice").Get(ctx, "internal", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected Service internal to exist, but got error: %v", err)
+	}
+	if _, ok := internal.Annotations[loadBalancerTagsAnnotation]; ok {
+		t.Errorf("Expected ClusterIP Service not to be tagged")
+	}
+
+	// 2 cores, 4GiB of memory, 100GiB of storage and a load balancer
+	expected := 2*0.04 + 4*0.005 + 100*0.073/730 + 0.02
+	cost := testutil.ToFloat64(metrics.EstimatedHourlyCost.WithLabelValues("alice", "alice"))
+	if math.Abs(cost-expected) > 1e-9 {
+		t.Errorf("Expected estimated hourly cost %g, but got %g", expected, cost)
+	}
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation set by admins on a managed namespace listing the comma separated users who share it with
+// its owner, e.g. covering a vacation or a mentor
+const delegatesAnnotation = "rosa-namespace-provisioner/delegates"
+
+// Returns the name of the RoleBinding granting the delegates of the namespace access
+func delegatesRoleBindingName(projectName string) string {
+	return fmt.Sprintf("%s-delegates", projectName)
+}
+
+// Returns the sorted delegates listed on the namespace, other than its owner
+func namespaceDelegates(namespace *corev1.Namespace, user string) []string {
+	seen := make(map[string]bool)
+	var delegates []string
+	for _, delegate := range strings.Split(namespace.Annotations[delegatesAnnotation], ",") {
+		delegate = strings.TrimSpace(delegate)
+		if delegate == "" || delegate == user || seen[delegate] {
+			continue
+		}
+		seen[delegate] = true
+		delegates = append(delegates, delegate)
+	}
+	sort.Strings(delegates)
+	return delegates
+}
+
+// Returns the delegates of the target user project, none if its namespace is gone
+func (c *Controller) projectDelegates(ctx context.Context, user string, projectName string) ([]string, error) {
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return namespaceDelegates(namespace, user), nil
+}
+
+// Returns the RoleBinding granting the delegates the ClusterRole of the owner under the project
+func desiredDelegatesRoleBinding(user string, projectName string, clusterRole string, delegates []string) *rbacv1.RoleBinding {
+	roleBinding := desiredRoleBinding(user, projectName, clusterRole)
+	roleBinding.Name = delegatesRoleBindingName(projectName)
+	roleBinding.Subjects = make([]rbacv1.Subject, 0, len(delegates))
+	for _, delegate := range delegates {
+		roleBinding.Subjects = append(roleBinding.Subjects, rbacv1.Subject{
+			Kind:     "User",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     delegate,
+		})
+	}
+	return roleBinding
+}
+
+// Grants the delegates of the target user project the ClusterRole of its owner while the owner's
+// access window is open, restoring the RoleBinding when modified, and deletes it when there are no
+// delegates or the window is closed
+func (c *Controller) syncDelegatesRoleBinding(ctx context.Context, user string, projectName string) error {
+	delegates, err := c.projectDelegates(ctx, user, projectName)
+	if err != nil {
+		klog.Errorf("Error getting delegates of user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil {
+		klog.Errorf("Error checking the access window of user %s: %v", user, err)
+		return err
+	}
+	name := delegatesRoleBindingName(projectName)
+	existing, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	found := err == nil
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error checking if RoleBinding %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	adopting := found && !isManaged(existing)
+	if adopting && !GetAdoptExistingEnabled() {
+		err := fmt.Errorf("RoleBinding %s under project %s is not managed by the controller and will not be overwritten", name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	if len(delegates) == 0 || !open {
+		if !found {
+			return nil
+		}
+		return c.deleteDelegatesRoleBinding(ctx, user, projectName)
+	}
+
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return err
+	}
+	roleBinding := desiredDelegatesRoleBinding(user, projectName, clusterRole, delegates)
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(roleBinding, anchorRef)
+	if adopting {
+		klog.Warningf("Adopting RoleBinding %s under project %s not created by the controller", name, projectName)
+		setManagedMetadata(existing, user)
+		setAnchorReference(existing, anchorRef)
+	}
+
+	switch {
+	case !found:
+		if err := c.createManagedRoleBinding(ctx, roleBinding); err != nil {
+			klog.Errorf("Error creating RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Granted delegates %v of user %s ClusterRole %s under project %s", delegates, user, clusterRole, projectName)
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, roleBindingCreatedReason,
+			fmt.Sprintf("Created RoleBinding %s granting ClusterRole %s to delegates %s", name, clusterRole, strings.Join(delegates, ", ")))
+	case existing.RoleRef != roleBinding.RoleRef:
+		// the role of a RoleBinding is immutable
+		return c.replaceRoleBinding(ctx, user, projectName, roleBinding)
+	case adopting || !equality.Semantic.DeepEqual(existing.Subjects, roleBinding.Subjects):
+		existing.Subjects = roleBinding.Subjects
+		if _, err := c.rbacClient.RoleBindings(projectName).Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			klog.Errorf("Error updating RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Updated delegates of user %s under project %s to %v", user, projectName, delegates)
+	default:
+		klog.V(2).Infof("RoleBinding %s under project %s already grants the delegates of user %s", name, projectName, user)
+	}
+	return nil
+}
+
+// Deletes the RoleBinding of the delegates under the target user project, keeping one not created by
+// the controller
+func (c *Controller) deleteDelegatesRoleBinding(ctx context.Context, user string, projectName string) error {
+	name := delegatesRoleBindingName(projectName)
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	if !isManaged(roleBinding) {
+		klog.Warningf("RoleBinding %s under project %s is not managed by the controller and will not be deleted", name, projectName)
+		return nil
+	}
+	if err := c.rbacClient.RoleBindings(projectName).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Deleted RoleBinding %s of the delegates of user %s under project %s", name, user, projectName)
+	return nil
+}
+
+// Returns the drift of the RoleBinding of the delegates under the target user project, if any
+func (c *Controller) delegatesDrift(ctx context.Context, user string, projectName string) (string, error) {
+	delegates, err := c.projectDelegates(ctx, user, projectName)
+	if err != nil || len(delegates) == 0 {
+		return "", err
+	}
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil || !open {
+		return "", err
+	}
+	name := delegatesRoleBindingName(projectName)
+	existing, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("RoleBinding %s is missing", name), nil
+	} else if err != nil {
+		return "", err
+	}
+	desired := desiredDelegatesRoleBinding(user, projectName, existing.RoleRef.Name, delegates)
+	if !equality.Semantic.DeepEqual(existing.Subjects, desired.Subjects) {
+		return fmt.Sprintf("RoleBinding %s does not bind delegates %s", name, strings.Join(delegates, ", ")), nil
+	}
+	return "", nil
+}
+
+// Sends a copy of a notification about the namespace of the target user to each of its delegates
+func (c *Controller) notifyDelegates(ctx context.Context, user string, notification notify.Notification) {
+	if !GetDelegatesEnabled() || notification.Namespace == "" {
+		return
+	}
+	delegates, err := c.projectDelegates(ctx, user, notification.Namespace)
+	if err != nil {
+		klog.Errorf("Error getting delegates of user %s to notify: %v", user, err)
+		return
+	}
+	for _, delegate := range delegates {
+		notification.User = delegate
+		if err := c.notifier.Notify(ctx, notification); err != nil {
+			klog.Errorf("Error notifying delegate %s of user %s: %v", delegate, user, err)
+		}
+	}
+}
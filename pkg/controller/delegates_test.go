@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncDelegatesRoleBinding(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "cohort")
+	t.Setenv("DELEGATES_ENABLED", "true")
+
+	ctx := context.Background()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "alice",
+		Labels:      map[string]string{ownerLabel: "alice"},
+		Annotations: map[string]string{delegatesAnnotation: "carol, bob,alice,bob"},
+	}}
+	kubeClient := fake.NewClientset(namespace)
+	notifier := &fakeNotifier{}
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice")),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("alice", "alice")),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		notifier:      notifier,
+	}
+	getDelegates := func() []string {
+		roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, delegatesRoleBindingName("alice"), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected the RoleBinding of the delegates of alice, but got error: %v", err)
+		}
+		var delegates []string
+		for _, subject := range roleBinding.Subjects {
+			delegates = append(delegates, subject.Name)
+		}
+		return delegates
+	}
+
+	// Delegates other than the owner are granted the owner's ClusterRole
+	if err := controller.syncDelegatesRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the delegates of alice to be granted access, but got error: %v", err)
+	}
+	if got := getDelegates(); len(got) != 2 || got[0] != "bob" || got[1] != "carol" {
+		t.Errorf("Expected delegates [bob carol], but got %v", got)
+	}
+
+	// Modified subjects are restored by the resync
+	roleBinding, _ := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, delegatesRoleBindingName("alice"), metav1.GetOptions{})
+	roleBinding.Subjects = roleBinding.Subjects[:1]
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Update(ctx, roleBinding, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update RoleBinding: %v", err)
+	}
+	if drift, err := controller.delegatesDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the removed delegate to be reported as drift, but got %q, %v", drift, err)
+	}
+	controller.resyncRoleBindings(ctx)
+	if got := getDelegates(); len(got) != 2 {
+		t.Errorf("Expected the delegates to be restored, but got %v", got)
+	}
+
+	// Delegates are notified along with the owner
+	controller.notifyDelegates(ctx, "alice", notify.Notification{User: "alice", Namespace: "alice", Subject: "Quota usage high"})
+	if len(notifier.notifications) != 2 || notifier.notifications[0].User != "bob" || notifier.notifications[1].User != "carol" {
+		t.Errorf("Expected bob and carol to be notified, but got %+v", notifier.notifications)
+	}
+
+	// Removing the delegates revokes their access
+	namespace.Annotations = nil
+	if _, err := kubeClient.CoreV1().Namespaces().Update(ctx, namespace, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update namespace: %v", err)
+	}
+	if err := controller.syncDelegatesRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the access of the delegates to be revoked, but got error: %v", err)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, delegatesRoleBindingName("alice"), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the RoleBinding of the delegates to be deleted, but got error: %v", err)
+	}
+}
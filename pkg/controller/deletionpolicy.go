@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// annotation set to when the project of a removed user was retained without their RoleBinding
+const retainedAnnotation = "rosa-namespace-provisioner/retained"
+
+// annotation set to when the project of a removed user was orphaned, the controller no longer
+// managing it
+const unmanagedAnnotation = "rosa-namespace-provisioner/unmanaged"
+
+// Reasons of the Events recorded when the project of a removed user is kept
+const (
+	projectRetainedReason = "ProjectRetained"
+	projectOrphanedReason = "ProjectOrphaned"
+)
+
+// Applies the project deletion policy to the project of a user removed from the target groups
+func (c *Controller) removeUserProject(ctx context.Context, user string, projectName string) error {
+	policy, err := GetProjectDeletionPolicy()
+	if err != nil {
+		klog.Errorf("Error reading project deletion policy for user %s: %v", user, err)
+		return err
+	}
+
+	switch policy {
+	case ProjectDeletionRetain:
+		return c.retainUserProject(ctx, user, projectName)
+	case ProjectDeletionOrphan:
+		return c.orphanUserProject(ctx, user, projectName)
+	default:
+		if GetDeletionGracePeriod() > 0 {
+			return c.scheduleProjectDeletion(ctx, user, projectName)
+		}
+		return c.deleteUserProject(ctx, user, projectName)
+	}
+}
+
+// Returns the project of the target user if it exists and isn't owned by another user, or nil
+func (c *Controller) removedUserProject(ctx context.Context, user string, projectName string) (*projectv1.Project, error) {
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			klog.Infof("Project %s does not exist for user %s", projectName, user)
+			return nil, nil
+		}
+		klog.Errorf("Error checking if project exists for user %s: %v", user, err)
+		return nil, err
+	}
+	if owner := objectOwner(project); owner != "" && owner != user {
+		klog.Warningf("Project %s is owned by user %s and will not be removed for user %s", projectName, owner, user)
+		return nil, nil
+	}
+	return project, nil
+}
+
+// Keeps the project of a removed user and its contents, removing their RoleBinding and marking the
+// project as retained until they are provisioned again
+func (c *Controller) retainUserProject(ctx context.Context, user string, projectName string) error {
+	project, err := c.removedUserProject(ctx, user, projectName)
+	if err != nil || project == nil {
+		return err
+	}
+	if !isManaged(project) {
+		klog.Warningf("Project %s is not managed by the controller and will not be retained for user %s", projectName, user)
+		return nil
+	}
+
+	// the RoleBinding goes first so a failure is retried while the project is still listed as managed
+	if err := c.deleteUserRoleBinding(ctx, user, projectName); err != nil {
+		return err
+	}
+	if err := c.deleteAdditionalRoleBindings(ctx, user, projectName); err != nil {
+		return err
+	}
+	if GetDelegatesEnabled() {
+		if err := c.deleteDelegatesRoleBinding(ctx, user, projectName); err != nil {
+			return err
+		}
+	}
+	if GetPeerClusterRole() != "" {
+		if err := c.deletePeersRoleBinding(ctx, user, projectName); err != nil {
+			return err
+		}
+	}
+	if GetAutomationServiceAccount() != "" {
+		if err := c.deleteAutomationRoleBinding(ctx, user, projectName); err != nil {
+			return err
+		}
+	}
+	if _, retained := project.Annotations[retainedAnnotation]; retained {
+		return nil
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, retainedAnnotation, time.Now().UTC().Format(time.RFC3339))
+	_, err = c.projectClient.ProjectV1().Projects().Patch(ctx, projectName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("Error marking project %s of user %s as retained: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Retained project %s of removed user %s without their RoleBinding", projectName, user)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, projectRetainedReason,
+		fmt.Sprintf("Retained project %s of user %s, removing their RoleBinding", projectName, user))
+	return nil
+}
+
+// Keeps the project of a removed user as is, releasing its protection finalizer and ownership
+// labels so the controller no longer manages it
+func (c *Controller) orphanUserProject(ctx context.Context, user string, projectName string) error {
+	project, err := c.removedUserProject(ctx, user, projectName)
+	if err != nil || project == nil {
+		return err
+	}
+	if !isManaged(project) {
+		klog.Warningf("Project %s is not managed by the controller and will not be orphaned for user %s", projectName, user)
+		return nil
+	}
+
+	// the finalizer goes first so a failure is retried while the project is still listed as managed
+	err = c.updateNamespace(ctx, projectName, func(namespace *corev1.Namespace) {
+		namespace.Finalizers = removeFinalizer(namespace.Finalizers, protectionFinalizer)
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error removing finalizer from namespace %s of user %s: %v", projectName, user, err)
+		return err
+	}
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:null,%q:null},"annotations":{%q:null,%q:null,%q:null,%q:%q}}}`,
+		ownerLabel, managedByLabel, ownerAnnotation, retainedAnnotation, scheduledDeletionAnnotation, unmanagedAnnotation, time.Now().UTC().Format(time.RFC3339))
+	_, err = c.projectClient.ProjectV1().Projects().Patch(ctx, projectName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("Error orphaning project %s of user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Orphaned project %s of removed user %s", projectName, user)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, projectOrphanedReason,
+		fmt.Sprintf("Orphaned project %s of user %s, which is no longer managed", projectName, user))
+	return nil
+}
+
+// Deletes the RoleBinding of the target user, keeping RoleBindings binding another user or not
+// created by the controller
+func (c *Controller) deleteUserRoleBinding(ctx context.Context, user string, projectName string) error {
+	name := roleBindingName(projectName)
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding exists for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	if !bindsUser(roleBinding, user) || !isManaged(roleBinding) {
+		klog.Warningf("RoleBinding %s under project %s is not the managed RoleBinding of user %s and will not be deleted", name, projectName, user)
+		return nil
+	}
+	if err := c.rbacClient.RoleBindings(projectName).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Deleted RoleBinding %s of removed user %s under project %s", name, user, projectName)
+	return nil
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_removeUserProject(t *testing.T) {
+	tests := []struct {
+		name               string
+		policy             string
+		expectDeleted      bool
+		expectRoleBinding  bool
+		expectManaged      bool
+		expectFinalizer    bool
+		expectedAnnotation string
+	}{
+		{
+			name:          "delete by default",
+			expectDeleted: true,
+		},
+		{
+			name:               "retain keeps the project without the RoleBinding",
+			policy:             ProjectDeletionRetain,
+			expectManaged:      true,
+			expectFinalizer:    true,
+			expectedAnnotation: retainedAnnotation,
+		},
+		{
+			name:               "orphan keeps the project unmanaged",
+			policy:             ProjectDeletionOrphan,
+			expectRoleBinding:  true,
+			expectedAnnotation: unmanagedAnnotation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROJECT_DELETION_POLICY", tt.policy)
+
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(desiredProject("alice", "alice"))
+			kubeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:       "alice",
+				Labels:     map[string]string{ownerLabel: "alice"},
+				Finalizers: []string{protectionFinalizer},
+			}})
+			if _, err := kubeClient.RbacV1().RoleBindings("alice").Create(ctx, desiredRoleBinding("alice", "alice", "edit"), metav1.CreateOptions{}); err != nil {
+				t.Fatalf("Failed to create RoleBinding: %v", err)
+			}
+			controller := &Controller{
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+
+			// the policy is applied once, however many times the user is deprovisioned
+			for i := 0; i < 2; i++ {
+				if err := controller.removeUserProject(ctx, "alice", "alice"); err != nil {
+					t.Fatalf("Expected the project of alice to be removed, but got error: %v", err)
+				}
+			}
+
+			project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+			if tt.expectDeleted {
+				if !apierrors.IsNotFound(err) {
+					t.Errorf("Expected project alice to be deleted, but got error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected project alice to be kept, but got error: %v", err)
+			}
+			if _, ok := project.Annotations[tt.expectedAnnotation]; !ok {
+				t.Errorf("Expected project alice to be annotated with %s, but got %v", tt.expectedAnnotation, project.Annotations)
+			}
+			if isManaged(project) != tt.expectManaged {
+				t.Errorf("Expected project alice to be managed: %t, but got labels %v", tt.expectManaged, project.Labels)
+			}
+
+			_, err = kubeClient.RbacV1().RoleBindings("alice").Get(ctx, roleBindingName("alice"), metav1.GetOptions{})
+			if (err == nil) != tt.expectRoleBinding {
+				t.Errorf("Expected the RoleBinding of alice to be kept: %t, but got error: %v", tt.expectRoleBinding, err)
+			}
+			namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get namespace alice: %v", err)
+			}
+			if hasFinalizer(namespace, protectionFinalizer) != tt.expectFinalizer {
+				t.Errorf("Expected namespace alice to keep its finalizer: %t, but got %v", tt.expectFinalizer, namespace.Finalizers)
+			}
+
+			managed, err := controller.ManagedUsers(ctx)
+			if err != nil {
+				t.Fatalf("Expected managed users to be listed, but got error: %v", err)
+			}
+			if managed["alice"] {
+				t.Errorf("Expected alice to no longer be a managed user")
+			}
+		})
+	}
+}
+
+func TestController_createUserProjectRetained(t *testing.T) {
+	t.Setenv("PROJECT_DELETION_POLICY", ProjectDeletionRetain)
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset(desiredProject("alice", "alice"))
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	if err := controller.removeUserProject(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the project of alice to be retained, but got error: %v", err)
+	}
+
+	// a retained project is managed again once its owner is provisioned again
+	if err := controller.createUserProject(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the project of alice to be provisioned, but got error: %v", err)
+	}
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get project alice: %v", err)
+	}
+	if _, retained := project.Annotations[retainedAnnotation]; retained {
+		t.Errorf("Expected the retained mark of project alice to be cleared")
+	}
+	if managed, err := controller.ManagedUsers(ctx); err != nil || !managed["alice"] {
+		t.Errorf("Expected alice to be a managed user again, but got %v, %v", managed, err)
+	}
+}
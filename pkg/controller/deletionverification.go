@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// Reason of the Event recorded, and of the Deprovisioned condition set, when the namespace of a
+// deprovisioned user isn't deleted in time
+const deletionStuckReason = "DeletionStuck"
+
+// Reason of the Deprovisioned condition while the namespace of a deprovisioned user is being deleted
+const deletionPendingReason = "NamespaceTerminating"
+
+// pendingDeletion is the deletion of the namespace of a deprovisioned user, until it is verified
+type pendingDeletion struct {
+	user        string
+	projectName string
+	// since is when the project was first deleted
+	since time.Time
+	// escalated is whether the deletion was reported as stuck
+	escalated bool
+}
+
+// Tracks the deletion of the target user project until the namespace is verified to be gone,
+// keeping the time of the first deletion
+func (c *Controller) trackDeletion(user string, projectName string, since time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pendingDeletions == nil {
+		c.pendingDeletions = make(map[string]*pendingDeletion)
+	}
+	if _, ok := c.pendingDeletions[projectName]; ok {
+		return
+	}
+	c.pendingDeletions[projectName] = &pendingDeletion{user: user, projectName: projectName, since: since}
+}
+
+// Stops tracking the deletion of a project, once verified or when its owner is provisioned again
+func (c *Controller) untrackDeletion(projectName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pendingDeletions, projectName)
+}
+
+// Returns whether the deletion of a project is waiting to be verified
+func (c *Controller) deletionPending(projectName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.pendingDeletions[projectName]
+	return ok
+}
+
+// Deletes the ManagedNamespace of a deprovisioned user once their namespace is gone, marking it as
+// deprovisioned until then
+func (c *Controller) deprovisionManagedNamespace(ctx context.Context, user string, projectName string) error {
+	if c.deletionPending(projectName) {
+		return c.setDeprovisionedCondition(ctx, user, projectName, deletionPendingReason,
+			fmt.Sprintf("User %s was deprovisioned, waiting for namespace %s to be deleted", user, projectName))
+	}
+	if deleteAt, scheduled := c.scheduledDeletion(ctx, projectName); scheduled {
+		return c.setDeprovisionedCondition(ctx, user, projectName, deletionScheduledReason,
+			fmt.Sprintf("User %s was deprovisioned, namespace %s is deleted at %s", user, projectName, deleteAt.Format(time.RFC3339)))
+	}
+	return c.deleteManagedNamespace(ctx, user, projectName)
+}
+
+// Sets the Deprovisioned condition on the ManagedNamespace of the target user project, if present
+func (c *Controller) setDeprovisionedCondition(ctx context.Context, user string, projectName string, reason string, message string) error {
+	if c.dynamicClient == nil || !GetManagedNamespacesEnabled() {
+		return nil
+	}
+	current, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		klog.Errorf("Error getting ManagedNamespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.Object, managed); err != nil {
+		return err
+	}
+	changed := meta.SetStatusCondition(&managed.Status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionDeprovisioned,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: managed.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if !changed {
+		return nil
+	}
+	return c.updateManagedNamespaceStatus(ctx, user, managed)
+}
+
+// Picks up the deletions of managed namespaces still terminating, which were being verified before
+// the controller restarted
+func (c *Controller) recoverPendingDeletions(ctx context.Context) {
+	namespaces, err := c.coreClient.Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing managed namespaces to verify their deletion: %v", err)
+		return
+	}
+	for _, namespace := range namespaces.Items {
+		if namespace.DeletionTimestamp != nil {
+			c.trackDeletion(objectOwner(&namespace), namespace.Name, namespace.DeletionTimestamp.Time)
+		}
+	}
+}
+
+// Verifies the pending deletions, retrying those which didn't go through and reporting those taking
+// longer than the verification timeout
+func (c *Controller) verifyDeletions(ctx context.Context) {
+	c.mu.Lock()
+	pending := make([]*pendingDeletion, 0, len(c.pendingDeletions))
+	for _, deletion := range c.pendingDeletions {
+		pending = append(pending, deletion)
+	}
+	c.mu.Unlock()
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].projectName < pending[j].projectName
+	})
+
+	now := time.Now()
+	stuck := 0
+	for _, deletion := range pending {
+		if c.verifyDeletion(ctx, deletion, now) {
+			stuck++
+		}
+	}
+	metrics.StuckDeletions.Set(float64(stuck))
+
+	// offboardings whose namespace is now gone, or whose report failed to be stored, are reported
+	c.completeDeprovisionReports(ctx)
+}
+
+// Verifies the deletion of the namespace of a deprovisioned user, returning whether it is stuck
+func (c *Controller) verifyDeletion(ctx context.Context, deletion *pendingDeletion, now time.Time) bool {
+	user, projectName := deletion.user, deletion.projectName
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := c.deleteManagedNamespace(ctx, user, projectName); err != nil {
+			return false
+		}
+		c.untrackDeletion(projectName)
+		klog.Infof("Verified deletion of namespace %s of user %s", projectName, user)
+		return false
+	} else if err != nil {
+		klog.Errorf("Error verifying deletion of namespace %s of user %s: %v", projectName, user, err)
+		return false
+	}
+
+	// namespaces held back by the protection finalizer are deleted on purpose later
+	if namespace.DeletionTimestamp != nil && hasFinalizer(namespace, protectionFinalizer) && deletionBlockedReason(namespace, now) != "" {
+		return false
+	}
+	if namespace.DeletionTimestamp == nil {
+		klog.Warningf("Namespace %s of user %s is not terminating, retrying the deletion of its project", projectName, user)
+		metrics.DeletionRetries.Inc()
+		_ = c.deleteUserProject(ctx, user, projectName)
+	}
+
+	waited := now.Sub(deletion.since)
+	if waited < GetDeletionVerifyTimeout() {
+		return false
+	}
+	if !deletion.escalated {
+		deletion.escalated = true
+		finalizers := append([]string{}, namespace.Finalizers...)
+		for _, finalizer := range namespace.Spec.Finalizers {
+			finalizers = append(finalizers, string(finalizer))
+		}
+		message := fmt.Sprintf("Namespace %s of deprovisioned user %s is still present after %s, with finalizers %v",
+			projectName, user, waited.Round(time.Second), finalizers)
+		klog.Error(message)
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeWarning, deletionStuckReason, message)
+		_ = c.setDeprovisionedCondition(ctx, user, projectName, deletionStuckReason, message)
+	}
+	return true
+}
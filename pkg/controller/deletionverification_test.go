@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestController_verifyDeletions(t *testing.T) {
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	ctx := context.Background()
+	// The fake project client doesn't delete namespaces, so the namespace outlives its project
+	kubeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}})
+	recorder := record.NewFakeRecorder(10)
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("alice", "alice")),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+		recorder:      recorder,
+	}
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", nil, nil); err != nil {
+		t.Fatalf("Failed to create ManagedNamespace: %v", err)
+	}
+	deprovisioned := func() *metav1.Condition {
+		return meta.FindStatusCondition(getManagedNamespace(t, controller, "alice").Status.Conditions, v1alpha1.ConditionDeprovisioned)
+	}
+
+	// The record is kept until the namespace is gone
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	if condition := deprovisioned(); condition == nil || condition.Reason != deletionPendingReason {
+		t.Errorf("Expected alice to be marked as deprovisioned while terminating, but got %+v", condition)
+	}
+
+	// A namespace still present past the timeout is reported once
+	t.Setenv("DELETION_VERIFY_TIMEOUT", "1ns")
+	for i := 0; i < 2; i++ {
+		controller.verifyDeletions(ctx)
+	}
+	if condition := deprovisioned(); condition == nil || condition.Reason != deletionStuckReason {
+		t.Errorf("Expected the deletion of alice to be reported as stuck, but got %+v", condition)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("Expected a single Event, but got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning DeletionStuck") {
+		t.Errorf("Expected a DeletionStuck Event, but got %q", event)
+	}
+
+	// The record is deleted once the deletion is verified
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "alice", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete namespace alice: %v", err)
+	}
+	controller.verifyDeletions(ctx)
+	if _, err := controller.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, "alice", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected ManagedNamespace alice to be deleted, but got error: %v", err)
+	}
+	if controller.deletionPending("alice") {
+		t.Errorf("Expected the deletion of alice to no longer be pending")
+	}
+}
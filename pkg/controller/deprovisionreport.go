@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/evidence"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// offboarding is the deprovisioning of a user until their namespace and external artifacts are gone
+type offboarding struct {
+	report evidence.Report
+	// integrations whose cleanup of the user's artifacts hasn't finished yet
+	pendingCleanups map[string]bool
+}
+
+// LoadDeprovisionReportKey reads the key signing deprovision reports from DEPROVISION_REPORT_KEY_FILE
+func LoadDeprovisionReportKey() ([]byte, error) {
+	return readKeyFile(GetDeprovisionReportKeyFile())
+}
+
+// Starts the report of deprovisioning the target user, listing what is about to be deleted before
+// the inventory record goes away. A report already in progress, e.g. of a retried deprovisioning, is
+// kept.
+func (c *Controller) beginDeprovisionReport(ctx context.Context, user string, projectName string) {
+	// dry runs don't delete anything to report
+	if c.reportStore == nil || GetDryRunEnabled() {
+		return
+	}
+	c.mu.Lock()
+	_, started := c.offboardings[projectName]
+	c.mu.Unlock()
+	if started {
+		return
+	}
+
+	policy, _ := GetProjectDeletionPolicy()
+	report := evidence.Report{
+		User:           user,
+		Namespace:      projectName,
+		Trigger:        reconcileTrigger(ctx),
+		DeletionPolicy: policy,
+		StartedAt:      time.Now().UTC(),
+	}
+	switch policy {
+	case ProjectDeletionDelete:
+		report.Deleted = append(report.Deleted, evidence.Resource{Kind: "Project", Name: projectName})
+		inventory, err := c.inventoryResources(ctx, projectName)
+		if err != nil {
+			klog.Errorf("Error listing the inventory of project %s for the deprovision report of user %s: %v", projectName, user, err)
+		}
+		report.Deleted = append(report.Deleted, inventory...)
+	case ProjectDeletionRetain:
+		report.Deleted = append(report.Deleted, evidence.Resource{Kind: "RoleBinding", Name: roleBindingName(projectName), Namespace: projectName})
+	}
+	if GetClusterResourceQuotaEnabled() {
+		report.Deleted = append(report.Deleted, evidence.Resource{Kind: "ClusterResourceQuota", Name: clusterResourceQuotaName(user)})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.offboardings == nil {
+		c.offboardings = make(map[string]*offboarding)
+	}
+	c.offboardings[projectName] = &offboarding{report: report, pendingCleanups: make(map[string]bool)}
+}
+
+// Returns the policies and seeded resources recorded on the ManagedNamespace of the project, none
+// without the inventory
+func (c *Controller) inventoryResources(ctx context.Context, projectName string) ([]evidence.Resource, error) {
+	if c.dynamicClient == nil || !GetManagedNamespacesEnabled() {
+		return nil, nil
+	}
+	current, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, projectName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.Object, managed); err != nil {
+		return nil, err
+	}
+
+	resources := []evidence.Resource{{Kind: v1alpha1.ManagedNamespaceKind, Name: projectName}}
+	for _, refs := range [][]v1alpha1.ResourceReference{managed.Status.Policies, managed.Status.SeededResources} {
+		for _, ref := range refs {
+			resources = append(resources, evidence.Resource{Kind: ref.Kind, Name: ref.Name, Namespace: ref.Namespace})
+		}
+	}
+	return resources, nil
+}
+
+// Drops the report in progress of a user provisioned again before their offboarding completed
+func (c *Controller) discardDeprovisionReport(projectName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.offboardings, projectName)
+}
+
+// Records the external cleanups queued for the offboarding of the project
+func (c *Controller) expectRevocations(projectName string, integrations []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if offboarding, ok := c.offboardings[projectName]; ok {
+		for _, integration := range integrations {
+			offboarding.pendingCleanups[integration] = true
+		}
+	}
+}
+
+// Records the outcome of an external cleanup in the offboarding of its project, nil when the
+// artifacts were deleted
+func (c *Controller) recordRevocation(item cleanupItem, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	offboarding, ok := c.offboardings[item.namespace]
+	if !ok || !offboarding.pendingCleanups[item.integration] {
+		return
+	}
+	delete(offboarding.pendingCleanups, item.integration)
+	revocation := evidence.Revocation{Integration: item.integration, Revoked: err == nil}
+	if err != nil {
+		revocation.Error = err.Error()
+	}
+	offboarding.report.Revoked = append(offboarding.report.Revoked, revocation)
+}
+
+// Completes the reports of every offboarding whose namespace and external artifacts are gone
+func (c *Controller) completeDeprovisionReports(ctx context.Context) {
+	if c.reportStore == nil {
+		return
+	}
+	c.mu.Lock()
+	projectNames := make([]string, 0, len(c.offboardings))
+	for projectName := range c.offboardings {
+		projectNames = append(projectNames, projectName)
+	}
+	c.mu.Unlock()
+	for _, projectName := range projectNames {
+		c.completeDeprovisionReport(ctx, projectName)
+	}
+}
+
+// Signs and stores the report of the offboarding of the project once its namespace was deleted, if
+// it is deleted at all, and every external cleanup finished, then notifies the owner with a link to it
+func (c *Controller) completeDeprovisionReport(ctx context.Context, projectName string) {
+	c.mu.Lock()
+	offboarding, ok := c.offboardings[projectName]
+	if !ok || len(offboarding.pendingCleanups) > 0 {
+		c.mu.Unlock()
+		return
+	}
+	report := offboarding.report
+	report.Revoked = append([]evidence.Revocation(nil), offboarding.report.Revoked...)
+	c.mu.Unlock()
+
+	if report.DeletionPolicy == ProjectDeletionDelete {
+		// the namespace is still going away
+		if c.deletionPending(projectName) {
+			return
+		}
+		if _, scheduled := c.scheduledDeletion(ctx, projectName); scheduled {
+			return
+		}
+		_, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error checking if namespace %s was deleted for the deprovision report of user %s: %v", projectName, report.User, err)
+			return
+		}
+		report.NamespaceDeleted = apierrors.IsNotFound(err)
+	}
+	report.CompletedAt = time.Now().UTC()
+
+	// take the offboarding over, so a concurrent completion doesn't store the report twice
+	c.mu.Lock()
+	if c.offboardings[projectName] != offboarding {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.offboardings, projectName)
+	c.mu.Unlock()
+
+	signed, err := evidence.Sign(report, c.reportKey)
+	if err == nil {
+		var name string
+		if name, err = c.reportStore.Store(ctx, signed); err == nil {
+			klog.Infof("Stored the deprovision report of user %s under project %s as %s", report.User, projectName, name)
+			c.notifyDeprovisionReport(ctx, report, name)
+			return
+		}
+	}
+
+	// retried on the next deletion verification, unless the user was provisioned again meanwhile
+	klog.Errorf("Error storing the deprovision report of user %s: %v", report.User, err)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.offboardings[projectName]; !ok {
+		c.offboardings[projectName] = offboarding
+	}
+}
+
+// Sends the final notification of an offboarding, linking its report
+func (c *Controller) notifyDeprovisionReport(ctx context.Context, report evidence.Report, name string) {
+	if c.notifier == nil {
+		return
+	}
+	link := name
+	if reportsURL := GetDeprovisionReportsURL(); reportsURL != "" {
+		link = reportsURL + "/" + name
+	}
+	notification := notify.Notification{
+		User:      report.User,
+		Namespace: report.Namespace,
+		Severity:  notify.SeverityInfo,
+		Subject:   fmt.Sprintf("Offboarding of namespace %s completed", report.Namespace),
+		Message:   fmt.Sprintf("User %s was fully deprovisioned from namespace %s. Deprovision report: %s", report.User, report.Namespace, link),
+	}
+	for _, revocation := range report.Revoked {
+		if !revocation.Revoked {
+			notification.Severity = notify.SeverityWarning
+			notification.Message += fmt.Sprintf(". The cleanup of integration %s failed: %s", revocation.Integration, revocation.Error)
+		}
+	}
+	if err := c.notifier.Notify(ctx, notification); err != nil {
+		klog.Errorf("Error notifying user %s about their deprovision report: %v", report.User, err)
+	}
+}
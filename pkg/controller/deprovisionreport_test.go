@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/evidence"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeReportStore keeps the reports it receives in memory
+type fakeReportStore struct {
+	reports []evidence.SignedReport
+}
+
+func (f *fakeReportStore) Store(ctx context.Context, report evidence.SignedReport) (string, error) {
+	f.reports = append(f.reports, report)
+	return report.Report.Namespace + ".json", nil
+}
+
+func TestController_deprovisionReport(t *testing.T) {
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+	t.Setenv("DEPROVISION_REPORTS_URL", "https://evidence.example.com/reports/")
+
+	ctx := context.Background()
+	// The fake project client doesn't delete namespaces, so the namespace outlives its project
+	kubeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}})
+	store := &fakeReportStore{}
+	notifier := &fakeNotifier{}
+	key := []byte("offboarding")
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("alice", "alice")),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+		notifier:      notifier,
+	}
+	WithDeprovisionReports(store, key)(controller)
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", nil, nil); err != nil {
+		t.Fatalf("Failed to create ManagedNamespace: %v", err)
+	}
+
+	// No report is stored while the namespace is terminating
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	controller.verifyDeletions(ctx)
+	if len(store.reports) != 0 {
+		t.Fatalf("Expected no report before the namespace is gone, but got %+v", store.reports)
+	}
+
+	// The report is signed and stored once the deletion is verified
+	if err := kubeClient.CoreV1().Namespaces().Delete(ctx, "alice", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete namespace alice: %v", err)
+	}
+	controller.verifyDeletions(ctx)
+	controller.verifyDeletions(ctx)
+	if len(store.reports) != 1 {
+		t.Fatalf("Expected a single report, but got %d", len(store.reports))
+	}
+	signed := store.reports[0]
+	if err := evidence.Verify(signed, key); err != nil {
+		t.Errorf("Expected the report to be signed with the key, but got error: %v", err)
+	}
+	report := signed.Report
+	if report.User != "alice" || !report.NamespaceDeleted || report.DeletionPolicy != ProjectDeletionDelete {
+		t.Errorf("Expected a report of the deleted namespace of alice, but got %+v", report)
+	}
+	kinds := make([]string, 0, len(report.Deleted))
+	for _, resource := range report.Deleted {
+		kinds = append(kinds, resource.Kind)
+	}
+	if got := strings.Join(kinds, ","); got != "Project,ManagedNamespace" {
+		t.Errorf("Expected the Project and ManagedNamespace to be reported deleted, but got %s", got)
+	}
+
+	// The final notification links the report
+	last := notifier.notifications[len(notifier.notifications)-1]
+	if !strings.Contains(last.Message, "https://evidence.example.com/reports/alice.json") {
+		t.Errorf("Expected the final notification to link the report, but got %q", last.Message)
+	}
+}
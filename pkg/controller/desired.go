@@ -0,0 +1,298 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"sort"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	quotav1 "github.com/openshift/api/quota/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// UserPlan lists the users whose namespaces would be provisioned, deprovisioned or kept
+// when reconciling the target group against the managed namespaces
+type UserPlan struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+	Keep   []string `json:"keep"`
+}
+
+// GroupMembers returns the users of every target group, resolving nested groups when enabled
+func (c *Controller) GroupMembers(ctx context.Context) (map[string]bool, error) {
+	members := make(map[string]bool)
+	for _, name := range GetTargetGroupNames() {
+		group, err := c.userClient.UserV1().Groups().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		users, err := c.groupMembers(group)
+		if err != nil {
+			return nil, err
+		}
+		for user := range users {
+			members[user] = true
+		}
+	}
+	return members, nil
+}
+
+// Returns the users of a target group, resolving nested groups when enabled
+func (c *Controller) groupMembers(group *userv1.Group) (map[string]bool, error) {
+	if GetNestedGroupsEnabled() {
+		return c.resolveGroupUsers(group)
+	}
+	return groupUserSet(group), nil
+}
+
+// Returns the first target group the user is a member of, or the first target group if they are a
+// member of none, e.g. when provisioned directly by bulk-onboard. Returns nil if that group doesn't exist.
+func (c *Controller) sourceGroup(ctx context.Context, user string) (*userv1.Group, error) {
+	groups, err := c.sourceGroups(ctx, user)
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return groups[0], nil
+}
+
+// Returns every target group the user is a member of, or the first target group if they are a member
+// of none. Target groups that don't exist are skipped.
+func (c *Controller) sourceGroups(ctx context.Context, user string) ([]*userv1.Group, error) {
+	var groups []*userv1.Group
+	var fallback *userv1.Group
+	for i, name := range GetTargetGroupNames() {
+		group, err := c.userClient.UserV1().Groups().Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			fallback = group
+		}
+		users, err := c.groupMembers(group)
+		if err != nil {
+			return nil, err
+		}
+		if users[user] {
+			groups = append(groups, group)
+		}
+	}
+	if len(groups) == 0 && fallback != nil {
+		groups = append(groups, fallback)
+	}
+	return groups, nil
+}
+
+// ManagedUsers returns the owners of the projects managed by the controller, except projects retained
+// or scheduled for deletion after their owner was removed
+func (c *Controller) ManagedUsers(ctx context.Context) (map[string]bool, error) {
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]bool)
+	for _, project := range projects.Items {
+		if removedUserMarked(&project) {
+			continue
+		}
+		users[objectOwner(&project)] = true
+	}
+	return users, nil
+}
+
+// PlanUsers diffs the target group, or the merged membership sources, against the managed namespaces
+// the same way membership changes are reconciled, without changing anything
+func (c *Controller) PlanUsers(ctx context.Context) (UserPlan, error) {
+	members, err := c.Members(ctx)
+	if err != nil {
+		return UserPlan{}, err
+	}
+	managed, err := c.ManagedUsers(ctx)
+	if err != nil {
+		return UserPlan{}, err
+	}
+
+	plan := UserPlan{}
+	plan.Add, plan.Remove = diffUsers(managed, members)
+	// filtered out users are never provisioned, while the namespaces they already have are kept
+	plan.Add = provisionableUsers(plan.Add)
+	for user := range members {
+		if managed[user] {
+			plan.Keep = append(plan.Keep, user)
+		}
+	}
+	sort.Strings(plan.Keep)
+	return plan, nil
+}
+
+// desiredObject is an object provisioned for a user along with its type
+type desiredObject struct {
+	obj runtime.Object
+	gvk schema.GroupVersionKind
+}
+
+// DesiredManifests returns the manifests of the cluster objects provisioned for the target user.
+// Secrets materialized from an external secret manager, objects copied from another namespace and the
+// protection finalizer are not included since they cannot be declared up front.
+func (c *Controller) DesiredManifests(user string) ([]map[string]interface{}, error) {
+	projectName := c.ProjectName(user)
+	clusterRole, err := c.userClusterRole(context.Background(), user)
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := c.accessWindowOpen(context.Background(), user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	project := desiredProject(user, projectName)
+	annotations, err := c.projectConsoleAnnotations(context.Background(), user)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(project.Annotations, annotations)
+	objects := []desiredObject{
+		{project, projectv1.GroupVersion.WithKind("Project")},
+	}
+	if open {
+		objects = append(objects, desiredObject{desiredRoleBinding(user, projectName, clusterRole), rbacv1.SchemeGroupVersion.WithKind("RoleBinding")})
+		bindings, err := GetAdditionalRoleBindings()
+		if err != nil {
+			return nil, err
+		}
+		for _, binding := range bindings {
+			objects = append(objects, desiredObject{desiredAdditionalRoleBinding(user, projectName, binding), rbacv1.SchemeGroupVersion.WithKind("RoleBinding")})
+		}
+	}
+	if group := GetOpsGroupName(); group != "" {
+		objects = append(objects, desiredObject{desiredOpsRoleBinding(user, projectName, group, GetOpsClusterRole()), rbacv1.SchemeGroupVersion.WithKind("RoleBinding")})
+	}
+	if GetPeerClusterRole() != "" {
+		roleBinding, err := c.desiredPeersRoleBinding(context.Background(), user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if roleBinding != nil {
+			objects = append(objects, desiredObject{roleBinding, rbacv1.SchemeGroupVersion.WithKind("RoleBinding")})
+		}
+	}
+	if GetAutomationServiceAccount() != "" {
+		_, pullSecret, err := GetAutomationPullSecret()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects,
+			desiredObject{desiredAutomationServiceAccount(user, projectName, pullSecret), corev1.SchemeGroupVersion.WithKind("ServiceAccount")},
+			desiredObject{desiredAutomationRoleBinding(user, projectName), rbacv1.SchemeGroupVersion.WithKind("RoleBinding")},
+		)
+	}
+	if GetDenyLoadBalancersEnabled() {
+		objects = append(objects, desiredObject{desiredLoadBalancerQuota(user, projectName), corev1.SchemeGroupVersion.WithKind("ResourceQuota")})
+	}
+	if GetResourceQuotaEnabled() {
+		quota, err := c.userComputeQuota(context.Background(), user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, desiredObject{quota, corev1.SchemeGroupVersion.WithKind("ResourceQuota")})
+	}
+	if GetLimitRangeEnabled() {
+		limitRange, err := desiredLimitRange(user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, desiredObject{limitRange, corev1.SchemeGroupVersion.WithKind("LimitRange")})
+	}
+	if GetNetworkPoliciesEnabled() {
+		policies, err := desiredNetworkPolicies(user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		for _, policy := range policies {
+			objects = append(objects, desiredObject{policy, networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy")})
+		}
+	}
+	if GetBandwidthLimitsEnabled() {
+		tier, err := c.userBandwidthTier(context.Background(), user)
+		if err != nil {
+			return nil, err
+		}
+		if tier != nil {
+			networkQoS := desiredNetworkQoS(user, projectName, tier)
+			objects = append(objects, desiredObject{networkQoS, networkQoS.GroupVersionKind()})
+		}
+	}
+	if GetObjectCountQuotaEnabled() {
+		quota, err := desiredObjectCountQuota(user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, desiredObject{quota, corev1.SchemeGroupVersion.WithKind("ResourceQuota")})
+	}
+	if GetClusterResourceQuotaEnabled() {
+		quota, err := desiredClusterResourceQuota(user)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, desiredObject{quota, quotav1.GroupVersion.WithKind("ClusterResourceQuota")})
+	}
+	if templateBundleEnabled() {
+		bundle, err := c.desiredBundleObjects(context.Background(), user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range bundle {
+			objects = append(objects, desiredObject{obj, obj.GroupVersionKind()})
+		}
+	}
+	if GetOnboardingEnabled() && onboardingDelivered(OnboardingDeliverySecret) {
+		secret, err := desiredOnboardingSecret(user, projectName, clusterRole)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, desiredObject{secret, corev1.SchemeGroupVersion.WithKind("Secret")})
+	}
+
+	manifests := make([]map[string]interface{}, 0, len(objects))
+	for _, object := range objects {
+		manifest, err := toManifest(object.obj, object.gvk)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// Converts an object into a manifest with its type set and server-populated fields removed
+func toManifest(obj runtime.Object, gvk schema.GroupVersionKind) (map[string]interface{}, error) {
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]interface{})
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	delete(manifest, "status")
+	if metadata, ok := manifest["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "creationTimestamp")
+	}
+	return manifest, nil
+}
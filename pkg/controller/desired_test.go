@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+)
+
+func TestController_PlanUsers(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("test-group", "carol", "alice")),
+		projectClient: projectfake.NewSimpleClientset(newOwnedProject("alice"), newOwnedProject("bob")),
+	}
+
+	plan, err := controller.PlanUsers(context.Background())
+	if err != nil {
+		t.Fatalf("Expected users to be planned, but got error: %v", err)
+	}
+
+	expected := UserPlan{Add: []string{"carol"}, Remove: []string{"bob"}, Keep: []string{"alice"}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Expected plan %+v, but got %+v", expected, plan)
+	}
+}
+
+func TestController_DesiredManifests(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_HARD", "pods=20")
+
+	controller := &Controller{}
+	manifests, err := controller.DesiredManifests("alice")
+	if err != nil {
+		t.Fatalf("Expected manifests, but got error: %v", err)
+	}
+
+	var kinds []string
+	for _, manifest := range manifests {
+		kinds = append(kinds, manifest["kind"].(string))
+		if _, ok := manifest["status"]; ok {
+			t.Errorf("Expected status to be removed from %s manifest", manifest["kind"])
+		}
+	}
+	if expected := []string{"Project", "RoleBinding", "ClusterResourceQuota"}; !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("Expected manifests of kinds %v, but got %v", expected, kinds)
+	}
+	if got := manifests[0]["apiVersion"]; got != "project.openshift.io/v1" {
+		t.Errorf("Expected Project apiVersion project.openshift.io/v1, but got %v", got)
+	}
+}
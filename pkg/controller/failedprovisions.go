@@ -0,0 +1,415 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// ErrProvisionDeadLettered is returned when provisioning a user whose provisioning kept failing,
+// until it is retried by an admin
+var ErrProvisionDeadLettered = errors.New("provisioning dead-lettered")
+
+// ErrNoFailedProvision is returned when retrying the provisioning of a user which isn't dead-lettered
+var ErrNoFailedProvision = errors.New("no dead-lettered provisioning")
+
+// reason of the Event recorded when the provisioning of a user is dead-lettered
+const provisioningDeadLetteredReason = "ProvisioningDeadLettered"
+
+// ConfigMap in the namespace of the controller persisting the failed provisions across restarts and
+// sharing them with every replica
+const failedProvisionsConfigMapName = "rosa-namespace-provisioner-failed-provisions"
+
+// key of the ConfigMap holding the failed provisions as JSON, as user names aren't valid keys
+const failedProvisionsKey = "failed-provisions.json"
+
+// FailedProvision is the provisioning of a user which failed permanently
+type FailedProvision struct {
+	User      string    `json:"user"`
+	Namespace string    `json:"namespace"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError"`
+	Since     time.Time `json:"since"`
+	// DeadLettered is set once the attempts reach PROVISIONING_MAX_FAILURES, after which only admins
+	// trigger the provisioning of the user
+	DeadLettered bool `json:"deadLettered"`
+	// RetryRequested is set when an admin retried the provisioning from a replica which isn't the leader
+	// or the retry-provision command, until the leader retries it
+	RetryRequested bool `json:"retryRequested,omitempty"`
+}
+
+// Returns whether the failed provisions are persisted, which requires the namespace of the controller
+func (c *Controller) failedProvisionsPersisted() bool {
+	return c.coreClient != nil && GetPodNamespace() != ""
+}
+
+// Creates an informer watching the ConfigMap of the failed provisions for retries requested by admins
+func newFailedProvisionsInformer(coreClient corev1client.CoreV1Interface, watches *watchHealth) cache.SharedIndexInformer {
+	filterName := func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", failedProvisionsConfigMapName).String()
+		tuneListOptions(options)
+	}
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			filterName(&options)
+			return coreClient.ConfigMaps(GetPodNamespace()).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			filterName(&options)
+			return coreClient.ConfigMaps(GetPodNamespace()).Watch(ctx, options)
+		},
+	}
+
+	return watches.newInformer("failedprovisions", listWatcher, &corev1.ConfigMap{}, GetInformerResyncPeriod())
+}
+
+// Retries the dead-lettered provisions an admin requested to retry in the ConfigMap
+func (c *Controller) reconcileFailedProvisionsRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	defer observeReconcile("failedprovisions", TriggerAdmin, time.Now())
+	obj, exists, err := c.failedProvisionsInformer.GetStore().GetByKey(request.String())
+	if err != nil || !exists {
+		return reconcile.Result{}, err
+	}
+	stored, err := decodeFailedProvisions(obj.(*corev1.ConfigMap))
+	if err != nil {
+		klog.Errorf("Error decoding ConfigMap %s: %v", request, err)
+		return reconcile.Result{}, nil
+	}
+
+	ctx = withTrigger(ctx, TriggerAdmin)
+	c.ensureFailedProvisionsLoaded(ctx)
+	for user, failed := range stored {
+		if failed.RetryRequested {
+			if err := c.retryFailedProvision(ctx, user); err != nil {
+				return reconcile.Result{}, err
+			}
+			_ = c.admitUser(ctx, user)
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+// Decodes the failed provisions of the ConfigMap, by user
+func decodeFailedProvisions(configMap *corev1.ConfigMap) (map[string]*FailedProvision, error) {
+	stored := make(map[string]*FailedProvision)
+	if data := configMap.Data[failedProvisionsKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), &stored); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// Reads the persisted failed provisions, by user, along with their ConfigMap if it exists
+func (c *Controller) readFailedProvisions(ctx context.Context) (map[string]*FailedProvision, *corev1.ConfigMap, error) {
+	configMap, err := c.coreClient.ConfigMaps(GetPodNamespace()).Get(ctx, failedProvisionsConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return make(map[string]*FailedProvision), nil, nil
+	} else if err != nil {
+		klog.Errorf("Error getting ConfigMap %s/%s: %v", GetPodNamespace(), failedProvisionsConfigMapName, err)
+		return nil, nil, err
+	}
+	stored, err := decodeFailedProvisions(configMap)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode ConfigMap %s/%s: %w", GetPodNamespace(), failedProvisionsConfigMapName, err)
+	}
+	return stored, configMap, nil
+}
+
+// Applies the change to the persisted failed provisions, writing them back when the change reports
+// that they changed
+func (c *Controller) updateFailedProvisions(ctx context.Context, change func(stored map[string]*FailedProvision) bool) error {
+	client := c.coreClient.ConfigMaps(GetPodNamespace())
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, func() error {
+		stored, configMap, err := c.readFailedProvisions(ctx)
+		if err != nil {
+			return err
+		}
+		if !change(stored) {
+			return nil
+		}
+		data, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+
+		if configMap == nil {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   failedProvisionsConfigMapName,
+					Labels: map[string]string{managedByLabel: componentName},
+				},
+			}
+			configMap.Data = map[string]string{failedProvisionsKey: string(data)}
+			_, err = client.Create(ctx, configMap, metav1.CreateOptions{})
+		} else {
+			configMap.Data = map[string]string{failedProvisionsKey: string(data)}
+			_, err = client.Update(ctx, configMap, metav1.UpdateOptions{})
+		}
+		if err != nil && !apierrors.IsConflict(err) && !apierrors.IsAlreadyExists(err) {
+			klog.Errorf("Error updating ConfigMap %s/%s: %v", GetPodNamespace(), failedProvisionsConfigMapName, err)
+		}
+		return err
+	})
+}
+
+// Persists the failed provisioning of the target user, or forgets it when nil. A retry requested in
+// the meantime is kept for the leader to pick up.
+func (c *Controller) persistFailedProvision(ctx context.Context, user string, failed *FailedProvision) {
+	if !c.failedProvisionsPersisted() {
+		return
+	}
+	err := c.updateFailedProvisions(ctx, func(stored map[string]*FailedProvision) bool {
+		existing, ok := stored[user]
+		if failed == nil {
+			delete(stored, user)
+			return ok
+		}
+		persisted := *failed
+		persisted.RetryRequested = ok && existing.RetryRequested
+		stored[user] = &persisted
+		return true
+	})
+	if err != nil {
+		klog.Errorf("Error persisting the failed provisioning of user %s: %v", user, err)
+	}
+}
+
+// Loads the persisted failed provisions once, before the leader first provisions a user, so a
+// restart or failover doesn't forget the dead-lettered users
+func (c *Controller) ensureFailedProvisionsLoaded(ctx context.Context) {
+	if !c.failedProvisionsPersisted() {
+		return
+	}
+	c.mu.Lock()
+	loaded := c.failedProvisionsLoaded
+	c.mu.Unlock()
+	if loaded {
+		return
+	}
+
+	stored, _, err := c.readFailedProvisions(ctx)
+	if err != nil {
+		// retried on the next provisioning
+		klog.Errorf("Error loading the failed provisions: %v", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failedProvisionsLoaded {
+		return
+	}
+	if c.failedProvisions == nil {
+		c.failedProvisions = make(map[string]*FailedProvision)
+	}
+	for user, failed := range stored {
+		// failures recorded since are more recent
+		if _, ok := c.failedProvisions[user]; !ok {
+			c.failedProvisions[user] = failed
+		}
+	}
+	c.failedProvisionsLoaded = true
+	c.updateFailedProvisionMetric()
+	klog.Infof("Loaded %d failed provisions", len(stored))
+}
+
+// Returns whether the provisioning of the target user is dead-lettered and the reconcile was not
+// triggered by an admin, who may always retry it
+func (c *Controller) provisionSkipped(ctx context.Context, user string) bool {
+	c.ensureFailedProvisionsLoaded(ctx)
+	if reconcileTrigger(ctx) == TriggerAdmin {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	failed, ok := c.failedProvisions[user]
+	return ok && failed.DeadLettered
+}
+
+// Records a failed provisioning of the target user, returning the error to report for it. Only
+// permanent failures count towards the dead letter, transient ones are retried on every reconcile.
+func (c *Controller) recordProvisionFailure(ctx context.Context, user string, projectName string, provisionErr error) error {
+	if !isPermanentError(provisionErr) {
+		return provisionErr
+	}
+	c.ensureFailedProvisionsLoaded(ctx)
+
+	c.mu.Lock()
+	if c.failedProvisions == nil {
+		c.failedProvisions = make(map[string]*FailedProvision)
+	}
+	failed, ok := c.failedProvisions[user]
+	if !ok {
+		failed = &FailedProvision{User: user, Since: time.Now()}
+		c.failedProvisions[user] = failed
+	}
+	failed.Namespace = projectName
+	failed.Attempts++
+	failed.LastError = provisionErr.Error()
+	deadLettered := !failed.DeadLettered && int64(failed.Attempts) >= GetProvisioningMaxFailures()
+	failed.DeadLettered = failed.DeadLettered || deadLettered
+	recorded := *failed
+	c.updateFailedProvisionMetric()
+	c.mu.Unlock()
+
+	c.persistFailedProvision(ctx, user, &recorded)
+	if !recorded.DeadLettered {
+		return provisionErr
+	}
+	provisionErr = fmt.Errorf("%w after %d failed attempts: %w", ErrProvisionDeadLettered, recorded.Attempts, provisionErr)
+	if deadLettered {
+		klog.Errorf("Giving up on provisioning user %s until it is retried: %v", user, provisionErr)
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeWarning, provisioningDeadLetteredReason, provisionErr.Error())
+	}
+	return provisionErr
+}
+
+// Returns whether the error reports a dead-lettered provisioning
+func provisionDeadLettered(err error) bool {
+	return errors.Is(err, ErrProvisionDeadLettered)
+}
+
+// Returns the number of consecutive permanent failures provisioning the target user
+func (c *Controller) provisionFailures(user string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if failed, ok := c.failedProvisions[user]; ok {
+		return failed.Attempts
+	}
+	return 0
+}
+
+// Forgets the failures of the target user once they are provisioned or deprovisioned
+func (c *Controller) clearProvisionFailure(ctx context.Context, user string) {
+	c.ensureFailedProvisionsLoaded(ctx)
+	c.mu.Lock()
+	_, ok := c.failedProvisions[user]
+	if ok {
+		delete(c.failedProvisions, user)
+		c.updateFailedProvisionMetric()
+	}
+	c.mu.Unlock()
+
+	if ok {
+		c.persistFailedProvision(ctx, user, nil)
+	}
+}
+
+// Exports the number of dead-lettered provisions. Must be called with the lock held.
+func (c *Controller) updateFailedProvisionMetric() {
+	count := 0
+	for _, failed := range c.failedProvisions {
+		if failed.DeadLettered {
+			count++
+		}
+	}
+	metrics.ProvisioningDeadLetters.Set(float64(count))
+}
+
+// FailedProvisions returns the dead-lettered provisions, sorted by user. They are read from their
+// ConfigMap when persisted, so every replica reports those of the leader.
+func (c *Controller) FailedProvisions(ctx context.Context) ([]FailedProvision, error) {
+	failedProvisions := make([]FailedProvision, 0)
+	if c.failedProvisionsPersisted() {
+		stored, _, err := c.readFailedProvisions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, failed := range stored {
+			if failed.DeadLettered {
+				failedProvisions = append(failedProvisions, *failed)
+			}
+		}
+	} else {
+		c.mu.Lock()
+		for _, failed := range c.failedProvisions {
+			if failed.DeadLettered {
+				failedProvisions = append(failedProvisions, *failed)
+			}
+		}
+		c.mu.Unlock()
+	}
+	sort.Slice(failedProvisions, func(i, j int) bool {
+		return failedProvisions[i].User < failedProvisions[j].User
+	})
+	return failedProvisions, nil
+}
+
+// RetryProvision provisions a user whose provisioning was dead-lettered again with a fresh set of
+// attempts, asynchronously. When the failed provisions are persisted, the retry is requested in their
+// ConfigMap and run by the leader, so it may be requested from any replica or the retry-provision
+// command. It returns ErrNoFailedProvision if the provisioning of the user isn't dead-lettered.
+func (c *Controller) RetryProvision(ctx context.Context, user string) error {
+	if c.failedProvisionsPersisted() {
+		requested := false
+		err := c.updateFailedProvisions(ctx, func(stored map[string]*FailedProvision) bool {
+			failed, ok := stored[user]
+			requested = ok && failed.DeadLettered
+			if !requested || failed.RetryRequested {
+				return false
+			}
+			failed.RetryRequested = true
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		if !requested {
+			return fmt.Errorf("%w for user %s", ErrNoFailedProvision, user)
+		}
+		klog.Infof("Requested retry of dead-lettered provisioning of user %s", user)
+		return nil
+	}
+
+	c.mu.Lock()
+	failed, ok := c.failedProvisions[user]
+	isDeadLettered := ok && failed.DeadLettered
+	c.mu.Unlock()
+	if !isDeadLettered {
+		return fmt.Errorf("%w for user %s", ErrNoFailedProvision, user)
+	}
+	if err := c.retryFailedProvision(ctx, user); err != nil {
+		return err
+	}
+	go func() {
+		_ = c.admitUser(withTrigger(context.Background(), TriggerAdmin), user)
+	}()
+	return nil
+}
+
+// Forgets the dead-lettered provisioning of the target user, giving it a fresh set of attempts
+func (c *Controller) retryFailedProvision(ctx context.Context, user string) error {
+	c.mu.Lock()
+	delete(c.failedProvisions, user)
+	c.updateFailedProvisionMetric()
+	c.mu.Unlock()
+
+	klog.Infof("Retrying dead-lettered provisioning of user %s", user)
+	if !c.failedProvisionsPersisted() {
+		return nil
+	}
+	return c.updateFailedProvisions(ctx, func(stored map[string]*FailedProvision) bool {
+		_, ok := stored[user]
+		delete(stored, user)
+		return ok
+	})
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestController_failedProvisions(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+	t.Setenv("PROVISIONING_MAX_FAILURES", "2")
+	t.Setenv("STEP_RETRY_ATTEMPTS", "1")
+
+	calls := 0
+	var sourceErr error
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		secretSource: secretSourceFunc(func(ctx context.Context, id string) (map[string][]byte, error) {
+			calls++
+			if sourceErr != nil {
+				return nil, sourceErr
+			}
+			return map[string][]byte{"value": []byte("abc")}, nil
+		}),
+	}
+	ctx := withTrigger(context.Background(), TriggerResync)
+
+	// transient failures are retried on every reconcile without counting towards the dead letter
+	sourceErr = apierrors.NewServiceUnavailable("try again later")
+	for i := 0; i < 3; i++ {
+		if err := controller.provisionUser(ctx, "alice"); err == nil || provisionDeadLettered(err) {
+			t.Fatalf("Expected a transient failure, but got %v", err)
+		}
+	}
+
+	sourceErr = errors.New("secret sandbox/model-api not found")
+	if err := controller.provisionUser(ctx, "alice"); err == nil || provisionDeadLettered(err) {
+		t.Fatalf("Expected the first permanent failure not to be dead-lettered, but got %v", err)
+	}
+	if err := controller.provisionUser(ctx, "alice"); !provisionDeadLettered(err) {
+		t.Fatalf("Expected the provisioning of alice to be dead-lettered, but got %v", err)
+	}
+	if value := testutil.ToFloat64(metrics.ProvisioningDeadLetters); value != 1 {
+		t.Errorf("Expected 1 dead-lettered provisioning, but got %v", value)
+	}
+
+	// reconciles other than those of admins skip the dead-lettered user
+	calls = 0
+	if err := controller.provisionUser(ctx, "alice"); !provisionDeadLettered(err) {
+		t.Errorf("Expected the dead-lettered provisioning to be skipped, but got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected no step to run for a dead-lettered user, but got %d calls", calls)
+	}
+	failed, err := controller.FailedProvisions(ctx)
+	if err != nil || len(failed) != 1 || failed[0].User != "alice" || failed[0].Attempts != 2 {
+		t.Errorf("Expected the provisioning of alice to be dead-lettered after 2 attempts, but got %+v (%v)", failed, err)
+	}
+	if err := controller.RetryProvision(ctx, "bob"); !errors.Is(err, ErrNoFailedProvision) {
+		t.Errorf("Expected no dead-lettered provisioning of bob, but got %v", err)
+	}
+
+	// an admin reconcile retries the user, clearing the dead letter once it succeeds
+	sourceErr = nil
+	if err := controller.provisionUser(withTrigger(context.Background(), TriggerAdmin), "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	if failed, _ := controller.FailedProvisions(ctx); len(failed) != 0 {
+		t.Errorf("Expected no dead-lettered provisioning, but got %+v", failed)
+	}
+	if value := testutil.ToFloat64(metrics.ProvisioningDeadLetters); value != 0 {
+		t.Errorf("Expected no dead-lettered provisioning to be counted, but got %v", value)
+	}
+}
+
+func TestController_failedProvisionsPersisted(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+	t.Setenv("PROVISIONING_MAX_FAILURES", "1")
+	t.Setenv("STEP_RETRY_ATTEMPTS", "1")
+	t.Setenv("POD_NAMESPACE", "rosa-namespace-provisioner")
+
+	kubeClient := fake.NewClientset()
+	sourceErr := errors.New("secret sandbox/model-api not found")
+	newReplica := func() *Controller {
+		return &Controller{
+			projectClient: projectfake.NewSimpleClientset(),
+			rbacClient:    kubeClient.RbacV1(),
+			coreClient:    kubeClient.CoreV1(),
+			secretSource: secretSourceFunc(func(ctx context.Context, id string) (map[string][]byte, error) {
+				if sourceErr != nil {
+					return nil, sourceErr
+				}
+				return map[string][]byte{"value": []byte("abc")}, nil
+			}),
+		}
+	}
+	ctx := withTrigger(context.Background(), TriggerResync)
+
+	leader := newReplica()
+	if err := leader.provisionUser(ctx, "alice"); !provisionDeadLettered(err) {
+		t.Fatalf("Expected the provisioning of alice to be dead-lettered, but got %v", err)
+	}
+
+	// a new leader, e.g. after a restart, keeps skipping the dead-lettered user
+	leader = newReplica()
+	if err := leader.provisionUser(ctx, "alice"); !provisionDeadLettered(err) {
+		t.Errorf("Expected the new leader to skip the dead-lettered user, but got %v", err)
+	}
+
+	// other replicas list the dead letters of the leader and request retries through the ConfigMap
+	replica := newReplica()
+	failed, err := replica.FailedProvisions(ctx)
+	if err != nil || len(failed) != 1 || failed[0].User != "alice" {
+		t.Fatalf("Expected another replica to list the dead-lettered alice, but got %+v (%v)", failed, err)
+	}
+	if err := replica.RetryProvision(ctx, "bob"); !errors.Is(err, ErrNoFailedProvision) {
+		t.Errorf("Expected no dead-lettered provisioning of bob, but got %v", err)
+	}
+	if err := replica.RetryProvision(ctx, "alice"); err != nil {
+		t.Fatalf("Expected the retry of alice to be requested, but got error: %v", err)
+	}
+
+	// the leader picks up the requested retry
+	sourceErr = nil
+	configMap, err := kubeClient.CoreV1().ConfigMaps("rosa-namespace-provisioner").Get(ctx, failedProvisionsConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the failed provisions ConfigMap to exist, but got error: %v", err)
+	}
+	leader.failedProvisionsInformer = newFailedProvisionsInformer(kubeClient.CoreV1(), newWatchHealth())
+	if err := leader.failedProvisionsInformer.GetStore().Add(configMap); err != nil {
+		t.Fatalf("Failed to add ConfigMap: %v", err)
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: configMap.Namespace, Name: configMap.Name}}
+	if _, err := leader.reconcileFailedProvisionsRequest(ctx, request); err != nil {
+		t.Fatalf("Expected the requested retry to succeed, but got error: %v", err)
+	}
+	if _, err := leader.projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be provisioned by the retry, but got error: %v", err)
+	}
+	if failed, _ := replica.FailedProvisions(ctx); len(failed) != 0 {
+		t.Errorf("Expected no dead-lettered provisioning, but got %+v", failed)
+	}
+}
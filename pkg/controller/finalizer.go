@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// finalizer placed on managed namespaces so their deletion routes through the controller
+const protectionFinalizer = "rosa-namespace-provisioner/protection"
+
+// annotation which blocks the deletion of a managed namespace while set to "true"
+const protectedAnnotation = "rosa-namespace-provisioner/protected"
+
+// annotation requesting the controller to delete a managed namespace once its deletion is allowed, as
+// deleting it directly is denied by the delete-protection admission policy
+const deletionRequestedAnnotation = "rosa-namespace-provisioner/deletion-requested"
+
+// Creates an informer watching the namespaces owned by a user
+func newNamespaceInformer(coreClient corev1client.CoreV1Interface, watches *watchHealth) cache.SharedIndexInformer {
+	filterNamespaces := func(options *metav1.ListOptions) {
+		options.LabelSelector = ownerLabel
+		options.FieldSelector = fields.Everything().String()
+		tuneListOptions(options)
+	}
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			filterNamespaces(&options)
+			return coreClient.Namespaces().List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			filterNamespaces(&options)
+			return coreClient.Namespaces().Watch(ctx, options)
+		},
+	}
+
+	// The resync re-evaluates blocked deletions
+	return watches.newInformer("namespaces", listWatcher, &corev1.Namespace{}, GetInformerResyncPeriod())
+}
+
+// Adds the protection finalizer to the namespace of the target user project
+func (c *Controller) addNamespaceFinalizer(ctx context.Context, user string, projectName string) error {
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	if hasFinalizer(namespace, protectionFinalizer) {
+		return nil
+	}
+
+	namespace.Finalizers = append(namespace.Finalizers, protectionFinalizer)
+	if _, err := c.coreClient.Namespaces().Update(ctx, namespace, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error adding finalizer to namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Added finalizer %s to namespace %s for user %s", protectionFinalizer, projectName, user)
+	return nil
+}
+
+// Releases the protection finalizer of a terminating namespace once the deletion policy allows it
+func (c *Controller) handleNamespaceDeletion(namespace *corev1.Namespace) {
+	if namespace.DeletionTimestamp == nil || !hasFinalizer(namespace, protectionFinalizer) {
+		return
+	}
+
+	if reason := deletionBlockedReason(namespace, time.Now()); reason != "" {
+		klog.Warningf("Deletion of namespace %s is blocked: %s", namespace.Name, reason)
+		return
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping release of finalizer %s from namespace %s", protectionFinalizer, namespace.Name)
+		return
+	}
+
+	updated := namespace.DeepCopy()
+	updated.Finalizers = removeFinalizer(updated.Finalizers, protectionFinalizer)
+	if _, err := c.coreClient.Namespaces().Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error removing finalizer from namespace %s: %v", namespace.Name, err)
+		return
+	}
+	klog.Infof("Released finalizer %s from namespace %s", protectionFinalizer, namespace.Name)
+}
+
+// Deletes a managed namespace whose deletion was requested with the deletion-requested annotation once
+// the deletion policy allows it, re-evaluating blocked requests on every resync
+func (c *Controller) handleDeletionRequest(ctx context.Context, namespace *corev1.Namespace) {
+	if namespace.DeletionTimestamp != nil || namespace.Annotations[deletionRequestedAnnotation] != "true" {
+		return
+	}
+
+	if reason := deletionBlockedReason(namespace, time.Now()); reason != "" {
+		klog.Warningf("Requested deletion of namespace %s is blocked: %s", namespace.Name, reason)
+		return
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping requested deletion of namespace %s", namespace.Name)
+		return
+	}
+
+	user := objectOwner(namespace)
+	c.trackDeletion(user, namespace.Name, time.Now())
+	if err := c.projectClient.ProjectV1().Projects().Delete(ctx, namespace.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error deleting namespace %s of user %s as requested: %v", namespace.Name, user, err)
+		return
+	}
+	klog.Infof("Deleted namespace %s of user %s as requested", namespace.Name, user)
+	metrics.ProjectsDeleted.Inc()
+	c.recordProvisioningEvent(ctx, user, namespace.Name, corev1.EventTypeNormal, projectDeletedReason,
+		fmt.Sprintf("Deleted project %s of user %s as requested", namespace.Name, user))
+}
+
+// Returns why the deletion of a namespace is currently not allowed, or an empty string if it is
+func deletionBlockedReason(namespace *corev1.Namespace, now time.Time) string {
+	if namespace.Annotations[protectedAnnotation] == "true" {
+		return fmt.Sprintf("namespace is annotated with %s", protectedAnnotation)
+	}
+
+	window := GetDeletionMaintenanceWindow()
+	if window == "" {
+		return ""
+	}
+	inWindow, err := inMaintenanceWindow(window, now)
+	if err != nil {
+		return fmt.Sprintf("invalid maintenance window: %v", err)
+	}
+	if !inWindow {
+		return fmt.Sprintf("outside of maintenance window %s (UTC)", window)
+	}
+	return ""
+}
+
+// Returns whether the given time falls within a daily "HH:MM-HH:MM" window in UTC,
+// where windows ending before they start wrap around midnight
+func inMaintenanceWindow(window string, now time.Time) (bool, error) {
+	startValue, endValue, found := strings.Cut(window, "-")
+	if !found {
+		return false, fmt.Errorf("expected HH:MM-HH:MM, got %q", window)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(startValue))
+	if err != nil {
+		return false, fmt.Errorf("invalid window start: %w", err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(endValue))
+	if err != nil {
+		return false, fmt.Errorf("invalid window end: %w", err)
+	}
+
+	now = now.UTC()
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+	if startMinutes <= endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes, nil
+	}
+	return minutes >= startMinutes || minutes < endMinutes, nil
+}
+
+// Returns whether the object carries the given finalizer
+func hasFinalizer(obj metav1.Object, finalizer string) bool {
+	for _, existing := range obj.GetFinalizers() {
+		if existing == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the finalizers without the given finalizer
+func removeFinalizer(finalizers []string, finalizer string) []string {
+	var remaining []string
+	for _, existing := range finalizers {
+		if existing != finalizer {
+			remaining = append(remaining, existing)
+		}
+	}
+	return remaining
+}
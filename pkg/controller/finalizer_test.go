@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestInMaintenanceWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      string
+		now         string
+		want        bool
+		shouldError bool
+	}{
+		{
+			name:   "within daytime window",
+			window: "09:00-17:00",
+			now:    "12:30",
+			want:   true,
+		},
+		{
+			name:   "outside daytime window",
+			window: "09:00-17:00",
+			now:    "17:00",
+			want:   false,
+		},
+		{
+			name:   "within window wrapping midnight",
+			window: "22:00-04:00",
+			now:    "01:15",
+			want:   true,
+		},
+		{
+			name:   "outside window wrapping midnight",
+			window: "22:00-04:00",
+			now:    "12:00",
+			want:   false,
+		},
+		{
+			name:        "invalid window",
+			window:      "nightly",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var now time.Time
+			if tt.now != "" {
+				now, _ = time.Parse("15:04", tt.now)
+			}
+
+			got, err := inMaintenanceWindow(tt.window, now)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("inMaintenanceWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_addNamespaceFinalizer(t *testing.T) {
+	ctx := context.Background()
+	coreClient := fake.NewClientset(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "alice",
+		},
+	}).CoreV1()
+	controller := &Controller{
+		coreClient: coreClient,
+	}
+
+	// Adding the finalizer twice should only add it once
+	for i := 0; i < 2; i++ {
+		if err := controller.addNamespaceFinalizer(ctx, "alice", "alice"); err != nil {
+			t.Fatalf("Expected finalizer to be added, but got error: %v", err)
+		}
+	}
+
+	namespace, err := coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace alice to be found, but got error: %v", err)
+	}
+	if len(namespace.Finalizers) != 1 || namespace.Finalizers[0] != protectionFinalizer {
+		t.Errorf("Expected finalizers [%s], but got %v", protectionFinalizer, namespace.Finalizers)
+	}
+}
+
+func TestController_handleNamespaceDeletion(t *testing.T) {
+	tests := []struct {
+		name              string
+		annotations       map[string]string
+		deleting          bool
+		window            string
+		expectedFinalizer bool
+	}{
+		{
+			name:              "namespace not being deleted",
+			expectedFinalizer: true,
+		},
+		{
+			name:              "deletion allowed",
+			deleting:          true,
+			expectedFinalizer: false,
+		},
+		{
+			name:              "deletion blocked by protection annotation",
+			annotations:       map[string]string{protectedAnnotation: "true"},
+			deleting:          true,
+			expectedFinalizer: true,
+		},
+		{
+			name:              "deletion blocked by invalid maintenance window",
+			deleting:          true,
+			window:            "whenever",
+			expectedFinalizer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DELETION_MAINTENANCE_WINDOW", tt.window)
+
+			ctx := context.Background()
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "alice",
+					Annotations: tt.annotations,
+					Finalizers:  []string{protectionFinalizer},
+				},
+			}
+			if tt.deleting {
+				now := metav1.Now()
+				namespace.DeletionTimestamp = &now
+			}
+			coreClient := fake.NewClientset(namespace).CoreV1()
+			controller := &Controller{
+				coreClient: coreClient,
+			}
+
+			controller.handleNamespaceDeletion(namespace)
+
+			got, err := coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected namespace alice to be found, but got error: %v", err)
+			}
+			if hasFinalizer(got, protectionFinalizer) != tt.expectedFinalizer {
+				t.Errorf("Expected finalizer present = %v, but got finalizers %v", tt.expectedFinalizer, got.Finalizers)
+			}
+		})
+	}
+}
+
+func TestController_handleDeletionRequest(t *testing.T) {
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		expectedDeleted bool
+	}{
+		{
+			name: "deletion not requested",
+		},
+		{
+			name:            "requested deletion allowed",
+			annotations:     map[string]string{deletionRequestedAnnotation: "true"},
+			expectedDeleted: true,
+		},
+		{
+			name:        "requested deletion blocked by protection annotation",
+			annotations: map[string]string{deletionRequestedAnnotation: "true", protectedAnnotation: "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(&projectv1.Project{
+				ObjectMeta: metav1.ObjectMeta{Name: "alice"},
+			})
+			controller := &Controller{
+				projectClient: projectClient,
+			}
+
+			controller.handleDeletionRequest(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "alice",
+					Labels:      map[string]string{ownerLabel: "alice"},
+					Annotations: tt.annotations,
+				},
+			})
+
+			_, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+			if deleted := apierrors.IsNotFound(err); deleted != tt.expectedDeleted {
+				t.Errorf("Expected project alice deleted = %v, but got error: %v", tt.expectedDeleted, err)
+			}
+		})
+	}
+}
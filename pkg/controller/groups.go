@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	userv1 "github.com/openshift/api/user/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// prefix marking a member of a target group as a reference to another Group rather than a user, e.g.
+// group:team-a, so users named like a group are never expanded
+const nestedGroupPrefix = "group:"
+
+// Resolves the transitive set of users of a group, expanding members that reference another
+// Group with the group: prefix as well as any configured sub-groups
+func (c *Controller) resolveGroupUsers(group *userv1.Group) (map[string]bool, error) {
+	users := make(map[string]bool)
+	visited := map[string]bool{group.Name: true}
+
+	pending := append([]string{}, group.Users...)
+	for _, subGroup := range GetSubGroupNames() {
+		pending = append(pending, nestedGroupPrefix+subGroup)
+	}
+
+	for len(pending) > 0 {
+		member := pending[0]
+		pending = pending[1:]
+		name, isGroup := strings.CutPrefix(member, nestedGroupPrefix)
+		if !isGroup {
+			users[member] = true
+			continue
+		}
+		if visited[name] {
+			// Either a group already expanded or a cycle back to one
+			continue
+		}
+		visited[name] = true
+
+		subGroup, err := c.getGroup(name)
+		if err != nil {
+			return nil, fmt.Errorf("error getting nested group %s of group %s: %w", name, group.Name, err)
+		}
+		if subGroup == nil {
+			klog.Warningf("Nested group %s of group %s does not exist", name, group.Name)
+			continue
+		}
+
+		klog.V(4).Infof("Expanding nested group %s of group %s", subGroup.Name, group.Name)
+		pending = append(pending, subGroup.Users...)
+	}
+
+	return users, nil
+}
+
+// Returns the named group from the group informer once it has synced, which watches every group when
+// nested groups are enabled, or else from the API. Returns nil if the group doesn't exist.
+func (c *Controller) getGroup(name string) (*userv1.Group, error) {
+	if c.informer != nil && c.informer.HasSynced() {
+		obj, exists, err := c.informer.GetStore().GetByKey(name)
+		if err != nil || !exists {
+			return nil, err
+		}
+		return obj.(*userv1.Group), nil
+	}
+
+	group, err := c.userClient.UserV1().Groups().Get(context.Background(), name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	return group, err
+}
+
+// Returns the direct members of a group as a set
+func groupUserSet(group *userv1.Group) map[string]bool {
+	users := make(map[string]bool)
+	if group != nil {
+		for _, user := range group.Users {
+			users[user] = true
+		}
+	}
+	return users
+}
+
+// buffers holding sorted copies of group members, reused across group events so diffing groups with
+// tens of thousands of members doesn't allocate on every resync
+var userBuffers = sync.Pool{
+	New: func() any {
+		return new([]string)
+	},
+}
+
+// Returns the members of a group sorted without duplicates, along with a function releasing the
+// buffer they were sorted into. Members which are already sorted, as the group sync usually writes
+// them, are returned without copying.
+func sortedGroupUsers(group *userv1.Group) ([]string, func()) {
+	if group == nil {
+		return nil, func() {}
+	}
+	users := group.Users
+	if isStrictlySorted(users) {
+		return users, func() {}
+	}
+
+	// the members of informer objects are shared and must not be sorted in place
+	buffer := userBuffers.Get().(*[]string)
+	sorted := append((*buffer)[:0], users...)
+	sort.Strings(sorted)
+	sorted = slices.Compact(sorted)
+	return sorted, func() {
+		clear(sorted)
+		*buffer = sorted[:0]
+		userBuffers.Put(buffer)
+	}
+}
+
+// Returns whether the users are sorted without duplicates
+func isStrictlySorted(users []string) bool {
+	for i := 1; i < len(users); i++ {
+		if users[i-1] >= users[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the users added to and removed from sorted lists of users without duplicates, in a single
+// merge pass without building sets
+func diffSortedUsers(oldUsers []string, newUsers []string) ([]string, []string) {
+	var addedUsers, removedUsers []string
+	i, j := 0, 0
+	for i < len(oldUsers) && j < len(newUsers) {
+		switch {
+		case oldUsers[i] == newUsers[j]:
+			i++
+			j++
+		case oldUsers[i] < newUsers[j]:
+			removedUsers = append(removedUsers, oldUsers[i])
+			i++
+		default:
+			addedUsers = append(addedUsers, newUsers[j])
+			j++
+		}
+	}
+	removedUsers = append(removedUsers, oldUsers[i:]...)
+	addedUsers = append(addedUsers, newUsers[j:]...)
+	return addedUsers, removedUsers
+}
@@ -0,0 +1,310 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newGroup(name string, users ...string) *userv1.Group {
+	return &userv1.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Users: users,
+	}
+}
+
+func TestController_resolveGroupUsers(t *testing.T) {
+	tests := []struct {
+		name           string
+		group          *userv1.Group
+		existingGroups []runtime.Object
+		subGroups      string
+		want           []string
+	}{
+		{
+			name:  "group without nested groups",
+			group: newGroup("test-group", "alice", "bob"),
+			want:  []string{"alice", "bob"},
+		},
+		{
+			name:  "group with nested group",
+			group: newGroup("test-group", "alice", "group:team-a"),
+			existingGroups: []runtime.Object{
+				newGroup("team-a", "bob", "charlie"),
+			},
+			want: []string{"alice", "bob", "charlie"},
+		},
+		{
+			name:  "user named like a group is not expanded",
+			group: newGroup("test-group", "alice", "team-a"),
+			existingGroups: []runtime.Object{
+				newGroup("team-a", "bob"),
+			},
+			want: []string{"alice", "team-a"},
+		},
+		{
+			name:  "missing nested group",
+			group: newGroup("test-group", "alice", "group:team-a"),
+			want:  []string{"alice"},
+		},
+		{
+			name:  "transitively nested groups",
+			group: newGroup("test-group", "group:team-a"),
+			existingGroups: []runtime.Object{
+				newGroup("team-a", "bob", "group:team-b"),
+				newGroup("team-b", "charlie"),
+			},
+			want: []string{"bob", "charlie"},
+		},
+		{
+			name:  "cyclic nested groups",
+			group: newGroup("test-group", "group:team-a"),
+			existingGroups: []runtime.Object{
+				newGroup("team-a", "bob", "group:test-group"),
+			},
+			want: []string{"bob"},
+		},
+		{
+			name:  "configured sub-groups",
+			group: newGroup("test-group", "alice"),
+			existingGroups: []runtime.Object{
+				newGroup("team-a", "bob"),
+			},
+			subGroups: "team-a,missing-team",
+			want:      []string{"alice", "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SUB_GROUP_NAMES", tt.subGroups)
+
+			controller := &Controller{
+				userClient: userfake.NewSimpleClientset(tt.existingGroups...),
+			}
+
+			got, err := controller.resolveGroupUsers(tt.group)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("resolveGroupUsers() = %v, want %v", got, tt.want)
+			}
+			for _, user := range tt.want {
+				if !got[user] {
+					t.Errorf("Expected user %s to be resolved, but got %v", user, got)
+				}
+			}
+		})
+	}
+}
+
+func TestController_handleGroupNestedGroups(t *testing.T) {
+	t.Setenv("NESTED_GROUPS_ENABLED", "true")
+
+	ctx := context.Background()
+	teamA := newGroup("team-a", "bob")
+	userClient := userfake.NewSimpleClientset(teamA)
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userClient,
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	group := newGroup("test-group", "alice", "group:team-a")
+	controller.handleGroup(nil, group)
+
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := projectClient.ProjectV1().Projects().Get(ctx, user, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected project %s to be created, but got error: %v", user, err)
+		}
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, controller.ProjectName("group:team-a"), metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no project to be created for nested group team-a")
+	}
+
+	// A sub-group change is picked up on the next update of the target group, which the group
+	// reconciler triggers for changes to any group
+	teamA.Users = []string{}
+	if _, err := userClient.UserV1().Groups().Update(ctx, teamA, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update group team-a: %v", err)
+	}
+	controller.handleGroup(group, group)
+
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected project bob to be deleted after leaving nested group")
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to still exist, but got error: %v", err)
+	}
+}
+
+func TestTargetGroupRequests(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "test-group,staff")
+
+	// A target group reconciles itself, any other group every target group it may be nested in
+	if got := targetGroupRequests(context.Background(), newGroup("staff")); len(got) != 1 || got[0].Name != "staff" {
+		t.Errorf("Expected only staff to be reconciled, but got %v", got)
+	}
+	if got := targetGroupRequests(context.Background(), newGroup("team-a")); len(got) != 2 {
+		t.Errorf("Expected every target group to be reconciled, but got %v", got)
+	}
+}
+
+func TestController_handleGroupMultipleTargetGroups(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "test-group,staff")
+
+	ctx := context.Background()
+	staff := newGroup("staff", "bob")
+	userClient := userfake.NewSimpleClientset(staff)
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userClient,
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	oldGroup := newGroup("test-group", "alice", "bob", "carol")
+	controller.handleGroup(nil, oldGroup)
+
+	// Users removed from one target group keep their project while they are a member of another
+	updated := newGroup("test-group", "alice")
+	if _, err := userClient.UserV1().Groups().Create(ctx, updated, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create group test-group: %v", err)
+	}
+	controller.handleGroup(oldGroup, updated)
+
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project bob to be kept through group staff, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "carol", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected project carol to be deleted after leaving every target group")
+	}
+
+	members, err := controller.GroupMembers(ctx)
+	if err != nil {
+		t.Fatalf("Expected group members, but got error: %v", err)
+	}
+	if len(members) != 2 || !members["alice"] || !members["bob"] {
+		t.Errorf("Expected members of every target group [alice bob], but got %v", members)
+	}
+}
+
+func TestDiffSortedUsers(t *testing.T) {
+	tests := []struct {
+		name            string
+		oldUsers        []string
+		newUsers        []string
+		expectedAdded   []string
+		expectedRemoved []string
+	}{
+		{
+			name:          "new group",
+			newUsers:      []string{"alice", "bob"},
+			expectedAdded: []string{"alice", "bob"},
+		},
+		{
+			name:     "unchanged group",
+			oldUsers: []string{"alice", "bob"},
+			newUsers: []string{"alice", "bob"},
+		},
+		{
+			name:            "members replaced",
+			oldUsers:        []string{"alice", "bob", "dave"},
+			newUsers:        []string{"alice", "carol", "erin"},
+			expectedAdded:   []string{"carol", "erin"},
+			expectedRemoved: []string{"bob", "dave"},
+		},
+		{
+			name:            "every member removed",
+			oldUsers:        []string{"alice", "bob"},
+			expectedRemoved: []string{"alice", "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffSortedUsers(tt.oldUsers, tt.newUsers)
+			if !reflect.DeepEqual(added, tt.expectedAdded) || !reflect.DeepEqual(removed, tt.expectedRemoved) {
+				t.Errorf("Expected added %v and removed %v, but got %v and %v", tt.expectedAdded, tt.expectedRemoved, added, removed)
+			}
+		})
+	}
+}
+
+func TestSortedGroupUsers(t *testing.T) {
+	group := newGroup("test-group", "carol", "alice", "bob", "alice")
+	users, release := sortedGroupUsers(group)
+	if expected := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(users, expected) {
+		t.Errorf("Expected users %v, but got %v", expected, users)
+	}
+	release()
+
+	// The members of the group itself are left untouched
+	if expected := []string{"carol", "alice", "bob", "alice"}; !reflect.DeepEqual([]string(group.Users), expected) {
+		t.Errorf("Expected group members %v to be kept, but got %v", expected, group.Users)
+	}
+}
+
+// Returns a group with the given number of sorted members
+func newLargeGroup(name string, size int) *userv1.Group {
+	users := make([]string, size)
+	for i := range users {
+		users[i] = fmt.Sprintf("user-%06d", i)
+	}
+	return newGroup(name, users...)
+}
+
+func BenchmarkDiffUsers(b *testing.B) {
+	oldGroup, newGroup := newLargeGroup("test-group", 50000), newLargeGroup("test-group", 50000)
+	b.ReportAllocs()
+	for b.Loop() {
+		diffUsers(groupUserSet(oldGroup), groupUserSet(newGroup))
+	}
+}
+
+func BenchmarkDiffSortedUsers(b *testing.B) {
+	oldGroup, newGroup := newLargeGroup("test-group", 50000), newLargeGroup("test-group", 50000)
+	// Members the group sync didn't sort are sorted into reused buffers
+	newGroup.Users[0], newGroup.Users[1] = newGroup.Users[1], newGroup.Users[0]
+	b.ReportAllocs()
+	for b.Loop() {
+		oldUsers, releaseOld := sortedGroupUsers(oldGroup)
+		newUsers, releaseNew := sortedGroupUsers(newGroup)
+		diffSortedUsers(oldUsers, newUsers)
+		releaseOld()
+		releaseNew()
+	}
+}
+
+func BenchmarkController_handleGroupResync(b *testing.B) {
+	b.Setenv("TARGET_GROUP_NAME", "test-group")
+	group := newLargeGroup("test-group", 50000)
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		controller.handleGroup(group, group)
+	}
+}
@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	userv1 "github.com/openshift/api/user/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// finalizer placed on the target groups so their deletion tears down the namespaces of their members
+const teardownFinalizer = "rosa-namespace-provisioner/teardown"
+
+// Reason of the Event recorded on a target group once the namespaces of its members are torn down
+const groupTornDownReason = "TeardownCompleted"
+
+// Adds the teardown finalizer to a target group which isn't being deleted
+func (c *Controller) addGroupFinalizer(ctx context.Context, group *userv1.Group) error {
+	if group.DeletionTimestamp != nil || hasFinalizer(group, teardownFinalizer) {
+		return nil
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping adding finalizer %s to group %s", teardownFinalizer, group.Name)
+		return nil
+	}
+	err := c.updateGroup(ctx, group.Name, func(group *userv1.Group) {
+		if !hasFinalizer(group, teardownFinalizer) {
+			group.Finalizers = append(group.Finalizers, teardownFinalizer)
+		}
+	})
+	if err != nil {
+		klog.Errorf("Error adding finalizer to group %s: %v", group.Name, err)
+		return err
+	}
+	klog.Infof("Added finalizer %s to group %s", teardownFinalizer, group.Name)
+	return nil
+}
+
+// Deprovisions the members of a deleted target group, applying the project deletion policy and
+// cleaning up their external resources, then releases the teardown finalizer so the group goes away.
+// Members of another target group keep their namespace. The finalizer is kept, and the teardown
+// retried, while any member fails to be deprovisioned.
+func (c *Controller) teardownGroup(ctx context.Context, group *userv1.Group) error {
+	if !hasFinalizer(group, teardownFinalizer) {
+		return nil
+	}
+	members, err := c.groupMembers(group)
+	if err != nil {
+		klog.Errorf("Error resolving members of group %s to tear it down: %v", group.Name, err)
+		return err
+	}
+	kept, err := c.otherGroupMembers(ctx, group.Name)
+	if err != nil {
+		klog.Errorf("Error getting members of target groups %s to tear down group %s: %v", targetGroupList(), group.Name, err)
+		return err
+	}
+
+	users := make([]string, 0, len(members))
+	for user := range members {
+		if kept[user] {
+			klog.Infof("Keeping namespace of user %s of deleted group %s as they are a member of another target group", user, group.Name)
+			continue
+		}
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	klog.Infof("Group %s is being deleted, tearing down the namespaces of %d users", group.Name, len(users))
+	var failed []string
+	for _, user := range users {
+		if err := c.deprovisionUser(ctx, user); err != nil {
+			failed = append(failed, user)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("tearing down group %s failed for users %v", group.Name, failed)
+	}
+
+	// the members were only logged, so the group is kept for the teardown to run for real
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping release of finalizer %s from group %s", teardownFinalizer, group.Name)
+		return nil
+	}
+	if c.recorder != nil {
+		c.recorder.Event(group, corev1.EventTypeNormal, groupTornDownReason,
+			fmt.Sprintf("Tore down the namespaces of %d members of group %s", len(users), group.Name))
+	}
+	err = c.updateGroup(ctx, group.Name, func(group *userv1.Group) {
+		group.Finalizers = removeFinalizer(group.Finalizers, teardownFinalizer)
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error removing finalizer from group %s: %v", group.Name, err)
+		return err
+	}
+	klog.Infof("Tore down group %s, releasing finalizer %s", group.Name, teardownFinalizer)
+	return nil
+}
+
+// Returns the members of the target groups other than the named one
+func (c *Controller) otherGroupMembers(ctx context.Context, name string) (map[string]bool, error) {
+	members := make(map[string]bool)
+	for _, other := range GetTargetGroupNames() {
+		if other == name {
+			continue
+		}
+		group, err := c.userClient.UserV1().Groups().Get(ctx, other, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		// members of a group also being deleted are torn down with it
+		if group.DeletionTimestamp != nil {
+			continue
+		}
+		users, err := c.groupMembers(group)
+		if err != nil {
+			return nil, err
+		}
+		for user := range users {
+			members[user] = true
+		}
+	}
+	return members, nil
+}
+
+// Gets the group, applies the change and updates it, retrying on conflicts
+func (c *Controller) updateGroup(ctx context.Context, name string, change func(group *userv1.Group)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		group, err := c.userClient.UserV1().Groups().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		change(group)
+		_, err = c.userClient.UserV1().Groups().Update(ctx, group, metav1.UpdateOptions{})
+		return err
+	})
+}
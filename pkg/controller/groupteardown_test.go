@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_teardownGroup(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort,staff")
+	t.Setenv("GROUP_FINALIZER_ENABLED", "true")
+
+	ctx := context.Background()
+	userClient := userfake.NewSimpleClientset(newGroup("cohort", "alice", "bob"), newGroup("staff", "bob"))
+	projectClient := projectfake.NewSimpleClientset(desiredProject("alice", "alice"), desiredProject("bob", "bob"))
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userClient,
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	getGroup := func(name string) *userv1.Group {
+		group, err := userClient.UserV1().Groups().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get group %s: %v", name, err)
+		}
+		return group
+	}
+
+	// The finalizer is added once
+	for i := 0; i < 2; i++ {
+		if err := controller.addGroupFinalizer(ctx, getGroup("cohort")); err != nil {
+			t.Fatalf("Expected the finalizer to be added to group cohort, but got error: %v", err)
+		}
+	}
+	if finalizers := getGroup("cohort").Finalizers; len(finalizers) != 1 || finalizers[0] != teardownFinalizer {
+		t.Fatalf("Expected group cohort to carry the teardown finalizer, but got %v", finalizers)
+	}
+
+	// Uninstalling releases the finalizer
+	actions, err := controller.PlanUninstall(ctx, UninstallKeepNamespaces)
+	if err != nil {
+		t.Fatalf("Expected an uninstall plan, but got error: %v", err)
+	}
+	if len(actions) != 1 || actions[0].Kind != "Group" || actions[0].Name != "cohort" {
+		t.Errorf("Expected the uninstall plan to release the finalizer of group cohort, but got %+v", actions)
+	}
+
+	// Deleting the group tears down its members, except those of another target group
+	group := getGroup("cohort")
+	now := metav1.Now()
+	group.DeletionTimestamp = &now
+	if err := controller.teardownGroup(ctx, group); err != nil {
+		t.Fatalf("Expected group cohort to be torn down, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project alice to be deleted, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project bob to be kept as a member of staff, but got error: %v", err)
+	}
+	if finalizers := getGroup("cohort").Finalizers; len(finalizers) != 0 {
+		t.Errorf("Expected the teardown finalizer to be released, but got %v", finalizers)
+	}
+}
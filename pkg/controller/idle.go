@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// how often the controller checks whether it has been idle long enough to shut down
+const idleCheckInterval = time.Minute
+
+// Records that the controller did work for the given trigger. Resyncs and periodic repairs re-apply
+// the desired state without anything having changed, so they don't keep the controller up.
+func (c *Controller) markActive(trigger string) {
+	if trigger == TriggerResync || trigger == TriggerRepair {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActivity = time.Now()
+}
+
+// Returns why the controller must keep running regardless of activity, or an empty string
+func (c *Controller) pendingWork() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case len(c.pendingSince) > 0:
+		return "users are waiting to be provisioned"
+	case len(c.pendingDeletions) > 0:
+		return "namespace deletions are waiting to be verified"
+	case c.cleanupQueue != nil && c.cleanupQueue.Len() > 0:
+		return "external cleanups are queued"
+	}
+	return ""
+}
+
+// Shuts the controller down once it has been idle for IDLE_SHUTDOWN_AFTER without pending work
+func (c *Controller) checkIdle(ctx context.Context) {
+	c.mu.Lock()
+	idle := time.Since(c.lastActivity)
+	c.mu.Unlock()
+	if idle < GetIdleShutdownAfter() {
+		return
+	}
+	if reason := c.pendingWork(); reason != "" {
+		klog.V(2).Infof("Idle for %s but not shutting down as %s", idle.Round(time.Second), reason)
+		return
+	}
+	klog.Infof("Idle for %s, shutting down until woken up", idle.Round(time.Second))
+	c.idleShutdown(ctx)
+}
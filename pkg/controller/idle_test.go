@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestController_checkIdle(t *testing.T) {
+	t.Setenv("IDLE_SHUTDOWN_AFTER", "30m")
+
+	shutdowns := 0
+	controller := &Controller{
+		idleShutdown: func(ctx context.Context) { shutdowns++ },
+		pendingSince: make(map[string]time.Time),
+	}
+	ctx := context.Background()
+
+	// Recent work keeps the controller up, periodic repairs don't count as work
+	controller.markActive(TriggerUpdate)
+	controller.markActive(TriggerRepair)
+	controller.checkIdle(ctx)
+	if shutdowns != 0 {
+		t.Errorf("Expected the controller to stay up after recent work, but it shut down")
+	}
+
+	// Users waiting to be provisioned keep an idle controller up
+	controller.lastActivity = time.Now().Add(-time.Hour)
+	controller.pendingSince["alice"] = time.Now()
+	controller.checkIdle(ctx)
+	if shutdowns != 0 {
+		t.Errorf("Expected the controller to stay up with pending users, but it shut down")
+	}
+
+	// An idle controller without pending work shuts down
+	delete(controller.pendingSince, "alice")
+	controller.checkIdle(ctx)
+	if shutdowns != 1 {
+		t.Errorf("Expected the idle controller to shut down once, but got %d shutdowns", shutdowns)
+	}
+}
@@ -0,0 +1,324 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// Returns the policies and seeded resources applied by the completed provisioning steps
+func (c *Controller) appliedResources(ctx context.Context, user string, projectName string, completed []provisioningStep) ([]v1alpha1.ResourceReference, []v1alpha1.ResourceReference) {
+	var policies, seeded []v1alpha1.ResourceReference
+	for _, step := range completed {
+		switch step.name {
+		case "finalizer":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "Finalizer", Name: protectionFinalizer})
+		case "rolebinding":
+			if open, err := c.accessWindowOpen(ctx, user, time.Now()); err == nil && open {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: roleBindingName(projectName), Namespace: projectName})
+			}
+		case "additionalrolebindings":
+			if open, err := c.accessWindowOpen(ctx, user, time.Now()); err != nil || !open {
+				continue
+			}
+			bindings, _ := GetAdditionalRoleBindings()
+			for _, binding := range bindings {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: additionalRoleBindingName(projectName, binding.Suffix), Namespace: projectName})
+			}
+		case "delegates":
+			if delegates, err := c.projectDelegates(ctx, user, projectName); err == nil && len(delegates) > 0 {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: delegatesRoleBindingName(projectName), Namespace: projectName})
+			}
+		case "opsrolebinding":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: opsRoleBindingName(projectName), Namespace: projectName})
+		case "peers":
+			if groups, err := c.peerGroups(ctx, user); err == nil && len(groups) > 0 {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: peersRoleBindingName(projectName), Namespace: projectName})
+			}
+		case "automation":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "RoleBinding", Name: automationRoleBindingName(projectName), Namespace: projectName})
+			seeded = append(seeded, v1alpha1.ResourceReference{Kind: "ServiceAccount", Name: GetAutomationServiceAccount(), Namespace: projectName})
+			if _, pullSecret, _ := GetAutomationPullSecret(); pullSecret != "" {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: "Secret", Name: pullSecret, Namespace: projectName})
+			}
+		case "loadbalancerquota":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "ResourceQuota", Name: loadBalancerQuotaName, Namespace: projectName})
+		case "computequota":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "ResourceQuota", Name: computeQuotaName, Namespace: projectName})
+		case "limitrange":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "LimitRange", Name: limitRangeName, Namespace: projectName})
+		case "networkpolicies":
+			policySet, err := desiredNetworkPolicies(user, projectName)
+			if err != nil {
+				continue
+			}
+			for _, policy := range policySet {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "NetworkPolicy", Name: policy.Name, Namespace: projectName})
+			}
+		case "bandwidth":
+			if tier, err := c.userBandwidthTier(ctx, user); err == nil && tier != nil {
+				policies = append(policies, v1alpha1.ResourceReference{Kind: "NetworkQoS", Name: networkQoSName, Namespace: projectName})
+			}
+		case "objectcountquota":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "ResourceQuota", Name: objectCountQuotaName, Namespace: projectName})
+		case "clusterresourcequota":
+			policies = append(policies, v1alpha1.ResourceReference{Kind: "ClusterResourceQuota", Name: clusterResourceQuotaName(user)})
+		case "secrets":
+			mappings, err := c.userSecretMappings(ctx, user)
+			if err != nil {
+				continue
+			}
+			for _, mapping := range mappings {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: "Secret", Name: mapping.SecretName, Namespace: projectName})
+			}
+		case "seedresources":
+			for _, name := range GetSeedSecrets() {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: "Secret", Name: name, Namespace: projectName})
+			}
+			for _, name := range GetSeedConfigMaps() {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: "ConfigMap", Name: name, Namespace: projectName})
+			}
+		case "templatebundle":
+			objects, err := c.desiredBundleObjects(ctx, user, projectName)
+			if err != nil {
+				continue
+			}
+			for _, obj := range objects {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: obj.GetKind(), Name: obj.GetName(), Namespace: projectName})
+			}
+		case "onboarding":
+			if onboardingDelivered(OnboardingDeliverySecret) {
+				seeded = append(seeded, v1alpha1.ResourceReference{Kind: "Secret", Name: onboardingSecretName, Namespace: projectName})
+			}
+		}
+	}
+	return policies, seeded
+}
+
+// Creates the ManagedNamespace of the target user project if missing and restores its spec,
+// returning the current record
+func (c *Controller) ensureManagedNamespace(ctx context.Context, user string, projectName string) (*v1alpha1.ManagedNamespace, error) {
+	client := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource)
+
+	sourceGroup := GetTargetGroupNames()[0]
+	if len(GetTargetGroupNames()) > 1 {
+		group, err := c.sourceGroup(ctx, user)
+		if err != nil {
+			klog.Errorf("Error getting the target group of user %s for ManagedNamespace %s: %v", user, projectName, err)
+			return nil, err
+		}
+		if group != nil {
+			sourceGroup = group.Name
+		}
+	}
+
+	desired := &v1alpha1.ManagedNamespace{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       v1alpha1.ManagedNamespaceKind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: projectName,
+			Labels: map[string]string{
+				ownerLabel: ownerLabelValue(user),
+			},
+		},
+		Spec: v1alpha1.ManagedNamespaceSpec{
+			Owner:       user,
+			SourceGroup: sourceGroup,
+		},
+	}
+
+	current, err := client.Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("Error checking if ManagedNamespace exists for user %s: %v", user, err)
+			return nil, err
+		}
+		obj, err := toUnstructured(desired)
+		if err != nil {
+			return nil, err
+		}
+		current, err = client.Create(ctx, obj, metav1.CreateOptions{})
+		if err != nil {
+			klog.Errorf("Error creating ManagedNamespace for user %s: %v", user, err)
+			return nil, err
+		}
+		klog.Infof("Successfully created ManagedNamespace %s for user %s", projectName, user)
+	}
+
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.Object, managed); err != nil {
+		return nil, err
+	}
+	// policy exceptions are recorded by admins
+	desired.Spec.PolicyExceptions = managed.Spec.PolicyExceptions
+
+	if !reflect.DeepEqual(managed.Spec, desired.Spec) || managed.Labels[ownerLabel] != ownerLabelValue(user) {
+		managed.Spec = desired.Spec
+		if managed.Labels == nil {
+			managed.Labels = make(map[string]string)
+		}
+		managed.Labels[ownerLabel] = ownerLabelValue(user)
+		obj, err := toUnstructured(managed)
+		if err != nil {
+			return nil, err
+		}
+		current, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+		if err != nil {
+			klog.Errorf("Error updating ManagedNamespace for user %s: %v", user, err)
+			return nil, err
+		}
+		managed.ResourceVersion = current.GetResourceVersion()
+		managed.Generation = current.GetGeneration()
+	}
+	return managed, nil
+}
+
+// Updates the status of the ManagedNamespace of the target user
+func (c *Controller) updateManagedNamespaceStatus(ctx context.Context, user string, managed *v1alpha1.ManagedNamespace) error {
+	managed.Status.Phase = managedNamespacePhase(managed)
+	obj, err := toUnstructured(managed)
+	if err != nil {
+		return err
+	}
+	if _, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating ManagedNamespace status for user %s: %v", user, err)
+		return err
+	}
+	return nil
+}
+
+// Creates or updates the ManagedNamespace recording the outcome of provisioning the target user,
+// given the steps that completed and the error which stopped provisioning, if any
+func (c *Controller) updateManagedNamespace(ctx context.Context, user string, projectName string, completed []provisioningStep, provisionErr error) error {
+	// dry runs leave the inventory untouched, as the steps weren't run
+	if c.dynamicClient == nil || !GetManagedNamespacesEnabled() || GetDryRunEnabled() {
+		return nil
+	}
+	managed, err := c.ensureManagedNamespace(ctx, user, projectName)
+	if err != nil {
+		return err
+	}
+
+	managed.Status.Policies, managed.Status.SeededResources = c.appliedResources(ctx, user, projectName, completed)
+	managed.Status.MembershipSources = c.userMembershipSources(user)
+	managed.Status.LastReconcileTrigger = reconcileTrigger(ctx)
+	now := metav1.Now()
+	managed.Status.LastAttemptTime = &now
+	managed.Status.LastError = ""
+	managed.Status.FailedAttempts = c.provisionFailures(user)
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: managed.Generation,
+		Reason:             "Provisioned",
+		Message:            "All provisioning steps succeeded",
+	}
+	if provisionErr == nil {
+		managed.Status.LastProvisionedTime = &now
+	} else {
+		managed.Status.LastError = provisionErr.Error()
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ProvisioningFailed"
+		if provisionDeadLettered(provisionErr) {
+			condition.Reason = provisioningDeadLetteredReason
+		}
+		condition.Message = provisionErr.Error()
+	}
+	meta.SetStatusCondition(&managed.Status.Conditions, condition)
+	if c.health != nil {
+		meta.SetStatusCondition(&managed.Status.Conditions, c.integrationsCondition(managed.Generation))
+	}
+	if managed.Labels[approvedLabel] == "true" {
+		meta.SetStatusCondition(&managed.Status.Conditions, approvalCondition(managed.Generation, true))
+	}
+
+	return c.updateManagedNamespaceStatus(ctx, user, managed)
+}
+
+// Returns the phase summarizing the conditions of the ManagedNamespace
+func managedNamespacePhase(managed *v1alpha1.ManagedNamespace) string {
+	if meta.IsStatusConditionTrue(managed.Status.Conditions, v1alpha1.ConditionDeprovisioned) {
+		return v1alpha1.PhaseDeprovisioning
+	}
+	if pendingApproval(managed) {
+		return v1alpha1.PhasePendingApproval
+	}
+	ready := meta.FindStatusCondition(managed.Status.Conditions, v1alpha1.ConditionReady)
+	switch {
+	case ready == nil:
+		return ""
+	case ready.Status == metav1.ConditionTrue:
+		return v1alpha1.PhaseProvisioned
+	case ready.Reason == provisioningDeadLetteredReason:
+		return v1alpha1.PhaseDeadLettered
+	default:
+		return v1alpha1.PhaseFailed
+	}
+}
+
+// Returns the condition reporting whether every optional integration is enabled
+func (c *Controller) integrationsCondition(generation int64) metav1.Condition {
+	var disabled []string
+	for _, status := range c.health.Statuses() {
+		if status.Disabled {
+			disabled = append(disabled, fmt.Sprintf("%s: %s", status.Name, status.LastError))
+		}
+	}
+
+	if len(disabled) == 0 {
+		return metav1.Condition{
+			Type:               v1alpha1.ConditionIntegrationsHealthy,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             "IntegrationsEnabled",
+			Message:            "All integrations are enabled",
+		}
+	}
+	return metav1.Condition{
+		Type:               v1alpha1.ConditionIntegrationsHealthy,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "IntegrationDisabled",
+		Message:            fmt.Sprintf("Disabled after failing persistently: %s", strings.Join(disabled, "; ")),
+	}
+}
+
+// Deletes the ManagedNamespace of the target user project if present
+func (c *Controller) deleteManagedNamespace(ctx context.Context, user string, projectName string) error {
+	if c.dynamicClient == nil || !GetManagedNamespacesEnabled() {
+		return nil
+	}
+
+	err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Delete(ctx, projectName, metav1.DeleteOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.Infof("ManagedNamespace %s does not exist for user %s", projectName, user)
+			return nil
+		}
+		klog.Errorf("Error deleting ManagedNamespace for user %s: %v", user, err)
+		return err
+	}
+	klog.Infof("Successfully deleted ManagedNamespace %s for user %s", projectName, user)
+	return nil
+}
+
+// Converts a typed object into its unstructured form for the dynamic client
+func toUnstructured(obj interface{}) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: content}, nil
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// Returns a fake dynamic client serving ManagedNamespaces
+func newInventoryClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		v1alpha1.ManagedNamespacesResource:  "ManagedNamespaceList",
+		v1alpha1.ProvisionerConfigsResource: "ProvisionerConfigList",
+		v1alpha1.NamespaceClaimsResource:    "NamespaceClaimList",
+	}, objects...)
+}
+
+// Returns the ManagedNamespace with the given name
+func getManagedNamespace(t *testing.T, controller *Controller, name string) *v1alpha1.ManagedNamespace {
+	t.Helper()
+	obj, err := controller.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ManagedNamespace %s to be found, but got error: %v", name, err)
+	}
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, managed); err != nil {
+		t.Fatalf("Failed to convert ManagedNamespace: %v", err)
+	}
+	return managed
+}
+
+func TestController_updateManagedNamespace(t *testing.T) {
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+	t.Setenv("TARGET_GROUP_NAME", "workshop-users")
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+
+	ctx := context.Background()
+	controller := &Controller{
+		dynamicClient: newInventoryClient(),
+	}
+	completed := []provisioningStep{{name: "project"}, {name: "rolebinding"}, {name: "secrets"}}
+
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", completed, nil); err != nil {
+		t.Fatalf("Expected ManagedNamespace to be created, but got error: %v", err)
+	}
+
+	managed := getManagedNamespace(t, controller, "alice")
+	if managed.Spec.Owner != "alice" || managed.Spec.SourceGroup != "workshop-users" {
+		t.Errorf("Expected owner alice from group workshop-users, but got %+v", managed.Spec)
+	}
+	if len(managed.Status.Policies) != 1 || managed.Status.Policies[0].Name != "alice-edit" {
+		t.Errorf("Expected the edit RoleBinding policy, but got %+v", managed.Status.Policies)
+	}
+	if len(managed.Status.SeededResources) != 1 || managed.Status.SeededResources[0].Name != "model-api-key" {
+		t.Errorf("Expected the model-api-key Secret to be seeded, but got %+v", managed.Status.SeededResources)
+	}
+	if !meta.IsStatusConditionTrue(managed.Status.Conditions, v1alpha1.ConditionReady) {
+		t.Errorf("Expected ManagedNamespace to be ready, but got %+v", managed.Status.Conditions)
+	}
+	if managed.Status.Phase != v1alpha1.PhaseProvisioned || managed.Status.LastProvisionedTime == nil || managed.Status.LastError != "" {
+		t.Errorf("Expected ManagedNamespace to be provisioned, but got %+v", managed.Status)
+	}
+	provisioned := managed.Status.LastProvisionedTime
+
+	// A later failure updates the existing record
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", completed[:1], fmt.Errorf("secret not found")); err != nil {
+		t.Fatalf("Expected ManagedNamespace to be updated, but got error: %v", err)
+	}
+	managed = getManagedNamespace(t, controller, "alice")
+	condition := meta.FindStatusCondition(managed.Status.Conditions, v1alpha1.ConditionReady)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Message != "secret not found" {
+		t.Errorf("Expected ManagedNamespace not to be ready, but got %+v", condition)
+	}
+	if len(managed.Status.Policies) != 0 {
+		t.Errorf("Expected no policies, but got %+v", managed.Status.Policies)
+	}
+	if managed.Status.Phase != v1alpha1.PhaseFailed || managed.Status.LastError != "secret not found" || managed.Status.LastAttemptTime == nil {
+		t.Errorf("Expected ManagedNamespace to report the failure, but got %+v", managed.Status)
+	}
+	if !managed.Status.LastProvisionedTime.Equal(provisioned) {
+		t.Errorf("Expected the last provisioning time to be kept, but got %v", managed.Status.LastProvisionedTime)
+	}
+}
+
+func TestController_deleteManagedNamespace(t *testing.T) {
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	ctx := context.Background()
+	controller := &Controller{
+		dynamicClient: newInventoryClient(),
+	}
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", nil, nil); err != nil {
+		t.Fatalf("Expected ManagedNamespace to be created, but got error: %v", err)
+	}
+
+	if err := controller.deleteManagedNamespace(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ManagedNamespace to be deleted, but got error: %v", err)
+	}
+	_, err := controller.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, "alice", metav1.GetOptions{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("Expected ManagedNamespace alice to be deleted, but got error: %v", err)
+	}
+
+	// Deleting a missing record should be a no-op
+	if err := controller.deleteManagedNamespace(ctx, "alice", "alice"); err != nil {
+		t.Errorf("Expected missing ManagedNamespace to be accepted, but got error: %v", err)
+	}
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// name of the LimitRange setting default container requests and limits in user namespaces
+const limitRangeName = "default-limits"
+
+// Reads the LimitRange template, whose limits are seeded into every managed namespace. The
+// template is typically a ConfigMap mounted into the controller, so changes to it are picked up on
+// the next reconciliation.
+func loadLimitRangeTemplate(path string) (*corev1.LimitRange, error) {
+	if path == "" {
+		return nil, errors.New("no LimitRange template file configured")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	template := &corev1.LimitRange{}
+	if err := yaml.UnmarshalStrict(data, template); err != nil {
+		return nil, fmt.Errorf("failed to parse LimitRange template %s: %w", path, err)
+	}
+	if len(template.Spec.Limits) == 0 {
+		return nil, fmt.Errorf("LimitRange template %s has no limits", path)
+	}
+	return template, nil
+}
+
+// Returns the LimitRange template set by the applied ProvisionerConfig, or read from LIMIT_RANGE_FILE
+func currentLimitRangeTemplate() (*corev1.LimitRange, error) {
+	return resolveLimitRangeTemplate(configLimitRange(), GetLimitRangeFile())
+}
+
+// Returns the LimitRange template of a ProvisionerConfig, or read from the file when it is nil
+func resolveLimitRangeTemplate(spec *corev1.LimitRangeSpec, path string) (*corev1.LimitRange, error) {
+	if spec != nil {
+		if len(spec.Limits) == 0 {
+			return nil, errors.New("LimitRange of the ProvisionerConfig has no limits")
+		}
+		return &corev1.LimitRange{Spec: *spec}, nil
+	}
+	return loadLimitRangeTemplate(path)
+}
+
+// Returns the LimitRange applying default container requests and limits to workloads of the target
+// user, so pods created without resources don't run unbounded
+func desiredLimitRange(user string, projectName string) (*corev1.LimitRange, error) {
+	template, err := currentLimitRangeTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      limitRangeName,
+			Namespace: projectName,
+			Labels:    seededLabels(user),
+		},
+		Spec: template.Spec,
+	}, nil
+}
+
+// Creates the seeded LimitRange under the target user project, or restores its limits when they
+// were modified
+func (c *Controller) createLimitRange(ctx context.Context, user string, projectName string) error {
+	limitRange, err := desiredLimitRange(user, projectName)
+	if err != nil {
+		klog.Errorf("Error building LimitRange %s for user %s: %v", limitRangeName, user, err)
+		return err
+	}
+	if excepted, err := c.policyExcepted(ctx, user, projectName, "LimitRange", limitRangeName); err != nil || excepted {
+		return err
+	}
+
+	existing, err := c.coreClient.LimitRanges(projectName).Get(ctx, limitRangeName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.coreClient.LimitRanges(projectName).Patch, limitRange, corev1.SchemeGroupVersion.WithKind("LimitRange"))
+			} else {
+				_, err = c.coreClient.LimitRanges(projectName).Create(ctx, limitRange, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating LimitRange %s for user %s under project %s: %v", limitRangeName, user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created LimitRange %s for user %s under project %s", limitRangeName, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if LimitRange %s exists for user %s under project %s: %v", limitRangeName, user, projectName, err)
+		return err
+	}
+
+	// never overwrite LimitRanges that were not seeded by the controller
+	if existing.Labels[partOfLabel] != seededSet {
+		err := fmt.Errorf("LimitRange %s under project %s is not managed by the controller and will not be overwritten", limitRangeName, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	released := releaseAnchorReference(existing)
+	if !released && equality.Semantic.DeepEqual(existing.Spec, limitRange.Spec) {
+		klog.V(2).Infof("LimitRange %s under project %s already exist for user %s", limitRangeName, projectName, user)
+		return nil
+	}
+
+	// the anchor reference is released by an update, which an apply would leave in place
+	if GetServerSideApplyEnabled() && !released {
+		_, err = applyObject(ctx, c.coreClient.LimitRanges(projectName).Patch, limitRange, corev1.SchemeGroupVersion.WithKind("LimitRange"))
+	} else {
+		existing.Spec = limitRange.Spec
+		_, err = c.coreClient.LimitRanges(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating LimitRange %s for user %s under project %s: %v", limitRangeName, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated LimitRange %s for user %s under project %s", limitRangeName, user, projectName)
+	return nil
+}
+
+// Returns the drift of the seeded LimitRange under the target user project, if any
+func (c *Controller) limitRangeDrift(ctx context.Context, user string, projectName string) (string, error) {
+	limitRange, err := desiredLimitRange(user, projectName)
+	if err != nil {
+		return "", err
+	}
+	existing, err := c.coreClient.LimitRanges(projectName).Get(ctx, limitRangeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("LimitRange %s is missing", limitRangeName), nil
+	} else if err != nil {
+		return "", err
+	}
+	if !equality.Semantic.DeepEqual(existing.Spec, limitRange.Spec) {
+		return fmt.Sprintf("LimitRange %s does not apply the desired limits", limitRangeName), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const limitRangeTemplate = `apiVersion: v1
+kind: LimitRange
+metadata:
+  name: template
+spec:
+  limits:
+  - type: Container
+    default:
+      cpu: 500m
+      memory: 512Mi
+    defaultRequest:
+      cpu: 100m
+      memory: 128Mi
+`
+
+func TestController_createLimitRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limitrange.yaml")
+	if err := os.WriteFile(path, []byte(limitRangeTemplate), 0o644); err != nil {
+		t.Fatalf("Failed to write LimitRange template: %v", err)
+	}
+	t.Setenv("LIMIT_RANGE_FILE", path)
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      limitRangeName,
+			Namespace: "bob",
+		},
+	})
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.createLimitRange(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected LimitRange to be created, but got error: %v", err)
+	}
+
+	// Modified limits are restored
+	limitRange, err := kubeClient.CoreV1().LimitRanges("alice").Get(ctx, limitRangeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected LimitRange %s to exist, but got error: %v", limitRangeName, err)
+	}
+	limitRange.Spec.Limits[0].Default = nil
+	if _, err := kubeClient.CoreV1().LimitRanges("alice").Update(ctx, limitRange, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update LimitRange %s: %v", limitRangeName, err)
+	}
+	if drift, err := controller.limitRangeDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the modified LimitRange to be reported as drift, but got %q, %v", drift, err)
+	}
+	if err := controller.createLimitRange(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected LimitRange to be restored, but got error: %v", err)
+	}
+
+	limitRange, err = kubeClient.CoreV1().LimitRanges("alice").Get(ctx, limitRangeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected LimitRange %s to exist, but got error: %v", limitRangeName, err)
+	}
+	memory := limitRange.Spec.Limits[0].Default[corev1.ResourceMemory]
+	if limitRange.Labels[partOfLabel] != seededSet || memory.String() != "512Mi" {
+		t.Errorf("Expected the seeded LimitRange to apply the template, but got %+v", limitRange)
+	}
+
+	// LimitRanges which were not seeded are never overwritten
+	if err := controller.createLimitRange(ctx, "bob", "bob"); err == nil {
+		t.Errorf("Expected the unmanaged LimitRange of project bob to be kept")
+	}
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Returns the subjects binding the target groups
+func groupSubjects(groups []string) []rbacv1.Subject {
+	subjects := make([]rbacv1.Subject, 0, len(groups))
+	for _, group := range groups {
+		subjects = append(subjects, rbacv1.Subject{
+			Kind:     "Group",
+			APIGroup: "rbac.authorization.k8s.io",
+			Name:     group,
+		})
+	}
+	return subjects
+}
+
+// Returns the names of the subjects of a RoleBinding
+func subjectNames(subjects []rbacv1.Subject) []string {
+	names := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		names = append(names, subject.Name)
+	}
+	return names
+}
+
+// Returns the subjects of a RoleBinding for log and event messages, e.g. "groups a, b and user alice"
+func subjectList(subjects []rbacv1.Subject) string {
+	var parts []string
+	for _, kind := range []struct{ kind, singular, plural string }{
+		{"User", "user", "users"},
+		{"Group", "group", "groups"},
+		{"ServiceAccount", "service account", "service accounts"},
+	} {
+		var names []string
+		for _, subject := range subjects {
+			if subject.Kind == kind.kind {
+				names = append(names, subject.Name)
+			}
+		}
+		switch len(names) {
+		case 0:
+		case 1:
+			parts = append(parts, kind.singular+" "+names[0])
+		default:
+			parts = append(parts, kind.plural+" "+strings.Join(names, ", "))
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Returns the RoleBinding of the given name granting the groups the ClusterRole under the target user
+// project
+func desiredGroupRoleBinding(user string, projectName string, name string, clusterRole string, groups []string) *rbacv1.RoleBinding {
+	roleBinding := desiredRoleBinding(user, projectName, clusterRole)
+	roleBinding.Name = name
+	roleBinding.Subjects = groupSubjects(groups)
+	return roleBinding
+}
+
+// Creates a managed RoleBinding under the target user project as desired, replacing it when it grants
+// another ClusterRole and restoring its subjects when modified. A RoleBinding of that name not created
+// by the controller is never overwritten.
+func (c *Controller) syncManagedRoleBinding(ctx context.Context, user string, projectName string, roleBinding *rbacv1.RoleBinding) error {
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(roleBinding, anchorRef)
+
+	name, clusterRole, subjects := roleBinding.Name, roleBinding.RoleRef.Name, subjectList(roleBinding.Subjects)
+	existing, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if err := c.createManagedRoleBinding(ctx, roleBinding); err != nil {
+			klog.Errorf("Error creating RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Granted %s ClusterRole %s under project %s of user %s", subjects, clusterRole, projectName, user)
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, roleBindingCreatedReason,
+			fmt.Sprintf("Created RoleBinding %s granting ClusterRole %s to %s", name, clusterRole, subjects))
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+
+	adopting := !isManaged(existing)
+	if adopting {
+		if !GetAdoptExistingEnabled() {
+			err := fmt.Errorf("RoleBinding %s under project %s is not managed by the controller and will not be overwritten", name, projectName)
+			klog.Error(err)
+			return err
+		}
+		klog.Warningf("Adopting RoleBinding %s under project %s not created by the controller", name, projectName)
+		setManagedMetadata(existing, user)
+		setAnchorReference(existing, anchorRef)
+	}
+	switch {
+	case existing.RoleRef != roleBinding.RoleRef:
+		// the role of a RoleBinding is immutable
+		return c.replaceRoleBinding(ctx, user, projectName, roleBinding)
+	case adopting || !equality.Semantic.DeepEqual(existing.Subjects, roleBinding.Subjects):
+		if GetServerSideApplyEnabled() && !adopting {
+			_, err = applyObject(ctx, c.rbacClient.RoleBindings(projectName).Patch, roleBinding, rbacv1.SchemeGroupVersion.WithKind("RoleBinding"))
+		} else {
+			existing.Subjects = roleBinding.Subjects
+			_, err = c.rbacClient.RoleBindings(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+		}
+		if err != nil {
+			klog.Errorf("Error updating RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Restored %s as the subjects of RoleBinding %s under project %s", subjects, name, projectName)
+	default:
+		klog.V(2).Infof("RoleBinding %s under project %s already grants %s", name, projectName, subjects)
+	}
+	return nil
+}
+
+// Creates the RoleBinding, applying it when SERVER_SIDE_APPLY_ENABLED is set
+func (c *Controller) createManagedRoleBinding(ctx context.Context, roleBinding *rbacv1.RoleBinding) error {
+	var err error
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.rbacClient.RoleBindings(roleBinding.Namespace).Patch, roleBinding, rbacv1.SchemeGroupVersion.WithKind("RoleBinding"))
+	} else {
+		_, err = c.rbacClient.RoleBindings(roleBinding.Namespace).Create(ctx, roleBinding, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// Deletes a managed RoleBinding under the target user project, keeping one not created by the
+// controller
+func (c *Controller) deleteManagedRoleBinding(ctx context.Context, user string, projectName string, name string) error {
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	if !isManaged(roleBinding) {
+		klog.Warningf("RoleBinding %s under project %s is not managed by the controller and will not be deleted", name, projectName)
+		return nil
+	}
+	if err := c.rbacClient.RoleBindings(projectName).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error deleting RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Deleted RoleBinding %s granting %s under project %s of user %s", name, subjectList(roleBinding.Subjects), projectName, user)
+	return nil
+}
+
+// Returns the drift of a managed RoleBinding from the desired one, if any
+func (c *Controller) managedRoleBindingDrift(ctx context.Context, desired *rbacv1.RoleBinding) (string, error) {
+	name := desired.Name
+	existing, err := c.rbacClient.RoleBindings(desired.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("RoleBinding %s is missing", name), nil
+	} else if err != nil {
+		return "", err
+	}
+	if existing.RoleRef != desired.RoleRef {
+		return fmt.Sprintf("RoleBinding %s does not grant ClusterRole %s", name, desired.RoleRef.Name), nil
+	}
+	if !equality.Semantic.DeepEqual(existing.Subjects, desired.Subjects) {
+		return fmt.Sprintf("RoleBinding %s does not bind only %s", name, subjectList(desired.Subjects)), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncManagedRoleBindingAdoptExisting(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: opsRoleBindingName("alice"), Namespace: "alice"},
+		Subjects:   []rbacv1.Subject{{Kind: "User", APIGroup: "rbac.authorization.k8s.io", Name: "mallory"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: "rbac.authorization.k8s.io", Kind: "ClusterRole", Name: "admin"},
+	})
+	controller := &Controller{rbacClient: kubeClient.RbacV1()}
+	desired := desiredOpsRoleBinding("alice", "alice", "platform-sre", "admin")
+
+	// A RoleBinding not created by the controller is never overwritten unless it is adopted
+	if err := controller.syncManagedRoleBinding(ctx, "alice", "alice", desired); err == nil {
+		t.Fatalf("Expected the unmanaged RoleBinding not to be overwritten")
+	}
+
+	t.Setenv("ADOPT_EXISTING_ROLEBINDINGS", "true")
+	if err := controller.syncManagedRoleBinding(ctx, "alice", "alice", desiredOpsRoleBinding("alice", "alice", "platform-sre", "admin")); err != nil {
+		t.Fatalf("Expected the RoleBinding to be adopted, but got error: %v", err)
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, opsRoleBindingName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected RoleBinding %s to be found, but got error: %v", opsRoleBindingName("alice"), err)
+	}
+	if !isManaged(roleBinding) {
+		t.Errorf("Expected the adopted RoleBinding to be managed, but got labels %v", roleBinding.Labels)
+	}
+	if got := subjectList(roleBinding.Subjects); got != "group platform-sre" {
+		t.Errorf("Expected the adopted RoleBinding to bind group platform-sre, but got %s", got)
+	}
+}
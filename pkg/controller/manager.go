@@ -0,0 +1,398 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// ManagerOptions returns the options of the controller-runtime manager running the controller,
+// serving metrics and health probes and electing a leader as configured
+func ManagerOptions() manager.Options {
+	leaseDuration := GetLeaderElectionLeaseDuration()
+	renewDeadline := GetLeaderElectionRenewDeadline()
+	retryPeriod := GetLeaderElectionRetryPeriod()
+	return manager.Options{
+		Metrics:                       metricsserver.Options{BindAddress: GetMetricsBindAddress()},
+		HealthProbeBindAddress:        GetHealthProbeBindAddress(),
+		PprofBindAddress:              GetPprofBindAddress(),
+		LeaderElection:                GetLeaderElectionEnabled(),
+		LeaderElectionID:              GetLeaderElectionID(),
+		LeaderElectionNamespace:       GetLeaderElectionNamespace(),
+		LeaderElectionReleaseOnCancel: true,
+		LeaseDuration:                 &leaseDuration,
+		RenewDeadline:                 &renewDeadline,
+		RetryPeriod:                   &retryPeriod,
+	}
+}
+
+// informerRunnable runs an informer on every replica, so a newly elected leader starts with a
+// synced cache
+type informerRunnable struct {
+	informer cache.SharedIndexInformer
+}
+
+// Start runs the informer until the context is cancelled
+func (r informerRunnable) Start(ctx context.Context) error {
+	r.informer.RunWithContext(ctx)
+	return nil
+}
+
+// NeedLeaderElection returns false, as informers only read
+func (r informerRunnable) NeedLeaderElection() bool {
+	return false
+}
+
+// SetupWithManager registers the informers, reconcilers and periodic tasks of the controller with a
+// controller-runtime manager. The reconcilers and periodic tasks only run on the elected leader.
+func (c *Controller) SetupWithManager(mgr manager.Manager) error {
+	// Apply the ProvisionerConfig before anything is reconciled against the environment alone
+	if c.configInformer != nil {
+		if err := c.loadProvisionerConfig(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	informers := []cache.SharedIndexInformer{c.informer, c.namespaceInformer}
+	if c.approvalInformer != nil {
+		informers = append(informers, c.approvalInformer)
+	}
+	if c.configInformer != nil {
+		informers = append(informers, c.configInformer)
+	}
+	if c.claimInformer != nil {
+		informers = append(informers, c.claimInformer)
+	}
+	if c.failedProvisionsInformer != nil {
+		informers = append(informers, c.failedProvisionsInformer)
+	}
+	for _, informer := range informers {
+		if err := mgr.Add(informerRunnable{informer: informer}); err != nil {
+			return err
+		}
+	}
+
+	// A field selector can only match a single group, so with several target groups every group is
+	// watched and the others are filtered out here, against the target groups currently configured.
+	// With nested groups, a change to any other group re-resolves every target group.
+	err := builder.ControllerManagedBy(mgr).
+		Named("groups").
+		WatchesRawSource(&source.Informer{
+			Informer: c.informer,
+			Handler:  handler.EnqueueRequestsFromMapFunc(targetGroupRequests),
+			Predicates: []predicate.Predicate{predicate.NewPredicateFuncs(func(obj client.Object) bool {
+				return GetNestedGroupsEnabled() || slices.Contains(GetTargetGroupNames(), obj.GetName())
+			})},
+		}).
+		Complete(reconcile.Func(c.reconcileGroupRequest))
+	if err != nil {
+		return fmt.Errorf("failed to set up group reconciler: %w", err)
+	}
+
+	err = builder.ControllerManagedBy(mgr).
+		Named("namespaces").
+		WatchesRawSource(&source.Informer{
+			Informer: c.namespaceInformer,
+			Handler:  &handler.EnqueueRequestForObject{},
+		}).
+		Complete(reconcile.Func(c.reconcileNamespaceRequest))
+	if err != nil {
+		return fmt.Errorf("failed to set up namespace reconciler: %w", err)
+	}
+
+	if c.approvalInformer != nil {
+		err = builder.ControllerManagedBy(mgr).
+			Named("approvals").
+			WatchesRawSource(&source.Informer{
+				Informer: c.approvalInformer,
+				Handler:  &handler.EnqueueRequestForObject{},
+			}).
+			Complete(reconcile.Func(c.reconcileApprovalRequest))
+		if err != nil {
+			return fmt.Errorf("failed to set up approval reconciler: %w", err)
+		}
+	}
+
+	if c.configInformer != nil {
+		err = builder.ControllerManagedBy(mgr).
+			Named("provisionerconfig").
+			WatchesRawSource(&source.Informer{
+				Informer: c.configInformer,
+				Handler:  &handler.EnqueueRequestForObject{},
+			}).
+			Complete(reconcile.Func(c.reconcileProvisionerConfigRequest))
+		if err != nil {
+			return fmt.Errorf("failed to set up ProvisionerConfig reconciler: %w", err)
+		}
+	}
+
+	if c.claimInformer != nil {
+		err = builder.ControllerManagedBy(mgr).
+			Named("namespaceclaims").
+			WatchesRawSource(&source.Informer{
+				Informer: c.claimInformer,
+				Handler:  &handler.EnqueueRequestForObject{},
+			}).
+			Complete(reconcile.Func(c.reconcileNamespaceClaimRequest))
+		if err != nil {
+			return fmt.Errorf("failed to set up NamespaceClaim reconciler: %w", err)
+		}
+	}
+
+	if c.failedProvisionsInformer != nil {
+		err = builder.ControllerManagedBy(mgr).
+			Named("failedprovisions").
+			WatchesRawSource(&source.Informer{
+				Informer: c.failedProvisionsInformer,
+				Handler:  &handler.EnqueueRequestForObject{},
+			}).
+			Complete(reconcile.Func(c.reconcileFailedProvisionsRequest))
+		if err != nil {
+			return fmt.Errorf("failed to set up failed provisions reconciler: %w", err)
+		}
+	}
+
+	if err := mgr.Add(c); err != nil {
+		return err
+	}
+	if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+		return err
+	}
+	return mgr.AddReadyzCheck("informers", func(*http.Request) error {
+		for _, informer := range informers {
+			if !informer.HasSynced() {
+				return fmt.Errorf("informers have not synced")
+			}
+		}
+		return c.watches.check(time.Now(), GetWatchBrokenThreshold())
+	})
+}
+
+// Start runs the periodic tasks of the controller until the context is cancelled. The manager
+// starts it once this replica is elected leader.
+func (c *Controller) Start(ctx context.Context) error {
+	klog.Infof("Controller started successfully, watching for updates to Groups: %s", targetGroupList())
+	// everything the periodic tasks provision or deprovision is a repair
+	ctx = withTrigger(ctx, TriggerRepair)
+
+	// Shut down once nothing happened for a while, to be woken up by the next change
+	if GetIdleShutdownAfter() > 0 && c.idleShutdown != nil {
+		c.markActive(TriggerStartup)
+		go wait.UntilWithContext(ctx, c.checkIdle, idleCheckInterval)
+	}
+
+	// Apply changes to the configuration file without a restart
+	if GetConfigFile() != "" {
+		go c.watchConfigFile(ctx)
+	}
+
+	// Pick up membership changes made while the controller was down
+	go c.reconcileOnStartup(withTrigger(ctx, TriggerStartup))
+
+	// Keep the aggregated ClusterRole granted to users, restoring modified selectors every resync
+	if GetAggregatedClusterRole() != "" {
+		go wait.UntilWithContext(ctx, c.syncAggregatedClusterRole, GetInformerResyncPeriod())
+	}
+
+	// Keep the Secrets and ConfigMaps copied into managed namespaces in sync with their source
+	if seedResourcesEnabled() {
+		go wait.UntilWithContext(ctx, c.resyncSeedResources, GetInformerResyncPeriod())
+	}
+
+	// Revoke and restore access as the access windows of target groups close and open
+	if windows, err := GetAccessWindows(); err == nil && len(windows) > 0 {
+		go wait.UntilWithContext(ctx, c.syncAccessWindows, GetAccessWindowSyncInterval())
+	}
+
+	// Periodically warn owners about high quota usage
+	if GetQuotaWarningsEnabled() {
+		go wait.UntilWithContext(ctx, c.checkQuotaUsage, GetQuotaWarningInterval())
+	}
+
+	// Periodically tag AWS resources with their owner and estimate namespace costs
+	if GetCostTrackingEnabled() {
+		go wait.UntilWithContext(ctx, c.trackCosts, GetCostTrackingInterval())
+	}
+
+	// Periodically sync the members of the membership sources, which aren't watched
+	if mergedMembershipEnabled() {
+		go wait.UntilWithContext(ctx, c.syncMembership, GetMembershipSyncInterval())
+	}
+
+	// Periodically announce namespaces waiting for the maintenance window in the console
+	if GetConsoleNotificationsEnabled() && c.dynamicClient != nil {
+		go wait.UntilWithContext(ctx, c.syncConsoleNotification, GetConsoleNotificationInterval())
+	}
+
+	// Verify the namespaces of deprovisioned users are deleted, retrying and reporting stuck deletions
+	go func() {
+		c.recoverPendingDeletions(ctx)
+		wait.UntilWithContext(ctx, c.verifyDeletions, GetDeletionVerifyInterval())
+	}()
+
+	// Restore RoleBindings deleted or modified since they were provisioned
+	go wait.UntilWithContext(ctx, c.resyncRoleBindings, GetRoleBindingResyncInterval())
+
+	// Delete the projects of removed users once their grace period ends
+	go wait.UntilWithContext(ctx, c.sweepScheduledDeletions, GetDeletionSweepInterval())
+
+	// Clean up external artifacts of deprovisioned users
+	if c.cleanupQueue != nil {
+		go wait.UntilWithContext(ctx, c.runCleanupWorker, time.Second)
+	}
+
+	// Periodically refresh materialized secrets
+	if c.secretSource != nil {
+		go wait.UntilWithContext(ctx, c.refreshSecrets, GetAWSSecretsRefreshInterval())
+	}
+
+	// Wait for context cancellation
+	<-ctx.Done()
+
+	klog.Info("Shutting down controller")
+	if c.cleanupQueue != nil {
+		c.cleanupQueue.ShutDown()
+	}
+	return nil
+}
+
+// Returns the target group to reconcile for a change to a group: the group itself when it is a target
+// group, otherwise every target group, as the group may be nested in any of them
+func targetGroupRequests(_ context.Context, obj client.Object) []reconcile.Request {
+	targetGroups := GetTargetGroupNames()
+	if slices.Contains(targetGroups, obj.GetName()) {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.GetName()}}}
+	}
+	requests := make([]reconcile.Request, 0, len(targetGroups))
+	for _, name := range targetGroups {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name}})
+	}
+	return requests
+}
+
+// Handles the current revision of a target group against the revision handled last, so users are
+// provisioned and deprovisioned as they are added to and removed from it
+func (c *Controller) reconcileGroupRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	started, trigger := time.Now(), TriggerDelete
+	defer func() { observeReconcile("groups", trigger, started) }()
+	obj, exists, err := c.informer.GetStore().GetByKey(request.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	c.mu.Lock()
+	if c.handledGroups == nil {
+		c.handledGroups = make(map[string]*userv1.Group)
+	}
+	previous := c.handledGroups[request.Name]
+	if exists {
+		c.handledGroups[request.Name] = obj.(*userv1.Group)
+	} else {
+		delete(c.handledGroups, request.Name)
+	}
+	c.mu.Unlock()
+
+	if !exists {
+		// We don't care about deletes for right now, so we'll ignore them
+		klog.V(4).Infof("Group %s was deleted (ignoring)", request.Name)
+		return reconcile.Result{}, nil
+	}
+	group := obj.(*userv1.Group)
+	trigger = groupTrigger(previous, group)
+	if GetGroupFinalizerEnabled() {
+		// a deleted group tears down the namespaces of its members rather than having them removed
+		if group.DeletionTimestamp != nil {
+			return reconcile.Result{}, c.teardownGroup(withTrigger(ctx, TriggerDelete), group)
+		}
+		if err := c.addGroupFinalizer(ctx, group); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	c.handleGroup(previous, group)
+	return reconcile.Result{}, nil
+}
+
+// HandleGroupChange handles a change of a target group from one revision to the next the same way
+// the group reconciler does, e.g. to check the configured policies against fixtures
+func (c *Controller) HandleGroupChange(oldGroup, newGroup *userv1.Group) {
+	c.handleGroup(oldGroup, newGroup)
+}
+
+// Completes the deletion of a managed namespace and re-provisions its owner when a reconcile of it
+// was requested since the revision handled last, or when it was deleted out-of-band
+func (c *Controller) reconcileNamespaceRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	started, trigger := time.Now(), TriggerDelete
+	defer func() { observeReconcile("namespaces", trigger, started) }()
+	obj, exists, err := c.namespaceInformer.GetStore().GetByKey(request.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	metrics.ManagedNamespaces.Set(float64(len(c.namespaceInformer.GetStore().ListKeys())))
+
+	c.mu.Lock()
+	if c.handledNamespaces == nil {
+		c.handledNamespaces = make(map[string]*corev1.Namespace)
+	}
+	previous := c.handledNamespaces[request.Name]
+	if exists {
+		c.handledNamespaces[request.Name] = obj.(*corev1.Namespace)
+	} else {
+		delete(c.handledNamespaces, request.Name)
+	}
+	c.mu.Unlock()
+
+	if !exists {
+		if previous != nil && GetRecreateDeletedProjectsEnabled() {
+			c.recreateDeletedProject(withTrigger(ctx, trigger), previous)
+		}
+		return reconcile.Result{}, nil
+	}
+	namespace := obj.(*corev1.Namespace)
+	trigger = namespaceTrigger(previous, namespace)
+	if GetNamespaceFinalizerEnabled() {
+		c.handleDeletionRequest(withTrigger(ctx, trigger), namespace)
+		c.handleNamespaceDeletion(namespace)
+	}
+	if previous != nil {
+		c.handleNamespaceReconcile(previous, namespace)
+	}
+	return reconcile.Result{}, nil
+}
+
+// Provisions the owner of an approved ManagedNamespace
+func (c *Controller) reconcileApprovalRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// approvals are granted by admins
+	defer observeReconcile("approvals", TriggerAdmin, time.Now())
+	obj, exists, err := c.approvalInformer.GetStore().GetByKey(request.Name)
+	if err != nil || !exists {
+		return reconcile.Result{}, err
+	}
+	c.handleApproval(obj.(*unstructured.Unstructured))
+	return reconcile.Result{}, nil
+}
+
+// Observes the time a reconciler took to handle a change since it started, by what triggered it
+func observeReconcile(reconciler string, trigger string, started time.Time) {
+	metrics.ReconcileDuration.WithLabelValues(reconciler, trigger).Observe(time.Since(started).Seconds())
+}
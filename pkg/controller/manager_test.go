@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+func TestController_SetupWithManager(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userClient := userfake.NewSimpleClientset(newGroup("test-group", "alice"), newGroup("other-group", "carol"))
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := NewController(userClient, projectClient, kubeClient.RbacV1(), quotafake.NewClientset(), kubeClient.CoreV1(), nil)
+
+	// The controller only goes through its clientsets, so the manager never reaches the API server
+	options := ManagerOptions()
+	options.Controller.SkipNameValidation = ptr.To(true)
+	mgr, err := manager.New(&rest.Config{Host: "https://cluster.invalid"}, options)
+	if err != nil {
+		t.Fatalf("Expected manager to be created, but got error: %v", err)
+	}
+	if err := controller.SetupWithManager(mgr); err != nil {
+		t.Fatalf("Expected controller to be set up, but got error: %v", err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- mgr.Start(ctx)
+	}()
+
+	projectExists := func(name string) bool {
+		_, err := projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+		return err == nil
+	}
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+			return condition(), nil
+		})
+		if err != nil {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+	}
+
+	waitFor("project alice to be provisioned", func() bool { return projectExists("alice") })
+
+	group, err := userClient.UserV1().Groups().Get(ctx, "test-group", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get group test-group: %v", err)
+	}
+	group.Users = []string{"bob"}
+	if _, err := userClient.UserV1().Groups().Update(ctx, group, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update group test-group: %v", err)
+	}
+	waitFor("project bob to be provisioned and alice deleted", func() bool {
+		return projectExists("bob") && !projectExists("alice")
+	})
+
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "carol", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected no project for members of other groups")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected manager to stop cleanly, but got error: %v", err)
+	}
+}
+
+func TestManagerOptions(t *testing.T) {
+	t.Setenv("LEADER_ELECTION_ENABLED", "true")
+	t.Setenv("LEADER_ELECTION_ID", "sandbox-provisioner")
+	t.Setenv("LEADER_ELECTION_NAMESPACE", "provisioner-system")
+	t.Setenv("LEADER_ELECTION_LEASE_DURATION", "30s")
+
+	options := ManagerOptions()
+	if !options.LeaderElection || options.LeaderElectionID != "sandbox-provisioner" || options.LeaderElectionNamespace != "provisioner-system" {
+		t.Errorf("Expected leader election on Lease provisioner-system/sandbox-provisioner, but got %t on %s/%s",
+			options.LeaderElection, options.LeaderElectionNamespace, options.LeaderElectionID)
+	}
+	if *options.LeaseDuration != 30*time.Second || *options.RenewDeadline != 10*time.Second || *options.RetryPeriod != 2*time.Second {
+		t.Errorf("Expected lease timings 30s/10s/2s, but got %s/%s/%s", *options.LeaseDuration, *options.RenewDeadline, *options.RetryPeriod)
+	}
+}
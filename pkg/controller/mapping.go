@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// the namespaces recorded in the namespace mapping ConfigMap, keyed by the owner label value of their
+// user, as read or written last
+type namespaceMapping struct {
+	namespaces map[string]string
+	loaded     bool
+}
+
+// Reads the namespace mapping ConfigMap into the cache, an empty mapping when it doesn't exist yet
+func (c *Controller) loadNamespaceMapping(ctx context.Context) error {
+	namespace, name, err := GetNamespaceMappingConfigMap()
+	if err != nil || name == "" {
+		return err
+	}
+	configMap, err := c.coreClient.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error reading namespace mapping ConfigMap %s/%s: %v", namespace, name, err)
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.namespaceMapping = namespaceMapping{namespaces: maps.Clone(configMap.Data), loaded: true}
+	return nil
+}
+
+// Returns the namespace recorded for the target user, if any. The mapping is read once and then
+// kept up to date by the controller, and read again each time a user is provisioned.
+func (c *Controller) mappedProjectName(user string) (string, bool) {
+	if _, name, err := GetNamespaceMappingConfigMap(); err != nil || name == "" {
+		return "", false
+	}
+	c.mu.Lock()
+	loaded := c.namespaceMapping.loaded
+	c.mu.Unlock()
+	if !loaded {
+		if err := c.loadNamespaceMapping(context.Background()); err != nil {
+			return "", false
+		}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	projectName, ok := c.namespaceMapping.namespaces[ownerLabelValue(user)]
+	return projectName, ok
+}
+
+// Records the namespace of the target user in the namespace mapping ConfigMap, or forgets the
+// namespace of the user when the project name is empty
+func (c *Controller) updateNamespaceMapping(ctx context.Context, user string, projectName string) error {
+	namespace, name, err := GetNamespaceMappingConfigMap()
+	if err != nil || name == "" {
+		return err
+	}
+	key := ownerLabelValue(user)
+	var data map[string]string
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := c.coreClient.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if projectName == "" {
+				return nil
+			}
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+					Labels:    map[string]string{managedByLabel: componentName},
+				},
+				Data: map[string]string{key: projectName},
+			}
+			if _, err := c.coreClient.ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+				return err
+			}
+			data = configMap.Data
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if current, ok := configMap.Data[key]; current == projectName && (ok || projectName == "") {
+			data = configMap.Data
+			return nil
+		}
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		if projectName == "" {
+			delete(configMap.Data, key)
+		} else {
+			configMap.Data[key] = projectName
+		}
+		if _, err := c.coreClient.ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		data = configMap.Data
+		return nil
+	})
+	if err != nil {
+		klog.Errorf("Error updating namespace mapping ConfigMap %s/%s for user %s: %v", namespace, name, user, err)
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.namespaceMapping = namespaceMapping{namespaces: maps.Clone(data), loaded: true}
+	return nil
+}
+
+// Forgets the namespace of a deprovisioned user once their project is removed, keeping it while the
+// project is retained or scheduled for deletion so the user gets it back when added again
+func (c *Controller) releaseNamespaceMapping(ctx context.Context, user string, projectName string) error {
+	policy, err := GetProjectDeletionPolicy()
+	if err != nil {
+		return err
+	}
+	if policy == ProjectDeletionRetain || (policy == ProjectDeletionDelete && GetDeletionGracePeriod() > 0) {
+		return nil
+	}
+	return c.forgetNamespaceMapping(ctx, user, projectName)
+}
+
+// Forgets the namespace of the target user when it is the recorded one, leaving the namespace a
+// naming migration moved them to
+func (c *Controller) forgetNamespaceMapping(ctx context.Context, user string, projectName string) error {
+	if mapped, ok := c.mappedProjectName(user); !ok || mapped != projectName {
+		return nil
+	}
+	return c.updateNamespaceMapping(ctx, user, "")
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_namespaceMapping(t *testing.T) {
+	t.Setenv("NAMESPACE_MAPPING_CONFIGMAP", "provisioner/namespaces")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	mapping, err := kubeClient.CoreV1().ConfigMaps("provisioner").Get(ctx, "namespaces", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the namespace mapping ConfigMap to be created, but got error: %v", err)
+	}
+	if got := mapping.Data["alice"]; got != "alice" {
+		t.Errorf("Expected namespace alice to be recorded for alice, but got %q", got)
+	}
+
+	// A changed naming convention keeps finding the recorded namespace
+	t.Setenv("PROJECT_PREFIX", "sandbox-")
+	if got := controller.ProjectName("alice"); got != "alice" {
+		t.Errorf("Expected the project of alice to keep the name alice, but got %s", got)
+	}
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned again, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "sandbox-alice", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected no second project for alice, but got error: %v", err)
+	}
+
+	// A new user is named by the current convention
+	if err := controller.provisionUser(ctx, "bob"); err != nil {
+		t.Fatalf("Expected user bob to be provisioned, but got error: %v", err)
+	}
+	if got := controller.ProjectName("bob"); got != "sandbox-bob" {
+		t.Errorf("Expected the project of bob to be named sandbox-bob, but got %s", got)
+	}
+
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project alice to be deleted, but got error: %v", err)
+	}
+	mapping, err = kubeClient.CoreV1().ConfigMaps("provisioner").Get(ctx, "namespaces", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get the namespace mapping ConfigMap: %v", err)
+	}
+	if _, ok := mapping.Data["alice"]; ok {
+		t.Errorf("Expected the namespace of alice to be forgotten, but got %v", mapping.Data)
+	}
+	if got := mapping.Data["bob"]; got != "sandbox-bob" {
+		t.Errorf("Expected namespace sandbox-bob to still be recorded for bob, but got %q", got)
+	}
+}
+
+func TestController_namespaceMappingRetained(t *testing.T) {
+	t.Setenv("NAMESPACE_MAPPING_CONFIGMAP", "provisioner/namespaces")
+	t.Setenv("PROJECT_DELETION_POLICY", ProjectDeletionRetain)
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+
+	// The retained project is given back to alice once she is added again
+	t.Setenv("PROJECT_PREFIX", "sandbox-")
+	if got := controller.ProjectName("alice"); got != "alice" {
+		t.Errorf("Expected the retained project alice to stay recorded, but got %s", got)
+	}
+}
+
+func TestController_namespaceMappingDisabled(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	configMaps, err := kubeClient.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Failed to list ConfigMaps: %v", err)
+	}
+	if len(configMaps.Items) != 0 {
+		t.Errorf("Expected no namespace mapping ConfigMap, but got %d ConfigMaps", len(configMaps.Items))
+	}
+}
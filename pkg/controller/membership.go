@@ -0,0 +1,205 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Names of the membership sources, where the group source is the target groups watched by the
+// controller
+const (
+	MembershipSourceGroup  = "group"
+	MembershipSourceRoster = "roster"
+	MembershipSourceGitHub = "github"
+)
+
+// Policies merging the members of several membership sources
+const (
+	MergePolicyUnion        = "union"
+	MergePolicyIntersection = "intersection"
+)
+
+// MembershipSource lists the users granted a namespace by a system other than the target groups
+type MembershipSource interface {
+	// Name identifies the source in MEMBERSHIP_SOURCES and in the status of ManagedNamespaces
+	Name() string
+	// Members returns the users the source currently grants a namespace
+	Members(ctx context.Context) (map[string]bool, error)
+}
+
+// WithMembershipSources registers external membership sources, which are merged with the built-in
+// sources when listed in MEMBERSHIP_SOURCES
+func WithMembershipSources(sources ...MembershipSource) Option {
+	return func(c *Controller) {
+		if c.membershipSources == nil {
+			c.membershipSources = make(map[string]MembershipSource, len(sources))
+		}
+		for _, source := range sources {
+			c.membershipSources[source.Name()] = source
+		}
+	}
+}
+
+// Returns whether membership is merged from configured sources rather than taken from the target
+// groups alone
+func mergedMembershipEnabled() bool {
+	sources := GetMembershipSources()
+	return len(sources) != 1 || sources[0] != MembershipSourceGroup
+}
+
+// Returns the members of a single membership source
+func (c *Controller) sourceMembers(ctx context.Context, name string) (map[string]bool, error) {
+	switch name {
+	case MembershipSourceGroup:
+		return c.GroupMembers(ctx)
+	case MembershipSourceRoster:
+		return c.rosterMembers(ctx)
+	}
+	source, ok := c.membershipSources[name]
+	if !ok {
+		return nil, fmt.Errorf("membership source %s is not available", name)
+	}
+	return source.Members(ctx)
+}
+
+// Reads the users listed in the roster ConfigMap, one per line or separated by commas, ignoring
+// blank lines and lines starting with #
+func (c *Controller) rosterMembers(ctx context.Context) (map[string]bool, error) {
+	namespace, name, err := GetRosterConfigMap()
+	if err != nil {
+		return nil, err
+	}
+	configMap, err := c.coreClient.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roster ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	key := GetRosterConfigMapKey()
+	roster, ok := configMap.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("roster ConfigMap %s/%s has no key %s", namespace, name, key)
+	}
+	return parseRoster(roster), nil
+}
+
+// Parses the users of a roster
+func parseRoster(roster string) map[string]bool {
+	members := make(map[string]bool)
+	for _, line := range strings.Split(roster, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, user := range strings.Split(line, ",") {
+			if user = strings.TrimSpace(user); user != "" {
+				members[user] = true
+			}
+		}
+	}
+	return members
+}
+
+// Returns the merged members of the configured membership sources, each with the sources granting
+// them a namespace in priority order. Fails if any source fails, rather than deprovision users whose
+// source could not be read.
+func (c *Controller) resolveMembership(ctx context.Context) (map[string][]string, error) {
+	sources := GetMembershipSources()
+	granted := make(map[string][]string)
+	for _, name := range sources {
+		members, err := c.sourceMembers(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get members of membership source %s: %w", name, err)
+		}
+		for user := range members {
+			granted[user] = append(granted[user], name)
+		}
+	}
+
+	if GetMembershipMergePolicy() == MergePolicyIntersection {
+		for user, grantedBy := range granted {
+			if len(grantedBy) < len(sources) {
+				delete(granted, user)
+			}
+		}
+	}
+	return granted, nil
+}
+
+// Members returns the users granted a namespace: the members of the target groups, or the merged
+// members of the configured membership sources
+func (c *Controller) Members(ctx context.Context) (map[string]bool, error) {
+	if !mergedMembershipEnabled() {
+		return c.GroupMembers(ctx)
+	}
+	granted, err := c.resolveMembership(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool, len(granted))
+	for user := range granted {
+		members[user] = true
+	}
+	return members, nil
+}
+
+// Returns the membership sources granting the target user a namespace as of the last sync, in
+// priority order
+func (c *Controller) userMembershipSources(user string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.grantedBy[user]
+}
+
+// Provisions the users granted a namespace by the merged membership sources and deprovisions the
+// managed users no longer granted one
+func (c *Controller) syncMembership(ctx context.Context) {
+	granted, err := c.resolveMembership(ctx)
+	if err != nil {
+		// Skip the sync rather than deprovision users of a source which could not be read
+		klog.Errorf("Error resolving %s: %v", membershipDescription(), err)
+		return
+	}
+	c.mu.Lock()
+	c.grantedBy = granted
+	c.mu.Unlock()
+
+	managed, err := c.ManagedUsers(ctx)
+	if err != nil {
+		klog.Errorf("Error listing managed users to sync membership: %v", err)
+		return
+	}
+	members := make(map[string]bool, len(granted))
+	for user := range granted {
+		members[user] = true
+	}
+	addedUsers, removedUsers := diffUsers(managed, members)
+
+	if len(addedUsers) > 0 {
+		klog.Infof("Users granted a namespace by membership sources: %v", addedUsers)
+		for _, user := range addedUsers {
+			c.markPending(user, time.Now())
+			_ = c.admitUser(ctx, user)
+		}
+	}
+	if len(removedUsers) > 0 {
+		klog.Infof("Users no longer granted a namespace by membership sources: %v", removedUsers)
+		for _, user := range removedUsers {
+			_ = c.deprovisionUser(ctx, user)
+		}
+	}
+	if len(addedUsers) == 0 && len(removedUsers) == 0 {
+		klog.V(2).Infof("Membership sources synced with no users to provision or deprovision")
+	}
+}
+
+// Returns what grants users a namespace for log and error messages
+func membershipDescription() string {
+	if !mergedMembershipEnabled() {
+		return "target groups " + targetGroupList()
+	}
+	return "membership sources " + strings.Join(GetMembershipSources(), ", ")
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// staticSource is a membership source with a fixed set of members
+type staticSource struct {
+	members map[string]bool
+	err     error
+}
+
+func (s *staticSource) Name() string {
+	return MembershipSourceGitHub
+}
+
+func (s *staticSource) Members(ctx context.Context) (map[string]bool, error) {
+	return s.members, s.err
+}
+
+func TestParseRoster(t *testing.T) {
+	roster := "# workshop attendees\nalice\n\nbob, carol\n  dave  \n"
+	expected := map[string]bool{"alice": true, "bob": true, "carol": true, "dave": true}
+	if got := parseRoster(roster); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected roster %v, but got %v", expected, got)
+	}
+}
+
+func TestController_resolveMembership(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+	t.Setenv("MEMBERSHIP_SOURCES", "roster,group,github")
+	t.Setenv("ROSTER_CONFIGMAP", "workshops/attendees")
+
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(newGroup("test-group", "alice", "bob")),
+		coreClient: fake.NewClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "attendees", Namespace: "workshops"},
+			Data:       map[string]string{"users": "alice\ncarol\n"},
+		}).CoreV1(),
+	}
+	github := &staticSource{members: map[string]bool{"alice": true, "bob": true, "dave": true}}
+	WithMembershipSources(github)(controller)
+
+	tests := []struct {
+		name     string
+		policy   string
+		expected map[string][]string
+	}{
+		{
+			name:   "union",
+			policy: MergePolicyUnion,
+			expected: map[string][]string{
+				"alice": {"roster", "group", "github"},
+				"bob":   {"group", "github"},
+				"carol": {"roster"},
+				"dave":  {"github"},
+			},
+		},
+		{
+			name:   "intersection",
+			policy: MergePolicyIntersection,
+			expected: map[string][]string{
+				"alice": {"roster", "group", "github"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MEMBERSHIP_MERGE_POLICY", tt.policy)
+			granted, err := controller.resolveMembership(context.Background())
+			if err != nil {
+				t.Fatalf("Expected membership to be resolved, but got error: %v", err)
+			}
+			if !reflect.DeepEqual(granted, tt.expected) {
+				t.Errorf("Expected membership %v, but got %v", tt.expected, granted)
+			}
+		})
+	}
+
+	// A failing source fails the resolution rather than dropping its members
+	github.err = errors.New("rate limited")
+	if _, err := controller.resolveMembership(context.Background()); err == nil {
+		t.Errorf("Expected the failing source to fail the resolution")
+	}
+}
+
+func TestController_syncMembership(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+	t.Setenv("MEMBERSHIP_SOURCES", "group,github")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	github := &staticSource{members: map[string]bool{"bob": true}}
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("test-group", "alice")),
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	WithMembershipSources(github)(controller)
+	projectExists := func(name string) bool {
+		_, err := projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+		return err == nil
+	}
+
+	// A group change syncs every source
+	controller.handleGroup(nil, newGroup("test-group", "alice"))
+	if !projectExists("alice") || !projectExists("bob") {
+		t.Fatalf("Expected projects for the members of both sources")
+	}
+	if sources := controller.userMembershipSources("bob"); !reflect.DeepEqual(sources, []string{"github"}) {
+		t.Errorf("Expected bob to be granted by github, but got %v", sources)
+	}
+
+	// A failing source keeps every namespace
+	github.err = errors.New("rate limited")
+	controller.syncMembership(ctx)
+	if !projectExists("bob") {
+		t.Errorf("Expected project bob to be kept while the source fails")
+	}
+
+	// Users no longer granted by any source are deprovisioned
+	github.err = nil
+	github.members = map[string]bool{}
+	controller.syncMembership(ctx)
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project bob to be deleted")
+	}
+	if !projectExists("alice") {
+		t.Errorf("Expected project alice to be kept")
+	}
+}
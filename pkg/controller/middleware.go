@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/tracing"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// longest wait between two attempts of a step
+const maxStepRetryBackoff = 30 * time.Second
+
+// stepRequest is a provisioning or deprovisioning step to run for a user
+type stepRequest struct {
+	// action is events.ActionProvision or events.ActionDeprovision, which double as the operations
+	// of the step metrics
+	action      string
+	user        string
+	projectName string
+	step        provisioningStep
+}
+
+// stepHandler runs a step
+type stepHandler func(ctx context.Context, req stepRequest) error
+
+// stepMiddleware wraps a step handler with a cross-cutting concern, such as logging or metrics, so
+// the steps themselves only make their changes
+type stepMiddleware func(next stepHandler) stepHandler
+
+// Runs the step itself, at the end of the middleware chain
+func runStep(ctx context.Context, req stepRequest) error {
+	return req.step.run(ctx)
+}
+
+// Returns the handler running steps through the middlewares, the first middleware being the
+// outermost
+func chainStepMiddlewares(handler stepHandler, middlewares ...stepMiddleware) stepHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Returns the handler running every provisioning and deprovisioning step through the configured
+// middlewares
+func (c *Controller) stepHandler() stepHandler {
+	middlewares := []stepMiddleware{c.tracingMiddleware, c.eventsMiddleware}
+	if GetAuditLogEnabled() {
+		middlewares = append(middlewares, auditMiddleware)
+	}
+	middlewares = append(middlewares, loggingMiddleware)
+	if GetDryRunEnabled() {
+		middlewares = append(middlewares, dryRunMiddleware)
+	}
+	if attempts := GetStepRetryAttempts(); attempts > 1 {
+		middlewares = append(middlewares, retryMiddleware(attempts, GetStepRetryBackoff()))
+	}
+	if limiter := c.getStepLimiter(); limiter != nil {
+		middlewares = append(middlewares, rateLimitMiddleware(limiter))
+	}
+	middlewares = append(middlewares, metricsMiddleware, timeoutMiddleware(GetProvisioningStepTimeout()))
+	return chainStepMiddlewares(runStep, middlewares...)
+}
+
+// Returns the limiter shared by every step when a step rate limit is configured
+func (c *Controller) getStepLimiter() *rate.Limiter {
+	limit := GetStepRateLimit()
+	if limit == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stepLimiter == nil {
+		c.stepLimiter = rate.NewLimiter(rate.Limit(limit), int(GetStepRateBurst()))
+	}
+	return c.stepLimiter
+}
+
+// Records each step as a span of the reconcile, covering the wait for the rate limiter
+func (c *Controller) tracingMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		ctx, span := c.tracer.Start(ctx, req.action+"/"+req.step.name, userAttributes(req.user, req.projectName, reconcileTrigger(ctx))...)
+		err := next(ctx, req)
+		span.End(err)
+		return err
+	}
+}
+
+// Returns the span attributes of reconciling the target user
+func userAttributes(user string, projectName string, trigger string) []tracing.Attribute {
+	return []tracing.Attribute{
+		tracing.String("user", user),
+		tracing.String("namespace", projectName),
+		tracing.String("trigger", trigger),
+	}
+}
+
+// Publishes the result of each step for live subscribers
+func (c *Controller) eventsMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		err := next(ctx, req)
+		result := events.ResultSucceeded
+		if err != nil {
+			result = events.ResultFailed
+		}
+		c.publishEvent(ctx, req.user, req.projectName, req.action, req.step.name, result, err)
+		return err
+	}
+}
+
+// Logs a structured record of each step with its outcome, for audit pipelines collecting the
+// controller logs
+func auditMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		started := time.Now()
+		err := next(ctx, req)
+		result := events.ResultSucceeded
+		if err != nil {
+			result = events.ResultFailed
+		}
+		klog.InfoS("Audit",
+			"action", req.action,
+			"step", req.step.name,
+			"user", req.user,
+			"namespace", req.projectName,
+			"result", result,
+			"trigger", reconcileTrigger(ctx),
+			"dryRun", GetDryRunEnabled(),
+			"duration", time.Since(started),
+			"error", err,
+		)
+		return err
+	}
+}
+
+// Logs the start and end of each step
+func loggingMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		klog.V(2).Infof("Running %s step %s for user %s under project %s", req.action, req.step.name, req.user, req.projectName)
+		started := time.Now()
+		err := next(ctx, req)
+		if err != nil {
+			klog.V(2).Infof("The %s step %s failed for user %s under project %s after %s: %v", req.action, req.step.name, req.user, req.projectName, time.Since(started), err)
+			return err
+		}
+		klog.V(2).Infof("Completed %s step %s for user %s under project %s in %s", req.action, req.step.name, req.user, req.projectName, time.Since(started))
+		return nil
+	}
+}
+
+// Logs and counts each step instead of running it
+func dryRunMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		klog.Infof("Dry run: skipping %s step %s for user %s under project %s", req.action, req.step.name, req.user, req.projectName)
+		metrics.DryRunSteps.WithLabelValues(req.action, req.step.name).Inc()
+		return nil
+	}
+}
+
+// Attempts each step again with exponential backoff while it fails with a transient error, such as a
+// timeout, throttling or a conflict, so a blip of the API server doesn't fail the whole reconcile
+func retryMiddleware(attempts int, backoff time.Duration) stepMiddleware {
+	return func(next stepHandler) stepHandler {
+		return func(ctx context.Context, req stepRequest) error {
+			steps := wait.Backoff{Steps: attempts, Duration: backoff, Factor: 2, Jitter: 0.1, Cap: maxStepRetryBackoff}
+			attempt := 0
+			return retry.OnError(steps, func(err error) bool {
+				if ctx.Err() != nil || isPermanentError(err) {
+					return false
+				}
+				// the last failure ends the backoff without a retry
+				if attempt >= attempts {
+					return true
+				}
+				klog.V(2).Infof("Retrying %s step %s for user %s under project %s after attempt %d: %v", req.action, req.step.name, req.user, req.projectName, attempt, err)
+				metrics.StepRetries.WithLabelValues(req.action, req.step.name).Inc()
+				return true
+			}, func() error {
+				attempt++
+				return next(ctx, req)
+			})
+		}
+	}
+}
+
+// Waits for the shared limiter before each step, so mass onboardings don't flood the API server
+func rateLimitMiddleware(limiter *rate.Limiter) stepMiddleware {
+	return func(next stepHandler) stepHandler {
+		return func(ctx context.Context, req stepRequest) error {
+			if err := limiter.Wait(ctx); err != nil {
+				return err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// Observes the duration of each step by result
+func metricsMiddleware(next stepHandler) stepHandler {
+	return func(ctx context.Context, req stepRequest) error {
+		started := time.Now()
+		err := next(ctx, req)
+		result := events.ResultSucceeded
+		if err != nil {
+			result = events.ResultFailed
+		}
+		metrics.StepDuration.WithLabelValues(req.action, req.step.name, result).Observe(time.Since(started).Seconds())
+		return err
+	}
+}
+
+// Runs each step under its own timeout
+func timeoutMiddleware(timeout time.Duration) stepMiddleware {
+	return func(next stepHandler) stepHandler {
+		return func(ctx context.Context, req stepRequest) error {
+			stepCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			return next(stepCtx, req)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestChainStepMiddlewares(t *testing.T) {
+	var calls []string
+	record := func(name string) stepMiddleware {
+		return func(next stepHandler) stepHandler {
+			return func(ctx context.Context, req stepRequest) error {
+				calls = append(calls, name+" before")
+				err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+
+	handler := chainStepMiddlewares(runStep, record("outer"), record("inner"))
+	err := handler(context.Background(), stepRequest{step: provisioningStep{
+		name: "project",
+		run: func(ctx context.Context) error {
+			calls = append(calls, "step")
+			return nil
+		},
+	}})
+	if err != nil {
+		t.Fatalf("Expected the step to run, but got error: %v", err)
+	}
+
+	expected := []string{"outer before", "inner before", "step", "inner after", "outer after"}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected calls %v, but got %v", expected, calls)
+	}
+}
+
+func TestController_provisionUserDryRun(t *testing.T) {
+	t.Setenv("DRY_RUN_ENABLED", "true")
+	t.Setenv("AUDIT_LOG_ENABLED", "true")
+
+	ctx := context.Background()
+	broadcaster := events.NewBroadcaster()
+	ch, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	projectClient := projectfake.NewSimpleClientset(desiredProject("bob", "bob"))
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		broadcaster:   broadcaster,
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected a dry run provisioning alice, but got error: %v", err)
+	}
+	if err := controller.deprovisionUser(ctx, "bob"); err != nil {
+		t.Fatalf("Expected a dry run deprovisioning bob, but got error: %v", err)
+	}
+
+	// Steps are reported without being run
+	if got := <-ch; got.Action != events.ActionProvision || got.Result != events.ResultStarted {
+		t.Errorf("Expected provisioning to start, but got %+v", got)
+	}
+	if got := <-ch; got.Step != "project" || got.Result != events.ResultSucceeded || !got.DryRun {
+		t.Errorf("Expected the project step to be reported as a dry run, but got %+v", got)
+	}
+	if got := testutil.ToFloat64(metrics.DryRunSteps.WithLabelValues(events.ActionDeprovision, "project")); got < 1 {
+		t.Errorf("Expected the skipped deprovisioning of project bob to be counted, but got %v", got)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected no project to be created for alice during a dry run")
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the project of bob to be kept during a dry run, but got error: %v", err)
+	}
+}
+
+func TestController_backgroundWritesDryRun(t *testing.T) {
+	t.Setenv("DRY_RUN_ENABLED", "true")
+	t.Setenv("AGGREGATED_CLUSTER_ROLE", "sandbox-user")
+
+	ctx := context.Background()
+	now := metav1.Now()
+	terminating := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:              "alice",
+		Labels:            map[string]string{ownerLabel: "alice"},
+		Finalizers:        []string{protectionFinalizer},
+		DeletionTimestamp: &now,
+	}}
+	kubeClient := fake.NewClientset(
+		terminating,
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "model", Namespace: "alice"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+		},
+	)
+	group := newGroup("test-group", "alice")
+	userClient := userfake.NewSimpleClientset(group)
+	controller := &Controller{
+		userClient: userClient,
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if _, err := controller.trackNamespaceCost(ctx, "alice", "alice", CostPrices{}); err != nil {
+		t.Fatalf("Expected the cost of namespace alice to be estimated, but got error: %v", err)
+	}
+	if err := controller.ensureAggregatedClusterRole(ctx); err != nil {
+		t.Fatalf("Expected the aggregated ClusterRole to be synced, but got error: %v", err)
+	}
+	controller.handleNamespaceDeletion(terminating)
+	if err := controller.addGroupFinalizer(ctx, group); err != nil {
+		t.Fatalf("Expected the group finalizer to be synced, but got error: %v", err)
+	}
+
+	service, err := kubeClient.CoreV1().Services("alice").Get(ctx, "model", metav1.GetOptions{})
+	if err != nil || len(service.Annotations) != 0 {
+		t.Errorf("Expected Service model not to be tagged during a dry run, but got %v (%v)", service, err)
+	}
+	if _, err := kubeClient.RbacV1().ClusterRoles().Get(ctx, "sandbox-user", metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected no aggregated ClusterRole to be created during a dry run")
+	}
+	namespace, err := kubeClient.CoreV1().Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil || !hasFinalizer(namespace, protectionFinalizer) {
+		t.Errorf("Expected the finalizer of namespace alice to be kept during a dry run, but got %v (%v)", namespace, err)
+	}
+	updated, err := userClient.UserV1().Groups().Get(ctx, "test-group", metav1.GetOptions{})
+	if err != nil || hasFinalizer(updated, teardownFinalizer) {
+		t.Errorf("Expected no finalizer to be added to the group during a dry run, but got %v (%v)", updated, err)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	t.Setenv("STEP_RATE_LIMIT", "0.001")
+	t.Setenv("STEP_RATE_BURST", "1")
+
+	controller := &Controller{}
+	limiter := controller.getStepLimiter()
+	if limiter == nil || controller.getStepLimiter() != limiter {
+		t.Fatalf("Expected a single limiter shared by every step")
+	}
+
+	runs := 0
+	handler := rateLimitMiddleware(limiter)(runStep)
+	req := stepRequest{step: provisioningStep{
+		name: "project",
+		run: func(ctx context.Context) error {
+			runs++
+			return nil
+		},
+	}}
+	if err := handler(context.Background(), req); err != nil {
+		t.Fatalf("Expected the first step to run within the burst, but got error: %v", err)
+	}
+
+	// A step waiting for the limiter stops with its context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := handler(ctx, req); err == nil {
+		t.Errorf("Expected the rate limited step to be stopped with its context")
+	}
+	if runs != 1 {
+		t.Errorf("Expected a single step to run, but got %d", runs)
+	}
+}
+
+func TestRetryMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     []error
+		expected int
+		fails    bool
+	}{
+		{name: "transient error is retried", errs: []error{apierrors.NewTooManyRequests("slow down", 1)}, expected: 2},
+		{name: "conflict is retried", errs: []error{apierrors.NewConflict(schema.GroupResource{Resource: "projects"}, "alice", errors.New("modified"))}, expected: 2},
+		{name: "permanent error is not retried", errs: []error{apierrors.NewForbidden(schema.GroupResource{Resource: "projects"}, "alice", errors.New("denied"))}, expected: 1, fails: true},
+		{name: "attempts are exhausted", errs: []error{apierrors.NewServiceUnavailable("down"), apierrors.NewServiceUnavailable("down"), apierrors.NewServiceUnavailable("down")}, expected: 3, fails: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries := metrics.StepRetries.WithLabelValues(events.ActionProvision, "project")
+			before := testutil.ToFloat64(retries)
+			runs := 0
+			handler := retryMiddleware(3, time.Millisecond)(runStep)
+			err := handler(context.Background(), stepRequest{action: events.ActionProvision, step: provisioningStep{
+				name: "project",
+				run: func(ctx context.Context) error {
+					runs++
+					if runs <= len(tt.errs) {
+						return tt.errs[runs-1]
+					}
+					return nil
+				},
+			}})
+			if (err != nil) != tt.fails {
+				t.Errorf("Expected failure %v, but got error: %v", tt.fails, err)
+			}
+			if runs != tt.expected {
+				t.Errorf("Expected %d attempts, but got %d", tt.expected, runs)
+			}
+			if counted := testutil.ToFloat64(retries) - before; counted != float64(tt.expected-1) {
+				t.Errorf("Expected %d retries to be counted, but got %v", tt.expected-1, counted)
+			}
+		})
+	}
+}
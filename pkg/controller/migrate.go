@@ -0,0 +1,247 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation on the old namespace of a naming migration recording the namespace that replaced it
+const migratedToAnnotation = "rosa-namespace-provisioner/migrated-to"
+
+// Policies for the old namespaces of a naming migration
+const (
+	// MigrationDeleteOld deletes old namespaces through the deletion policy, so the protection
+	// finalizer holds them back until the maintenance window or while they are protected
+	MigrationDeleteOld = "delete"
+	// MigrationKeepOld keeps old namespaces as unmanaged namespaces
+	MigrationKeepOld = "keep"
+)
+
+// Kinds of resources a naming migration can copy into the new namespaces
+const (
+	MigrationCopySecrets    = "secrets"
+	MigrationCopyConfigMaps = "configmaps"
+)
+
+// ConfigMaps the cluster publishes into every namespace, which are never copied
+var clusterConfigMaps = map[string]bool{
+	"kube-root-ca.crt":         true,
+	"openshift-service-ca.crt": true,
+}
+
+// NamingMigration maps a managed namespace to the name the current naming scheme gives its owner
+type NamingMigration struct {
+	User string `json:"user"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// MigrationOptions configures how managed namespaces are migrated to their new names
+type MigrationOptions struct {
+	// Copy lists the kinds of resources copied from the old namespaces, secrets or configmaps
+	Copy []string
+	// OldNamespaces is the policy for the old namespaces, delete or keep
+	OldNamespaces string
+}
+
+// Validate checks the copied kinds and the policy for the old namespaces
+func (o MigrationOptions) Validate() error {
+	for _, kind := range o.Copy {
+		if kind != MigrationCopySecrets && kind != MigrationCopyConfigMaps {
+			return fmt.Errorf("invalid kind %q to copy, expected %s or %s", kind, MigrationCopySecrets, MigrationCopyConfigMaps)
+		}
+	}
+	if o.OldNamespaces != MigrationDeleteOld && o.OldNamespaces != MigrationKeepOld {
+		return fmt.Errorf("invalid policy %q for old namespaces, expected %s or %s", o.OldNamespaces, MigrationDeleteOld, MigrationKeepOld)
+	}
+	return nil
+}
+
+// PlanNamingMigration returns the managed namespaces whose name differs from the name the current
+// naming scheme gives their owner, sorted by owner. It only issues read requests.
+func (c *Controller) PlanNamingMigration(ctx context.Context) ([]NamingMigration, error) {
+	namespaces, err := c.coreClient.Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing managed namespaces for naming migration: %v", err)
+		return nil, err
+	}
+
+	var migrations []NamingMigration
+	for _, namespace := range namespaces.Items {
+		// skip old namespaces of earlier migrations waiting to be deleted
+		if namespace.DeletionTimestamp != nil {
+			continue
+		}
+		user := objectOwner(&namespace)
+		if projectName := c.desiredProjectName(user); projectName != namespace.Name {
+			migrations = append(migrations, NamingMigration{User: user, From: namespace.Name, To: projectName})
+		}
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].User < migrations[j].User
+	})
+	return migrations, nil
+}
+
+// MigrateNamespace provisions the new namespace of a naming migration, copies the configured
+// resources from the old namespace, moves the ownership over and applies the policy for the old
+// namespace. Re-running it after a failure resumes the migration.
+func (c *Controller) MigrateNamespace(ctx context.Context, migration NamingMigration, opts MigrationOptions) error {
+	ctx = withDefaultTrigger(ctx, TriggerAdmin)
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	members, err := c.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get members of %s: %w", membershipDescription(), err)
+	}
+	if !members[migration.User] {
+		return fmt.Errorf("user %s is not granted a namespace by %s", migration.User, membershipDescription())
+	}
+
+	// the user is provisioned into the new namespace once it is recorded as theirs
+	if !GetDryRunEnabled() {
+		if err := c.updateNamespaceMapping(ctx, migration.User, migration.To); err != nil {
+			return err
+		}
+	}
+	if err := c.provisionUser(ctx, migration.User); err != nil {
+		return err
+	}
+	for _, kind := range opts.Copy {
+		if err := c.copyNamespaceResources(ctx, migration, kind); err != nil {
+			return err
+		}
+	}
+
+	// The new namespace now holds the inventory record of the user
+	if err := c.deleteManagedNamespace(ctx, migration.User, migration.From); err != nil {
+		return err
+	}
+
+	if opts.OldNamespaces == MigrationKeepOld {
+		err := c.updateNamespace(ctx, migration.From, func(namespace *corev1.Namespace) {
+			releaseMetadata(&namespace.ObjectMeta)
+			namespace.Finalizers = removeFinalizer(namespace.Finalizers, protectionFinalizer)
+			if namespace.Annotations == nil {
+				namespace.Annotations = make(map[string]string)
+			}
+			namespace.Annotations[migratedToAnnotation] = migration.To
+		})
+		if err != nil {
+			klog.Errorf("Error releasing namespace %s migrated to %s for user %s: %v", migration.From, migration.To, migration.User, err)
+			return err
+		}
+		klog.Infof("Migrated namespace %s to %s for user %s, keeping %s unmanaged", migration.From, migration.To, migration.User, migration.From)
+		return nil
+	}
+
+	// The owner label stays until the namespace is gone so the controller still releases its finalizer
+	err = c.updateNamespace(ctx, migration.From, func(namespace *corev1.Namespace) {
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string)
+		}
+		namespace.Annotations[migratedToAnnotation] = migration.To
+	})
+	if err != nil {
+		klog.Errorf("Error annotating namespace %s migrated to %s for user %s: %v", migration.From, migration.To, migration.User, err)
+		return err
+	}
+	// the old namespace is removed like the namespace of a removed user, honoring the deletion policy
+	// and grace period
+	if err := c.removeUserProject(ctx, migration.User, migration.From); err != nil {
+		return err
+	}
+	klog.Infof("Migrated namespace %s to %s for user %s, removing %s by the project deletion policy", migration.From, migration.To, migration.User, migration.From)
+	return nil
+}
+
+// Returns whether an object was generated by the cluster or seeded by the provisioner, so it must
+// not be copied into another namespace
+func generatedObject(obj metav1.Object) bool {
+	if _, ok := obj.GetLabels()[ownerLabel]; ok {
+		return true
+	}
+	if _, ok := obj.GetAnnotations()[corev1.ServiceAccountNameKey]; ok {
+		return true
+	}
+	return len(obj.GetOwnerReferences()) > 0
+}
+
+// Copies the Secrets or ConfigMaps users created in the old namespace of a migration into the new
+// one, skipping those which already exist there
+func (c *Controller) copyNamespaceResources(ctx context.Context, migration NamingMigration, kind string) error {
+	switch kind {
+	case MigrationCopySecrets:
+		secrets, err := c.coreClient.Secrets(migration.From).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.Errorf("Error listing Secrets under project %s for user %s: %v", migration.From, migration.User, err)
+			return err
+		}
+		for _, secret := range secrets.Items {
+			if generatedObject(&secret) {
+				continue
+			}
+			copied := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        secret.Name,
+					Namespace:   migration.To,
+					Labels:      secret.Labels,
+					Annotations: secret.Annotations,
+				},
+				Type: secret.Type,
+				Data: secret.Data,
+			}
+			_, err := c.coreClient.Secrets(migration.To).Create(ctx, copied, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				klog.V(2).Infof("Secret %s already exists under project %s for user %s", secret.Name, migration.To, migration.User)
+				continue
+			} else if err != nil {
+				klog.Errorf("Error copying Secret %s from project %s to %s for user %s: %v", secret.Name, migration.From, migration.To, migration.User, err)
+				return err
+			}
+			klog.Infof("Copied Secret %s from project %s to %s for user %s", secret.Name, migration.From, migration.To, migration.User)
+		}
+	case MigrationCopyConfigMaps:
+		configMaps, err := c.coreClient.ConfigMaps(migration.From).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.Errorf("Error listing ConfigMaps under project %s for user %s: %v", migration.From, migration.User, err)
+			return err
+		}
+		for _, configMap := range configMaps.Items {
+			if clusterConfigMaps[configMap.Name] || generatedObject(&configMap) {
+				continue
+			}
+			copied := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        configMap.Name,
+					Namespace:   migration.To,
+					Labels:      configMap.Labels,
+					Annotations: configMap.Annotations,
+				},
+				Data:       configMap.Data,
+				BinaryData: configMap.BinaryData,
+			}
+			_, err := c.coreClient.ConfigMaps(migration.To).Create(ctx, copied, metav1.CreateOptions{})
+			if errors.IsAlreadyExists(err) {
+				klog.V(2).Infof("ConfigMap %s already exists under project %s for user %s", configMap.Name, migration.To, migration.User)
+				continue
+			} else if err != nil {
+				klog.Errorf("Error copying ConfigMap %s from project %s to %s for user %s: %v", configMap.Name, migration.From, migration.To, migration.User, err)
+				return err
+			}
+			klog.Infof("Copied ConfigMap %s from project %s to %s for user %s", configMap.Name, migration.From, migration.To, migration.User)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Returns a Controller managing the namespace alice-old of alice, named under a previous naming scheme
+func newMigrationController() *Controller {
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:       "alice-old",
+			Labels:     map[string]string{ownerLabel: "alice"},
+			Finalizers: []string{protectionFinalizer},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{ownerLabel: "bob"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "alice-old"}, Data: map[string][]byte{"password": []byte("secret")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "model-api-key", Namespace: "alice-old", Labels: seededLabels("alice")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:        "default-token",
+			Namespace:   "alice-old",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: "default"},
+		}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "alice-old"}, Data: map[string]string{"epochs": "3"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "alice-old"}},
+	)
+	return &Controller{
+		userClient: userfake.NewSimpleClientset(newGroup(GetTargetGroupName(), "alice", "bob")),
+		projectClient: projectfake.NewSimpleClientset(
+			&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice-old", Labels: map[string]string{ownerLabel: "alice", managedByLabel: componentName}}},
+		),
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+}
+
+func TestController_MigrateNamespaceDeletionPolicy(t *testing.T) {
+	t.Setenv("DELETION_GRACE_PERIOD", "168h")
+
+	ctx := context.Background()
+	controller := newMigrationController()
+	migration := NamingMigration{User: "alice", From: "alice-old", To: "alice"}
+	if err := controller.MigrateNamespace(ctx, migration, MigrationOptions{OldNamespaces: MigrationDeleteOld}); err != nil {
+		t.Fatalf("Expected namespace to be migrated, but got error: %v", err)
+	}
+
+	project, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice-old", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project alice-old to be kept for the grace period, but got error: %v", err)
+	}
+	if _, scheduled := scheduledDeletionTime(project); !scheduled {
+		t.Errorf("Expected deletion of project alice-old to be scheduled, but got %v", project.Annotations)
+	}
+
+	// the sweep deletes the old namespace once the grace period ended although alice is still a member
+	project.Annotations[scheduledDeletionAnnotation] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	project.Annotations[migratedToAnnotation] = "alice"
+	if _, err := controller.projectClient.ProjectV1().Projects().Update(ctx, project, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update project alice-old: %v", err)
+	}
+	controller.sweepScheduledDeletions(ctx)
+	if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice-old", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected project alice-old to be deleted by the sweep, but got error: %v", err)
+	}
+	if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be kept, but got error: %v", err)
+	}
+}
+
+func TestController_PlanNamingMigration(t *testing.T) {
+	migrations, err := newMigrationController().PlanNamingMigration(context.Background())
+	if err != nil {
+		t.Fatalf("Expected naming migration to be planned, but got error: %v", err)
+	}
+	if len(migrations) != 1 || migrations[0] != (NamingMigration{User: "alice", From: "alice-old", To: "alice"}) {
+		t.Errorf("Expected only alice-old to be migrated to alice, but got %+v", migrations)
+	}
+}
+
+func TestController_MigrateNamespace(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldNamespaces string
+		expectDeleted bool
+	}{
+		{
+			name:          "delete old namespaces",
+			oldNamespaces: MigrationDeleteOld,
+			expectDeleted: true,
+		},
+		{
+			name:          "keep old namespaces",
+			oldNamespaces: MigrationKeepOld,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			controller := newMigrationController()
+			migration := NamingMigration{User: "alice", From: "alice-old", To: "alice"}
+			opts := MigrationOptions{Copy: []string{MigrationCopySecrets, MigrationCopyConfigMaps}, OldNamespaces: tt.oldNamespaces}
+
+			if err := controller.MigrateNamespace(ctx, migration, opts); err != nil {
+				t.Fatalf("Expected namespace to be migrated, but got error: %v", err)
+			}
+
+			if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected project alice to be provisioned, but got error: %v", err)
+			}
+			if _, err := controller.coreClient.Secrets("alice").Get(ctx, "db-credentials", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected Secret db-credentials to be copied, but got error: %v", err)
+			}
+			if _, err := controller.coreClient.ConfigMaps("alice").Get(ctx, "settings", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected ConfigMap settings to be copied, but got error: %v", err)
+			}
+			for _, name := range []string{"model-api-key", "default-token"} {
+				if _, err := controller.coreClient.Secrets("alice").Get(ctx, name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+					t.Errorf("Expected Secret %s not to be copied, but got error: %v", name, err)
+				}
+			}
+			if _, err := controller.coreClient.ConfigMaps("alice").Get(ctx, "kube-root-ca.crt", metav1.GetOptions{}); !errors.IsNotFound(err) {
+				t.Errorf("Expected ConfigMap kube-root-ca.crt not to be copied, but got error: %v", err)
+			}
+
+			_, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice-old", metav1.GetOptions{})
+			if tt.expectDeleted != errors.IsNotFound(err) {
+				t.Errorf("Expected deletion of project alice-old to be %v, but got error: %v", tt.expectDeleted, err)
+			}
+			namespace, err := controller.coreClient.Namespaces().Get(ctx, "alice-old", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Failed to get namespace alice-old: %v", err)
+			}
+			if namespace.Annotations[migratedToAnnotation] != "alice" {
+				t.Errorf("Expected namespace alice-old to record its migration to alice, but got %v", namespace.Annotations)
+			}
+			if _, owned := namespace.Labels[ownerLabel]; owned == (tt.oldNamespaces == MigrationKeepOld) {
+				t.Errorf("Expected the owner label of namespace alice-old to be removed only when it is kept, but got %v", namespace.Labels)
+			}
+		})
+	}
+
+	if err := newMigrationController().MigrateNamespace(context.Background(), NamingMigration{User: "carol", From: "carol-old", To: "carol"}, MigrationOptions{OldNamespaces: MigrationDeleteOld}); err == nil {
+		t.Errorf("Expected migrating a user who is not a member to receive an error")
+	}
+}
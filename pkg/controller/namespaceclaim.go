@@ -0,0 +1,299 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// finalizer holding a NamespaceClaim until the namespace it claimed is deleted
+const claimFinalizer = "rosa-namespace-provisioner/namespace-claim"
+
+// label identifying the user who claimed a namespace. Claimed namespaces don't carry the owner
+// label, so they are never mistaken for the namespace provisioned for the user.
+const claimantLabel = "rosa-namespace-provisioner/claimant"
+
+// annotation of a claimed namespace holding the <namespace>/<name> of its NamespaceClaim
+const claimAnnotation = "rosa-namespace-provisioner/claim"
+
+// Reasons of the Ready condition of a NamespaceClaim
+const (
+	claimProvisionedReason     = "Provisioned"
+	claimNotManagedReason      = "NotInManagedNamespace"
+	claimNotMemberReason       = "NotAMember"
+	claimInvalidNameReason     = "InvalidName"
+	claimLimitReachedReason    = "LimitReached"
+	claimNamespaceTakenReason  = "NamespaceConflict"
+	claimProvisionFailedReason = "ProvisioningFailed"
+)
+
+// Creates an informer watching the NamespaceClaims of every namespace
+func newNamespaceClaimInformer(dynamicClient dynamic.Interface, watches *watchHealth) cache.SharedIndexInformer {
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			tuneListOptions(&options)
+			return dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			tuneListOptions(&options)
+			return dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).Watch(ctx, options)
+		},
+	}
+
+	return watches.newInformer("namespaceclaims", listWatcher, &unstructured.Unstructured{}, GetInformerResyncPeriod())
+}
+
+// Provisions or releases the namespace of a changed NamespaceClaim
+func (c *Controller) reconcileNamespaceClaimRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	defer observeReconcile("namespaceclaims", TriggerClaim, time.Now())
+	obj, exists, err := c.claimInformer.GetStore().GetByKey(request.String())
+	if err != nil || !exists {
+		return reconcile.Result{}, err
+	}
+	claim := &v1alpha1.NamespaceClaim{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, claim); err != nil {
+		klog.Errorf("Error decoding NamespaceClaim %s: %v", request, err)
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{}, c.handleNamespaceClaim(withTrigger(ctx, TriggerClaim), claim)
+}
+
+// Returns the name of the namespace claimed by the NamespaceClaim
+func claimedNamespaceName(claim *v1alpha1.NamespaceClaim) string {
+	return claim.Namespace + "-" + claim.Name
+}
+
+// Returns the <namespace>/<name> key of the NamespaceClaim
+func claimKey(claim *v1alpha1.NamespaceClaim) string {
+	return claim.Namespace + "/" + claim.Name
+}
+
+// Provisions the namespace claimed by a NamespaceClaim and the RoleBinding granting it to the owner
+// of the managed namespace the claim was created in, recording the outcome on the claim. Claims which
+// cannot be granted are reported on their Ready condition rather than retried.
+func (c *Controller) handleNamespaceClaim(ctx context.Context, claim *v1alpha1.NamespaceClaim) error {
+	if claim.DeletionTimestamp != nil {
+		return c.releaseNamespaceClaim(ctx, claim)
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: skipping NamespaceClaim %s", claimKey(claim))
+		return nil
+	}
+	if !hasFinalizer(claim, claimFinalizer) {
+		claim.Finalizers = append(claim.Finalizers, claimFinalizer)
+		if err := c.updateNamespaceClaim(ctx, claim); err != nil {
+			return err
+		}
+	}
+
+	name := claimedNamespaceName(claim)
+	user, reason, err := c.claimant(ctx, claim)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return c.updateNamespaceClaimStatus(ctx, claim, "", reason, fmt.Errorf("namespace %s cannot be claimed from namespace %s", name, claim.Namespace))
+	}
+	if !userProvisionable(user) {
+		return c.rejectNamespaceClaim(ctx, claim, user, fmt.Errorf("user %s is not allowed namespaces", user))
+	}
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s for NamespaceClaim %s: %v", membershipDescription(), claimKey(claim), err)
+		return err
+	}
+	if !members[user] {
+		return c.rejectNamespaceClaim(ctx, claim, user, fmt.Errorf("user %s is not granted a namespace by %s", user, membershipDescription()))
+	}
+	if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+		return c.updateNamespaceClaimStatus(ctx, claim, "", claimInvalidNameReason, fmt.Errorf("namespace %s is invalid: %s", name, msgs[0]))
+	}
+
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		claimed, err := c.claimedNamespaceCount(ctx, user)
+		if err != nil {
+			return err
+		}
+		if int64(claimed) >= GetNamespaceClaimsMax() {
+			return c.updateNamespaceClaimStatus(ctx, claim, "", claimLimitReachedReason, fmt.Errorf("user %s already claimed %d namespaces", user, claimed))
+		}
+		if err := c.createProject(ctx, user, desiredClaimedProject(claim, user)); err != nil {
+			klog.Errorf("Error creating project %s claimed by user %s: %v", name, user, err)
+			return c.failNamespaceClaim(ctx, claim, err)
+		}
+		klog.Infof("Successfully created project %s claimed by user %s", name, user)
+	} else if err != nil {
+		klog.Errorf("Error checking if project %s claimed by user %s exists: %v", name, user, err)
+		return c.failNamespaceClaim(ctx, claim, err)
+	} else if project.Annotations[claimAnnotation] != claimKey(claim) || !isManaged(project) {
+		return c.updateNamespaceClaimStatus(ctx, claim, "", claimNamespaceTakenReason, fmt.Errorf("namespace %s already exists", name))
+	}
+
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return c.failNamespaceClaim(ctx, claim, err)
+	}
+	roleBinding := desiredRoleBinding(user, name, clusterRole)
+	if err := c.createManagedRoleBinding(ctx, roleBinding); err != nil && !apierrors.IsAlreadyExists(err) {
+		klog.Errorf("Error creating RoleBinding %s for user %s under claimed project %s: %v", roleBinding.Name, user, name, err)
+		return c.failNamespaceClaim(ctx, claim, err)
+	}
+	return c.updateNamespaceClaimStatus(ctx, claim, name, claimProvisionedReason, nil)
+}
+
+// Returns the owner of the managed namespace the NamespaceClaim was created in, or the reason the
+// claim is rejected when it wasn't created in one
+func (c *Controller) claimant(ctx context.Context, claim *v1alpha1.NamespaceClaim) (string, string, error) {
+	namespace, err := c.coreClient.Namespaces().Get(ctx, claim.Namespace, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting namespace %s of NamespaceClaim %s: %v", claim.Namespace, claim.Name, err)
+		return "", "", err
+	}
+	if _, ok := namespace.Labels[ownerLabel]; !ok {
+		return "", claimNotManagedReason, nil
+	}
+	return objectOwner(namespace), "", nil
+}
+
+// Returns the number of namespaces the target user claimed
+func (c *Controller) claimedNamespaceCount(ctx context.Context, user string) (int, error) {
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: claimantLabel + "=" + ownerLabelValue(user),
+	})
+	if err != nil {
+		klog.Errorf("Error listing the projects claimed by user %s: %v", user, err)
+		return 0, err
+	}
+	return len(projects.Items), nil
+}
+
+// Returns the Project claimed by the NamespaceClaim for the target user
+func desiredClaimedProject(claim *v1alpha1.NamespaceClaim, user string) *projectv1.Project {
+	project := &projectv1.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: claimedNamespaceName(claim),
+			Labels: map[string]string{
+				claimantLabel:  ownerLabelValue(user),
+				managedByLabel: componentName,
+			},
+			Annotations: map[string]string{
+				ownerAnnotation: user,
+				claimAnnotation: claimKey(claim),
+			},
+		},
+	}
+	if claim.Spec.DisplayName != "" {
+		project.Annotations[displayNameAnnotation] = claim.Spec.DisplayName
+	}
+	return project
+}
+
+// Revokes the access of a user no longer granted namespaces to the namespace they claimed, keeping
+// the namespace until the claim is deleted, and reports the claim as rejected
+func (c *Controller) rejectNamespaceClaim(ctx context.Context, claim *v1alpha1.NamespaceClaim, user string, reason error) error {
+	name := claimedNamespaceName(claim)
+	if err := c.deleteManagedRoleBinding(ctx, user, name, roleBindingName(name)); err != nil {
+		return err
+	}
+	return c.updateNamespaceClaimStatus(ctx, claim, "", claimNotMemberReason, reason)
+}
+
+// Reports a failure to provision the claimed namespace, returning the error so it is retried
+func (c *Controller) failNamespaceClaim(ctx context.Context, claim *v1alpha1.NamespaceClaim, provisionErr error) error {
+	if err := c.updateNamespaceClaimStatus(ctx, claim, "", claimProvisionFailedReason, provisionErr); err != nil {
+		return err
+	}
+	return provisionErr
+}
+
+// Deletes the namespace claimed by a deleted NamespaceClaim, then releases the claim
+func (c *Controller) releaseNamespaceClaim(ctx context.Context, claim *v1alpha1.NamespaceClaim) error {
+	if !hasFinalizer(claim, claimFinalizer) {
+		return nil
+	}
+	if GetDryRunEnabled() {
+		klog.Infof("Dry run: keeping the namespace claimed by deleted NamespaceClaim %s", claimKey(claim))
+		return nil
+	}
+
+	name := claimedNamespaceName(claim)
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Errorf("Error getting project %s of deleted NamespaceClaim %s: %v", name, claimKey(claim), err)
+		return err
+	}
+	// a namespace the claim was rejected for is left alone
+	if err == nil && isManaged(project) && project.Annotations[claimAnnotation] == claimKey(claim) {
+		if err := c.projectClient.ProjectV1().Projects().Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error deleting project %s of deleted NamespaceClaim %s: %v", name, claimKey(claim), err)
+			return err
+		}
+		klog.Infof("Successfully deleted project %s of deleted NamespaceClaim %s", name, claimKey(claim))
+	}
+
+	claim.Finalizers = removeFinalizer(claim.Finalizers, claimFinalizer)
+	return c.updateNamespaceClaim(ctx, claim)
+}
+
+// Updates the NamespaceClaim, keeping the returned resource version for the status update
+func (c *Controller) updateNamespaceClaim(ctx context.Context, claim *v1alpha1.NamespaceClaim) error {
+	obj, err := toUnstructured(claim)
+	if err != nil {
+		return err
+	}
+	updated, err := c.dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).Namespace(claim.Namespace).Update(ctx, obj, metav1.UpdateOptions{})
+	if err != nil {
+		klog.Errorf("Error updating NamespaceClaim %s: %v", claimKey(claim), err)
+		return err
+	}
+	claim.ResourceVersion = updated.GetResourceVersion()
+	return nil
+}
+
+// Records the claimed namespace and the Ready condition on the NamespaceClaim, when they changed
+func (c *Controller) updateNamespaceClaimStatus(ctx context.Context, claim *v1alpha1.NamespaceClaim, namespace string, reason string, claimErr error) error {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: claim.Generation,
+		Reason:             reason,
+		Message:            fmt.Sprintf("Namespace %s is provisioned", namespace),
+	}
+	if claimErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = claimErr.Error()
+		klog.Warningf("NamespaceClaim %s is not ready: %v", claimKey(claim), claimErr)
+	}
+	changed := meta.SetStatusCondition(&claim.Status.Conditions, condition)
+	if !changed && claim.Status.Namespace == namespace && claim.Status.ObservedGeneration == claim.Generation {
+		return nil
+	}
+	claim.Status.Namespace = namespace
+	claim.Status.ObservedGeneration = claim.Generation
+
+	obj, err := toUnstructured(claim)
+	if err != nil {
+		return err
+	}
+	if _, err := c.dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).Namespace(claim.Namespace).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating NamespaceClaim %s status: %v", claimKey(claim), err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Creates the NamespaceClaim, returning it as read back from the client
+func createNamespaceClaim(t *testing.T, controller *Controller, namespace string, name string) *v1alpha1.NamespaceClaim {
+	t.Helper()
+	claim := &v1alpha1.NamespaceClaim{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: v1alpha1.NamespaceClaimKind},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1alpha1.NamespaceClaimSpec{DisplayName: "Experiments"},
+	}
+	obj, err := toUnstructured(claim)
+	if err != nil {
+		t.Fatalf("Failed to convert NamespaceClaim: %v", err)
+	}
+	if _, err := controller.dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).Namespace(namespace).Create(context.Background(), obj, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create NamespaceClaim %s/%s: %v", namespace, name, err)
+	}
+	return getNamespaceClaim(t, controller, namespace, name)
+}
+
+// Returns the NamespaceClaim with the given namespace and name
+func getNamespaceClaim(t *testing.T, controller *Controller, namespace string, name string) *v1alpha1.NamespaceClaim {
+	t.Helper()
+	obj, err := controller.dynamicClient.Resource(v1alpha1.NamespaceClaimsResource).Namespace(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected NamespaceClaim %s/%s to be found, but got error: %v", namespace, name, err)
+	}
+	claim := &v1alpha1.NamespaceClaim{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, claim); err != nil {
+		t.Fatalf("Failed to decode NamespaceClaim %s/%s: %v", namespace, name, err)
+	}
+	return claim
+}
+
+func TestController_handleNamespaceClaim(t *testing.T) {
+	t.Setenv("NAMESPACE_CLAIMS_MAX", "1")
+
+	ctx := withTrigger(context.Background(), TriggerClaim)
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{ownerLabel: "bob"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+	)
+	projectClient := projectfake.NewSimpleClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup(GetTargetGroupName(), "alice")),
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+	}
+
+	claim := createNamespaceClaim(t, controller, "alice", "experiments")
+	if err := controller.handleNamespaceClaim(ctx, claim); err != nil {
+		t.Fatalf("Expected the NamespaceClaim to be provisioned, but got error: %v", err)
+	}
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice-experiments", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project alice-experiments to be created, but got error: %v", err)
+	}
+	if project.Labels[claimantLabel] != "alice" || project.Labels[ownerLabel] != "" || project.Annotations[claimAnnotation] != "alice/experiments" {
+		t.Errorf("Expected project alice-experiments to be claimed by alice, but got labels %v and annotations %v", project.Labels, project.Annotations)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice-experiments").Get(ctx, roleBindingName("alice-experiments"), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of alice to be created, but got error: %v", err)
+	}
+	claim = getNamespaceClaim(t, controller, "alice", "experiments")
+	if !hasFinalizer(claim, claimFinalizer) || claim.Status.Namespace != "alice-experiments" || !meta.IsStatusConditionTrue(claim.Status.Conditions, v1alpha1.ConditionReady) {
+		t.Errorf("Expected the NamespaceClaim to be ready with its finalizer, but got %+v", claim)
+	}
+
+	// reconciling again changes nothing
+	if err := controller.handleNamespaceClaim(ctx, claim); err != nil {
+		t.Errorf("Expected the provisioned NamespaceClaim to be reconciled, but got error: %v", err)
+	}
+
+	// claims are rejected beyond the limit, outside managed namespaces and for users not in a target group
+	rejected := []struct {
+		namespace string
+		reason    string
+	}{
+		{namespace: "alice", reason: claimLimitReachedReason},
+		{namespace: "shared", reason: claimNotManagedReason},
+		{namespace: "bob", reason: claimNotMemberReason},
+	}
+	for _, tt := range rejected {
+		claim := createNamespaceClaim(t, controller, tt.namespace, "more")
+		if err := controller.handleNamespaceClaim(ctx, claim); err != nil {
+			t.Errorf("Expected the NamespaceClaim in %s to be rejected without error, but got: %v", tt.namespace, err)
+		}
+		claim = getNamespaceClaim(t, controller, tt.namespace, "more")
+		condition := meta.FindStatusCondition(claim.Status.Conditions, v1alpha1.ConditionReady)
+		if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != tt.reason {
+			t.Errorf("Expected the NamespaceClaim in %s to be rejected with reason %s, but got %+v", tt.namespace, tt.reason, condition)
+		}
+		if _, err := projectClient.ProjectV1().Projects().Get(ctx, tt.namespace+"-more", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected project %s-more not to be created, but got error: %v", tt.namespace, err)
+		}
+	}
+
+	// deleting the claim deletes the claimed namespace and releases the claim
+	claim = getNamespaceClaim(t, controller, "alice", "experiments")
+	claim.DeletionTimestamp = &metav1.Time{}
+	if err := controller.handleNamespaceClaim(ctx, claim); err != nil {
+		t.Fatalf("Expected the deleted NamespaceClaim to be released, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice-experiments", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project alice-experiments to be deleted, but got error: %v", err)
+	}
+	if claim := getNamespaceClaim(t, controller, "alice", "experiments"); hasFinalizer(claim, claimFinalizer) {
+		t.Errorf("Expected the finalizer to be removed from the deleted NamespaceClaim, but got %v", claim.Finalizers)
+	}
+}
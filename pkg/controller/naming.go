@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// length of the hash suffix keeping apart the sanitized names of users which only differ in characters
+// that aren't allowed in names
+const nameHashLength = 8
+
+// Returns the user name as it appears in the name of an object: unchanged when it is already a DNS
+// label of at most maxLength characters, e.g. alice, otherwise lowercased with runs of other characters
+// replaced with a dash, truncated and suffixed with a hash of the user name, e.g. alice@example.com
+// becomes alice-example-com-<hash>. The hash keeps Alice and alice, or alice.smith and alice_smith,
+// apart.
+func sanitizeName(user string, maxLength int) string {
+	if len(user) <= maxLength && len(validation.IsDNS1123Label(user)) == 0 {
+		return user
+	}
+	hash := nameHash(user)
+
+	var name strings.Builder
+	for _, r := range strings.ToLower(user) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			name.WriteRune(r)
+		case name.Len() > 0 && !strings.HasSuffix(name.String(), "-"):
+			name.WriteByte('-')
+		}
+	}
+	sanitized := name.String()
+	if limit := maxLength - nameHashLength - 1; len(sanitized) > limit {
+		sanitized = sanitized[:max(limit, 0)]
+	}
+	sanitized = strings.TrimRight(sanitized, "-")
+	if sanitized == "" {
+		return hash
+	}
+	return sanitized + "-" + hash
+}
+
+// Returns the hash suffix of names derived from the user name
+func nameHash(user string) string {
+	sum := sha256.Sum256([]byte(user))
+	return hex.EncodeToString(sum[:])[:nameHashLength]
+}
+
+// Returns the value of the owner label of the objects of the target user: the user name when it is a
+// valid label value, otherwise its sanitized form. The exact user name is kept in the owner annotation.
+func ownerLabelValue(user string) string {
+	if len(validation.IsValidLabelValue(user)) == 0 {
+		return user
+	}
+	return sanitizeName(user, validation.LabelValueMaxLength)
+}
+
+// Returns the user owning a managed object, read from the owner annotation and falling back to the
+// owner label of objects labeled before the annotation was set
+func objectOwner(obj metav1.Object) string {
+	if owner, ok := obj.GetAnnotations()[ownerAnnotation]; ok {
+		return owner
+	}
+	return obj.GetLabels()[ownerLabel]
+}
+
+// characters PROJECT_PREFIX and PROJECT_SUFFIX may contain, so they don't change the template
+var projectAffixPattern = regexp.MustCompile(`^[a-z0-9-]*$`)
+
+// annotation of a project named with a hash suffix as its name collided with a namespace its owner
+// doesn't own, holding the name it collided on
+const collidingNameAnnotation = "rosa-namespace-provisioner/colliding-name"
+
+// Returns the name given to the project of the target user instead of a name colliding with a
+// namespace they don't own: the name truncated to fit and suffixed with a hash of the user name, e.g.
+// sandbox-alice-<hash>. It is the same every time, so the project is found again.
+func collisionProjectName(name string, user string) string {
+	if limit := validation.DNS1123LabelMaxLength - nameHashLength - 1; len(name) > limit {
+		name = name[:limit]
+	}
+	return strings.TrimRight(name, "-") + "-" + nameHash(user)
+}
+
+// data a project name template is executed with
+type projectNameData struct {
+	// User is the user name, sanitized when it isn't a valid DNS label
+	User string
+}
+
+// the last parsed project name template, parsed again when PROJECT_NAME_TEMPLATE changes
+var projectNameTemplate struct {
+	sync.Mutex
+	source     string
+	parsed     *template.Template
+	userLength int
+}
+
+// Returns the parsed project name template, where {user} is shorthand for {{ .User }}, and the longest
+// user name it names a project of at most 63 characters with
+func parseProjectNameTemplate(source string) (*template.Template, int, error) {
+	projectNameTemplate.Lock()
+	defer projectNameTemplate.Unlock()
+	if projectNameTemplate.parsed != nil && projectNameTemplate.source == source {
+		return projectNameTemplate.parsed, projectNameTemplate.userLength, nil
+	}
+	parsed, err := template.New("project-name").
+		Option("missingkey=error").
+		Parse(strings.ReplaceAll(source, projectNameUserPlaceholder, "{{ .User }}"))
+	if err != nil {
+		return nil, 0, err
+	}
+	userLength, err := projectNameUserLength(parsed)
+	if err != nil {
+		return nil, 0, err
+	}
+	projectNameTemplate.source, projectNameTemplate.parsed, projectNameTemplate.userLength = source, parsed, userLength
+	return parsed, userLength, nil
+}
+
+// Executes the project name template for an already sanitized user name
+func executeProjectNameTemplate(tmpl *template.Template, user string) (string, error) {
+	var name strings.Builder
+	if err := tmpl.Execute(&name, projectNameData{User: user}); err != nil {
+		return "", err
+	}
+	return name.String(), nil
+}
+
+// Returns the longest the user name may be in the project name of the template, so the project name is
+// at most 63 characters long, or -1 when no user name fits. Templates may use the user name any number
+// of times, conditionally or formatted, so user names of every length are tried from the longest down.
+func projectNameUserLength(tmpl *template.Template) (int, error) {
+	for length := validation.DNS1123LabelMaxLength; length >= 0; length-- {
+		name, err := executeProjectNameTemplate(tmpl, strings.Repeat("u", length))
+		if err != nil {
+			return 0, err
+		}
+		if len(name) <= validation.DNS1123LabelMaxLength {
+			return length, nil
+		}
+	}
+	return -1, nil
+}
+
+// Names the project of the target user with the template, sanitizing the user name to fit, and checks
+// that the name is a valid namespace name
+func renderProjectName(source string, user string) (string, error) {
+	tmpl, userLength, err := parseProjectNameTemplate(source)
+	if err != nil {
+		return "", err
+	}
+	if userLength < 0 {
+		return "", fmt.Errorf("template %q leaves no room for the user name", source)
+	}
+	name, err := executeProjectNameTemplate(tmpl, sanitizeName(user, userLength))
+	if err != nil {
+		return "", err
+	}
+	if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+		return "", fmt.Errorf("template %q names project %q of user %s, which is not a valid namespace name: %s",
+			source, name, user, strings.Join(msgs, ", "))
+	}
+	return name, nil
+}
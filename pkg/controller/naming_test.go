@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_ProjectName(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		prefix   string
+		suffix   string
+		user     string
+		expected string
+	}{
+		{
+			name:     "valid user name is kept",
+			user:     "alice",
+			expected: `^alice$`,
+		},
+		{
+			name:     "email",
+			user:     "alice@example.com",
+			expected: `^alice-example-com-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "uppercase and colons",
+			user:     "Corp:Alice.Smith",
+			expected: `^corp-alice-smith-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "only invalid characters",
+			user:     "@@@",
+			expected: `^[0-9a-f]{8}$`,
+		},
+		{
+			name:     "long user name is truncated",
+			user:     strings.Repeat("a", 70),
+			expected: `^a{54}-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "go template suffix",
+			template: "{{ .User }}-dev",
+			user:     "alice",
+			expected: `^alice-dev$`,
+		},
+		{
+			name:     "go template prefix",
+			template: "ai-{{ .User }}",
+			user:     "alice@example.com",
+			expected: `^ai-alice-example-com-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "truncated to fit the template",
+			template: "sandbox-{user}",
+			user:     strings.Repeat("b", 60) + "@example.com",
+			expected: `^sandbox-b{46}-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "truncated to fit every use in the template",
+			template: "{{ .User }}-{{ .User }}",
+			user:     strings.Repeat("c", 40),
+			expected: `^c{22}-[0-9a-f]{8}-c{22}-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "conditional template",
+			template: `{{ if gt (len .User) 20 }}{{ .User }}{{ else }}dev-{{ .User }}{{ end }}`,
+			user:     strings.Repeat("d", 70),
+			expected: `^d{54}-[0-9a-f]{8}$`,
+		},
+		{
+			name:     "formatted template",
+			template: `{{ printf "%.10s" .User }}-dev`,
+			user:     "alice@example.com",
+			expected: `^alice-exam-dev$`,
+		},
+		{
+			name:     "prefix and suffix",
+			prefix:   "sandbox-",
+			suffix:   "-dev",
+			user:     "alice",
+			expected: `^sandbox-alice-dev$`,
+		},
+		{
+			name:     "prefix around a template",
+			template: "{{ .User }}-ws",
+			prefix:   "ai-",
+			user:     strings.Repeat("e", 70),
+			expected: `^ai-e{48}-[0-9a-f]{8}-ws$`,
+		},
+	}
+
+	controller := &Controller{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROJECT_NAME_TEMPLATE", tt.template)
+			t.Setenv("PROJECT_PREFIX", tt.prefix)
+			t.Setenv("PROJECT_SUFFIX", tt.suffix)
+
+			got := controller.ProjectName(tt.user)
+			if !regexp.MustCompile(tt.expected).MatchString(got) {
+				t.Errorf("Expected project name matching %s, but got %s", tt.expected, got)
+			}
+			for _, msg := range validation.IsDNS1123Label(got) {
+				t.Errorf("Expected a valid namespace name, but got %s: %s", got, msg)
+			}
+		})
+	}
+
+	// User names differing only in invalid characters get different projects
+	if controller.ProjectName("alice.smith") == controller.ProjectName("alice_smith") {
+		t.Errorf("Expected alice.smith and alice_smith to get different projects")
+	}
+	if controller.ProjectName("Alice") == controller.ProjectName("alice") {
+		t.Errorf("Expected Alice and alice to get different projects")
+	}
+}
+
+func TestRenderProjectName_invalidName(t *testing.T) {
+	// Names which aren't valid namespace names are rejected instead of failing the project creation
+	if name, err := renderProjectName("{{ .User }}-", "alice"); err == nil {
+		t.Errorf("Expected an error for invalid project name, but got %s", name)
+	}
+}
+
+func TestController_provisionUserWithEmail(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	quotaClient := quotafake.NewClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		quotaClient:   quotaClient,
+		coreClient:    kubeClient.CoreV1(),
+	}
+	user := "Alice@Example.com"
+	projectName := controller.ProjectName(user)
+
+	// The project is named and labeled with the sanitized user name, and annotated with the exact one
+	if err := controller.provisionUser(ctx, user); err != nil {
+		t.Fatalf("Expected user %s to be provisioned, but got error: %v", user, err)
+	}
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project %s to exist, but got error: %v", projectName, err)
+	}
+	label := project.Labels[ownerLabel]
+	for _, msg := range validation.IsValidLabelValue(label) {
+		t.Errorf("Expected a valid owner label, but got %s: %s", label, msg)
+	}
+	if owner := objectOwner(project); owner != user {
+		t.Errorf("Expected project %s to be owned by %s, but got %s", projectName, user, owner)
+	}
+	quota, err := quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, clusterResourceQuotaName(user), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the ClusterResourceQuota of %s to exist, but got error: %v", user, err)
+	}
+	if got := quota.Spec.Selector.LabelSelector.MatchLabels[ownerLabel]; got != label {
+		t.Errorf("Expected the ClusterResourceQuota to select owner %s, but got %s", label, got)
+	}
+
+	// Deprovisioning finds the project under the same name
+	if err := controller.deprovisionUser(ctx, user); err != nil {
+		t.Fatalf("Expected user %s to be deprovisioned, but got error: %v", user, err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected project %s to be deleted, but got error: %v", projectName, err)
+	}
+}
+
+func TestController_provisionUserRenamesCollidingProject(t *testing.T) {
+	t.Setenv("PROJECT_PREFIX", "sandbox-")
+	t.Setenv("EXISTING_PROJECT_POLICY", "rename")
+
+	ctx := context.Background()
+	// a namespace the controller doesn't manage already has the name of alice's project
+	projectClient := projectfake.NewSimpleClientset(&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "sandbox-alice"}})
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	renamed := "sandbox-alice-" + nameHash("alice")
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, renamed, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project %s to be created, but got error: %v", renamed, err)
+	}
+	if got := project.Annotations[collidingNameAnnotation]; got != "sandbox-alice" {
+		t.Errorf("Expected the colliding name to be recorded, but got %q", got)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings(renamed).Get(ctx, roleBindingName(renamed), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of alice under %s, but got error: %v", renamed, err)
+	}
+	existing, err := projectClient.ProjectV1().Projects().Get(ctx, "sandbox-alice", metav1.GetOptions{})
+	if err != nil || objectOwner(existing) != "" {
+		t.Errorf("Expected namespace sandbox-alice to be left alone, but got %v (%v)", existing, err)
+	}
+
+	// The renamed project is found again, even once the colliding namespace is gone
+	if err := projectClient.ProjectV1().Projects().Delete(ctx, "sandbox-alice", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete project sandbox-alice: %v", err)
+	}
+	if got := controller.ProjectName("alice"); got != renamed {
+		t.Errorf("Expected the project of alice to keep the name %s, but got %s", renamed, got)
+	}
+}
@@ -0,0 +1,246 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// label selecting the namespaces of the OpenShift ingress controllers
+const ingressPolicyGroupLabel = "policy-group.network.openshift.io/ingress"
+
+// WithNetworkingClient enables seeding NetworkPolicies into every user project through the given client
+func WithNetworkingClient(networkingClient networkingv1client.NetworkingV1Interface) Option {
+	return func(c *Controller) {
+		c.networkingClient = networkingClient
+	}
+}
+
+// Returns the baseline NetworkPolicies seeded when no template is configured: ingress is denied
+// except from pods of the same namespace and from the OpenShift routers
+func defaultNetworkPolicies() []networkingv1.NetworkPolicy {
+	return []networkingv1.NetworkPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "deny-all-ingress"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-same-namespace"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-from-openshift-ingress"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{ingressPolicyGroupLabel: ""},
+					}}},
+				}},
+			},
+		},
+	}
+}
+
+// Reads the NetworkPolicy templates seeded into every managed namespace from a multi-document YAML
+// file, or returns the baseline policies when no file is configured. The file is typically a
+// ConfigMap mounted into the controller, so changes to it are picked up on the next reconciliation.
+func loadNetworkPolicyTemplates(path string) ([]networkingv1.NetworkPolicy, error) {
+	if path == "" {
+		return defaultNetworkPolicies(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []networkingv1.NetworkPolicy
+	names := make(map[string]bool)
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for {
+		document, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read NetworkPolicy templates %s: %w", path, err)
+		}
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		template := networkingv1.NetworkPolicy{}
+		if err := yaml.UnmarshalStrict(document, &template); err != nil {
+			return nil, fmt.Errorf("failed to parse NetworkPolicy template %d of %s: %w", len(templates)+1, path, err)
+		}
+		if template.Kind != "" && template.Kind != "NetworkPolicy" {
+			return nil, fmt.Errorf("NetworkPolicy template %d of %s has kind %s", len(templates)+1, path, template.Kind)
+		}
+		if msgs := validation.IsDNS1123Subdomain(template.Name); len(msgs) > 0 {
+			return nil, fmt.Errorf("NetworkPolicy template %d of %s has an invalid name %q: %s", len(templates)+1, path, template.Name, strings.Join(msgs, ", "))
+		}
+		if names[template.Name] {
+			return nil, fmt.Errorf("NetworkPolicy template %s is defined more than once in %s", template.Name, path)
+		}
+		names[template.Name] = true
+		templates = append(templates, template)
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("NetworkPolicy templates %s define no policies", path)
+	}
+	return templates, nil
+}
+
+// Returns the NetworkPolicies isolating the namespace of the target user from the workloads of
+// other users
+func desiredNetworkPolicies(user string, projectName string) ([]*networkingv1.NetworkPolicy, error) {
+	templates, err := loadNetworkPolicyTemplates(GetNetworkPoliciesFile())
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]*networkingv1.NetworkPolicy, 0, len(templates))
+	for _, template := range templates {
+		policies = append(policies, &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      template.Name,
+				Namespace: projectName,
+				Labels:    seededLabels(user),
+			},
+			Spec: template.Spec,
+		})
+	}
+	return policies, nil
+}
+
+// Creates the seeded NetworkPolicies under the target user project, restores the policies that were
+// modified and prunes those removed from the templates
+func (c *Controller) createNetworkPolicies(ctx context.Context, user string, projectName string) error {
+	if c.networkingClient == nil {
+		return errors.New("no networking client configured")
+	}
+	policies, err := desiredNetworkPolicies(user, projectName)
+	if err != nil {
+		klog.Errorf("Error building NetworkPolicies for user %s: %v", user, err)
+		return err
+	}
+
+	exceptions, err := c.policyExceptions(ctx, projectName)
+	if err != nil {
+		return err
+	}
+
+	desired := make(map[string]bool, len(policies))
+	for _, policy := range policies {
+		desired[policy.Name] = true
+		if findPolicyException(exceptions, "NetworkPolicy", policy.Name) != nil {
+			klog.V(2).Infof("Leaving NetworkPolicy %s for user %s under project %s as found, an exception was approved", policy.Name, user, projectName)
+			continue
+		}
+		if err := c.syncNetworkPolicy(ctx, user, projectName, policy); err != nil {
+			return err
+		}
+	}
+	return c.pruneSeededNetworkPolicies(ctx, user, projectName, desired)
+}
+
+// Creates a single seeded NetworkPolicy under the target user project, or restores its spec when it
+// was modified
+func (c *Controller) syncNetworkPolicy(ctx context.Context, user string, projectName string, policy *networkingv1.NetworkPolicy) error {
+	existing, err := c.networkingClient.NetworkPolicies(projectName).Get(ctx, policy.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.networkingClient.NetworkPolicies(projectName).Patch, policy, networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"))
+			} else {
+				_, err = c.networkingClient.NetworkPolicies(projectName).Create(ctx, policy, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating NetworkPolicy %s for user %s under project %s: %v", policy.Name, user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created NetworkPolicy %s for user %s under project %s", policy.Name, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if NetworkPolicy %s exists for user %s under project %s: %v", policy.Name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite NetworkPolicies that were not seeded by the controller
+	if existing.Labels[partOfLabel] != seededSet {
+		err := fmt.Errorf("NetworkPolicy %s under project %s is not managed by the controller and will not be overwritten", policy.Name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	released := releaseAnchorReference(existing)
+	if !released && equality.Semantic.DeepEqual(existing.Spec, policy.Spec) {
+		klog.V(2).Infof("NetworkPolicy %s under project %s already exist for user %s", policy.Name, projectName, user)
+		return nil
+	}
+
+	// the anchor reference is released by an update, which an apply would leave in place
+	if GetServerSideApplyEnabled() && !released {
+		_, err = applyObject(ctx, c.networkingClient.NetworkPolicies(projectName).Patch, policy, networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"))
+	} else {
+		existing.Spec = policy.Spec
+		_, err = c.networkingClient.NetworkPolicies(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating NetworkPolicy %s for user %s under project %s: %v", policy.Name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated NetworkPolicy %s for user %s under project %s", policy.Name, user, projectName)
+	return nil
+}
+
+// Returns the drift of the seeded NetworkPolicies under the target user project, other than those
+// excepted
+func (c *Controller) networkPolicyDrift(ctx context.Context, user string, projectName string, exceptions []v1alpha1.PolicyException) ([]string, error) {
+	if c.networkingClient == nil {
+		return nil, nil
+	}
+	policies, err := desiredNetworkPolicies(user, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []string
+	for _, policy := range policies {
+		if findPolicyException(exceptions, "NetworkPolicy", policy.Name) != nil {
+			continue
+		}
+		existing, err := c.networkingClient.NetworkPolicies(projectName).Get(ctx, policy.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			drift = append(drift, fmt.Sprintf("NetworkPolicy %s is missing", policy.Name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !equality.Semantic.DeepEqual(existing.Spec, policy.Spec) {
+			drift = append(drift, fmt.Sprintf("NetworkPolicy %s does not apply the desired rules", policy.Name))
+		}
+	}
+	return drift, nil
+}
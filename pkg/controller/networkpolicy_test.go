@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const networkPolicyTemplates = `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: deny-all-ingress
+spec:
+  policyTypes: [Ingress]
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-from-monitoring
+spec:
+  policyTypes: [Ingress]
+  ingress:
+  - from:
+    - namespaceSelector:
+        matchLabels:
+          network.openshift.io/policy-group: monitoring
+`
+
+func TestLoadNetworkPolicyTemplates(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write NetworkPolicy templates: %v", err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		expected    []string
+		shouldError bool
+	}{
+		{name: "baseline", path: "", expected: []string{"deny-all-ingress", "allow-same-namespace", "allow-from-openshift-ingress"}},
+		{name: "templates", path: write("valid.yaml", networkPolicyTemplates), expected: []string{"deny-all-ingress", "allow-from-monitoring"}},
+		{name: "duplicate name", path: write("duplicate.yaml", networkPolicyTemplates+"---\n"+networkPolicyTemplates), shouldError: true},
+		{name: "missing name", path: write("unnamed.yaml", "kind: NetworkPolicy\nspec: {}\n"), shouldError: true},
+		{name: "other kind", path: write("kind.yaml", "kind: LimitRange\nmetadata:\n  name: limits\n"), shouldError: true},
+		{name: "no policies", path: write("empty.yaml", "---\n"), shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := loadNetworkPolicyTemplates(tt.path)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected templates %s to be rejected", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected templates to load, but got error: %v", err)
+			}
+			var names []string
+			for _, template := range templates {
+				names = append(names, template.Name)
+			}
+			if len(names) != len(tt.expected) {
+				t.Fatalf("Expected policies %v, but got %v", tt.expected, names)
+			}
+			for i := range names {
+				if names[i] != tt.expected[i] {
+					t.Errorf("Expected policies %v, but got %v", tt.expected, names)
+				}
+			}
+		})
+	}
+}
+
+func TestController_createNetworkPolicies(t *testing.T) {
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deny-all-ingress",
+			Namespace: "bob",
+		},
+	})
+	controller := &Controller{
+		coreClient:       kubeClient.CoreV1(),
+		networkingClient: kubeClient.NetworkingV1(),
+	}
+	policies := kubeClient.NetworkingV1().NetworkPolicies("alice")
+
+	if err := controller.createNetworkPolicies(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected NetworkPolicies to be created, but got error: %v", err)
+	}
+	for _, template := range defaultNetworkPolicies() {
+		policy, err := policies.Get(ctx, template.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected NetworkPolicy %s to exist, but got error: %v", template.Name, err)
+		}
+		if policy.Labels[partOfLabel] != seededSet || policy.Labels[ownerLabel] != "alice" {
+			t.Errorf("Expected NetworkPolicy %s to be labeled as seeded for alice, but got %v", template.Name, policy.Labels)
+		}
+	}
+
+	// Modified policies are restored
+	policy, err := policies.Get(ctx, "allow-same-namespace", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected NetworkPolicy allow-same-namespace to exist, but got error: %v", err)
+	}
+	policy.Spec.Ingress = nil
+	if _, err := policies.Update(ctx, policy, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update NetworkPolicy allow-same-namespace: %v", err)
+	}
+	if drift, err := controller.networkPolicyDrift(ctx, "alice", "alice", nil); err != nil || len(drift) != 1 {
+		t.Errorf("Expected the modified NetworkPolicy to be reported as drift, but got %v, %v", drift, err)
+	}
+	if err := controller.createNetworkPolicies(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected NetworkPolicies to be restored, but got error: %v", err)
+	}
+	if policy, err := policies.Get(ctx, "allow-same-namespace", metav1.GetOptions{}); err != nil || len(policy.Spec.Ingress) != 1 {
+		t.Errorf("Expected NetworkPolicy allow-same-namespace to be restored, but got %+v, %v", policy, err)
+	}
+
+	// Policies removed from the templates are pruned
+	path := filepath.Join(t.TempDir(), "networkpolicies.yaml")
+	if err := os.WriteFile(path, []byte(networkPolicyTemplates), 0o644); err != nil {
+		t.Fatalf("Failed to write NetworkPolicy templates: %v", err)
+	}
+	t.Setenv("NETWORK_POLICIES_FILE", path)
+	if err := controller.createNetworkPolicies(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected NetworkPolicies to be synced with the templates, but got error: %v", err)
+	}
+	for _, name := range []string{"allow-same-namespace", "allow-from-openshift-ingress"} {
+		if _, err := policies.Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+			t.Errorf("Expected NetworkPolicy %s to be pruned", name)
+		}
+	}
+	if _, err := policies.Get(ctx, "allow-from-monitoring", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected NetworkPolicy allow-from-monitoring to be created, but got error: %v", err)
+	}
+
+	// NetworkPolicies which were not seeded are never overwritten
+	if err := controller.createNetworkPolicies(ctx, "bob", "bob"); err == nil {
+		t.Errorf("Expected the unmanaged NetworkPolicy of project bob to be kept")
+	}
+}
@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/klog/v2"
+)
+
+// Ways onboarding artifacts are delivered to users
+const (
+	OnboardingDeliverySecret       = "secret"
+	OnboardingDeliveryNotification = "notification"
+)
+
+// name of the Secret holding the onboarding artifact in every managed namespace
+const onboardingSecretName = "sandbox-onboarding"
+
+// value of the partOfLabel for the onboarding Secret, kept apart from the seeded set so the pruning of
+// materialized Secrets, which are seeded under any name, never removes it
+const onboardingSet = "onboarding"
+
+// annotation recording when the onboarding notification was sent for a managed namespace, so it is
+// only sent once
+const onboardingNotifiedAnnotation = "rosa-namespace-provisioner/onboarding-notified"
+
+// Returns whether onboarding artifacts are delivered the given way
+func onboardingDelivered(delivery string) bool {
+	return slices.Contains(GetOnboardingDelivery(), delivery)
+}
+
+// Returns the name oc login gives the cluster of an API server in kubeconfigs, e.g.
+// api-my-rosa-example-com:443, so the snippet merges with the user's own kubeconfig
+func kubeconfigClusterName(apiURL string) (string, error) {
+	parsed, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Host == "" {
+		return "", fmt.Errorf("missing host in API URL %q", apiURL)
+	}
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host += ":443"
+	}
+	return strings.ReplaceAll(host, ".", "-"), nil
+}
+
+// Returns a kubeconfig selecting the target user project on the cluster, without credentials: the
+// user adds their own token by logging in with it
+func onboardingKubeconfig(user string, projectName string) ([]byte, error) {
+	apiURL := GetOnboardingAPIURL()
+	cluster, err := kubeconfigClusterName(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	authInfo := user + "/" + cluster
+	contextName := projectName + "/" + cluster + "/" + user
+
+	config := clientcmdapi.NewConfig()
+	config.Clusters[cluster] = &clientcmdapi.Cluster{Server: apiURL}
+	config.AuthInfos[authInfo] = &clientcmdapi.AuthInfo{}
+	config.Contexts[contextName] = &clientcmdapi.Context{
+		Cluster:   cluster,
+		AuthInfo:  authInfo,
+		Namespace: projectName,
+	}
+	config.CurrentContext = contextName
+	return clientcmd.Write(*config)
+}
+
+// Returns the instructions explaining the target user how to access their project
+func onboardingInstructions(user string, projectName string, clusterRole string) string {
+	apiURL := GetOnboardingAPIURL()
+	var b strings.Builder
+	fmt.Fprintf(&b, "Your sandbox namespace %s is ready, %s.\n\n", projectName, user)
+	fmt.Fprintf(&b, "API server: %s\n", apiURL)
+	if consoleURL := GetOnboardingConsoleURL(); consoleURL != "" {
+		fmt.Fprintf(&b, "Web console: %s/k8s/cluster/projects/%s\n", consoleURL, projectName)
+	}
+	fmt.Fprintf(&b, "Role: %s\n", clusterRole)
+	b.WriteString("\nTo use it from the command line:\n\n")
+	fmt.Fprintf(&b, "  oc login --web --server=%s\n", apiURL)
+	fmt.Fprintf(&b, "  oc project %s\n", projectName)
+	fmt.Fprintf(&b, "\nOr save the kubeconfig key of the %s Secret in your namespace to a file and log in with it:\n\n", onboardingSecretName)
+	fmt.Fprintf(&b, "  oc login --web --kubeconfig=sandbox.kubeconfig --server=%s\n", apiURL)
+	return b.String()
+}
+
+// Returns the Secret holding the onboarding artifact of the target user project
+func desiredOnboardingSecret(user string, projectName string, clusterRole string) (*corev1.Secret, error) {
+	kubeconfig, err := onboardingKubeconfig(user, projectName)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      onboardingSecretName,
+			Namespace: projectName,
+			Labels: map[string]string{
+				ownerLabel:  ownerLabelValue(user),
+				partOfLabel: onboardingSet,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"api-url":      []byte(GetOnboardingAPIURL()),
+			"namespace":    []byte(projectName),
+			"kubeconfig":   kubeconfig,
+			"instructions": []byte(onboardingInstructions(user, projectName, clusterRole)),
+		},
+	}, nil
+}
+
+// Delivers the onboarding artifact of the target user project as configured
+func (c *Controller) deliverOnboarding(ctx context.Context, user string, projectName string) error {
+	if onboardingDelivered(OnboardingDeliverySecret) {
+		if err := c.syncOnboardingSecret(ctx, user, projectName); err != nil {
+			return err
+		}
+	}
+	if onboardingDelivered(OnboardingDeliveryNotification) {
+		return c.notifyOnboarding(ctx, user, projectName)
+	}
+	return nil
+}
+
+// Creates the onboarding Secret under the target user project, restoring it when modified
+func (c *Controller) syncOnboardingSecret(ctx context.Context, user string, projectName string) error {
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return err
+	}
+	secret, err := desiredOnboardingSecret(user, projectName, clusterRole)
+	if err != nil {
+		klog.Errorf("Error building onboarding Secret for user %s: %v", user, err)
+		return err
+	}
+
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(secret, anchorRef)
+
+	existing, err := c.coreClient.Secrets(projectName).Get(ctx, onboardingSecretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+			} else {
+				_, err = c.coreClient.Secrets(projectName).Create(ctx, secret, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating onboarding Secret %s for user %s under project %s: %v", onboardingSecretName, user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created onboarding Secret %s for user %s under project %s", onboardingSecretName, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if onboarding Secret %s exists for user %s under project %s: %v", onboardingSecretName, user, projectName, err)
+		return err
+	}
+
+	// never overwrite Secrets that were not seeded by the controller. Earlier releases seeded the
+	// onboarding Secret in the seeded set, from which it is moved.
+	set := existing.Labels[partOfLabel]
+	if set != onboardingSet && set != seededSet {
+		err := fmt.Errorf("Secret %s under project %s is not managed by the controller and will not be overwritten", onboardingSecretName, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	adopted := setAnchorReference(existing, anchorRef)
+	if !adopted && set == onboardingSet && equality.Semantic.DeepEqual(existing.Data, secret.Data) {
+		klog.V(2).Infof("Onboarding Secret %s under project %s already exist for user %s", onboardingSecretName, projectName, user)
+		return nil
+	}
+
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+	} else {
+		existing.Data = secret.Data
+		existing.Labels[partOfLabel] = onboardingSet
+		_, err = c.coreClient.Secrets(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating onboarding Secret %s for user %s under project %s: %v", onboardingSecretName, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated onboarding Secret %s for user %s under project %s", onboardingSecretName, user, projectName)
+	return nil
+}
+
+// Sends the onboarding instructions to the target user once per namespace. A failed notification
+// doesn't fail provisioning and is retried on the next reconcile.
+func (c *Controller) notifyOnboarding(ctx context.Context, user string, projectName string) error {
+	if c.notifier == nil {
+		klog.Warningf("Skipping onboarding notification for user %s as no notification provider is configured", user)
+		return nil
+	}
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	if _, ok := namespace.Annotations[onboardingNotifiedAnnotation]; ok {
+		return nil
+	}
+
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return err
+	}
+	email, err := c.userEmail(ctx, user)
+	if err != nil {
+		klog.Errorf("Error resolving the email address of user %s: %v", user, err)
+		return err
+	}
+
+	err = c.notifier.Notify(ctx, notify.Notification{
+		User:      user,
+		Email:     email,
+		Namespace: projectName,
+		Severity:  notify.SeverityInfo,
+		Subject:   fmt.Sprintf("Your sandbox namespace %s is ready", projectName),
+		Message:   onboardingInstructions(user, projectName, clusterRole),
+	})
+	if err != nil {
+		klog.Errorf("Error notifying user %s about onboarding: %v", user, err)
+		return nil
+	}
+
+	err = c.updateNamespace(ctx, projectName, func(namespace *corev1.Namespace) {
+		if namespace.Annotations == nil {
+			namespace.Annotations = make(map[string]string)
+		}
+		namespace.Annotations[onboardingNotifiedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	})
+	if err != nil {
+		klog.Errorf("Error recording onboarding notification on namespace %s for user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Sent onboarding instructions to user %s for project %s", user, projectName)
+	return nil
+}
+
+// Returns the email address of the target user from NOTIFICATION_EMAIL_CONFIGMAP, else from the email
+// of their identities when NOTIFICATION_EMAIL_FROM_IDENTITY is set, or "" to leave it to the email
+// provider
+func (c *Controller) userEmail(ctx context.Context, user string) (string, error) {
+	namespace, name, err := GetNotificationEmailConfigMap()
+	if err != nil {
+		return "", err
+	}
+	if name != "" {
+		configMap, err := c.coreClient.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return "", err
+		}
+		if err == nil {
+			if email := strings.TrimSpace(configMap.Data[user]); email != "" {
+				return email, nil
+			}
+		}
+	}
+
+	if !GetNotificationEmailFromIdentity() {
+		return "", nil
+	}
+	userObj, err := c.userClient.UserV1().Users().Get(ctx, user, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	for _, identityName := range userObj.Identities {
+		identity, err := c.userClient.UserV1().Identities().Get(ctx, identityName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if email := identity.Extra["email"]; email != "" {
+			return email, nil
+		}
+	}
+	return "", nil
+}
+
+// Returns the drift of the onboarding Secret under the target user project, if any
+func (c *Controller) onboardingDrift(ctx context.Context, user string, projectName string) (string, error) {
+	clusterRole, err := c.userClusterRole(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	secret, err := desiredOnboardingSecret(user, projectName, clusterRole)
+	if err != nil {
+		return "", err
+	}
+	existing, err := c.coreClient.Secrets(projectName).Get(ctx, onboardingSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Sprintf("Secret %s is missing", onboardingSecretName), nil
+	} else if err != nil {
+		return "", err
+	}
+	if !equality.Semantic.DeepEqual(existing.Data, secret.Data) {
+		return fmt.Sprintf("Secret %s does not hold the onboarding artifact", onboardingSecretName), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+func TestKubeconfigClusterName(t *testing.T) {
+	tests := []struct {
+		apiURL   string
+		expected string
+	}{
+		{apiURL: "https://api.my-rosa.example.com:6443", expected: "api-my-rosa-example-com:6443"},
+		{apiURL: "https://api.my-rosa.example.com", expected: "api-my-rosa-example-com:443"},
+	}
+
+	for _, tt := range tests {
+		got, err := kubeconfigClusterName(tt.apiURL)
+		if err != nil {
+			t.Fatalf("Expected a cluster name for %s, but got error: %v", tt.apiURL, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Expected cluster name %s for %s, but got %s", tt.expected, tt.apiURL, got)
+		}
+	}
+}
+
+func TestController_syncOnboardingSecret(t *testing.T) {
+	t.Setenv("ONBOARDING_API_URL", "https://api.my-rosa.example.com:443")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: onboardingSecretName, Namespace: "carol"},
+	})
+	controller := &Controller{coreClient: kubeClient.CoreV1()}
+
+	if err := controller.syncOnboardingSecret(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected onboarding Secret to be created, but got error: %v", err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, onboardingSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected onboarding Secret to exist, but got error: %v", err)
+	}
+	config, err := clientcmd.Load(secret.Data["kubeconfig"])
+	if err != nil {
+		t.Fatalf("Expected a valid kubeconfig, but got error: %v", err)
+	}
+	current := config.Contexts[config.CurrentContext]
+	if current == nil || current.Namespace != "alice" || config.Clusters[current.Cluster].Server != "https://api.my-rosa.example.com:443" {
+		t.Errorf("Expected the kubeconfig to select namespace alice on the API server, but got %v", current)
+	}
+	if !strings.Contains(string(secret.Data["instructions"]), "oc project alice") {
+		t.Errorf("Expected instructions to switch to project alice, but got %q", secret.Data["instructions"])
+	}
+
+	// A modified artifact is reported as drift and restored
+	secret.Data["kubeconfig"] = []byte("modified")
+	if _, err := kubeClient.CoreV1().Secrets("alice").Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update onboarding Secret: %v", err)
+	}
+	if drift, err := controller.onboardingDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the modified Secret to be reported as drift, but got %q, %v", drift, err)
+	}
+	if err := controller.syncOnboardingSecret(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected onboarding Secret to be restored, but got error: %v", err)
+	}
+	if drift, err := controller.onboardingDrift(ctx, "alice", "alice"); err != nil || drift != "" {
+		t.Errorf("Expected no drift once restored, but got %q, %v", drift, err)
+	}
+
+	// Secrets which were not seeded are never overwritten
+	if err := controller.syncOnboardingSecret(ctx, "carol", "carol"); err == nil {
+		t.Errorf("Expected the unmanaged Secret of project carol to be kept")
+	}
+}
+
+func TestController_notifyOnboarding(t *testing.T) {
+	t.Setenv("ONBOARDING_API_URL", "https://api.my-rosa.example.com:443")
+	t.Setenv("ONBOARDING_CONSOLE_URL", "https://console.apps.my-rosa.example.com/")
+
+	ctx := context.Background()
+	notifier := &fakeNotifier{}
+	controller := &Controller{
+		coreClient: fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice"}}).CoreV1(),
+		notifier:   notifier,
+	}
+
+	// The instructions are only sent once per namespace
+	for i := 0; i < 2; i++ {
+		if err := controller.notifyOnboarding(ctx, "alice", "alice"); err != nil {
+			t.Fatalf("Expected onboarding notification to be sent, but got error: %v", err)
+		}
+	}
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("Expected a single onboarding notification, but got %d", len(notifier.notifications))
+	}
+	if message := notifier.notifications[0].Message; !strings.Contains(message, "https://console.apps.my-rosa.example.com/k8s/cluster/projects/alice") {
+		t.Errorf("Expected the instructions to link the project in the console, but got %q", message)
+	}
+	if message := notifier.notifications[0].Message; !strings.Contains(message, "Role: edit") {
+		t.Errorf("Expected the instructions to name the granted ClusterRole, but got %q", message)
+	}
+}
+
+func TestController_userEmail(t *testing.T) {
+	t.Setenv("NOTIFICATION_EMAIL_CONFIGMAP", "provisioner/emails")
+	t.Setenv("NOTIFICATION_EMAIL_FROM_IDENTITY", "true")
+
+	ctx := context.Background()
+	controller := &Controller{
+		coreClient: fake.NewClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "emails", Namespace: "provisioner"},
+			Data:       map[string]string{"alice": "alice.liddell@example.org"},
+		}).CoreV1(),
+		userClient: userfake.NewSimpleClientset(
+			&userv1.User{ObjectMeta: metav1.ObjectMeta{Name: "bob"}, Identities: []string{"github:1234", "sso:bob"}},
+			&userv1.Identity{ObjectMeta: metav1.ObjectMeta{Name: "sso:bob"}, Extra: map[string]string{"email": "bob@example.org"}},
+			&userv1.User{ObjectMeta: metav1.ObjectMeta{Name: "carol"}},
+		),
+	}
+
+	for user, expected := range map[string]string{
+		// mapped by the ConfigMap
+		"alice": "alice.liddell@example.org",
+		// from the first identity with an email
+		"bob": "bob@example.org",
+		// left to the email provider
+		"carol": "",
+		"dave":  "",
+	} {
+		email, err := controller.userEmail(ctx, user)
+		if err != nil {
+			t.Fatalf("Expected the email of %s to be resolved, but got error: %v", user, err)
+		}
+		if email != expected {
+			t.Errorf("Expected email %q for %s, but got %q", expected, user, email)
+		}
+	}
+}
+
+func TestController_onboardingSecretNotPrunedWithSeededSecrets(t *testing.T) {
+	t.Setenv("ONBOARDING_API_URL", "https://api.my-rosa.example.com:443")
+	t.Setenv("ONBOARDING_ENABLED", "true")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(&corev1.Secret{
+		// seeded into the seeded set by an earlier release
+		ObjectMeta: metav1.ObjectMeta{Name: onboardingSecretName, Namespace: "alice", Labels: seededLabels("alice")},
+	})
+	controller := &Controller{coreClient: kubeClient.CoreV1()}
+
+	if err := controller.syncOnboardingSecret(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected onboarding Secret to be adopted, but got error: %v", err)
+	}
+	// the secrets step and refresh prune every seeded Secret which isn't materialized
+	if err := controller.pruneSeededSecrets(ctx, "alice", "alice", nil); err != nil {
+		t.Fatalf("Expected seeded Secrets to be pruned, but got error: %v", err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, onboardingSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected onboarding Secret to be kept, but got error: %v", err)
+	}
+	if secret.Labels[partOfLabel] != onboardingSet {
+		t.Errorf("Expected onboarding Secret to be moved to its own set, but got %v", secret.Labels)
+	}
+
+	// the onboarding Secret is pruned once no longer delivered
+	t.Setenv("ONBOARDING_ENABLED", "false")
+	if err := controller.pruneSeededObjects(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected seeded objects to be pruned, but got error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, onboardingSecretName, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected onboarding Secret to be pruned, but got error: %v", err)
+	}
+}
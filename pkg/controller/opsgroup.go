@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Returns the name of the RoleBinding granting the ops group access to the namespace
+func opsRoleBindingName(projectName string) string {
+	return fmt.Sprintf("%s-ops", projectName)
+}
+
+// Returns the RoleBinding granting the ops group the ClusterRole under the target user project
+func desiredOpsRoleBinding(user string, projectName string, group string, clusterRole string) *rbacv1.RoleBinding {
+	return desiredGroupRoleBinding(user, projectName, opsRoleBindingName(projectName), clusterRole, []string{group})
+}
+
+// Grants the ops group OPS_CLUSTER_ROLE under the target user project, so platform admins keep access
+// without cluster-admin, restoring the RoleBinding when modified. Unlike the owner's RoleBinding, it
+// ignores access windows and is kept when the project is retained.
+func (c *Controller) syncOpsRoleBinding(ctx context.Context, user string, projectName string) error {
+	return c.syncManagedRoleBinding(ctx, user, projectName, desiredOpsRoleBinding(user, projectName, GetOpsGroupName(), GetOpsClusterRole()))
+}
+
+// Returns the drift of the RoleBinding of the ops group under the target user project, if any
+func (c *Controller) opsDrift(ctx context.Context, user string, projectName string) (string, error) {
+	return c.managedRoleBindingDrift(ctx, desiredOpsRoleBinding(user, projectName, GetOpsGroupName(), GetOpsClusterRole()))
+}
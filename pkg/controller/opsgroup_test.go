@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncOpsRoleBinding(t *testing.T) {
+	t.Setenv("OPS_GROUP_NAME", "platform-sre")
+	t.Setenv("PROJECT_DELETION_POLICY", ProjectDeletionRetain)
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+	getOpsRoleBinding := func() *rbacv1.RoleBinding {
+		roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, opsRoleBindingName("alice"), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Expected the RoleBinding of the ops group under alice, but got error: %v", err)
+		}
+		return roleBinding
+	}
+
+	// The ops group is granted admin besides the owner's RoleBinding
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	roleBinding := getOpsRoleBinding()
+	if roleBinding.RoleRef.Name != "admin" {
+		t.Errorf("Expected the ops group to be granted admin, but got %s", roleBinding.RoleRef.Name)
+	}
+	if len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0].Kind != "Group" || roleBinding.Subjects[0].Name != "platform-sre" {
+		t.Errorf("Expected group platform-sre to be bound, but got %+v", roleBinding.Subjects)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, roleBindingName("alice"), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of alice, but got error: %v", err)
+	}
+
+	// Modified subjects are reported and restored by the resync
+	roleBinding.Subjects[0].Name = "someone-else"
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Update(ctx, roleBinding, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update RoleBinding: %v", err)
+	}
+	if drift, err := controller.opsDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the modified subject to be reported as drift, but got %q, %v", drift, err)
+	}
+	controller.resyncRoleBindings(ctx)
+	if got := getOpsRoleBinding().Subjects[0].Name; got != "platform-sre" {
+		t.Errorf("Expected group platform-sre to be restored, but got %s", got)
+	}
+
+	// A new role replaces the RoleBinding
+	t.Setenv("OPS_CLUSTER_ROLE", "view")
+	if err := controller.syncOpsRoleBinding(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the ops RoleBinding to be synced, but got error: %v", err)
+	}
+	if got := getOpsRoleBinding().RoleRef.Name; got != "view" {
+		t.Errorf("Expected the ops group to be granted view, but got %s", got)
+	}
+
+	// SREs keep access to the retained project of a removed user
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	getOpsRoleBinding()
+}
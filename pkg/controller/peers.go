@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/klog/v2"
+)
+
+// Returns the name of the RoleBinding granting the peers of the owner access to the namespace
+func peersRoleBindingName(projectName string) string {
+	return fmt.Sprintf("%s-peers", projectName)
+}
+
+// Returns the target groups the user is a member of, whose members are their peers. Nested groups
+// are resolved for membership, while only the direct members of the target groups are bound.
+func (c *Controller) peerGroups(ctx context.Context, user string) ([]string, error) {
+	groups, err := c.sourceGroups(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names, nil
+}
+
+// Returns the RoleBinding granting the peer groups PEER_CLUSTER_ROLE under the target user project, or
+// nil when the user is in no target group
+func (c *Controller) desiredPeersRoleBinding(ctx context.Context, user string, projectName string) (*rbacv1.RoleBinding, error) {
+	groups, err := c.peerGroups(ctx, user)
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return desiredGroupRoleBinding(user, projectName, peersRoleBindingName(projectName), GetPeerClusterRole(), groups), nil
+}
+
+// Grants the target groups of the user PEER_CLUSTER_ROLE under their project, so peers can e.g. view
+// each other's work, restoring the RoleBinding when modified
+func (c *Controller) syncPeersRoleBinding(ctx context.Context, user string, projectName string) error {
+	roleBinding, err := c.desiredPeersRoleBinding(ctx, user, projectName)
+	if err != nil {
+		klog.Errorf("Error getting the target groups of user %s: %v", user, err)
+		return err
+	}
+	if roleBinding == nil {
+		klog.V(2).Infof("User %s is in no target group, no peers to grant access to project %s", user, projectName)
+		return nil
+	}
+	return c.syncManagedRoleBinding(ctx, user, projectName, roleBinding)
+}
+
+// Deletes the RoleBinding of the peers under the target user project, keeping one not created by the
+// controller
+func (c *Controller) deletePeersRoleBinding(ctx context.Context, user string, projectName string) error {
+	return c.deleteManagedRoleBinding(ctx, user, projectName, peersRoleBindingName(projectName))
+}
+
+// Returns the drift of the RoleBinding of the peers under the target user project, if any
+func (c *Controller) peersDrift(ctx context.Context, user string, projectName string) (string, error) {
+	desired, err := c.desiredPeersRoleBinding(ctx, user, projectName)
+	if err != nil || desired == nil {
+		return "", err
+	}
+	return c.managedRoleBindingDrift(ctx, desired)
+}
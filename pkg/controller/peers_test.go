@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncPeersRoleBinding(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort-a,cohort-b,cohort-c")
+	t.Setenv("PEER_CLUSTER_ROLE", "view")
+	t.Setenv("PROJECT_DELETION_POLICY", ProjectDeletionRetain)
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(
+			newGroup("cohort-a", "alice", "bob"),
+			newGroup("cohort-b", "carol"),
+			newGroup("cohort-c", "alice"),
+		),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	// The target groups of alice are granted view besides her own RoleBinding
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, peersRoleBindingName("alice"), metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the RoleBinding of the peers under alice, but got error: %v", err)
+	}
+	if roleBinding.RoleRef.Name != "view" {
+		t.Errorf("Expected the peers to be granted view, but got %s", roleBinding.RoleRef.Name)
+	}
+	if got := subjectNames(roleBinding.Subjects); len(got) != 2 || got[0] != "cohort-a" || got[1] != "cohort-c" {
+		t.Errorf("Expected groups cohort-a and cohort-c to be bound, but got %v", got)
+	}
+	for _, subject := range roleBinding.Subjects {
+		if subject.Kind != "Group" {
+			t.Errorf("Expected a Group subject, but got %+v", subject)
+		}
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, roleBindingName("alice"), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of alice, but got error: %v", err)
+	}
+
+	// A deleted RoleBinding is reported and restored by the resync
+	if err := kubeClient.RbacV1().RoleBindings("alice").Delete(ctx, peersRoleBindingName("alice"), metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete RoleBinding: %v", err)
+	}
+	if drift, err := controller.peersDrift(ctx, "alice", "alice"); err != nil || drift == "" {
+		t.Errorf("Expected the missing RoleBinding to be reported as drift, but got %q, %v", drift, err)
+	}
+	controller.resyncRoleBindings(ctx)
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, peersRoleBindingName("alice"), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of the peers to be restored, but got error: %v", err)
+	}
+
+	// Peers lose access to the retained project of a removed user
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("alice").Get(ctx, peersRoleBindingName("alice"), metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the RoleBinding of the peers to be deleted, but got error: %v", err)
+	}
+}
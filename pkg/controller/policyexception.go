@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// Returns the unexpired policy exceptions approved for the target user project on its
+// ManagedNamespace, none without the inventory
+func (c *Controller) policyExceptions(ctx context.Context, projectName string) ([]v1alpha1.PolicyException, error) {
+	if c.dynamicClient == nil || !GetManagedNamespacesEnabled() {
+		return nil, nil
+	}
+	current, err := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, projectName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		klog.Errorf("Error getting policy exceptions of project %s: %v", projectName, err)
+		return nil, err
+	}
+	managed := &v1alpha1.ManagedNamespace{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(current.Object, managed); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var exceptions []v1alpha1.PolicyException
+	for _, exception := range managed.Spec.PolicyExceptions {
+		if exception.Expires != nil && !exception.Expires.After(now) {
+			continue
+		}
+		exceptions = append(exceptions, exception)
+	}
+	return exceptions, nil
+}
+
+// Returns the exception to the policy object of the given kind and name, or nil if there's none
+func findPolicyException(exceptions []v1alpha1.PolicyException, kind string, name string) *v1alpha1.PolicyException {
+	for i := range exceptions {
+		if exceptions[i].Kind == kind && exceptions[i].Name == name {
+			return &exceptions[i]
+		}
+	}
+	return nil
+}
+
+// Returns whether an exception was approved to the policy object under the target user project, in
+// which case reconcilers leave it as found
+func (c *Controller) policyExcepted(ctx context.Context, user string, projectName string, kind string, name string) (bool, error) {
+	exceptions, err := c.policyExceptions(ctx, projectName)
+	if err != nil {
+		return false, err
+	}
+	exception := findPolicyException(exceptions, kind, name)
+	if exception == nil {
+		return false, nil
+	}
+	klog.V(2).Infof("Leaving %s %s for user %s under project %s as found, an exception was approved: %s",
+		kind, name, user, projectName, exception.Reason)
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_policyExceptions(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "cohort")
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+	t.Setenv("DENY_LOAD_BALANCERS_ENABLED", "true")
+
+	ctx := context.Background()
+	// an admin allowed alice a LoadBalancer by raising the deny quota
+	quota := desiredLoadBalancerQuota("alice", "alice")
+	quota.Spec.Hard[corev1.ResourceServicesLoadBalancers] = resource.MustParse("1")
+	expired := metav1.NewTime(time.Now().Add(-time.Hour))
+	record, err := toUnstructured(&v1alpha1.ManagedNamespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: v1alpha1.ManagedNamespaceKind},
+		ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}},
+		Spec: v1alpha1.ManagedNamespaceSpec{
+			Owner:       "alice",
+			SourceGroup: "cohort",
+			PolicyExceptions: []v1alpha1.PolicyException{
+				{Kind: "ResourceQuota", Name: loadBalancerQuotaName, Reason: "demo ingress", ApprovedBy: "admin"},
+				{Kind: "LimitRange", Name: limitRangeName, Reason: "benchmark", Expires: &expired},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert ManagedNamespace: %v", err)
+	}
+	kubeClient := fake.NewClientset(quota)
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice")),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("alice", "alice")),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(record),
+	}
+
+	// Expired exceptions are enforced again
+	exceptions, err := controller.policyExceptions(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected the policy exceptions of alice, but got error: %v", err)
+	}
+	if len(exceptions) != 1 || exceptions[0].Name != loadBalancerQuotaName {
+		t.Errorf("Expected only the exception to %s to be active, but got %+v", loadBalancerQuotaName, exceptions)
+	}
+
+	// The excepted quota is left as found
+	if err := controller.createLoadBalancerQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the excepted quota to be skipped, but got error: %v", err)
+	}
+	current, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, loadBalancerQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ResourceQuota: %v", err)
+	}
+	if got := current.Spec.Hard[corev1.ResourceServicesLoadBalancers]; got.Value() != 1 {
+		t.Errorf("Expected the excepted quota to keep allowing a LoadBalancer, but got %s", got.String())
+	}
+
+	// Provisioning keeps the exceptions recorded by admins
+	if err := controller.updateManagedNamespace(ctx, "alice", "alice", nil, nil); err != nil {
+		t.Fatalf("Expected ManagedNamespace to be updated, but got error: %v", err)
+	}
+	if got := getManagedNamespace(t, controller, "alice").Spec.PolicyExceptions; len(got) != 2 {
+		t.Errorf("Expected the policy exceptions to be kept, but got %+v", got)
+	}
+
+	// The report lists the exceptions instead of drift
+	reports, err := controller.Report(ctx)
+	if err != nil {
+		t.Fatalf("Expected a report, but got error: %v", err)
+	}
+	if len(reports) != 1 || len(reports[0].Exceptions) != 1 {
+		t.Fatalf("Expected the report to list the exception of alice, but got %+v", reports)
+	}
+	for _, drift := range reports[0].Drift {
+		if strings.Contains(drift, loadBalancerQuotaName) {
+			t.Errorf("Expected no drift of the excepted quota, but got %q", drift)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation on a User or a target group selecting the provisioning profile of the user, or of the
+// members of the group
+const profileAnnotation = "rosa-namespace-provisioner/profile"
+
+// Profile is a named shape of the namespaces of the users selecting it, e.g. a larger quota and a
+// broader ClusterRole for ML users
+type Profile struct {
+	Name string
+	// ClusterRole replaces USER_CLUSTER_ROLE when set
+	ClusterRole string
+	// QuotaHard overrides the limits of RESOURCE_QUOTA_HARD of the same resources
+	QuotaHard corev1.ResourceList
+}
+
+// Parses "<profile>:<resource>=<quantity>" quota limits and "<profile>=<cluster-role>" ClusterRoles
+// into profiles keyed by name
+func parseProfiles(quotas []string, clusterRoles []string) (map[string]Profile, error) {
+	profiles := make(map[string]Profile)
+	for _, entry := range quotas {
+		name, limit, found := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" || strings.TrimSpace(limit) == "" {
+			return nil, fmt.Errorf("invalid profile quota %q, expected <profile>:<resource>=<quantity>", entry)
+		}
+		hard, err := parseResourceList(limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quota of profile %s: %w", name, err)
+		}
+		profile := profiles[name]
+		profile.Name = name
+		if profile.QuotaHard == nil {
+			profile.QuotaHard = corev1.ResourceList{}
+		}
+		maps.Copy(profile.QuotaHard, hard)
+		profiles[name] = profile
+	}
+	for _, entry := range clusterRoles {
+		name, role, found := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		role = strings.TrimSpace(role)
+		if !found || name == "" || role == "" {
+			return nil, fmt.Errorf("invalid profile ClusterRole %q, expected <profile>=<cluster-role>", entry)
+		}
+		profile := profiles[name]
+		profile.Name = name
+		profile.ClusterRole = role
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// Returns the name of the profile selected for the target user: the profile annotation on their User,
+// else the one on their target group, else PROFILE_DEFAULT
+func (c *Controller) selectedProfile(ctx context.Context, user string) (string, error) {
+	userObj, err := c.userClient.UserV1().Users().Get(ctx, user, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error getting User %s to select its profile: %v", user, err)
+		return "", err
+	}
+	if err == nil {
+		if profile := userObj.Annotations[profileAnnotation]; profile != "" {
+			return profile, nil
+		}
+	}
+
+	group, err := c.sourceGroup(ctx, user)
+	if err != nil {
+		klog.Errorf("Error getting the target group of user %s to select their profile: %v", user, err)
+		return "", err
+	}
+	if group != nil {
+		if profile := group.Annotations[profileAnnotation]; profile != "" {
+			return profile, nil
+		}
+	}
+	return GetProfileDefault(), nil
+}
+
+// Returns the profile of the target user, or nil when no profiles are configured or none is selected
+func (c *Controller) userProfile(ctx context.Context, user string) (*Profile, error) {
+	profiles, err := GetProfiles()
+	if err != nil || len(profiles) == 0 {
+		return nil, err
+	}
+	name, err := c.selectedProfile(ctx, user)
+	if err != nil || name == "" {
+		return nil, err
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s is not configured", name)
+	}
+	return &profile, nil
+}
+
+// Returns the compute quota of the target user, with the limits of their profile overriding those of
+// RESOURCE_QUOTA_HARD
+func (c *Controller) userComputeQuota(ctx context.Context, user string, projectName string) (*corev1.ResourceQuota, error) {
+	quota, err := desiredComputeQuota(user, projectName)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := c.userProfile(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		maps.Copy(quota.Spec.Hard, profile.QuotaHard)
+	}
+	return quota, nil
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := parseProfiles([]string{"ml:requests.cpu=16", "ml:requests.nvidia.com/gpu=2", "small:pods=5"}, []string{"ml=admin", "viewer=view"})
+	if err != nil {
+		t.Fatalf("Expected profiles to be parsed, but got error: %v", err)
+	}
+	if len(profiles) != 3 {
+		t.Fatalf("Expected 3 profiles, but got %v", profiles)
+	}
+	ml := profiles["ml"]
+	if ml.ClusterRole != "admin" || len(ml.QuotaHard) != 2 {
+		t.Errorf("Expected profile ml to grant admin with 2 limits, but got %+v", ml)
+	}
+	if profiles["viewer"].QuotaHard != nil {
+		t.Errorf("Expected profile viewer to have no limits, but got %v", profiles["viewer"].QuotaHard)
+	}
+
+	for _, tt := range []struct {
+		quotas       []string
+		clusterRoles []string
+		err          string
+	}{
+		{quotas: []string{"requests.cpu=16"}, err: "expected <profile>:<resource>=<quantity>"},
+		{quotas: []string{"ml:requests.cpu=lots"}, err: "invalid quota of profile ml"},
+		{clusterRoles: []string{"ml"}, err: "expected <profile>=<cluster-role>"},
+	} {
+		if _, err := parseProfiles(tt.quotas, tt.clusterRoles); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Expected error containing %q, but got %v", tt.err, err)
+		}
+	}
+}
+
+func TestController_userProfile(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort,ml-research")
+	t.Setenv("RESOURCE_QUOTA_ENABLED", "true")
+	t.Setenv("RESOURCE_QUOTA_HARD", "requests.cpu=2,pods=10")
+	t.Setenv("PROFILE_QUOTAS", "large:requests.cpu=16,large:requests.nvidia.com/gpu=2")
+	t.Setenv("PROFILE_CLUSTER_ROLES", "large=admin,viewer=view")
+	t.Setenv("PROFILE_DEFAULT", "viewer")
+
+	ctx := context.Background()
+	research := newGroup("ml-research", "bob")
+	research.Annotations = map[string]string{profileAnnotation: "large"}
+	carol := &userv1.User{ObjectMeta: metav1.ObjectMeta{
+		Name:        "carol",
+		Annotations: map[string]string{profileAnnotation: "large"},
+	}}
+	dave := &userv1.User{ObjectMeta: metav1.ObjectMeta{
+		Name:        "dave",
+		Annotations: map[string]string{profileAnnotation: "huge"},
+	}}
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice", "carol", "dave"), research, carol, dave),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	tests := []struct {
+		user        string
+		clusterRole string
+		cpu         string
+	}{
+		// the default profile
+		{user: "alice", clusterRole: "view", cpu: "2"},
+		// the profile of the target group
+		{user: "bob", clusterRole: "admin", cpu: "16"},
+		// the profile of the User
+		{user: "carol", clusterRole: "admin", cpu: "16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.user, func(t *testing.T) {
+			if err := controller.provisionUser(ctx, tt.user); err != nil {
+				t.Fatalf("Expected user %s to be provisioned, but got error: %v", tt.user, err)
+			}
+			roleBinding, err := kubeClient.RbacV1().RoleBindings(tt.user).Get(ctx, roleBindingName(tt.user), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected RoleBinding under %s, but got error: %v", tt.user, err)
+			}
+			if roleBinding.RoleRef.Name != tt.clusterRole {
+				t.Errorf("Expected ClusterRole %s, but got %s", tt.clusterRole, roleBinding.RoleRef.Name)
+			}
+			quota, err := kubeClient.CoreV1().ResourceQuotas(tt.user).Get(ctx, computeQuotaName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected ResourceQuota under %s, but got error: %v", tt.user, err)
+			}
+			if cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]; cpu.Cmp(resource.MustParse(tt.cpu)) != 0 {
+				t.Errorf("Expected requests.cpu=%s, but got %s", tt.cpu, cpu.String())
+			}
+			// limits the profile doesn't override are kept
+			if pods := quota.Spec.Hard[corev1.ResourcePods]; pods.Cmp(resource.MustParse("10")) != 0 {
+				t.Errorf("Expected pods=10, but got %s", pods.String())
+			}
+		})
+	}
+
+	if _, err := controller.userProfile(ctx, "dave"); err == nil || !strings.Contains(err.Error(), "profile huge is not configured") {
+		t.Errorf("Expected an unknown profile to be rejected, but got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"text/template"
+)
+
+// annotation recording the user a project was requested by, shown by the OpenShift console
+const requesterAnnotation = "openshift.io/requester"
+
+// projectMetadataData is the data the display name and description templates of projects are
+// executed with
+type projectMetadataData struct {
+	// User is the user name as is
+	User string
+	// Group is the first target group the user is a member of, or the first target group when they are
+	// a member of none, e.g. when provisioned by bulk-onboard. It is empty when that group doesn't exist.
+	Group string
+}
+
+// Executes a display name or description template of projects
+func renderProjectMetadata(source string, data projectMetadataData) (string, error) {
+	tmpl, err := template.New("project-metadata").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", err
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(rendered.String()), nil
+}
+
+// Returns the annotations the OpenShift console shows the ownership of the project of the target
+// user from: its requester, display name and description. The target group of the user is only looked
+// up when a template uses it.
+func (c *Controller) projectConsoleAnnotations(ctx context.Context, user string) (map[string]string, error) {
+	displayName, description := GetProjectDisplayNameTemplate(), GetProjectDescriptionTemplate()
+	data := projectMetadataData{User: user}
+	if strings.Contains(displayName, ".Group") || strings.Contains(description, ".Group") {
+		group, err := c.sourceGroup(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if group != nil {
+			data.Group = group.Name
+		}
+	}
+
+	annotations := map[string]string{requesterAnnotation: user}
+	for annotation, source := range map[string]string{displayNameAnnotation: displayName, descriptionAnnotation: description} {
+		value, err := renderProjectMetadata(source, data)
+		if err != nil {
+			return nil, err
+		}
+		if value != "" {
+			annotations[annotation] = value
+		}
+	}
+	return annotations, nil
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_projectConsoleAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		user        string
+		displayName string
+		description string
+	}{
+		{
+			name:        "default templates",
+			user:        "alice@example.com",
+			displayName: "alice@example.com",
+			description: "Sandbox of alice@example.com, provisioned by rosa-namespace-provisioner",
+		},
+		{
+			name: "templates using the target group",
+			env: map[string]string{
+				"PROJECT_DISPLAY_NAME_TEMPLATE": "{{ .User }} ({{ .Group }})",
+				"PROJECT_DESCRIPTION_TEMPLATE":  "Workshop sandbox{{ with .Group }} of cohort {{ . }}{{ end }}",
+			},
+			user:        "bob",
+			displayName: "bob (cohort-b)",
+			description: "Workshop sandbox of cohort cohort-b",
+		},
+		{
+			name: "user in no target group gets the first one",
+			env: map[string]string{
+				"PROJECT_DESCRIPTION_TEMPLATE": "Workshop sandbox{{ with .Group }} of cohort {{ . }}{{ end }}",
+			},
+			user:        "carol",
+			displayName: "carol",
+			description: "Workshop sandbox of cohort cohort-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TARGET_GROUP_NAMES", "cohort-a,cohort-b")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			projectClient := projectfake.NewSimpleClientset()
+			controller := &Controller{
+				userClient:    userfake.NewSimpleClientset(newGroup("cohort-a", "alice"), newGroup("cohort-b", "bob")),
+				projectClient: projectClient,
+				coreClient:    fake.NewClientset().CoreV1(),
+			}
+
+			ctx := context.Background()
+			projectName := controller.ProjectName(tt.user)
+			if err := controller.createUserProject(ctx, tt.user, projectName); err != nil {
+				t.Fatalf("Expected project to be created for user %s, but got error: %v", tt.user, err)
+			}
+			project, err := projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected project %s to exist, but got error: %v", projectName, err)
+			}
+			if got := project.Annotations[requesterAnnotation]; got != tt.user {
+				t.Errorf("Expected requester %q, but got %q", tt.user, got)
+			}
+			if got := project.Annotations[displayNameAnnotation]; got != tt.displayName {
+				t.Errorf("Expected display name %q, but got %q", tt.displayName, got)
+			}
+			if got := project.Annotations[descriptionAnnotation]; got != tt.description {
+				t.Errorf("Expected description %q, but got %q", tt.description, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// the settings of the applied ProvisionerConfig, taking precedence over the environment
+var appliedConfig struct {
+	sync.RWMutex
+	// values of the configuration variables set by the ProvisionerConfig
+	overrides map[string]string
+	// LimitRange template replacing LIMIT_RANGE_FILE
+	limitRange *corev1.LimitRangeSpec
+}
+
+// Returns the value the applied ProvisionerConfig sets the configuration variable to, if any
+func configOverride(name string) (string, bool) {
+	appliedConfig.RLock()
+	defer appliedConfig.RUnlock()
+	value, ok := appliedConfig.overrides[name]
+	return value, ok
+}
+
+// Returns the LimitRange template of the applied ProvisionerConfig, or nil to use LIMIT_RANGE_FILE
+func configLimitRange() *corev1.LimitRangeSpec {
+	appliedConfig.RLock()
+	defer appliedConfig.RUnlock()
+	return appliedConfig.limitRange
+}
+
+// Returns the configuration variables set by the applied ProvisionerConfig
+func configOverrides() map[string]string {
+	appliedConfig.RLock()
+	defer appliedConfig.RUnlock()
+	return appliedConfig.overrides
+}
+
+// Replaces the applied settings, returning whether they changed
+func setConfigOverrides(overrides map[string]string, limitRange *corev1.LimitRangeSpec) bool {
+	appliedConfig.Lock()
+	defer appliedConfig.Unlock()
+	changed := !maps.Equal(appliedConfig.overrides, overrides) || !reflect.DeepEqual(appliedConfig.limitRange, limitRange)
+	appliedConfig.overrides, appliedConfig.limitRange = overrides, limitRange
+	return changed
+}
+
+// Returns the configuration variables set by the fields of a ProvisionerConfig
+func provisionerConfigOverrides(spec v1alpha1.ProvisionerConfigSpec) map[string]string {
+	overrides := make(map[string]string)
+	if len(spec.TargetGroups) > 0 {
+		overrides["TARGET_GROUP_NAMES"] = strings.Join(spec.TargetGroups, ",")
+	}
+	if spec.ProjectNameTemplate != "" {
+		overrides["PROJECT_NAME_TEMPLATE"] = spec.ProjectNameTemplate
+	}
+	if spec.UserClusterRole != "" {
+		overrides["USER_CLUSTER_ROLE"] = spec.UserClusterRole
+	}
+	if len(spec.UserClusterRoleOverrides) > 0 {
+		entries := make([]string, 0, len(spec.UserClusterRoleOverrides))
+		for group, role := range spec.UserClusterRoleOverrides {
+			entries = append(entries, group+"="+role)
+		}
+		sort.Strings(entries)
+		overrides["USER_CLUSTER_ROLE_OVERRIDES"] = strings.Join(entries, ",")
+	}
+	if spec.ResourceQuota != nil {
+		entries := make([]string, 0, len(spec.ResourceQuota.Hard))
+		for name, quantity := range spec.ResourceQuota.Hard {
+			entries = append(entries, fmt.Sprintf("%s=%s", name, quantity.String()))
+		}
+		sort.Strings(entries)
+		overrides["RESOURCE_QUOTA_ENABLED"] = "true"
+		overrides["RESOURCE_QUOTA_HARD"] = strings.Join(entries, ",")
+	}
+	if spec.LimitRange != nil {
+		overrides["LIMIT_RANGE_ENABLED"] = "true"
+	}
+	if spec.ProjectDeletionPolicy != "" {
+		overrides["PROJECT_DELETION_POLICY"] = spec.ProjectDeletionPolicy
+	}
+	if spec.DeletionGracePeriod != nil {
+		overrides["DELETION_GRACE_PERIOD"] = spec.DeletionGracePeriod.Duration.String()
+	}
+	return overrides
+}
+
+// Applies the ProvisionerConfig on top of the environment, or only the environment when it is nil,
+// returning whether the configuration changed. An invalid configuration is rejected and the previous
+// one kept, as is one changing settings only read on startup unless the controller is starting.
+func applyProvisionerConfig(config *v1alpha1.ProvisionerConfig, starting bool) (bool, error) {
+	overrides, limitRange := map[string]string(nil), (*corev1.LimitRangeSpec)(nil)
+	if config != nil {
+		overrides, limitRange = provisionerConfigOverrides(config.Spec), config.Spec.LimitRange
+	}
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	candidate := layeredLookup(overrides, fileConfigValues())
+	if starting {
+		if errs := validateAppliedSettings(candidate, limitRange); len(errs) > 0 {
+			return false, errors.Join(errs...)
+		}
+	} else if err := validateReload(candidate, limitRange, variablesSetBy(configOverrides(), overrides)); err != nil {
+		return false, err
+	}
+	return setConfigOverrides(overrides, limitRange), nil
+}
+
+// Applies the ProvisionerConfig found when the controller starts, so the first reconciles already use
+// it. An invalid one is reported and left to the reconciler to record.
+func (c *Controller) loadProvisionerConfig(ctx context.Context) error {
+	obj, err := c.dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).Get(ctx, v1alpha1.ProvisionerConfigName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		klog.Infof("No ProvisionerConfig %s found, using the environment", v1alpha1.ProvisionerConfigName)
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get ProvisionerConfig %s: %w", v1alpha1.ProvisionerConfigName, err)
+	}
+	config := &v1alpha1.ProvisionerConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, config); err != nil {
+		return fmt.Errorf("failed to decode ProvisionerConfig %s: %w", v1alpha1.ProvisionerConfigName, err)
+	}
+	if _, err := applyProvisionerConfig(config, true); err != nil {
+		klog.Errorf("Ignoring invalid ProvisionerConfig %s, using the environment: %v", v1alpha1.ProvisionerConfigName, err)
+		return nil
+	}
+	klog.Infof("Applied ProvisionerConfig %s generation %d", v1alpha1.ProvisionerConfigName, config.Generation)
+	return nil
+}
+
+// Creates an informer watching the ProvisionerConfig applied by the controller
+func newProvisionerConfigInformer(dynamicClient dynamic.Interface, watches *watchHealth) cache.SharedIndexInformer {
+	filterName := func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("metadata.name", v1alpha1.ProvisionerConfigName).String()
+		tuneListOptions(options)
+	}
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			filterName(&options)
+			return dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).List(ctx, options)
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			filterName(&options)
+			return dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).Watch(ctx, options)
+		},
+	}
+
+	return watches.newInformer("provisionerconfig", listWatcher, &unstructured.Unstructured{}, GetInformerResyncPeriod())
+}
+
+// Applies changes to the ProvisionerConfig, recording on it whether they were applied, and
+// re-provisions every member so the new configuration takes effect
+func (c *Controller) reconcileProvisionerConfigRequest(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// the configuration is changed by admins
+	defer observeReconcile("provisionerconfig", TriggerAdmin, time.Now())
+	obj, exists, err := c.configInformer.GetStore().GetByKey(request.Name)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	var config *v1alpha1.ProvisionerConfig
+	if exists {
+		config = &v1alpha1.ProvisionerConfig{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.(*unstructured.Unstructured).Object, config); err != nil {
+			klog.Errorf("Error decoding ProvisionerConfig %s: %v", request.Name, err)
+			return reconcile.Result{}, nil
+		}
+	}
+
+	changed, applyErr := applyProvisionerConfig(config, false)
+	if applyErr != nil {
+		klog.Errorf("Rejected ProvisionerConfig %s, keeping the previous configuration: %v", request.Name, applyErr)
+	}
+	if config != nil {
+		if err := c.updateProvisionerConfigStatus(ctx, config, applyErr); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+	if !changed {
+		return reconcile.Result{}, nil
+	}
+
+	if config == nil {
+		klog.Infof("ProvisionerConfig %s was deleted, reverting to the environment", request.Name)
+	} else {
+		klog.Infof("Applied ProvisionerConfig %s generation %d", request.Name, config.Generation)
+	}
+	c.reprovisionMembers(withTrigger(ctx, TriggerAdmin))
+	return reconcile.Result{}, nil
+}
+
+// Records whether the current generation of the ProvisionerConfig was applied
+func (c *Controller) updateProvisionerConfigStatus(ctx context.Context, config *v1alpha1.ProvisionerConfig, applyErr error) error {
+	condition := metav1.Condition{
+		Type:               v1alpha1.ConditionApplied,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: config.Generation,
+		Reason:             "Applied",
+		Message:            "The configuration is applied",
+	}
+	if applyErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "Invalid"
+		condition.Message = applyErr.Error()
+	}
+	changed := meta.SetStatusCondition(&config.Status.Conditions, condition)
+	if !changed && config.Status.ObservedGeneration == config.Generation {
+		return nil
+	}
+	config.Status.ObservedGeneration = config.Generation
+
+	obj, err := toUnstructured(config)
+	if err != nil {
+		return err
+	}
+	if _, err := c.dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error updating ProvisionerConfig %s status: %v", config.Name, err)
+		return err
+	}
+	return nil
+}
+
+// Re-provisions every member after the configuration changed, so their namespaces get the new roles,
+// quota and limits and members of newly targeted groups get one. Users no longer members keep their
+// namespace until they are removed from a watched group.
+func (c *Controller) reprovisionMembers(ctx context.Context) {
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s to apply the new configuration: %v", membershipDescription(), err)
+		return
+	}
+	users := make([]string, 0, len(members))
+	for user := range members {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	klog.Infof("Re-provisioning %d members of %s with the new configuration", len(users), membershipDescription())
+	for _, user := range users {
+		_ = c.admitUser(ctx, user)
+	}
+}
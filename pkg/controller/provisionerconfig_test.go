@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// Returns the ProvisionerConfig named cluster with the given spec
+func newProvisionerConfig(t *testing.T, generation int64, spec v1alpha1.ProvisionerConfigSpec) *unstructured.Unstructured {
+	t.Helper()
+	obj, err := toUnstructured(&v1alpha1.ProvisionerConfig{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: v1alpha1.ProvisionerConfigKind},
+		ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.ProvisionerConfigName, Generation: generation},
+		Spec:       spec,
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert ProvisionerConfig: %v", err)
+	}
+	return obj
+}
+
+// Returns the Applied condition of the ProvisionerConfig named cluster
+func getAppliedCondition(t *testing.T, controller *Controller) *metav1.Condition {
+	t.Helper()
+	obj, err := controller.dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).Get(context.Background(), v1alpha1.ProvisionerConfigName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ProvisionerConfig to be found, but got error: %v", err)
+	}
+	config := &v1alpha1.ProvisionerConfig{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, config); err != nil {
+		t.Fatalf("Failed to convert ProvisionerConfig: %v", err)
+	}
+	return meta.FindStatusCondition(config.Status.Conditions, v1alpha1.ConditionApplied)
+}
+
+func TestController_reconcileProvisionerConfigRequest(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort")
+	t.Setenv("PROVISIONER_CONFIG_ENABLED", "true")
+	t.Cleanup(func() { setConfigOverrides(nil, nil) })
+
+	ctx := context.Background()
+	config := newProvisionerConfig(t, 1, v1alpha1.ProvisionerConfigSpec{
+		TargetGroups:  []string{"staff"},
+		ResourceQuota: &v1alpha1.ResourceQuotaTemplate{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}},
+	})
+	dynamicClient := newInventoryClient(config)
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice"), newGroup("staff", "bob")),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: dynamicClient,
+	}
+	controller.configInformer = newProvisionerConfigInformer(dynamicClient, newWatchHealth())
+	store := controller.configInformer.GetStore()
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: v1alpha1.ProvisionerConfigName}}
+
+	// The ProvisionerConfig takes precedence over the environment and members get the new configuration
+	if err := store.Add(config); err != nil {
+		t.Fatalf("Failed to add ProvisionerConfig: %v", err)
+	}
+	if _, err := controller.reconcileProvisionerConfigRequest(ctx, request); err != nil {
+		t.Fatalf("Expected the ProvisionerConfig to be applied, but got error: %v", err)
+	}
+	if got := GetTargetGroupNames(); !slices.Equal(got, []string{"staff"}) {
+		t.Errorf("Expected the target groups of the ProvisionerConfig, but got %v", got)
+	}
+	if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project bob to be provisioned, but got error: %v", err)
+	}
+	quota, err := kubeClient.CoreV1().ResourceQuotas("bob").Get(ctx, computeQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the ResourceQuota of the ProvisionerConfig to be seeded, but got error: %v", err)
+	}
+	if got := quota.Spec.Hard[corev1.ResourcePods]; got.Value() != 10 {
+		t.Errorf("Expected the ResourceQuota to allow 10 pods, but got %s", got.String())
+	}
+	if condition := getAppliedCondition(t, controller); condition == nil || condition.Status != metav1.ConditionTrue || condition.ObservedGeneration != 1 {
+		t.Errorf("Expected generation 1 to be recorded as applied, but got %+v", condition)
+	}
+
+	// An invalid configuration is rejected and the previous one kept
+	invalid := newProvisionerConfig(t, 2, v1alpha1.ProvisionerConfigSpec{
+		TargetGroups:    []string{"staff"},
+		UserClusterRole: "edit/all",
+	})
+	invalid.SetResourceVersion(getResourceVersion(t, controller))
+	if err := store.Update(invalid); err != nil {
+		t.Fatalf("Failed to update ProvisionerConfig: %v", err)
+	}
+	if _, err := controller.reconcileProvisionerConfigRequest(ctx, request); err != nil {
+		t.Fatalf("Expected the invalid ProvisionerConfig to be recorded, but got error: %v", err)
+	}
+	if got := GetUserClusterRole(); got != "edit" {
+		t.Errorf("Expected the previous ClusterRole to be kept, but got %s", got)
+	}
+	if condition := getAppliedCondition(t, controller); condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "Invalid" {
+		t.Errorf("Expected generation 2 to be recorded as invalid, but got %+v", condition)
+	}
+
+	// A new naming template is only applied on startup
+	renamed := newProvisionerConfig(t, 3, v1alpha1.ProvisionerConfigSpec{
+		TargetGroups:        []string{"staff"},
+		ProjectNameTemplate: "sandbox-{user}",
+	})
+	renamed.SetResourceVersion(getResourceVersion(t, controller))
+	if err := store.Update(renamed); err != nil {
+		t.Fatalf("Failed to update ProvisionerConfig: %v", err)
+	}
+	if _, err := controller.reconcileProvisionerConfigRequest(ctx, request); err != nil {
+		t.Fatalf("Expected the renaming ProvisionerConfig to be recorded, but got error: %v", err)
+	}
+	if got := controller.ProjectName("bob"); got != "bob" {
+		t.Errorf("Expected the previous naming template to be kept, but got project %s", got)
+	}
+	if condition := getAppliedCondition(t, controller); condition == nil || condition.Status != metav1.ConditionFalse || !strings.Contains(condition.Message, "PROJECT_NAME_TEMPLATE: can only be changed with a restart") {
+		t.Errorf("Expected generation 3 to be recorded as requiring a restart, but got %+v", condition)
+	}
+
+	// Deleting the ProvisionerConfig reverts to the environment
+	if err := store.Delete(renamed); err != nil {
+		t.Fatalf("Failed to delete ProvisionerConfig: %v", err)
+	}
+	if _, err := controller.reconcileProvisionerConfigRequest(ctx, request); err != nil {
+		t.Fatalf("Expected the environment to be restored, but got error: %v", err)
+	}
+	if got := GetTargetGroupNames(); !slices.Equal(got, []string{"cohort"}) {
+		t.Errorf("Expected the target groups of the environment, but got %v", got)
+	}
+	if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be provisioned, but got error: %v", err)
+	}
+}
+
+func TestController_loadProvisionerConfig(t *testing.T) {
+	t.Setenv("PROVISIONER_CONFIG_ENABLED", "true")
+	t.Cleanup(func() { setConfigOverrides(nil, nil) })
+
+	// Settings only read on startup are applied when the controller starts
+	controller := &Controller{dynamicClient: newInventoryClient(newProvisionerConfig(t, 1, v1alpha1.ProvisionerConfigSpec{
+		ProjectNameTemplate: "sandbox-{user}",
+	}))}
+	if err := controller.loadProvisionerConfig(context.Background()); err != nil {
+		t.Fatalf("Expected the ProvisionerConfig to be loaded, but got error: %v", err)
+	}
+	if got := controller.ProjectName("bob"); got != "sandbox-bob" {
+		t.Errorf("Expected the naming template of the ProvisionerConfig, but got project %s", got)
+	}
+}
+
+// Returns the current resource version of the ProvisionerConfig named cluster
+func getResourceVersion(t *testing.T, controller *Controller) string {
+	t.Helper()
+	obj, err := controller.dynamicClient.Resource(v1alpha1.ProvisionerConfigsResource).Get(context.Background(), v1alpha1.ProvisionerConfigName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ProvisionerConfig to be found, but got error: %v", err)
+	}
+	return obj.GetResourceVersion()
+}
@@ -0,0 +1,601 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog/v2"
+)
+
+// provisioningStep is a single step of provisioning a user, optionally paired with a
+// compensation that rolls back what the step created
+type provisioningStep struct {
+	name       string
+	run        func(ctx context.Context) error
+	compensate func(ctx context.Context) error
+}
+
+// Returns the ordered provisioning steps for the target user and project
+func (c *Controller) provisioningSteps(user string, projectName string) []provisioningStep {
+	steps := []provisioningStep{
+		{
+			name: "project",
+			run: func(ctx context.Context) error {
+				return c.createUserProject(ctx, user, projectName)
+			},
+		},
+	}
+
+	if _, name, _ := GetNamespaceMappingConfigMap(); name != "" {
+		steps = append(steps, provisioningStep{
+			name: "namespacemapping",
+			run: func(ctx context.Context) error {
+				return c.updateNamespaceMapping(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetNamespaceFinalizerEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "finalizer",
+			run: func(ctx context.Context) error {
+				return c.addNamespaceFinalizer(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetAuditTaggingEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "auditlabels",
+			run: func(ctx context.Context) error {
+				return c.addAuditLabels(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetOwnerReferencesEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "anchor",
+			run: func(ctx context.Context) error {
+				return c.createAnchor(ctx, user, projectName)
+			},
+		})
+	}
+
+	steps = append(steps,
+		provisioningStep{
+			name: "rolebinding",
+			run: func(ctx context.Context) error {
+				return c.syncRoleBinding(ctx, user, projectName)
+			},
+		},
+	)
+
+	if bindings, _ := GetAdditionalRoleBindings(); len(bindings) > 0 {
+		steps = append(steps, provisioningStep{
+			name: "additionalrolebindings",
+			run: func(ctx context.Context) error {
+				return c.syncAdditionalRoleBindings(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetDelegatesEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "delegates",
+			run: func(ctx context.Context) error {
+				return c.syncDelegatesRoleBinding(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetOpsGroupName() != "" {
+		steps = append(steps, provisioningStep{
+			name: "opsrolebinding",
+			run: func(ctx context.Context) error {
+				return c.syncOpsRoleBinding(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetPeerClusterRole() != "" {
+		steps = append(steps, provisioningStep{
+			name: "peers",
+			run: func(ctx context.Context) error {
+				return c.syncPeersRoleBinding(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetAutomationServiceAccount() != "" {
+		steps = append(steps, provisioningStep{
+			name: "automation",
+			run: func(ctx context.Context) error {
+				return c.syncAutomation(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetDenyLoadBalancersEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "loadbalancerquota",
+			run: func(ctx context.Context) error {
+				return c.createLoadBalancerQuota(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetResourceQuotaEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "computequota",
+			run: func(ctx context.Context) error {
+				return c.createComputeQuota(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetLimitRangeEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "limitrange",
+			run: func(ctx context.Context) error {
+				return c.createLimitRange(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetNetworkPoliciesEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "networkpolicies",
+			run: func(ctx context.Context) error {
+				return c.createNetworkPolicies(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetBandwidthLimitsEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "bandwidth",
+			run: func(ctx context.Context) error {
+				return c.syncNetworkQoS(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetObjectCountQuotaEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "objectcountquota",
+			run: func(ctx context.Context) error {
+				return c.createObjectCountQuota(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetClusterResourceQuotaEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "clusterresourcequota",
+			run: func(ctx context.Context) error {
+				return c.createClusterResourceQuota(ctx, user)
+			},
+			compensate: func(ctx context.Context) error {
+				return c.deleteClusterResourceQuota(ctx, user)
+			},
+		})
+	}
+
+	if c.secretSource != nil && !c.health.Enabled(IntegrationAWSSecrets) {
+		klog.Warningf("Skipping secrets for user %s while integration %s is disabled", user, IntegrationAWSSecrets)
+	} else if c.secretSource != nil {
+		steps = append(steps, provisioningStep{
+			name: "secrets",
+			run: func(ctx context.Context) error {
+				return c.syncUserSecrets(ctx, user, projectName)
+			},
+		})
+	}
+
+	if seedResourcesEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "seedresources",
+			run: func(ctx context.Context) error {
+				return c.syncSeedResources(ctx, user, projectName)
+			},
+		})
+	}
+
+	if templateBundleEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "templatebundle",
+			run: func(ctx context.Context) error {
+				return c.applyTemplateBundle(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetOnboardingEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "onboarding",
+			run: func(ctx context.Context) error {
+				return c.deliverOnboarding(ctx, user, projectName)
+			},
+		})
+	}
+
+	// Remove what earlier configurations seeded, including the objects of features turned off since
+	steps = append(steps, provisioningStep{
+		name: "prune",
+		run: func(ctx context.Context) error {
+			return c.pruneSeededObjects(ctx, user, projectName)
+		},
+	})
+
+	return steps
+}
+
+// Returns the ordered deprovisioning steps for the target user and project, which all run even when
+// one of them fails
+func (c *Controller) deprovisioningSteps(user string, projectName string) []provisioningStep {
+	steps := []provisioningStep{
+		{
+			name: "project",
+			run: func(ctx context.Context) error {
+				return c.removeUserProject(ctx, user, projectName)
+			},
+		},
+		{
+			name: "inventory",
+			run: func(ctx context.Context) error {
+				return c.deprovisionManagedNamespace(ctx, user, projectName)
+			},
+		},
+	}
+
+	if _, name, _ := GetNamespaceMappingConfigMap(); name != "" {
+		steps = append(steps, provisioningStep{
+			name: "namespacemapping",
+			run: func(ctx context.Context) error {
+				return c.releaseNamespaceMapping(ctx, user, projectName)
+			},
+		})
+	}
+
+	if GetClusterResourceQuotaEnabled() {
+		steps = append(steps, provisioningStep{
+			name: "clusterresourcequota",
+			run: func(ctx context.Context) error {
+				return c.deleteClusterResourceQuota(ctx, user)
+			},
+		})
+	}
+
+	if c.cleanupQueue != nil {
+		steps = append(steps, provisioningStep{
+			name: "externalcleanup",
+			run: func(ctx context.Context) error {
+				c.queueExternalCleanups(user, projectName)
+				return nil
+			},
+		})
+	}
+
+	return steps
+}
+
+// Provisions the target user by running each provisioning step in order through the step
+// middlewares. When a step fails permanently, the completed steps are compensated in reverse order
+// so a failed onboarding doesn't leak resources outside of the user project.
+func (c *Controller) provisionUser(ctx context.Context, user string) error {
+	if c.provisionSkipped(ctx, user) {
+		klog.V(2).Infof("Skipping provisioning of user %s, dead-lettered until it is retried", user)
+		return fmt.Errorf("%w for user %s", ErrProvisionDeadLettered, user)
+	}
+	projectName, err := c.provisionedProjectName(ctx, user)
+	if err != nil {
+		return err
+	}
+	steps := c.provisioningSteps(user, projectName)
+	handler := c.stepHandler()
+	// a user provisioned again keeps their namespace
+	c.untrackDeletion(projectName)
+	c.discardDeprovisionReport(projectName)
+	started := time.Now()
+	trigger := reconcileTrigger(ctx)
+	metrics.ReconcileTriggers.WithLabelValues(metrics.OperationProvision, trigger).Inc()
+	c.markActive(trigger)
+	ctx, span := c.tracer.Start(ctx, events.ActionProvision, userAttributes(user, projectName, trigger)...)
+
+	c.publishEvent(ctx, user, projectName, events.ActionProvision, "", events.ResultStarted, nil)
+	for i, step := range steps {
+		err := handler(ctx, stepRequest{action: events.ActionProvision, user: user, projectName: projectName, step: step})
+		if err == nil {
+			continue
+		}
+
+		err = fmt.Errorf("provisioning step %s failed for user %s: %w", step.name, user, err)
+		completed := steps[:i]
+		if !isPermanentError(err) {
+			klog.Warningf("%v (transient, keeping completed steps)", err)
+		} else {
+			klog.Errorf("%v (permanent, compensating completed steps)", err)
+			c.compensateSteps(ctx, user, completed)
+			completed = uncompensatedSteps(completed)
+		}
+		err = c.recordProvisionFailure(ctx, user, projectName, err)
+		_ = c.updateManagedNamespace(ctx, user, projectName, completed, err)
+		metrics.ReconcileErrors.WithLabelValues(metrics.OperationProvision, trigger).Inc()
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeWarning, provisioningFailedReason, err.Error())
+		c.publishEvent(ctx, user, projectName, events.ActionProvision, "", events.ResultFailed, err)
+		span.End(err)
+		return err
+	}
+
+	c.clearProvisionFailure(ctx, user)
+	_ = c.updateManagedNamespace(ctx, user, projectName, steps, nil)
+	c.recordProvisioningLatency(ctx, user, projectName, started, c.clearPending(user, started))
+	c.publishEvent(ctx, user, projectName, events.ActionProvision, "", events.ResultSucceeded, nil)
+	span.End(nil)
+	return nil
+}
+
+// Deprovisions the target user by running each deprovisioning step through the step middlewares,
+// deleting their project and any resources kept outside of it
+func (c *Controller) deprovisionUser(ctx context.Context, user string) error {
+	projectName := c.ProjectName(user)
+	c.clearPending(user, time.Time{})
+	c.clearProvisionFailure(ctx, user)
+	handler := c.stepHandler()
+	trigger := reconcileTrigger(ctx)
+	metrics.ReconcileTriggers.WithLabelValues(metrics.OperationDeprovision, trigger).Inc()
+	c.markActive(trigger)
+	ctx, span := c.tracer.Start(ctx, events.ActionDeprovision, userAttributes(user, projectName, trigger)...)
+	c.beginDeprovisionReport(ctx, user, projectName)
+	c.publishEvent(ctx, user, projectName, events.ActionDeprovision, "", events.ResultStarted, nil)
+
+	var err error
+	for _, step := range c.deprovisioningSteps(user, projectName) {
+		if stepErr := handler(ctx, stepRequest{action: events.ActionDeprovision, user: user, projectName: projectName, step: step}); stepErr != nil && err == nil {
+			err = stepErr
+		}
+	}
+
+	if err != nil {
+		metrics.ReconcileErrors.WithLabelValues(metrics.OperationDeprovision, trigger).Inc()
+		c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeWarning, deprovisioningFailedReason,
+			fmt.Sprintf("Deprovisioning user %s failed: %v", user, err))
+		c.publishEvent(ctx, user, projectName, events.ActionDeprovision, "", events.ResultFailed, err)
+		span.End(err)
+		return err
+	}
+	c.publishEvent(ctx, user, projectName, events.ActionDeprovision, "", events.ResultSucceeded, nil)
+	c.completeDeprovisionReport(ctx, projectName)
+	span.End(nil)
+	return nil
+}
+
+// Deletes the project of the target user if it exists and was created by the controller for them
+func (c *Controller) deleteUserProject(ctx context.Context, user string, projectName string) error {
+	project, err := c.removedUserProject(ctx, user, projectName)
+	if err != nil || project == nil {
+		return err
+	}
+	// never delete projects the controller didn't create for the user, such as projects named after
+	// them that were kept without claiming them, unless configured to
+	if !isManaged(project) {
+		if !GetDeleteUnmanagedProjects() {
+			klog.Warningf("Project %s is not managed by the controller and will not be deleted for user %s", projectName, user)
+			return nil
+		}
+		klog.Warningf("Deleting project %s of user %s although it is not managed by the controller", projectName, user)
+	}
+
+	// the deletion is verified, and retried when it doesn't go through
+	c.trackDeletion(user, projectName, time.Now())
+	err = c.projectClient.ProjectV1().Projects().Delete(ctx, projectName, metav1.DeleteOptions{})
+	if err != nil {
+		klog.Errorf("Error deleting project for user %s: %v", user, err)
+		return err
+	}
+	klog.Infof("Successfully deleted project %s for user %s", projectName, user)
+	metrics.ProjectsDeleted.Inc()
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, projectDeletedReason,
+		fmt.Sprintf("Deleted project %s of user %s", projectName, user))
+	return nil
+}
+
+// Publishes a provisioning event for live subscribers, if any, and notifies the owner and delegates of
+// the final result unless notifications are sent as a digest
+func (c *Controller) publishEvent(ctx context.Context, user string, projectName string, action string, step string, result string, err error) {
+	event := events.Event{
+		User:      user,
+		Namespace: projectName,
+		Action:    action,
+		Step:      step,
+		Result:    result,
+		Trigger:   reconcileTrigger(ctx),
+		DryRun:    GetDryRunEnabled(),
+	}
+	if err != nil {
+		event.Message = err.Error()
+	}
+	c.broadcaster.Publish(event)
+
+	// users aren't notified of changes a dry run didn't make
+	if c.notifier == nil || event.DryRun || step != "" || result == events.ResultStarted || GetNotificationMode() != NotificationModeImmediate {
+		return
+	}
+	notification := notify.Notification{
+		User:      user,
+		Namespace: projectName,
+		Severity:  notify.SeverityInfo,
+		Subject:   fmt.Sprintf("Namespace %s %s %s", projectName, action, result),
+		Message:   fmt.Sprintf("The %s of namespace %s for user %s %s", action, projectName, user, result),
+	}
+	if err != nil {
+		notification.Severity = notify.SeverityError
+		notification.Message = fmt.Sprintf("%s: %v", notification.Message, err)
+	}
+	if err := c.notifier.Notify(ctx, notification); err != nil {
+		klog.Errorf("Error notifying user %s about %s: %v", user, action, err)
+	}
+	c.notifyDelegates(ctx, user, notification)
+}
+
+// ProjectName returns the name of the project provisioned for the target user: the one recorded in the
+// namespace mapping ConfigMap if any, else the name given by PROJECT_NAME_TEMPLATE or the name it got
+// instead when it collided with a namespace the user doesn't own
+func (c *Controller) ProjectName(user string) string {
+	if name, ok := c.mappedProjectName(user); ok {
+		return name
+	}
+	return c.desiredProjectName(user)
+}
+
+// Returns the name the current naming scheme gives the project of the target user, ignoring the
+// namespace mapping
+func (c *Controller) desiredProjectName(user string) string {
+	name := templateProjectName(user)
+	if policy, _ := GetExistingProjectPolicy(); policy == ExistingProjectRename {
+		if renamed := collisionProjectName(name, user); c.ownsNamespace(renamed, user) {
+			return renamed
+		}
+	}
+	return name
+}
+
+// Returns the name PROJECT_NAME_TEMPLATE gives the project of the target user. An invalid template,
+// only possible when starting degraded, names the project after the user alone.
+func templateProjectName(user string) string {
+	name, err := renderProjectName(GetProjectNameTemplate(), user)
+	if err != nil {
+		klog.Errorf("Error naming the project of user %s, naming it after the user: %v", user, err)
+		return sanitizeName(user, validation.DNS1123LabelMaxLength)
+	}
+	return name
+}
+
+// Returns whether the namespace exists and is owned by the target user, read from the namespace
+// informer once it has synced
+func (c *Controller) ownsNamespace(name string, user string) bool {
+	if c.namespaceInformer != nil && c.namespaceInformer.HasSynced() {
+		obj, exists, err := c.namespaceInformer.GetStore().GetByKey(name)
+		return err == nil && exists && objectOwner(obj.(*corev1.Namespace)) == user
+	}
+	project, err := c.projectClient.ProjectV1().Projects().Get(context.Background(), name, metav1.GetOptions{})
+	return err == nil && objectOwner(project) == user
+}
+
+// Returns the name of the project to provision for the target user. With EXISTING_PROJECT_POLICY=rename,
+// a name colliding with a namespace the user doesn't own is replaced with a hashed one.
+func (c *Controller) provisionedProjectName(ctx context.Context, user string) (string, error) {
+	// the mapping may have been changed by another replica or a naming migration
+	if err := c.loadNamespaceMapping(ctx); err != nil {
+		return "", err
+	}
+	if name, ok := c.mappedProjectName(user); ok {
+		return name, nil
+	}
+	name := c.desiredProjectName(user)
+	if policy, _ := GetExistingProjectPolicy(); policy != ExistingProjectRename || name != templateProjectName(user) {
+		return name, nil
+	}
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return name, nil
+	} else if err != nil {
+		klog.Errorf("Error checking if project %s of user %s collides with another namespace: %v", name, user, err)
+		return "", err
+	}
+	if objectOwner(project) == user {
+		return name, nil
+	}
+	renamed := collisionProjectName(name, user)
+	klog.Warningf("Namespace %s already exists and is not owned by user %s, naming their project %s", name, user, renamed)
+	return renamed, nil
+}
+
+// ProvisionUser provisions the project of the target user directly, outside of group events
+func (c *Controller) ProvisionUser(ctx context.Context, user string) error {
+	ctx = withDefaultTrigger(ctx, TriggerAdmin)
+	return c.provisionUser(ctx, user)
+}
+
+// DeprovisionUser deprovisions the project of the target user directly, outside of group events
+func (c *Controller) DeprovisionUser(ctx context.Context, user string) error {
+	ctx = withDefaultTrigger(ctx, TriggerAdmin)
+	return c.deprovisionUser(ctx, user)
+}
+
+// IsUserReady returns whether the project of the target user is active and grants the user access
+func (c *Controller) IsUserReady(ctx context.Context, user string) (bool, error) {
+	projectName := c.ProjectName(user)
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if project.Status.Phase != corev1.NamespaceActive {
+		return false, nil
+	}
+
+	_, err = c.rbacClient.RoleBindings(projectName).Get(ctx, roleBindingName(projectName), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Runs the compensations of the given completed steps in reverse order
+func (c *Controller) compensateSteps(ctx context.Context, user string, completed []provisioningStep) {
+	timeout := GetProvisioningStepTimeout()
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.compensate == nil {
+			continue
+		}
+
+		stepCtx, cancel := context.WithTimeout(ctx, timeout)
+		if err := step.compensate(stepCtx); err != nil {
+			klog.Errorf("Error compensating provisioning step %s for user %s: %v", step.name, user, err)
+		} else {
+			klog.Infof("Compensated provisioning step %s for user %s", step.name, user)
+		}
+		cancel()
+	}
+}
+
+// Returns the completed steps which are kept after compensation
+func uncompensatedSteps(completed []provisioningStep) []provisioningStep {
+	var kept []provisioningStep
+	for _, step := range completed {
+		if step.compensate == nil {
+			kept = append(kept, step)
+		}
+	}
+	return kept
+}
+
+// Returns whether an error is not expected to succeed when retried
+func isPermanentError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	return !(apierrors.IsTimeout(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsConflict(err))
+}
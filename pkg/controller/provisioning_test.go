@@ -0,0 +1,409 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var projectGroupResource = schema.GroupResource{Group: "project.openshift.io", Resource: "projects"}
+
+// secretSourceFunc adapts a function to a SecretSource
+type secretSourceFunc func(ctx context.Context, id string) (map[string][]byte, error)
+
+func (f secretSourceFunc) GetSecretData(ctx context.Context, id string) (map[string][]byte, error) {
+	return f(ctx, id)
+}
+
+func TestController_provisionUser(t *testing.T) {
+	tests := []struct {
+		name          string
+		secretSource  secretSourceFunc
+		shouldError   bool
+		expectedQuota bool
+	}{
+		{
+			name: "all steps succeed",
+			secretSource: func(ctx context.Context, id string) (map[string][]byte, error) {
+				return map[string][]byte{"value": []byte("abc")}, nil
+			},
+			expectedQuota: true,
+		},
+		{
+			name: "permanent failure compensates completed steps",
+			secretSource: func(ctx context.Context, id string) (map[string][]byte, error) {
+				return nil, fmt.Errorf("secret %s not found", id)
+			},
+			shouldError:   true,
+			expectedQuota: false,
+		},
+		{
+			name: "transient failure keeps completed steps",
+			secretSource: func(ctx context.Context, id string) (map[string][]byte, error) {
+				return nil, apierrors.NewServiceUnavailable("try again later")
+			},
+			shouldError:   true,
+			expectedQuota: true,
+		},
+		{
+			name: "step timeout keeps completed steps",
+			secretSource: func(ctx context.Context, id string) (map[string][]byte, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+			shouldError:   true,
+			expectedQuota: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+			t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+			t.Setenv("PROVISIONING_STEP_TIMEOUT", "50ms")
+			t.Setenv("STEP_RETRY_BACKOFF", "1ms")
+
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset()
+			quotaClient := quotafake.NewClientset()
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				quotaClient:   quotaClient,
+				coreClient:    kubeClient.CoreV1(),
+				secretSource:  tt.secretSource,
+			}
+
+			err := controller.provisionUser(ctx, "alice")
+			if tt.shouldError && err == nil {
+				t.Errorf("Expected case '%s' to receive an error", tt.name)
+			} else if !tt.shouldError && err != nil {
+				t.Errorf("Expected user alice to be provisioned, but got error: %v", err)
+			}
+
+			// The project itself is never rolled back
+			if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected project alice to exist, but got error: %v", err)
+			}
+
+			_, err = quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, "alice-quota", metav1.GetOptions{})
+			if tt.expectedQuota && err != nil {
+				t.Errorf("Expected ClusterResourceQuota alice-quota to exist, but got error: %v", err)
+			} else if !tt.expectedQuota && !apierrors.IsNotFound(err) {
+				t.Errorf("Expected ClusterResourceQuota alice-quota to be rolled back, but it still exists")
+			}
+		})
+	}
+}
+
+func TestController_provisionUserSkipsDisabledIntegrations(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+	// every provisioning reports a single failure of the integration
+	t.Setenv("STEP_RETRY_ATTEMPTS", "1")
+
+	ctx := context.Background()
+	tracker := health.NewTracker(2, time.Hour)
+	tracker.Register(IntegrationAWSSecrets)
+	calls := 0
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		health:        tracker,
+		secretSource: secretSourceFunc(func(ctx context.Context, id string) (map[string][]byte, error) {
+			calls++
+			return nil, apierrors.NewServiceUnavailable("secrets manager unavailable")
+		}),
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if err := controller.provisionUser(ctx, user); err == nil {
+			t.Errorf("Expected provisioning of %s to fail while the secret source fails", user)
+		}
+	}
+	if tracker.Enabled(IntegrationAWSSecrets) {
+		t.Fatalf("Expected integration %s to be disabled after persistent failures", IntegrationAWSSecrets)
+	}
+
+	// Core provisioning continues without the disabled integration
+	if err := controller.provisionUser(ctx, "carol"); err != nil {
+		t.Errorf("Expected user carol to be provisioned without secrets, but got error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the disabled secret source not to be called again, but got %d calls", calls)
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("carol").Get(ctx, roleBindingName("carol"), metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the RoleBinding of carol to exist, but got error: %v", err)
+	}
+}
+
+func TestController_deleteUserProject(t *testing.T) {
+	tests := []struct {
+		name            string
+		deleteUnmanaged string
+		expectedDeleted []string
+		expectedKept    []string
+	}{
+		{
+			name:            "only managed projects of the user are deleted",
+			expectedDeleted: []string{"alice"},
+			expectedKept:    []string{"bob", "carol"},
+		},
+		{
+			name:            "unmanaged projects are deleted when configured",
+			deleteUnmanaged: "true",
+			expectedDeleted: []string{"alice", "bob"},
+			expectedKept:    []string{"carol"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DELETE_UNMANAGED_PROJECTS", tt.deleteUnmanaged)
+
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(
+				desiredProject("alice", "alice"),
+				// a project named after the user which was kept without claiming it
+				&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "bob"}},
+				// a project named after the user but created for another user
+				desiredProject("dave", "carol"),
+			)
+			controller := &Controller{projectClient: projectClient}
+
+			for _, user := range []string{"alice", "bob", "carol"} {
+				if err := controller.deleteUserProject(ctx, user, user); err != nil {
+					t.Fatalf("Expected project of %s to be handled, but got error: %v", user, err)
+				}
+			}
+			for _, name := range tt.expectedDeleted {
+				if _, err := projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+					t.Errorf("Expected project %s to be deleted, but got error: %v", name, err)
+				}
+			}
+			for _, name := range tt.expectedKept {
+				if _, err := projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{}); err != nil {
+					t.Errorf("Expected project %s to be kept, but got error: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "forbidden",
+			err:  apierrors.NewForbidden(projectGroupResource, "alice", errors.New("denied")),
+			want: true,
+		},
+		{
+			name: "too many requests",
+			err:  apierrors.NewTooManyRequests("slow down", 1),
+			want: false,
+		},
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(projectGroupResource, "alice", errors.New("modified")),
+			want: false,
+		},
+		{
+			name: "wrapped deadline exceeded",
+			err:  fmt.Errorf("step failed: %w", context.DeadlineExceeded),
+			want: false,
+		},
+		{
+			name: "generic error",
+			err:  errors.New("invalid configuration"),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPermanentError(tt.err); got != tt.want {
+				t.Errorf("isPermanentError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_provisionUserPublishesEvents(t *testing.T) {
+	broadcaster := events.NewBroadcaster()
+	ch, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		broadcaster:   broadcaster,
+	}
+
+	if err := controller.provisionUser(context.Background(), "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	if err := controller.deprovisionUser(context.Background(), "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+
+	expected := []events.Event{
+		{Action: events.ActionProvision, Result: events.ResultStarted},
+		{Action: events.ActionProvision, Step: "project", Result: events.ResultSucceeded},
+		{Action: events.ActionProvision, Step: "rolebinding", Result: events.ResultSucceeded},
+		{Action: events.ActionProvision, Step: "prune", Result: events.ResultSucceeded},
+		{Action: events.ActionProvision, Result: events.ResultSucceeded},
+		{Action: events.ActionDeprovision, Result: events.ResultStarted},
+		{Action: events.ActionDeprovision, Step: "project", Result: events.ResultSucceeded},
+		{Action: events.ActionDeprovision, Step: "inventory", Result: events.ResultSucceeded},
+		{Action: events.ActionDeprovision, Result: events.ResultSucceeded},
+	}
+	for _, want := range expected {
+		got := <-ch
+		if got.User != "alice" || got.Action != want.Action || got.Step != want.Step || got.Result != want.Result {
+			t.Errorf("Expected event %s/%s/%s for alice, but got %+v", want.Action, want.Step, want.Result, got)
+		}
+	}
+}
+
+func TestController_provisionUserNotifies(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		expectedCount int
+	}{
+		{
+			name:          "immediate mode notifies every result",
+			mode:          "immediate",
+			expectedCount: 2,
+		},
+		{
+			name: "digest mode leaves results to the digest",
+			mode: "digest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NOTIFICATION_MODE", tt.mode)
+
+			notifier := &fakeNotifier{}
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				projectClient: projectfake.NewSimpleClientset(),
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+				notifier:      notifier,
+			}
+
+			if err := controller.provisionUser(context.Background(), "alice"); err != nil {
+				t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+			}
+			if err := controller.deprovisionUser(context.Background(), "alice"); err != nil {
+				t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+			}
+
+			if len(notifier.notifications) != tt.expectedCount {
+				t.Fatalf("Expected %d notifications, but got %d", tt.expectedCount, len(notifier.notifications))
+			}
+			if tt.expectedCount > 0 && notifier.notifications[0].Subject != "Namespace alice provision succeeded" {
+				t.Errorf("Unexpected notification subject %q", notifier.notifications[0].Subject)
+			}
+		})
+	}
+}
+
+func TestController_IsUserReady(t *testing.T) {
+	tests := []struct {
+		name        string
+		phase       corev1.NamespacePhase
+		project     bool
+		roleBinding bool
+		want        bool
+	}{
+		{
+			name: "project missing",
+			want: false,
+		},
+		{
+			name:    "project terminating",
+			project: true,
+			phase:   corev1.NamespaceTerminating,
+			want:    false,
+		},
+		{
+			name:    "RoleBinding missing",
+			project: true,
+			phase:   corev1.NamespaceActive,
+			want:    false,
+		},
+		{
+			name:        "project ready",
+			project:     true,
+			phase:       corev1.NamespaceActive,
+			roleBinding: true,
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var projectObjects []runtime.Object
+			if tt.project {
+				projectObjects = append(projectObjects, &projectv1.Project{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "alice",
+					},
+					Status: projectv1.ProjectStatus{
+						Phase: tt.phase,
+					},
+				})
+			}
+			var kubernetesObjects []runtime.Object
+			if tt.roleBinding {
+				kubernetesObjects = append(kubernetesObjects, &rbacv1.RoleBinding{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "alice-edit",
+						Namespace: "alice",
+					},
+				})
+			}
+			kubeClient := fake.NewClientset(kubernetesObjects...)
+			controller := &Controller{
+				projectClient: projectfake.NewSimpleClientset(projectObjects...),
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+
+			got, err := controller.IsUserReady(context.Background(), "alice")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsUserReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Reasons of the Events recorded for provisioning actions
+const (
+	projectCreatedReason       = "ProjectCreated"
+	projectDeletedReason       = "ProjectDeleted"
+	roleBindingCreatedReason   = "RoleBindingCreated"
+	roleBindingRestoredReason  = "RoleBindingRestored"
+	provisioningFailedReason   = "ProvisioningFailed"
+	deprovisioningFailedReason = "DeprovisioningFailed"
+)
+
+// Records an Event about a provisioning action on the target group granting the user a namespace
+// and, unless it was deleted, on the namespace itself, so actions can be audited without pod logs
+func (c *Controller) recordProvisioningEvent(ctx context.Context, user string, projectName string, eventType string, reason string, message string) {
+	if c.recorder == nil {
+		return
+	}
+
+	group, err := c.sourceGroup(ctx, user)
+	if err != nil {
+		klog.V(2).Infof("Not recording %s Event on the target group of user %s: %v", reason, user, err)
+	} else if group != nil {
+		c.recorder.Event(group, eventType, reason, message)
+	}
+
+	if reason == projectDeletedReason {
+		return
+	}
+	namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		klog.V(2).Infof("Not recording %s Event on namespace %s of user %s: %v", reason, projectName, user, err)
+		return
+	}
+	c.recorder.Event(namespace, eventType, reason, message)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestController_recordProvisioningEvent(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+
+	ctx := context.Background()
+	// The fake project client doesn't create namespaces, so the namespace exists up front
+	kubeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "alice"}})
+	recorder := record.NewFakeRecorder(20)
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("test-group", "alice")),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		recorder:      recorder,
+	}
+	drain := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+	count := func(events []string, prefix string) int {
+		n := 0
+		for _, event := range events {
+			if strings.HasPrefix(event, prefix) {
+				n++
+			}
+		}
+		return n
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	events := drain()
+	// Each action is recorded on both the group and the namespace
+	if got := count(events, "Normal ProjectCreated"); got != 2 {
+		t.Errorf("Expected 2 ProjectCreated Events, but got %d in %v", got, events)
+	}
+	if got := count(events, "Normal RoleBindingCreated"); got != 2 {
+		t.Errorf("Expected 2 RoleBindingCreated Events, but got %d in %v", got, events)
+	}
+
+	if err := controller.deprovisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be deprovisioned, but got error: %v", err)
+	}
+	// The deleted namespace has no Events recorded on it
+	if events := drain(); count(events, "Normal ProjectDeleted") != 1 {
+		t.Errorf("Expected a single ProjectDeleted Event on the group, but got %v", events)
+	}
+}
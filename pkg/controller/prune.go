@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// label marking objects seeded into user namespaces as members of the managed set,
+// following the apply-set convention of pruning by membership label
+const partOfLabel = "rosa-namespace-provisioner/part-of"
+
+// value of the partOfLabel for objects seeded into user namespaces
+const seededSet = "seeded"
+
+// selects the objects seeded into user namespaces
+var seededSelector = fmt.Sprintf("%s=%s", partOfLabel, seededSet)
+
+// Returns the labels applied to every object seeded into a user namespace
+func seededLabels(user string) map[string]string {
+	return map[string]string{
+		ownerLabel:  ownerLabelValue(user),
+		partOfLabel: seededSet,
+	}
+}
+
+// Deletes the seeded objects of a kind under the target user project which are not in the desired set,
+// through the delete function. Objects of kinds owned by the anchor are only pruned when owned by it.
+func (c *Controller) pruneSeeded(ctx context.Context, user string, projectName string, kind string, anchored bool, objects []metav1.Object, desired map[string]bool, deleteObject func(name string) error) error {
+	var anchorRef *metav1.OwnerReference
+	if anchored {
+		var err error
+		if anchorRef, err = c.anchorReference(ctx, projectName); err != nil {
+			klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+			return err
+		}
+	}
+
+	var pruneErr error
+	for _, obj := range objects {
+		if desired[obj.GetName()] {
+			continue
+		}
+		// only prune objects owned by the anchor once owner references are enabled
+		if anchorRef != nil && !ownedByAnchor(obj, anchorRef) {
+			klog.V(2).Infof("Skipping pruning of %s %s not owned by the anchor under project %s", kind, obj.GetName(), projectName)
+			continue
+		}
+		if err := deleteObject(obj.GetName()); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error pruning %s %s for user %s under project %s: %v", kind, obj.GetName(), user, projectName, err)
+			pruneErr = err
+			continue
+		}
+		klog.Infof("Pruned %s %s no longer seeded for user %s under project %s", kind, obj.GetName(), user, projectName)
+	}
+	return pruneErr
+}
+
+// Deletes the seeded Secrets of the target user project which are no longer part of the desired set
+func (c *Controller) pruneSeededSecrets(ctx context.Context, user string, projectName string, desired map[string]bool) error {
+	secrets, err := c.coreClient.Secrets(projectName).List(ctx, metav1.ListOptions{LabelSelector: seededSelector})
+	if err != nil {
+		klog.Errorf("Error listing seeded Secrets for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		objects = append(objects, &secrets.Items[i])
+	}
+	return c.pruneSeeded(ctx, user, projectName, "Secret", true, objects, desired, func(name string) error {
+		return c.coreClient.Secrets(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the seeded NetworkPolicies of the target user project which are no longer part of the
+// desired set
+func (c *Controller) pruneSeededNetworkPolicies(ctx context.Context, user string, projectName string, desired map[string]bool) error {
+	policies, err := c.networkingClient.NetworkPolicies(projectName).List(ctx, metav1.ListOptions{LabelSelector: seededSelector})
+	if err != nil {
+		klog.Errorf("Error listing seeded NetworkPolicies for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(policies.Items))
+	for i := range policies.Items {
+		objects = append(objects, &policies.Items[i])
+	}
+	return c.pruneSeeded(ctx, user, projectName, "NetworkPolicy", false, objects, desired, func(name string) error {
+		return c.networkingClient.NetworkPolicies(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the seeded ResourceQuotas of the target user project which are no longer part of the
+// desired set
+func (c *Controller) pruneSeededResourceQuotas(ctx context.Context, user string, projectName string, desired map[string]bool) error {
+	quotas, err := c.coreClient.ResourceQuotas(projectName).List(ctx, metav1.ListOptions{LabelSelector: seededSelector})
+	if err != nil {
+		klog.Errorf("Error listing seeded ResourceQuotas for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(quotas.Items))
+	for i := range quotas.Items {
+		objects = append(objects, &quotas.Items[i])
+	}
+	return c.pruneSeeded(ctx, user, projectName, "ResourceQuota", false, objects, desired, func(name string) error {
+		return c.coreClient.ResourceQuotas(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the seeded LimitRanges of the target user project which are no longer part of the desired set
+func (c *Controller) pruneSeededLimitRanges(ctx context.Context, user string, projectName string, desired map[string]bool) error {
+	limitRanges, err := c.coreClient.LimitRanges(projectName).List(ctx, metav1.ListOptions{LabelSelector: seededSelector})
+	if err != nil {
+		klog.Errorf("Error listing seeded LimitRanges for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	objects := make([]metav1.Object, 0, len(limitRanges.Items))
+	for i := range limitRanges.Items {
+		objects = append(objects, &limitRanges.Items[i])
+	}
+	return c.pruneSeeded(ctx, user, projectName, "LimitRange", false, objects, desired, func(name string) error {
+		return c.coreClient.LimitRanges(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the seeded NetworkQoS of the target user project, which is only ever seeded under one name
+func (c *Controller) pruneSeededNetworkQoS(ctx context.Context, user string, projectName string) error {
+	client := c.dynamicClient.Resource(networkQoSResource).Namespace(projectName)
+	networkQoS, err := client.Get(ctx, networkQoSName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// OVN-Kubernetes may not serve NetworkQoSes on this cluster, so there may be nothing to prune
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error getting NetworkQoS %s for user %s under project %s: %v", networkQoSName, user, projectName, err)
+		return err
+	}
+	var objects []metav1.Object
+	if networkQoS.GetLabels()[partOfLabel] == seededSet {
+		objects = append(objects, networkQoS)
+	}
+	return c.pruneSeeded(ctx, user, projectName, "NetworkQoS", false, objects, nil, func(name string) error {
+		return client.Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes the onboarding Secret of the target user project, which is a set of its own so the pruning of
+// materialized Secrets never removes it
+func (c *Controller) pruneOnboardingSecret(ctx context.Context, user string, projectName string) error {
+	secret, err := c.coreClient.Secrets(projectName).Get(ctx, onboardingSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error getting onboarding Secret %s for user %s under project %s: %v", onboardingSecretName, user, projectName, err)
+		return err
+	}
+	var objects []metav1.Object
+	if secret.Labels[partOfLabel] == onboardingSet {
+		objects = append(objects, secret)
+	}
+	return c.pruneSeeded(ctx, user, projectName, "Secret", true, objects, nil, func(name string) error {
+		return c.coreClient.Secrets(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+}
+
+// Deletes every object seeded into the target user project that the current configuration no longer
+// seeds, including all seeded objects of features that were turned off. Kinds whose own step prunes
+// them against the desired set, such as the NetworkPolicies of an enabled NETWORK_POLICIES, are left
+// to that step.
+func (c *Controller) pruneSeededObjects(ctx context.Context, user string, projectName string) error {
+	var errs []error
+
+	if c.coreClient != nil {
+		quotas := make(map[string]bool)
+		if GetDenyLoadBalancersEnabled() {
+			quotas[loadBalancerQuotaName] = true
+		}
+		if GetResourceQuotaEnabled() {
+			quotas[computeQuotaName] = true
+		}
+		if GetObjectCountQuotaEnabled() {
+			quotas[objectCountQuotaName] = true
+		}
+		errs = append(errs, c.pruneSeededResourceQuotas(ctx, user, projectName, quotas))
+
+		if !GetLimitRangeEnabled() {
+			errs = append(errs, c.pruneSeededLimitRanges(ctx, user, projectName, nil))
+		}
+
+		// materialized Secrets are pruned by the secrets step while an external secret source is configured
+		if c.secretSource == nil {
+			errs = append(errs, c.pruneSeededSecrets(ctx, user, projectName, nil))
+		}
+		if !GetOnboardingEnabled() || !onboardingDelivered(OnboardingDeliverySecret) {
+			errs = append(errs, c.pruneOnboardingSecret(ctx, user, projectName))
+		}
+		errs = append(errs, c.pruneAutomation(ctx, user, projectName))
+		errs = append(errs, c.pruneSeedResources(ctx, user, projectName))
+	}
+	if !GetNetworkPoliciesEnabled() && c.networkingClient != nil {
+		errs = append(errs, c.pruneSeededNetworkPolicies(ctx, user, projectName, nil))
+	}
+	if !GetBandwidthLimitsEnabled() && c.dynamicClient != nil {
+		errs = append(errs, c.pruneSeededNetworkQoS(ctx, user, projectName))
+	}
+	return errors.Join(errs...)
+}
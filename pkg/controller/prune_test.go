@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_pruneSeededSecrets(t *testing.T) {
+	ctx := context.Background()
+	coreClient := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "model-api-key",
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "retired-key",
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "user-secret",
+				Namespace: "alice",
+			},
+		},
+	).CoreV1()
+	controller := &Controller{
+		coreClient: coreClient,
+	}
+
+	err := controller.pruneSeededSecrets(ctx, "alice", "alice", map[string]bool{"model-api-key": true})
+	if err != nil {
+		t.Fatalf("Expected seeded Secrets to be pruned, but got error: %v", err)
+	}
+
+	if _, err := coreClient.Secrets("alice").Get(ctx, "retired-key", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Secret retired-key to be pruned, but it still exists")
+	}
+	for _, name := range []string{"model-api-key", "user-secret"} {
+		if _, err := coreClient.Secrets("alice").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected Secret %s to be kept, but got error: %v", name, err)
+		}
+	}
+}
+
+func TestController_pruneSeededSecretsOwnedByAnchor(t *testing.T) {
+	t.Setenv("OWNER_REFERENCES_ENABLED", "true")
+
+	ctx := context.Background()
+	anchorRef := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: anchorConfigMapName, UID: types.UID("anchor-uid")}
+	coreClient := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: anchorConfigMapName, Namespace: "alice", UID: anchorRef.UID},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "owned-key",
+				Namespace:       "alice",
+				Labels:          seededLabels("alice"),
+				OwnerReferences: []metav1.OwnerReference{anchorRef},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "copied-key",
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+	).CoreV1()
+	controller := &Controller{
+		coreClient: coreClient,
+	}
+
+	if err := controller.pruneSeededSecrets(ctx, "alice", "alice", map[string]bool{}); err != nil {
+		t.Fatalf("Expected seeded Secrets to be pruned, but got error: %v", err)
+	}
+
+	if _, err := coreClient.Secrets("alice").Get(ctx, "owned-key", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected Secret owned-key to be pruned, but it still exists")
+	}
+	if _, err := coreClient.Secrets("alice").Get(ctx, "copied-key", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret copied-key not owned by the anchor to be kept, but got error: %v", err)
+	}
+}
+
+func TestController_pruneSeededObjects(t *testing.T) {
+	t.Setenv("RESOURCE_QUOTA_ENABLED", "true")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		desiredLoadBalancerQuota("alice", "alice"),
+		&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      computeQuotaName,
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+		&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "user-quota",
+				Namespace: "alice",
+			},
+		},
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      limitRangeName,
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "deny-other-users",
+				Namespace: "alice",
+				Labels:    seededLabels("alice"),
+			},
+		},
+	)
+	controller := &Controller{
+		coreClient:       kubeClient.CoreV1(),
+		networkingClient: kubeClient.NetworkingV1(),
+	}
+
+	// The objects of features turned off are pruned, those of enabled features and unlabeled ones kept
+	if err := controller.pruneSeededObjects(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected seeded objects to be pruned, but got error: %v", err)
+	}
+	for _, name := range []string{loadBalancerQuotaName} {
+		if _, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, name, metav1.GetOptions{}); !errors.IsNotFound(err) {
+			t.Errorf("Expected ResourceQuota %s of a disabled feature to be pruned", name)
+		}
+	}
+	for _, name := range []string{computeQuotaName, "user-quota"} {
+		if _, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, name, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected ResourceQuota %s to be kept, but got error: %v", name, err)
+		}
+	}
+	if _, err := kubeClient.CoreV1().LimitRanges("alice").Get(ctx, limitRangeName, metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected LimitRange %s of a disabled feature to be pruned", limitRangeName)
+	}
+	if _, err := kubeClient.NetworkingV1().NetworkPolicies("alice").Get(ctx, "deny-other-users", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected NetworkPolicy deny-other-users of a disabled feature to be pruned")
+	}
+}
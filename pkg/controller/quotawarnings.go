@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+)
+
+// reason of the Event emitted when quota usage crosses the warning threshold
+const quotaUsageHighReason = "QuotaUsageHigh"
+
+// usedQuota describes the usage of a single quota object
+type usedQuota struct {
+	object    runtime.Object
+	kind      string
+	name      string
+	namespace string
+	hard      corev1.ResourceList
+	used      corev1.ResourceList
+}
+
+// Compares quota usage against hard limits for every managed namespace, warning the owner
+// once per resource when usage crosses the configured threshold
+func (c *Controller) checkQuotaUsage(ctx context.Context) {
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects for quota usage check: %v", err)
+		return
+	}
+
+	warned := make(map[string]bool)
+	for _, project := range projects.Items {
+		user := objectOwner(&project)
+		quotas, err := c.coreClient.ResourceQuotas(project.Name).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			klog.Errorf("Error listing ResourceQuotas for user %s under project %s: %v", user, project.Name, err)
+			continue
+		}
+
+		for i := range quotas.Items {
+			quota := &quotas.Items[i]
+			c.checkUsedQuota(ctx, user, usedQuota{
+				object:    quota,
+				kind:      "ResourceQuota",
+				name:      quota.Name,
+				namespace: quota.Namespace,
+				hard:      quota.Status.Hard,
+				used:      quota.Status.Used,
+			}, warned)
+		}
+	}
+
+	if GetClusterResourceQuotaEnabled() {
+		owners := make(map[string]string)
+		for _, project := range projects.Items {
+			owners[objectOwner(&project)] = project.Name
+		}
+		for user, namespace := range owners {
+			quota, err := c.quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, clusterResourceQuotaName(user), metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					klog.Errorf("Error getting ClusterResourceQuota for user %s: %v", user, err)
+				}
+				continue
+			}
+			c.checkUsedQuota(ctx, user, usedQuota{
+				object:    quota,
+				kind:      "ClusterResourceQuota",
+				name:      quota.Name,
+				namespace: namespace,
+				hard:      quota.Status.Total.Hard,
+				used:      quota.Status.Total.Used,
+			}, warned)
+		}
+	}
+
+	// Forget warnings whose usage dropped below the threshold so they fire again next time
+	c.quotaWarnings = warned
+}
+
+// Warns the owner about every resource of the quota at or above the threshold that was not
+// already reported, recording the resources currently above it in warned
+func (c *Controller) checkUsedQuota(ctx context.Context, user string, quota usedQuota, warned map[string]bool) {
+	threshold := GetQuotaWarningThreshold()
+	for name, hard := range quota.hard {
+		used := quota.used[name]
+		ratio, ok := quotaUsageRatio(hard, used)
+		if !ok || ratio < threshold {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s/%s", quota.kind, quota.namespace, quota.name, name)
+		warned[key] = true
+		if c.quotaWarnings[key] {
+			// owner was already warned while usage stayed above the threshold
+			continue
+		}
+
+		message := fmt.Sprintf("Usage of %s in %s %s is at %.0f%% (%s of %s)",
+			name,
+			quota.kind,
+			quota.name,
+			ratio*100,
+			used.String(),
+			hard.String(),
+		)
+		klog.Infof("Quota usage warning for user %s under project %s: %s", user, quota.namespace, message)
+
+		if c.recorder != nil {
+			c.recorder.Event(quota.object, corev1.EventTypeWarning, quotaUsageHighReason, message)
+		}
+
+		if c.notifier != nil {
+			notification := notify.Notification{
+				User:      user,
+				Namespace: quota.namespace,
+				Severity:  notify.SeverityWarning,
+				Subject:   fmt.Sprintf("Quota usage high in namespace %s", quota.namespace),
+				Message:   message,
+			}
+			if err := c.notifier.Notify(ctx, notification); err != nil {
+				klog.Errorf("Error notifying user %s about quota usage: %v", user, err)
+			}
+			c.notifyDelegates(ctx, user, notification)
+		}
+	}
+}
+
+// Returns the fraction of the hard limit in use, or false when there is no usable limit
+func quotaUsageRatio(hard resource.Quantity, used resource.Quantity) (float64, bool) {
+	if hard.IsZero() {
+		return 0, false
+	}
+	return used.AsApproximateFloat64() / hard.AsApproximateFloat64(), true
+}
@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	quotav1 "github.com/openshift/api/quota/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/notify"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeNotifier records the notifications it receives
+type fakeNotifier struct {
+	notifications []notify.Notification
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, notification notify.Notification) error {
+	f.notifications = append(f.notifications, notification)
+	return nil
+}
+
+// Returns a ResourceQuota in the given namespace with the given pods usage
+func newPodsQuota(namespace string, hard string, used string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "compute",
+			Namespace: namespace,
+		},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse(hard)},
+			Used: corev1.ResourceList{corev1.ResourcePods: resource.MustParse(used)},
+		},
+	}
+}
+
+func TestController_checkQuotaUsage(t *testing.T) {
+	t.Setenv("QUOTA_WARNING_THRESHOLD", "80")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset(
+		&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+		&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "bob", Labels: map[string]string{ownerLabel: "bob"}}},
+	)
+	kubeClient := fake.NewClientset(
+		newPodsQuota("alice", "10", "9"),
+		newPodsQuota("bob", "10", "2"),
+	)
+	recorder := record.NewFakeRecorder(10)
+	notifier := &fakeNotifier{}
+	controller := &Controller{
+		projectClient: projectClient,
+		coreClient:    kubeClient.CoreV1(),
+		recorder:      recorder,
+		notifier:      notifier,
+	}
+
+	controller.checkQuotaUsage(ctx)
+
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("Expected 1 notification, but got %d", len(notifier.notifications))
+	}
+	if got := notifier.notifications[0].User; got != "alice" {
+		t.Errorf("Expected notification for user alice, but got %q", got)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected 1 Event to be recorded, but got %d", len(recorder.Events))
+	}
+
+	// Usage staying above the threshold should not warn again
+	controller.checkQuotaUsage(ctx)
+	if len(notifier.notifications) != 1 {
+		t.Errorf("Expected no repeated notification, but got %d notifications", len(notifier.notifications))
+	}
+
+	// Usage dropping below the threshold should re-arm the warning
+	_, err := kubeClient.CoreV1().ResourceQuotas("alice").UpdateStatus(ctx, newPodsQuota("alice", "10", "1"), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota status to be updated, but got error: %v", err)
+	}
+	controller.checkQuotaUsage(ctx)
+	_, err = kubeClient.CoreV1().ResourceQuotas("alice").UpdateStatus(ctx, newPodsQuota("alice", "10", "10"), metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota status to be updated, but got error: %v", err)
+	}
+	controller.checkQuotaUsage(ctx)
+	if len(notifier.notifications) != 2 {
+		t.Errorf("Expected warning to fire again after usage dropped, but got %d notifications", len(notifier.notifications))
+	}
+}
+
+func TestController_checkQuotaUsage_clusterResourceQuota(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+
+	ctx := context.Background()
+	quota := &quotav1.ClusterResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "alice-quota"},
+	}
+	quota.Status.Total.Hard = corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("4")}
+	quota.Status.Total.Used = corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("3800m")}
+
+	notifier := &fakeNotifier{}
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(
+			&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+		),
+		quotaClient: quotafake.NewClientset(quota),
+		coreClient:  fake.NewClientset().CoreV1(),
+		notifier:    notifier,
+	}
+
+	controller.checkQuotaUsage(ctx)
+
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("Expected 1 notification, but got %d", len(notifier.notifications))
+	}
+	if got := notifier.notifications[0].Namespace; got != "alice" {
+		t.Errorf("Expected notification for namespace alice, but got %q", got)
+	}
+}
+
+func TestQuotaUsageRatio(t *testing.T) {
+	tests := []struct {
+		name   string
+		hard   string
+		used   string
+		want   float64
+		wantOK bool
+	}{
+		{
+			name:   "half used",
+			hard:   "10",
+			used:   "5",
+			want:   0.5,
+			wantOK: true,
+		},
+		{
+			name:   "different units",
+			hard:   "2Gi",
+			used:   "1536Mi",
+			want:   0.75,
+			wantOK: true,
+		},
+		{
+			name: "zero hard limit",
+			hard: "0",
+			used: "0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := quotaUsageRatio(resource.MustParse(tt.hard), resource.MustParse(tt.used))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Expected ratio %v (%v), but got %v (%v)", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
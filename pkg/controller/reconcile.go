@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"context"
+
+	userv1 "github.com/openshift/api/user/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation on the target group or a managed namespace forcing a full reconcile whenever its value
+// changes, e.g. set to the current timestamp
+const reconcileAnnotation = "rosa-namespace-provisioner/reconcile"
+
+// Returns whether the reconcile annotation changed between two revisions of an object
+func reconcileRequested(oldObj, newObj metav1.Object) bool {
+	value := newObj.GetAnnotations()[reconcileAnnotation]
+	return value != "" && value != oldObj.GetAnnotations()[reconcileAnnotation]
+}
+
+// Re-provisions every member of the target group, given sorted by name
+func (c *Controller) reconcileGroup(group *userv1.Group, members []string) {
+	klog.Infof("Reconcile of group %s requested with %s=%s, re-provisioning %d users",
+		group.Name,
+		reconcileAnnotation,
+		group.Annotations[reconcileAnnotation],
+		len(members),
+	)
+	ctx := withTrigger(context.Background(), TriggerAdmin)
+	for _, user := range members {
+		_ = c.admitUser(ctx, user)
+	}
+}
+
+// Re-provisions the owner of a managed namespace when a reconcile of it was requested
+func (c *Controller) handleNamespaceReconcile(oldNamespace, newNamespace *corev1.Namespace) {
+	if !reconcileRequested(oldNamespace, newNamespace) || newNamespace.DeletionTimestamp != nil {
+		return
+	}
+	user := objectOwner(newNamespace)
+	klog.Infof("Reconcile of namespace %s requested with %s=%s",
+		newNamespace.Name,
+		reconcileAnnotation,
+		newNamespace.Annotations[reconcileAnnotation],
+	)
+
+	ctx := withTrigger(context.Background(), TriggerAdmin)
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s to reconcile namespace %s: %v", membershipDescription(), newNamespace.Name, err)
+		return
+	}
+	if !members[user] {
+		klog.Warningf("Skipping reconcile of namespace %s as its owner %s is not granted a namespace by %s", newNamespace.Name, user, membershipDescription())
+		return
+	}
+	_ = c.provisionUser(ctx, user)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileRequested(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldValue string
+		newValue string
+		want     bool
+	}{
+		{
+			name:     "annotation added",
+			newValue: "2026-10-16T10:00:00Z",
+			want:     true,
+		},
+		{
+			name:     "annotation changed",
+			oldValue: "2026-10-16T10:00:00Z",
+			newValue: "2026-10-16T11:00:00Z",
+			want:     true,
+		},
+		{
+			name:     "annotation unchanged",
+			oldValue: "2026-10-16T10:00:00Z",
+			newValue: "2026-10-16T10:00:00Z",
+		},
+		{
+			name:     "annotation removed",
+			oldValue: "2026-10-16T10:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldGroup := newGroup("test-group")
+			updatedGroup := newGroup("test-group")
+			if tt.oldValue != "" {
+				oldGroup.Annotations = map[string]string{reconcileAnnotation: tt.oldValue}
+			}
+			if tt.newValue != "" {
+				updatedGroup.Annotations = map[string]string{reconcileAnnotation: tt.newValue}
+			}
+
+			if got := reconcileRequested(oldGroup, updatedGroup); got != tt.want {
+				t.Errorf("reconcileRequested() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestController_handleGroupReconcile(t *testing.T) {
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	// Membership is unchanged, so only the annotation triggers provisioning
+	oldGroup := newGroup("test-group", "alice", "bob")
+	updatedGroup := newGroup("test-group", "alice", "bob")
+	updatedGroup.Annotations = map[string]string{reconcileAnnotation: "2026-10-16T10:00:00Z"}
+	controller.handleGroup(oldGroup, updatedGroup)
+
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := projectClient.ProjectV1().Projects().Get(ctx, user, metav1.GetOptions{}); err != nil {
+			t.Errorf("Expected project %s to be reconciled, but got error: %v", user, err)
+		}
+	}
+}
+
+func TestController_handleNamespaceReconcile(t *testing.T) {
+	tests := []struct {
+		name            string
+		owner           string
+		expectedProject bool
+	}{
+		{
+			name:            "owner is a member",
+			owner:           "alice",
+			expectedProject: true,
+		},
+		{
+			name:  "owner left the group",
+			owner: "bob",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset()
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				userClient:    userfake.NewSimpleClientset(newGroup(GetTargetGroupName(), "alice")),
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+
+			oldNamespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   tt.owner,
+					Labels: map[string]string{ownerLabel: tt.owner},
+				},
+			}
+			newNamespace := oldNamespace.DeepCopy()
+			newNamespace.Annotations = map[string]string{reconcileAnnotation: "2026-10-16T10:00:00Z"}
+			controller.handleNamespaceReconcile(oldNamespace, newNamespace)
+
+			_, err := projectClient.ProjectV1().Projects().Get(ctx, tt.owner, metav1.GetOptions{})
+			if tt.expectedProject && err != nil {
+				t.Errorf("Expected project %s to be reconciled, but got error: %v", tt.owner, err)
+			} else if !tt.expectedProject && !errors.IsNotFound(err) {
+				t.Errorf("Expected project %s not to be reconciled, but got error: %v", tt.owner, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Provisions the owner of a managed namespace again once it is gone, when it was deleted out-of-band,
+// e.g. by an admin, while they are still granted a namespace. Namespaces the controller deleted,
+// retained or migrated, and namespaces which only stopped being labeled, are left gone.
+func (c *Controller) recreateDeletedProject(ctx context.Context, namespace *corev1.Namespace) {
+	user := objectOwner(namespace)
+	if user == "" || c.deletionPending(namespace.Name) || removedUserMarked(namespace) ||
+		namespace.Annotations[migratedToAnnotation] != "" || c.ProjectName(user) != namespace.Name {
+		return
+	}
+
+	// a namespace whose owner label was removed, e.g. orphaned, leaves the watch without being deleted
+	_, err := c.coreClient.Namespaces().Get(ctx, namespace.Name, metav1.GetOptions{})
+	if err == nil {
+		return
+	} else if !apierrors.IsNotFound(err) {
+		klog.Errorf("Error checking if namespace %s of user %s was deleted: %v", namespace.Name, user, err)
+		return
+	}
+
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s to recreate namespace %s: %v", membershipDescription(), namespace.Name, err)
+		return
+	}
+	if !members[user] {
+		return
+	}
+	klog.Warningf("Namespace %s of user %s was deleted out-of-band, provisioning it again", namespace.Name, user)
+	_ = c.provisionUser(ctx, user)
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_recreateDeletedProject(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "cohort")
+	t.Setenv("RECREATE_DELETED_PROJECTS", "true")
+
+	ownedNamespace := func(user string) *corev1.Namespace {
+		return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        user,
+			Labels:      map[string]string{ownerLabel: user},
+			Annotations: map[string]string{},
+		}}
+	}
+	migrated := ownedNamespace("dave")
+	migrated.Annotations[migratedToAnnotation] = "user-dave"
+	// erin's namespace was orphaned, so it left the watch without being deleted
+	orphaned := ownedNamespace("erin")
+
+	tests := []struct {
+		name      string
+		namespace *corev1.Namespace
+		pending   bool
+		recreated bool
+	}{
+		{name: "deleted by an admin", namespace: ownedNamespace("alice"), recreated: true},
+		{name: "deleted by the controller", namespace: ownedNamespace("bob"), pending: true},
+		{name: "owner no longer a member", namespace: ownedNamespace("carol")},
+		{name: "migrated", namespace: migrated},
+		{name: "still exists", namespace: orphaned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset()
+			kubeClient := fake.NewClientset(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "erin"}})
+			controller := &Controller{
+				userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice", "bob", "dave", "erin")),
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+			user := tt.namespace.Labels[ownerLabel]
+			if tt.pending {
+				controller.trackDeletion(user, tt.namespace.Name, time.Now())
+			}
+
+			controller.recreateDeletedProject(ctx, tt.namespace)
+			_, err := projectClient.ProjectV1().Projects().Get(ctx, user, metav1.GetOptions{})
+			if recreated := err == nil; recreated != tt.recreated {
+				t.Errorf("Expected project %s to be recreated: %v, but got error: %v", user, tt.recreated, err)
+			}
+		})
+	}
+}
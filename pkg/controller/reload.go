@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// serializes configuration changes, so each candidate is validated against the configuration it
+// replaces
+var reloadMu sync.Mutex
+
+// configuration variables a ProvisionerConfig or configuration file may change without a restart, as
+// every member is re-provisioned once they change. The others are read by the controller on startup.
+var reloadableVariables = map[string]bool{
+	"TARGET_GROUP_NAME":           true,
+	"TARGET_GROUP_NAMES":          true,
+	"ALLOWED_USERS":               true,
+	"ALLOWED_USER_REGEX":          true,
+	"EXCLUDED_USERS":              true,
+	"EXCLUDED_USER_REGEX":         true,
+	"USER_CLUSTER_ROLE":           true,
+	"USER_CLUSTER_ROLE_OVERRIDES": true,
+	"RESOURCE_QUOTA_ENABLED":      true,
+	"RESOURCE_QUOTA_HARD":         true,
+	"LIMIT_RANGE_ENABLED":         true,
+	"LIMIT_RANGE_FILE":            true,
+	"PROJECT_DELETION_POLICY":     true,
+	"DELETION_GRACE_PERIOD":       true,
+}
+
+// Validates a candidate configuration before it replaces the current one, returning every ConfigError
+// found joined into a single error. The variables are the ones set by the replaced or the candidate
+// settings, and changing any of them that is only read on startup is rejected.
+func validateReload(candidate configLookup, limitRange *corev1.LimitRangeSpec, variables []string) error {
+	var errs []error
+	sort.Strings(variables)
+	for _, variable := range variables {
+		if reloadableVariables[variable] {
+			continue
+		}
+		current, currentSet := lookupEnv(variable)
+		value, set := candidate(variable)
+		if current == value && currentSet == set {
+			continue
+		}
+		err := errors.New("can only be changed with a restart")
+		// a new template would give every member a second namespace instead of renaming theirs
+		if variable == "PROJECT_NAME_TEMPLATE" {
+			err = errors.New("can only be changed with a restart, after moving existing namespaces with migrate-naming")
+		}
+		errs = append(errs, &ConfigError{Variable: variable, Err: err})
+	}
+	errs = append(errs, validateAppliedSettings(candidate, limitRange)...)
+	return errors.Join(errs...)
+}
+
+// Returns the variables set by either settings, without duplicates
+func variablesSetBy(previous map[string]string, next map[string]string) []string {
+	seen := make(map[string]bool, len(previous)+len(next))
+	var variables []string
+	for _, values := range []map[string]string{previous, next} {
+		for variable := range values {
+			if !seen[variable] {
+				seen[variable] = true
+				variables = append(variables, variable)
+			}
+		}
+	}
+	return variables
+}
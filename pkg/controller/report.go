@@ -0,0 +1,302 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// NamespaceReport describes a managed namespace and how it drifted from its desired state
+type NamespaceReport struct {
+	Namespace string    `json:"namespace"`
+	Owner     string    `json:"owner"`
+	Phase     string    `json:"phase"`
+	Created   time.Time `json:"created"`
+	Drift     []string  `json:"drift,omitempty"`
+	// Exceptions lists the approved policy exceptions, whose objects aren't checked for drift
+	Exceptions []v1alpha1.PolicyException `json:"exceptions,omitempty"`
+}
+
+// Report returns the inventory of managed namespaces along with any drift from their desired
+// state and the policy exceptions approved for them. It only issues read requests so it can run with
+// a read-only ServiceAccount.
+func (c *Controller) Report(ctx context.Context) ([]NamespaceReport, error) {
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects for report: %v", err)
+		return nil, err
+	}
+
+	reports := make([]NamespaceReport, 0, len(projects.Items))
+	for _, project := range projects.Items {
+		user := objectOwner(&project)
+		exceptions, err := c.policyExceptions(ctx, project.Name)
+		if err != nil {
+			return nil, err
+		}
+		var drift []string
+		// retained projects of removed users are no longer reconciled
+		if _, retained := project.Annotations[retainedAnnotation]; !retained {
+			drift, err = c.namespaceDrift(ctx, user, project.Name, exceptions)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if project.Status.Phase != corev1.NamespaceActive {
+			drift = append([]string{fmt.Sprintf("project is %s", project.Status.Phase)}, drift...)
+		}
+		reports = append(reports, NamespaceReport{
+			Namespace:  project.Name,
+			Owner:      user,
+			Phase:      string(project.Status.Phase),
+			Created:    project.CreationTimestamp.Time,
+			Drift:      drift,
+			Exceptions: exceptions,
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Namespace < reports[j].Namespace
+	})
+	return reports, nil
+}
+
+// Returns how the objects managed for the target user project differ from their desired state,
+// skipping the policy objects an exception was approved to
+func (c *Controller) namespaceDrift(ctx context.Context, user string, projectName string, exceptions []v1alpha1.PolicyException) ([]string, error) {
+	var drift []string
+
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	name := roleBindingName(projectName)
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case !open && errors.IsNotFound(err):
+	case errors.IsNotFound(err):
+		drift = append(drift, fmt.Sprintf("RoleBinding %s is missing", name))
+	case err != nil:
+		return nil, err
+	case !open:
+		drift = append(drift, fmt.Sprintf("RoleBinding %s grants access outside the access window", name))
+	default:
+		clusterRole, err := c.userClusterRole(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if roleBinding.RoleRef.Kind != "ClusterRole" || roleBinding.RoleRef.Name != clusterRole {
+			drift = append(drift, fmt.Sprintf("RoleBinding %s does not grant ClusterRole %s", name, clusterRole))
+		}
+		if !bindsUser(roleBinding, user) {
+			drift = append(drift, fmt.Sprintf("RoleBinding %s does not bind user %s", name, user))
+		} else if len(roleBinding.Subjects) > 1 {
+			drift = append(drift, fmt.Sprintf("RoleBinding %s binds subjects other than user %s", name, user))
+		}
+	}
+
+	if open {
+		bindingsDrift, err := c.additionalRoleBindingsDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, bindingsDrift...)
+	}
+
+	if GetDelegatesEnabled() {
+		delegatesDrift, err := c.delegatesDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if delegatesDrift != "" {
+			drift = append(drift, delegatesDrift)
+		}
+	}
+
+	if GetOpsGroupName() != "" {
+		opsDrift, err := c.opsDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if opsDrift != "" {
+			drift = append(drift, opsDrift)
+		}
+	}
+
+	if GetPeerClusterRole() != "" {
+		peersDrift, err := c.peersDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if peersDrift != "" {
+			drift = append(drift, peersDrift)
+		}
+	}
+
+	if GetAutomationServiceAccount() != "" {
+		automationDrift, err := c.automationDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, automationDrift...)
+	}
+
+	if GetDenyLoadBalancersEnabled() && findPolicyException(exceptions, "ResourceQuota", loadBalancerQuotaName) == nil {
+		quotaDrift, err := c.resourceQuotaDrift(ctx, projectName, desiredLoadBalancerQuota(user, projectName))
+		if err != nil {
+			return nil, err
+		}
+		if quotaDrift != "" {
+			drift = append(drift, quotaDrift)
+		}
+	}
+
+	if GetResourceQuotaEnabled() && findPolicyException(exceptions, "ResourceQuota", computeQuotaName) == nil {
+		quota, err := c.userComputeQuota(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		quotaDrift, err := c.resourceQuotaDrift(ctx, projectName, quota)
+		if err != nil {
+			return nil, err
+		}
+		if quotaDrift != "" {
+			drift = append(drift, quotaDrift)
+		}
+	}
+
+	if GetLimitRangeEnabled() && findPolicyException(exceptions, "LimitRange", limitRangeName) == nil {
+		limitRangeDrift, err := c.limitRangeDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if limitRangeDrift != "" {
+			drift = append(drift, limitRangeDrift)
+		}
+	}
+
+	if GetNetworkPoliciesEnabled() {
+		policyDrift, err := c.networkPolicyDrift(ctx, user, projectName, exceptions)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, policyDrift...)
+	}
+
+	if GetBandwidthLimitsEnabled() {
+		bandwidthDrift, err := c.networkQoSDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if bandwidthDrift != "" {
+			drift = append(drift, bandwidthDrift)
+		}
+	}
+
+	if GetObjectCountQuotaEnabled() && findPolicyException(exceptions, "ResourceQuota", objectCountQuotaName) == nil {
+		quota, err := desiredObjectCountQuota(user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		quotaDrift, err := c.resourceQuotaDrift(ctx, projectName, quota)
+		if err != nil {
+			return nil, err
+		}
+		if quotaDrift != "" {
+			drift = append(drift, quotaDrift)
+		}
+	}
+
+	if GetClusterResourceQuotaEnabled() {
+		name := clusterResourceQuotaName(user)
+		_, err := c.quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			drift = append(drift, fmt.Sprintf("ClusterResourceQuota %s is missing", name))
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	if GetNamespaceFinalizerEnabled() {
+		namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if !hasFinalizer(namespace, protectionFinalizer) {
+			drift = append(drift, fmt.Sprintf("finalizer %s is missing", protectionFinalizer))
+		}
+	}
+
+	if GetAuditTaggingEnabled() {
+		namespace, err := c.coreClient.Namespaces().Get(ctx, projectName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, label := range missingAuditLabels(namespace, desiredAuditLabels(user)) {
+			drift = append(drift, fmt.Sprintf("audit label %s is missing", label))
+		}
+	}
+
+	if seedResourcesEnabled() {
+		seedDrift, err := c.seedResourcesDrift(ctx, projectName)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, seedDrift...)
+	}
+
+	if templateBundleEnabled() {
+		bundleDrift, err := c.templateBundleDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, bundleDrift...)
+	}
+
+	if GetAWSSecretsEnabled() {
+		mappings, err := c.userSecretMappings(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, mapping := range mappings {
+			_, err := c.coreClient.Secrets(projectName).Get(ctx, mapping.SecretName, metav1.GetOptions{})
+			if errors.IsNotFound(err) {
+				drift = append(drift, fmt.Sprintf("Secret %s is missing", mapping.SecretName))
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if GetOnboardingEnabled() && onboardingDelivered(OnboardingDeliverySecret) {
+		onboardingDrift, err := c.onboardingDrift(ctx, user, projectName)
+		if err != nil {
+			return nil, err
+		}
+		if onboardingDrift != "" {
+			drift = append(drift, onboardingDrift)
+		}
+	}
+
+	return drift, nil
+}
+
+// Returns whether the RoleBinding binds the target user
+func bindsUser(roleBinding *rbacv1.RoleBinding, user string) bool {
+	for _, subject := range roleBinding.Subjects {
+		if subject.Kind == "User" && subject.Name == user {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Returns an active project owned by the target user
+func newOwnedProject(user string) *projectv1.Project {
+	return &projectv1.Project{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   user,
+			Labels: map[string]string{ownerLabel: user},
+		},
+		Status: projectv1.ProjectStatus{Phase: corev1.NamespaceActive},
+	}
+}
+
+func TestController_Report(t *testing.T) {
+	t.Setenv("CLUSTER_RESOURCE_QUOTA_ENABLED", "true")
+
+	kubeClient := fake.NewClientset(
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice-edit", Namespace: "alice"},
+			Subjects:   []rbacv1.Subject{{Kind: "User", Name: "alice"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "edit"},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "bob-edit", Namespace: "bob"},
+			Subjects:   []rbacv1.Subject{{Kind: "User", Name: "mallory"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "admin"},
+		},
+	)
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(newOwnedProject("bob"), newOwnedProject("alice"), newOwnedProject("carol")),
+		rbacClient:    kubeClient.RbacV1(),
+		quotaClient:   quotafake.NewClientset(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	reports, err := controller.Report(context.Background())
+	if err != nil {
+		t.Fatalf("Expected report, but got error: %v", err)
+	}
+
+	expected := map[string][]string{
+		"alice": {
+			"ClusterResourceQuota alice-quota is missing",
+		},
+		"bob": {
+			"RoleBinding bob-edit does not grant ClusterRole edit",
+			"RoleBinding bob-edit does not bind user bob",
+			"ClusterResourceQuota bob-quota is missing",
+		},
+		"carol": {
+			"RoleBinding carol-edit is missing",
+			"ClusterResourceQuota carol-quota is missing",
+		},
+	}
+	if len(reports) != len(expected) {
+		t.Fatalf("Expected %d namespaces, but got %d", len(expected), len(reports))
+	}
+	for i, name := range []string{"alice", "bob", "carol"} {
+		if reports[i].Namespace != name {
+			t.Errorf("Expected namespace %s at position %d, but got %s", name, i, reports[i].Namespace)
+		}
+		if !reflect.DeepEqual(reports[i].Drift, expected[name]) {
+			t.Errorf("Expected drift %v for namespace %s, but got %v", expected[name], name, reports[i].Drift)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// name of the ResourceQuota denying LoadBalancer Services in user namespaces
+const loadBalancerQuotaName = "deny-load-balancers"
+
+// Returns the ResourceQuota preventing the target user from creating LoadBalancer Services, which
+// provision a costly AWS load balancer each on ROSA
+func desiredLoadBalancerQuota(user string, projectName string) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      loadBalancerQuotaName,
+			Namespace: projectName,
+			Labels:    seededLabels(user),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{
+				corev1.ResourceServicesLoadBalancers: resource.MustParse("0"),
+			},
+		},
+	}
+}
+
+// name of the ResourceQuota limiting the number of objects in user namespaces
+const objectCountQuotaName = "object-counts"
+
+// Returns the ResourceQuota limiting the number of objects the target user may create, so a
+// runaway script in one namespace can't flood etcd and degrade the shared control plane
+func desiredObjectCountQuota(user string, projectName string) (*corev1.ResourceQuota, error) {
+	hard, err := GetObjectCountQuotaHard()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse object count limits: %w", err)
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      objectCountQuotaName,
+			Namespace: projectName,
+			Labels:    seededLabels(user),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: hard,
+		},
+	}, nil
+}
+
+// Creates the object count quota under the target user project
+func (c *Controller) createObjectCountQuota(ctx context.Context, user string, projectName string) error {
+	quota, err := desiredObjectCountQuota(user, projectName)
+	if err != nil {
+		klog.Errorf("Error building ResourceQuota %s for user %s: %v", objectCountQuotaName, user, err)
+		return err
+	}
+	return c.syncResourceQuota(ctx, user, projectName, quota)
+}
+
+// name of the ResourceQuota limiting compute and storage in user namespaces
+const computeQuotaName = "compute-resources"
+
+// Returns the ResourceQuota limiting the CPU, memory, pods and storage the target user may consume
+// in their namespace, from the configured template, scoped to the configured PriorityClasses like the
+// ClusterResourceQuota
+func desiredComputeQuota(user string, projectName string) (*corev1.ResourceQuota, error) {
+	hard, err := GetResourceQuotaHard()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse resource quota limits: %w", err)
+	}
+	scopeSelector, err := priorityClassScopeSelector()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource quota scope: %w", err)
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      computeQuotaName,
+			Namespace: projectName,
+			Labels:    seededLabels(user),
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard:          hard,
+			ScopeSelector: scopeSelector,
+		},
+	}, nil
+}
+
+// Creates the compute quota under the target user project
+func (c *Controller) createComputeQuota(ctx context.Context, user string, projectName string) error {
+	quota, err := c.userComputeQuota(ctx, user, projectName)
+	if err != nil {
+		klog.Errorf("Error building ResourceQuota %s for user %s: %v", computeQuotaName, user, err)
+		return err
+	}
+	return c.syncResourceQuota(ctx, user, projectName, quota)
+}
+
+// Creates the LoadBalancer deny quota under the target user project
+func (c *Controller) createLoadBalancerQuota(ctx context.Context, user string, projectName string) error {
+	return c.syncResourceQuota(ctx, user, projectName, desiredLoadBalancerQuota(user, projectName))
+}
+
+// Returns whether the ResourceQuota enforces the limits and scopes of the desired one
+func resourceQuotaSpecMatches(existing *corev1.ResourceQuota, desired *corev1.ResourceQuota) bool {
+	return equality.Semantic.DeepEqual(existing.Spec.Hard, desired.Spec.Hard) &&
+		equality.Semantic.DeepEqual(existing.Spec.Scopes, desired.Spec.Scopes) &&
+		equality.Semantic.DeepEqual(existing.Spec.ScopeSelector, desired.Spec.ScopeSelector)
+}
+
+// Creates the seeded ResourceQuota under the target user project, or restores its hard limits and
+// scopes when they were modified, unless an exception to it was approved
+func (c *Controller) syncResourceQuota(ctx context.Context, user string, projectName string, quota *corev1.ResourceQuota) error {
+	if excepted, err := c.policyExcepted(ctx, user, projectName, "ResourceQuota", quota.Name); err != nil || excepted {
+		return err
+	}
+
+	existingQuota, err := c.coreClient.ResourceQuotas(projectName).Get(ctx, quota.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.coreClient.ResourceQuotas(projectName).Patch, quota, corev1.SchemeGroupVersion.WithKind("ResourceQuota"))
+			} else {
+				_, err = c.coreClient.ResourceQuotas(projectName).Create(ctx, quota, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating ResourceQuota %s for user %s under project %s: %v", quota.Name, user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created ResourceQuota %s for user %s under project %s", quota.Name, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if ResourceQuota %s exists for user %s under project %s: %v", quota.Name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite ResourceQuotas that were not seeded by the controller
+	if existingQuota.Labels[partOfLabel] != seededSet {
+		err := fmt.Errorf("ResourceQuota %s under project %s is not managed by the controller and will not be overwritten", quota.Name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	released := releaseAnchorReference(existingQuota)
+	if !released && resourceQuotaSpecMatches(existingQuota, quota) {
+		klog.V(2).Infof("ResourceQuota %s under project %s already exist for user %s", quota.Name, projectName, user)
+		return nil
+	}
+
+	// the anchor reference is released by an update, which an apply would leave in place
+	if GetServerSideApplyEnabled() && !released {
+		_, err = applyObject(ctx, c.coreClient.ResourceQuotas(projectName).Patch, quota, corev1.SchemeGroupVersion.WithKind("ResourceQuota"))
+	} else {
+		existingQuota.Spec.Hard = quota.Spec.Hard
+		existingQuota.Spec.Scopes = quota.Spec.Scopes
+		existingQuota.Spec.ScopeSelector = quota.Spec.ScopeSelector
+		_, err = c.coreClient.ResourceQuotas(projectName).Update(ctx, existingQuota, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating ResourceQuota %s for user %s under project %s: %v", quota.Name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated ResourceQuota %s for user %s under project %s", quota.Name, user, projectName)
+	return nil
+}
+
+// Returns the drift of a seeded ResourceQuota under the target user project, if any
+func (c *Controller) resourceQuotaDrift(ctx context.Context, projectName string, quota *corev1.ResourceQuota) (string, error) {
+	existingQuota, err := c.coreClient.ResourceQuotas(projectName).Get(ctx, quota.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return fmt.Sprintf("ResourceQuota %s is missing", quota.Name), nil
+	} else if err != nil {
+		return "", err
+	}
+	if !resourceQuotaSpecMatches(existingQuota, quota) {
+		return fmt.Sprintf("ResourceQuota %s does not enforce the desired limits", quota.Name), nil
+	}
+	return "", nil
+}
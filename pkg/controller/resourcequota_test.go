@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_createLoadBalancerQuota(t *testing.T) {
+	tests := []struct {
+		name          string
+		existingQuota *corev1.ResourceQuota
+		shouldError   bool
+	}{
+		{
+			name: "creates quota",
+		},
+		{
+			name: "restores modified quota",
+			existingQuota: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      loadBalancerQuotaName,
+					Namespace: "alice",
+					Labels:    seededLabels("alice"),
+				},
+				Spec: corev1.ResourceQuotaSpec{
+					Hard: corev1.ResourceList{
+						corev1.ResourceServicesLoadBalancers: resource.MustParse("5"),
+					},
+				},
+			},
+		},
+		{
+			name: "does not overwrite unmanaged quota",
+			existingQuota: &corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      loadBalancerQuotaName,
+					Namespace: "alice",
+				},
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			var objects []runtime.Object
+			if tt.existingQuota != nil {
+				objects = append(objects, tt.existingQuota)
+			}
+			kubeClient := fake.NewClientset(objects...)
+			controller := &Controller{
+				coreClient: kubeClient.CoreV1(),
+			}
+
+			err := controller.createLoadBalancerQuota(ctx, "alice", "alice")
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected ResourceQuota to be created, but got error: %v", err)
+			}
+
+			quota, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, loadBalancerQuotaName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", loadBalancerQuotaName, err)
+			}
+			limit := quota.Spec.Hard[corev1.ResourceServicesLoadBalancers]
+			if !limit.IsZero() {
+				t.Errorf("Expected LoadBalancer Services to be limited to 0, but got %s", limit.String())
+			}
+
+			drift, err := controller.resourceQuotaDrift(ctx, "alice", desiredLoadBalancerQuota("alice", "alice"))
+			if err != nil || drift != "" {
+				t.Errorf("Expected no drift, but got %q (error: %v)", drift, err)
+			}
+		})
+	}
+}
+
+func TestController_createObjectCountQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		hard        string
+		shouldError bool
+	}{
+		{
+			name: "creates quota",
+			hard: "pods=50,configmaps=100,count/deployments.apps=20",
+		},
+		{
+			name:        "invalid limits",
+			hard:        "pods=many",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("OBJECT_COUNT_QUOTA_HARD", tt.hard)
+
+			ctx := context.Background()
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				coreClient: kubeClient.CoreV1(),
+			}
+
+			err := controller.createObjectCountQuota(ctx, "alice", "alice")
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected ResourceQuota to be created, but got error: %v", err)
+			}
+
+			quota, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, objectCountQuotaName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", objectCountQuotaName, err)
+			}
+			limit := quota.Spec.Hard["count/deployments.apps"]
+			if len(quota.Spec.Hard) != 3 || limit.Value() != 20 {
+				t.Errorf("Expected the configured object counts to be limited, but got %v", quota.Spec.Hard)
+			}
+		})
+	}
+}
+
+func TestController_createComputeQuota(t *testing.T) {
+	t.Setenv("RESOURCE_QUOTA_HARD", "requests.cpu=4,limits.memory=16Gi,pods=20,persistentvolumeclaims=5")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.createComputeQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be created, but got error: %v", err)
+	}
+
+	// Modified limits are restored
+	t.Setenv("RESOURCE_QUOTA_HARD", "requests.cpu=8,limits.memory=16Gi,pods=20,persistentvolumeclaims=5")
+	if err := controller.createComputeQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be updated, but got error: %v", err)
+	}
+
+	quota, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, computeQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", computeQuotaName, err)
+	}
+	cpu := quota.Spec.Hard[corev1.ResourceRequestsCPU]
+	if len(quota.Spec.Hard) != 4 || cpu.Value() != 8 {
+		t.Errorf("Expected the configured resources to be limited, but got %v", quota.Spec.Hard)
+	}
+	if quota.Labels[partOfLabel] != seededSet {
+		t.Errorf("Expected ResourceQuota to be labeled as seeded, but got labels %v", quota.Labels)
+	}
+}
+
+func TestController_createComputeQuotaScoped(t *testing.T) {
+	t.Setenv("RESOURCE_QUOTA_HARD", "requests.cpu=4,pods=20")
+	t.Setenv("QUOTA_PRIORITY_CLASSES", "high-priority")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	if err := controller.createComputeQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be created, but got error: %v", err)
+	}
+	quota, err := kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, computeQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", computeQuotaName, err)
+	}
+	if quota.Spec.ScopeSelector == nil || quota.Spec.ScopeSelector.MatchExpressions[0].Values[0] != "high-priority" {
+		t.Errorf("Expected ResourceQuota to be scoped to high-priority, but got %v", quota.Spec.ScopeSelector)
+	}
+
+	// A changed scope is drift and is restored
+	t.Setenv("QUOTA_PRIORITY_CLASSES", "critical")
+	desired, err := desiredComputeQuota("alice", "alice")
+	if err != nil {
+		t.Fatalf("Expected desired ResourceQuota, but got error: %v", err)
+	}
+	if drift, err := controller.resourceQuotaDrift(ctx, "alice", desired); err != nil || drift == "" {
+		t.Errorf("Expected scope drift, but got %q (error: %v)", drift, err)
+	}
+	if err := controller.createComputeQuota(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected ResourceQuota to be updated, but got error: %v", err)
+	}
+	quota, err = kubeClient.CoreV1().ResourceQuotas("alice").Get(ctx, computeQuotaName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected ResourceQuota %s to exist, but got error: %v", computeQuotaName, err)
+	}
+	if got := quota.Spec.ScopeSelector.MatchExpressions[0].Values; len(got) != 1 || got[0] != "critical" {
+		t.Errorf("Expected ResourceQuota to be scoped to critical, but got %v", got)
+	}
+}
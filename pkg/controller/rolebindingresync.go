@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Restores the RoleBindings of every managed namespace an admin deleted or modified since they were
+// provisioned, those of the owner along with ADDITIONAL_ROLE_BINDINGS and the RoleBindings of the
+// delegates, ops group, peers and automation ServiceAccount, as provisioning only runs again on a change
+// to the target groups
+func (c *Controller) resyncRoleBindings(ctx context.Context) {
+	if GetDryRunEnabled() {
+		klog.V(2).Infof("Dry run: skipping RoleBinding resync")
+		return
+	}
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects to resync RoleBindings: %v", err)
+		return
+	}
+
+	for _, project := range projects.Items {
+		// removed users keep no RoleBinding, and terminating projects can't be changed
+		if removedUserMarked(&project) || project.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		user := objectOwner(&project)
+		if err := c.restoreRoleBindingSubjects(ctx, user, project.Name); err != nil {
+			continue
+		}
+		// recreates a deleted RoleBinding and replaces one granting another ClusterRole
+		_ = c.syncRoleBinding(ctx, user, project.Name)
+		_ = c.syncAdditionalRoleBindings(ctx, user, project.Name)
+		if GetDelegatesEnabled() {
+			_ = c.syncDelegatesRoleBinding(ctx, user, project.Name)
+		}
+		if GetOpsGroupName() != "" {
+			_ = c.syncOpsRoleBinding(ctx, user, project.Name)
+		}
+		if GetPeerClusterRole() != "" {
+			_ = c.syncPeersRoleBinding(ctx, user, project.Name)
+		}
+		if GetAutomationServiceAccount() != "" {
+			_ = c.syncManagedRoleBinding(ctx, user, project.Name, desiredAutomationRoleBinding(user, project.Name))
+		}
+	}
+}
+
+// Restores the subjects of the managed RoleBinding under the target user project when they were
+// changed, e.g. the owner was removed or other users added. RoleBindings not managed by the
+// controller are left alone.
+func (c *Controller) restoreRoleBindingSubjects(ctx context.Context, user string, projectName string) error {
+	open, err := c.accessWindowOpen(ctx, user, time.Now())
+	if err != nil {
+		klog.Errorf("Error checking the access window of user %s: %v", user, err)
+		return err
+	}
+	name := roleBindingName(projectName)
+	roleBinding, err := c.rbacClient.RoleBindings(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || (err == nil && (!open || !isManaged(roleBinding))) {
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if RoleBinding exists for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+
+	desired := desiredRoleBinding(user, projectName, roleBinding.RoleRef.Name)
+	if equality.Semantic.DeepEqual(roleBinding.Subjects, desired.Subjects) {
+		return nil
+	}
+	previous := roleBinding.Subjects
+	roleBinding.Subjects = desired.Subjects
+	if _, err := c.rbacClient.RoleBindings(projectName).Update(ctx, roleBinding, metav1.UpdateOptions{}); err != nil {
+		klog.Errorf("Error restoring subjects of RoleBinding %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Restored subjects of RoleBinding %s for user %s under project %s, replacing %v", name, user, projectName, previous)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, roleBindingRestoredReason,
+		fmt.Sprintf("Restored RoleBinding %s to bind only user %s", name, user))
+	return nil
+}
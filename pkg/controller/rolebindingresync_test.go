@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_resyncRoleBindings(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "cohort")
+
+	ctx := context.Background()
+	// an admin deleted the RoleBinding of alice, swapped bob for mallory and bound an extra group
+	// to carol, while dave's project was retained after he was removed
+	retained := desiredProject("dave", "dave")
+	retained.Annotations[retainedAnnotation] = "true"
+	swapped := desiredRoleBinding("bob", "bob", "edit")
+	swapped.Subjects[0].Name = "mallory"
+	extended := desiredRoleBinding("carol", "carol", "edit")
+	extended.Subjects = append(extended.Subjects, rbacv1.Subject{Kind: "Group", APIGroup: "rbac.authorization.k8s.io", Name: "contractors"})
+	foreign := desiredRoleBinding("erin", "erin", "edit")
+	foreign.Labels = nil
+	foreign.Subjects[0].Name = "frank"
+
+	kubeClient := fake.NewClientset(swapped, extended, foreign)
+	controller := &Controller{
+		userClient: userfake.NewSimpleClientset(newGroup("cohort", "alice", "bob", "carol", "erin")),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("alice", "alice"), desiredProject("bob", "bob"),
+			desiredProject("carol", "carol"), retained, desiredProject("erin", "erin")),
+		rbacClient: kubeClient.RbacV1(),
+		coreClient: kubeClient.CoreV1(),
+	}
+	controller.resyncRoleBindings(ctx)
+
+	for _, user := range []string{"alice", "bob", "carol"} {
+		roleBinding, err := kubeClient.RbacV1().RoleBindings(user).Get(ctx, roleBindingName(user), metav1.GetOptions{})
+		if err != nil {
+			t.Errorf("Expected the RoleBinding of %s to be restored, but got error: %v", user, err)
+			continue
+		}
+		if len(roleBinding.Subjects) != 1 || !bindsUser(roleBinding, user) {
+			t.Errorf("Expected the RoleBinding of %s to bind only them, but got %+v", user, roleBinding.Subjects)
+		}
+	}
+	if _, err := kubeClient.RbacV1().RoleBindings("dave").Get(ctx, roleBindingName("dave"), metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected no RoleBinding to be restored in the retained project of dave")
+	}
+	roleBinding, err := kubeClient.RbacV1().RoleBindings("erin").Get(ctx, roleBindingName("erin"), metav1.GetOptions{})
+	if err != nil || !bindsUser(roleBinding, "frank") {
+		t.Errorf("Expected the unmanaged RoleBinding of erin to be left alone, but got %+v, %v", roleBinding, err)
+	}
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// annotation set to when the project of a removed user is deleted, once their grace period ends
+const scheduledDeletionAnnotation = "rosa-namespace-provisioner/scheduled-deletion"
+
+// Reason of the Event recorded, and of the Deprovisioned condition set, when the deletion of the
+// project of a removed user is scheduled
+const deletionScheduledReason = "DeletionScheduled"
+
+// Schedules the deletion of the project of a removed user at the end of the grace period, deleting
+// projects not owned by the user, which aren't swept, right away
+func (c *Controller) scheduleProjectDeletion(ctx context.Context, user string, projectName string) error {
+	project, err := c.removedUserProject(ctx, user, projectName)
+	if err != nil || project == nil {
+		return err
+	}
+	if objectOwner(project) != user {
+		return c.deleteUserProject(ctx, user, projectName)
+	}
+	// removing the user again doesn't postpone the deletion
+	if _, scheduled := project.Annotations[scheduledDeletionAnnotation]; scheduled {
+		return nil
+	}
+
+	deleteAt := time.Now().Add(GetDeletionGracePeriod()).UTC().Format(time.RFC3339)
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, scheduledDeletionAnnotation, deleteAt)
+	_, err = c.projectClient.ProjectV1().Projects().Patch(ctx, projectName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("Error scheduling deletion of project %s of user %s: %v", projectName, user, err)
+		return err
+	}
+	klog.Infof("Scheduled deletion of project %s of removed user %s at %s", projectName, user, deleteAt)
+	c.recordProvisioningEvent(ctx, user, projectName, corev1.EventTypeNormal, deletionScheduledReason,
+		fmt.Sprintf("Project %s of user %s is deleted at %s unless they are added back", projectName, user, deleteAt))
+	return nil
+}
+
+// Returns when the project is deleted if its deletion is scheduled
+func (c *Controller) scheduledDeletion(ctx context.Context, projectName string) (time.Time, bool) {
+	if GetDeletionGracePeriod() == 0 {
+		return time.Time{}, false
+	}
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			klog.Errorf("Error checking if deletion of project %s is scheduled: %v", projectName, err)
+		}
+		return time.Time{}, false
+	}
+	return scheduledDeletionTime(project)
+}
+
+// Returns the time of the scheduled deletion of a project, if any
+func scheduledDeletionTime(project *projectv1.Project) (time.Time, bool) {
+	value, scheduled := project.Annotations[scheduledDeletionAnnotation]
+	if !scheduled {
+		return time.Time{}, false
+	}
+	deleteAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		klog.Errorf("Invalid %s annotation %q on project %s: %v", scheduledDeletionAnnotation, value, project.Name, err)
+		return time.Time{}, false
+	}
+	return deleteAt, true
+}
+
+// Deletes the projects whose grace period ended, unless their owner was added back in the meantime
+func (c *Controller) sweepScheduledDeletions(ctx context.Context) {
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects to sweep scheduled deletions: %v", err)
+		return
+	}
+	// never delete a project when its owner may still be a member
+	members, err := c.Members(ctx)
+	if err != nil {
+		klog.Errorf("Error getting members of %s to sweep scheduled deletions: %v", membershipDescription(), err)
+		return
+	}
+
+	now := time.Now()
+	scheduled := 0
+	for _, project := range projects.Items {
+		deleteAt, ok := scheduledDeletionTime(&project)
+		if !ok {
+			continue
+		}
+		user := objectOwner(&project)
+		// the old namespace of a naming migration is deleted although its owner is still a member
+		if _, migrated := project.Annotations[migratedToAnnotation]; members[user] && !migrated {
+			klog.Infof("Not deleting project %s as user %s was added back, waiting for them to be provisioned", project.Name, user)
+			continue
+		}
+		if now.Before(deleteAt) {
+			scheduled++
+			continue
+		}
+		if GetDryRunEnabled() {
+			klog.Infof("Dry run: skipping scheduled deletion of project %s of user %s", project.Name, user)
+			continue
+		}
+
+		klog.Infof("Grace period of project %s of user %s ended, deleting it", project.Name, user)
+		if err := c.deleteUserProject(ctx, user, project.Name); err != nil {
+			continue
+		}
+		_ = c.deprovisionManagedNamespace(ctx, user, project.Name)
+		_ = c.forgetNamespaceMapping(ctx, user, project.Name)
+	}
+	metrics.ScheduledDeletions.Set(float64(scheduled))
+}
@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_sweepScheduledDeletions(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort")
+	t.Setenv("DELETION_GRACE_PERIOD", "168h")
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset(desiredProject("alice", "alice"), desiredProject("bob", "bob"))
+	kubeClient := fake.NewClientset()
+	userClient := userfake.NewSimpleClientset(newGroup("cohort"))
+	controller := &Controller{
+		userClient:    userClient,
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+	}
+	for _, user := range []string{"alice", "bob"} {
+		if err := controller.updateManagedNamespace(ctx, user, user, nil, nil); err != nil {
+			t.Fatalf("Failed to create ManagedNamespace: %v", err)
+		}
+		if err := controller.deprovisionUser(ctx, user); err != nil {
+			t.Fatalf("Expected user %s to be deprovisioned, but got error: %v", user, err)
+		}
+	}
+	projectExists := func(name string) bool {
+		_, err := projectClient.ProjectV1().Projects().Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			t.Fatalf("Failed to get project %s: %v", name, err)
+		}
+		return err == nil
+	}
+
+	// Removed users keep their project and record during the grace period
+	if !projectExists("alice") || !projectExists("bob") {
+		t.Fatalf("Expected projects to be kept during the grace period")
+	}
+	condition := meta.FindStatusCondition(getManagedNamespace(t, controller, "alice").Status.Conditions, v1alpha1.ConditionDeprovisioned)
+	if condition == nil || condition.Reason != deletionScheduledReason {
+		t.Errorf("Expected alice to be marked as scheduled for deletion, but got %+v", condition)
+	}
+	if managed, err := controller.ManagedUsers(ctx); err != nil || len(managed) != 0 {
+		t.Errorf("Expected no managed users during the grace period, but got %v, %v", managed, err)
+	}
+	controller.sweepScheduledDeletions(ctx)
+	if !projectExists("alice") {
+		t.Errorf("Expected project alice to be kept before its grace period ends")
+	}
+
+	// Only projects of users not added back are deleted once the grace period ends
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	for _, name := range []string{"alice", "bob"} {
+		patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, scheduledDeletionAnnotation, past)
+		if _, err := projectClient.ProjectV1().Projects().Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
+			t.Fatalf("Failed to patch project %s: %v", name, err)
+		}
+	}
+	if _, err := userClient.UserV1().Groups().Update(ctx, newGroup("cohort", "bob"), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update group: %v", err)
+	}
+	controller.sweepScheduledDeletions(ctx)
+	if projectExists("alice") {
+		t.Errorf("Expected project alice to be deleted once its grace period ended")
+	}
+	if !controller.deletionPending("alice") {
+		t.Errorf("Expected the deletion of alice to be verified")
+	}
+	if !projectExists("bob") {
+		t.Errorf("Expected project bob to be kept as bob was added back")
+	}
+
+	// Provisioning a user added back cancels the deletion
+	if err := controller.createUserProject(ctx, "bob", "bob"); err != nil {
+		t.Fatalf("Expected project bob to be provisioned, but got error: %v", err)
+	}
+	project, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get project bob: %v", err)
+	}
+	if _, scheduled := project.Annotations[scheduledDeletionAnnotation]; scheduled {
+		t.Errorf("Expected the scheduled deletion of project bob to be cancelled")
+	}
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// user the seeded manifests are rendered for when checked against the cluster
+const schemaSampleUser = "sample-user"
+
+// options of the server-side dry runs checking seeded manifests, which reject unknown or duplicate
+// fields against the OpenAPI schema of the cluster instead of dropping them
+var schemaCheckOptions = metav1.CreateOptions{
+	DryRun:          []string{metav1.DryRunAll},
+	FieldValidation: metav1.FieldValidationStrict,
+}
+
+// ValidateSeededManifests renders the manifests seeded into every user namespace for a sample user and
+// creates them with a server-side dry run in the namespace, so the cluster checks them against its
+// OpenAPI schema and admission at startup instead of when the first user is provisioned. It returns
+// every ConfigError found joined into a single error.
+func (c *Controller) ValidateSeededManifests(ctx context.Context, namespace string) error {
+	// manifests that can't be rendered are already reported by ValidateConfig
+	var errs []error
+	check := func(variable, name string, err error) {
+		// an object of the same name in the namespace doesn't make the manifest invalid
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			errs = append(errs, &ConfigError{Variable: variable, Entry: name, Err: err})
+		}
+	}
+
+	if GetDenyLoadBalancersEnabled() {
+		quota := desiredLoadBalancerQuota(schemaSampleUser, namespace)
+		_, err := c.coreClient.ResourceQuotas(namespace).Create(ctx, quota, schemaCheckOptions)
+		check("DENY_LOAD_BALANCERS_ENABLED", quota.Name, err)
+	}
+
+	if GetResourceQuotaEnabled() {
+		if quota, err := desiredComputeQuota(schemaSampleUser, namespace); err == nil {
+			_, err := c.coreClient.ResourceQuotas(namespace).Create(ctx, quota, schemaCheckOptions)
+			check("RESOURCE_QUOTA_HARD", quota.Name, err)
+		}
+	}
+
+	if GetObjectCountQuotaEnabled() {
+		if quota, err := desiredObjectCountQuota(schemaSampleUser, namespace); err == nil {
+			_, err := c.coreClient.ResourceQuotas(namespace).Create(ctx, quota, schemaCheckOptions)
+			check("OBJECT_COUNT_QUOTA_HARD", quota.Name, err)
+		}
+	}
+
+	if GetLimitRangeEnabled() {
+		if limitRange, err := desiredLimitRange(schemaSampleUser, namespace); err == nil {
+			_, err := c.coreClient.LimitRanges(namespace).Create(ctx, limitRange, schemaCheckOptions)
+			check("LIMIT_RANGE_FILE", limitRange.Name, err)
+		}
+	}
+
+	if GetNetworkPoliciesEnabled() && c.networkingClient != nil {
+		if policies, err := desiredNetworkPolicies(schemaSampleUser, namespace); err == nil {
+			for _, policy := range policies {
+				_, err := c.networkingClient.NetworkPolicies(namespace).Create(ctx, policy, schemaCheckOptions)
+				check("NETWORK_POLICIES_FILE", policy.Name, err)
+			}
+		}
+	}
+
+	if GetBandwidthLimitsEnabled() && c.dynamicClient != nil {
+		if tiers, err := GetBandwidthTiers(); err == nil {
+			names := make([]string, 0, len(tiers))
+			for name := range tiers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				tier := tiers[name]
+				networkQoS := desiredNetworkQoS(schemaSampleUser, namespace, &tier)
+				_, err := c.dynamicClient.Resource(networkQoSResource).Namespace(namespace).Create(ctx, networkQoS, schemaCheckOptions)
+				check("BANDWIDTH_TIERS", name, err)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		klog.V(2).Infof("Seeded manifests passed the schema check in namespace %s", namespace)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestController_ValidateSeededManifests(t *testing.T) {
+	t.Setenv("DENY_LOAD_BALANCERS_ENABLED", "true")
+	t.Setenv("LIMIT_RANGE_ENABLED", "true")
+	path := filepath.Join(t.TempDir(), "limitrange.yaml")
+	if err := os.WriteFile(path, []byte(limitRangeTemplate), 0o600); err != nil {
+		t.Fatalf("Failed to write LimitRange template: %v", err)
+	}
+	t.Setenv("LIMIT_RANGE_FILE", path)
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset()
+	var dryRuns []string
+	kubeClient.PrependReactor("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateActionImpl)
+		if len(create.CreateOptions.DryRun) == 0 || create.CreateOptions.FieldValidation != "Strict" {
+			t.Errorf("Expected a strict dry run of %s, but got %+v", action.GetResource().Resource, create.CreateOptions)
+		}
+		dryRuns = append(dryRuns, action.GetResource().Resource)
+		if action.GetResource().Resource == "limitranges" {
+			return true, nil, apierrors.NewInvalid(schema.GroupKind{Kind: "LimitRange"}, limitRangeName, field.ErrorList{
+				field.Invalid(field.NewPath("spec", "limits").Index(0).Child("type"), "Node", "unsupported type"),
+			})
+		}
+		return true, nil, nil
+	})
+	controller := &Controller{
+		coreClient: kubeClient.CoreV1(),
+	}
+
+	err := controller.ValidateSeededManifests(ctx, "rosa-namespace-provisioner")
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Variable != "LIMIT_RANGE_FILE" || configErr.Entry != limitRangeName {
+		t.Errorf("Expected the LimitRange to be reported as invalid, but got %v", err)
+	}
+	if len(dryRuns) != 2 {
+		t.Errorf("Expected the quota and LimitRange to be checked, but got %v", dryRuns)
+	}
+}
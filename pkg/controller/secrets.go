@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// annotation recording the external secret a managed Secret was materialized from
+const secretSourceAnnotation = "rosa-namespace-provisioner/secret-source"
+
+// SecretSource retrieves secret values from an external secret manager
+type SecretSource interface {
+	GetSecretData(ctx context.Context, id string) (map[string][]byte, error)
+}
+
+// SecretMapping maps an external secret id to the Secret materialized in each user namespace
+type SecretMapping struct {
+	SourceID   string
+	SecretName string
+}
+
+// Parses "<secret-id>=<secret-name>" entries into secret mappings
+func parseSecretMappings(entries []string) ([]SecretMapping, error) {
+	var mappings []SecretMapping
+	for _, entry := range entries {
+		sourceID, secretName, found := strings.Cut(entry, "=")
+		sourceID = strings.TrimSpace(sourceID)
+		secretName = strings.TrimSpace(secretName)
+		if !found || sourceID == "" || secretName == "" {
+			return nil, fmt.Errorf("invalid secret mapping %q, expected <secret-id>=<secret-name>", entry)
+		}
+		mappings = append(mappings, SecretMapping{
+			SourceID:   sourceID,
+			SecretName: secretName,
+		})
+	}
+	return mappings, nil
+}
+
+// Materializes the configured external secrets, including those of the user's selected bundle, as
+// Secrets in the target user project. Secrets of a previously selected bundle are pruned.
+func (c *Controller) syncUserSecrets(ctx context.Context, user string, projectName string) error {
+	mappings, err := c.userSecretMappings(ctx, user)
+	if err != nil {
+		klog.Errorf("Error resolving secret mappings for user %s: %v", user, err)
+		return err
+	}
+
+	var syncErr error
+	desired := make(map[string]bool)
+	for _, mapping := range mappings {
+		desired[mapping.SecretName] = true
+		if err := c.syncUserSecret(ctx, user, projectName, mapping); err != nil {
+			syncErr = err
+		}
+	}
+
+	// Remove Secrets whose mapping was dropped from the configuration
+	if err := c.pruneSeededSecrets(ctx, user, projectName, desired); err != nil {
+		syncErr = err
+	}
+	return syncErr
+}
+
+// Creates or refreshes a single materialized Secret under the target user project
+func (c *Controller) syncUserSecret(ctx context.Context, user string, projectName string, mapping SecretMapping) error {
+	data, err := c.secretSource.GetSecretData(ctx, mapping.SourceID)
+	c.health.Record(IntegrationAWSSecrets, err)
+	if err != nil {
+		klog.Errorf("Error fetching secret %s for user %s: %v", mapping.SourceID, user, err)
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mapping.SecretName,
+			Namespace: projectName,
+			Labels:    seededLabels(user),
+			Annotations: map[string]string{
+				secretSourceAnnotation: mapping.SourceID,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	setAnchorReference(secret, anchorRef)
+
+	existingSecret, err := c.coreClient.Secrets(projectName).Get(ctx, secret.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			if GetServerSideApplyEnabled() {
+				_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+			} else {
+				_, err = c.coreClient.Secrets(projectName).Create(ctx, secret, metav1.CreateOptions{})
+			}
+			if err != nil {
+				klog.Errorf("Error creating Secret %s for user %s under project %s: %v", secret.Name, user, projectName, err)
+				return err
+			}
+			klog.Infof("Successfully created Secret %s for user %s under project %s", secret.Name, user, projectName)
+			return nil
+		}
+		klog.Errorf("Error checking if Secret %s exists for user %s under project %s: %v", secret.Name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite Secrets that were not materialized by the controller
+	owned := anchorRef != nil && ownedByAnchor(existingSecret, anchorRef)
+	if !owned && existingSecret.Annotations[secretSourceAnnotation] != mapping.SourceID {
+		err := fmt.Errorf("Secret %s under project %s is not managed from %s and will not be overwritten",
+			secret.Name,
+			projectName,
+			mapping.SourceID,
+		)
+		klog.Error(err)
+		return err
+	}
+
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+	} else {
+		existingSecret.Data = data
+		setAnchorReference(existingSecret, anchorRef)
+		if existingSecret.Annotations == nil {
+			existingSecret.Annotations = make(map[string]string)
+		}
+		existingSecret.Annotations[secretSourceAnnotation] = mapping.SourceID
+		if existingSecret.Labels == nil {
+			existingSecret.Labels = make(map[string]string)
+		}
+		for key, value := range seededLabels(user) {
+			existingSecret.Labels[key] = value
+		}
+		_, err = c.coreClient.Secrets(projectName).Update(ctx, existingSecret, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error refreshing Secret %s for user %s under project %s: %v", secret.Name, user, projectName, err)
+		return err
+	}
+	klog.V(2).Infof("Refreshed Secret %s for user %s under project %s", secret.Name, user, projectName)
+	return nil
+}
+
+// Refreshes the materialized Secrets of every project owned by a user
+func (c *Controller) refreshSecrets(ctx context.Context) {
+	if !c.health.Enabled(IntegrationAWSSecrets) {
+		klog.Warningf("Skipping secret refresh while integration %s is disabled", IntegrationAWSSecrets)
+		return
+	}
+	if GetDryRunEnabled() {
+		klog.V(2).Infof("Dry run: skipping secret refresh")
+		return
+	}
+
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects for secret refresh: %v", err)
+		return
+	}
+
+	for _, project := range projects.Items {
+		_ = c.syncUserSecrets(ctx, objectOwner(&project), project.Name)
+	}
+}
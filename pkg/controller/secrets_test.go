@@ -0,0 +1,203 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeSecretSource serves secret values from memory
+type fakeSecretSource struct {
+	secrets map[string]map[string][]byte
+}
+
+func (f *fakeSecretSource) GetSecretData(ctx context.Context, id string) (map[string][]byte, error) {
+	data, ok := f.secrets[id]
+	if !ok {
+		return nil, fmt.Errorf("secret %s not found", id)
+	}
+	return data, nil
+}
+
+func TestParseSecretMappings(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     []string
+		want        []SecretMapping
+		shouldError bool
+	}{
+		{
+			name:    "valid mappings",
+			entries: []string{"sandbox/model-api=model-api-key", " arn:aws:secretsmanager:us-east-1:123:secret:ca = ca-bundle "},
+			want: []SecretMapping{
+				{SourceID: "sandbox/model-api", SecretName: "model-api-key"},
+				{SourceID: "arn:aws:secretsmanager:us-east-1:123:secret:ca", SecretName: "ca-bundle"},
+			},
+		},
+		{
+			name:        "missing secret name",
+			entries:     []string{"sandbox/model-api"},
+			shouldError: true,
+		},
+		{
+			name:        "empty secret id",
+			entries:     []string{"=model-api-key"},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSecretMappings(tt.entries)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseSecretMappings() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("parseSecretMappings()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestController_syncUserSecrets(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key,sandbox/ca=ca-bundle")
+
+	tests := []struct {
+		name            string
+		existingSecrets []runtime.Object
+		shouldError     bool
+	}{
+		{
+			name: "Create Secrets in user project",
+		},
+		{
+			name: "Refresh previously materialized Secret",
+			existingSecrets: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "model-api-key",
+						Namespace: "alice",
+						Annotations: map[string]string{
+							secretSourceAnnotation: "sandbox/model-api",
+						},
+					},
+					Data: map[string][]byte{"api-key": []byte("stale")},
+				},
+			},
+		},
+		{
+			name: "Attempt to overwrite an unmanaged Secret",
+			existingSecrets: []runtime.Object{
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "model-api-key",
+						Namespace: "alice",
+					},
+					Data: map[string][]byte{"api-key": []byte("user-provided")},
+				},
+			},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			coreClient := fake.NewClientset(tt.existingSecrets...).CoreV1()
+			controller := &Controller{
+				coreClient: coreClient,
+				secretSource: &fakeSecretSource{
+					secrets: map[string]map[string][]byte{
+						"sandbox/model-api": {"api-key": []byte("abc")},
+						"sandbox/ca":        {"value": []byte("pem")},
+					},
+				},
+			}
+
+			err := controller.syncUserSecrets(ctx, "alice", "alice")
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				secret, err := coreClient.Secrets("alice").Get(ctx, "model-api-key", metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("Expected Secret model-api-key to be found, but got error: %v", err)
+				}
+				if string(secret.Data["api-key"]) != "user-provided" {
+					t.Errorf("Expected unmanaged Secret to be left untouched, but got %q", secret.Data["api-key"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected Secrets to be synced, but got error: %v", err)
+			}
+
+			secret, err := coreClient.Secrets("alice").Get(ctx, "model-api-key", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected Secret model-api-key to be found, but got error: %v", err)
+			}
+			if string(secret.Data["api-key"]) != "abc" {
+				t.Errorf("Expected Secret model-api-key to hold the current value, but got %q", secret.Data["api-key"])
+			}
+			if _, err := coreClient.Secrets("alice").Get(ctx, "ca-bundle", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected Secret ca-bundle to be found, but got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestController_refreshSecrets(t *testing.T) {
+	t.Setenv("AWS_SECRETS", "sandbox/model-api=model-api-key")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset(
+		&projectv1.Project{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "alice",
+				Labels: map[string]string{ownerLabel: "alice"},
+			},
+		},
+		&projectv1.Project{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "unmanaged",
+			},
+		},
+	)
+	coreClient := fake.NewClientset().CoreV1()
+	controller := &Controller{
+		projectClient: projectClient,
+		coreClient:    coreClient,
+		secretSource: &fakeSecretSource{
+			secrets: map[string]map[string][]byte{
+				"sandbox/model-api": {"api-key": []byte("abc")},
+			},
+		},
+	}
+
+	controller.refreshSecrets(ctx)
+
+	if _, err := coreClient.Secrets("alice").Get(ctx, "model-api-key", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected Secret model-api-key to be materialized for alice, but got error: %v", err)
+	}
+	if _, err := coreClient.Secrets("unmanaged").Get(ctx, "model-api-key", metav1.GetOptions{}); err == nil {
+		t.Errorf("Expected no Secret to be materialized in an unowned project")
+	}
+}
@@ -0,0 +1,296 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// value of the partOfLabel for the Secrets and ConfigMaps copied from SEED_NAMESPACE, kept apart from
+// the seeded set so the secrets step doesn't prune the copied Secrets
+const copiedSet = "copied"
+
+// selects the objects copied from SEED_NAMESPACE
+var copiedSelector = fmt.Sprintf("%s=%s", partOfLabel, copiedSet)
+
+// annotation recording the <namespace>/<name> a copied Secret or ConfigMap is kept in sync with
+const seedSourceAnnotation = "rosa-namespace-provisioner/seed-source"
+
+// Returns whether Secrets or ConfigMaps are copied into the managed namespaces
+func seedResourcesEnabled() bool {
+	return GetSeedNamespace() != "" && (len(GetSeedSecrets()) > 0 || len(GetSeedConfigMaps()) > 0)
+}
+
+// Returns the metadata of the copy of a seed object under the target user project
+func seedObjectMeta(user string, projectName string, name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: projectName,
+		Labels: map[string]string{
+			ownerLabel:  ownerLabelValue(user),
+			partOfLabel: copiedSet,
+		},
+		Annotations: map[string]string{
+			seedSourceAnnotation: GetSeedNamespace() + "/" + name,
+		},
+	}
+}
+
+// Copies SEED_SECRETS and SEED_CONFIGMAPS from SEED_NAMESPACE into the target user project, updating
+// the copies that no longer match their source. A missing source doesn't stop the others from being
+// copied.
+func (c *Controller) syncSeedResources(ctx context.Context, user string, projectName string) error {
+	anchorRef, err := c.anchorReference(ctx, projectName)
+	if err != nil {
+		klog.Errorf("Error getting anchor for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+
+	var errs []error
+	for _, name := range GetSeedSecrets() {
+		errs = append(errs, c.syncSeedSecret(ctx, user, projectName, name, anchorRef))
+	}
+	for _, name := range GetSeedConfigMaps() {
+		errs = append(errs, c.syncSeedConfigMap(ctx, user, projectName, name, anchorRef))
+	}
+	return errors.Join(errs...)
+}
+
+// Copies a Secret of SEED_NAMESPACE into the target user project, restoring the copy when modified
+func (c *Controller) syncSeedSecret(ctx context.Context, user string, projectName string, name string, anchorRef *metav1.OwnerReference) error {
+	source, err := c.coreClient.Secrets(GetSeedNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting seed Secret %s/%s for user %s: %v", GetSeedNamespace(), name, user, err)
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: seedObjectMeta(user, projectName, name),
+		Type:       source.Type,
+		Data:       source.Data,
+	}
+	setAnchorReference(secret, anchorRef)
+
+	existing, err := c.coreClient.Secrets(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if GetServerSideApplyEnabled() {
+			_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+		} else {
+			_, err = c.coreClient.Secrets(projectName).Create(ctx, secret, metav1.CreateOptions{})
+		}
+		if err != nil {
+			klog.Errorf("Error copying seed Secret %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Copied seed Secret %s from %s for user %s under project %s", name, GetSeedNamespace(), user, projectName)
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if seed Secret %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite Secrets that were not copied by the controller
+	if existing.Labels[partOfLabel] != copiedSet {
+		err := fmt.Errorf("Secret %s under project %s is not managed by the controller and will not be overwritten", name, projectName)
+		klog.Error(err)
+		return err
+	}
+	// the type of a Secret is immutable
+	if existing.Type != secret.Type {
+		if err := c.coreClient.Secrets(projectName).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			klog.Errorf("Error deleting seed Secret %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		return c.syncSeedSecret(ctx, user, projectName, name, anchorRef)
+	}
+
+	adopted := setAnchorReference(existing, anchorRef)
+	if !adopted && equality.Semantic.DeepEqual(existing.Data, secret.Data) {
+		klog.V(2).Infof("Seed Secret %s under project %s already in sync for user %s", name, projectName, user)
+		return nil
+	}
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.coreClient.Secrets(projectName).Patch, secret, corev1.SchemeGroupVersion.WithKind("Secret"))
+	} else {
+		existing.Data = secret.Data
+		_, err = c.coreClient.Secrets(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating seed Secret %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated seed Secret %s for user %s under project %s", name, user, projectName)
+	return nil
+}
+
+// Copies a ConfigMap of SEED_NAMESPACE into the target user project, restoring the copy when modified
+func (c *Controller) syncSeedConfigMap(ctx context.Context, user string, projectName string, name string, anchorRef *metav1.OwnerReference) error {
+	source, err := c.coreClient.ConfigMaps(GetSeedNamespace()).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting seed ConfigMap %s/%s for user %s: %v", GetSeedNamespace(), name, user, err)
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: seedObjectMeta(user, projectName, name),
+		Data:       source.Data,
+		BinaryData: source.BinaryData,
+	}
+	setAnchorReference(configMap, anchorRef)
+
+	existing, err := c.coreClient.ConfigMaps(projectName).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if GetServerSideApplyEnabled() {
+			_, err = applyObject(ctx, c.coreClient.ConfigMaps(projectName).Patch, configMap, corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		} else {
+			_, err = c.coreClient.ConfigMaps(projectName).Create(ctx, configMap, metav1.CreateOptions{})
+		}
+		if err != nil {
+			klog.Errorf("Error copying seed ConfigMap %s for user %s under project %s: %v", name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Copied seed ConfigMap %s from %s for user %s under project %s", name, GetSeedNamespace(), user, projectName)
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if seed ConfigMap %s exists for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite ConfigMaps that were not copied by the controller
+	if existing.Labels[partOfLabel] != copiedSet {
+		err := fmt.Errorf("ConfigMap %s under project %s is not managed by the controller and will not be overwritten", name, projectName)
+		klog.Error(err)
+		return err
+	}
+
+	adopted := setAnchorReference(existing, anchorRef)
+	if !adopted && equality.Semantic.DeepEqual(existing.Data, configMap.Data) && equality.Semantic.DeepEqual(existing.BinaryData, configMap.BinaryData) {
+		klog.V(2).Infof("Seed ConfigMap %s under project %s already in sync for user %s", name, projectName, user)
+		return nil
+	}
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, c.coreClient.ConfigMaps(projectName).Patch, configMap, corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	} else {
+		existing.Data = configMap.Data
+		existing.BinaryData = configMap.BinaryData
+		_, err = c.coreClient.ConfigMaps(projectName).Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating seed ConfigMap %s for user %s under project %s: %v", name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Updated seed ConfigMap %s for user %s under project %s", name, user, projectName)
+	return nil
+}
+
+// Keeps the copies of SEED_SECRETS and SEED_CONFIGMAPS of every managed namespace in sync with their
+// source, as provisioning only runs again on a change to the target groups
+func (c *Controller) resyncSeedResources(ctx context.Context) {
+	if GetDryRunEnabled() {
+		klog.V(2).Infof("Dry run: skipping seed resource resync")
+		return
+	}
+	projects, err := c.projectClient.ProjectV1().Projects().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing owned projects to resync seed resources: %v", err)
+		return
+	}
+
+	start := time.Now()
+	for _, project := range projects.Items {
+		// removed users keep no copies, and terminating projects can't be changed
+		if removedUserMarked(&project) || project.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		_ = c.syncSeedResources(ctx, objectOwner(&project), project.Name)
+	}
+	klog.V(2).Infof("Resynced seed resources of %d projects in %s", len(projects.Items), time.Since(start))
+}
+
+// Deletes the copies of the target user project whose name was removed from SEED_SECRETS or
+// SEED_CONFIGMAPS, or all of them once SEED_NAMESPACE is unset
+func (c *Controller) pruneSeedResources(ctx context.Context, user string, projectName string) error {
+	desiredSecrets := make(map[string]bool)
+	desiredConfigMaps := make(map[string]bool)
+	if GetSeedNamespace() != "" {
+		for _, name := range GetSeedSecrets() {
+			desiredSecrets[name] = true
+		}
+		for _, name := range GetSeedConfigMaps() {
+			desiredConfigMaps[name] = true
+		}
+	}
+
+	secrets, err := c.coreClient.Secrets(projectName).List(ctx, metav1.ListOptions{LabelSelector: copiedSelector})
+	if err != nil {
+		klog.Errorf("Error listing seed Secrets for user %s under project %s: %v", user, projectName, err)
+		return err
+	}
+	secretObjects := make([]metav1.Object, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		secretObjects = append(secretObjects, &secrets.Items[i])
+	}
+	secretsErr := c.pruneSeeded(ctx, user, projectName, "Secret", true, secretObjects, desiredSecrets, func(name string) error {
+		return c.coreClient.Secrets(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+
+	configMaps, err := c.coreClient.ConfigMaps(projectName).List(ctx, metav1.ListOptions{LabelSelector: copiedSelector})
+	if err != nil {
+		klog.Errorf("Error listing seed ConfigMaps for user %s under project %s: %v", user, projectName, err)
+		return errors.Join(secretsErr, err)
+	}
+	configMapObjects := make([]metav1.Object, 0, len(configMaps.Items))
+	for i := range configMaps.Items {
+		configMapObjects = append(configMapObjects, &configMaps.Items[i])
+	}
+	configMapsErr := c.pruneSeeded(ctx, user, projectName, "ConfigMap", true, configMapObjects, desiredConfigMaps, func(name string) error {
+		return c.coreClient.ConfigMaps(projectName).Delete(ctx, name, metav1.DeleteOptions{})
+	})
+	return errors.Join(secretsErr, configMapsErr)
+}
+
+// Returns the drift of the copies of SEED_SECRETS and SEED_CONFIGMAPS under the target user project from
+// their source
+func (c *Controller) seedResourcesDrift(ctx context.Context, projectName string) ([]string, error) {
+	var drift []string
+	for _, name := range GetSeedSecrets() {
+		source, err := c.coreClient.Secrets(GetSeedNamespace()).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		existing, err := c.coreClient.Secrets(projectName).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			drift = append(drift, fmt.Sprintf("Secret %s is missing", name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !equality.Semantic.DeepEqual(existing.Data, source.Data) {
+			drift = append(drift, fmt.Sprintf("Secret %s is out of sync with %s/%s", name, GetSeedNamespace(), name))
+		}
+	}
+	for _, name := range GetSeedConfigMaps() {
+		source, err := c.coreClient.ConfigMaps(GetSeedNamespace()).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		existing, err := c.coreClient.ConfigMaps(projectName).Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			drift = append(drift, fmt.Sprintf("ConfigMap %s is missing", name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !equality.Semantic.DeepEqual(existing.Data, source.Data) || !equality.Semantic.DeepEqual(existing.BinaryData, source.BinaryData) {
+			drift = append(drift, fmt.Sprintf("ConfigMap %s is out of sync with %s/%s", name, GetSeedNamespace(), name))
+		}
+	}
+	return drift, nil
+}
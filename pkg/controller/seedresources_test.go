@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_syncSeedResources(t *testing.T) {
+	t.Setenv("SEED_NAMESPACE", "sandbox-seed")
+	t.Setenv("SEED_SECRETS", "model-registry")
+	t.Setenv("SEED_CONFIGMAPS", "ca-bundle")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "model-registry", Namespace: "sandbox-seed"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"token": []byte("s3cr3t")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "sandbox-seed"},
+			Data:       map[string]string{"ca.crt": "first"},
+		},
+	)
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	secret, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "model-registry", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the seed Secret to be copied under alice, but got error: %v", err)
+	}
+	if string(secret.Data["token"]) != "s3cr3t" {
+		t.Errorf("Expected the copy to hold the data of the source, but got %v", secret.Data)
+	}
+	if got := secret.Annotations[seedSourceAnnotation]; got != "sandbox-seed/model-registry" {
+		t.Errorf("Expected the copy to record its source, but got %q", got)
+	}
+
+	// A changed source is reported as drift and copied again by the resync
+	source, err := kubeClient.CoreV1().ConfigMaps("sandbox-seed").Get(ctx, "ca-bundle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	source.Data["ca.crt"] = "rotated"
+	if _, err := kubeClient.CoreV1().ConfigMaps("sandbox-seed").Update(ctx, source, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+	if drift, err := controller.seedResourcesDrift(ctx, "alice"); err != nil || len(drift) != 1 {
+		t.Errorf("Expected the stale ConfigMap to be reported as drift, but got %v, %v", drift, err)
+	}
+	controller.resyncSeedResources(ctx)
+	configMap, err := kubeClient.CoreV1().ConfigMaps("alice").Get(ctx, "ca-bundle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the seed ConfigMap to be copied under alice, but got error: %v", err)
+	}
+	if got := configMap.Data["ca.crt"]; got != "rotated" {
+		t.Errorf("Expected the copy to follow its source, but got %q", got)
+	}
+
+	// A name removed from the list is pruned
+	t.Setenv("SEED_SECRETS", "")
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned again, but got error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().Secrets("alice").Get(ctx, "model-registry", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the copied Secret to be pruned, but got error: %v", err)
+	}
+	if _, err := kubeClient.CoreV1().ConfigMaps("alice").Get(ctx, "ca-bundle", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected the copied ConfigMap to be kept, but got error: %v", err)
+	}
+}
+
+func TestController_syncSeedResourcesUnmanaged(t *testing.T) {
+	t.Setenv("SEED_NAMESPACE", "sandbox-seed")
+	t.Setenv("SEED_CONFIGMAPS", "ca-bundle")
+
+	ctx := context.Background()
+	kubeClient := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "sandbox-seed"},
+			Data:       map[string]string{"ca.crt": "seed"},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: "alice"},
+			Data:       map[string]string{"ca.crt": "own"},
+		},
+	)
+	controller := &Controller{coreClient: kubeClient.CoreV1()}
+
+	if err := controller.syncSeedResources(ctx, "alice", "alice"); err == nil {
+		t.Errorf("Expected a ConfigMap not copied by the controller not to be overwritten")
+	}
+	configMap, err := kubeClient.CoreV1().ConfigMaps("alice").Get(ctx, "ca-bundle", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get ConfigMap: %v", err)
+	}
+	if got := configMap.Data["ca.crt"]; got != "own" {
+		t.Errorf("Expected the ConfigMap of alice to be kept, but got %q", got)
+	}
+}
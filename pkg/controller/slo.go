@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// annotation recording how long the namespace took to provision
+const provisioningDurationAnnotation = "rosa-namespace-provisioner/provisioning-duration"
+
+// annotation set to "true" when provisioning the namespace breached the SLO target
+const sloBreachedAnnotation = "rosa-namespace-provisioner/provisioning-slo-breached"
+
+// reason of the Event emitted when provisioning breaches the SLO target
+const sloBreachedReason = "ProvisioningSLOBreached"
+
+// Records the time the target user appeared in the group, keeping the earliest time across retries
+func (c *Controller) markPending(user string, since time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pendingSince == nil {
+		c.pendingSince = make(map[string]time.Time)
+	}
+	if _, ok := c.pendingSince[user]; !ok {
+		c.pendingSince[user] = since
+	}
+}
+
+// Forgets when the target user appeared in the group, returning that time or the fallback if unknown
+func (c *Controller) clearPending(user string, fallback time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	since, ok := c.pendingSince[user]
+	delete(c.pendingSince, user)
+	if !ok {
+		return fallback
+	}
+	return since
+}
+
+// Observes the provisioning latency of a newly provisioned namespace and records whether it
+// breached the SLO target. Namespaces created before the provisioning started, e.g. when
+// existing users are replayed on startup, are skipped so they don't skew the latency.
+func (c *Controller) recordProvisioningLatency(ctx context.Context, user string, projectName string, started time.Time, since time.Time) {
+	project, err := c.projectClient.ProjectV1().Projects().Get(ctx, projectName, metav1.GetOptions{})
+	if err != nil {
+		klog.Errorf("Error getting project %s for user %s: %v", projectName, user, err)
+		return
+	}
+	if project.CreationTimestamp.Time.Before(started.Truncate(time.Second)) {
+		klog.V(4).Infof("Project %s for user %s predates provisioning, skipping latency", projectName, user)
+		return
+	}
+
+	duration := time.Since(since)
+	target := GetProvisioningSLOTarget()
+	breached := duration > target
+	metrics.ProvisioningDuration.Observe(duration.Seconds())
+
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q,%q:%q}}}`,
+		provisioningDurationAnnotation, duration.Round(time.Second).String(),
+		sloBreachedAnnotation, fmt.Sprint(breached),
+	)
+	_, err = c.projectClient.ProjectV1().Projects().Patch(ctx, projectName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		klog.Errorf("Error recording provisioning duration on project %s for user %s: %v", projectName, user, err)
+	}
+
+	if !breached {
+		klog.V(2).Infof("Provisioned namespace %s for user %s in %s", projectName, user, duration)
+		return
+	}
+
+	metrics.ProvisioningSLOBreaches.Inc()
+	message := fmt.Sprintf("Provisioning took %s, exceeding the SLO target of %s", duration.Round(time.Second), target)
+	klog.Warningf("Provisioning SLO breached for user %s under project %s: %s", user, projectName, message)
+	if c.recorder != nil {
+		c.recorder.Event(project, corev1.EventTypeWarning, sloBreachedReason, message)
+	}
+}
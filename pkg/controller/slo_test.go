@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestController_recordProvisioningLatency(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name             string
+		createdAt        time.Time
+		since            time.Time
+		expectedBreached string
+		expectedEvents   int
+	}{
+		{
+			name:             "provisioned within the SLO target",
+			createdAt:        now,
+			since:            now.Add(-time.Minute),
+			expectedBreached: "false",
+		},
+		{
+			name:             "provisioning breached the SLO target",
+			createdAt:        now,
+			since:            now.Add(-10 * time.Minute),
+			expectedBreached: "true",
+			expectedEvents:   1,
+		},
+		{
+			name:      "project predates provisioning",
+			createdAt: now.Add(-time.Hour),
+			since:     now.Add(-10 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PROVISIONING_SLO_TARGET", "5m")
+
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(&projectv1.Project{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "alice",
+					CreationTimestamp: metav1.NewTime(tt.createdAt),
+				},
+			})
+			recorder := record.NewFakeRecorder(10)
+			controller := &Controller{
+				projectClient: projectClient,
+				recorder:      recorder,
+			}
+
+			controller.recordProvisioningLatency(ctx, "alice", "alice", now.Add(-time.Second), tt.since)
+
+			project, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("Expected project alice to be found, but got error: %v", err)
+			}
+			if got := project.Annotations[sloBreachedAnnotation]; got != tt.expectedBreached {
+				t.Errorf("Expected SLO breached annotation %q, but got %q", tt.expectedBreached, got)
+			}
+			if len(recorder.Events) != tt.expectedEvents {
+				t.Errorf("Expected %d Events to be recorded, but got %d", tt.expectedEvents, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestController_pendingSince(t *testing.T) {
+	controller := &Controller{}
+	first := time.Now().Add(-time.Minute)
+
+	// Retries keep the time the user first appeared
+	controller.markPending("alice", first)
+	controller.markPending("alice", time.Now())
+
+	if got := controller.clearPending("alice", time.Now()); !got.Equal(first) {
+		t.Errorf("Expected pending time %v, but got %v", first, got)
+	}
+
+	fallback := time.Now()
+	if got := controller.clearPending("alice", fallback); !got.Equal(fallback) {
+		t.Errorf("Expected fallback time %v once cleared, but got %v", fallback, got)
+	}
+}
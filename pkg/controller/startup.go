@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Reconciles the members of the target groups against the managed projects once on startup, picking
+// up the changes made while the controller was down: members without a project are provisioned and,
+// when enabled, managed users no longer members are deprovisioned. A drop in membership flagged as
+// anomalous keeps every namespace.
+func (c *Controller) reconcileOnStartup(ctx context.Context) {
+	// the membership sync reconciles every source on startup
+	if mergedMembershipEnabled() {
+		return
+	}
+	plan, err := c.PlanUsers(ctx)
+	if err != nil {
+		// Skip the reconciliation rather than act on a partial view of the groups
+		klog.Errorf("Error planning startup reconciliation of %s: %v", membershipDescription(), err)
+		return
+	}
+	klog.Infof("Reconciling %s on startup: %d users to provision, %d users no longer members, %d users up to date",
+		membershipDescription(), len(plan.Add), len(plan.Remove), len(plan.Keep))
+
+	for _, user := range plan.Add {
+		c.markPending(user, time.Now())
+		_ = c.admitUser(ctx, user)
+	}
+
+	if len(plan.Remove) == 0 {
+		return
+	}
+	if !GetStartupCleanupEnabled() {
+		klog.Infof("Keeping namespaces of users no longer members of %s as startup cleanup is disabled: %v",
+			membershipDescription(), plan.Remove)
+		return
+	}
+	managed := len(plan.Remove) + len(plan.Keep)
+	if GetGroupAnomalyDetectionEnabled() && anomalousDrop(managed, len(plan.Keep)) {
+		klog.Warningf("Keeping namespaces of users no longer members of %s as %d of %d managed users left while the controller was down: %v",
+			membershipDescription(), len(plan.Remove), managed, plan.Remove)
+		return
+	}
+	for _, user := range plan.Remove {
+		_ = c.deprovisionUser(ctx, user)
+	}
+}
+
+// ReconcileOnce reconciles every member of the target groups, or of the merged membership sources,
+// against the managed projects and returns, for running the controller as a CronJob. Every member is
+// provisioned again, restoring their project and RoleBindings, managed users no longer members are
+// deprovisioned unless that drop is anomalous, and projects whose grace period ended are deleted. The
+// errors of every user are returned joined.
+func (c *Controller) ReconcileOnce(ctx context.Context) error {
+	ctx = withDefaultTrigger(ctx, TriggerOnce)
+	plan, err := c.PlanUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to plan the reconciliation of %s: %w", membershipDescription(), err)
+	}
+	klog.Infof("Reconciling %s once: %d users to provision, %d users no longer members, %d users up to date",
+		membershipDescription(), len(plan.Add), len(plan.Remove), len(plan.Keep))
+
+	var errs []error
+	for _, user := range append(plan.Add, plan.Keep...) {
+		if err := c.admitUser(ctx, user); err != nil {
+			errs = append(errs, fmt.Errorf("failed to provision user %s: %w", user, err))
+		}
+	}
+
+	managed := len(plan.Remove) + len(plan.Keep)
+	if len(plan.Remove) > 0 && GetGroupAnomalyDetectionEnabled() && anomalousDrop(managed, len(plan.Keep)) {
+		errs = append(errs, fmt.Errorf("keeping namespaces of users no longer members of %s as %d of %d managed users left: %v",
+			membershipDescription(), len(plan.Remove), managed, plan.Remove))
+		return errors.Join(errs...)
+	}
+	for _, user := range plan.Remove {
+		if err := c.deprovisionUser(ctx, user); err != nil {
+			errs = append(errs, fmt.Errorf("failed to deprovision user %s: %w", user, err))
+		}
+	}
+	// there is no sweeper between runs to delete the projects whose grace period ended
+	c.sweepScheduledDeletions(ctx)
+	return errors.Join(errs...)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestController_reconcileOnStartup(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectRemoved bool
+	}{
+		{name: "cleanup disabled", expectRemoved: false},
+		{name: "cleanup enabled", env: map[string]string{"STARTUP_CLEANUP_ENABLED": "true"}, expectRemoved: true},
+		{
+			name: "anomalous drop",
+			env: map[string]string{
+				"STARTUP_CLEANUP_ENABLED":         "true",
+				"GROUP_ANOMALY_DETECTION_ENABLED": "true",
+				"GROUP_ANOMALY_MIN_SIZE":          "1",
+				"GROUP_ANOMALY_THRESHOLD":         "40",
+			},
+			expectRemoved: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TARGET_GROUP_NAMES", "cohort")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			// alice joined and carol left while the controller was down
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(desiredProject("bob", "bob"), desiredProject("carol", "carol"))
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice", "bob")),
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+			controller.reconcileOnStartup(withTrigger(ctx, TriggerStartup))
+
+			if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected project alice to be provisioned, but got error: %v", err)
+			}
+			if _, err := projectClient.ProjectV1().Projects().Get(ctx, "bob", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected project bob to be kept, but got error: %v", err)
+			}
+			_, err := projectClient.ProjectV1().Projects().Get(ctx, "carol", metav1.GetOptions{})
+			if removed := apierrors.IsNotFound(err); removed != tt.expectRemoved {
+				t.Errorf("Expected project carol to be removed: %v, but got error: %v", tt.expectRemoved, err)
+			}
+		})
+	}
+}
+
+func TestController_ReconcileOnce(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectRemoved bool
+		expectError   bool
+	}{
+		{name: "removed users are deprovisioned", expectRemoved: true},
+		{
+			name: "anomalous drop",
+			env: map[string]string{
+				"GROUP_ANOMALY_DETECTION_ENABLED": "true",
+				"GROUP_ANOMALY_MIN_SIZE":          "1",
+				"GROUP_ANOMALY_THRESHOLD":         "40",
+			},
+			expectRemoved: false,
+			expectError:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TARGET_GROUP_NAMES", "cohort")
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			// alice joined, the RoleBinding of bob was deleted and carol left since the last run
+			ctx := context.Background()
+			projectClient := projectfake.NewSimpleClientset(desiredProject("bob", "bob"), desiredProject("carol", "carol"))
+			kubeClient := fake.NewClientset()
+			controller := &Controller{
+				userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice", "bob")),
+				projectClient: projectClient,
+				rbacClient:    kubeClient.RbacV1(),
+				coreClient:    kubeClient.CoreV1(),
+			}
+			err := controller.ReconcileOnce(ctx)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error: %v, but got %v", tt.expectError, err)
+			}
+
+			if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected project alice to be provisioned, but got error: %v", err)
+			}
+			if _, err := kubeClient.RbacV1().RoleBindings("bob").Get(ctx, roleBindingName("bob"), metav1.GetOptions{}); err != nil {
+				t.Errorf("Expected the RoleBinding of bob to be restored, but got error: %v", err)
+			}
+			_, err = projectClient.ProjectV1().Projects().Get(ctx, "carol", metav1.GetOptions{})
+			if removed := apierrors.IsNotFound(err); removed != tt.expectRemoved {
+				t.Errorf("Expected project carol to be removed: %v, but got error: %v", tt.expectRemoved, err)
+			}
+		})
+	}
+}
+
+func TestController_ReconcileOnceGroupError(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort")
+
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(),
+		projectClient: projectfake.NewSimpleClientset(desiredProject("carol", "carol")),
+	}
+	if err := controller.ReconcileOnce(context.Background()); err == nil {
+		t.Errorf("Expected an error when the target group can't be read")
+	}
+}
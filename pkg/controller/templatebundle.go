@@ -0,0 +1,323 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// value of the partOfLabel for the objects applied from the template bundle
+const bundleSet = "template-bundle"
+
+// WithRESTMapper enables applying the template bundle, resolving the resources of its kinds through the
+// given mapper
+func WithRESTMapper(mapper meta.RESTMapper) Option {
+	return func(c *Controller) {
+		c.restMapper = mapper
+	}
+}
+
+// templateBundleData is the data the manifests of the template bundle are executed with
+type templateBundleData struct {
+	// User is the user name as is
+	User string
+	// Namespace is the namespace of the user the manifests are applied into
+	Namespace string
+}
+
+// Returns whether a template bundle is configured
+func templateBundleEnabled() bool {
+	_, name, _ := GetTemplateBundleConfigMap()
+	return GetTemplateBundleDir() != "" || name != ""
+}
+
+// Reads the manifest templates of TEMPLATE_BUNDLE_DIR, keyed by file name. Hidden files, such as the
+// entries of a mounted ConfigMap, and files other than YAML or JSON are skipped.
+func readTemplateBundleDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(name)) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		sources[name] = string(data)
+	}
+	return sources, nil
+}
+
+// Returns the manifest templates of the template bundle, keyed by file name or ConfigMap key
+func (c *Controller) templateBundleSources(ctx context.Context) (map[string]string, error) {
+	if dir := GetTemplateBundleDir(); dir != "" {
+		return readTemplateBundleDir(dir)
+	}
+	namespace, name, err := GetTemplateBundleConfigMap()
+	if err != nil || name == "" {
+		return nil, err
+	}
+	configMap, err := c.coreClient.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return configMap.Data, nil
+}
+
+// Executes the manifest templates in the order of their keys and returns the objects they define under
+// the namespace of the data. Each template may hold several YAML documents.
+func renderTemplateBundle(sources map[string]string, data templateBundleData) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	seen := make(map[string]bool)
+	for _, key := range slices.Sorted(maps.Keys(sources)) {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(sources[key])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", key, err)
+		}
+		var rendered bytes.Buffer
+		if err := tmpl.Execute(&rendered, data); err != nil {
+			return nil, fmt.Errorf("failed to execute template %s: %w", key, err)
+		}
+
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(&rendered))
+		for document := 1; ; document++ {
+			raw, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read template %s: %w", key, err)
+			}
+			if len(bytes.TrimSpace(raw)) == 0 {
+				continue
+			}
+			manifest, err := yaml.YAMLToJSON(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse document %d of template %s: %w", document, key, err)
+			}
+			if string(manifest) == "null" {
+				continue
+			}
+			// integers are decoded as int64, the way the API server returns them
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse document %d of template %s: %w", document, key, err)
+			}
+			if obj.GetAPIVersion() == "" || obj.GetName() == "" {
+				return nil, fmt.Errorf("document %d of template %s has no apiVersion or name", document, key)
+			}
+			if namespace := obj.GetNamespace(); namespace != "" && namespace != data.Namespace {
+				return nil, fmt.Errorf("%s %s of template %s targets namespace %s", obj.GetKind(), obj.GetName(), key, namespace)
+			}
+			id := obj.GroupVersionKind().GroupKind().String() + "/" + obj.GetName()
+			if seen[id] {
+				return nil, fmt.Errorf("%s %s is defined more than once in the template bundle", obj.GetKind(), obj.GetName())
+			}
+			seen[id] = true
+			objects = append(objects, obj)
+		}
+	}
+	return objects, nil
+}
+
+// Returns the objects of the template bundle rendered for the target user project
+func (c *Controller) desiredBundleObjects(ctx context.Context, user string, projectName string) ([]*unstructured.Unstructured, error) {
+	sources, err := c.templateBundleSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := renderTemplateBundle(sources, templateBundleData{User: user, Namespace: projectName})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		obj.SetNamespace(projectName)
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[ownerLabel] = ownerLabelValue(user)
+		labels[partOfLabel] = bundleSet
+		obj.SetLabels(labels)
+	}
+	return objects, nil
+}
+
+// Applies the objects of the template bundle under the target user project, restoring the fields they
+// set when modified. Objects of the same name not applied by the controller are never overwritten.
+func (c *Controller) applyTemplateBundle(ctx context.Context, user string, projectName string) error {
+	if c.dynamicClient == nil || c.restMapper == nil {
+		return errors.New("no dynamic client or REST mapper configured")
+	}
+	objects, err := c.desiredBundleObjects(ctx, user, projectName)
+	if err != nil {
+		klog.Errorf("Error rendering the template bundle for user %s: %v", user, err)
+		return err
+	}
+
+	var errs []error
+	for _, obj := range objects {
+		if err := c.applyBundleObject(ctx, user, projectName, obj); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Creates or restores a single object of the template bundle under the target user project
+func (c *Controller) applyBundleObject(ctx context.Context, user string, projectName string, obj *unstructured.Unstructured) error {
+	gvk := obj.GroupVersionKind()
+	kind, name := obj.GetKind(), obj.GetName()
+	mapping, err := c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		klog.Errorf("Error resolving the resource of %s %s for user %s: %v", kind, name, user, err)
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		err := fmt.Errorf("%s %s of the template bundle is cluster-scoped", kind, name)
+		klog.Error(err)
+		return err
+	}
+	client := c.dynamicClient.Resource(mapping.Resource).Namespace(projectName)
+
+	existing, err := client.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if GetServerSideApplyEnabled() {
+			_, err = applyObject(ctx, client.Patch, obj, gvk)
+		} else {
+			_, err = client.Create(ctx, obj, metav1.CreateOptions{})
+		}
+		if err != nil {
+			klog.Errorf("Error creating %s %s for user %s under project %s: %v", kind, name, user, projectName, err)
+			return err
+		}
+		klog.Infof("Successfully created %s %s for user %s under project %s", kind, name, user, projectName)
+		return nil
+	} else if err != nil {
+		klog.Errorf("Error checking if %s %s exists for user %s under project %s: %v", kind, name, user, projectName, err)
+		return err
+	}
+
+	// never overwrite objects that were not applied by the controller
+	if existing.GetLabels()[partOfLabel] != bundleSet {
+		err := fmt.Errorf("%s %s under project %s is not managed by the controller and will not be overwritten", kind, name, projectName)
+		klog.Error(err)
+		return err
+	}
+	if bundleObjectInSync(existing, obj) {
+		klog.V(2).Infof("%s %s under project %s already exist for user %s", kind, name, projectName, user)
+		return nil
+	}
+
+	if GetServerSideApplyEnabled() {
+		_, err = applyObject(ctx, client.Patch, obj, gvk)
+	} else {
+		// only the fields set by the template are restored, leaving those defaulted by the API server
+		labels := existing.GetLabels()
+		maps.Copy(labels, obj.GetLabels())
+		existing.SetLabels(labels)
+		for field, value := range obj.Object {
+			if field != "metadata" && field != "apiVersion" && field != "kind" {
+				existing.Object[field] = value
+			}
+		}
+		_, err = client.Update(ctx, existing, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		klog.Errorf("Error updating %s %s for user %s under project %s: %v", kind, name, user, projectName, err)
+		return err
+	}
+	klog.Infof("Restored %s %s for user %s under project %s", kind, name, user, projectName)
+	return nil
+}
+
+// Returns whether the object holds the labels and fields set by the template, ignoring the fields the
+// API server defaulted
+func bundleObjectInSync(existing *unstructured.Unstructured, desired *unstructured.Unstructured) bool {
+	labels := existing.GetLabels()
+	for key, value := range desired.GetLabels() {
+		if labels[key] != value {
+			return false
+		}
+	}
+	for field, value := range desired.Object {
+		if field == "metadata" || field == "apiVersion" || field == "kind" {
+			continue
+		}
+		if !manifestContains(existing.Object[field], value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns whether the manifest value holds every field of the desired one with the same value
+func manifestContains(value interface{}, desired interface{}) bool {
+	desiredMap, ok := desired.(map[string]interface{})
+	if !ok {
+		return equality.Semantic.DeepEqual(value, desired)
+	}
+	valueMap, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, field := range desiredMap {
+		if !manifestContains(valueMap[key], field) {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the drift of the objects of the template bundle under the target user project, if any
+func (c *Controller) templateBundleDrift(ctx context.Context, user string, projectName string) ([]string, error) {
+	if c.dynamicClient == nil || c.restMapper == nil {
+		return nil, nil
+	}
+	objects, err := c.desiredBundleObjects(ctx, user, projectName)
+	if err != nil {
+		return nil, err
+	}
+
+	var drift []string
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		mapping, err := c.restMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := c.dynamicClient.Resource(mapping.Resource).Namespace(projectName).Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			drift = append(drift, fmt.Sprintf("%s %s is missing", obj.GetKind(), obj.GetName()))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if !bundleObjectInSync(existing, obj) {
+			drift = append(drift, fmt.Sprintf("%s %s differs from the template bundle", obj.GetKind(), obj.GetName()))
+		}
+	}
+	return drift, nil
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var devWorkspaceTemplateGVK = schema.GroupVersionKind{Group: "workspace.devfile.io", Version: "v1alpha2", Kind: "DevWorkspaceTemplate"}
+
+func TestRenderTemplateBundle(t *testing.T) {
+	tests := []struct {
+		name     string
+		sources  map[string]string
+		expected []string
+		err      string
+	}{
+		{
+			name: "templates rendered in the order of their keys",
+			sources: map[string]string{
+				"b.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: welcome\ndata:\n  user: {{ .User }}\n",
+				"a.yaml": "# defaults\n---\napiVersion: workspace.devfile.io/v1alpha2\nkind: DevWorkspaceTemplate\nmetadata:\n  name: {{ .User }}-ide\n  namespace: {{ .Namespace }}\n---\n",
+			},
+			expected: []string{"DevWorkspaceTemplate/alice-ide", "ConfigMap/welcome"},
+		},
+		{
+			name:    "unknown field",
+			sources: map[string]string{"a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Username }}\n"},
+			err:     "failed to execute template a.yaml",
+		},
+		{
+			name:    "object of another namespace",
+			sources: map[string]string{"a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: welcome\n  namespace: openshift-config\n"},
+			err:     "targets namespace openshift-config",
+		},
+		{
+			name: "object defined twice",
+			sources: map[string]string{
+				"a.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: welcome\n",
+				"b.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: welcome\n",
+			},
+			err: "ConfigMap welcome is defined more than once",
+		},
+		{
+			name:    "object without a name",
+			sources: map[string]string{"a.yaml": "apiVersion: v1\nkind: ConfigMap\n"},
+			err:     "document 1 of template a.yaml has no apiVersion or name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := renderTemplateBundle(tt.sources, templateBundleData{User: "alice", Namespace: "alice"})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, but got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected templates to be rendered, but got error: %v", err)
+			}
+			var got []string
+			for _, obj := range objects {
+				got = append(got, obj.GetKind()+"/"+obj.GetName())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected objects %v, but got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestController_applyTemplateBundle(t *testing.T) {
+	dir := t.TempDir()
+	manifest := "apiVersion: workspace.devfile.io/v1alpha2\nkind: DevWorkspaceTemplate\nmetadata:\n  name: ide\nspec:\n  components:\n  - name: {{ .User }}\n"
+	if err := os.WriteFile(filepath.Join(dir, "ide.yaml"), []byte(manifest), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	// entries of a mounted ConfigMap are skipped
+	if err := os.WriteFile(filepath.Join(dir, "..data"), []byte("not a manifest"), 0o644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	t.Setenv("TEMPLATE_BUNDLE_DIR", dir)
+	// the fake dynamic client can't apply objects which don't exist yet
+	t.Setenv("SERVER_SIDE_APPLY_ENABLED", "false")
+
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(devWorkspaceTemplateGVK, meta.RESTScopeNamespace)
+	resource := schema.GroupVersionResource{Group: "workspace.devfile.io", Version: "v1alpha2", Resource: "devworkspacetemplates"}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{resource: "DevWorkspaceTemplateList"})
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: dynamicClient,
+		restMapper:    mapper,
+	}
+
+	if err := controller.provisionUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	obj, err := dynamicClient.Resource(resource).Namespace("alice").Get(ctx, "ide", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the DevWorkspaceTemplate to be applied under alice, but got error: %v", err)
+	}
+	if obj.GetLabels()[partOfLabel] != bundleSet {
+		t.Errorf("Expected the DevWorkspaceTemplate to be labeled as part of the bundle, but got %v", obj.GetLabels())
+	}
+	components, _, _ := unstructured.NestedSlice(obj.Object, "spec", "components")
+	if len(components) != 1 || components[0].(map[string]interface{})["name"] != "alice" {
+		t.Errorf("Expected the template to be rendered for alice, but got %v", components)
+	}
+
+	// A modified object is reported as drift and restored, keeping the fields set by the API server
+	if err := unstructured.SetNestedSlice(obj.Object, []interface{}{}, "spec", "components"); err != nil {
+		t.Fatalf("Failed to modify DevWorkspaceTemplate: %v", err)
+	}
+	if err := unstructured.SetNestedField(obj.Object, "Running", "status", "phase"); err != nil {
+		t.Fatalf("Failed to modify DevWorkspaceTemplate: %v", err)
+	}
+	if _, err := dynamicClient.Resource(resource).Namespace("alice").Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update DevWorkspaceTemplate: %v", err)
+	}
+	if drift, err := controller.templateBundleDrift(ctx, "alice", "alice"); err != nil || len(drift) != 1 {
+		t.Errorf("Expected the modified DevWorkspaceTemplate to be reported as drift, but got %v, %v", drift, err)
+	}
+	if err := controller.applyTemplateBundle(ctx, "alice", "alice"); err != nil {
+		t.Fatalf("Expected the template bundle to be applied again, but got error: %v", err)
+	}
+	obj, err = dynamicClient.Resource(resource).Namespace("alice").Get(ctx, "ide", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get DevWorkspaceTemplate: %v", err)
+	}
+	if components, _, _ := unstructured.NestedSlice(obj.Object, "spec", "components"); len(components) != 1 {
+		t.Errorf("Expected the components to be restored, but got %v", components)
+	}
+	if phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase"); phase != "Running" {
+		t.Errorf("Expected the status to be kept, but got %q", phase)
+	}
+	if drift, err := controller.templateBundleDrift(ctx, "alice", "alice"); err != nil || len(drift) != 0 {
+		t.Errorf("Expected no drift once restored, but got %v, %v", drift, err)
+	}
+}
+
+func TestController_applyTemplateBundleUnmanaged(t *testing.T) {
+	t.Setenv("TEMPLATE_BUNDLE_CONFIGMAP", "provisioner/bundle")
+
+	ctx := context.Background()
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("ConfigMap")
+	existing.SetName("welcome")
+	existing.SetNamespace("alice")
+	kubeClient := fake.NewClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bundle", Namespace: "provisioner"},
+		Data: map[string]string{
+			"welcome.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: welcome\n",
+			"namespace.yaml": "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: {{ .User }}-extra\n",
+		},
+	})
+	controller := &Controller{
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existing),
+		restMapper:    mapper,
+	}
+
+	err := controller.applyTemplateBundle(ctx, "alice", "alice")
+	if err == nil {
+		t.Fatalf("Expected the template bundle not to be applied")
+	}
+	for _, expected := range []string{"Namespace alice-extra of the template bundle is cluster-scoped", "ConfigMap welcome under project alice is not managed by the controller"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error containing %q, but got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+
+	userv1 "github.com/openshift/api/user/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Reasons a reconcile was triggered, labeling the reconcile metrics and recorded on the ManagedNamespace
+// of the users it provisions
+const (
+	// TriggerAdd is a watched object seen for the first time, e.g. a created group or a controller restart
+	TriggerAdd = "add"
+	// TriggerUpdate is a change to a watched object
+	TriggerUpdate = "update"
+	// TriggerResync is a watched object delivered again unchanged by the periodic informer resync
+	TriggerResync = "resync"
+	// TriggerDelete is the deletion of a watched object, e.g. a target group being torn down
+	TriggerDelete = "delete"
+	// TriggerAdmin is an admin action: the admin API, approvals, requested reconciles and commands such as
+	// bulk-onboard
+	TriggerAdmin = "admin"
+	// TriggerRepair is a periodic task, such as the membership sync or the deletion sweeper
+	TriggerRepair = "repair"
+	// TriggerStartup is the reconciliation of the target groups against the managed projects on startup
+	TriggerStartup = "startup"
+	// TriggerOnce is a one-shot reconcile run with --once, e.g. from a CronJob
+	TriggerOnce = "once"
+	// TriggerClaim is a NamespaceClaim created, changed or deleted by a user requesting another namespace
+	TriggerClaim = "claim"
+)
+
+// trigger reported when none was set, which would be a bug
+const triggerUnknown = "unknown"
+
+// triggerKey is the context key of the reconcile trigger
+type triggerKey struct{}
+
+// Returns a context carrying the reason of the reconcile
+func withTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// Returns the reason of the reconcile the context belongs to
+func reconcileTrigger(ctx context.Context) string {
+	if trigger, ok := ctx.Value(triggerKey{}).(string); ok {
+		return trigger
+	}
+	return triggerUnknown
+}
+
+// Returns a context carrying the trigger unless the context already carries one
+func withDefaultTrigger(ctx context.Context, trigger string) context.Context {
+	if _, ok := ctx.Value(triggerKey{}).(string); ok {
+		return ctx
+	}
+	return withTrigger(ctx, trigger)
+}
+
+// Returns what triggered handling a group against the revision handled last
+func groupTrigger(oldGroup, newGroup *userv1.Group) string {
+	switch {
+	case oldGroup == nil:
+		return TriggerAdd
+	case newGroup.DeletionTimestamp != nil:
+		return TriggerDelete
+	case oldGroup.ResourceVersion == newGroup.ResourceVersion:
+		return TriggerResync
+	default:
+		return TriggerUpdate
+	}
+}
+
+// Returns what triggered handling a namespace against the revision handled last
+func namespaceTrigger(oldNamespace, newNamespace *corev1.Namespace) string {
+	switch {
+	case oldNamespace == nil:
+		return TriggerAdd
+	case newNamespace.DeletionTimestamp != nil:
+		return TriggerDelete
+	case oldNamespace.ResourceVersion == newNamespace.ResourceVersion:
+		return TriggerResync
+	default:
+		return TriggerUpdate
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGroupTrigger(t *testing.T) {
+	revision := func(resourceVersion string, users ...string) *userv1.Group {
+		group := newGroup("cohort", users...)
+		group.ResourceVersion = resourceVersion
+		return group
+	}
+	deleted := revision("3", "alice")
+	deleted.DeletionTimestamp = &metav1.Time{}
+
+	tests := []struct {
+		name     string
+		oldGroup *userv1.Group
+		newGroup *userv1.Group
+		expected string
+	}{
+		{name: "first seen", newGroup: revision("1", "alice"), expected: TriggerAdd},
+		{name: "changed", oldGroup: revision("1", "alice"), newGroup: revision("2", "alice", "bob"), expected: TriggerUpdate},
+		{name: "delivered again", oldGroup: revision("2", "alice"), newGroup: revision("2", "alice"), expected: TriggerResync},
+		{name: "being deleted", oldGroup: revision("2", "alice"), newGroup: deleted, expected: TriggerDelete},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := groupTrigger(tt.oldGroup, tt.newGroup); got != tt.expected {
+				t.Errorf("Expected trigger %s, but got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestController_provisionUserTrigger(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAMES", "cohort")
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	broadcaster := events.NewBroadcaster()
+	ch, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("cohort", "alice")),
+		projectClient: projectfake.NewSimpleClientset(),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		dynamicClient: newInventoryClient(),
+		broadcaster:   broadcaster,
+	}
+
+	// Users added to a group are provisioned by the update
+	old := newGroup("cohort")
+	old.ResourceVersion = "1"
+	updated := newGroup("cohort", "alice")
+	updated.ResourceVersion = "2"
+	controller.handleGroup(old, updated)
+	if got := <-ch; got.Trigger != TriggerUpdate {
+		t.Errorf("Expected the provisioning of alice to be triggered by the update, but got %+v", got)
+	}
+	if got := getManagedNamespace(t, controller, "alice").Status.LastReconcileTrigger; got != TriggerUpdate {
+		t.Errorf("Expected the ManagedNamespace of alice to record the update trigger, but got %q", got)
+	}
+
+	// Callers of the exported API are admins
+	if err := controller.ProvisionUser(context.Background(), "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	if got := getManagedNamespace(t, controller, "alice").Status.LastReconcileTrigger; got != TriggerAdmin {
+		t.Errorf("Expected the ManagedNamespace of alice to record the admin trigger, but got %q", got)
+	}
+}
@@ -0,0 +1,385 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	userv1 "github.com/openshift/api/user/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// Policies for the managed namespaces when uninstalling the provisioner
+const (
+	// UninstallKeepNamespaces keeps managed namespaces and their workloads, only removing what the
+	// provisioner added to them
+	UninstallKeepNamespaces = "keep"
+	// UninstallDeleteNamespaces deletes managed namespaces along with everything in them
+	UninstallDeleteNamespaces = "delete"
+)
+
+// prefix of the labels and annotations the provisioner sets
+const keyPrefix = "rosa-namespace-provisioner/"
+
+// CleanupAction is a single change made when uninstalling the provisioner
+type CleanupAction struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Change describes what happens to the object
+	Change string
+
+	run func(ctx context.Context) error
+}
+
+// Apply makes the change, succeeding if the object is already gone
+func (a CleanupAction) Apply(ctx context.Context) error {
+	if err := a.run(ctx); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// PlanUninstall lists the changes removing everything the provisioner created, deleting or keeping
+// the managed namespaces according to the policy. It only issues read requests, so the plan can
+// be reviewed before applying it. The controller must be stopped first so it doesn't re-provision
+// what is removed.
+func (c *Controller) PlanUninstall(ctx context.Context, policy string) ([]CleanupAction, error) {
+	if policy != UninstallKeepNamespaces && policy != UninstallDeleteNamespaces {
+		return nil, fmt.Errorf("invalid namespace policy %q, expected %s or %s", policy, UninstallKeepNamespaces, UninstallDeleteNamespaces)
+	}
+
+	namespaces, err := c.coreClient.Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: ownerLabel,
+	})
+	if err != nil {
+		klog.Errorf("Error listing managed namespaces for uninstall: %v", err)
+		return nil, err
+	}
+	sort.Slice(namespaces.Items, func(i, j int) bool {
+		return namespaces.Items[i].Name < namespaces.Items[j].Name
+	})
+
+	var actions []CleanupAction
+	for _, namespace := range namespaces.Items {
+		// namespaces the controller didn't create are only released
+		if policy == UninstallDeleteNamespaces && isManaged(&namespace) {
+			actions = append(actions, c.deleteNamespaceAction(namespace.Name))
+			continue
+		}
+		namespaceActions, err := c.releaseNamespaceActions(ctx, namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, namespaceActions...)
+	}
+
+	// target groups would otherwise never finish deleting without the controller
+	if c.userClient != nil {
+		for _, name := range GetTargetGroupNames() {
+			group, err := c.userClient.UserV1().Groups().Get(ctx, name, metav1.GetOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				klog.Errorf("Error getting group %s for uninstall: %v", name, err)
+				return nil, err
+			}
+			if err != nil || !hasFinalizer(group, teardownFinalizer) {
+				continue
+			}
+			groupName := name
+			actions = append(actions, CleanupAction{
+				Kind:   "Group",
+				Name:   groupName,
+				Change: "keep, remove finalizer",
+				run: func(ctx context.Context) error {
+					return c.updateGroup(ctx, groupName, func(group *userv1.Group) {
+						group.Finalizers = removeFinalizer(group.Finalizers, teardownFinalizer)
+					})
+				},
+			})
+		}
+	}
+
+	if c.quotaClient != nil {
+		quotas, err := c.quotaClient.QuotaV1().ClusterResourceQuotas().List(ctx, metav1.ListOptions{
+			LabelSelector: ownerLabel,
+		})
+		if err != nil {
+			klog.Errorf("Error listing ClusterResourceQuotas for uninstall: %v", err)
+			return nil, err
+		}
+		for _, quota := range quotas.Items {
+			name := quota.Name
+			actions = append(actions, CleanupAction{
+				Kind:   "ClusterResourceQuota",
+				Name:   name,
+				Change: "delete",
+				run: func(ctx context.Context) error {
+					return c.quotaClient.QuotaV1().ClusterResourceQuotas().Delete(ctx, name, metav1.DeleteOptions{})
+				},
+			})
+		}
+	}
+
+	if c.dynamicClient != nil {
+		client := c.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource)
+		records, err := client.List(ctx, metav1.ListOptions{})
+		// the CRD is only installed when the inventory is enabled
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Error listing ManagedNamespaces for uninstall: %v", err)
+			return nil, err
+		}
+		if err == nil {
+			for _, record := range records.Items {
+				name := record.GetName()
+				actions = append(actions, CleanupAction{
+					Kind:   v1alpha1.ManagedNamespaceKind,
+					Name:   name,
+					Change: "delete",
+					run: func(ctx context.Context) error {
+						return client.Delete(ctx, name, metav1.DeleteOptions{})
+					},
+				})
+			}
+		}
+	}
+
+	if c.dynamicClient != nil {
+		client := c.dynamicClient.Resource(consoleNotificationsResource)
+		_, err := client.Get(ctx, consoleNotificationName, metav1.GetOptions{})
+		// the resource only exists on clusters running the OpenShift console
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Errorf("Error getting ConsoleNotification %s for uninstall: %v", consoleNotificationName, err)
+			return nil, err
+		}
+		if err == nil {
+			actions = append(actions, CleanupAction{
+				Kind:   "ConsoleNotification",
+				Name:   consoleNotificationName,
+				Change: "delete",
+				run: func(ctx context.Context) error {
+					return client.Delete(ctx, consoleNotificationName, metav1.DeleteOptions{})
+				},
+			})
+		}
+	}
+
+	return actions, nil
+}
+
+// Returns the action deleting a managed namespace, releasing its protection finalizer first so the
+// deletion completes without the controller
+func (c *Controller) deleteNamespaceAction(name string) CleanupAction {
+	return CleanupAction{
+		Kind:   "Project",
+		Name:   name,
+		Change: "delete with its contents",
+		run: func(ctx context.Context) error {
+			if err := c.updateNamespace(ctx, name, func(namespace *corev1.Namespace) {
+				namespace.Finalizers = removeFinalizer(namespace.Finalizers, protectionFinalizer)
+			}); err != nil {
+				return err
+			}
+			return c.projectClient.ProjectV1().Projects().Delete(ctx, name, metav1.DeleteOptions{})
+		},
+	}
+}
+
+// Returns the actions removing the policies, anchor and ownership metadata the provisioner added
+// to a managed namespace while keeping the namespace and its workloads
+func (c *Controller) releaseNamespaceActions(ctx context.Context, name string) ([]CleanupAction, error) {
+	var actions []CleanupAction
+	seeded := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", partOfLabel, seededSet)}
+
+	// Seeded Secrets may be used by workloads, so they are kept but no longer owned by the anchor,
+	// along with the onboarding Secret, the automation pull Secret and the copies of seed Secrets
+	secrets, err := c.coreClient.Secrets(name).List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s in (%s,%s,%s,%s)", partOfLabel, seededSet, onboardingSet, automationSet, copiedSet),
+	})
+	if err != nil {
+		klog.Errorf("Error listing seeded Secrets under project %s for uninstall: %v", name, err)
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		secretName := secret.Name
+		actions = append(actions, CleanupAction{
+			Kind:      "Secret",
+			Name:      secretName,
+			Namespace: name,
+			Change:    "keep, remove ownership",
+			run: func(ctx context.Context) error {
+				return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					secret, err := c.coreClient.Secrets(name).Get(ctx, secretName, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					releaseMetadata(&secret.ObjectMeta)
+					_, err = c.coreClient.Secrets(name).Update(ctx, secret, metav1.UpdateOptions{})
+					return err
+				})
+			},
+		})
+	}
+
+	// The copies of seed ConfigMaps are kept the same way
+	configMaps, err := c.coreClient.ConfigMaps(name).List(ctx, metav1.ListOptions{LabelSelector: copiedSelector})
+	if err != nil {
+		klog.Errorf("Error listing seed ConfigMaps under project %s for uninstall: %v", name, err)
+		return nil, err
+	}
+	for _, configMap := range configMaps.Items {
+		configMapName := configMap.Name
+		actions = append(actions, CleanupAction{
+			Kind:      "ConfigMap",
+			Name:      configMapName,
+			Namespace: name,
+			Change:    "keep, remove ownership",
+			run: func(ctx context.Context) error {
+				return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					configMap, err := c.coreClient.ConfigMaps(name).Get(ctx, configMapName, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					releaseMetadata(&configMap.ObjectMeta)
+					_, err = c.coreClient.ConfigMaps(name).Update(ctx, configMap, metav1.UpdateOptions{})
+					return err
+				})
+			},
+		})
+	}
+
+	// The automation ServiceAccount may be used by pipelines, so it is kept the same way
+	serviceAccounts, err := c.coreClient.ServiceAccounts(name).List(ctx, metav1.ListOptions{LabelSelector: automationSelector})
+	if err != nil {
+		klog.Errorf("Error listing automation ServiceAccounts under project %s for uninstall: %v", name, err)
+		return nil, err
+	}
+	for _, serviceAccount := range serviceAccounts.Items {
+		serviceAccountName := serviceAccount.Name
+		actions = append(actions, CleanupAction{
+			Kind:      "ServiceAccount",
+			Name:      serviceAccountName,
+			Namespace: name,
+			Change:    "keep, remove ownership",
+			run: func(ctx context.Context) error {
+				return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+					serviceAccount, err := c.coreClient.ServiceAccounts(name).Get(ctx, serviceAccountName, metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					releaseMetadata(&serviceAccount.ObjectMeta)
+					_, err = c.coreClient.ServiceAccounts(name).Update(ctx, serviceAccount, metav1.UpdateOptions{})
+					return err
+				})
+			},
+		})
+	}
+
+	quotas, err := c.coreClient.ResourceQuotas(name).List(ctx, seeded)
+	if err != nil {
+		klog.Errorf("Error listing seeded ResourceQuotas under project %s for uninstall: %v", name, err)
+		return nil, err
+	}
+	for _, quota := range quotas.Items {
+		quotaName := quota.Name
+		actions = append(actions, CleanupAction{
+			Kind:      "ResourceQuota",
+			Name:      quotaName,
+			Namespace: name,
+			Change:    "delete",
+			run: func(ctx context.Context) error {
+				return c.coreClient.ResourceQuotas(name).Delete(ctx, quotaName, metav1.DeleteOptions{})
+			},
+		})
+	}
+
+	roleBinding := roleBindingName(name)
+	existing, err := c.rbacClient.RoleBindings(name).Get(ctx, roleBinding, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		klog.Errorf("Error getting RoleBinding %s under project %s for uninstall: %v", roleBinding, name, err)
+		return nil, err
+	}
+	// RoleBindings the controller didn't create are kept
+	if err == nil && isManaged(existing) {
+		actions = append(actions, CleanupAction{
+			Kind:      "RoleBinding",
+			Name:      roleBinding,
+			Namespace: name,
+			Change:    "delete",
+			run: func(ctx context.Context) error {
+				return c.rbacClient.RoleBindings(name).Delete(ctx, roleBinding, metav1.DeleteOptions{})
+			},
+		})
+	}
+
+	if _, err := c.coreClient.ConfigMaps(name).Get(ctx, anchorConfigMapName, metav1.GetOptions{}); err == nil {
+		actions = append(actions, CleanupAction{
+			Kind:      "ConfigMap",
+			Name:      anchorConfigMapName,
+			Namespace: name,
+			Change:    "delete",
+			run: func(ctx context.Context) error {
+				return c.coreClient.ConfigMaps(name).Delete(ctx, anchorConfigMapName, metav1.DeleteOptions{})
+			},
+		})
+	} else if !errors.IsNotFound(err) {
+		klog.Errorf("Error getting anchor ConfigMap under project %s for uninstall: %v", name, err)
+		return nil, err
+	}
+
+	actions = append(actions, CleanupAction{
+		Kind:   "Namespace",
+		Name:   name,
+		Change: "keep, remove ownership and finalizer",
+		run: func(ctx context.Context) error {
+			return c.updateNamespace(ctx, name, func(namespace *corev1.Namespace) {
+				releaseMetadata(&namespace.ObjectMeta)
+				namespace.Finalizers = removeFinalizer(namespace.Finalizers, protectionFinalizer)
+			})
+		},
+	})
+	return actions, nil
+}
+
+// Gets the namespace, applies the change and updates it, retrying on conflicts
+func (c *Controller) updateNamespace(ctx context.Context, name string, change func(namespace *corev1.Namespace)) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		namespace, err := c.coreClient.Namespaces().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		change(namespace)
+		_, err = c.coreClient.Namespaces().Update(ctx, namespace, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Removes the labels, annotations and anchor owner references the provisioner set on an object
+func releaseMetadata(obj *metav1.ObjectMeta) {
+	if obj.Labels[managedByLabel] == componentName {
+		delete(obj.Labels, managedByLabel)
+	}
+	for key := range obj.Labels {
+		if strings.HasPrefix(key, keyPrefix) {
+			delete(obj.Labels, key)
+		}
+	}
+	for key := range obj.Annotations {
+		if strings.HasPrefix(key, keyPrefix) {
+			delete(obj.Annotations, key)
+		}
+	}
+
+	var ownerRefs []metav1.OwnerReference
+	for _, ownerRef := range obj.OwnerReferences {
+		if ownerRef.Kind != "ConfigMap" || ownerRef.Name != anchorConfigMapName {
+			ownerRefs = append(ownerRefs, ownerRef)
+		}
+	}
+	obj.OwnerReferences = ownerRefs
+}
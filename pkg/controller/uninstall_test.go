@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	quotav1 "github.com/openshift/api/quota/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// Returns a Controller managing the namespace of alice with every object the provisioner creates
+func newUninstallController(t *testing.T) *Controller {
+	t.Helper()
+	t.Setenv("MANAGED_NAMESPACES_ENABLED", "true")
+
+	anchorRef := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: anchorConfigMapName, UID: "anchor"}
+	kubeClient := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "alice",
+			Labels:      map[string]string{ownerLabel: "alice", managedByLabel: componentName, "team": "ml"},
+			Annotations: map[string]string{provisioningDurationAnnotation: "5s"},
+			Finalizers:  []string{protectionFinalizer},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged"}},
+		desiredRoleBinding("alice", "alice", "edit"),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: anchorConfigMapName, Namespace: "alice", Labels: map[string]string{ownerLabel: "alice"}}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+			Name:            "model-api-key",
+			Namespace:       "alice",
+			Labels:          seededLabels("alice"),
+			Annotations:     map[string]string{secretSourceAnnotation: "sandbox/model-api"},
+			OwnerReferences: []metav1.OwnerReference{anchorRef},
+		}},
+		desiredLoadBalancerQuota("alice", "alice"),
+	)
+	controller := &Controller{
+		projectClient: projectfake.NewSimpleClientset(&projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: "alice", Labels: map[string]string{ownerLabel: "alice"}}}),
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+		quotaClient: quotafake.NewClientset(&quotav1.ClusterResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: clusterResourceQuotaName("alice"), Labels: map[string]string{ownerLabel: "alice"}},
+		}),
+		dynamicClient: newInventoryClient(),
+	}
+	if err := controller.updateManagedNamespace(context.Background(), "alice", "alice", nil, nil); err != nil {
+		t.Fatalf("Failed to create ManagedNamespace: %v", err)
+	}
+	return controller
+}
+
+// Applies every action of the uninstall plan
+func applyUninstall(t *testing.T, controller *Controller, policy string) []CleanupAction {
+	t.Helper()
+	ctx := context.Background()
+	actions, err := controller.PlanUninstall(ctx, policy)
+	if err != nil {
+		t.Fatalf("Expected uninstall to be planned, but got error: %v", err)
+	}
+	for _, action := range actions {
+		if err := action.Apply(ctx); err != nil {
+			t.Errorf("Expected %s %s to be cleaned up, but got error: %v", action.Kind, action.Name, err)
+		}
+	}
+	return actions
+}
+
+func TestController_PlanUninstallKeepNamespaces(t *testing.T) {
+	ctx := context.Background()
+	controller := newUninstallController(t)
+	actions := applyUninstall(t, controller, UninstallKeepNamespaces)
+	if len(actions) != 7 {
+		t.Errorf("Expected 7 changes, but got %+v", actions)
+	}
+
+	namespace, err := controller.coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace alice to be kept, but got error: %v", err)
+	}
+	if len(namespace.Labels) != 1 || namespace.Labels["team"] != "ml" {
+		t.Errorf("Expected only the owner and managed-by labels to be removed, but got %v", namespace.Labels)
+	}
+	if len(namespace.Annotations) != 0 || len(namespace.Finalizers) != 0 {
+		t.Errorf("Expected annotations and finalizer to be removed, but got %v and %v", namespace.Annotations, namespace.Finalizers)
+	}
+
+	secret, err := controller.coreClient.Secrets("alice").Get(ctx, "model-api-key", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected Secret model-api-key to be kept, but got error: %v", err)
+	}
+	if len(secret.Labels) != 0 || len(secret.Annotations) != 0 || len(secret.OwnerReferences) != 0 {
+		t.Errorf("Expected Secret model-api-key to no longer be owned, but got %+v", secret.ObjectMeta)
+	}
+
+	for _, check := range []struct {
+		kind string
+		err  error
+	}{
+		{kind: "RoleBinding", err: getErr(controller.rbacClient.RoleBindings("alice").Get(ctx, roleBindingName("alice"), metav1.GetOptions{}))},
+		{kind: "ConfigMap", err: getErr(controller.coreClient.ConfigMaps("alice").Get(ctx, anchorConfigMapName, metav1.GetOptions{}))},
+		{kind: "ResourceQuota", err: getErr(controller.coreClient.ResourceQuotas("alice").Get(ctx, loadBalancerQuotaName, metav1.GetOptions{}))},
+		{kind: "ClusterResourceQuota", err: getErr(controller.quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, clusterResourceQuotaName("alice"), metav1.GetOptions{}))},
+		{kind: "ManagedNamespace", err: getErr(controller.dynamicClient.Resource(v1alpha1.ManagedNamespacesResource).Get(ctx, "alice", metav1.GetOptions{}))},
+	} {
+		if !errors.IsNotFound(check.err) {
+			t.Errorf("Expected %s to be deleted, but got error: %v", check.kind, check.err)
+		}
+	}
+
+	// Nothing is left to clean up
+	if actions, err := controller.PlanUninstall(ctx, UninstallKeepNamespaces); err != nil || len(actions) != 0 {
+		t.Errorf("Expected nothing left to clean up, but got %+v with error: %v", actions, err)
+	}
+}
+
+func TestController_PlanUninstallDeleteNamespaces(t *testing.T) {
+	ctx := context.Background()
+	controller := newUninstallController(t)
+	applyUninstall(t, controller, UninstallDeleteNamespaces)
+
+	if _, err := controller.projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected project alice to be deleted, but got error: %v", err)
+	}
+	namespace, err := controller.coreClient.Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err == nil && len(namespace.Finalizers) != 0 {
+		t.Errorf("Expected the finalizer to be released so the deletion completes, but got %v", namespace.Finalizers)
+	}
+	if _, err := controller.quotaClient.QuotaV1().ClusterResourceQuotas().Get(ctx, clusterResourceQuotaName("alice"), metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected ClusterResourceQuota to be deleted, but got error: %v", err)
+	}
+
+	if _, err := controller.PlanUninstall(ctx, "archive"); err == nil {
+		t.Errorf("Expected an invalid policy to receive an error")
+	}
+}
+
+// Returns the error of a Get call
+func getErr[T any](_ T, err error) error {
+	return err
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"slices"
+
+	"k8s.io/klog/v2"
+)
+
+// Returns whether the target user may be provisioned a namespace: allowed by ALLOWED_USERS or
+// ALLOWED_USER_REGEX when either is set, and not excluded
+func userProvisionable(user string) bool {
+	return userAllowed(user) && !userExcluded(user)
+}
+
+// Returns whether the target user is allowed by ALLOWED_USERS or ALLOWED_USER_REGEX, every user being
+// allowed when neither is set
+func userAllowed(user string) bool {
+	users := GetAllowedUsers()
+	re, err := GetAllowedUserRegex()
+	if err != nil {
+		// only possible when starting degraded, when no user is allowed by it
+		klog.Errorf("Error reading allowed users: %v", err)
+		return slices.Contains(users, user)
+	}
+	if len(users) == 0 && re == nil {
+		return true
+	}
+	return slices.Contains(users, user) || (re != nil && re.MatchString(user))
+}
+
+// Returns whether the target user is excluded from provisioning by EXCLUDED_USERS or
+// EXCLUDED_USER_REGEX
+func userExcluded(user string) bool {
+	if slices.Contains(GetExcludedUsers(), user) {
+		return true
+	}
+	re, err := GetExcludedUserRegex()
+	if err != nil {
+		// only possible when starting degraded, when no user is excluded by it
+		klog.Errorf("Error reading excluded users: %v", err)
+		return false
+	}
+	return re != nil && re.MatchString(user)
+}
+
+// Returns the users who may be provisioned a namespace, in the same order
+func provisionableUsers(users []string) []string {
+	var provisionable []string
+	for _, user := range users {
+		if userProvisionable(user) {
+			provisionable = append(provisionable, user)
+		}
+	}
+	return provisionable
+}
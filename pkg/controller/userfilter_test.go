@@ -0,0 +1,185 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestUserExcluded(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		user     string
+		expected bool
+	}{
+		{
+			name:     "nothing excluded by default",
+			user:     "alice",
+			expected: false,
+		},
+		{
+			name:     "excluded user",
+			env:      map[string]string{"EXCLUDED_USERS": "admin, ci-bot"},
+			user:     "ci-bot",
+			expected: true,
+		},
+		{
+			name:     "user not in the excluded users",
+			env:      map[string]string{"EXCLUDED_USERS": "admin,ci-bot"},
+			user:     "alice",
+			expected: false,
+		},
+		{
+			name:     "user matching the regex",
+			env:      map[string]string{"EXCLUDED_USER_REGEX": "system:serviceaccount:.*|.*-bot"},
+			user:     "release-bot",
+			expected: true,
+		},
+		{
+			name:     "regex matches the whole user name",
+			env:      map[string]string{"EXCLUDED_USER_REGEX": "bot"},
+			user:     "abbott",
+			expected: false,
+		},
+		{
+			name:     "invalid regex excludes no one",
+			env:      map[string]string{"EXCLUDED_USER_REGEX": "bot-("},
+			user:     "bot-(",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := userExcluded(tt.user); got != tt.expected {
+				t.Errorf("Expected userExcluded(%q) to be %v, but got %v", tt.user, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUserProvisionable(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		user     string
+		expected bool
+	}{
+		{
+			name:     "every user allowed by default",
+			user:     "alice",
+			expected: true,
+		},
+		{
+			name:     "allowed user",
+			env:      map[string]string{"ALLOWED_USERS": "alice,bob"},
+			user:     "bob",
+			expected: true,
+		},
+		{
+			name:     "user not in the allowed users",
+			env:      map[string]string{"ALLOWED_USERS": "alice,bob"},
+			user:     "carol",
+			expected: false,
+		},
+		{
+			name:     "user matching the allowed regex",
+			env:      map[string]string{"ALLOWED_USERS": "alice", "ALLOWED_USER_REGEX": "pilot-.*"},
+			user:     "pilot-carol",
+			expected: true,
+		},
+		{
+			name:     "user matching neither the allowed users nor the regex",
+			env:      map[string]string{"ALLOWED_USERS": "alice", "ALLOWED_USER_REGEX": "pilot-.*"},
+			user:     "carol",
+			expected: false,
+		},
+		{
+			name:     "exclusion wins over the allowlist",
+			env:      map[string]string{"ALLOWED_USER_REGEX": "pilot-.*", "EXCLUDED_USERS": "pilot-bot"},
+			user:     "pilot-bot",
+			expected: false,
+		},
+		{
+			name:     "invalid allowed regex allows the listed users alone",
+			env:      map[string]string{"ALLOWED_USERS": "alice", "ALLOWED_USER_REGEX": "[pilot"},
+			user:     "bob",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			if got := userProvisionable(tt.user); got != tt.expected {
+				t.Errorf("Expected userProvisionable(%q) to be %v, but got %v", tt.user, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestController_admitUserExcluded(t *testing.T) {
+	t.Setenv("EXCLUDED_USER_REGEX", ".*-bot")
+
+	ctx := context.Background()
+	projectClient := projectfake.NewSimpleClientset()
+	kubeClient := fake.NewClientset()
+	controller := &Controller{
+		projectClient: projectClient,
+		rbacClient:    kubeClient.RbacV1(),
+		coreClient:    kubeClient.CoreV1(),
+	}
+
+	controller.markPending("release-bot", time.Now())
+	if err := controller.admitUser(ctx, "release-bot"); err != nil {
+		t.Fatalf("Expected excluded user to be skipped, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "release-bot", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected no project for excluded user release-bot, but got error: %v", err)
+	}
+	if _, pending := controller.pendingSince["release-bot"]; pending {
+		t.Errorf("Expected excluded user release-bot not to count towards the provisioning SLO")
+	}
+
+	if err := controller.admitUser(ctx, "alice"); err != nil {
+		t.Fatalf("Expected user alice to be provisioned, but got error: %v", err)
+	}
+	if _, err := projectClient.ProjectV1().Projects().Get(ctx, "alice", metav1.GetOptions{}); err != nil {
+		t.Errorf("Expected project alice to be created, but got error: %v", err)
+	}
+}
+
+func TestController_PlanUsersFiltered(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+	t.Setenv("EXCLUDED_USERS", "admin,carol")
+	t.Setenv("ALLOWED_USERS", "admin,alice,carol,dave")
+
+	controller := &Controller{
+		userClient:    userfake.NewSimpleClientset(newGroup("test-group", "admin", "carol", "dave", "alice", "erin")),
+		projectClient: projectfake.NewSimpleClientset(newOwnedProject("alice"), newOwnedProject("admin")),
+	}
+
+	plan, err := controller.PlanUsers(context.Background())
+	if err != nil {
+		t.Fatalf("Expected users to be planned, but got error: %v", err)
+	}
+
+	// the namespace the admin got before being excluded is kept, and erin is not part of the pilot
+	expected := UserPlan{Add: []string{"dave"}, Keep: []string{"admin", "alice"}}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Expected plan %+v, but got %+v", expected, plan)
+	}
+}
@@ -0,0 +1,755 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/validation/path"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ConfigError locates an invalid configuration value
+type ConfigError struct {
+	// Variable is the environment variable holding the invalid value
+	Variable string
+	// Entry is the invalid entry of a list variable, if any
+	Entry string
+	Err   error
+}
+
+// Error returns the location and cause of the invalid value
+func (e *ConfigError) Error() string {
+	if e.Entry != "" {
+		return fmt.Sprintf("%s: entry %q: %v", e.Variable, e.Entry, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Variable, e.Err)
+}
+
+// Unwrap returns the cause of the invalid value
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateConfig checks the configuration of every enabled feature, so invalid values are reported
+// at startup instead of when the first user is provisioned. It returns every ConfigError found
+// joined into a single error.
+func ValidateConfig() error {
+	var errs []error
+	invalid := func(variable, entry string, err error) {
+		errs = append(errs, &ConfigError{Variable: variable, Entry: entry, Err: err})
+	}
+
+	errs = append(errs, validateAppliedSettings(lookupEnv, configLimitRange())...)
+
+	for _, group := range GetSubGroupNames() {
+		for _, msg := range path.IsValidPathSegmentName(group) {
+			invalid("SUB_GROUP_NAMES", group, errors.New(msg))
+		}
+	}
+	if group := GetOpsGroupName(); group != "" {
+		for _, msg := range path.IsValidPathSegmentName(group) {
+			invalid("OPS_GROUP_NAME", "", errors.New(msg))
+		}
+		for _, msg := range path.IsValidPathSegmentName(GetOpsClusterRole()) {
+			invalid("OPS_CLUSTER_ROLE", "", errors.New(msg))
+		}
+	}
+	bindings, err := GetAdditionalRoleBindings()
+	if err != nil {
+		invalid("ADDITIONAL_ROLE_BINDINGS", "", err)
+	}
+	for _, binding := range bindings {
+		for _, msg := range path.IsValidPathSegmentName(binding.ClusterRole) {
+			invalid("ADDITIONAL_ROLE_BINDINGS", binding.Suffix, errors.New(msg))
+		}
+	}
+	for variable, source := range map[string]string{
+		"PROJECT_DISPLAY_NAME_TEMPLATE": GetProjectDisplayNameTemplate(),
+		"PROJECT_DESCRIPTION_TEMPLATE":  GetProjectDescriptionTemplate(),
+	} {
+		if _, err := renderProjectMetadata(source, projectMetadataData{User: "user", Group: "group"}); err != nil {
+			invalid(variable, "", fmt.Errorf("template %q is invalid: %w", source, err))
+		}
+	}
+	if role := GetPeerClusterRole(); role != "" {
+		for _, msg := range path.IsValidPathSegmentName(role) {
+			invalid("PEER_CLUSTER_ROLE", "", errors.New(msg))
+		}
+	}
+	if name := GetAutomationServiceAccount(); name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			invalid("AUTOMATION_SERVICE_ACCOUNT", "", errors.New(msg))
+		}
+		for _, msg := range path.IsValidPathSegmentName(GetAutomationClusterRole()) {
+			invalid("AUTOMATION_CLUSTER_ROLE", "", errors.New(msg))
+		}
+		if _, _, err := GetAutomationPullSecret(); err != nil {
+			invalid("AUTOMATION_PULL_SECRET", "", err)
+		}
+	}
+	if len(GetSeedSecrets()) > 0 || len(GetSeedConfigMaps()) > 0 {
+		if namespace := GetSeedNamespace(); namespace == "" {
+			invalid("SEED_NAMESPACE", "", errors.New("required with SEED_SECRETS or SEED_CONFIGMAPS"))
+		} else {
+			for _, msg := range validation.IsDNS1123Label(namespace) {
+				invalid("SEED_NAMESPACE", "", errors.New(msg))
+			}
+		}
+		for _, name := range GetSeedSecrets() {
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				invalid("SEED_SECRETS", name, errors.New(msg))
+			}
+		}
+		for _, name := range GetSeedConfigMaps() {
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				invalid("SEED_CONFIGMAPS", name, errors.New(msg))
+			}
+		}
+	}
+	if _, name, err := GetTemplateBundleConfigMap(); err != nil {
+		invalid("TEMPLATE_BUNDLE_CONFIGMAP", "", err)
+	} else if name != "" && GetTemplateBundleDir() != "" {
+		invalid("TEMPLATE_BUNDLE_CONFIGMAP", "", errors.New("cannot be combined with TEMPLATE_BUNDLE_DIR"))
+	}
+	if dir := GetTemplateBundleDir(); dir != "" {
+		sources, err := readTemplateBundleDir(dir)
+		if err == nil {
+			_, err = renderTemplateBundle(sources, templateBundleData{User: "user", Namespace: "user"})
+		}
+		if err != nil {
+			invalid("TEMPLATE_BUNDLE_DIR", "", err)
+		}
+	}
+	if _, err := parseProfiles(getListEnv("PROFILE_QUOTAS"), nil); err != nil {
+		invalid("PROFILE_QUOTAS", "", err)
+	} else if _, err := parseProfiles(nil, getListEnv("PROFILE_CLUSTER_ROLES")); err != nil {
+		invalid("PROFILE_CLUSTER_ROLES", "", err)
+	} else if profiles, err := GetProfiles(); err == nil {
+		scoped := len(GetQuotaPriorityClasses()) > 0
+		for name, profile := range profiles {
+			for _, msg := range validation.IsQualifiedName(name) {
+				invalid("PROFILE_QUOTAS", name, fmt.Errorf("invalid profile name: %s", msg))
+			}
+			if len(profile.QuotaHard) > 0 && !GetResourceQuotaEnabled() {
+				invalid("PROFILE_QUOTAS", name, errors.New("requires RESOURCE_QUOTA_ENABLED"))
+			}
+			for resource := range profile.QuotaHard {
+				for _, msg := range validation.IsQualifiedName(string(resource)) {
+					invalid("PROFILE_QUOTAS", name+":"+string(resource), fmt.Errorf("invalid resource name: %s", msg))
+				}
+				if scoped && !isPodResource(resource) {
+					invalid("PROFILE_QUOTAS", name+":"+string(resource), errors.New("not a pod resource, which is required when QUOTA_PRIORITY_CLASSES is set"))
+				}
+			}
+			if profile.ClusterRole != "" {
+				for _, msg := range path.IsValidPathSegmentName(profile.ClusterRole) {
+					invalid("PROFILE_CLUSTER_ROLES", name, errors.New(msg))
+				}
+			}
+		}
+		if profile := GetProfileDefault(); profile != "" {
+			if _, ok := profiles[profile]; !ok {
+				invalid("PROFILE_DEFAULT", profile, errors.New("not a configured profile"))
+			}
+		}
+	}
+	if role := GetAggregatedClusterRole(); role != "" {
+		if builtinClusterRoles[role] {
+			invalid("AGGREGATED_CLUSTER_ROLE", "", fmt.Errorf("%s is a built-in ClusterRole", role))
+		}
+		if _, err := GetAggregatedClusterRoleSelectors(); err != nil {
+			invalid("AGGREGATED_CLUSTER_ROLE_SELECTORS", "", err)
+		}
+	}
+
+	if GetLeaderElectionEnabled() {
+		errs = append(errs, validateLeaderElection()...)
+	}
+	if GetOnboardingEnabled() {
+		errs = append(errs, validateOnboarding()...)
+	}
+	errs = append(errs, validateMembershipSources()...)
+
+	if value := getEnv("STEP_RATE_LIMIT"); value != "" {
+		if limit, err := strconv.ParseFloat(value, 64); err != nil || limit < 0 {
+			invalid("STEP_RATE_LIMIT", "", fmt.Errorf("invalid rate %q, expected a non-negative number of steps per second", value))
+		}
+	}
+
+	if _, err := GetExistingProjectPolicy(); err != nil {
+		invalid("EXISTING_PROJECT_POLICY", "", err)
+	}
+	if _, _, err := GetNamespaceMappingConfigMap(); err != nil {
+		invalid("NAMESPACE_MAPPING_CONFIGMAP", "", err)
+	}
+
+	if value := getEnv("IDLE_SHUTDOWN_AFTER"); value != "" {
+		if after, err := time.ParseDuration(value); err != nil || after < 0 {
+			invalid("IDLE_SHUTDOWN_AFTER", "", fmt.Errorf("invalid duration %q, expected a non-negative duration such as 30m", value))
+		}
+	}
+	if _, _, err := GetIdleShutdownDeployment(); err != nil {
+		invalid("IDLE_SHUTDOWN_DEPLOYMENT", "", err)
+	}
+
+	if dir := GetDeprovisionReportsDir(); dir != "" {
+		if info, err := os.Stat(dir); err != nil {
+			invalid("DEPROVISION_REPORTS_DIR", "", err)
+		} else if !info.IsDir() {
+			invalid("DEPROVISION_REPORTS_DIR", "", fmt.Errorf("%s is not a directory", dir))
+		}
+		if _, err := LoadDeprovisionReportKey(); err != nil {
+			invalid("DEPROVISION_REPORT_KEY_FILE", "", err)
+		}
+		if reportsURL := GetDeprovisionReportsURL(); reportsURL != "" {
+			if err := validateWebhookURL(reportsURL); err != nil {
+				invalid("DEPROVISION_REPORTS_URL", "", err)
+			}
+		}
+	}
+
+	if address := GetPprofBindAddress(); address != "0" {
+		if err := ValidateLoopbackAddress(address); err != nil {
+			invalid("PPROF_BIND_ADDRESS", "", err)
+		}
+	}
+	if _, _, err := GetNotificationEmailConfigMap(); err != nil {
+		invalid("NOTIFICATION_EMAIL_CONFIGMAP", "", err)
+	}
+	if endpoint := GetTracingEndpoint(); endpoint != "" {
+		if err := validateWebhookURL(endpoint); err != nil {
+			variable := "OTEL_EXPORTER_OTLP_ENDPOINT"
+			if getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+				variable = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+			}
+			invalid(variable, "", err)
+		}
+	}
+	if webhook := GetLifecycleWebhookURL(); webhook != "" {
+		if err := validateWebhookURL(webhook); err != nil {
+			invalid("LIFECYCLE_WEBHOOK_URL", "", err)
+		} else if !strings.HasPrefix(webhook, "https://") {
+			invalid("LIFECYCLE_WEBHOOK_URL", "", errors.New("must be an https URL"))
+		}
+		if _, err := LoadLifecycleWebhookKey(); err != nil {
+			invalid("LIFECYCLE_WEBHOOK_KEY_FILE", "", err)
+		}
+	}
+
+	if GetGroupFinalizerEnabled() && mergedMembershipEnabled() {
+		invalid("GROUP_FINALIZER_ENABLED", "", errors.New("requires the target groups to be the only membership source"))
+	}
+
+	if GetApprovalRequired() && !GetManagedNamespacesEnabled() {
+		invalid("APPROVAL_REQUIRED", "", errors.New("requires MANAGED_NAMESPACES_ENABLED=true to record pending approvals"))
+	}
+
+	if window := GetDeletionMaintenanceWindow(); window != "" {
+		if _, err := inMaintenanceWindow(window, time.Now()); err != nil {
+			invalid("DELETION_MAINTENANCE_WINDOW", "", err)
+		}
+	}
+
+	if GetConsoleNotificationsEnabled() {
+		if !GetNamespaceFinalizerEnabled() || GetDeletionMaintenanceWindow() == "" {
+			invalid("CONSOLE_NOTIFICATIONS_ENABLED", "", errors.New("requires NAMESPACE_FINALIZER_ENABLED=true and a DELETION_MAINTENANCE_WINDOW to announce deletions"))
+		}
+		if link := GetConsoleNotificationLink(); link != "" {
+			if err := validateWebhookURL(link); err != nil {
+				invalid("CONSOLE_NOTIFICATION_LINK", "", err)
+			}
+		}
+	}
+
+	if GetClusterResourceQuotaEnabled() {
+		errs = append(errs, validateClusterResourceQuota()...)
+	}
+
+	if GetNetworkPoliciesEnabled() {
+		if _, err := loadNetworkPolicyTemplates(GetNetworkPoliciesFile()); err != nil {
+			invalid("NETWORK_POLICIES_FILE", "", err)
+		}
+	}
+
+	if GetBandwidthLimitsEnabled() {
+		errs = append(errs, validateBandwidthTiers()...)
+	}
+
+	if GetObjectCountQuotaEnabled() {
+		hard, err := GetObjectCountQuotaHard()
+		if err != nil {
+			invalid("OBJECT_COUNT_QUOTA_HARD", "", err)
+		} else if len(hard) == 0 {
+			invalid("OBJECT_COUNT_QUOTA_HARD", "", errors.New("at least one limit is required when object count quotas are enabled"))
+		}
+		for name := range hard {
+			if !isObjectCountResource(name) {
+				invalid("OBJECT_COUNT_QUOTA_HARD", string(name), errors.New("not an object count, expected a core object such as pods or count/<resource>.<group>"))
+			}
+		}
+	}
+
+	if GetAWSSecretsEnabled() {
+		mappings, err := GetAWSSecretMappings()
+		if err != nil {
+			invalid("AWS_SECRETS", "", err)
+		}
+		for _, mapping := range mappings {
+			for _, msg := range validation.IsDNS1123Subdomain(mapping.SecretName) {
+				invalid("AWS_SECRETS", mapping.SourceID+"="+mapping.SecretName, fmt.Errorf("invalid Secret name: %s", msg))
+			}
+		}
+		bundles, err := GetAWSSecretBundles()
+		if err != nil {
+			invalid("AWS_SECRET_BUNDLES", "", err)
+		}
+		names := make([]string, 0, len(bundles))
+		for bundle := range bundles {
+			names = append(names, bundle)
+		}
+		sort.Strings(names)
+		for _, bundle := range names {
+			mappings := bundles[bundle]
+			for _, msg := range validation.IsValidLabelValue(bundle) {
+				invalid("AWS_SECRET_BUNDLES", bundle, fmt.Errorf("invalid bundle name: %s", msg))
+			}
+			for _, mapping := range mappings {
+				for _, msg := range validation.IsDNS1123Subdomain(mapping.SecretName) {
+					invalid("AWS_SECRET_BUNDLES", bundle+":"+mapping.SourceID+"="+mapping.SecretName, fmt.Errorf("invalid Secret name: %s", msg))
+				}
+			}
+		}
+	}
+
+	if GetCostTrackingEnabled() {
+		if strings.ContainsAny(GetCostTagKey(), ",=") {
+			invalid("COST_TAG_KEY", "", errors.New("must not contain ',' or '='"))
+		}
+		for _, variable := range []string{"COST_PRICE_CPU_CORE_HOUR", "COST_PRICE_MEMORY_GIB_HOUR", "COST_PRICE_STORAGE_GIB_MONTH", "COST_PRICE_LOAD_BALANCER_HOUR"} {
+			if value := getEnv(variable); value != "" {
+				if price, err := strconv.ParseFloat(value, 64); err != nil || price < 0 {
+					invalid(variable, "", fmt.Errorf("invalid price %q, expected a non-negative number of dollars", value))
+				}
+			}
+		}
+	}
+
+	if GetGroupAnomalyDetectionEnabled() {
+		if value := getEnv("GROUP_ANOMALY_THRESHOLD"); value != "" {
+			if percent, err := strconv.ParseFloat(value, 64); err != nil || percent <= 0 || percent >= 100 {
+				invalid("GROUP_ANOMALY_THRESHOLD", "", fmt.Errorf("invalid threshold %q, expected a percentage between 0 and 100", value))
+			}
+		}
+	}
+
+	if GetAuditTaggingEnabled() {
+		for _, key := range GetAuditTenantLabels() {
+			for _, msg := range validation.IsQualifiedName(key) {
+				invalid("AUDIT_TENANT_LABELS", key, errors.New(msg))
+			}
+			if key == ownerLabel {
+				invalid("AUDIT_TENANT_LABELS", key, errors.New("reserved for the owner label"))
+			}
+		}
+	}
+
+	providers, err := GetNotificationProviders()
+	if err != nil {
+		invalid("NOTIFICATION_PROVIDERS", "", err)
+	}
+	for _, provider := range providers {
+		switch provider.Name {
+		case NotificationProviderWebhook:
+			if err := validateWebhookURL(GetNotificationWebhookURL()); err != nil {
+				invalid("NOTIFICATION_WEBHOOK_URL", "", err)
+			}
+		case NotificationProviderSlack:
+			if err := validateWebhookURL(GetNotificationSlackWebhookURL()); err != nil {
+				invalid("NOTIFICATION_SLACK_WEBHOOK_URL", "", err)
+			}
+		case NotificationProviderEmail:
+			if _, _, err := net.SplitHostPort(GetNotificationSMTPAddress()); err != nil {
+				invalid("NOTIFICATION_SMTP_ADDRESS", "", err)
+			}
+			if GetNotificationEmailFrom() == "" {
+				invalid("NOTIFICATION_EMAIL_FROM", "", errors.New("required by the email notification provider"))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validates the settings a ProvisionerConfig or configuration file may change without a restart, as
+// set by the lookup and the LimitRange template of the ProvisionerConfig. Candidate configurations
+// are checked with it before they are applied.
+func validateAppliedSettings(lookup configLookup, limitRange *corev1.LimitRangeSpec) []error {
+	var errs []error
+	invalid := func(variable, entry string, err error) {
+		errs = append(errs, &ConfigError{Variable: variable, Entry: entry, Err: err})
+	}
+
+	targetGroups := make(map[string]bool)
+	if names := lookup.list("TARGET_GROUP_NAMES"); len(names) > 0 {
+		for _, name := range names {
+			for _, msg := range path.IsValidPathSegmentName(name) {
+				invalid("TARGET_GROUP_NAMES", name, errors.New(msg))
+			}
+			if targetGroups[name] {
+				invalid("TARGET_GROUP_NAMES", name, errors.New("duplicate group"))
+			}
+			targetGroups[name] = true
+		}
+	} else {
+		name := targetGroupNames(lookup)[0]
+		for _, msg := range path.IsValidPathSegmentName(name) {
+			invalid("TARGET_GROUP_NAME", "", errors.New(msg))
+		}
+		targetGroups[name] = true
+	}
+
+	for _, variable := range []string{"ALLOWED_USER_REGEX", "EXCLUDED_USER_REGEX"} {
+		if _, err := userPattern(lookup.get(variable)); err != nil {
+			invalid(variable, "", err)
+		}
+	}
+
+	for _, variable := range []string{"PROJECT_PREFIX", "PROJECT_SUFFIX"} {
+		if value := strings.TrimSpace(lookup.get(variable)); !projectAffixPattern.MatchString(value) {
+			invalid(variable, "", fmt.Errorf("invalid %q, expected lowercase letters, digits and dashes", value))
+		}
+	}
+	// users are named like DNS labels in practice, so the template must name one for a plain user name
+	if err := validateProjectNameTemplate(projectNameTemplateSource(lookup)); err != nil {
+		invalid("PROJECT_NAME_TEMPLATE", "", err)
+	}
+
+	for _, msg := range path.IsValidPathSegmentName(userClusterRole(lookup)) {
+		invalid("USER_CLUSTER_ROLE", "", errors.New(msg))
+	}
+	if strings.TrimSpace(lookup.get("AGGREGATED_CLUSTER_ROLE")) != "" && strings.TrimSpace(lookup.get("USER_CLUSTER_ROLE")) != "" {
+		invalid("AGGREGATED_CLUSTER_ROLE", "", errors.New("cannot be combined with USER_CLUSTER_ROLE"))
+	}
+	overrides, err := parseUserClusterRoleOverrides(lookup.list("USER_CLUSTER_ROLE_OVERRIDES"))
+	if err != nil {
+		invalid("USER_CLUSTER_ROLE_OVERRIDES", "", err)
+	}
+	for group, role := range overrides {
+		if !targetGroups[group] {
+			invalid("USER_CLUSTER_ROLE_OVERRIDES", group, errors.New("not a target group"))
+		}
+		for _, msg := range path.IsValidPathSegmentName(role) {
+			invalid("USER_CLUSTER_ROLE_OVERRIDES", group, errors.New(msg))
+		}
+	}
+	windows, err := parseAccessWindows(lookup.list("ACCESS_WINDOWS"))
+	if err != nil {
+		invalid("ACCESS_WINDOWS", "", err)
+	}
+	for group := range windows {
+		if !targetGroups[group] {
+			invalid("ACCESS_WINDOWS", group, errors.New("not a target group"))
+		}
+	}
+
+	if _, err := parseProjectDeletionPolicy(lookup.get("PROJECT_DELETION_POLICY")); err != nil {
+		invalid("PROJECT_DELETION_POLICY", "", err)
+	}
+	// a mistyped grace period, e.g. 7d, would otherwise delete projects right away
+	if value := lookup.get("DELETION_GRACE_PERIOD"); value != "" {
+		if period, err := time.ParseDuration(value); err != nil || period < 0 {
+			invalid("DELETION_GRACE_PERIOD", "", fmt.Errorf("invalid duration %q, expected a non-negative duration such as 168h", value))
+		}
+	}
+	// timers only fire while the controller runs, and nothing wakes it when they are due
+	if lookup.duration("IDLE_SHUTDOWN_AFTER", 0) > 0 {
+		if lookup.duration("DELETION_GRACE_PERIOD", 0) > 0 {
+			invalid("IDLE_SHUTDOWN_AFTER", "", errors.New("can't be combined with a DELETION_GRACE_PERIOD, whose deletions are swept while running"))
+		}
+		if len(windows) > 0 {
+			invalid("IDLE_SHUTDOWN_AFTER", "", errors.New("can't be combined with ACCESS_WINDOWS, which open and close while running"))
+		}
+	}
+
+	if lookup.boolean("RESOURCE_QUOTA_ENABLED", false) {
+		hard, err := parseResourceList(lookup.get("RESOURCE_QUOTA_HARD"))
+		if err != nil {
+			invalid("RESOURCE_QUOTA_HARD", "", err)
+		} else if len(hard) == 0 {
+			invalid("RESOURCE_QUOTA_HARD", "", errors.New("at least one limit is required when resource quotas are enabled"))
+		}
+		scoped := len(lookup.list("QUOTA_PRIORITY_CLASSES")) > 0
+		for name := range hard {
+			for _, msg := range validation.IsQualifiedName(string(name)) {
+				invalid("RESOURCE_QUOTA_HARD", string(name), fmt.Errorf("invalid resource name: %s", msg))
+			}
+			// the API server rejects quotas scoped to PriorityClasses that limit anything but pods
+			if scoped && !isPodResource(name) {
+				invalid("RESOURCE_QUOTA_HARD", string(name), errors.New("not a pod resource, which is required when QUOTA_PRIORITY_CLASSES is set"))
+			}
+		}
+	}
+
+	if lookup.boolean("LIMIT_RANGE_ENABLED", false) {
+		if _, err := resolveLimitRangeTemplate(limitRange, lookup.get("LIMIT_RANGE_FILE")); err != nil {
+			invalid("LIMIT_RANGE_FILE", "", err)
+		}
+	}
+
+	return errs
+}
+
+// Validates the limits and scope of the per-user ClusterResourceQuota
+func validateClusterResourceQuota() []error {
+	var errs []error
+
+	hard, err := GetClusterResourceQuotaHard()
+	if err != nil {
+		errs = append(errs, &ConfigError{Variable: "CLUSTER_RESOURCE_QUOTA_HARD", Err: err})
+	}
+	scoped := len(GetQuotaPriorityClasses()) > 0
+	for name := range hard {
+		for _, msg := range validation.IsQualifiedName(string(name)) {
+			errs = append(errs, &ConfigError{Variable: "CLUSTER_RESOURCE_QUOTA_HARD", Entry: string(name), Err: fmt.Errorf("invalid resource name: %s", msg)})
+		}
+		if scoped && !isPodResource(name) {
+			errs = append(errs, &ConfigError{Variable: "CLUSTER_RESOURCE_QUOTA_HARD", Entry: string(name), Err: errors.New("not a pod resource, which is required when QUOTA_PRIORITY_CLASSES is set")})
+		}
+	}
+
+	if _, err := GetQuotaPriorityClassOperator(); err != nil {
+		errs = append(errs, &ConfigError{Variable: "QUOTA_PRIORITY_CLASS_OPERATOR", Err: err})
+	}
+	for _, class := range GetQuotaPriorityClasses() {
+		for _, msg := range validation.IsDNS1123Subdomain(class) {
+			errs = append(errs, &ConfigError{Variable: "QUOTA_PRIORITY_CLASSES", Entry: class, Err: errors.New(msg)})
+		}
+	}
+	return errs
+}
+
+// Validates the bandwidth tiers and the tier selected for every user
+func validateBandwidthTiers() []error {
+	var errs []error
+
+	tiers, err := GetBandwidthTiers()
+	if err != nil {
+		errs = append(errs, &ConfigError{Variable: "BANDWIDTH_TIERS", Err: err})
+	} else if len(tiers) == 0 {
+		errs = append(errs, &ConfigError{Variable: "BANDWIDTH_TIERS", Err: errors.New("at least one tier is required when bandwidth limits are enabled")})
+	}
+	if tier := GetBandwidthDefaultTier(); tier != "" && err == nil {
+		if _, ok := tiers[tier]; !ok {
+			errs = append(errs, &ConfigError{Variable: "BANDWIDTH_DEFAULT_TIER", Entry: tier, Err: errors.New("not a configured tier")})
+		}
+	}
+
+	overrides, overrideErr := GetBandwidthTierOverrides()
+	if overrideErr != nil {
+		errs = append(errs, &ConfigError{Variable: "BANDWIDTH_TIER_OVERRIDES", Err: overrideErr})
+	}
+	targetGroups := make(map[string]bool)
+	for _, name := range GetTargetGroupNames() {
+		targetGroups[name] = true
+	}
+	for group, tier := range overrides {
+		if !targetGroups[group] {
+			errs = append(errs, &ConfigError{Variable: "BANDWIDTH_TIER_OVERRIDES", Entry: group, Err: errors.New("not a target group")})
+		}
+		if _, ok := tiers[tier]; !ok && err == nil {
+			errs = append(errs, &ConfigError{Variable: "BANDWIDTH_TIER_OVERRIDES", Entry: group, Err: fmt.Errorf("tier %s is not configured", tier)})
+		}
+	}
+	return errs
+}
+
+// Validates the Lease and timing of the leader election, which client-go would otherwise only reject
+// when the manager starts
+func validateLeaderElection() []error {
+	var errs []error
+	for _, msg := range validation.IsDNS1123Subdomain(GetLeaderElectionID()) {
+		errs = append(errs, &ConfigError{Variable: "LEADER_ELECTION_ID", Err: errors.New(msg)})
+	}
+	if namespace := GetLeaderElectionNamespace(); namespace != "" {
+		for _, msg := range validation.IsDNS1123Label(namespace) {
+			errs = append(errs, &ConfigError{Variable: "LEADER_ELECTION_NAMESPACE", Err: errors.New(msg)})
+		}
+	}
+
+	leaseDuration := GetLeaderElectionLeaseDuration()
+	renewDeadline := GetLeaderElectionRenewDeadline()
+	retryPeriod := GetLeaderElectionRetryPeriod()
+	if renewDeadline >= leaseDuration {
+		errs = append(errs, &ConfigError{Variable: "LEADER_ELECTION_RENEW_DEADLINE", Err: fmt.Errorf("%s must be shorter than the lease duration %s", renewDeadline, leaseDuration)})
+	}
+	// client-go retries with up to 20% jitter, which must fit within the renew deadline
+	if float64(renewDeadline) <= 1.2*float64(retryPeriod) {
+		errs = append(errs, &ConfigError{Variable: "LEADER_ELECTION_RETRY_PERIOD", Err: fmt.Errorf("%s with jitter must be shorter than the renew deadline %s", retryPeriod, renewDeadline)})
+	}
+	return errs
+}
+
+// Validates the delivery and URLs of the onboarding artifacts
+func validateOnboarding() []error {
+	var errs []error
+	for _, delivery := range GetOnboardingDelivery() {
+		switch delivery {
+		case OnboardingDeliverySecret:
+		case OnboardingDeliveryNotification:
+			if providers, err := GetNotificationProviders(); err == nil && len(providers) == 0 {
+				errs = append(errs, &ConfigError{Variable: "ONBOARDING_DELIVERY", Entry: delivery, Err: errors.New("requires a notification provider")})
+			}
+		default:
+			errs = append(errs, &ConfigError{Variable: "ONBOARDING_DELIVERY", Entry: delivery, Err: fmt.Errorf("unknown delivery, expected %s or %s", OnboardingDeliverySecret, OnboardingDeliveryNotification)})
+		}
+	}
+	if err := validateWebhookURL(GetOnboardingAPIURL()); err != nil {
+		errs = append(errs, &ConfigError{Variable: "ONBOARDING_API_URL", Err: err})
+	}
+	if consoleURL := GetOnboardingConsoleURL(); consoleURL != "" {
+		if err := validateWebhookURL(consoleURL); err != nil {
+			errs = append(errs, &ConfigError{Variable: "ONBOARDING_CONSOLE_URL", Err: err})
+		}
+	}
+	return errs
+}
+
+// Validates the membership sources, their merge policy and the settings of each source
+func validateMembershipSources() []error {
+	var errs []error
+	invalid := func(variable, entry string, err error) {
+		errs = append(errs, &ConfigError{Variable: variable, Entry: entry, Err: err})
+	}
+
+	seen := make(map[string]bool)
+	for _, source := range GetMembershipSources() {
+		switch source {
+		case MembershipSourceGroup:
+		case MembershipSourceRoster:
+			if _, _, err := GetRosterConfigMap(); err != nil {
+				invalid("ROSTER_CONFIGMAP", "", err)
+			}
+		case MembershipSourceGitHub:
+			if _, _, err := GetGitHubTeam(); err != nil {
+				invalid("GITHUB_TEAM", "", err)
+			}
+			if GetGitHubToken() == "" {
+				invalid("GITHUB_TOKEN", "", errors.New("required by the github membership source"))
+			}
+			if err := validateWebhookURL(GetGitHubAPIURL()); err != nil {
+				invalid("GITHUB_API_URL", "", err)
+			}
+		default:
+			invalid("MEMBERSHIP_SOURCES", source, fmt.Errorf("unknown source, expected %s, %s or %s", MembershipSourceGroup, MembershipSourceRoster, MembershipSourceGitHub))
+		}
+		if seen[source] {
+			invalid("MEMBERSHIP_SOURCES", source, errors.New("duplicate source"))
+		}
+		seen[source] = true
+	}
+
+	switch policy := GetMembershipMergePolicy(); policy {
+	case MergePolicyUnion, MergePolicyIntersection:
+	default:
+		invalid("MEMBERSHIP_MERGE_POLICY", "", fmt.Errorf("unknown policy %q, expected %s or %s", policy, MergePolicyUnion, MergePolicyIntersection))
+	}
+	return errs
+}
+
+// Returns whether the quota resource is consumed by pods, the only resources a quota scoped to
+// PriorityClasses may limit
+func isPodResource(name corev1.ResourceName) bool {
+	switch name {
+	case corev1.ResourcePods, corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage, "count/pods":
+		return true
+	case corev1.ResourceRequestsStorage:
+		return false
+	}
+	return strings.HasPrefix(string(name), corev1.ResourceRequestsHugePagesPrefix) ||
+		strings.HasPrefix(string(name), corev1.ResourceHugePagesPrefix) ||
+		strings.HasPrefix(string(name), "requests.") || strings.HasPrefix(string(name), "limits.")
+}
+
+// Returns whether the quota resource limits a number of objects rather than compute or storage
+func isObjectCountResource(name corev1.ResourceName) bool {
+	if strings.HasPrefix(string(name), "count/") {
+		return true
+	}
+	switch name {
+	case corev1.ResourcePods, corev1.ResourceServices, corev1.ResourceReplicationControllers,
+		corev1.ResourceQuotas, corev1.ResourceSecrets, corev1.ResourceConfigMaps,
+		corev1.ResourcePersistentVolumeClaims, corev1.ResourceServicesNodePorts,
+		corev1.ResourceServicesLoadBalancers:
+		return true
+	}
+	return false
+}
+
+// Validates that the project name template includes the user name and names valid namespaces
+func validateProjectNameTemplate(source string) error {
+	tmpl, userLength, err := parseProjectNameTemplate(source)
+	if err != nil {
+		return fmt.Errorf("template %q is invalid: %w", source, err)
+	}
+	name, err := executeProjectNameTemplate(tmpl, "user")
+	if err != nil {
+		return fmt.Errorf("template %q is invalid: %w", source, err)
+	}
+	other, err := executeProjectNameTemplate(tmpl, "other")
+	if err != nil {
+		return fmt.Errorf("template %q is invalid: %w", source, err)
+	}
+	if name == other {
+		return fmt.Errorf("template %q must contain %s or {{ .User }}", source, projectNameUserPlaceholder)
+	}
+	if msgs := validation.IsDNS1123Label(name); len(msgs) > 0 {
+		return fmt.Errorf("template %q does not name a valid namespace: %s", source, strings.Join(msgs, ", "))
+	}
+	// user names which aren't DNS labels are replaced with at least a hash
+	if userLength < nameHashLength {
+		return fmt.Errorf("template %q leaves %d characters for the user name, at least %d are required", source, max(userLength, 0), nameHashLength)
+	}
+	return nil
+}
+
+// Validates that notifications can be posted to the webhook URL
+func validateWebhookURL(webhook string) error {
+	if webhook == "" {
+		return errors.New("missing URL")
+	}
+	parsed, err := url.Parse(webhook)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q, expected http or https", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return errors.New("missing host")
+	}
+	return nil
+}
+
+// ValidateLoopbackAddress validates that the listen address only accepts connections from within the
+// pod, for endpoints served without authorization such as profiles exposing the memory of the controller
+func ValidateLoopbackAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%s is not a loopback address, e.g. localhost:6060", address)
+	}
+	return nil
+}
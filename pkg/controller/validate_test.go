@@ -0,0 +1,303 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		shouldError bool
+		expected    []string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "valid enabled features",
+			env: map[string]string{
+				"CLUSTER_RESOURCE_QUOTA_ENABLED": "true",
+				"CLUSTER_RESOURCE_QUOTA_HARD":    "requests.cpu=4,pods=20",
+				"QUOTA_PRIORITY_CLASSES":         "high-priority",
+				"AWS_SECRETS_ENABLED":            "true",
+				"AWS_SECRETS":                    "sandbox/model-api=model-api-key",
+				"NOTIFICATION_WEBHOOK_URL":       "https://hooks.example.com/provisioner",
+				"DELETION_MAINTENANCE_WINDOW":    "22:00-04:00",
+				"RESOURCE_QUOTA_ENABLED":         "true",
+				"RESOURCE_QUOTA_HARD":            "requests.cpu=4,requests.memory=16Gi,pods=20",
+				"BANDWIDTH_LIMITS_ENABLED":       "true",
+				"BANDWIDTH_TIERS":                "standard=100M,large=1G/2G",
+				"BANDWIDTH_DEFAULT_TIER":         "standard",
+				"PROFILE_QUOTAS":                 "ml:requests.cpu=16,ml:requests.memory=64Gi",
+				"PROFILE_CLUSTER_ROLES":          "ml=admin",
+				"PROFILE_DEFAULT":                "ml",
+				"MEMBERSHIP_SOURCES":             "github,group,roster",
+				"MEMBERSHIP_MERGE_POLICY":        "intersection",
+				"ROSTER_CONFIGMAP":               "workshops/attendees",
+				"NAMESPACE_MAPPING_CONFIGMAP":    "provisioner/namespaces",
+				"GITHUB_TEAM":                    "redhat-ai-dev/sandbox-users",
+				"GITHUB_TOKEN":                   "token",
+				"AGGREGATED_CLUSTER_ROLE":        "sandbox-user",
+				"LEADER_ELECTION_ENABLED":        "true",
+				"LEADER_ELECTION_ID":             "sandbox-provisioner",
+				"ONBOARDING_ENABLED":             "true",
+				"ONBOARDING_DELIVERY":            "secret,notification",
+				"ONBOARDING_API_URL":             "https://api.my-rosa.example.com:443",
+				"ACCESS_WINDOWS":                 "spring-cohort=2026-03-01/2026-04-30,spring-cohort=2026-09-01/2026-10-31",
+				"TARGET_GROUP_NAMES":             "spring-cohort,staff",
+			},
+		},
+		{
+			name: "disabled features are not validated",
+			env: map[string]string{
+				"CLUSTER_RESOURCE_QUOTA_HARD": "pods",
+				"AWS_SECRETS":                 "invalid",
+			},
+		},
+		{
+			name: "idle shutdown with timers",
+			env: map[string]string{
+				"IDLE_SHUTDOWN_AFTER":   "30m",
+				"DELETION_GRACE_PERIOD": "24h",
+			},
+			shouldError: true,
+			expected:    []string{"IDLE_SHUTDOWN_AFTER: can't be combined with a DELETION_GRACE_PERIOD"},
+		},
+		{
+			name: "quota scoped to PriorityClasses limiting storage",
+			env: map[string]string{
+				"RESOURCE_QUOTA_ENABLED": "true",
+				"RESOURCE_QUOTA_HARD":    "requests.cpu=4,requests.storage=100Gi",
+				"QUOTA_PRIORITY_CLASSES": "high-priority",
+			},
+			shouldError: true,
+			expected:    []string{`RESOURCE_QUOTA_HARD: entry "requests.storage": not a pod resource`},
+		},
+		{
+			name: "project name template referencing an unknown field",
+			env: map[string]string{
+				"PROJECT_NAME_TEMPLATE": "{{ .Group }}-{{ .User }}",
+			},
+			shouldError: true,
+			expected:    []string{`PROJECT_NAME_TEMPLATE: template "{{ .Group }}-{{ .User }}" is invalid`},
+		},
+		{
+			name: "project name template without room for sanitized user names",
+			env: map[string]string{
+				"PROJECT_NAME_TEMPLATE": strings.Repeat("a", 56) + "-{user}",
+			},
+			shouldError: true,
+			expected:    []string{"leaves 6 characters for the user name, at least 8 are required"},
+		},
+		{
+			name: "prefix which isn't part of a namespace name",
+			env: map[string]string{
+				"PROJECT_PREFIX": "Sandbox_",
+			},
+			shouldError: true,
+			expected:    []string{`PROJECT_PREFIX: invalid "Sandbox_", expected lowercase letters, digits and dashes`},
+		},
+		{
+			name: "suffix without room for sanitized user names",
+			env: map[string]string{
+				"PROJECT_PREFIX": strings.Repeat("a", 30) + "-",
+				"PROJECT_SUFFIX": "-" + strings.Repeat("b", 25),
+			},
+			shouldError: true,
+			expected:    []string{"leaves 6 characters for the user name, at least 8 are required"},
+		},
+		{
+			name: "every invalid value is located",
+			env: map[string]string{
+				"EXISTING_PROJECT_POLICY":           "adopt",
+				"NAMESPACE_MAPPING_CONFIGMAP":       "namespaces",
+				"EXCLUDED_USER_REGEX":               "bot-(",
+				"OPS_GROUP_NAME":                    "platform/sre",
+				"ALLOWED_USER_REGEX":                "[pilot",
+				"PROJECT_DELETION_POLICY":           "Archive",
+				"DELETION_GRACE_PERIOD":             "7d",
+				"GROUP_FINALIZER_ENABLED":           "true",
+				"APPROVAL_REQUIRED":                 "true",
+				"CLUSTER_RESOURCE_QUOTA_ENABLED":    "true",
+				"CLUSTER_RESOURCE_QUOTA_HARD":       "pods=lots",
+				"QUOTA_PRIORITY_CLASS_OPERATOR":     "Exists",
+				"AWS_SECRETS_ENABLED":               "true",
+				"AWS_SECRETS":                       "sandbox/model-api=Model_API",
+				"NOTIFICATION_WEBHOOK_URL":          "hooks.example.com",
+				"NOTIFICATION_PROVIDERS":            "webhook,email:warning",
+				"NOTIFICATION_SMTP_ADDRESS":         "smtp.example.com",
+				"DELETION_MAINTENANCE_WINDOW":       "22:00",
+				"SUB_GROUP_NAMES":                   "team/a",
+				"TARGET_GROUP_NAMES":                "workshop,team/b,workshop",
+				"OBJECT_COUNT_QUOTA_ENABLED":        "true",
+				"OBJECT_COUNT_QUOTA_HARD":           "pods=50,requests.cpu=4",
+				"COST_TRACKING_ENABLED":             "true",
+				"COST_TAG_KEY":                      "owner=user",
+				"COST_PRICE_CPU_CORE_HOUR":          "-1",
+				"GROUP_ANOMALY_DETECTION_ENABLED":   "true",
+				"GROUP_ANOMALY_THRESHOLD":           "150",
+				"AUDIT_TAGGING_ENABLED":             "true",
+				"AUDIT_TENANT_LABELS":               "audit.example.com/tenant,tenant id",
+				"USER_CLUSTER_ROLE":                 "edit/all",
+				"USER_CLUSTER_ROLE_OVERRIDES":       "staff=admin",
+				"ADDITIONAL_ROLE_BINDINGS":          "monitoring=monitoring-rules-view,edit=admin",
+				"PROJECT_DESCRIPTION_TEMPLATE":      "Sandbox of {{ .Username }}",
+				"AUTOMATION_SERVICE_ACCOUNT":        "CI",
+				"AUTOMATION_PULL_SECRET":            "ci-registry",
+				"SEED_CONFIGMAPS":                   "ca-bundle",
+				"TEMPLATE_BUNDLE_CONFIGMAP":         "bundle",
+				"PROFILE_CLUSTER_ROLES":             "ml=admin",
+				"PROFILE_DEFAULT":                   "large",
+				"LIFECYCLE_WEBHOOK_URL":             "http://cmdb.example.com/events",
+				"NOTIFICATION_EMAIL_CONFIGMAP":      "emails",
+				"OTEL_EXPORTER_OTLP_ENDPOINT":       "otel-collector:4318",
+				"PPROF_BIND_ADDRESS":                ":6060",
+				"RESOURCE_QUOTA_ENABLED":            "true",
+				"LIMIT_RANGE_ENABLED":               "true",
+				"CONSOLE_NOTIFICATIONS_ENABLED":     "true",
+				"CONSOLE_NOTIFICATION_LINK":         "docs.example.com",
+				"NETWORK_POLICIES_ENABLED":          "true",
+				"NETWORK_POLICIES_FILE":             "/nonexistent/networkpolicies.yaml",
+				"BANDWIDTH_LIMITS_ENABLED":          "true",
+				"BANDWIDTH_TIERS":                   "standard=100M/20M",
+				"BANDWIDTH_DEFAULT_TIER":            "large",
+				"BANDWIDTH_TIER_OVERRIDES":          "staff=standard",
+				"MEMBERSHIP_SOURCES":                "group,roster,github,ldap,roster",
+				"MEMBERSHIP_MERGE_POLICY":           "priority",
+				"ROSTER_CONFIGMAP":                  "attendees",
+				"GITHUB_TEAM":                       "redhat-ai-dev",
+				"AGGREGATED_CLUSTER_ROLE":           "edit",
+				"AGGREGATED_CLUSTER_ROLE_SELECTORS": "tier in (gpu",
+				"LEADER_ELECTION_ENABLED":           "true",
+				"LEADER_ELECTION_ID":                "Provisioner Lease",
+				"LEADER_ELECTION_LEASE_DURATION":    "10s",
+				"LEADER_ELECTION_RENEW_DEADLINE":    "15s",
+				"LEADER_ELECTION_RETRY_PERIOD":      "15s",
+				"ONBOARDING_ENABLED":                "true",
+				"ONBOARDING_DELIVERY":               "secret,email",
+				"ONBOARDING_API_URL":                "api.my-rosa.example.com",
+				"ACCESS_WINDOWS":                    "workshop=2026-04-30/2026-03-01",
+				"IDLE_SHUTDOWN_AFTER":               "-30m",
+				"IDLE_SHUTDOWN_DEPLOYMENT":          "rosa-namespace-provisioner",
+				"PROJECT_NAME_TEMPLATE":             "sandbox",
+				"DEPROVISION_REPORTS_DIR":           "/nonexistent/reports",
+				"DEPROVISION_REPORTS_URL":           "evidence.example.com",
+			},
+			shouldError: true,
+			expected: []string{
+				"EXISTING_PROJECT_POLICY: ",
+				"ALLOWED_USER_REGEX: ",
+				"EXCLUDED_USER_REGEX: ",
+				"OPS_GROUP_NAME: ",
+				"NAMESPACE_MAPPING_CONFIGMAP: ",
+				"PROJECT_DELETION_POLICY: ",
+				"DELETION_GRACE_PERIOD: ",
+				"GROUP_FINALIZER_ENABLED: ",
+				"APPROVAL_REQUIRED: ",
+				"CLUSTER_RESOURCE_QUOTA_HARD: ",
+				"QUOTA_PRIORITY_CLASS_OPERATOR: ",
+				`AWS_SECRETS: entry "sandbox/model-api=Model_API": invalid Secret name`,
+				"NOTIFICATION_WEBHOOK_URL: ",
+				"NOTIFICATION_SMTP_ADDRESS: ",
+				"NOTIFICATION_EMAIL_FROM: ",
+				"DELETION_MAINTENANCE_WINDOW: ",
+				`SUB_GROUP_NAMES: entry "team/a"`,
+				`TARGET_GROUP_NAMES: entry "team/b"`,
+				`TARGET_GROUP_NAMES: entry "workshop": duplicate group`,
+				`OBJECT_COUNT_QUOTA_HARD: entry "requests.cpu": not an object count`,
+				"COST_TAG_KEY: ",
+				"COST_PRICE_CPU_CORE_HOUR: ",
+				"GROUP_ANOMALY_THRESHOLD: ",
+				`AUDIT_TENANT_LABELS: entry "tenant id"`,
+				"USER_CLUSTER_ROLE: ",
+				`USER_CLUSTER_ROLE_OVERRIDES: entry "staff": not a target group`,
+				`ADDITIONAL_ROLE_BINDINGS: RoleBinding suffix "edit" is used by the controller`,
+				`PROJECT_DESCRIPTION_TEMPLATE: template "Sandbox of {{ .Username }}" is invalid`,
+				"AUTOMATION_SERVICE_ACCOUNT: ",
+				`AUTOMATION_PULL_SECRET: invalid automation pull Secret "ci-registry"`,
+				"SEED_NAMESPACE: required with SEED_SECRETS or SEED_CONFIGMAPS",
+				`TEMPLATE_BUNDLE_CONFIGMAP: invalid template bundle ConfigMap "bundle"`,
+				`PROFILE_DEFAULT: entry "large": not a configured profile`,
+				"LIFECYCLE_WEBHOOK_URL: must be an https URL",
+				"LIFECYCLE_WEBHOOK_KEY_FILE: no signing key file configured",
+				`NOTIFICATION_EMAIL_CONFIGMAP: invalid email ConfigMap "emails"`,
+				"OTEL_EXPORTER_OTLP_ENDPOINT: ",
+				"PPROF_BIND_ADDRESS: :6060 is not a loopback address",
+				"RESOURCE_QUOTA_HARD: at least one limit is required",
+				"LIMIT_RANGE_FILE: no LimitRange template file configured",
+				"CONSOLE_NOTIFICATIONS_ENABLED: requires NAMESPACE_FINALIZER_ENABLED=true",
+				"CONSOLE_NOTIFICATION_LINK: ",
+				"NETWORK_POLICIES_FILE: open /nonexistent/networkpolicies.yaml",
+				`BANDWIDTH_DEFAULT_TIER: entry "large": not a configured tier`,
+				`BANDWIDTH_TIER_OVERRIDES: entry "staff": not a target group`,
+				`MEMBERSHIP_SOURCES: entry "ldap": unknown source`,
+				`MEMBERSHIP_SOURCES: entry "roster": duplicate source`,
+				`MEMBERSHIP_MERGE_POLICY: unknown policy "priority"`,
+				`ROSTER_CONFIGMAP: invalid roster ConfigMap "attendees"`,
+				`GITHUB_TEAM: invalid GitHub team "redhat-ai-dev"`,
+				"GITHUB_TOKEN: required by the github membership source",
+				"AGGREGATED_CLUSTER_ROLE: cannot be combined with USER_CLUSTER_ROLE",
+				"AGGREGATED_CLUSTER_ROLE: edit is a built-in ClusterRole",
+				"AGGREGATED_CLUSTER_ROLE_SELECTORS: ",
+				"LEADER_ELECTION_ID: ",
+				"LEADER_ELECTION_RENEW_DEADLINE: 15s must be shorter than the lease duration 10s",
+				"LEADER_ELECTION_RETRY_PERIOD: ",
+				`ONBOARDING_DELIVERY: entry "email": unknown delivery`,
+				"ONBOARDING_API_URL: ",
+				`ACCESS_WINDOWS: invalid access window "workshop=2026-04-30/2026-03-01": window ends`,
+				"IDLE_SHUTDOWN_AFTER: ",
+				"IDLE_SHUTDOWN_DEPLOYMENT: ",
+				`PROJECT_NAME_TEMPLATE: template "sandbox" must contain {user}`,
+				"DEPROVISION_REPORTS_DIR: stat /nonexistent/reports",
+				"DEPROVISION_REPORT_KEY_FILE: no signing key file configured",
+				"DEPROVISION_REPORTS_URL: ",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			err := ValidateConfig()
+			if tt.shouldError && err == nil {
+				t.Fatalf("Expected case '%s' to receive an error", tt.name)
+			} else if !tt.shouldError && err != nil {
+				t.Fatalf("Expected configuration to be valid, but got error: %v", err)
+			}
+
+			for _, expected := range tt.expected {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("Expected error to contain %q, but got: %v", expected, err)
+				}
+			}
+			if err != nil {
+				var configErr *ConfigError
+				if !errors.As(err, &configErr) {
+					t.Errorf("Expected a ConfigError, but got: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLoopbackAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		"127.0.0.1:8081": true,
+		"localhost:6060": true,
+		"[::1]:8081":     true,
+		":8081":          false,
+		"0.0.0.0:8081":   false,
+		"10.0.0.1:8081":  false,
+		"localhost":      false,
+	} {
+		if err := ValidateLoopbackAddress(address); (err == nil) != valid {
+			t.Errorf("Expected %s to be valid: %v, but got error: %v", address, valid, err)
+		}
+	}
+}
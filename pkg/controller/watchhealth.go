@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// watchHealth tracks since when the watch of each informer is broken, so a replica whose cache
+// silently went stale stops reporting ready
+type watchHealth struct {
+	mu          sync.Mutex
+	brokenSince map[string]time.Time
+}
+
+// Creates a watchHealth with every watch healthy
+func newWatchHealth() *watchHealth {
+	return &watchHealth{brokenSince: make(map[string]time.Time)}
+}
+
+// Records that the watch of the named informer failed, keeping the time of the first failure while
+// it stays broken
+func (w *watchHealth) broken(name string, now time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.brokenSince[name]; !ok {
+		w.brokenSince[name] = now
+	}
+}
+
+// Records that the watch of the named informer was established again
+func (w *watchHealth) restored(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.brokenSince[name]; ok {
+		klog.Infof("Watch of %s informer restored", name)
+		delete(w.brokenSince, name)
+	}
+}
+
+// Returns an error naming the informers whose watch has been broken for longer than the threshold
+func (w *watchHealth) check(now time.Time, threshold time.Duration) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var stale []string
+	for name, since := range w.brokenSince {
+		if now.Sub(since) > threshold {
+			stale = append(stale, fmt.Sprintf("%s since %s", name, since.Format(time.RFC3339)))
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	sort.Strings(stale)
+	return fmt.Errorf("informer watches broken: %s", strings.Join(stale, ", "))
+}
+
+// Creates an informer over the list watcher, tracking the health of its watch under the given name:
+// the watch is broken when listing or watching fails and restored once a watch is established
+func (w *watchHealth) newInformer(name string, listWatcher *cache.ListWatch, objType runtime.Object, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	watchFunc := listWatcher.WatchFuncWithContext
+	listWatcher.WatchFuncWithContext = func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+		watcher, err := watchFunc(ctx, options)
+		if err != nil {
+			w.broken(name, time.Now())
+			return nil, err
+		}
+		w.restored(name)
+		return watcher, nil
+	}
+
+	informer := cache.NewSharedIndexInformer(listWatcher, objType, resyncPeriod, cache.Indexers{})
+	// Setting the handler only fails once the informer has started
+	_ = informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, r *cache.Reflector, err error) {
+		// A watch closed by the server is re-established right away
+		if !errors.Is(err, io.EOF) {
+			w.broken(name, time.Now())
+		}
+		cache.DefaultWatchErrorHandler(ctx, r, err)
+	})
+	return informer
+}
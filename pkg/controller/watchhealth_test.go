@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWatchHealth_check(t *testing.T) {
+	now := time.Now()
+	watches := newWatchHealth()
+	watches.broken("groups", now.Add(-5*time.Minute))
+	watches.broken("namespaces", now.Add(-30*time.Second))
+
+	// Repeated failures keep the time of the first one
+	watches.broken("groups", now)
+
+	if err := watches.check(now, 2*time.Minute); err == nil {
+		t.Errorf("Expected the groups watch broken for 5m to fail the check")
+	}
+	watches.restored("groups")
+	if err := watches.check(now, 2*time.Minute); err != nil {
+		t.Errorf("Expected a watch broken for 30s to pass the check, but got error: %v", err)
+	}
+}
+
+func TestWatchHealth_newInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	listWatcher := &cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.NamespaceList{}, nil
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			if failing.Load() {
+				return nil, errors.New("connection refused")
+			}
+			return watch.NewFake(), nil
+		},
+	}
+	watches := newWatchHealth()
+	informer := watches.newInformer("namespaces", listWatcher, &corev1.Namespace{}, 0)
+	go informer.RunWithContext(ctx)
+
+	waitFor := func(description string, condition func() bool) {
+		t.Helper()
+		err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 10*time.Second, true, func(context.Context) (bool, error) {
+			return condition(), nil
+		})
+		if err != nil {
+			t.Fatalf("Timed out waiting for %s", description)
+		}
+	}
+
+	waitFor("the failing watch to be reported broken", func() bool { return watches.check(time.Now(), 0) != nil })
+	failing.Store(false)
+	waitFor("the watch to be restored", func() bool { return watches.check(time.Now(), 0) == nil })
+}
@@ -0,0 +1,90 @@
+// Package events broadcasts provisioning events to live subscribers
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Actions of a provisioning event
+const (
+	ActionProvision   = "provision"
+	ActionDeprovision = "deprovision"
+)
+
+// Results of a provisioning event
+const (
+	ResultStarted   = "started"
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+)
+
+// number of events buffered per subscriber before events are dropped for it
+const subscriberBufferSize = 64
+
+// Event describes the progress of provisioning or deprovisioning a user
+type Event struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Namespace string    `json:"namespace,omitempty"`
+	Action    string    `json:"action"`
+	Step      string    `json:"step,omitempty"`
+	Result    string    `json:"result"`
+	Message   string    `json:"message,omitempty"`
+	Trigger   string    `json:"trigger,omitempty"`
+	// DryRun marks events of steps only logged instead of run
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// Broadcaster fans out published events to every current subscriber
+type Broadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewBroadcaster creates a new Broadcaster instance
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish sends the event to all subscribers without blocking; events are dropped for
+// subscribers that are not keeping up so the controller is never slowed down
+func (b *Broadcaster) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a new subscriber, returning its event channel and a function
+// that unregisters it and closes the channel
+func (b *Broadcaster) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
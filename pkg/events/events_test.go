@@ -0,0 +1,56 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBroadcaster(t *testing.T) {
+	broadcaster := NewBroadcaster()
+
+	first, unsubscribeFirst := broadcaster.Subscribe()
+	second, unsubscribeSecond := broadcaster.Subscribe()
+	defer unsubscribeSecond()
+
+	broadcaster.Publish(Event{User: "alice", Action: ActionProvision, Result: ResultSucceeded})
+
+	for _, ch := range []<-chan Event{first, second} {
+		event := <-ch
+		if event.User != "alice" || event.Result != ResultSucceeded {
+			t.Errorf("Expected succeeded event for alice, but got %+v", event)
+		}
+		if event.Time.IsZero() {
+			t.Error("Expected event time to be set")
+		}
+	}
+
+	unsubscribeFirst()
+	unsubscribeFirst() // unsubscribing twice should be a no-op
+	if _, ok := <-first; ok {
+		t.Error("Expected channel to be closed after unsubscribing")
+	}
+
+	broadcaster.Publish(Event{User: "bob", Action: ActionDeprovision, Result: ResultStarted})
+	if event := <-second; event.User != "bob" {
+		t.Errorf("Expected event for bob, but got %+v", event)
+	}
+}
+
+func TestBroadcasterDropsEventsForSlowSubscribers(t *testing.T) {
+	broadcaster := NewBroadcaster()
+	ch, unsubscribe := broadcaster.Subscribe()
+	defer unsubscribe()
+
+	// Publishing more events than buffered must never block
+	for i := 0; i < subscriberBufferSize*2; i++ {
+		broadcaster.Publish(Event{User: "alice"})
+	}
+
+	if len(ch) != subscriberBufferSize {
+		t.Errorf("Expected %d buffered events, but got %d", subscriberBufferSize, len(ch))
+	}
+}
+
+func TestNilBroadcasterPublish(t *testing.T) {
+	var broadcaster *Broadcaster
+	broadcaster.Publish(Event{User: "alice"})
+}
@@ -0,0 +1,165 @@
+// Package evidence produces signed reports of deprovisioned users, kept as offboarding evidence
+package evidence
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SignatureAlgorithm is the algorithm signing reports with a shared key
+const SignatureAlgorithm = "HMAC-SHA256"
+
+// ErrInvalidSignature is returned when a report doesn't match its signature
+var ErrInvalidSignature = errors.New("report signature does not match")
+
+// Resource is an object deleted along with the namespace of the user
+type Resource struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Revocation is the cleanup of the artifacts an integration created for the user outside of the cluster
+type Revocation struct {
+	Integration string `json:"integration"`
+	// Revoked is whether the artifacts were deleted, false when the cleanup gave up after every retry
+	Revoked bool   `json:"revoked"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report describes everything done to offboard a user, from their deprovisioning until their
+// namespace and external artifacts are gone
+type Report struct {
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+	// Trigger is what triggered the deprovisioning, e.g. update or admin
+	Trigger string `json:"trigger,omitempty"`
+	// DeletionPolicy is what happened to the namespace: Delete, Retain or Orphan
+	DeletionPolicy string `json:"deletionPolicy"`
+	// NamespaceDeleted is whether the namespace was verified to be gone
+	NamespaceDeleted bool `json:"namespaceDeleted"`
+	// Deleted lists the objects deleted by the provisioner or along with the namespace
+	Deleted []Resource `json:"deleted,omitempty"`
+	// Revoked lists the cleanups of external artifacts
+	Revoked []Revocation `json:"revoked,omitempty"`
+	// StartedAt is when the user was deprovisioned
+	StartedAt time.Time `json:"startedAt"`
+	// CompletedAt is when the offboarding was found complete
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// Signature authenticates a report
+type Signature struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+// SignedReport is a report along with its signature
+type SignedReport struct {
+	Report    Report    `json:"report"`
+	Signature Signature `json:"signature"`
+}
+
+// Returns the MAC of the report encoded as JSON under the key
+func sign(report Report, key []byte) ([]byte, error) {
+	content, err := json.Marshal(report)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(content)
+	return mac.Sum(nil), nil
+}
+
+// Sign signs the report with the key, so auditors holding the key can verify it wasn't altered
+func Sign(report Report, key []byte) (SignedReport, error) {
+	if len(key) == 0 {
+		return SignedReport{}, errors.New("no signing key")
+	}
+	value, err := sign(report, key)
+	if err != nil {
+		return SignedReport{}, err
+	}
+	return SignedReport{Report: report, Signature: Signature{
+		Algorithm: SignatureAlgorithm,
+		Value:     base64.StdEncoding.EncodeToString(value),
+	}}, nil
+}
+
+// Verify returns ErrInvalidSignature unless the report was signed with the key
+func Verify(signed SignedReport, key []byte) error {
+	if signed.Signature.Algorithm != SignatureAlgorithm {
+		return fmt.Errorf("unsupported signature algorithm %q", signed.Signature.Algorithm)
+	}
+	expected, err := sign(signed.Report, key)
+	if err != nil {
+		return err
+	}
+	actual, err := base64.StdEncoding.DecodeString(signed.Signature.Value)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	if !hmac.Equal(actual, expected) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Store keeps signed reports, returning where each one was stored
+type Store interface {
+	Store(ctx context.Context, report SignedReport) (string, error)
+}
+
+// FileStore writes each signed report as a JSON file to a directory, e.g. a mounted volume collected
+// by the audit pipeline
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore writing to the directory
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+// Store writes the report to <namespace>-<completion time>.json, never overwriting an existing report,
+// and returns the name of the file
+func (s *FileStore) Store(ctx context.Context, report SignedReport) (string, error) {
+	name := fmt.Sprintf("%s-%s.json", report.Report.Namespace, report.Report.CompletedAt.UTC().Format("20060102T150405Z"))
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(s.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(append(content, '\n')); err != nil {
+		_ = file.Close()
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ReadFile reads a signed report written by a FileStore
+func ReadFile(path string) (SignedReport, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return SignedReport{}, err
+	}
+	var signed SignedReport
+	if err := json.Unmarshal(content, &signed); err != nil {
+		return SignedReport{}, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return signed, nil
+}
@@ -0,0 +1,72 @@
+package evidence
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	key := []byte("offboarding")
+	report := Report{
+		User:             "alice",
+		Namespace:        "alice",
+		DeletionPolicy:   "Delete",
+		NamespaceDeleted: true,
+		Deleted:          []Resource{{Kind: "Project", Name: "alice"}},
+		Revoked:          []Revocation{{Integration: "registry", Revoked: true}},
+	}
+	signed, err := Sign(report, key)
+	if err != nil {
+		t.Fatalf("Expected the report to be signed, but got error: %v", err)
+	}
+	if err := Verify(signed, key); err != nil {
+		t.Errorf("Expected the signature to match, but got error: %v", err)
+	}
+	if err := Verify(signed, []byte("other")); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected another key to be rejected, but got error: %v", err)
+	}
+
+	signed.Report.Revoked[0].Revoked = false
+	if err := Verify(signed, key); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected an altered report to be rejected, but got error: %v", err)
+	}
+
+	if _, err := Sign(report, nil); err == nil {
+		t.Errorf("Expected signing without a key to fail")
+	}
+}
+
+func TestFileStore_Store(t *testing.T) {
+	ctx := context.Background()
+	key := []byte("offboarding")
+	dir := t.TempDir()
+	signed, err := Sign(Report{User: "alice", Namespace: "alice", CompletedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}, key)
+	if err != nil {
+		t.Fatalf("Expected the report to be signed, but got error: %v", err)
+	}
+
+	store := NewFileStore(dir)
+	name, err := store.Store(ctx, signed)
+	if err != nil {
+		t.Fatalf("Expected the report to be stored, but got error: %v", err)
+	}
+	if name != "alice-20260301T120000Z.json" {
+		t.Errorf("Expected the report to be named after its namespace and completion, but got %s", name)
+	}
+
+	read, err := ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Expected the report to be read, but got error: %v", err)
+	}
+	if err := Verify(read, key); err != nil {
+		t.Errorf("Expected the stored report to verify, but got error: %v", err)
+	}
+
+	// Reports are never overwritten
+	if _, err := store.Store(ctx, signed); err == nil {
+		t.Errorf("Expected storing the same report twice to fail")
+	}
+}
@@ -0,0 +1,185 @@
+// Package export renders the namespaces managed by the provisioner for infrastructure-as-code pipelines
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+)
+
+// Planner plans the provisioning of the target group and describes the objects provisioned per user
+type Planner interface {
+	PlanUsers(ctx context.Context) (controller.UserPlan, error)
+	DesiredManifests(user string) ([]map[string]interface{}, error)
+}
+
+// UserResources are the manifests provisioned for a single user
+type UserResources struct {
+	User      string                   `json:"user"`
+	Manifests []map[string]interface{} `json:"manifests"`
+}
+
+// Plan is the desired per-user state along with the changes needed to reach it
+type Plan struct {
+	Add    []UserResources `json:"add"`
+	Keep   []UserResources `json:"keep"`
+	Remove []string        `json:"remove"`
+}
+
+// BuildPlan diffs the target group against the managed namespaces and renders the manifests of
+// every user that should have a namespace
+func BuildPlan(ctx context.Context, planner Planner) (*Plan, error) {
+	users, err := planner.PlanUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan users: %w", err)
+	}
+
+	plan := &Plan{
+		Add:    []UserResources{},
+		Keep:   []UserResources{},
+		Remove: users.Remove,
+	}
+	if plan.Remove == nil {
+		plan.Remove = []string{}
+	}
+	for _, user := range users.Add {
+		resources, err := userResources(planner, user)
+		if err != nil {
+			return nil, err
+		}
+		plan.Add = append(plan.Add, resources)
+	}
+	for _, user := range users.Keep {
+		resources, err := userResources(planner, user)
+		if err != nil {
+			return nil, err
+		}
+		plan.Keep = append(plan.Keep, resources)
+	}
+	return plan, nil
+}
+
+// Returns the manifests provisioned for the target user
+func userResources(planner Planner, user string) (UserResources, error) {
+	manifests, err := planner.DesiredManifests(user)
+	if err != nil {
+		return UserResources{}, fmt.Errorf("failed to render resources of user %s: %w", user, err)
+	}
+	return UserResources{User: user, Manifests: manifests}, nil
+}
+
+// WriteJSON writes the plan as indented JSON
+func WriteJSON(w io.Writer, plan *Plan) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(plan)
+}
+
+// WriteHCL writes the desired state of the plan as kubernetes_manifest resources of the Terraform
+// kubernetes provider. Removed users are left out so applying the configuration deletes them.
+func WriteHCL(w io.Writer, plan *Plan) error {
+	resources := append(append([]UserResources{}, plan.Add...), plan.Keep...)
+	sort.Slice(resources, func(i, j int) bool {
+		return resources[i].User < resources[j].User
+	})
+
+	var b strings.Builder
+	b.WriteString("# Generated by rosa-namespace-provisioner, do not edit\n")
+	for _, user := range resources {
+		seen := make(map[string]bool)
+		for _, manifest := range user.Manifests {
+			kind, _ := manifest["kind"].(string)
+			name := resourceName(kind, user.User)
+			// qualify further objects of the same kind with their own name, e.g. several ResourceQuotas
+			if seen[name] {
+				metadata, _ := manifest["metadata"].(map[string]interface{})
+				objectName, _ := metadata["name"].(string)
+				name = resourceName(kind, user.User+"_"+objectName)
+			}
+			seen[name] = true
+			fmt.Fprintf(&b, "\nresource \"kubernetes_manifest\" %q {\n", name)
+			b.WriteString("  manifest = ")
+			writeHCLValue(&b, manifest, 1)
+			b.WriteString("\n}\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// characters not allowed in Terraform resource names
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// identifiers which can be written as bare HCL object keys
+var hclIdentifier = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// Returns the Terraform resource name of an object of the given kind provisioned for a user
+func resourceName(kind string, user string) string {
+	name := fmt.Sprintf("%s_%s", strings.ToLower(kind), invalidNameChars.ReplaceAllString(user, "_"))
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// Writes a manifest value as an HCL expression
+func writeHCLValue(b *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for _, key := range keys {
+			b.WriteString(indent + "  ")
+			if hclIdentifier.MatchString(key) {
+				b.WriteString(key)
+			} else {
+				b.WriteString(hclString(key))
+			}
+			b.WriteString(" = ")
+			writeHCLValue(b, v[key], depth+1)
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + "}")
+	case []interface{}:
+		if len(v) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[\n")
+		for _, item := range v {
+			b.WriteString(indent + "  ")
+			writeHCLValue(b, item, depth+1)
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	case string:
+		b.WriteString(hclString(v))
+	case nil:
+		b.WriteString("null")
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
+
+// Returns a quoted HCL string literal, escaping template sequences
+func hclString(value string) string {
+	quoted := fmt.Sprintf("%q", value)
+	quoted = strings.ReplaceAll(quoted, "${", "$${")
+	return strings.ReplaceAll(quoted, "%{", "%%{")
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+)
+
+// fakePlanner plans a fixed set of users with a Project and two ResourceQuota manifests each
+type fakePlanner struct {
+	plan controller.UserPlan
+}
+
+func (f *fakePlanner) PlanUsers(ctx context.Context) (controller.UserPlan, error) {
+	return f.plan, nil
+}
+
+func (f *fakePlanner) DesiredManifests(user string) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{
+			"apiVersion": "project.openshift.io/v1",
+			"kind":       "Project",
+			"metadata": map[string]interface{}{
+				"name": user,
+				"labels": map[string]interface{}{
+					"rosa-namespace-provisioner/owner": user,
+				},
+			},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "ResourceQuota",
+			"metadata":   map[string]interface{}{"name": "deny-load-balancers", "namespace": user},
+		},
+		{
+			"apiVersion": "v1",
+			"kind":       "ResourceQuota",
+			"metadata":   map[string]interface{}{"name": "object-counts", "namespace": user},
+		},
+	}, nil
+}
+
+func TestBuildPlan(t *testing.T) {
+	planner := &fakePlanner{plan: controller.UserPlan{Add: []string{"carol"}, Keep: []string{"alice"}, Remove: []string{"bob"}}}
+
+	plan, err := BuildPlan(context.Background(), planner)
+	if err != nil {
+		t.Fatalf("Expected plan to be built, but got error: %v", err)
+	}
+	if len(plan.Add) != 1 || plan.Add[0].User != "carol" || len(plan.Add[0].Manifests) != 3 {
+		t.Errorf("Expected carol to be added with her manifests, but got %+v", plan.Add)
+	}
+	if len(plan.Keep) != 1 || plan.Keep[0].User != "alice" {
+		t.Errorf("Expected alice to be kept, but got %+v", plan.Keep)
+	}
+	if len(plan.Remove) != 1 || plan.Remove[0] != "bob" {
+		t.Errorf("Expected bob to be removed, but got %v", plan.Remove)
+	}
+
+	var out bytes.Buffer
+	if err := WriteJSON(&out, plan); err != nil {
+		t.Fatalf("Expected JSON plan to be written, but got error: %v", err)
+	}
+	decoded := &Plan{}
+	if err := json.Unmarshal(out.Bytes(), decoded); err != nil {
+		t.Fatalf("Expected JSON plan to be valid, but got error: %v", err)
+	}
+}
+
+func TestWriteHCL(t *testing.T) {
+	planner := &fakePlanner{plan: controller.UserPlan{Add: []string{"carol.smith"}, Keep: []string{"alice"}, Remove: []string{"bob"}}}
+	plan, err := BuildPlan(context.Background(), planner)
+	if err != nil {
+		t.Fatalf("Expected plan to be built, but got error: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteHCL(&out, plan); err != nil {
+		t.Fatalf("Expected HCL to be written, but got error: %v", err)
+	}
+	hcl := out.String()
+
+	for _, expected := range []string{
+		`resource "kubernetes_manifest" "project_alice" {`,
+		`resource "kubernetes_manifest" "project_carol_smith" {`,
+		`resource "kubernetes_manifest" "resourcequota_alice" {`,
+		`resource "kubernetes_manifest" "resourcequota_alice_object-counts" {`,
+		`apiVersion = "project.openshift.io/v1"`,
+		`"rosa-namespace-provisioner/owner" = "alice"`,
+	} {
+		if !strings.Contains(hcl, expected) {
+			t.Errorf("Expected HCL to contain %q, but got:\n%s", expected, hcl)
+		}
+	}
+	if strings.Contains(hcl, "bob") {
+		t.Errorf("Expected removed user bob to be left out, but got:\n%s", hcl)
+	}
+	if strings.Index(hcl, "project_alice") > strings.Index(hcl, "project_carol_smith") {
+		t.Errorf("Expected resources to be sorted by user")
+	}
+}
+
+func TestHCLString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "plain string",
+			value: "alice",
+			want:  `"alice"`,
+		},
+		{
+			name:  "quotes",
+			value: `say "hi"`,
+			want:  `"say \"hi\""`,
+		},
+		{
+			name:  "template sequences",
+			value: "${var} %{if}",
+			want:  `"$${var} %%{if}"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hclString(tt.value); got != tt.want {
+				t.Errorf("hclString() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Package fakecluster runs the provisioner against in-memory fake clientsets, driven by a scenario
+// of group changes, so it can be developed and demoed without a cluster. Scenarios can be recorded
+// from the group changes a controller observes on a real cluster.
+package fakecluster
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	projectfake "github.com/openshift/client-go/project/clientset/versioned/fake"
+	quotafake "github.com/openshift/client-go/quota/clientset/versioned/fake"
+	userclient "github.com/openshift/client-go/user/clientset/versioned"
+	userfake "github.com/openshift/client-go/user/clientset/versioned/fake"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Clients are the in-memory fake clientsets the controller runs against
+type Clients struct {
+	User    *userfake.Clientset
+	Project *projectfake.Clientset
+	Quota   *quotafake.Clientset
+	Kube    *kubefake.Clientset
+	Dynamic *dynamicfake.FakeDynamicClient
+}
+
+// NewClients creates the fake clientsets with the target groups, the first of them holding the
+// given members. Projects are served from the namespaces of the fake core client, as on OpenShift,
+// so the controller sees a consistent state through both.
+func NewClients(groups []string, members []string) *Clients {
+	objects := make([]runtime.Object, 0, len(groups))
+	for i, name := range groups {
+		group := &userv1.Group{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if i == 0 {
+			group.Users = members
+		}
+		objects = append(objects, group)
+	}
+	clients := &Clients{
+		User:    userfake.NewSimpleClientset(objects...),
+		Project: projectfake.NewSimpleClientset(),
+		Quota:   quotafake.NewClientset(),
+		Kube:    kubefake.NewClientset(),
+		Dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			v1alpha1.ManagedNamespacesResource: "ManagedNamespaceList",
+		}),
+	}
+	clients.Project.PrependReactor("*", "projects", projectReactor(clients.Kube))
+	return clients
+}
+
+// Config returns a placeholder REST config for the controller-runtime manager in fake mode. The
+// controller only watches and writes through the fake clientsets, so it never reaches an API server.
+func Config() *rest.Config {
+	return &rest.Config{Host: "https://fake-cluster.invalid"}
+}
+
+// Returns a reactor serving projects from the namespaces of the core client
+func projectReactor(kube kubernetes.Interface) clienttesting.ReactionFunc {
+	namespaces := kube.CoreV1().Namespaces()
+	return func(action clienttesting.Action) (bool, runtime.Object, error) {
+		ctx := context.Background()
+		switch action.GetVerb() {
+		case "get":
+			name := action.(clienttesting.GetAction).GetName()
+			namespace, err := namespaces.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return true, nil, err
+			}
+			return true, projectFromNamespace(namespace), nil
+		case "list":
+			list, err := namespaces.List(ctx, metav1.ListOptions{
+				LabelSelector: action.(clienttesting.ListAction).GetListRestrictions().Labels.String(),
+			})
+			if err != nil {
+				return true, nil, err
+			}
+			projects := &projectv1.ProjectList{}
+			for i := range list.Items {
+				projects.Items = append(projects.Items, *projectFromNamespace(&list.Items[i]))
+			}
+			return true, projects, nil
+		case "create":
+			// Fill in what the API server would set on a new namespace
+			project := action.(clienttesting.CreateAction).GetObject().(*projectv1.Project)
+			namespace := &corev1.Namespace{
+				ObjectMeta: *project.ObjectMeta.DeepCopy(),
+				Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+			}
+			namespace.CreationTimestamp = metav1.Now()
+			namespace, err := namespaces.Create(ctx, namespace, metav1.CreateOptions{})
+			if err != nil {
+				return true, nil, err
+			}
+			return true, projectFromNamespace(namespace), nil
+		case "patch":
+			patch := action.(clienttesting.PatchAction)
+			namespace, err := namespaces.Patch(ctx, patch.GetName(), patch.GetPatchType(), patch.GetPatch(), metav1.PatchOptions{})
+			if err != nil {
+				return true, nil, err
+			}
+			return true, projectFromNamespace(namespace), nil
+		case "delete":
+			return true, nil, namespaces.Delete(ctx, action.(clienttesting.DeleteAction).GetName(), metav1.DeleteOptions{})
+		}
+		return false, nil, nil
+	}
+}
+
+// Returns the project view of a namespace
+func projectFromNamespace(namespace *corev1.Namespace) *projectv1.Project {
+	return &projectv1.Project{
+		ObjectMeta: *namespace.ObjectMeta.DeepCopy(),
+		Status:     projectv1.ProjectStatus{Phase: namespace.Status.Phase},
+	}
+}
+
+// Step is a change to the members of a target group
+type Step struct {
+	// After is the delay since the previous step
+	After metav1.Duration `json:"after,omitempty"`
+	// Group is the target group changed by the step, defaulting to the first target group
+	Group string `json:"group,omitempty"`
+	// Add lists the users added to the group
+	Add []string `json:"add,omitempty"`
+	// Remove lists the users removed from the group
+	Remove []string `json:"remove,omitempty"`
+}
+
+// Scenario scripts the members of the target groups over time
+type Scenario struct {
+	// Members are the initial members of the first target group
+	Members []string `json:"members,omitempty"`
+	// Steps are played in order once the controller has started
+	Steps []Step `json:"steps,omitempty"`
+}
+
+// LoadScenario reads a scenario from a YAML or JSON file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenario := &Scenario{}
+	if err := yaml.UnmarshalStrict(data, scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario %s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// Play applies the steps of the scenario to the target groups, waiting the delay of each step
+// before applying it. Steps naming no group change the default group. It returns early when the
+// context is cancelled.
+func (s *Scenario) Play(ctx context.Context, client userclient.Interface, defaultGroup string) error {
+	for i, step := range s.Steps {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(step.After.Duration):
+		}
+
+		group := step.Group
+		if group == "" {
+			group = defaultGroup
+		}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current, err := client.UserV1().Groups().Get(ctx, group, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			current.Users = applyStep(current.Users, step)
+			_, err = client.UserV1().Groups().Update(ctx, current, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply step %d of scenario: %w", i+1, err)
+		}
+		klog.Infof("Applied step %d of scenario to group %s: added %v, removed %v", i+1, group, step.Add, step.Remove)
+	}
+	return nil
+}
+
+// Returns the members of a group after applying a step
+func applyStep(users []string, step Step) []string {
+	removed := make(map[string]bool, len(step.Remove))
+	for _, user := range step.Remove {
+		removed[user] = true
+	}
+
+	var members []string
+	present := make(map[string]bool, len(users))
+	for _, user := range users {
+		if !removed[user] && !present[user] {
+			members = append(members, user)
+			present[user] = true
+		}
+	}
+	for _, user := range step.Add {
+		if !removed[user] && !present[user] {
+			members = append(members, user)
+			present[user] = true
+		}
+	}
+	return members
+}
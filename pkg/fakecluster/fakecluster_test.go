@@ -0,0 +1,148 @@
+package fakecluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNewClients_projects(t *testing.T) {
+	ctx := context.Background()
+	clients := NewClients([]string{"test-group"}, []string{"alice"})
+	projects := clients.Project.ProjectV1().Projects()
+
+	for _, name := range []string{"alice", "unowned"} {
+		project := &projectv1.Project{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if name == "alice" {
+			project.Labels = map[string]string{"owner": "alice"}
+		}
+		if _, err := projects.Create(ctx, project, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Expected project %s to be created, but got error: %v", name, err)
+		}
+	}
+
+	// Projects are backed by namespaces
+	namespace, err := clients.Kube.CoreV1().Namespaces().Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected namespace alice to be created with its project, but got error: %v", err)
+	}
+	if namespace.Labels["owner"] != "alice" || namespace.Status.Phase != "Active" {
+		t.Errorf("Expected an active namespace labelled with its owner, but got %+v", namespace)
+	}
+
+	patch := []byte(`{"metadata":{"annotations":{"team":"ai"}}}`)
+	if _, err := projects.Patch(ctx, "alice", "application/merge-patch+json", patch, metav1.PatchOptions{}); err != nil {
+		t.Fatalf("Expected project alice to be patched, but got error: %v", err)
+	}
+	project, err := projects.Get(ctx, "alice", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected project alice to be found, but got error: %v", err)
+	}
+	if project.Annotations["team"] != "ai" {
+		t.Errorf("Expected project alice to be annotated, but got %v", project.Annotations)
+	}
+
+	list, err := projects.List(ctx, metav1.ListOptions{LabelSelector: "owner"})
+	if err != nil {
+		t.Fatalf("Expected projects to be listed, but got error: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "alice" {
+		t.Errorf("Expected only project alice to match the selector, but got %v", list.Items)
+	}
+
+	if err := projects.Delete(ctx, "alice", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Expected project alice to be deleted, but got error: %v", err)
+	}
+	if _, err := clients.Kube.CoreV1().Namespaces().Get(ctx, "alice", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected namespace alice to be deleted with its project, but got error: %v", err)
+	}
+}
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		shouldError bool
+		expected    *Scenario
+	}{
+		{
+			name: "valid scenario",
+			content: `members: [alice]
+steps:
+- after: 30s
+  add: [bob]
+- remove: [alice]
+`,
+			expected: &Scenario{
+				Members: []string{"alice"},
+				Steps: []Step{
+					{After: metav1.Duration{Duration: 30 * time.Second}, Add: []string{"bob"}},
+					{Remove: []string{"alice"}},
+				},
+			},
+		},
+		{
+			name:        "unknown field",
+			content:     "steps:\n- added: [bob]\n",
+			shouldError: true,
+		},
+		{
+			name:        "invalid delay",
+			content:     "steps:\n- after: soon\n",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "scenario.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write scenario: %v", err)
+			}
+
+			scenario, err := LoadScenario(path)
+			if tt.shouldError {
+				if err == nil {
+					t.Errorf("Expected case '%s' to receive an error", tt.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected scenario to be loaded, but got error: %v", err)
+			}
+			if !reflect.DeepEqual(scenario, tt.expected) {
+				t.Errorf("LoadScenario() = %+v, want %+v", scenario, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScenario_Play(t *testing.T) {
+	ctx := context.Background()
+	clients := NewClients([]string{"test-group"}, []string{"alice", "bob"})
+	scenario := &Scenario{
+		Steps: []Step{
+			{Add: []string{"carol", "alice"}},
+			{After: metav1.Duration{Duration: time.Millisecond}, Remove: []string{"alice"}},
+		},
+	}
+
+	if err := scenario.Play(ctx, clients.User, "test-group"); err != nil {
+		t.Fatalf("Expected scenario to be played, but got error: %v", err)
+	}
+
+	group, err := clients.User.UserV1().Groups().Get(ctx, "test-group", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected group to be found, but got error: %v", err)
+	}
+	if expected := []string{"bob", "carol"}; !reflect.DeepEqual([]string(group.Users), expected) {
+		t.Errorf("Expected group members %v, but got %v", expected, group.Users)
+	}
+}
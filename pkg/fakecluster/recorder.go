@@ -0,0 +1,120 @@
+package fakecluster
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// Recorder records the membership changes of the target groups as a scenario, so they can be
+// replayed against a fake cluster
+type Recorder struct {
+	path         string
+	defaultGroup string
+	now          func() time.Time
+
+	mu       sync.Mutex
+	scenario Scenario
+	members  map[string]map[string]bool
+	last     time.Time
+}
+
+// NewRecorder creates a Recorder writing the recorded scenario to the given file. Changes to the
+// default group are recorded as the scenario members and steps naming no group.
+func NewRecorder(path string, defaultGroup string) *Recorder {
+	return &Recorder{
+		path:         path,
+		defaultGroup: defaultGroup,
+		now:          time.Now,
+		members:      make(map[string]map[string]bool),
+	}
+}
+
+// RecordGroup records the members of a group when it is first observed and the users added and
+// removed by every later change, rewriting the scenario file after each of them. Changes which
+// leave the membership unchanged, such as resyncs, are not recorded.
+func (r *Recorder) RecordGroup(oldGroup, newGroup *userv1.Group) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	users := make(map[string]bool, len(newGroup.Users))
+	for _, user := range newGroup.Users {
+		users[user] = true
+	}
+
+	if len(r.members) == 0 {
+		r.last = now
+	}
+	previous, observed := r.members[newGroup.Name]
+	if !observed && newGroup.Name == r.defaultGroup {
+		r.scenario.Members = sortedUsers(users, nil)
+		r.members[newGroup.Name] = users
+		r.write()
+		return
+	}
+
+	// Other groups start out empty when the scenario is played, so their first members are added
+	step := Step{
+		After:  metav1.Duration{Duration: now.Sub(r.last).Round(time.Millisecond)},
+		Add:    sortedUsers(users, previous),
+		Remove: sortedUsers(previous, users),
+	}
+	if newGroup.Name != r.defaultGroup {
+		step.Group = newGroup.Name
+	}
+	r.members[newGroup.Name] = users
+	if len(step.Add) == 0 && len(step.Remove) == 0 {
+		return
+	}
+	r.scenario.Steps = append(r.scenario.Steps, step)
+	r.last = now
+	r.write()
+}
+
+// Returns the users of a set which are missing from another set, sorted by name
+func sortedUsers(users map[string]bool, exclude map[string]bool) []string {
+	var sorted []string
+	for user := range users {
+		if !exclude[user] {
+			sorted = append(sorted, user)
+		}
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Writes the recorded scenario, replacing the file at once so it is never read half written
+func (r *Recorder) write() {
+	data, err := yaml.Marshal(r.scenario)
+	if err != nil {
+		klog.Errorf("Error encoding recorded scenario: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		klog.Errorf("Error recording group changes to %s: %v", r.path, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		klog.Errorf("Error recording group changes to %s: %v", r.path, err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		klog.Errorf("Error recording group changes to %s: %v", r.path, err)
+		return
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		klog.Errorf("Error recording group changes to %s: %v", r.path, err)
+	}
+}
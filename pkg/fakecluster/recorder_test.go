@@ -0,0 +1,105 @@
+package fakecluster
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	userv1 "github.com/openshift/api/user/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecorder_RecordGroup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recorded.yaml")
+	recorder := NewRecorder(path, "test-group")
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	group := func(users ...string) *userv1.Group {
+		return &userv1.Group{ObjectMeta: metav1.ObjectMeta{Name: "test-group"}, Users: users}
+	}
+
+	recorder.RecordGroup(nil, group("bob", "alice"))
+	now = now.Add(1500 * time.Millisecond)
+	recorder.RecordGroup(group("bob", "alice"), group("alice", "bob", "carol"))
+	// A resync leaves the membership unchanged and is not recorded
+	now = now.Add(time.Minute)
+	recorder.RecordGroup(group("alice", "bob", "carol"), group("alice", "bob", "carol"))
+	now = now.Add(time.Second)
+	recorder.RecordGroup(group("alice", "bob", "carol"), group("carol", "dave"))
+
+	scenario, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("Expected recorded scenario to be loaded, but got error: %v", err)
+	}
+	expected := &Scenario{
+		Members: []string{"alice", "bob"},
+		Steps: []Step{
+			{After: metav1.Duration{Duration: 1500 * time.Millisecond}, Add: []string{"carol"}},
+			{After: metav1.Duration{Duration: time.Minute + time.Second}, Add: []string{"dave"}, Remove: []string{"alice", "bob"}},
+		},
+	}
+	if !reflect.DeepEqual(scenario, expected) {
+		t.Errorf("Expected recorded scenario %+v, but got %+v", expected, scenario)
+	}
+
+	// Replaying the recording reproduces the final membership
+	clients := NewClients([]string{"test-group"}, scenario.Members)
+	scenario.Steps[0].After.Duration = 0
+	scenario.Steps[1].After.Duration = 0
+	if err := scenario.Play(t.Context(), clients.User, "test-group"); err != nil {
+		t.Fatalf("Expected recorded scenario to be played, but got error: %v", err)
+	}
+	replayed, err := clients.User.UserV1().Groups().Get(t.Context(), "test-group", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected group to be found, but got error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(replayed.Users), []string{"carol", "dave"}) {
+		t.Errorf("Expected replayed members [carol dave], but got %v", replayed.Users)
+	}
+}
+
+func TestRecorder_RecordGroup_multipleGroups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recorded.yaml")
+	recorder := NewRecorder(path, "test-group")
+	now := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	group := func(name string, users ...string) *userv1.Group {
+		return &userv1.Group{ObjectMeta: metav1.ObjectMeta{Name: name}, Users: users}
+	}
+
+	recorder.RecordGroup(nil, group("test-group", "alice"))
+	recorder.RecordGroup(nil, group("staff", "bob"))
+	now = now.Add(time.Second)
+	recorder.RecordGroup(group("staff", "bob"), group("staff", "bob", "carol"))
+
+	scenario, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("Expected recorded scenario to be loaded, but got error: %v", err)
+	}
+	expected := &Scenario{
+		Members: []string{"alice"},
+		Steps: []Step{
+			{Group: "staff", Add: []string{"bob"}},
+			{After: metav1.Duration{Duration: time.Second}, Group: "staff", Add: []string{"carol"}},
+		},
+	}
+	if !reflect.DeepEqual(scenario, expected) {
+		t.Errorf("Expected recorded scenario %+v, but got %+v", expected, scenario)
+	}
+
+	clients := NewClients([]string{"test-group", "staff"}, scenario.Members)
+	scenario.Steps[1].After.Duration = 0
+	if err := scenario.Play(t.Context(), clients.User, "test-group"); err != nil {
+		t.Fatalf("Expected recorded scenario to be played, but got error: %v", err)
+	}
+	replayed, err := clients.User.UserV1().Groups().Get(t.Context(), "staff", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected group to be found, but got error: %v", err)
+	}
+	if !reflect.DeepEqual([]string(replayed.Users), []string{"bob", "carol"}) {
+		t.Errorf("Expected replayed members [bob carol], but got %v", replayed.Users)
+	}
+}
@@ -0,0 +1,94 @@
+// Package githubteam reads the members of a GitHub team, so membership of the team can grant
+// namespaces to the OpenShift users of a GitHub identity provider, whose usernames are GitHub logins
+package githubteam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// number of members requested per page, the maximum allowed by the GitHub API
+const pageSize = 100
+
+// Source lists the members of a GitHub team
+type Source struct {
+	apiURL string
+	org    string
+	team   string
+	token  string
+	client *http.Client
+}
+
+// NewSource creates a new Source listing the members of the team of the organization through the
+// GitHub API at the given base URL
+func NewSource(apiURL, org, team, token string) *Source {
+	return &Source{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		org:    org,
+		team:   team,
+		token:  token,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Name returns "github"
+func (s *Source) Name() string {
+	return "github"
+}
+
+// Members returns the logins of the direct and child team members of the team
+func (s *Source) Members(ctx context.Context) (map[string]bool, error) {
+	members := make(map[string]bool)
+	for page := 1; ; page++ {
+		logins, err := s.membersPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for _, login := range logins {
+			members[login] = true
+		}
+		if len(logins) < pageSize {
+			return members, nil
+		}
+	}
+}
+
+// Returns the logins of a page of team members
+func (s *Source) membersPage(ctx context.Context, page int) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/orgs/%s/teams/%s/members?per_page=%d&page=%d",
+		s.apiURL, url.PathEscape(s.org), url.PathEscape(s.team), pageSize, page)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GitHub team members request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of GitHub team %s/%s: %w", s.org, s.team, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("listing members of GitHub team %s/%s returned status %d", s.org, s.team, resp.StatusCode)
+	}
+	var members []struct {
+		Login string `json:"login"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&members); err != nil {
+		return nil, fmt.Errorf("failed to decode members of GitHub team %s/%s: %w", s.org, s.team, err)
+	}
+	logins := make([]string, 0, len(members))
+	for _, member := range members {
+		logins = append(logins, member.Login)
+	}
+	return logins, nil
+}
@@ -0,0 +1,49 @@
+package githubteam
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestSource_Members(t *testing.T) {
+	// 150 members served over two pages
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/redhat-ai-dev/teams/sandbox-users/members" {
+			t.Errorf("Unexpected request path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("Expected the token to be sent, but got %q", auth)
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		var members []map[string]string
+		for i := (page - 1) * pageSize; i < 150 && i < page*pageSize; i++ {
+			members = append(members, map[string]string{"login": fmt.Sprintf("user%d", i)})
+		}
+		_ = json.NewEncoder(w).Encode(members)
+	}))
+	defer server.Close()
+
+	members, err := NewSource(server.URL+"/", "redhat-ai-dev", "sandbox-users", "token").Members(context.Background())
+	if err != nil {
+		t.Fatalf("Expected team members to be listed, but got error: %v", err)
+	}
+	if len(members) != 150 || !members["user0"] || !members["user149"] {
+		t.Errorf("Expected 150 members from both pages, but got %d", len(members))
+	}
+}
+
+func TestSource_MembersErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	if _, err := NewSource(server.URL, "redhat-ai-dev", "missing", "token").Members(context.Background()); err == nil {
+		t.Errorf("Expected an error for a missing team")
+	}
+}
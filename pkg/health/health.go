@@ -0,0 +1,141 @@
+// Package health tracks the health of the provisioner's optional integrations
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"k8s.io/klog/v2"
+)
+
+// Status describes the health of a single integration
+type Status struct {
+	Name                string    `json:"name"`
+	Healthy             bool      `json:"healthy"`
+	Disabled            bool      `json:"disabled"`
+	ConsecutiveFailures int       `json:"consecutiveFailures"`
+	LastError           string    `json:"lastError,omitempty"`
+	LastSuccess         time.Time `json:"lastSuccess,omitzero"`
+	LastFailure         time.Time `json:"lastFailure,omitzero"`
+	DisabledUntil       time.Time `json:"disabledUntil,omitzero"`
+}
+
+// Tracker records the results of calls to each integration, disabling an integration for a
+// cool-down period once it fails persistently so it doesn't hold back core provisioning
+type Tracker struct {
+	mu           sync.Mutex
+	threshold    int
+	cooldown     time.Duration
+	integrations map[string]*Status
+	now          func() time.Time
+}
+
+// NewTracker creates a new Tracker disabling integrations for the cool-down period after the
+// given number of consecutive failures
+func NewTracker(threshold int, cooldown time.Duration) *Tracker {
+	return &Tracker{
+		threshold:    threshold,
+		cooldown:     cooldown,
+		integrations: make(map[string]*Status),
+		now:          time.Now,
+	}
+}
+
+// Register starts tracking an integration, reporting it healthy until a call fails
+func (t *Tracker) Register(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.integrations[name]; !ok {
+		t.integrations[name] = &Status{Name: name, Healthy: true}
+		metrics.IntegrationUp.WithLabelValues(name).Set(1)
+		metrics.IntegrationDisabled.WithLabelValues(name).Set(0)
+	}
+}
+
+// Enabled returns whether the integration should be called. A disabled integration is enabled
+// again once its cool-down has passed, and disabled anew if the next call fails.
+func (t *Tracker) Enabled(name string) bool {
+	if t == nil {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.integrations[name]
+	if !ok || !status.Disabled {
+		return true
+	}
+	if t.now().Before(status.DisabledUntil) {
+		return false
+	}
+
+	klog.Infof("Re-enabling integration %s after cool-down", name)
+	status.Disabled = false
+	status.DisabledUntil = time.Time{}
+	status.ConsecutiveFailures = t.threshold - 1
+	metrics.IntegrationDisabled.WithLabelValues(name).Set(0)
+	return true
+}
+
+// Record records the result of a call to the integration
+func (t *Tracker) Record(name string, err error) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.integrations[name]
+	if !ok {
+		status = &Status{Name: name}
+		t.integrations[name] = status
+	}
+
+	now := t.now()
+	if err == nil {
+		status.Healthy = true
+		status.ConsecutiveFailures = 0
+		status.LastSuccess = now
+		metrics.IntegrationUp.WithLabelValues(name).Set(1)
+		return
+	}
+
+	status.Healthy = false
+	status.ConsecutiveFailures++
+	status.LastError = err.Error()
+	status.LastFailure = now
+	metrics.IntegrationUp.WithLabelValues(name).Set(0)
+	metrics.IntegrationFailures.WithLabelValues(name).Inc()
+
+	if !status.Disabled && t.threshold > 0 && status.ConsecutiveFailures >= t.threshold {
+		status.Disabled = true
+		status.DisabledUntil = now.Add(t.cooldown)
+		metrics.IntegrationDisabled.WithLabelValues(name).Set(1)
+		klog.Errorf("Disabling integration %s until %s after %d consecutive failures, last error: %v",
+			name,
+			status.DisabledUntil.Format(time.RFC3339),
+			status.ConsecutiveFailures,
+			err,
+		)
+	}
+}
+
+// Statuses returns the health of every tracked integration sorted by name
+func (t *Tracker) Statuses() []Status {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	statuses := make([]Status, 0, len(t.integrations))
+	for _, status := range t.integrations {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
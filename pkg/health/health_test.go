@@ -0,0 +1,58 @@
+package health
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(2, 10*time.Minute)
+	tracker.now = func() time.Time { return now }
+	tracker.Register("aws-secrets")
+
+	tracker.Record("aws-secrets", fmt.Errorf("access denied"))
+	if !tracker.Enabled("aws-secrets") {
+		t.Fatalf("Expected integration to stay enabled below the failure threshold")
+	}
+
+	tracker.Record("aws-secrets", fmt.Errorf("access denied"))
+	if tracker.Enabled("aws-secrets") {
+		t.Fatalf("Expected integration to be disabled after reaching the failure threshold")
+	}
+	status := tracker.Statuses()[0]
+	if status.Healthy || !status.Disabled || status.LastError != "access denied" {
+		t.Errorf("Expected unhealthy disabled integration, but got %+v", status)
+	}
+
+	// The integration is tried again after the cool-down and disabled again on failure
+	now = now.Add(11 * time.Minute)
+	if !tracker.Enabled("aws-secrets") {
+		t.Fatalf("Expected integration to be re-enabled after the cool-down")
+	}
+	tracker.Record("aws-secrets", fmt.Errorf("access denied"))
+	if tracker.Enabled("aws-secrets") {
+		t.Fatalf("Expected integration to be disabled again after failing once more")
+	}
+
+	now = now.Add(11 * time.Minute)
+	tracker.Enabled("aws-secrets")
+	tracker.Record("aws-secrets", nil)
+	status = tracker.Statuses()[0]
+	if !status.Healthy || status.Disabled || status.ConsecutiveFailures != 0 {
+		t.Errorf("Expected healthy enabled integration after a success, but got %+v", status)
+	}
+}
+
+func TestTracker_nil(t *testing.T) {
+	var tracker *Tracker
+	tracker.Register("notifications")
+	tracker.Record("notifications", fmt.Errorf("unreachable"))
+	if !tracker.Enabled("notifications") {
+		t.Errorf("Expected integrations to be enabled without a tracker")
+	}
+	if statuses := tracker.Statuses(); statuses != nil {
+		t.Errorf("Expected no statuses without a tracker, but got %+v", statuses)
+	}
+}
@@ -0,0 +1,229 @@
+// Package metrics defines the Prometheus metrics exported by the provisioner
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// namespace of all exported metric names
+const metricsNamespace = "rosa_namespace_provisioner"
+
+// Names of the exported metrics, shared with the generated dashboards and alerts
+const (
+	ProvisioningDurationName       = metricsNamespace + "_provisioning_duration_seconds"
+	ProvisioningSLOBreachesName    = metricsNamespace + "_provisioning_slo_breaches_total"
+	IntegrationUpName              = metricsNamespace + "_integration_up"
+	IntegrationDisabledName        = metricsNamespace + "_integration_disabled"
+	IntegrationFailuresName        = metricsNamespace + "_integration_failures_total"
+	EstimatedHourlyCostName        = metricsNamespace + "_estimated_hourly_cost_dollars"
+	ExternalCleanupDeadLettersName = metricsNamespace + "_external_cleanup_dead_letters"
+	ProvisioningDeadLettersName    = metricsNamespace + "_provisioning_dead_letters"
+	GroupMembersName               = metricsNamespace + "_group_members"
+	GroupMembershipAnomalyName     = metricsNamespace + "_group_membership_anomaly"
+	ProjectsCreatedName            = metricsNamespace + "_projects_created_total"
+	ProjectsDeletedName            = metricsNamespace + "_projects_deleted_total"
+	RoleBindingsCreatedName        = metricsNamespace + "_rolebindings_created_total"
+	ReconcileErrorsName            = metricsNamespace + "_reconcile_errors_total"
+	ReconcileDurationName          = metricsNamespace + "_reconcile_duration_seconds"
+	ReconcileTriggersName          = metricsNamespace + "_reconcile_triggers_total"
+	StepDurationName               = metricsNamespace + "_step_duration_seconds"
+	DryRunStepsName                = metricsNamespace + "_dry_run_steps_total"
+	StepRetriesName                = metricsNamespace + "_step_retries_total"
+	StuckDeletionsName             = metricsNamespace + "_stuck_deletions"
+	DeletionRetriesName            = metricsNamespace + "_deletion_retries_total"
+	ScheduledDeletionsName         = metricsNamespace + "_scheduled_deletions"
+	ManagedNamespacesName          = metricsNamespace + "_managed_namespaces"
+)
+
+// Operations counted by ReconcileErrors
+const (
+	OperationProvision   = "provision"
+	OperationDeprovision = "deprovision"
+)
+
+var (
+	// Registry holds every metric exported by the provisioner. It is shared with controller-runtime,
+	// which adds the Go runtime, process, reconcile and workqueue metrics.
+	Registry = ctrlmetrics.Registry
+
+	// ProvisioningDuration observes the time from a user appearing in the target group until
+	// their namespace is fully provisioned
+	ProvisioningDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    ProvisioningDurationName,
+		Help:    "Time from a user appearing in the target group until their namespace is fully provisioned.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	})
+
+	// ProvisioningSLOBreaches counts provisionings which took longer than the SLO target
+	ProvisioningSLOBreaches = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: ProvisioningSLOBreachesName,
+		Help: "Number of namespaces whose provisioning took longer than the SLO target.",
+	})
+
+	// IntegrationUp reports whether the last call to each optional integration succeeded
+	IntegrationUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: IntegrationUpName,
+		Help: "Whether the last call to the integration succeeded (1) or failed (0).",
+	}, []string{"integration"})
+
+	// IntegrationDisabled reports whether each optional integration is disabled after failing persistently
+	IntegrationDisabled = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: IntegrationDisabledName,
+		Help: "Whether the integration is disabled after failing persistently (1) or enabled (0).",
+	}, []string{"integration"})
+
+	// IntegrationFailures counts the failed calls to each optional integration
+	IntegrationFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: IntegrationFailuresName,
+		Help: "Number of failed calls to the integration.",
+	}, []string{"integration"})
+
+	// EstimatedHourlyCost reports the estimated hourly cost of each managed namespace
+	EstimatedHourlyCost = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: EstimatedHourlyCostName,
+		Help: "Estimated hourly cost in dollars of the resources requested in the managed namespace.",
+	}, []string{"user", "namespace"})
+
+	// ExternalCleanupDeadLetters reports the external cleanups of each integration which kept failing
+	// after every retry
+	ExternalCleanupDeadLetters = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: ExternalCleanupDeadLettersName,
+		Help: "Number of external cleanups of the integration which kept failing after every retry.",
+	}, []string{"integration"})
+
+	// ProvisioningDeadLetters reports the users whose provisioning kept failing and is only retried by
+	// admins
+	ProvisioningDeadLetters = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: ProvisioningDeadLettersName,
+		Help: "Number of users whose provisioning kept failing and waits to be retried by an admin.",
+	})
+
+	// GroupMembers reports the last observed number of users in each target group
+	GroupMembers = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: GroupMembersName,
+		Help: "Number of users in the target group when it was last observed.",
+	}, []string{"group"})
+
+	// GroupMembershipAnomaly reports whether deprovisioning is paused for each target group after an
+	// anomalous drop in its membership
+	GroupMembershipAnomaly = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: GroupMembershipAnomalyName,
+		Help: "Whether deprovisioning is paused after an anomalous drop in the group's membership (1) or not (0).",
+	}, []string{"group"})
+
+	// ProjectsCreated counts the projects created for users
+	ProjectsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: ProjectsCreatedName,
+		Help: "Number of projects created for users.",
+	})
+
+	// ProjectsDeleted counts the projects deleted when deprovisioning users
+	ProjectsDeleted = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: ProjectsDeletedName,
+		Help: "Number of projects deleted when deprovisioning users.",
+	})
+
+	// RoleBindingsCreated counts the RoleBindings created to grant users access to their project
+	RoleBindingsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: RoleBindingsCreatedName,
+		Help: "Number of RoleBindings created to grant users access to their project.",
+	})
+
+	// ReconcileErrors counts the users whose provisioning or deprovisioning failed, by what triggered
+	// it. Failures are logged and retried on the next change rather than returned to the reconcilers,
+	// so they don't show up in the controller-runtime reconcile errors.
+	ReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ReconcileErrorsName,
+		Help: "Number of users whose provisioning or deprovisioning failed.",
+	}, []string{"operation", "trigger"})
+
+	// ReconcileDuration observes the time each reconciler takes to handle a change, by what triggered
+	// it, e.g. an update or a resync
+	ReconcileDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    ReconcileDurationName,
+		Help:    "Time taken to handle a change of a target group, managed namespace or approval.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 30, 60, 120},
+	}, []string{"reconciler", "trigger"})
+
+	// ReconcileTriggers counts the users provisioned and deprovisioned by what triggered it, telling
+	// event-driven work from resyncs and periodic repairs
+	ReconcileTriggers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: ReconcileTriggersName,
+		Help: "Number of users provisioned or deprovisioned, by operation and trigger.",
+	}, []string{"operation", "trigger"})
+
+	// StepDuration observes the time each provisioning and deprovisioning step takes, by result
+	StepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    StepDurationName,
+		Help:    "Time taken by each provisioning and deprovisioning step.",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 15, 30, 60},
+	}, []string{"operation", "step", "result"})
+
+	// DryRunSteps counts the provisioning and deprovisioning steps a dry run skipped, i.e. the changes
+	// the controller would have made
+	DryRunSteps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: DryRunStepsName,
+		Help: "Number of provisioning and deprovisioning steps skipped by a dry run.",
+	}, []string{"operation", "step"})
+
+	// StepRetries counts the provisioning and deprovisioning steps attempted again after a transient
+	// error
+	StepRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: StepRetriesName,
+		Help: "Number of provisioning and deprovisioning steps retried after a transient error.",
+	}, []string{"operation", "step"})
+
+	// StuckDeletions reports the namespaces of deprovisioned users still present past the deletion
+	// verification timeout
+	StuckDeletions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: StuckDeletionsName,
+		Help: "Number of namespaces of deprovisioned users still present past the deletion verification timeout.",
+	})
+
+	// ScheduledDeletions reports the projects of removed users waiting for their grace period to end
+	ScheduledDeletions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: ScheduledDeletionsName,
+		Help: "Number of projects of removed users scheduled for deletion once their grace period ends.",
+	})
+
+	// DeletionRetries counts the project deletions retried because the namespace wasn't terminating
+	DeletionRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: DeletionRetriesName,
+		Help: "Number of project deletions retried because the namespace of a deprovisioned user wasn't terminating.",
+	})
+
+	// ManagedNamespaces reports the current number of namespaces managed by the provisioner
+	ManagedNamespaces = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: ManagedNamespacesName,
+		Help: "Number of namespaces currently managed by the provisioner.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		ProvisioningDuration,
+		ProvisioningSLOBreaches,
+		IntegrationUp,
+		IntegrationDisabled,
+		IntegrationFailures,
+		EstimatedHourlyCost,
+		ExternalCleanupDeadLetters,
+		ProvisioningDeadLetters,
+		GroupMembers,
+		GroupMembershipAnomaly,
+		ProjectsCreated,
+		ProjectsDeleted,
+		RoleBindingsCreated,
+		ReconcileErrors,
+		ReconcileDuration,
+		ReconcileTriggers,
+		StepDuration,
+		DryRunSteps,
+		StepRetries,
+		StuckDeletions,
+		DeletionRetries,
+		ScheduledDeletions,
+		ManagedNamespaces,
+	)
+}
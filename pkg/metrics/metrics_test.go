@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	ProvisioningDuration.Observe(42)
+	ProvisioningSLOBreaches.Inc()
+	ProjectsCreated.Inc()
+	ReconcileErrors.WithLabelValues(OperationProvision, "update").Inc()
+	ReconcileDuration.WithLabelValues("groups", "update").Observe(0.2)
+	ReconcileTriggers.WithLabelValues(OperationProvision, "update").Inc()
+	StepDuration.WithLabelValues(OperationProvision, "project", "succeeded").Observe(0.1)
+	DryRunSteps.WithLabelValues(OperationProvision, "project").Inc()
+	StepRetries.WithLabelValues(OperationProvision, "project").Inc()
+	ProvisioningDeadLetters.Set(1)
+
+	families, err := Registry.Gather()
+	if err != nil {
+		t.Fatalf("Expected metrics to be gathered, but got error: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, family := range families {
+		found[family.GetName()] = true
+	}
+	for _, name := range []string{
+		"rosa_namespace_provisioner_provisioning_duration_seconds",
+		"rosa_namespace_provisioner_provisioning_slo_breaches_total",
+		"rosa_namespace_provisioner_projects_created_total",
+		"rosa_namespace_provisioner_reconcile_errors_total",
+		"rosa_namespace_provisioner_reconcile_duration_seconds",
+		"rosa_namespace_provisioner_reconcile_triggers_total",
+		"rosa_namespace_provisioner_step_duration_seconds",
+		"rosa_namespace_provisioner_dry_run_steps_total",
+		"rosa_namespace_provisioner_step_retries_total",
+		"rosa_namespace_provisioner_provisioning_dead_letters",
+		"rosa_namespace_provisioner_managed_namespaces",
+	} {
+		if !found[name] {
+			t.Errorf("Expected metric %s to be registered", name)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"k8s.io/klog/v2"
+)
+
+// Digest batches the results of provisioning into periodic summary notifications
+type Digest struct {
+	notifier Notifier
+	interval time.Duration
+
+	mu      sync.Mutex
+	since   time.Time
+	created []string
+	deleted []string
+	failed  []string
+}
+
+// NewDigest creates a new Digest sending a summary through the notifier every interval
+func NewDigest(notifier Notifier, interval time.Duration) *Digest {
+	return &Digest{
+		notifier: notifier,
+		interval: interval,
+		since:    time.Now().UTC(),
+	}
+}
+
+// Add records the final result of provisioning or deprovisioning a user, ignoring step progress
+func (d *Digest) Add(event events.Event) {
+	if event.Step != "" || event.Result == events.ResultStarted {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case event.Result == events.ResultFailed:
+		d.failed = append(d.failed, fmt.Sprintf("%s %s: %s", event.Action, event.User, event.Message))
+	case event.Action == events.ActionProvision:
+		d.created = append(d.created, event.Namespace)
+	case event.Action == events.ActionDeprovision:
+		d.deleted = append(d.deleted, event.Namespace)
+	}
+}
+
+// Run collects events and sends a digest every interval until the context is cancelled,
+// sending the pending digest before returning
+func (d *Digest) Run(ctx context.Context, ch <-chan events.Event) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Send what was collected so far on shutdown
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			d.flush(flushCtx)
+			cancel()
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			d.Add(event)
+		case <-ticker.C:
+			d.flush(ctx)
+		}
+	}
+}
+
+// Sends the digest of the collected results, logging rather than returning delivery errors
+func (d *Digest) flush(ctx context.Context) {
+	if err := d.Flush(ctx); err != nil {
+		klog.Errorf("Error sending notification digest: %v", err)
+	}
+}
+
+// Flush sends the digest of the results collected since the last digest, if any
+func (d *Digest) Flush(ctx context.Context) error {
+	d.mu.Lock()
+	now := time.Now().UTC()
+	since := d.since
+	created, deleted, failed := d.created, d.deleted, d.failed
+	d.since = now
+	d.created, d.deleted, d.failed = nil, nil, nil
+	d.mu.Unlock()
+
+	if len(created) == 0 && len(deleted) == 0 && len(failed) == 0 {
+		return nil
+	}
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "Provisioning between %s and %s\n", since.Format(time.RFC3339), now.Format(time.RFC3339))
+	writeDigestSection(&message, "Created namespaces", created)
+	writeDigestSection(&message, "Deleted namespaces", deleted)
+	writeDigestSection(&message, "Failures", failed)
+
+	severity := SeverityInfo
+	if len(failed) > 0 {
+		severity = SeverityWarning
+	}
+	return d.notifier.Notify(ctx, Notification{
+		Time:     now,
+		Severity: severity,
+		Subject:  fmt.Sprintf("Provisioning digest: %d created, %d deleted, %d failed", len(created), len(deleted), len(failed)),
+		Message:  message.String(),
+	})
+}
+
+// Writes a titled list of digest entries, skipping empty lists
+func writeDigestSection(b *strings.Builder, title string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	sort.Strings(entries)
+	fmt.Fprintf(b, "\n%s (%d):\n", title, len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(b, "- %s\n", entry)
+	}
+}
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+)
+
+// recordingNotifier records the notifications it receives
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, notification Notification) error {
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func TestDigest_Flush(t *testing.T) {
+	notifier := &recordingNotifier{}
+	digest := NewDigest(notifier, 0)
+
+	for _, event := range []events.Event{
+		{User: "alice", Namespace: "alice", Action: events.ActionProvision, Result: events.ResultStarted},
+		{User: "alice", Namespace: "alice", Action: events.ActionProvision, Step: "project", Result: events.ResultSucceeded},
+		{User: "alice", Namespace: "alice", Action: events.ActionProvision, Result: events.ResultSucceeded},
+		{User: "bob", Namespace: "bob", Action: events.ActionDeprovision, Result: events.ResultSucceeded},
+		{User: "carol", Namespace: "carol", Action: events.ActionProvision, Result: events.ResultFailed, Message: "quota exceeded"},
+	} {
+		digest.Add(event)
+	}
+
+	if err := digest.Flush(context.Background()); err != nil {
+		t.Fatalf("Expected digest to be sent, but got error: %v", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Fatalf("Expected 1 digest, but got %d", len(notifier.notifications))
+	}
+
+	digestNotification := notifier.notifications[0]
+	if expected := "Provisioning digest: 1 created, 1 deleted, 1 failed"; digestNotification.Subject != expected {
+		t.Errorf("Expected subject %q, but got %q", expected, digestNotification.Subject)
+	}
+	if digestNotification.Severity != SeverityWarning {
+		t.Errorf("Expected a digest with failures to have severity %s, but got %s", SeverityWarning, digestNotification.Severity)
+	}
+	for _, expected := range []string{"- alice", "- bob", "- provision carol: quota exceeded"} {
+		if !strings.Contains(digestNotification.Message, expected) {
+			t.Errorf("Expected digest to contain %q, but got:\n%s", expected, digestNotification.Message)
+		}
+	}
+
+	// An empty digest is not sent
+	if err := digest.Flush(context.Background()); err != nil {
+		t.Fatalf("Expected empty digest to be skipped, but got error: %v", err)
+	}
+	if len(notifier.notifications) != 1 {
+		t.Errorf("Expected no further digest, but got %d", len(notifier.notifications))
+	}
+}
@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends notifications by email through an SMTP server, to the address of the namespace
+// owner or else at the configured domain, or to the fallback recipients for notifications without an
+// owner
+type EmailNotifier struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	domain   string
+	fallback []string
+	send     func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailNotifier creates a new EmailNotifier sending through the SMTP server at addr, authenticating
+// with PLAIN auth when a username is given
+func NewEmailNotifier(addr string, username string, password string, from string, domain string, fallback []string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{
+		addr:     addr,
+		auth:     auth,
+		from:     from,
+		domain:   domain,
+		fallback: fallback,
+		send:     smtp.SendMail,
+	}
+}
+
+// Returns the recipients of the notification
+func (e *EmailNotifier) recipients(notification Notification) []string {
+	if notification.Email != "" {
+		return []string{notification.Email}
+	}
+	if notification.User != "" && e.domain != "" {
+		return []string{fmt.Sprintf("%s@%s", notification.User, e.domain)}
+	}
+	return e.fallback
+}
+
+// Notify sends the notification by email, skipping notifications without recipients
+func (e *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	to := e.recipients(notification)
+	if len(to) == 0 {
+		return nil
+	}
+	if notification.Time.IsZero() {
+		notification.Time = time.Now().UTC()
+	}
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.ReplaceAll(notification.Subject, "\n", " "))
+	fmt.Fprintf(&msg, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(notification.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	// net/smtp doesn't accept a context, so honor cancellation before sending at least
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := e.send(e.addr, e.auth, e.from, to, []byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
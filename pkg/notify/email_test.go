@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification Notification
+		domain       string
+		want         []string
+	}{
+		{
+			name:         "owner at domain",
+			notification: Notification{User: "alice", Subject: "Namespace ready"},
+			domain:       "example.com",
+			want:         []string{"alice@example.com"},
+		},
+		{
+			name:         "address of the owner",
+			notification: Notification{User: "alice", Email: "alice.liddell@example.org", Subject: "Namespace ready"},
+			domain:       "example.com",
+			want:         []string{"alice.liddell@example.org"},
+		},
+		{
+			name:         "fallback without domain",
+			notification: Notification{User: "alice", Subject: "Namespace ready"},
+			want:         []string{"platform@example.com"},
+		},
+		{
+			name:         "fallback without owner",
+			notification: Notification{Subject: "Provisioning digest"},
+			domain:       "example.com",
+			want:         []string{"platform@example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var to []string
+			var msg string
+			notifier := NewEmailNotifier("smtp.example.com:587", "", "", "provisioner@example.com", tt.domain, []string{"platform@example.com"})
+			notifier.send = func(addr string, auth smtp.Auth, from string, recipients []string, body []byte) error {
+				to = recipients
+				msg = string(body)
+				return nil
+			}
+
+			if err := notifier.Notify(context.Background(), tt.notification); err != nil {
+				t.Fatalf("Expected email to be sent, but got error: %v", err)
+			}
+			if !reflect.DeepEqual(to, tt.want) {
+				t.Errorf("Expected email to be sent to %v, but got %v", tt.want, to)
+			}
+			if !strings.Contains(msg, "Subject: "+tt.notification.Subject+"\r\n") {
+				t.Errorf("Expected email to have subject %q, but got:\n%s", tt.notification.Subject, msg)
+			}
+		})
+	}
+}
+
+func TestEmailNotifier_NotifyWithoutRecipients(t *testing.T) {
+	notifier := NewEmailNotifier("smtp.example.com:587", "", "", "provisioner@example.com", "", nil)
+	notifier.send = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		t.Errorf("Expected no email to be sent, but sent to %v", to)
+		return nil
+	}
+	if err := notifier.Notify(context.Background(), Notification{User: "alice"}); err != nil {
+		t.Errorf("Expected notification without recipients to be skipped, but got error: %v", err)
+	}
+}
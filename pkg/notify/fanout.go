@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Provider is a notification channel along with the minimum severity it receives
+type Provider struct {
+	Name        string
+	Notifier    Notifier
+	MinSeverity string
+}
+
+// FanOut delivers every notification to each provider accepting its severity
+type FanOut struct {
+	providers []Provider
+}
+
+// NewFanOut creates a new FanOut delivering to the given providers
+func NewFanOut(providers ...Provider) *FanOut {
+	return &FanOut{providers: providers}
+}
+
+// Notify delivers the notification to every provider accepting its severity, returning the errors
+// of all providers that failed
+func (f *FanOut) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, provider := range f.providers {
+		if severityRank(notification.Severity) < severityRank(provider.MinSeverity) {
+			continue
+		}
+		if err := provider.Notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", provider.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// failingNotifier fails every notification
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(ctx context.Context, notification Notification) error {
+	return errors.New("unavailable")
+}
+
+func TestFanOut_Notify(t *testing.T) {
+	all := &recordingNotifier{}
+	errorsOnly := &recordingNotifier{}
+	fanOut := NewFanOut(
+		Provider{Name: "webhook", Notifier: all},
+		Provider{Name: "slack", Notifier: errorsOnly, MinSeverity: SeverityError},
+	)
+
+	for _, severity := range []string{SeverityInfo, SeverityWarning, SeverityError} {
+		if err := fanOut.Notify(context.Background(), Notification{User: "alice", Severity: severity}); err != nil {
+			t.Fatalf("Expected notification to be delivered, but got error: %v", err)
+		}
+	}
+	if len(all.notifications) != 3 {
+		t.Errorf("Expected provider without minimum severity to receive 3 notifications, but got %d", len(all.notifications))
+	}
+	if len(errorsOnly.notifications) != 1 || errorsOnly.notifications[0].Severity != SeverityError {
+		t.Errorf("Expected provider with minimum severity error to receive only the error, but got %+v", errorsOnly.notifications)
+	}
+}
+
+func TestFanOut_NotifyErrors(t *testing.T) {
+	delivered := &recordingNotifier{}
+	fanOut := NewFanOut(
+		Provider{Name: "email", Notifier: failingNotifier{}},
+		Provider{Name: "webhook", Notifier: delivered},
+	)
+
+	err := fanOut.Notify(context.Background(), Notification{User: "alice"})
+	if err == nil || !strings.Contains(err.Error(), "email: unavailable") {
+		t.Errorf("Expected the failing provider to be named in the error, but got %v", err)
+	}
+	if len(delivered.notifications) != 1 {
+		t.Errorf("Expected a failing provider not to block the others, but got %d notifications", len(delivered.notifications))
+	}
+}
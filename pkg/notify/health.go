@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+)
+
+// trackedNotifier records the health of the notifier it wraps and skips it while disabled
+type trackedNotifier struct {
+	notifier Notifier
+	tracker  *health.Tracker
+	name     string
+}
+
+// WithHealth wraps a notifier so every delivery is recorded under the given integration name,
+// and notifications are dropped while the integration is disabled
+func WithHealth(notifier Notifier, tracker *health.Tracker, name string) Notifier {
+	tracker.Register(name)
+	return &trackedNotifier{
+		notifier: notifier,
+		tracker:  tracker,
+		name:     name,
+	}
+}
+
+// Notify delivers the notification unless the integration is disabled
+func (t *trackedNotifier) Notify(ctx context.Context, notification Notification) error {
+	if !t.tracker.Enabled(t.name) {
+		return fmt.Errorf("integration %s is disabled after failing persistently", t.name)
+	}
+	err := t.notifier.Notify(ctx, notification)
+	t.tracker.Record(t.name, err)
+	return err
+}
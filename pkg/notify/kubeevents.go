@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// reason of the Events recorded for notifications
+const notificationReason = "ProvisionerNotification"
+
+// EventNotifier records notifications as Kubernetes Events on the namespace they are about, so they
+// show up in `oc get events` and the namespace's event list in the console
+type EventNotifier struct {
+	recorder record.EventRecorder
+}
+
+// NewEventNotifier creates a new EventNotifier recording Events through the given client
+func NewEventNotifier(client corev1client.EventsGetter, component string) *EventNotifier {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&corev1client.EventSinkImpl{Interface: client.Events("")})
+	return &EventNotifier{
+		recorder: broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component}),
+	}
+}
+
+// Notify records the notification as an Event on its namespace, skipping notifications without one
+func (e *EventNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.Namespace == "" {
+		return nil
+	}
+	eventType := corev1.EventTypeNormal
+	if notification.Severity == SeverityWarning || notification.Severity == SeverityError {
+		eventType = corev1.EventTypeWarning
+	}
+	namespace := &corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Namespace",
+		Name:       notification.Namespace,
+	}
+	e.recorder.Eventf(namespace, eventType, notificationReason, "%s: %s", notification.Subject, notification.Message)
+	return nil
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEventNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name         string
+		notification Notification
+		want         string
+	}{
+		{
+			name:         "info",
+			notification: Notification{Namespace: "alice", Severity: SeverityInfo, Subject: "Namespace ready", Message: "alice was provisioned"},
+			want:         "Normal ProvisionerNotification Namespace ready: alice was provisioned",
+		},
+		{
+			name:         "error",
+			notification: Notification{Namespace: "alice", Severity: SeverityError, Subject: "Provisioning failed", Message: "quota exceeded"},
+			want:         "Warning ProvisionerNotification Provisioning failed: quota exceeded",
+		},
+		{
+			name:         "without namespace",
+			notification: Notification{Severity: SeverityWarning, Subject: "Provisioning digest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			notifier := &EventNotifier{recorder: recorder}
+			if err := notifier.Notify(context.Background(), tt.notification); err != nil {
+				t.Fatalf("Expected Event to be recorded, but got error: %v", err)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if event != tt.want {
+					t.Errorf("Expected Event %q, but got %q", tt.want, event)
+				}
+			default:
+				if tt.want != "" {
+					t.Errorf("Expected Event %q to be recorded", tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+)
+
+// Headers of the requests of the lifecycle webhook
+const (
+	// LifecycleDeliveryHeader identifies a delivery, kept across its retries so receivers can skip
+	// duplicates
+	LifecycleDeliveryHeader = "X-Provisioner-Delivery"
+	// LifecycleTimestampHeader is the Unix time the request was signed at
+	LifecycleTimestampHeader = "X-Provisioner-Timestamp"
+	// LifecycleSignatureHeader is "sha256=" followed by the hex HMAC-SHA256 of the timestamp, a dot and
+	// the body under the shared key
+	LifecycleSignatureHeader = "X-Provisioner-Signature"
+)
+
+// number of lifecycle events queued for delivery before new ones are dropped
+const lifecycleQueueSize = 1024
+
+// longest wait between two attempts of a delivery
+const lifecycleMaxBackoff = time.Minute
+
+// LifecycleWebhook posts the final results of provisioning and deprovisioning users as signed JSON
+// events, retrying failed deliveries with exponential backoff
+type LifecycleWebhook struct {
+	url      string
+	key      []byte
+	attempts int
+	// backoff is the wait before the second attempt, doubled for every further one
+	backoff time.Duration
+	client  *http.Client
+}
+
+// NewLifecycleWebhook creates a new LifecycleWebhook posting to the given URL, signing events with the
+// key and attempting each delivery up to the given number of times
+func NewLifecycleWebhook(url string, key []byte, attempts int) *LifecycleWebhook {
+	return &LifecycleWebhook{
+		url:      url,
+		key:      key,
+		attempts: max(attempts, 1),
+		backoff:  time.Second,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Returns whether the event is a lifecycle event: the final result of provisioning or deprovisioning a
+// user, leaving out step progress and dry runs
+func lifecycleEvent(event events.Event) bool {
+	return event.Step == "" && event.Result != events.ResultStarted && !event.DryRun
+}
+
+// Signs the body sent at the timestamp under the key
+func signLifecycleEvent(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Run delivers the lifecycle events received on the channel in order until the context is cancelled.
+// Events are queued so slow deliveries don't drop them from the subscription.
+func (w *LifecycleWebhook) Run(ctx context.Context, ch <-chan events.Event) {
+	queue := make(chan events.Event, lifecycleQueueSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range queue {
+			if ctx.Err() != nil {
+				return
+			}
+			if err := w.Deliver(ctx, event); err != nil {
+				klog.Errorf("Error delivering the %s event of user %s to the lifecycle webhook: %v", event.Action, event.User, err)
+			}
+		}
+	}()
+	defer func() {
+		close(queue)
+		<-done
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if !lifecycleEvent(event) {
+				continue
+			}
+			select {
+			case queue <- event:
+			default:
+				klog.Errorf("Dropping the %s event of user %s, the lifecycle webhook is %d events behind", event.Action, event.User, lifecycleQueueSize)
+			}
+		}
+	}
+}
+
+// Deliver posts the event to the webhook, retrying on network errors, 429 and 5xx responses
+func (w *LifecycleWebhook) Deliver(ctx context.Context, event events.Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode lifecycle event: %w", err)
+	}
+	delivery := string(uuid.NewUUID())
+
+	backoff := w.backoff
+	for attempt := 1; ; attempt++ {
+		retryable, err := w.post(ctx, delivery, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= w.attempts {
+			return fmt.Errorf("delivery %s failed after %d attempts: %w", delivery, attempt, err)
+		}
+		klog.V(2).Infof("Retrying delivery %s to the lifecycle webhook in %s: %v", delivery, backoff, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, lifecycleMaxBackoff)
+	}
+}
+
+// Posts a single attempt of the delivery, returning whether a failure may succeed when retried
+func (w *LifecycleWebhook) post(ctx context.Context, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create lifecycle event request: %w", err)
+	}
+	// signed per attempt so a retry isn't rejected as a replay
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(LifecycleDeliveryHeader, delivery)
+	req.Header.Set(LifecycleTimestampHeader, timestamp)
+	req.Header.Set(LifecycleSignatureHeader, signLifecycleEvent(w.key, timestamp, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to send lifecycle event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retryable, fmt.Errorf("lifecycle webhook returned status %d", resp.StatusCode)
+	}
+	return false, nil
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/events"
+)
+
+func TestLifecycleWebhook_Deliver(t *testing.T) {
+	key := []byte("s3cr3t")
+	var mu sync.Mutex
+	var deliveries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		deliveries = append(deliveries, r.Header.Get(LifecycleDeliveryHeader))
+		// the first attempt fails transiently
+		if len(deliveries) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		expected := signLifecycleEvent(key, r.Header.Get(LifecycleTimestampHeader), body)
+		if got := r.Header.Get(LifecycleSignatureHeader); got != expected {
+			t.Errorf("Expected signature %s, but got %s", expected, got)
+		}
+		var event events.Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("Failed to decode lifecycle event: %v", err)
+		}
+		if event.User != "alice" || event.Action != events.ActionProvision || event.Time.IsZero() {
+			t.Errorf("Unexpected lifecycle event received: %+v", event)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewLifecycleWebhook(server.URL, key, 3)
+	webhook.backoff = time.Millisecond
+	err := webhook.Deliver(context.Background(), events.Event{User: "alice", Namespace: "alice", Action: events.ActionProvision, Result: events.ResultSucceeded})
+	if err != nil {
+		t.Fatalf("Expected the event to be delivered, but got error: %v", err)
+	}
+	if len(deliveries) != 2 || deliveries[0] == "" || deliveries[0] != deliveries[1] {
+		t.Errorf("Expected a retry of the same delivery, but got %v", deliveries)
+	}
+}
+
+func TestLifecycleWebhook_DeliverPermanentError(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhook := NewLifecycleWebhook(server.URL, []byte("s3cr3t"), 3)
+	webhook.backoff = time.Millisecond
+	if err := webhook.Deliver(context.Background(), events.Event{User: "alice"}); err == nil {
+		t.Error("Expected an error for a rejected event")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected a rejected event not to be retried, but got %d attempts", attempts)
+	}
+}
+
+func TestLifecycleWebhook_Run(t *testing.T) {
+	received := make(chan events.Event, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event events.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("Failed to decode lifecycle event: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan events.Event, 4)
+	ch <- events.Event{User: "alice", Action: events.ActionProvision, Result: events.ResultStarted}
+	ch <- events.Event{User: "alice", Action: events.ActionProvision, Step: "project", Result: events.ResultSucceeded}
+	ch <- events.Event{User: "bob", Action: events.ActionDeprovision, Result: events.ResultSucceeded, DryRun: true}
+	ch <- events.Event{User: "carol", Action: events.ActionDeprovision, Result: events.ResultFailed}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		NewLifecycleWebhook(server.URL, []byte("s3cr3t"), 1).Run(ctx, ch)
+	}()
+
+	select {
+	case event := <-received:
+		if event.User != "carol" || event.Result != events.ResultFailed {
+			t.Errorf("Expected only the final result of carol to be delivered, but got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a lifecycle event to be delivered")
+	}
+	cancel()
+	<-done
+	if len(received) != 0 {
+		t.Errorf("Expected a single lifecycle event, but got %d more", len(received))
+	}
+}
@@ -0,0 +1,101 @@
+// Package notify delivers notifications about provisioning to external channels
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Severities of notifications, in increasing order
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Notification is a message about a user's namespace
+type Notification struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Namespace string    `json:"namespace,omitempty"`
+	Severity  string    `json:"severity,omitempty"`
+	Subject   string    `json:"subject"`
+	Message   string    `json:"message"`
+	// Email is the address of the user, left to the email provider to derive when empty
+	Email string `json:"email,omitempty"`
+}
+
+// Notifier delivers notifications through a single channel. New channels implement it and are
+// added to a FanOut without changes to the code sending notifications.
+type Notifier interface {
+	Notify(ctx context.Context, notification Notification) error
+}
+
+// Returns the rank of a severity for comparisons, treating unknown severities as info
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityWarning:
+		return 1
+	case SeverityError:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// ValidSeverity returns whether the severity is one of the known severities
+func ValidSeverity(severity string) bool {
+	switch severity {
+	case SeverityInfo, SeverityWarning, SeverityError:
+		return true
+	}
+	return false
+}
+
+// WebhookNotifier posts notifications as JSON to a webhook endpoint
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a new WebhookNotifier posting to the given URL
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify posts the notification to the webhook endpoint
+func (w *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	if notification.Time.IsZero() {
+		notification.Time = time.Now().UTC()
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
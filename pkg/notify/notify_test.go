@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/health"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Expected POST request, but got %s", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Expected Content-Type application/json, but got %s", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+	err := notifier.Notify(context.Background(), Notification{
+		User:    "alice",
+		Subject: "Quota usage high",
+		Message: "pods at 90%",
+	})
+	if err != nil {
+		t.Fatalf("Expected notification to be sent, but got error: %v", err)
+	}
+	if received.User != "alice" || received.Subject != "Quota usage high" {
+		t.Errorf("Unexpected notification received: %+v", received)
+	}
+	if received.Time.IsZero() {
+		t.Error("Expected notification time to be set")
+	}
+}
+
+func TestWebhookNotifier_NotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.URL)
+	if err := notifier.Notify(context.Background(), Notification{User: "alice"}); err == nil {
+		t.Error("Expected an error for a failing webhook")
+	}
+}
+
+func TestWithHealth(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tracker := health.NewTracker(1, time.Hour)
+	notifier := WithHealth(NewWebhookNotifier(server.URL), tracker, "notifications")
+
+	if err := notifier.Notify(context.Background(), Notification{User: "alice"}); err == nil {
+		t.Fatalf("Expected failing webhook to return an error")
+	}
+	if err := notifier.Notify(context.Background(), Notification{User: "alice"}); err == nil {
+		t.Fatalf("Expected disabled integration to return an error")
+	}
+	if calls != 1 {
+		t.Errorf("Expected webhook to be skipped once disabled, but it was called %d times", calls)
+	}
+}
@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// slackEscaper escapes the control characters of Slack messages, so user names and API errors can't
+// mention channels or break the formatting
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// SlackNotifier posts notifications to a Slack incoming webhook
+type SlackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlackNotifier creates a new SlackNotifier posting to the given incoming webhook URL
+func NewSlackNotifier(url string) *SlackNotifier {
+	return &SlackNotifier{
+		url: url,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify posts the notification as a Slack message
+func (s *SlackNotifier) Notify(ctx context.Context, notification Notification) error {
+	text := fmt.Sprintf("*%s*\n%s", escapeSlack(notification.Subject), escapeSlack(notification.Message))
+	if notification.Severity == SeverityError {
+		text = ":rotating_light: " + text
+	} else if notification.Severity == SeverityWarning {
+		text = ":warning: " + text
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("failed to encode Slack message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Slack message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Returns the text with the control characters of Slack messages escaped
+func escapeSlack(text string) string {
+	return slackEscaper.Replace(text)
+}
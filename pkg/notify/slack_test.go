@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotifier_Notify(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode Slack message: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	err := notifier.Notify(context.Background(), Notification{
+		User:     "alice",
+		Severity: SeverityWarning,
+		Subject:  "Quota usage high",
+		Message:  "pods at 90%",
+	})
+	if err != nil {
+		t.Fatalf("Expected Slack message to be sent, but got error: %v", err)
+	}
+	if text := received["text"]; !strings.HasPrefix(text, ":warning: *Quota usage high*") || !strings.Contains(text, "pods at 90%") {
+		t.Errorf("Unexpected Slack message received: %q", text)
+	}
+}
+
+func TestSlackNotifier_NotifyEscapes(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode Slack message: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	err := notifier.Notify(context.Background(), Notification{
+		User:     "alice",
+		Severity: SeverityError,
+		Subject:  "Namespace alice provision failed",
+		Message:  "quota <!channel> exceeded & denied",
+	})
+	if err != nil {
+		t.Fatalf("Expected Slack message to be sent, but got error: %v", err)
+	}
+	if text := received["text"]; !strings.Contains(text, "quota &lt;!channel&gt; exceeded &amp; denied") {
+		t.Errorf("Expected the control characters to be escaped, but got %q", text)
+	}
+}
+
+func TestSlackNotifier_NotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	notifier := NewSlackNotifier(server.URL)
+	if err := notifier.Notify(context.Background(), Notification{User: "alice"}); err == nil {
+		t.Error("Expected an error for a failing Slack webhook")
+	}
+}
@@ -0,0 +1,276 @@
+// Package observability generates the Grafana dashboard and Prometheus alerts of the provisioner
+// from the metric names and thresholds defined in code
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+// name of the generated PrometheusRule and title of the generated dashboard
+const name = "rosa-namespace-provisioner"
+
+// Options configures the thresholds of the generated dashboard and alerts
+type Options struct {
+	// SLOTarget is the provisioning latency above which the SLO counts as breached
+	SLOTarget time.Duration
+	// Namespace is the namespace of the generated PrometheusRule
+	Namespace string
+}
+
+// rule is a Prometheus alerting rule
+type rule struct {
+	Alert       string            `json:"alert"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Returns the alerting rules of the provisioner
+func alertRules(opts Options) []rule {
+	return []rule{
+		{
+			Alert: "RosaNamespaceProvisionerSLOBreached",
+			Expr:  fmt.Sprintf("increase(%s[1h]) > 0", metrics.ProvisioningSLOBreachesName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Namespace provisioning breached its SLO",
+				"description": fmt.Sprintf("{{ $value | humanize }} namespaces took longer than %s to provision in the last hour.", opts.SLOTarget),
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerLatencyHigh",
+			Expr: fmt.Sprintf("histogram_quantile(0.95, sum by (le) (rate(%s_bucket[30m]))) > %g",
+				metrics.ProvisioningDurationName,
+				opts.SLOTarget.Seconds(),
+			),
+			For: "15m",
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Namespace provisioning is slow",
+				"description": fmt.Sprintf("The 95th percentile of provisioning latency is {{ $value | humanizeDuration }}, above the SLO target of %s.", opts.SLOTarget),
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerReconcileErrors",
+			Expr:  fmt.Sprintf("sum by (operation) (increase(%s[15m])) > 0", metrics.ReconcileErrorsName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Users fail to {{ $labels.operation }}",
+				"description": "The {{ $labels.operation }} of {{ $value | humanize }} users failed in the last 15 minutes.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerIntegrationDisabled",
+			Expr:  fmt.Sprintf("max by (integration) (%s) == 1", metrics.IntegrationDisabledName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Integration {{ $labels.integration }} is disabled",
+				"description": "The {{ $labels.integration }} integration failed persistently and is skipped until its cool-down has passed.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerIntegrationFailing",
+			Expr:  fmt.Sprintf("max by (integration) (%s) == 0", metrics.IntegrationUpName),
+			For:   "15m",
+			Labels: map[string]string{
+				"severity": "info",
+			},
+			Annotations: map[string]string{
+				"summary":     "Integration {{ $labels.integration }} is failing",
+				"description": "The last calls to the {{ $labels.integration }} integration failed for 15 minutes.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerExternalCleanupDeadLetters",
+			Expr:  fmt.Sprintf("max by (integration) (%s) > 0", metrics.ExternalCleanupDeadLettersName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "External cleanups of {{ $labels.integration }} are dead-lettered",
+				"description": "{{ $value }} cleanups of the {{ $labels.integration }} integration kept failing after every retry and need to be retried through the admin API.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerProvisioningDeadLetters",
+			Expr:  fmt.Sprintf("max(%s) > 0", metrics.ProvisioningDeadLettersName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Provisioning of users is dead-lettered",
+				"description": "The provisioning of {{ $value }} users kept failing and is only retried through the admin API or a reconcile annotation, see the ProvisioningDeadLettered Events.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerGroupMembershipAnomaly",
+			Expr:  fmt.Sprintf("max by (group) (%s) == 1", metrics.GroupMembershipAnomalyName),
+			Labels: map[string]string{
+				"severity": "critical",
+			},
+			Annotations: map[string]string{
+				"summary":     "Membership of group {{ $labels.group }} dropped anomalously",
+				"description": "Deprovisioning of users removed from {{ $labels.group }} is paused until an admin checks the identity provider sync and acknowledges the drop.",
+			},
+		},
+		{
+			Alert: "RosaNamespaceProvisionerDeletionStuck",
+			Expr:  fmt.Sprintf("max(%s) > 0", metrics.StuckDeletionsName),
+			Labels: map[string]string{
+				"severity": "warning",
+			},
+			Annotations: map[string]string{
+				"summary":     "Namespaces of deprovisioned users are not being deleted",
+				"description": "{{ $value }} namespaces of deprovisioned users are still present past the verification timeout, see the DeletionStuck Events.",
+			},
+		},
+	}
+}
+
+// WritePrometheusRule writes the alerting rules as a PrometheusRule of the Prometheus operator
+func WritePrometheusRule(w io.Writer, opts Options) error {
+	prometheusRule := map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": opts.Namespace,
+		},
+		"spec": map[string]interface{}{
+			"groups": []interface{}{
+				map[string]interface{}{
+					"name":  name,
+					"rules": alertRules(opts),
+				},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(prometheusRule)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Returns a Grafana timeseries panel plotting the given queries
+func panel(id int, title string, unit string, x int, y int, targets ...map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         id,
+		"type":       "timeseries",
+		"title":      title,
+		"datasource": map[string]interface{}{"type": "prometheus", "uid": "${datasource}"},
+		"gridPos":    map[string]interface{}{"h": 8, "w": 12, "x": x, "y": y},
+		"fieldConfig": map[string]interface{}{
+			"defaults":  map[string]interface{}{"unit": unit},
+			"overrides": []interface{}{},
+		},
+		"targets": targets,
+	}
+}
+
+// Returns a Grafana query target
+func target(refID string, expr string, legend string) map[string]interface{} {
+	return map[string]interface{}{
+		"refId":        refID,
+		"expr":         expr,
+		"legendFormat": legend,
+	}
+}
+
+// WriteDashboard writes the Grafana dashboard of the provisioner as JSON
+func WriteDashboard(w io.Writer, opts Options) error {
+	latency := panel(1, "Provisioning latency", "s", 0, 0,
+		target("A", fmt.Sprintf("histogram_quantile(0.5, sum by (le) (rate(%s_bucket[$__rate_interval])))", metrics.ProvisioningDurationName), "p50"),
+		target("B", fmt.Sprintf("histogram_quantile(0.95, sum by (le) (rate(%s_bucket[$__rate_interval])))", metrics.ProvisioningDurationName), "p95"),
+		target("C", fmt.Sprintf("vector(%g)", opts.SLOTarget.Seconds()), "SLO target"),
+	)
+	latency["fieldConfig"].(map[string]interface{})["defaults"].(map[string]interface{})["thresholds"] = map[string]interface{}{
+		"mode": "absolute",
+		"steps": []interface{}{
+			map[string]interface{}{"color": "green", "value": nil},
+			map[string]interface{}{"color": "red", "value": opts.SLOTarget.Seconds()},
+		},
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         name,
+		"uid":           name,
+		"schemaVersion": 39,
+		"editable":      true,
+		"time":          map[string]interface{}{"from": "now-24h", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+			},
+		},
+		"panels": []interface{}{
+			latency,
+			panel(2, "Provisionings per hour", "short", 12, 0,
+				target("A", fmt.Sprintf("sum(increase(%s_count[1h]))", metrics.ProvisioningDurationName), "provisioned"),
+				target("B", fmt.Sprintf("sum(increase(%s[1h]))", metrics.ProvisioningSLOBreachesName), "SLO breaches"),
+			),
+			panel(3, "Integration health", "short", 0, 8,
+				target("A", fmt.Sprintf("max by (integration) (%s)", metrics.IntegrationUpName), "{{integration}} up"),
+				target("B", fmt.Sprintf("max by (integration) (%s)", metrics.IntegrationDisabledName), "{{integration}} disabled"),
+			),
+			panel(4, "Integration failures", "short", 12, 8,
+				target("A", fmt.Sprintf("sum by (integration) (rate(%s[$__rate_interval]))", metrics.IntegrationFailuresName), "{{integration}}"),
+			),
+			panel(5, "Estimated hourly cost by user", "currencyUSD", 0, 16,
+				target("A", fmt.Sprintf("topk(10, sum by (user) (%s))", metrics.EstimatedHourlyCostName), "{{user}}"),
+			),
+			panel(6, "Estimated hourly cost", "currencyUSD", 12, 16,
+				target("A", fmt.Sprintf("sum(%s)", metrics.EstimatedHourlyCostName), "total"),
+			),
+			panel(7, "Dead-lettered external cleanups", "short", 0, 24,
+				target("A", fmt.Sprintf("max by (integration) (%s)", metrics.ExternalCleanupDeadLettersName), "{{integration}}"),
+			),
+			panel(8, "Target group members", "short", 12, 24,
+				target("A", fmt.Sprintf("max by (group) (%s)", metrics.GroupMembersName), "{{group}}"),
+				target("B", fmt.Sprintf("max by (group) (%s)", metrics.GroupMembershipAnomalyName), "{{group}} anomaly"),
+			),
+			panel(9, "Managed namespaces", "short", 0, 32,
+				target("A", fmt.Sprintf("max(%s)", metrics.ManagedNamespacesName), "managed"),
+			),
+			panel(10, "Operations per hour", "short", 12, 32,
+				target("A", fmt.Sprintf("sum(increase(%s[1h]))", metrics.ProjectsCreatedName), "projects created"),
+				target("B", fmt.Sprintf("sum(increase(%s[1h]))", metrics.ProjectsDeletedName), "projects deleted"),
+				target("C", fmt.Sprintf("sum(increase(%s[1h]))", metrics.RoleBindingsCreatedName), "RoleBindings created"),
+				target("D", fmt.Sprintf("sum by (operation) (increase(%s[1h]))", metrics.ReconcileErrorsName), "{{operation}} errors"),
+			),
+			panel(11, "Reconcile duration (p95)", "s", 0, 40,
+				target("A", fmt.Sprintf("histogram_quantile(0.95, sum by (le, reconciler) (rate(%s_bucket[$__rate_interval])))", metrics.ReconcileDurationName), "{{reconciler}}"),
+			),
+			panel(12, "Reconciles by trigger", "short", 12, 40,
+				target("A", fmt.Sprintf("sum by (reconciler, trigger) (rate(%s_count[$__rate_interval]))", metrics.ReconcileDurationName), "{{reconciler}} {{trigger}}"),
+				target("B", fmt.Sprintf("sum by (operation, trigger) (rate(%s[$__rate_interval]))", metrics.ReconcileTriggersName), "{{operation}} {{trigger}}"),
+			),
+		},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dashboard)
+}
@@ -0,0 +1,106 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/metrics"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWritePrometheusRule(t *testing.T) {
+	var out bytes.Buffer
+	if err := WritePrometheusRule(&out, Options{SLOTarget: 2 * time.Minute, Namespace: "monitoring"}); err != nil {
+		t.Fatalf("Expected PrometheusRule to be written, but got error: %v", err)
+	}
+
+	var rule struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+		Spec struct {
+			Groups []struct {
+				Rules []rule `json:"rules"`
+			} `json:"groups"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(out.Bytes(), &rule); err != nil {
+		t.Fatalf("Expected PrometheusRule to be valid YAML, but got error: %v", err)
+	}
+	if rule.Kind != "PrometheusRule" || rule.Metadata.Namespace != "monitoring" {
+		t.Errorf("Expected a PrometheusRule in namespace monitoring, but got %s in %s", rule.Kind, rule.Metadata.Namespace)
+	}
+	if len(rule.Spec.Groups) != 1 || len(rule.Spec.Groups[0].Rules) == 0 {
+		t.Fatalf("Expected a single group of alerting rules, but got %+v", rule.Spec.Groups)
+	}
+
+	exprs := make(map[string]string)
+	for _, alert := range rule.Spec.Groups[0].Rules {
+		exprs[alert.Alert] = alert.Expr
+	}
+	if expr := exprs["RosaNamespaceProvisionerLatencyHigh"]; !strings.Contains(expr, metrics.ProvisioningDurationName+"_bucket") || !strings.HasSuffix(expr, "> 120") {
+		t.Errorf("Expected the latency alert to use the SLO target of 120s, but got %q", expr)
+	}
+	if expr := exprs["RosaNamespaceProvisionerReconcileErrors"]; !strings.Contains(expr, metrics.ReconcileErrorsName) {
+		t.Errorf("Expected the reconcile errors alert to use %s, but got %q", metrics.ReconcileErrorsName, expr)
+	}
+	if expr := exprs["RosaNamespaceProvisionerIntegrationDisabled"]; !strings.Contains(expr, metrics.IntegrationDisabledName) {
+		t.Errorf("Expected the integration alert to use %s, but got %q", metrics.IntegrationDisabledName, expr)
+	}
+}
+
+func TestWriteDashboard(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteDashboard(&out, Options{SLOTarget: 5 * time.Minute}); err != nil {
+		t.Fatalf("Expected dashboard to be written, but got error: %v", err)
+	}
+
+	var dashboard struct {
+		Panels []struct {
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &dashboard); err != nil {
+		t.Fatalf("Expected dashboard to be valid JSON, but got error: %v", err)
+	}
+
+	// Every exported metric is plotted
+	var exprs []string
+	for _, panel := range dashboard.Panels {
+		for _, target := range panel.Targets {
+			exprs = append(exprs, target.Expr)
+		}
+	}
+	joined := strings.Join(exprs, "\n")
+	for _, name := range []string{
+		metrics.ProvisioningDurationName,
+		metrics.ProvisioningSLOBreachesName,
+		metrics.IntegrationUpName,
+		metrics.IntegrationDisabledName,
+		metrics.IntegrationFailuresName,
+		metrics.EstimatedHourlyCostName,
+		metrics.ExternalCleanupDeadLettersName,
+		metrics.GroupMembersName,
+		metrics.GroupMembershipAnomalyName,
+		metrics.ProjectsCreatedName,
+		metrics.ProjectsDeletedName,
+		metrics.RoleBindingsCreatedName,
+		metrics.ReconcileErrorsName,
+		metrics.ReconcileDurationName,
+		metrics.ReconcileTriggersName,
+		metrics.ManagedNamespacesName,
+	} {
+		if !strings.Contains(joined, name) {
+			t.Errorf("Expected dashboard to plot %s", name)
+		}
+	}
+	if !strings.Contains(joined, "vector(300)") {
+		t.Errorf("Expected dashboard to plot the SLO target of 300s, but got:\n%s", joined)
+	}
+}
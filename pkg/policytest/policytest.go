@@ -0,0 +1,355 @@
+// Package policytest checks the provisioning policies of a configuration against declarative
+// fixtures. Each fixture changes the members of a target group on top of existing cluster objects,
+// runs the controller's diff engine against in-memory fake clientsets and asserts the actions it
+// takes, so admins can validate their configuration before rolling it out.
+package policytest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	projectv1 "github.com/openshift/api/project/v1"
+	quotav1 "github.com/openshift/api/quota/v1"
+	userv1 "github.com/openshift/api/user/v1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/apis/v1alpha1"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/controller"
+	"github.com/redhat-ai-dev/rosa-namespace-provisioner/pkg/fakecluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	clienttesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+// Fixture is a change to the members of a target group and the actions the controller is expected
+// to take in response
+type Fixture struct {
+	// Name describes the fixture, defaulting to its file name
+	Name string `json:"name,omitempty"`
+	// Env overrides the configuration of the controller for this fixture
+	Env map[string]string `json:"env,omitempty"`
+	// Group is the target group changed, defaulting to the first target group
+	Group string `json:"group,omitempty"`
+	// Before lists the members of the group before the change
+	Before []string `json:"before,omitempty"`
+	// After lists the members of the group after the change
+	After []string `json:"after,omitempty"`
+	// Objects are the manifests of the cluster objects existing before the change
+	Objects []runtime.RawExtension `json:"objects,omitempty"`
+	// Expect lists the actions which must be taken
+	Expect []Action `json:"expect,omitempty"`
+	// Reject lists the actions which must not be taken
+	Reject []Action `json:"reject,omitempty"`
+}
+
+// Action is a write request the controller sends to the API server, with the apply verb for
+// server-side apply patches. An empty namespace or name matches any.
+type Action struct {
+	Verb      string `json:"verb"`
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+// String returns the action as "<verb> <resource> [<namespace>/]<name>"
+func (a Action) String() string {
+	name := a.Name
+	if name == "" {
+		name = "*"
+	}
+	if a.Namespace != "" {
+		name = a.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", a.Verb, a.Resource, name)
+}
+
+// Returns whether any of the taken actions matches the asserted one
+func (a Action) matchesAnyOf(taken []Action) bool {
+	for _, action := range taken {
+		if a.matches(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether a taken action matches the asserted one
+func (a Action) matches(taken Action) bool {
+	return a.Verb == taken.Verb &&
+		a.Resource == taken.Resource &&
+		(a.Namespace == "" || a.Namespace == taken.Namespace) &&
+		(a.Name == "" || a.Name == taken.Name)
+}
+
+// Result is the outcome of running a fixture
+type Result struct {
+	Name string
+	// ConfigErrors lists the invalid values of the configuration under test
+	ConfigErrors []string
+	// Actions lists the write requests the controller sent, in the order sent through each clientset
+	Actions []Action
+	// Missing lists the expected actions which were not taken
+	Missing []Action
+	// Rejected lists the taken actions which the fixture rejects
+	Rejected []Action
+}
+
+// Passed returns whether the configuration is valid and every assertion of the fixture holds
+func (r *Result) Passed() bool {
+	return len(r.ConfigErrors) == 0 && len(r.Missing) == 0 && len(r.Rejected) == 0
+}
+
+// LoadFixtures reads the fixtures of a YAML or JSON file, or of every such file in a directory
+// sorted by name
+func LoadFixtures(path string) ([]*Fixture, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = nil
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml", ".json":
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	fixtures := make([]*Fixture, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fixture := &Fixture{}
+		if err := yaml.UnmarshalStrict(data, fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", file, err)
+		}
+		if fixture.Name == "" {
+			fixture.Name = filepath.Base(file)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// Scheme decoding the manifests of existing objects
+var objectScheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(scheme.AddToScheme(objectScheme))
+	utilruntime.Must(userv1.Install(objectScheme))
+	utilruntime.Must(projectv1.Install(objectScheme))
+	utilruntime.Must(quotav1.Install(objectScheme))
+}
+
+// Run applies the group change of the fixture to fake clientsets holding its existing objects and
+// asserts the actions the controller took. The configuration is read from the environment with the
+// overrides of the fixture, so fixtures must not run concurrently.
+func Run(fixture *Fixture) (*Result, error) {
+	restore := setEnv(fixture.Env)
+	defer restore()
+
+	result := &Result{Name: fixture.Name}
+	if err := controller.ValidateConfig(); err != nil {
+		result.ConfigErrors = strings.Split(err.Error(), "\n")
+	}
+
+	groups := controller.GetTargetGroupNames()
+	groupName := fixture.Group
+	if groupName == "" {
+		groupName = groups[0]
+	}
+	clients := fakecluster.NewClients(groups, nil)
+	for i, raw := range fixture.Objects {
+		if err := addObject(clients, raw.Raw); err != nil {
+			return nil, fmt.Errorf("invalid object %d of fixture %s: %w", i+1, fixture.Name, err)
+		}
+	}
+	before := &userv1.Group{
+		ObjectMeta: metav1.ObjectMeta{Name: groupName, ResourceVersion: "1"},
+		Users:      fixture.Before,
+	}
+	after := before.DeepCopy()
+	after.ResourceVersion = "2"
+	after.Users = fixture.After
+	if err := addGroup(clients, after); err != nil {
+		return nil, fmt.Errorf("failed to add group %s: %w", groupName, err)
+	}
+	for _, recorder := range []interface{ ClearActions() }{clients.User, clients.Project, clients.Quota, clients.Kube, clients.Dynamic} {
+		recorder.ClearActions()
+	}
+
+	ctrl := controller.NewController(clients.User, clients.Project, clients.Kube.RbacV1(), clients.Quota, clients.Kube.CoreV1(), clients.Dynamic,
+		controller.WithNetworkingClient(clients.Kube.NetworkingV1()))
+	ctrl.HandleGroupChange(before, after)
+
+	result.Actions = takenActions(clients)
+	for _, expected := range fixture.Expect {
+		if !expected.matchesAnyOf(result.Actions) {
+			result.Missing = append(result.Missing, expected)
+		}
+	}
+	for _, taken := range result.Actions {
+		if matchesAny(fixture.Reject, taken) {
+			result.Rejected = append(result.Rejected, taken)
+		}
+	}
+	return result, nil
+}
+
+// Sets the environment variables and returns a function restoring their previous values
+func setEnv(env map[string]string) func() {
+	previous := make(map[string]*string, len(env))
+	for name, value := range env {
+		if old, ok := os.LookupEnv(name); ok {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		_ = os.Setenv(name, value)
+	}
+	return func() {
+		for name, old := range previous {
+			if old == nil {
+				_ = os.Unsetenv(name)
+			} else {
+				_ = os.Setenv(name, *old)
+			}
+		}
+	}
+}
+
+// Adds an existing object to the fake clientset serving it. Projects are added as the namespaces
+// backing them.
+func addObject(clients *fakecluster.Clients, manifest []byte) error {
+	obj, _, err := serializer.NewCodecFactory(objectScheme).UniversalDeserializer().Decode(manifest, nil, nil)
+	if runtime.IsNotRegisteredError(err) {
+		custom := &unstructured.Unstructured{}
+		if err := custom.UnmarshalJSON(manifest); err != nil {
+			return err
+		}
+		if custom.GroupVersionKind().GroupVersion() != v1alpha1.SchemeGroupVersion {
+			return fmt.Errorf("unsupported kind %s", custom.GroupVersionKind())
+		}
+		return clients.Dynamic.Tracker().Add(custom)
+	} else if err != nil {
+		return err
+	}
+
+	switch obj := obj.(type) {
+	case *userv1.Group:
+		return addGroup(clients, obj)
+	case *userv1.User:
+		return clients.User.Tracker().Add(obj)
+	case *quotav1.ClusterResourceQuota:
+		return clients.Quota.Tracker().Add(obj)
+	case *projectv1.Project:
+		return clients.Kube.Tracker().Add(&corev1.Namespace{
+			ObjectMeta: obj.ObjectMeta,
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		})
+	}
+	if obj.GetObjectKind().GroupVersionKind().Group == quotav1.GroupName {
+		return fmt.Errorf("unsupported kind %s", obj.GetObjectKind().GroupVersionKind())
+	}
+	return clients.Kube.Tracker().Add(obj)
+}
+
+// Adds a group, replacing the empty target group of the same name
+func addGroup(clients *fakecluster.Clients, group *userv1.Group) error {
+	groups := clients.User.UserV1().Groups()
+	_, err := groups.Update(context.Background(), group, metav1.UpdateOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = groups.Create(context.Background(), group, metav1.CreateOptions{})
+	}
+	return err
+}
+
+// Returns the write requests sent through the fake clientsets, leaving out Events and the namespace
+// writes backing project writes
+func takenActions(clients *fakecluster.Clients) []Action {
+	var actions []Action
+	projectWrites := make(map[string]bool)
+	for _, action := range clients.Project.Actions() {
+		if taken, ok := writeAction(action); ok {
+			actions = append(actions, taken)
+			projectWrites[taken.Verb+"/"+taken.Name] = true
+		}
+	}
+	for _, recorded := range [][]clienttesting.Action{
+		clients.Kube.Actions(),
+		clients.User.Actions(),
+		clients.Quota.Actions(),
+		clients.Dynamic.Actions(),
+	} {
+		for _, action := range recorded {
+			taken, ok := writeAction(action)
+			if !ok || taken.Resource == "events" {
+				continue
+			}
+			if taken.Resource == "namespaces" && projectWrites[taken.Verb+"/"+taken.Name] {
+				continue
+			}
+			actions = append(actions, taken)
+		}
+	}
+	return actions
+}
+
+// Returns the action of a write request
+func writeAction(action clienttesting.Action) (Action, bool) {
+	taken := Action{
+		Verb:      action.GetVerb(),
+		Resource:  action.GetResource().Resource,
+		Namespace: action.GetNamespace(),
+	}
+	switch action := action.(type) {
+	case clienttesting.CreateAction:
+		if obj, ok := action.GetObject().(metav1.Object); ok {
+			taken.Name = obj.GetName()
+		}
+	case clienttesting.UpdateAction:
+		if obj, ok := action.GetObject().(metav1.Object); ok {
+			taken.Name = obj.GetName()
+		}
+	case clienttesting.PatchAction:
+		taken.Name = action.GetName()
+		// server-side apply creates or updates the object alike
+		if action.GetPatchType() == types.ApplyPatchType {
+			taken.Verb = "apply"
+		}
+	case clienttesting.DeleteAction:
+		taken.Name = action.GetName()
+	default:
+		return Action{}, false
+	}
+	return taken, true
+}
+
+// Returns whether an asserted action matches any of the actions
+func matchesAny(asserted []Action, action Action) bool {
+	for _, a := range asserted {
+		if a.matches(action) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package policytest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fixture replacing bob with carol in the target group, extended by each test case
+const baseFixture = `name: replacing a member
+before: [alice, bob]
+after: [alice, carol]
+objects:
+- apiVersion: project.openshift.io/v1
+  kind: Project
+  metadata:
+    name: bob
+    labels:
+      rosa-namespace-provisioner/owner: bob
+      app.kubernetes.io/managed-by: rosa-namespace-provisioner
+`
+
+func TestRun(t *testing.T) {
+	t.Setenv("TARGET_GROUP_NAME", "test-group")
+
+	tests := []struct {
+		name         string
+		fixture      string
+		passed       bool
+		configErrors bool
+		missing      []Action
+		rejected     []Action
+	}{
+		{
+			name: "assertions hold",
+			fixture: `expect:
+- {verb: create, resource: projects, name: carol}
+- {verb: apply, resource: rolebindings, namespace: carol, name: carol-edit}
+- {verb: delete, resource: projects, name: bob}
+reject:
+- {verb: delete, resource: projects, name: alice}
+`,
+			passed: true,
+		},
+		{
+			name: "expected action missing",
+			fixture: `expect:
+- {verb: create, resource: resourcequotas, namespace: carol}
+`,
+			missing: []Action{{Verb: "create", Resource: "resourcequotas", Namespace: "carol"}},
+		},
+		{
+			name: "rejected action taken",
+			fixture: `reject:
+- {verb: delete, resource: projects}
+`,
+			rejected: []Action{{Verb: "delete", Resource: "projects", Name: "bob"}},
+		},
+		{
+			name: "env overrides the configuration",
+			fixture: `env:
+  RESOURCE_QUOTA_ENABLED: "true"
+  RESOURCE_QUOTA_HARD: requests.cpu=4
+expect:
+- {verb: apply, resource: resourcequotas, namespace: carol, name: compute-resources}
+`,
+			passed: true,
+		},
+		{
+			name: "invalid configuration",
+			fixture: `env:
+  EXISTING_PROJECT_POLICY: adopt
+`,
+			configErrors: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "fixture.yaml"), []byte(baseFixture+tt.fixture), 0o644); err != nil {
+				t.Fatalf("Failed to write fixture: %v", err)
+			}
+			fixtures, err := LoadFixtures(dir)
+			if err != nil {
+				t.Fatalf("Expected fixtures to be loaded, but got error: %v", err)
+			}
+			if len(fixtures) != 1 {
+				t.Fatalf("Expected 1 fixture, but got %d", len(fixtures))
+			}
+
+			result, err := Run(fixtures[0])
+			if err != nil {
+				t.Fatalf("Expected fixture to run, but got error: %v", err)
+			}
+			if result.Passed() != tt.passed {
+				t.Errorf("Expected fixture to pass: %v, but got result %+v", tt.passed, result)
+			}
+			if (len(result.ConfigErrors) > 0) != tt.configErrors {
+				t.Errorf("Expected configuration errors: %v, but got %v", tt.configErrors, result.ConfigErrors)
+			}
+			if !reflect.DeepEqual(result.Missing, tt.missing) || !reflect.DeepEqual(result.Rejected, tt.rejected) {
+				t.Errorf("Expected missing %v and rejected %v, but got %v and %v", tt.missing, tt.rejected, result.Missing, result.Rejected)
+			}
+		})
+	}
+
+	if value := os.Getenv("RESOURCE_QUOTA_ENABLED"); value != "" {
+		t.Errorf("Expected the environment to be restored, but got RESOURCE_QUOTA_ENABLED=%s", value)
+	}
+}
@@ -0,0 +1,283 @@
+// Package tracing records spans of reconciles and exports them to an OpenTelemetry collector with the
+// JSON encoding of OTLP over HTTP
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// name of the instrumentation scope of the exported spans
+const scopeName = "github.com/redhat-ai-dev/rosa-namespace-provisioner"
+
+// number of finished spans kept for export before new ones are dropped
+const maxPendingSpans = 4096
+
+// how often finished spans are exported
+const exportInterval = 5 * time.Second
+
+// OTLP status codes of a span
+const (
+	statusCodeOK    = 1
+	statusCodeError = 2
+)
+
+// Attribute is a key and string value describing a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// String returns an attribute with the given key and value
+func String(key string, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts spans and periodically exports the finished ones to an OTLP endpoint. A nil Tracer
+// records nothing, so callers don't need to check whether tracing is enabled.
+type Tracer struct {
+	endpoint string
+	service  string
+	client   *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	dropped int
+}
+
+// NewTracer creates a new Tracer exporting spans of the named service to the OTLP traces endpoint,
+// e.g. http://otel-collector:4318/v1/traces
+func NewTracer(endpoint string, service string) *Tracer {
+	return &Tracer{
+		endpoint: endpoint,
+		service:  service,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Span is a timed operation within a trace
+type Span struct {
+	tracer   *Tracer
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	attrs    []Attribute
+	start    time.Time
+	end      time.Time
+	err      error
+	once     sync.Once
+}
+
+// spanKey is the context key of the current span
+type spanKey struct{}
+
+// Start starts a span as a child of the span of the context, if any, and returns a context carrying
+// the new span
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	span := &Span{
+		tracer: t,
+		name:   name,
+		attrs:  attrs,
+		start:  time.Now(),
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	} else {
+		_, _ = rand.Read(span.traceID[:])
+	}
+	_, _ = rand.Read(span.spanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// End ends the span, marking it failed with the error if any, and queues it for export. Ending a span
+// again has no effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.end = time.Now()
+		s.err = err
+		s.tracer.queue(s)
+	})
+}
+
+// Queues a finished span for the next export
+func (t *Tracer) queue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.pending) >= maxPendingSpans {
+		t.dropped++
+		return
+	}
+	t.pending = append(t.pending, span)
+}
+
+// Run exports the finished spans every few seconds until the context is cancelled, exporting the
+// pending ones before returning
+func (t *Tracer) Run(ctx context.Context) {
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			t.flush(flushCtx)
+			cancel()
+			return
+		case <-ticker.C:
+			t.flush(ctx)
+		}
+	}
+}
+
+// Exports the pending spans, dropping them when the endpoint fails so a collector outage doesn't
+// grow the memory of the controller
+func (t *Tracer) flush(ctx context.Context) {
+	t.mu.Lock()
+	spans, dropped := t.pending, t.dropped
+	t.pending, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		klog.Warningf("Dropped %d spans exceeding the %d pending spans", dropped, maxPendingSpans)
+	}
+	if len(spans) == 0 {
+		return
+	}
+	if err := t.export(ctx, spans); err != nil {
+		klog.Errorf("Error exporting %d spans to %s: %v", len(spans), t.endpoint, err)
+	}
+}
+
+// OTLP JSON encoding of the exported spans, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope      `json:"scope"`
+		Spans []spanJSON `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	spanJSON struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// Returns the attributes in their OTLP encoding
+func keyValues(attrs []Attribute) []keyValue {
+	var values []keyValue
+	for _, attr := range attrs {
+		values = append(values, keyValue{Key: attr.Key, Value: anyValue{StringValue: attr.Value}})
+	}
+	return values
+}
+
+// Returns the OTLP export request of the spans
+func (t *Tracer) exportRequest(spans []*Span) exportRequest {
+	encoded := make([]spanJSON, 0, len(spans))
+	for _, span := range spans {
+		s := spanJSON{
+			TraceID: hex.EncodeToString(span.traceID[:]),
+			SpanID:  hex.EncodeToString(span.spanID[:]),
+			Name:    span.name,
+			// internal spans, the API calls they wrap aren't traced
+			Kind:              1,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Attributes:        keyValues(span.attrs),
+			Status:            status{Code: statusCodeOK},
+		}
+		if span.parentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		if span.err != nil {
+			s.Status = status{Code: statusCodeError, Message: span.err.Error()}
+		}
+		encoded = append(encoded, s)
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource:   resource{Attributes: keyValues([]Attribute{String("service.name", t.service)})},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: scopeName}, Spans: encoded}},
+	}}}
+}
+
+// Posts the spans to the OTLP endpoint
+func (t *Tracer) export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(t.exportRequest(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
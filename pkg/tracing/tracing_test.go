@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	var received exportRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON export request, but got %s", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("Failed to decode export request: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(server.URL, "rosa-namespace-provisioner")
+	ctx, parent := tracer.Start(context.Background(), "provision", String("user", "alice"))
+	_, child := tracer.Start(ctx, "provision/project")
+	child.End(errors.New("forbidden"))
+	child.End(nil)
+	parent.End(nil)
+	tracer.flush(context.Background())
+
+	if len(received.ResourceSpans) != 1 || len(received.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Expected the spans of a single resource and scope, but got %+v", received)
+	}
+	if service := received.ResourceSpans[0].Resource.Attributes; len(service) != 1 || service[0].Value.StringValue != "rosa-namespace-provisioner" {
+		t.Errorf("Expected the service name as resource attribute, but got %+v", service)
+	}
+	spans := received.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, each exported once, but got %+v", spans)
+	}
+	exportedChild, exportedParent := spans[0], spans[1]
+	if exportedChild.TraceID != exportedParent.TraceID || exportedChild.ParentSpanID != exportedParent.SpanID {
+		t.Errorf("Expected the step to be a child of the provisioning, but got %+v and %+v", exportedChild, exportedParent)
+	}
+	if exportedParent.ParentSpanID != "" || len(exportedParent.TraceID) != 32 || len(exportedParent.SpanID) != 16 {
+		t.Errorf("Expected a root span with hex IDs, but got %+v", exportedParent)
+	}
+	if exportedChild.Status.Code != statusCodeError || exportedChild.Status.Message != "forbidden" {
+		t.Errorf("Expected the failed step to have an error status, but got %+v", exportedChild.Status)
+	}
+	if exportedParent.Status.Code != statusCodeOK || exportedParent.Attributes[0].Key != "user" {
+		t.Errorf("Expected the provisioning to succeed for alice, but got %+v", exportedParent)
+	}
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	ctx, span := tracer.Start(context.Background(), "provision")
+	if ctx == nil || span != nil {
+		t.Errorf("Expected a nil tracer to start no span")
+	}
+	span.SetAttributes(String("user", "alice"))
+	span.End(nil)
+}
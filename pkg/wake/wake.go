@@ -0,0 +1,155 @@
+// Package wake serves the webhook waking up a controller scaled to zero after idling
+package wake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1client "k8s.io/client-go/kubernetes/typed/apps/v1"
+	"k8s.io/klog/v2"
+)
+
+// Status reports whether the controller was asked to wake up recently, in the shape read by the
+// KEDA metrics-api scaler
+type Status struct {
+	// Pending is 1 while a wake-up requested within the window may still be in progress, otherwise 0
+	Pending int `json:"pending"`
+	// LastWake is when the controller was last asked to wake up
+	LastWake *time.Time `json:"lastWake,omitempty"`
+}
+
+// Server wakes up the controller on request, scaling its Deployment up when one is configured and
+// reporting the request to KEDA otherwise
+type Server struct {
+	server     *http.Server
+	client     appsv1client.DeploymentsGetter
+	namespace  string
+	deployment string
+	window     time.Duration
+
+	mu       sync.Mutex
+	lastWake time.Time
+}
+
+// NewServer creates a wake Server listening on the given address. With a client, a wake-up scales the
+// named Deployment to one replica if it has none; either way the request is reported as pending for
+// the window.
+func NewServer(addr string, client appsv1client.DeploymentsGetter, namespace string, deployment string, window time.Duration) *Server {
+	s := &Server{
+		client:     client,
+		namespace:  namespace,
+		deployment: deployment,
+		window:     window,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /wake", s.handleWake)
+	mux.HandleFunc("GET /wake", s.handleStatus)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Run serves the wake webhook until the context is cancelled
+func (s *Server) Run(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			klog.Errorf("Error shutting down wake server: %v", err)
+		}
+	}()
+
+	klog.Infof("Serving wake webhook on %s", s.server.Addr)
+	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Records the wake-up and scales the controller up when it is scaled to zero
+func (s *Server) handleWake(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.lastWake = time.Now()
+	s.mu.Unlock()
+
+	if s.client != nil {
+		if err := ScaleUp(r.Context(), s.client, s.namespace, s.deployment); err != nil {
+			klog.Errorf("Error waking up Deployment %s/%s: %v", s.namespace, s.deployment, err)
+			http.Error(w, "failed to wake up the controller", http.StatusInternalServerError)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Returns whether a wake-up was requested within the window as JSON
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	lastWake := s.lastWake
+	s.mu.Unlock()
+
+	status := Status{}
+	if !lastWake.IsZero() {
+		status.LastWake = &lastWake
+		if time.Since(lastWake) < s.window {
+			status.Pending = 1
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		klog.Errorf("Error encoding wake status: %v", err)
+	}
+}
+
+// Reports the wake server as live
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = fmt.Fprintln(w, "ok")
+}
+
+// ScaleUp scales the Deployment to one replica if it has none, leaving it alone otherwise
+func ScaleUp(ctx context.Context, client appsv1client.DeploymentsGetter, namespace string, name string) error {
+	scale, err := client.Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if scale.Spec.Replicas > 0 {
+		return nil
+	}
+	scale.Spec.Replicas = 1
+	if _, err := client.Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("Scaled Deployment %s/%s up to wake up the controller", namespace, name)
+	return nil
+}
+
+// ScaleDown scales the Deployment to zero replicas
+func ScaleDown(ctx context.Context, client appsv1client.DeploymentsGetter, namespace string, name string) error {
+	scale, err := client.Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if scale.Spec.Replicas == 0 {
+		return nil
+	}
+	scale.Spec.Replicas = 0
+	if _, err := client.Deployments(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	klog.Infof("Scaled Deployment %s/%s down to zero", namespace, name)
+	return nil
+}
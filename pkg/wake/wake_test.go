@@ -0,0 +1,113 @@
+package wake
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// Returns a fake clientset whose Deployment scale subresource tracks the given replicas
+func newScaleClient(replicas *int32) *fake.Clientset {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "controller", Namespace: "provisioner"},
+	})
+	scale := func() *autoscalingv1.Scale {
+		return &autoscalingv1.Scale{
+			ObjectMeta: metav1.ObjectMeta{Name: "controller", Namespace: "provisioner"},
+			Spec:       autoscalingv1.ScaleSpec{Replicas: *replicas},
+		}
+	}
+	client.PrependReactor("get", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		return true, scale(), nil
+	})
+	client.PrependReactor("update", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		*replicas = action.(clienttesting.UpdateAction).GetObject().(*autoscalingv1.Scale).Spec.Replicas
+		return true, scale(), nil
+	})
+	return client
+}
+
+func TestServer_handleWake(t *testing.T) {
+	replicas := int32(0)
+	server := NewServer("", newScaleClient(&replicas).AppsV1(), "provisioner", "controller", time.Minute)
+	httpServer := httptest.NewServer(server.server.Handler)
+	defer httpServer.Close()
+
+	status := func() Status {
+		resp, err := http.Get(httpServer.URL + "/wake")
+		if err != nil {
+			t.Fatalf("Failed to get wake status: %v", err)
+		}
+		defer resp.Body.Close()
+		var status Status
+		if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode wake status: %v", err)
+		}
+		return status
+	}
+
+	// Nothing is pending before a wake-up
+	if got := status(); got.Pending != 0 || got.LastWake != nil {
+		t.Errorf("Expected no pending wake-up, but got %+v", got)
+	}
+
+	// A wake-up scales the controller up and is reported as pending
+	resp, err := http.Post(httpServer.URL+"/wake", "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to wake up: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected status %d, but got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	if replicas != 1 {
+		t.Errorf("Expected the controller to be scaled to 1 replica, but got %d", replicas)
+	}
+	if got := status(); got.Pending != 1 || got.LastWake == nil {
+		t.Errorf("Expected a pending wake-up, but got %+v", got)
+	}
+}
+
+func TestScaleUp(t *testing.T) {
+	ctx := context.Background()
+
+	// A running controller keeps its replicas
+	replicas := int32(2)
+	if err := ScaleUp(ctx, newScaleClient(&replicas).AppsV1(), "provisioner", "controller"); err != nil {
+		t.Fatalf("Expected the scale up to succeed, but got error: %v", err)
+	}
+	if replicas != 2 {
+		t.Errorf("Expected the controller to keep 2 replicas, but got %d", replicas)
+	}
+
+	// Scaling down and back up restores one replica
+	client := newScaleClient(&replicas).AppsV1()
+	if err := ScaleDown(ctx, client, "provisioner", "controller"); err != nil {
+		t.Fatalf("Expected the scale down to succeed, but got error: %v", err)
+	}
+	if replicas != 0 {
+		t.Errorf("Expected the controller to be scaled to zero, but got %d", replicas)
+	}
+	if err := ScaleUp(ctx, client, "provisioner", "controller"); err != nil {
+		t.Fatalf("Expected the scale up to succeed, but got error: %v", err)
+	}
+	if replicas != 1 {
+		t.Errorf("Expected the controller to be scaled to 1 replica, but got %d", replicas)
+	}
+}